@@ -5,14 +5,11 @@ import (
 	"flag"
 	"fmt"
 	"os"
-	"os/signal"
-	"syscall"
-	"time"
 
-	"github.com/bingooyong/ops-scaffold-framework/agent/internal/api"
+	"github.com/bingooyong/ops-scaffold-framework/agent/internal/check"
 	"github.com/bingooyong/ops-scaffold-framework/agent/internal/config"
-	"github.com/bingooyong/ops-scaffold-framework/agent/internal/heartbeat"
 	"github.com/bingooyong/ops-scaffold-framework/agent/internal/logger"
+	"github.com/bingooyong/ops-scaffold-framework/agent/pkg/sdk"
 	"go.uber.org/zap"
 )
 
@@ -50,88 +47,65 @@ func main() {
 		zap.String("version", version),
 		zap.Int("pid", os.Getpid()))
 
-	// 创建心跳管理器
-	hbManager := heartbeat.NewManager(
-		cfg.Heartbeat.SocketPath,
-		cfg.Heartbeat.Interval,
-		version,
-		log,
-	)
-
-	// 创建 HTTP API 服务器
-	apiServer := api.NewServer(
-		cfg.HTTP.Host,
-		cfg.HTTP.Port,
-		cfg.AgentID,
-		version,
-		log,
-	)
-
-	// 集成心跳管理器与 API 服务器
-	// 1. 设置资源使用获取器
-	apiServer.SetResourceGetter(hbManager)
-
-	// 2. 设置心跳状态回调
-	hbManager.SetHeartbeatCallbacks(
-		func() { apiServer.UpdateHeartbeatStatus(true) },
-		func() { apiServer.UpdateHeartbeatStatus(false) },
-	)
-
-	// 3. 设置配置重载回调
-	apiServer.SetReloadCallback(func() error {
-		log.Info("reloading configuration...")
-		// TODO: 实现配置重载逻辑
-		// 1. 重新加载配置文件
-		// 2. 验证新配置
-		// 3. 更新心跳间隔等参数
-		log.Warn("config reload not fully implemented yet")
-		return nil
+	// 基于 SDK 创建 Agent（心跳、控制通道、HTTP 接口和优雅退出由 SDK 负责）
+	agent, err := sdk.New(sdk.Options{
+		AgentID:           cfg.AgentID,
+		Version:           version,
+		HeartbeatURL:      cfg.Heartbeat.URL,
+		HeartbeatSocket:   cfg.Heartbeat.SocketPath,
+		HeartbeatInterval: cfg.Heartbeat.Interval,
+		HTTPAddr:          fmt.Sprintf("%s:%d", cfg.HTTP.Host, cfg.HTTP.Port),
+		ControlSocket:     cfg.Control.SocketPath,
+		Logger:            log,
+		LogLevel:          logger.AtomicLevel(),
 	})
-
-	// 启动心跳管理器
-	if err := hbManager.Start(); err != nil {
-		log.Error("failed to start heartbeat manager", zap.Error(err))
+	if err != nil {
+		log.Error("failed to create agent", zap.Error(err))
 		os.Exit(1)
 	}
 
-	// 启动 HTTP API 服务器
-	if err := apiServer.Start(); err != nil {
-		log.Error("failed to start HTTP server", zap.Error(err))
+	// 启动定时检查，结果通过心跳自定义指标上报，并在 GET /checks 中展示
+	checkRunner := check.NewRunner(log)
+	if err := checkRunner.Start(cfg.Checks); err != nil {
+		log.Error("failed to start checks", zap.Error(err))
 		os.Exit(1)
 	}
+	agent.SetMetricsProvider(checkRunner.HeartbeatMetrics)
+	agent.HandleHTTP("/checks", checkRunner)
+	agent.OnShutdown(func(ctx context.Context) error {
+		checkRunner.Stop()
+		return nil
+	})
 
-	log.Info("agent started successfully",
-		zap.String("agent_id", cfg.AgentID),
-		zap.String("http_addr", fmt.Sprintf("%s:%d", cfg.HTTP.Host, cfg.HTTP.Port)),
-		zap.String("socket_path", cfg.Heartbeat.SocketPath))
-
-	// 等待退出信号
-	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
-
-	sig := <-sigCh
-	log.Info("received shutdown signal", zap.String("signal", sig.String()))
-
-	// 优雅退出
-	gracefulShutdown(hbManager, apiServer, log)
-
-	log.Info("agent stopped")
-}
-
-// gracefulShutdown 优雅退出
-func gracefulShutdown(hbManager *heartbeat.Manager, apiServer *api.Server, log *zap.Logger) {
-	log.Info("shutting down gracefully")
-
-	// 停止心跳管理器（会发送最后一次心跳）
-	hbManager.Stop()
-
-	// 停止 HTTP 服务器（设置超时）
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	// 配置重载：重新读取配置文件，应用日志级别和检查配置，心跳和 HTTP 配置需重启生效
+	agent.OnReload(func(ctx context.Context) error {
+		newCfg, err := config.LoadConfig(*configPath)
+		if err != nil {
+			return err
+		}
+		if err := newCfg.Validate(); err != nil {
+			return fmt.Errorf("invalid config: %w", err)
+		}
+		if err := logger.SetLevel(newCfg.Log.Level); err != nil {
+			return err
+		}
+		if err := checkRunner.Start(newCfg.Checks); err != nil {
+			return err
+		}
+		if newCfg.Heartbeat != cfg.Heartbeat || newCfg.HTTP != cfg.HTTP {
+			log.Warn("heartbeat and http changes take effect after restart")
+		}
+		log.Info("config applied",
+			zap.String("log_level", logger.GetLevel()),
+			zap.Int("checks", len(newCfg.Checks)))
+		return nil
+	})
 
-	if err := apiServer.Stop(ctx); err != nil {
-		log.Error("failed to stop HTTP server gracefully", zap.Error(err))
+	// 运行直到收到停止信号（SIGHUP 触发配置重载，用于不支持控制通道时的回退）
+	if err := agent.Run(context.Background()); err != nil {
+		log.Error("agent exited with error", zap.Error(err))
+		os.Exit(1)
 	}
 
-	log.Info("graceful shutdown completed")
+	log.Info("agent stopped")
 }
@@ -0,0 +1,152 @@
+package check
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bingooyong/ops-scaffold-framework/agent/internal/config"
+)
+
+const (
+	// defaultInterval 默认检查间隔
+	defaultInterval = 60 * time.Second
+
+	// defaultTimeout 默认单次检查超时
+	defaultTimeout = 10 * time.Second
+
+	// maxOutputLength 检查输出保留的最大字节数
+	maxOutputLength = 1024
+)
+
+// Status 检查状态（Nagios 约定）
+type Status string
+
+const (
+	StatusOK       Status = "OK"
+	StatusWarning  Status = "WARNING"
+	StatusCritical Status = "CRITICAL"
+	StatusUnknown  Status = "UNKNOWN"
+)
+
+// Code 返回状态对应的 Nagios 退出码: OK=0 WARNING=1 CRITICAL=2 UNKNOWN=3
+func (s Status) Code() int {
+	switch s {
+	case StatusOK:
+		return 0
+	case StatusWarning:
+		return 1
+	case StatusCritical:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// statusFromCode 根据 Nagios 退出码返回状态
+func statusFromCode(code int) Status {
+	switch code {
+	case 0:
+		return StatusOK
+	case 1:
+		return StatusWarning
+	case 2:
+		return StatusCritical
+	default:
+		return StatusUnknown
+	}
+}
+
+// Outcome 单次检查的结果
+type Outcome struct {
+	Status   Status
+	Output   string
+	Perfdata map[string]float64
+}
+
+// Result 检查结果（包含检查元信息）
+type Result struct {
+	Name       string             `json:"name"`
+	Type       string             `json:"type"`
+	Status     Status             `json:"status"`
+	Output     string             `json:"output"`
+	Perfdata   map[string]float64 `json:"perfdata,omitempty"`
+	DurationMs float64            `json:"duration_ms"`
+	CheckedAt  time.Time          `json:"checked_at"`
+}
+
+// Checker 检查器
+type Checker interface {
+	// Check 执行一次检查，ctx 在超时后取消
+	Check(ctx context.Context) Outcome
+}
+
+// Factory 根据配置创建检查器，配置无效时返回错误
+type Factory func(cfg *config.CheckConfig) (Checker, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = make(map[string]Factory)
+)
+
+// RegisterType 注册检查类型，同名类型会被覆盖
+func RegisterType(typ string, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	factories[typ] = factory
+}
+
+// Types 返回已注册的检查类型（按名称排序）
+func Types() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+	types := make([]string, 0, len(factories))
+	for typ := range factories {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// NewChecker 根据配置创建检查器
+func NewChecker(cfg *config.CheckConfig) (Checker, error) {
+	factoriesMu.RLock()
+	factory, ok := factories[cfg.Type]
+	factoriesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("check %s: unknown type %q, must be one of: %v", cfg.Name, cfg.Type, Types())
+	}
+	checker, err := factory(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("check %s: %w", cfg.Name, err)
+	}
+	return checker, nil
+}
+
+func init() {
+	RegisterType("exec", newExecChecker)
+	RegisterType("http", newHTTPChecker)
+	RegisterType("tcp", newTCPChecker)
+	RegisterType("file", newFileChecker)
+}
+
+// truncate 截断字符串到 max 字节（不截断 UTF-8 字符）
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	for max > 0 && s[max]&0xC0 == 0x80 {
+		max--
+	}
+	return s[:max]
+}
+
+// latencyStatus 根据耗时和 WARNING 阈值返回状态
+func latencyStatus(elapsed, warning time.Duration) Status {
+	if warning > 0 && elapsed > warning {
+		return StatusWarning
+	}
+	return StatusOK
+}
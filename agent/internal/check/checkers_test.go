@@ -0,0 +1,180 @@
+package check
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bingooyong/ops-scaffold-framework/agent/internal/config"
+)
+
+func TestNewCheckerValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.CheckConfig
+	}{
+		{"unknown type", config.CheckConfig{Name: "c", Type: "ping"}},
+		{"exec without command", config.CheckConfig{Name: "c", Type: "exec"}},
+		{"http without url", config.CheckConfig{Name: "c", Type: "http"}},
+		{"http invalid scheme", config.CheckConfig{Name: "c", Type: "http", URL: "ftp://example.com"}},
+		{"http invalid status", config.CheckConfig{Name: "c", Type: "http", URL: "http://example.com", ExpectStatus: 42}},
+		{"tcp without address", config.CheckConfig{Name: "c", Type: "tcp"}},
+		{"tcp without port", config.CheckConfig{Name: "c", Type: "tcp", Address: "localhost"}},
+		{"file without path", config.CheckConfig{Name: "c", Type: "file"}},
+		{"file negative size", config.CheckConfig{Name: "c", Type: "file", Path: "/tmp/x", MinSize: -1}},
+		{"file min above max", config.CheckConfig{Name: "c", Type: "file", Path: "/tmp/x", MinSize: 10, MaxSize: 5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewChecker(&tt.cfg); err == nil {
+				t.Error("expected validation error")
+			}
+		})
+	}
+}
+
+func TestHTTPChecker(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.Write([]byte("status: ready"))
+		case "/slow":
+			time.Sleep(50 * time.Millisecond)
+			w.Write([]byte("ok"))
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		cfg    config.CheckConfig
+		status Status
+	}{
+		{"2xx", config.CheckConfig{URL: server.URL + "/ok"}, StatusOK},
+		{"expected body", config.CheckConfig{URL: server.URL + "/ok", ExpectBody: "ready"}, StatusOK},
+		{"missing body", config.CheckConfig{URL: server.URL + "/ok", ExpectBody: "healthy"}, StatusCritical},
+		{"non-2xx", config.CheckConfig{URL: server.URL + "/down"}, StatusCritical},
+		{"expected status", config.CheckConfig{URL: server.URL + "/down", ExpectStatus: http.StatusServiceUnavailable}, StatusOK},
+		{"unexpected status", config.CheckConfig{URL: server.URL + "/ok", ExpectStatus: http.StatusNoContent}, StatusCritical},
+		{"slow", config.CheckConfig{URL: server.URL + "/slow", Warning: time.Millisecond}, StatusWarning},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.Name, tt.cfg.Type = "web", "http"
+			checker, err := NewChecker(&tt.cfg)
+			if err != nil {
+				t.Fatalf("failed to create checker: %v", err)
+			}
+			outcome := checker.Check(context.Background())
+			if outcome.Status != tt.status {
+				t.Errorf("got %s (%s), want %s", outcome.Status, outcome.Output, tt.status)
+			}
+			if _, ok := outcome.Perfdata["time"]; !ok {
+				t.Errorf("missing time perfdata: %v", outcome.Perfdata)
+			}
+		})
+	}
+}
+
+func TestHTTPCheckerTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	checker, err := NewChecker(&config.CheckConfig{Name: "web", Type: "http", URL: server.URL})
+	if err != nil {
+		t.Fatalf("failed to create checker: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if outcome := checker.Check(ctx); outcome.Status != StatusCritical {
+		t.Errorf("expected CRITICAL on timeout, got %s", outcome.Status)
+	}
+}
+
+func TestTCPChecker(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	address := listener.Addr().String()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	checker, err := NewChecker(&config.CheckConfig{Name: "port", Type: "tcp", Address: address})
+	if err != nil {
+		t.Fatalf("failed to create checker: %v", err)
+	}
+	if outcome := checker.Check(context.Background()); outcome.Status != StatusOK {
+		t.Fatalf("expected OK, got %s: %s", outcome.Status, outcome.Output)
+	}
+
+	listener.Close()
+	if outcome := checker.Check(context.Background()); outcome.Status != StatusCritical {
+		t.Errorf("expected CRITICAL after listener closed, got %s", outcome.Status)
+	}
+}
+
+func TestFileChecker(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data")
+	if err := os.WriteFile(path, []byte("0123456789"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	old := filepath.Join(dir, "old")
+	if err := os.WriteFile(old, nil, 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	past := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(old, past, past); err != nil {
+		t.Fatalf("failed to set mtime: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		cfg    config.CheckConfig
+		status Status
+		output string
+	}{
+		{"exists", config.CheckConfig{Path: path}, StatusOK, "size 10"},
+		{"missing", config.CheckConfig{Path: filepath.Join(dir, "missing")}, StatusCritical, "does not exist"},
+		{"too old", config.CheckConfig{Path: old, MaxAge: time.Minute}, StatusCritical, "exceeds 1m0s"},
+		{"too small", config.CheckConfig{Path: path, MinSize: 20}, StatusCritical, "below 20"},
+		{"too large", config.CheckConfig{Path: path, MaxSize: 5}, StatusCritical, "exceeds 5"},
+		{"within limits", config.CheckConfig{Path: path, MaxAge: time.Minute, MinSize: 1, MaxSize: 100}, StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.Name, tt.cfg.Type = "file", "file"
+			checker, err := NewChecker(&tt.cfg)
+			if err != nil {
+				t.Fatalf("failed to create checker: %v", err)
+			}
+			outcome := checker.Check(context.Background())
+			if outcome.Status != tt.status || !strings.Contains(outcome.Output, tt.output) {
+				t.Errorf("got %s %q, want %s containing %q", outcome.Status, outcome.Output, tt.status, tt.output)
+			}
+		})
+	}
+}
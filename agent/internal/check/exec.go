@@ -0,0 +1,196 @@
+package check
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/bingooyong/ops-scaffold-framework/agent/internal/config"
+)
+
+// maxExecOutput exec 检查保留的 stdout/stderr 最大字节数，超出部分被丢弃
+const maxExecOutput = 64 * 1024
+
+// execChecker 执行脚本/Nagios 插件
+// 退出码 0/1/2/3 对应 OK/WARNING/CRITICAL/UNKNOWN，输出第一行 "|" 之后为 perfdata
+type execChecker struct {
+	command []string
+}
+
+// newExecChecker 创建 exec 检查器
+func newExecChecker(cfg *config.CheckConfig) (Checker, error) {
+	if len(cfg.Command) == 0 || cfg.Command[0] == "" {
+		return nil, errors.New("command is required for exec check")
+	}
+	return &execChecker{command: cfg.Command}, nil
+}
+
+// Check 执行命令，超时后杀死整个进程组并返回 CRITICAL
+func (c *execChecker) Check(ctx context.Context) Outcome {
+	stdout := &limitedBuffer{limit: maxExecOutput}
+	stderr := &limitedBuffer{limit: maxExecOutput}
+	cmd := exec.Command(c.command[0], c.command[1:]...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	if err := cmd.Start(); err != nil {
+		return Outcome{Status: StatusUnknown, Output: fmt.Sprintf("failed to execute %s: %v", c.command[0], err)}
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		<-done
+		return Outcome{Status: StatusCritical, Output: fmt.Sprintf("check timed out: %v", ctx.Err())}
+	}
+
+	code := 0
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return Outcome{Status: StatusUnknown, Output: fmt.Sprintf("failed to wait for %s: %v", c.command[0], err)}
+		}
+		code = exitErr.ExitCode()
+	}
+
+	output := stdout.String()
+	if strings.TrimSpace(output) == "" {
+		output = stderr.String()
+	}
+	text, perfdata := parsePluginOutput(output)
+	if text == "" {
+		text = fmt.Sprintf("exit code %d", code)
+	}
+	return Outcome{Status: statusFromCode(code), Output: text, Perfdata: perfdata}
+}
+
+// limitedBuffer 只保留前 limit 字节的 Writer
+// 超出部分被丢弃但仍返回写入成功，避免插件因管道写失败提前退出
+type limitedBuffer struct {
+	buf   bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remaining := b.limit - b.buf.Len(); remaining > 0 {
+		if len(p) > remaining {
+			b.buf.Write(p[:remaining])
+		} else {
+			b.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
+
+// parsePluginOutput 解析 Nagios 插件输出
+// 格式: "TEXT | perfdata\nLONG TEXT | perfdata"，返回第一行文本和所有 perfdata
+func parsePluginOutput(output string) (string, map[string]float64) {
+	output = strings.TrimSpace(output)
+	first, rest := output, ""
+	if i := strings.IndexByte(output, '\n'); i >= 0 {
+		first, rest = output[:i], output[i+1:]
+	}
+
+	var perf []string
+	text := first
+	if i := strings.IndexByte(first, '|'); i >= 0 {
+		text = first[:i]
+		perf = append(perf, first[i+1:])
+	}
+	if i := strings.IndexByte(rest, '|'); i >= 0 {
+		perf = append(perf, strings.ReplaceAll(rest[i+1:], "\n", " "))
+	}
+
+	var perfdata map[string]float64
+	for _, p := range perf {
+		for label, value := range parsePerfdata(p) {
+			if perfdata == nil {
+				perfdata = make(map[string]float64)
+			}
+			perfdata[label] = value
+		}
+	}
+	return strings.TrimSpace(text), perfdata
+}
+
+// parsePerfdata 解析 perfdata: 'label'=value[UOM];[warn];[crit];[min];[max] ...
+// 只保留数值，无法解析的项被忽略
+func parsePerfdata(s string) map[string]float64 {
+	perfdata := make(map[string]float64)
+	for len(s) > 0 {
+		s = strings.TrimLeft(s, " \t")
+		if s == "" {
+			break
+		}
+
+		// 解析标签（单引号中可包含空格和等号，标签中的单引号写作两个单引号）
+		var label string
+		if s[0] == '\'' {
+			var ok bool
+			label, s, ok = parseQuotedLabel(s[1:])
+			if !ok {
+				break
+			}
+		} else {
+			eq := strings.IndexByte(s, '=')
+			if eq < 0 {
+				break
+			}
+			label, s = s[:eq], s[eq+1:]
+		}
+
+		// 解析值（到下一个空白为止）
+		token := s
+		if end := strings.IndexAny(s, " \t"); end >= 0 {
+			token, s = s[:end], s[end:]
+		} else {
+			s = ""
+		}
+		if i := strings.IndexByte(token, ';'); i >= 0 {
+			token = token[:i]
+		}
+		token = strings.TrimRight(token, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ%")
+		value, err := strconv.ParseFloat(token, 64)
+		if err != nil || label == "" {
+			continue
+		}
+		perfdata[label] = value
+	}
+	return perfdata
+}
+
+// parseQuotedLabel 解析单引号标签（不含起始引号），返回标签和 "=" 之后的剩余内容
+func parseQuotedLabel(s string) (string, string, bool) {
+	var label strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\'' {
+			label.WriteByte(s[i])
+			continue
+		}
+		if i+1 < len(s) && s[i+1] == '\'' {
+			label.WriteByte('\'')
+			i++
+			continue
+		}
+		if i+1 < len(s) && s[i+1] == '=' {
+			return label.String(), s[i+2:], true
+		}
+		return "", "", false
+	}
+	return "", "", false
+}
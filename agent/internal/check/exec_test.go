@@ -0,0 +1,196 @@
+package check
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/bingooyong/ops-scaffold-framework/agent/internal/config"
+)
+
+func TestParsePerfdata(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected map[string]float64
+	}{
+		{"plain", "time=0.5s size=1024B", map[string]float64{"time": 0.5, "size": 1024}},
+		{"thresholds", "load1=1.5;4;8;0; load5=0.75;;;0;", map[string]float64{"load1": 1.5, "load5": 0.75}},
+		{"uom suffixes", "used=75% rx=10KB tx=1.5MB count=42c up=3600s", map[string]float64{"used": 75, "rx": 10, "tx": 1.5, "count": 42, "up": 3600}},
+		{"negative", "offset=-0.25s", map[string]float64{"offset": -0.25}},
+		{"quoted label with space", "'disk used'=80%;90;95", map[string]float64{"disk used": 80}},
+		{"quoted label with equals", "'a=b'=1 c=2", map[string]float64{"a=b": 1, "c": 2}},
+		{"quoted label with escaped quote", "'it''s'=3", map[string]float64{"it's": 3}},
+		{"quoted label containing quote-equals", "'x''=y'=4 z=5", map[string]float64{"x'=y": 4, "z": 5}},
+		{"unknown value", "a=U b=U;1;2 c=7", map[string]float64{"c": 7}},
+		{"invalid tokens skipped", "a= =5 b=abc d=9", map[string]float64{"d": 9}},
+		{"unterminated quote", "'broken=1", map[string]float64{}},
+		{"extra whitespace", "  a=1\t b=2  ", map[string]float64{"a": 1, "b": 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parsePerfdata(tt.input)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("parsePerfdata(%q) = %v, want %v", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParsePluginOutput(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		text     string
+		perfdata map[string]float64
+	}{
+		{"text only", "OK - all good\n", "OK - all good", nil},
+		{"single line perfdata", "OK - load 0.5 | load1=0.5;4;8", "OK - load 0.5", map[string]float64{"load1": 0.5}},
+		{
+			"long output",
+			"DISK OK - free space\n/ 80% used\n/data 40% used",
+			"DISK OK - free space",
+			nil,
+		},
+		{
+			"long output with perfdata",
+			"DISK OK | '/'=80%;90;95\n/ 80% used\n/data 40% used | '/data'=40%;90;95\n'/var'=10%",
+			"DISK OK",
+			map[string]float64{"/": 80, "/data": 40, "/var": 10},
+		},
+		{"empty", "  \n", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, perfdata := parsePluginOutput(tt.input)
+			if text != tt.text {
+				t.Errorf("text = %q, want %q", text, tt.text)
+			}
+			if !reflect.DeepEqual(perfdata, tt.perfdata) {
+				t.Errorf("perfdata = %v, want %v", perfdata, tt.perfdata)
+			}
+		})
+	}
+}
+
+func newTestExecChecker(t *testing.T, script string) Checker {
+	t.Helper()
+	checker, err := NewChecker(&config.CheckConfig{Name: "test", Type: "exec", Command: []string{"/bin/sh", "-c", script}})
+	if err != nil {
+		t.Fatalf("failed to create exec checker: %v", err)
+	}
+	return checker
+}
+
+func TestExecCheckerExitCodes(t *testing.T) {
+	tests := []struct {
+		script string
+		status Status
+		output string
+	}{
+		{"echo 'OK - fine | v=1'", StatusOK, "OK - fine"},
+		{"echo 'WARN - slow'; exit 1", StatusWarning, "WARN - slow"},
+		{"echo 'CRIT - down'; exit 2", StatusCritical, "CRIT - down"},
+		{"exit 3", StatusUnknown, "exit code 3"},
+		{"exit 4", StatusUnknown, "exit code 4"},
+		{"echo 'failed on stderr' >&2; exit 2", StatusCritical, "failed on stderr"},
+		{"kill -9 $$", StatusUnknown, "exit code -1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.script, func(t *testing.T) {
+			outcome := newTestExecChecker(t, tt.script).Check(context.Background())
+			if outcome.Status != tt.status || outcome.Output != tt.output {
+				t.Errorf("got %s %q, want %s %q", outcome.Status, outcome.Output, tt.status, tt.output)
+			}
+		})
+	}
+}
+
+func TestExecCheckerCommandNotFound(t *testing.T) {
+	checker, err := NewChecker(&config.CheckConfig{Name: "test", Type: "exec", Command: []string{"/nonexistent/plugin"}})
+	if err != nil {
+		t.Fatalf("failed to create exec checker: %v", err)
+	}
+	if outcome := checker.Check(context.Background()); outcome.Status != StatusUnknown {
+		t.Errorf("expected UNKNOWN, got %s: %s", outcome.Status, outcome.Output)
+	}
+}
+
+func TestExecCheckerOutputCapped(t *testing.T) {
+	// 输出远超上限时插件仍正常退出，保留的输出不超过上限
+	script := "head -c " + strconv.Itoa(maxExecOutput*4) + " /dev/zero | tr '\\0' 'x'; echo '| v=1'"
+	checker := newTestExecChecker(t, script)
+
+	stdout := &limitedBuffer{limit: maxExecOutput}
+	stdout.Write([]byte(strings.Repeat("x", maxExecOutput+10)))
+	if len(stdout.String()) != maxExecOutput {
+		t.Fatalf("limitedBuffer kept %d bytes", len(stdout.String()))
+	}
+
+	outcome := checker.Check(context.Background())
+	if outcome.Status != StatusOK {
+		t.Fatalf("expected OK, got %s", outcome.Status)
+	}
+	if len(outcome.Output) > maxExecOutput {
+		t.Errorf("output should be capped at %d bytes, got %d", maxExecOutput, len(outcome.Output))
+	}
+	if outcome.Perfdata != nil {
+		t.Errorf("perfdata beyond the cap should be dropped: %v", outcome.Perfdata)
+	}
+}
+
+func TestExecCheckerTimeoutKillsProcessGroup(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "child.pid")
+	// 子进程继承 stdout，未杀死整个进程组时 Wait 会一直等到子进程退出
+	checker := newTestExecChecker(t, "sleep 30 & echo $! > "+pidFile+"; wait")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	outcome := checker.Check(ctx)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("check should return soon after timeout, took %s", elapsed)
+	}
+	if outcome.Status != StatusCritical || !strings.Contains(outcome.Output, "timed out") {
+		t.Fatalf("expected CRITICAL timeout, got %s: %s", outcome.Status, outcome.Output)
+	}
+
+	data, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatalf("failed to read child pid: %v", err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		t.Fatalf("invalid child pid %q: %v", data, err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for processAlive(pid) {
+		if time.Now().After(deadline) {
+			syscall.Kill(pid, syscall.SIGKILL)
+			t.Fatalf("child process %d should have been killed with the process group", pid)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// processAlive 进程是否存在且不是僵尸进程
+func processAlive(pid int) bool {
+	data, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return false
+	}
+	// 格式: pid (comm) state ...
+	stat := string(data)
+	i := strings.LastIndexByte(stat, ')')
+	return i < 0 || i+2 >= len(stat) || stat[i+2] != 'Z'
+}
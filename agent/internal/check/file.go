@@ -0,0 +1,69 @@
+package check
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bingooyong/ops-scaffold-framework/agent/internal/config"
+)
+
+// fileChecker 检查文件是否存在以及最后修改时间和大小
+type fileChecker struct {
+	path    string
+	maxAge  time.Duration
+	minSize int64
+	maxSize int64
+}
+
+// newFileChecker 创建 file 检查器
+func newFileChecker(cfg *config.CheckConfig) (Checker, error) {
+	if cfg.Path == "" {
+		return nil, errors.New("path is required for file check")
+	}
+	if cfg.MaxAge < 0 || cfg.MinSize < 0 || cfg.MaxSize < 0 {
+		return nil, errors.New("max_age, min_size and max_size must not be negative")
+	}
+	if cfg.MaxSize > 0 && cfg.MinSize > cfg.MaxSize {
+		return nil, errors.New("min_size must not exceed max_size")
+	}
+	return &fileChecker{path: cfg.Path, maxAge: cfg.MaxAge, minSize: cfg.MinSize, maxSize: cfg.MaxSize}, nil
+}
+
+// Check 检查文件属性，任一断言不满足时返回 CRITICAL
+func (c *fileChecker) Check(ctx context.Context) Outcome {
+	info, err := os.Stat(c.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Outcome{Status: StatusCritical, Output: fmt.Sprintf("%s does not exist", c.path)}
+		}
+		return Outcome{Status: StatusUnknown, Output: fmt.Sprintf("failed to stat %s: %v", c.path, err)}
+	}
+
+	age := time.Since(info.ModTime())
+	size := info.Size()
+	perfdata := map[string]float64{"age": age.Seconds(), "size": float64(size)}
+
+	var problems []string
+	if c.maxAge > 0 && age > c.maxAge {
+		problems = append(problems, fmt.Sprintf("age %s exceeds %s", age.Truncate(time.Second), c.maxAge))
+	}
+	if c.minSize > 0 && size < c.minSize {
+		problems = append(problems, fmt.Sprintf("size %d is below %d", size, c.minSize))
+	}
+	if c.maxSize > 0 && size > c.maxSize {
+		problems = append(problems, fmt.Sprintf("size %d exceeds %d", size, c.maxSize))
+	}
+	if len(problems) > 0 {
+		return Outcome{Status: StatusCritical, Output: c.path + ": " + strings.Join(problems, ", "), Perfdata: perfdata}
+	}
+
+	return Outcome{
+		Status:   StatusOK,
+		Output:   fmt.Sprintf("%s: age %s, size %d", c.path, age.Truncate(time.Second), size),
+		Perfdata: perfdata,
+	}
+}
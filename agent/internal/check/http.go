@@ -0,0 +1,85 @@
+package check
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bingooyong/ops-scaffold-framework/agent/internal/config"
+)
+
+// maxHTTPBody 读取响应体的最大字节数（用于匹配 expect_body）
+const maxHTTPBody = 64 * 1024
+
+// httpChecker 发送 HTTP GET 请求并检查状态码和响应体
+type httpChecker struct {
+	url          string
+	expectStatus int
+	expectBody   string
+	warning      time.Duration
+	client       *http.Client
+}
+
+// newHTTPChecker 创建 http 检查器
+func newHTTPChecker(cfg *config.CheckConfig) (Checker, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("url is required for http check")
+	}
+	u, err := url.Parse(cfg.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid url %q", cfg.URL)
+	}
+	if cfg.ExpectStatus != 0 && (cfg.ExpectStatus < 100 || cfg.ExpectStatus > 599) {
+		return nil, fmt.Errorf("invalid expect_status %d", cfg.ExpectStatus)
+	}
+	return &httpChecker{
+		url:          cfg.URL,
+		expectStatus: cfg.ExpectStatus,
+		expectBody:   cfg.ExpectBody,
+		warning:      cfg.Warning,
+		// 超时由 Check 的 ctx 控制
+		client: &http.Client{},
+	}, nil
+}
+
+// Check 发送 GET 请求
+func (c *httpChecker) Check(ctx context.Context) Outcome {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return Outcome{Status: StatusUnknown, Output: err.Error()}
+	}
+
+	start := time.Now()
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return Outcome{Status: StatusCritical, Output: fmt.Sprintf("request failed: %v", err)}
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPBody))
+	elapsed := time.Since(start)
+	perfdata := map[string]float64{"time": elapsed.Seconds(), "size": float64(len(body))}
+	if err != nil {
+		return Outcome{Status: StatusCritical, Output: fmt.Sprintf("failed to read response: %v", err), Perfdata: perfdata}
+	}
+
+	if c.expectStatus != 0 && resp.StatusCode != c.expectStatus {
+		return Outcome{Status: StatusCritical, Output: fmt.Sprintf("HTTP %d, expected %d", resp.StatusCode, c.expectStatus), Perfdata: perfdata}
+	}
+	if c.expectStatus == 0 && (resp.StatusCode < 200 || resp.StatusCode > 299) {
+		return Outcome{Status: StatusCritical, Output: fmt.Sprintf("HTTP %d", resp.StatusCode), Perfdata: perfdata}
+	}
+	if c.expectBody != "" && !strings.Contains(string(body), c.expectBody) {
+		return Outcome{Status: StatusCritical, Output: fmt.Sprintf("HTTP %d, response does not contain %q", resp.StatusCode, c.expectBody), Perfdata: perfdata}
+	}
+
+	return Outcome{
+		Status:   latencyStatus(elapsed, c.warning),
+		Output:   fmt.Sprintf("HTTP %d in %.3fs", resp.StatusCode, elapsed.Seconds()),
+		Perfdata: perfdata,
+	}
+}
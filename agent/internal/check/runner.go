@@ -0,0 +1,268 @@
+package check
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bingooyong/ops-scaffold-framework/agent/internal/config"
+	"go.uber.org/zap"
+)
+
+// 心跳自定义指标限制（与 daemon/internal/agent/custom_metrics.go 保持一致）
+const (
+	maxHeartbeatMetrics  = 64
+	maxHeartbeatFields   = 32
+	maxHeartbeatKeyLen   = 64
+	maxHeartbeatFieldLen = 256
+)
+
+// scheduledCheck 已创建检查器的检查
+type scheduledCheck struct {
+	name     string
+	typ      string
+	interval time.Duration
+	timeout  time.Duration
+	checker  Checker
+}
+
+// Runner 检查调度器
+// 按各检查的间隔定时执行，保存最近一次结果，并转换为心跳自定义指标上报给 daemon
+type Runner struct {
+	logger *zap.Logger
+
+	// runMu 串行化 Start/Stop
+	runMu  sync.Mutex
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu      sync.RWMutex
+	results map[string]*Result
+}
+
+// NewRunner 创建检查调度器
+func NewRunner(logger *zap.Logger) *Runner {
+	return &Runner{
+		logger:  logger,
+		results: make(map[string]*Result),
+	}
+}
+
+// Start 按配置启动检查，已在运行时替换为新的检查
+// 任一检查配置无效时返回错误，正在运行的检查保持不变
+func (r *Runner) Start(cfgs []config.CheckConfig) error {
+	checks := make([]*scheduledCheck, 0, len(cfgs))
+	for i := range cfgs {
+		cfg := &cfgs[i]
+		checker, err := NewChecker(cfg)
+		if err != nil {
+			return err
+		}
+		sc := &scheduledCheck{
+			name:     cfg.Name,
+			typ:      cfg.Type,
+			interval: cfg.Interval,
+			timeout:  cfg.Timeout,
+			checker:  checker,
+		}
+		if sc.interval <= 0 {
+			sc.interval = defaultInterval
+		}
+		if sc.timeout <= 0 {
+			sc.timeout = defaultTimeout
+		}
+		if sc.timeout > sc.interval {
+			sc.timeout = sc.interval
+		}
+		checks = append(checks, sc)
+	}
+
+	r.runMu.Lock()
+	defer r.runMu.Unlock()
+	r.stopLocked()
+
+	// 移除已删除检查的结果
+	names := make(map[string]bool, len(checks))
+	for _, sc := range checks {
+		names[sc.name] = true
+	}
+	r.mu.Lock()
+	for name := range r.results {
+		if !names[name] {
+			delete(r.results, name)
+		}
+	}
+	r.mu.Unlock()
+
+	if len(checks) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	for _, sc := range checks {
+		r.wg.Add(1)
+		go r.run(ctx, sc)
+	}
+
+	r.logger.Info("checks started", zap.Int("count", len(checks)))
+	return nil
+}
+
+// Stop 停止所有检查并等待正在执行的检查结束
+func (r *Runner) Stop() {
+	r.runMu.Lock()
+	defer r.runMu.Unlock()
+	r.stopLocked()
+}
+
+// stopLocked 停止所有检查（需持有 runMu）
+func (r *Runner) stopLocked() {
+	if r.cancel == nil {
+		return
+	}
+	r.cancel()
+	r.wg.Wait()
+	r.cancel = nil
+}
+
+// run 立即执行一次检查，之后按间隔定时执行
+func (r *Runner) run(ctx context.Context, sc *scheduledCheck) {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(sc.interval)
+	defer ticker.Stop()
+
+	for {
+		r.execute(ctx, sc)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// execute 执行一次检查并保存结果，调度器停止时丢弃结果
+func (r *Runner) execute(ctx context.Context, sc *scheduledCheck) {
+	checkCtx, cancel := context.WithTimeout(ctx, sc.timeout)
+	defer cancel()
+
+	start := time.Now()
+	outcome := sc.checker.Check(checkCtx)
+	elapsed := time.Since(start)
+	if ctx.Err() != nil {
+		return
+	}
+
+	result := &Result{
+		Name:       sc.name,
+		Type:       sc.typ,
+		Status:     outcome.Status,
+		Output:     truncate(outcome.Output, maxOutputLength),
+		Perfdata:   outcome.Perfdata,
+		DurationMs: float64(elapsed.Microseconds()) / 1000,
+		CheckedAt:  start,
+	}
+
+	r.mu.Lock()
+	previous := r.results[sc.name]
+	r.results[sc.name] = result
+	r.mu.Unlock()
+
+	// 状态变化时记录日志
+	fields := []zap.Field{
+		zap.String("check", sc.name),
+		zap.String("status", string(result.Status)),
+		zap.String("output", result.Output),
+	}
+	switch {
+	case previous != nil && previous.Status == result.Status:
+		r.logger.Debug("check executed", fields...)
+	case result.Status == StatusOK:
+		r.logger.Info("check ok", fields...)
+	default:
+		r.logger.Warn("check not ok", fields...)
+	}
+}
+
+// Results 返回所有检查最近一次的结果（按名称排序）
+func (r *Runner) Results() []Result {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	results := make([]Result, 0, len(r.results))
+	for _, result := range r.results {
+		results = append(results, *result)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+	return results
+}
+
+// HeartbeatMetrics 将检查结果转换为心跳自定义指标和状态字段
+//   - check.<name>.status: Nagios 退出码（0=OK 1=WARNING 2=CRITICAL 3=UNKNOWN）
+//   - check.<name>.duration_ms: 检查耗时
+//   - check.<name>.<label>: perfdata（超出 daemon 单次心跳指标数量上限的部分被丢弃）
+//   - 字段 check.<name>: "<STATUS>: <输出>"
+func (r *Runner) HeartbeatMetrics() (map[string]float64, map[string]string) {
+	results := r.Results()
+	if len(results) == 0 {
+		return nil, nil
+	}
+
+	metrics := make(map[string]float64, len(results)*2)
+	fields := make(map[string]string, len(results))
+	for _, result := range results {
+		prefix := "check." + result.Name
+		metrics[prefix+".status"] = float64(result.Status.Code())
+		metrics[prefix+".duration_ms"] = result.DurationMs
+		if len(fields) < maxHeartbeatFields {
+			fields[prefix] = truncate(string(result.Status)+": "+result.Output, maxHeartbeatFieldLen)
+		}
+	}
+
+	for _, result := range results {
+		labels := make([]string, 0, len(result.Perfdata))
+		for label := range result.Perfdata {
+			labels = append(labels, label)
+		}
+		sort.Strings(labels)
+		for _, label := range labels {
+			if len(metrics) >= maxHeartbeatMetrics {
+				return metrics, fields
+			}
+			key := "check." + result.Name + "." + sanitizeLabel(label)
+			if len(key) > maxHeartbeatKeyLen {
+				continue
+			}
+			if _, exists := metrics[key]; exists {
+				continue
+			}
+			metrics[key] = result.Perfdata[label]
+		}
+	}
+	return metrics, fields
+}
+
+// sanitizeLabel 将 perfdata 标签转换为合法的指标名称（非字母、数字、下划线和点的字符替换为下划线）
+func sanitizeLabel(label string) string {
+	return strings.Map(func(c rune) rune {
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '.' {
+			return c
+		}
+		return '_'
+	}, label)
+}
+
+// ServeHTTP 以 JSON 返回所有检查最近一次的结果（GET /checks）
+func (r *Runner) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(map[string]interface{}{"checks": r.Results()})
+}
@@ -0,0 +1,208 @@
+package check
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bingooyong/ops-scaffold-framework/agent/internal/config"
+	"go.uber.org/zap"
+)
+
+// waitForResults 等待调度器产生指定数量的结果
+func waitForResults(t *testing.T, r *Runner, n int) []Result {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		results := r.Results()
+		if len(results) == n {
+			return results
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %d results, got %v", n, results)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRunnerStartAndReplace(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data")
+	if err := os.WriteFile(path, []byte("hello"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	r := NewRunner(zap.NewNop())
+	defer r.Stop()
+
+	err := r.Start([]config.CheckConfig{
+		{Name: "data_file", Type: "file", Path: path, Interval: time.Hour},
+		{Name: "missing_file", Type: "file", Path: filepath.Join(dir, "missing"), Interval: time.Hour},
+	})
+	if err != nil {
+		t.Fatalf("failed to start runner: %v", err)
+	}
+
+	results := waitForResults(t, r, 2)
+	if results[0].Name != "data_file" || results[0].Status != StatusOK || results[0].Type != "file" {
+		t.Errorf("unexpected result: %+v", results[0])
+	}
+	if results[1].Name != "missing_file" || results[1].Status != StatusCritical {
+		t.Errorf("unexpected result: %+v", results[1])
+	}
+
+	// 配置无效时返回错误，正在运行的检查保持不变
+	if err := r.Start([]config.CheckConfig{{Name: "bad", Type: "unknown"}}); err == nil {
+		t.Fatal("expected error for invalid check")
+	}
+	if len(r.Results()) != 2 {
+		t.Fatalf("invalid config should not replace running checks: %v", r.Results())
+	}
+
+	// 替换配置后移除已删除检查的结果
+	if err := r.Start([]config.CheckConfig{{Name: "data_file", Type: "file", Path: path, Interval: time.Hour}}); err != nil {
+		t.Fatalf("failed to restart runner: %v", err)
+	}
+	if results := waitForResults(t, r, 1); results[0].Name != "data_file" {
+		t.Errorf("unexpected results after replace: %v", results)
+	}
+}
+
+func TestRunnerTruncatesOutput(t *testing.T) {
+	r := NewRunner(zap.NewNop())
+	defer r.Stop()
+
+	err := r.Start([]config.CheckConfig{
+		{Name: "noisy", Type: "exec", Command: []string{"/bin/sh", "-c", "head -c 4096 /dev/zero | tr '\\0' 'x'"}, Interval: time.Hour},
+	})
+	if err != nil {
+		t.Fatalf("failed to start runner: %v", err)
+	}
+	results := waitForResults(t, r, 1)
+	if len(results[0].Output) != maxOutputLength {
+		t.Errorf("output should be truncated to %d bytes, got %d", maxOutputLength, len(results[0].Output))
+	}
+}
+
+func TestRunnerStopDiscardsCanceledCheck(t *testing.T) {
+	r := NewRunner(zap.NewNop())
+	err := r.Start([]config.CheckConfig{
+		{Name: "slow", Type: "exec", Command: []string{"/bin/sh", "-c", "sleep 30"}, Interval: time.Hour, Timeout: time.Hour},
+	})
+	if err != nil {
+		t.Fatalf("failed to start runner: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		r.Stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stop should cancel running checks")
+	}
+	if results := r.Results(); len(results) != 0 {
+		t.Errorf("results of canceled checks should be discarded: %v", results)
+	}
+}
+
+func TestHeartbeatMetrics(t *testing.T) {
+	r := NewRunner(zap.NewNop())
+	r.results["db"] = &Result{
+		Name:       "db",
+		Status:     StatusWarning,
+		Output:     "slow query",
+		Perfdata:   map[string]float64{"query time": 1.5, "/var": 10},
+		DurationMs: 12,
+	}
+	r.results["web"] = &Result{Name: "web", Status: StatusOK, Output: "HTTP 200", DurationMs: 3}
+
+	metrics, fields := r.HeartbeatMetrics()
+	expected := map[string]float64{
+		"check.db.status":       1,
+		"check.db.duration_ms":  12,
+		"check.db.query_time":   1.5,
+		"check.db._var":         10,
+		"check.web.status":      0,
+		"check.web.duration_ms": 3,
+	}
+	if len(metrics) != len(expected) {
+		t.Fatalf("unexpected metrics: %v", metrics)
+	}
+	for key, value := range expected {
+		if metrics[key] != value {
+			t.Errorf("metrics[%s] = %v, want %v", key, metrics[key], value)
+		}
+	}
+	if fields["check.db"] != "WARNING: slow query" || fields["check.web"] != "OK: HTTP 200" {
+		t.Errorf("unexpected fields: %v", fields)
+	}
+}
+
+func TestHeartbeatMetricsLimit(t *testing.T) {
+	r := NewRunner(zap.NewNop())
+	perfdata := make(map[string]float64)
+	for i := 0; i < maxHeartbeatMetrics*2; i++ {
+		perfdata[strings.Repeat("p", 1+i%8)+string(rune('a'+i%26))+string(rune('a'+i/26))] = float64(i)
+	}
+	perfdata[strings.Repeat("l", maxHeartbeatKeyLen)] = 1
+	r.results["big"] = &Result{Name: "big", Status: StatusOK, Perfdata: perfdata}
+
+	metrics, _ := r.HeartbeatMetrics()
+	if len(metrics) > maxHeartbeatMetrics {
+		t.Errorf("metrics should be capped at %d, got %d", maxHeartbeatMetrics, len(metrics))
+	}
+	for key := range metrics {
+		if len(key) > maxHeartbeatKeyLen {
+			t.Errorf("metric key exceeds %d bytes: %s", maxHeartbeatKeyLen, key)
+		}
+	}
+	if _, ok := metrics["check.big.status"]; !ok {
+		t.Error("status metric must always be reported")
+	}
+}
+
+func TestRunnerServeHTTP(t *testing.T) {
+	r := NewRunner(zap.NewNop())
+	r.results["web"] = &Result{Name: "web", Type: "http", Status: StatusOK}
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/checks", nil))
+	var body struct {
+		Checks []Result `json:"checks"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(body.Checks) != 1 || body.Checks[0].Name != "web" {
+		t.Errorf("unexpected checks: %+v", body.Checks)
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/checks", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST should be rejected, got %d", rec.Code)
+	}
+}
+
+func TestExecuteTimeout(t *testing.T) {
+	r := NewRunner(zap.NewNop())
+	checker, err := NewChecker(&config.CheckConfig{Name: "hang", Type: "exec", Command: []string{"/bin/sh", "-c", "sleep 30"}})
+	if err != nil {
+		t.Fatalf("failed to create checker: %v", err)
+	}
+	r.execute(context.Background(), &scheduledCheck{name: "hang", typ: "exec", timeout: 100 * time.Millisecond, checker: checker})
+
+	results := r.Results()
+	if len(results) != 1 || results[0].Status != StatusCritical || !strings.Contains(results[0].Output, "timed out") {
+		t.Errorf("unexpected result: %+v", results)
+	}
+}
@@ -0,0 +1,46 @@
+package check
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/bingooyong/ops-scaffold-framework/agent/internal/config"
+)
+
+// tcpChecker 检查 TCP 端口是否可连接
+type tcpChecker struct {
+	address string
+	warning time.Duration
+}
+
+// newTCPChecker 创建 tcp 检查器
+func newTCPChecker(cfg *config.CheckConfig) (Checker, error) {
+	if cfg.Address == "" {
+		return nil, errors.New("address is required for tcp check")
+	}
+	if _, _, err := net.SplitHostPort(cfg.Address); err != nil {
+		return nil, fmt.Errorf("invalid address %q: %w", cfg.Address, err)
+	}
+	return &tcpChecker{address: cfg.Address, warning: cfg.Warning}, nil
+}
+
+// Check 建立 TCP 连接后立即关闭
+func (c *tcpChecker) Check(ctx context.Context) Outcome {
+	var dialer net.Dialer
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", c.address)
+	elapsed := time.Since(start)
+	if err != nil {
+		return Outcome{Status: StatusCritical, Output: fmt.Sprintf("connect to %s failed: %v", c.address, err)}
+	}
+	conn.Close()
+
+	return Outcome{
+		Status:   latencyStatus(elapsed, c.warning),
+		Output:   fmt.Sprintf("connected to %s in %.3fs", c.address, elapsed.Seconds()),
+		Perfdata: map[string]float64{"time": elapsed.Seconds()},
+	}
+}
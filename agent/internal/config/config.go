@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"regexp"
 	"time"
 
 	"github.com/spf13/viper"
@@ -14,10 +15,14 @@ type Config struct {
 	Heartbeat HeartbeatConfig `mapstructure:"heartbeat"`
 	HTTP      HTTPConfig      `mapstructure:"http"`
 	Log       LogConfig       `mapstructure:"log"`
+	Control   ControlConfig   `mapstructure:"control"`
+	Checks    []CheckConfig   `mapstructure:"checks"`
 }
 
 // HeartbeatConfig 心跳配置
 type HeartbeatConfig struct {
+	// URL Daemon HTTP 心跳地址（设置后优先于 SocketPath，daemon 通过 OPS_DAEMON_HEARTBEAT_URL 下发）
+	URL        string        `mapstructure:"url"`
 	SocketPath string        `mapstructure:"socket_path"`
 	Interval   time.Duration `mapstructure:"interval"`
 }
@@ -28,6 +33,56 @@ type HTTPConfig struct {
 	Host string `mapstructure:"host"`
 }
 
+// ControlConfig 控制通道配置
+type ControlConfig struct {
+	// SocketPath 控制 Unix Socket 路径（为空时不启用，daemon 通过 OPS_AGENT_CONTROL_SOCKET 下发）
+	SocketPath string `mapstructure:"socket_path"`
+}
+
+// CheckConfig 定时检查配置
+// 检查结果通过心跳的自定义指标（check.<name>.*）和状态字段（check.<name>）上报
+type CheckConfig struct {
+	// Name 检查名称（字母或下划线开头，仅含字母、数字、下划线，最长 32 个字符）
+	Name string `mapstructure:"name"`
+	// Type 检查类型: exec/http/tcp/file
+	Type string `mapstructure:"type"`
+	// Interval 检查间隔（默认 60s）
+	Interval time.Duration `mapstructure:"interval"`
+	// Timeout 单次检查超时（默认 10s，不超过 Interval）
+	Timeout time.Duration `mapstructure:"timeout"`
+
+	// Command exec: 命令及参数，按 Nagios 插件约定解析退出码（0/1/2/3）和 perfdata
+	Command []string `mapstructure:"command"`
+
+	// URL http: GET 请求地址
+	URL string `mapstructure:"url"`
+	// ExpectStatus http: 期望的状态码（默认任意 2xx）
+	ExpectStatus int `mapstructure:"expect_status"`
+	// ExpectBody http: 响应体需包含的字符串（可选）
+	ExpectBody string `mapstructure:"expect_body"`
+
+	// Address tcp: 连接地址（host:port）
+	Address string `mapstructure:"address"`
+
+	// Path file: 文件路径
+	Path string `mapstructure:"path"`
+	// MaxAge file: 最后修改时间距今的最大时长（0 表示不检查）
+	MaxAge time.Duration `mapstructure:"max_age"`
+	// MinSize file: 最小字节数（0 表示不检查）
+	MinSize int64 `mapstructure:"min_size"`
+	// MaxSize file: 最大字节数（0 表示不检查）
+	MaxSize int64 `mapstructure:"max_size"`
+
+	// Warning http/tcp: 响应耗时超过该值时为 WARNING（0 表示不检查）
+	Warning time.Duration `mapstructure:"warning"`
+}
+
+// MaxChecks 最多配置的检查数量（受 daemon 单次心跳自定义指标和字段数量限制）
+const MaxChecks = 16
+
+// checkNamePattern 检查名称格式
+var checkNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]{0,31}$`)
+
 // LogConfig 日志配置
 type LogConfig struct {
 	Level  string `mapstructure:"level"`
@@ -53,12 +108,14 @@ func LoadConfig(configPath string) (*Config, error) {
 	v.AutomaticEnv()
 	v.SetEnvPrefix("AGENT")
 
-	// 绑定关键配置项到环境变量
-	v.BindEnv("agent_id", "AGENT_AGENT_ID")
-	v.BindEnv("heartbeat.socket_path", "AGENT_HEARTBEAT_SOCKET_PATH")
+	// 绑定关键配置项到环境变量（由 daemon 启动时，daemon 下发的 OPS_* 环境变量优先于配置文件）
+	v.BindEnv("agent_id", "AGENT_AGENT_ID", "OPS_AGENT_ID")
+	v.BindEnv("heartbeat.url", "AGENT_HEARTBEAT_URL", "OPS_DAEMON_HEARTBEAT_URL")
+	v.BindEnv("heartbeat.socket_path", "AGENT_HEARTBEAT_SOCKET_PATH", "OPS_DAEMON_HEARTBEAT_SOCKET")
 	v.BindEnv("heartbeat.interval", "AGENT_HEARTBEAT_INTERVAL")
 	v.BindEnv("http.port", "AGENT_HTTP_PORT")
 	v.BindEnv("log.level", "AGENT_LOG_LEVEL")
+	v.BindEnv("control.socket_path", "OPS_AGENT_CONTROL_SOCKET")
 
 	// 设置默认值
 	v.SetDefault("version", "1.0.0")
@@ -94,8 +151,8 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("agent_id is required")
 	}
 
-	if c.Heartbeat.SocketPath == "" {
-		return fmt.Errorf("heartbeat.socket_path is required")
+	if c.Heartbeat.URL == "" && c.Heartbeat.SocketPath == "" {
+		return fmt.Errorf("heartbeat.url or heartbeat.socket_path is required")
 	}
 
 	if c.Heartbeat.Interval <= 0 {
@@ -106,5 +163,35 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("http.port must be between 1 and 65535")
 	}
 
+	return c.validateChecks()
+}
+
+// validateChecks 验证检查配置的通用字段，各类型的字段由检查器创建时验证
+func (c *Config) validateChecks() error {
+	if len(c.Checks) > MaxChecks {
+		return fmt.Errorf("at most %d checks are allowed, got %d", MaxChecks, len(c.Checks))
+	}
+
+	names := make(map[string]bool, len(c.Checks))
+	for i, check := range c.Checks {
+		if !checkNamePattern.MatchString(check.Name) {
+			return fmt.Errorf("checks[%d].name %q must match %s", i, check.Name, checkNamePattern.String())
+		}
+		if names[check.Name] {
+			return fmt.Errorf("checks[%d].name %q is duplicated", i, check.Name)
+		}
+		names[check.Name] = true
+
+		if check.Type == "" {
+			return fmt.Errorf("checks[%d].type is required", i)
+		}
+		if check.Interval < 0 || check.Timeout < 0 || check.Warning < 0 {
+			return fmt.Errorf("checks[%d]: interval, timeout and warning must not be negative", i)
+		}
+		if check.Interval > 0 && check.Timeout > check.Interval {
+			return fmt.Errorf("checks[%d].timeout must not exceed interval", i)
+		}
+	}
+
 	return nil
 }
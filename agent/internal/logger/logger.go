@@ -1,12 +1,16 @@
 package logger
 
 import (
+	"fmt"
 	"os"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
+// atomicLevel 全局日志级别（支持运行时调整）
+var atomicLevel = zap.NewAtomicLevel()
+
 // InitLogger 初始化日志
 func InitLogger(level, logFile, format string) (*zap.Logger, error) {
 	// 解析日志级别
@@ -14,6 +18,7 @@ func InitLogger(level, logFile, format string) (*zap.Logger, error) {
 	if err := zapLevel.UnmarshalText([]byte(level)); err != nil {
 		zapLevel = zapcore.InfoLevel
 	}
+	atomicLevel.SetLevel(zapLevel)
 
 	// 配置编码器
 	var encoder zapcore.Encoder
@@ -40,10 +45,30 @@ func InitLogger(level, logFile, format string) (*zap.Logger, error) {
 	}
 
 	// 创建 Core
-	core := zapcore.NewCore(encoder, writeSyncer, zapLevel)
+	core := zapcore.NewCore(encoder, writeSyncer, atomicLevel)
 
 	// 创建 Logger
 	logger := zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1))
 
 	return logger, nil
 }
+
+// SetLevel 运行时调整日志级别（debug/info/warn/error）
+func SetLevel(level string) error {
+	var zapLevel zapcore.Level
+	if err := zapLevel.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("invalid log level: %s", level)
+	}
+	atomicLevel.SetLevel(zapLevel)
+	return nil
+}
+
+// AtomicLevel 返回全局日志级别（供 SDK 的 set_log_level 控制命令调整）
+func AtomicLevel() *zap.AtomicLevel {
+	return &atomicLevel
+}
+
+// GetLevel 获取当前日志级别
+func GetLevel() string {
+	return atomicLevel.Level().String()
+}
@@ -0,0 +1,236 @@
+package sdk
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// 控制命令（与 daemon/pkg/control 保持一致）
+const (
+	CommandPing            = "ping"
+	CommandReload          = "reload"
+	CommandSetLogLevel     = "set_log_level"
+	CommandDumpDiagnostics = "dump_diagnostics"
+	CommandDrain           = "drain"
+)
+
+// 响应错误码（与 daemon/pkg/control 保持一致）
+const (
+	CodeUnknownCommand = "unknown_command"
+	CodeInvalidArgs    = "invalid_args"
+	CodeFailed         = "failed"
+)
+
+// maxControlMessageSize 单条控制请求的最大字节数
+const maxControlMessageSize = 1 << 20
+
+// controlRequest 控制请求
+type controlRequest struct {
+	ID      string            `json:"id"`
+	Command string            `json:"command"`
+	Args    map[string]string `json:"args,omitempty"`
+}
+
+// controlResponse 控制响应
+type controlResponse struct {
+	ID      string            `json:"id"`
+	Success bool              `json:"success"`
+	Code    string            `json:"code,omitempty"`
+	Message string            `json:"message,omitempty"`
+	Data    map[string]string `json:"data,omitempty"`
+}
+
+// ControlHandler 控制命令处理函数，返回响应消息和数据，出错时返回错误
+// 返回 *InvalidArgsError 时响应错误码为 invalid_args，其他错误为 failed
+type ControlHandler func(ctx context.Context, args map[string]string) (message string, data map[string]string, err error)
+
+// InvalidArgsError 命令参数错误
+type InvalidArgsError struct {
+	Message string
+}
+
+func (e *InvalidArgsError) Error() string {
+	return e.Message
+}
+
+// controlServer 控制通道服务器
+// 在 Unix Socket 上接收 Daemon 下发的控制命令（换行分隔的 JSON 请求/响应）
+type controlServer struct {
+	socketPath string
+	logger     *zap.Logger
+	ctx        context.Context
+	cancel     context.CancelFunc
+	listener   net.Listener
+	mu         sync.RWMutex
+	handlers   map[string]ControlHandler
+}
+
+// newControlServer 创建控制通道服务器
+func newControlServer(socketPath string, logger *zap.Logger) *controlServer {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &controlServer{
+		socketPath: socketPath,
+		logger:     logger,
+		ctx:        ctx,
+		cancel:     cancel,
+		handlers:   make(map[string]ControlHandler),
+	}
+}
+
+// handle 注册控制命令处理函数，已注册的命令会被覆盖
+func (s *controlServer) handle(command string, handler ControlHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[command] = handler
+}
+
+// start 启动控制通道监听
+func (s *controlServer) start() error {
+	if err := os.MkdirAll(filepath.Dir(s.socketPath), 0755); err != nil {
+		return fmt.Errorf("failed to create control socket directory: %w", err)
+	}
+	// 清理上次运行遗留的 socket 文件
+	if err := os.Remove(s.socketPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove stale control socket: %w", err)
+	}
+
+	listener, err := net.Listen("unix", s.socketPath)
+	if err != nil {
+		return fmt.Errorf("failed to listen on control socket: %w", err)
+	}
+	// 只允许同一用户（daemon 以相同或更高权限运行）连接
+	if err := os.Chmod(s.socketPath, 0600); err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to chmod control socket: %w", err)
+	}
+	s.listener = listener
+
+	s.logger.Info("control server started", zap.String("socket_path", s.socketPath))
+
+	go s.acceptLoop()
+	return nil
+}
+
+// stop 停止控制通道监听
+func (s *controlServer) stop() {
+	s.cancel()
+	if s.listener != nil {
+		s.listener.Close()
+		os.Remove(s.socketPath)
+	}
+	s.logger.Info("control server stopped")
+}
+
+// acceptLoop 接收连接
+func (s *controlServer) acceptLoop() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			s.logger.Warn("failed to accept control connection", zap.Error(err))
+			continue
+		}
+		go s.serveConn(conn)
+	}
+}
+
+// serveConn 按顺序处理单个连接上的请求
+func (s *controlServer) serveConn(conn net.Conn) {
+	defer conn.Close()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 4096), maxControlMessageSize)
+	for scanner.Scan() {
+		var req controlRequest
+		var resp *controlResponse
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			resp = &controlResponse{Code: CodeInvalidArgs, Message: "invalid request: " + err.Error()}
+		} else {
+			resp = s.dispatch(&req)
+		}
+		resp.ID = req.ID
+
+		data, err := json.Marshal(resp)
+		if err != nil {
+			return
+		}
+		conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		if _, err := conn.Write(append(data, '\n')); err != nil {
+			s.logger.Warn("failed to write control response", zap.Error(err))
+			return
+		}
+	}
+}
+
+// dispatch 执行控制命令
+func (s *controlServer) dispatch(req *controlRequest) *controlResponse {
+	if req.Command == CommandPing {
+		return &controlResponse{
+			Success: true,
+			Message: "pong",
+			Data:    map[string]string{"supported_commands": strings.Join(s.commands(), ",")},
+		}
+	}
+
+	s.mu.RLock()
+	handler, ok := s.handlers[req.Command]
+	s.mu.RUnlock()
+	if !ok {
+		return &controlResponse{Code: CodeUnknownCommand, Message: "unknown command: " + req.Command}
+	}
+
+	s.logger.Info("received control command",
+		zap.String("id", req.ID),
+		zap.String("command", req.Command))
+
+	message, data, err := handler(s.ctx, req.Args)
+	if err != nil {
+		s.logger.Warn("control command failed",
+			zap.String("command", req.Command),
+			zap.Error(err))
+		code := CodeFailed
+		var argsErr *InvalidArgsError
+		if errors.As(err, &argsErr) {
+			code = CodeInvalidArgs
+		}
+		return &controlResponse{Code: code, Message: err.Error()}
+	}
+	return &controlResponse{Success: true, Message: message, Data: data}
+}
+
+// call 在本进程内执行控制命令（用于信号回退）
+func (s *controlServer) call(ctx context.Context, command string, args map[string]string) (string, map[string]string, error) {
+	s.mu.RLock()
+	handler, ok := s.handlers[command]
+	s.mu.RUnlock()
+	if !ok {
+		return "", nil, fmt.Errorf("unknown command: %s", command)
+	}
+	return handler(ctx, args)
+}
+
+// commands 返回已注册的命令（按名称排序）
+func (s *controlServer) commands() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	commands := make([]string, 0, len(s.handlers))
+	for command := range s.handlers {
+		commands = append(commands, command)
+	}
+	sort.Strings(commands)
+	return commands
+}
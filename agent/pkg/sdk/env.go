@@ -0,0 +1,42 @@
+package sdk
+
+import "os"
+
+// Daemon 启动 Agent 时下发的环境变量（与 daemon/pkg/heartbeat、daemon/pkg/control 保持一致）
+const (
+	// EnvAgentID Agent ID（与 daemon 配置中的 agents[].id 一致）
+	EnvAgentID = "OPS_AGENT_ID"
+	// EnvHeartbeatSecret 本次运行的心跳签名密钥
+	EnvHeartbeatSecret = "OPS_AGENT_HEARTBEAT_SECRET"
+	// EnvHeartbeatURL Daemon HTTP 心跳地址
+	EnvHeartbeatURL = "OPS_DAEMON_HEARTBEAT_URL"
+	// EnvHeartbeatSocket Daemon Unix Socket 心跳路径
+	EnvHeartbeatSocket = "OPS_DAEMON_HEARTBEAT_SOCKET"
+	// EnvControlSocket 控制通道 Unix Socket 路径（Agent 在该路径上监听）
+	EnvControlSocket = "OPS_AGENT_CONTROL_SOCKET"
+)
+
+// Env Daemon 下发的运行环境
+type Env struct {
+	AgentID         string
+	HeartbeatSecret string
+	HeartbeatURL    string
+	HeartbeatSocket string
+	ControlSocket   string
+}
+
+// LoadEnv 从环境变量读取 Daemon 下发的运行环境
+func LoadEnv() Env {
+	return Env{
+		AgentID:         os.Getenv(EnvAgentID),
+		HeartbeatSecret: os.Getenv(EnvHeartbeatSecret),
+		HeartbeatURL:    os.Getenv(EnvHeartbeatURL),
+		HeartbeatSocket: os.Getenv(EnvHeartbeatSocket),
+		ControlSocket:   os.Getenv(EnvControlSocket),
+	}
+}
+
+// Managed 是否由 Daemon 启动（Daemon 启动时总会下发 Agent ID）
+func (e Env) Managed() bool {
+	return e.AgentID != ""
+}
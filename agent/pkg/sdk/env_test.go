@@ -0,0 +1,76 @@
+package sdk
+
+import (
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestLoadEnv(t *testing.T) {
+	t.Setenv(EnvAgentID, "agent-1")
+	t.Setenv(EnvHeartbeatSecret, "secret")
+	t.Setenv(EnvHeartbeatURL, "http://127.0.0.1:8080/heartbeat")
+	t.Setenv(EnvHeartbeatSocket, "/run/daemon.sock")
+	t.Setenv(EnvControlSocket, "/run/agent-1.sock")
+
+	env := LoadEnv()
+	expected := Env{
+		AgentID:         "agent-1",
+		HeartbeatSecret: "secret",
+		HeartbeatURL:    "http://127.0.0.1:8080/heartbeat",
+		HeartbeatSocket: "/run/daemon.sock",
+		ControlSocket:   "/run/agent-1.sock",
+	}
+	if env != expected {
+		t.Fatalf("unexpected env: %+v", env)
+	}
+	if !env.Managed() {
+		t.Error("expected env with agent id to be managed")
+	}
+}
+
+func TestLoadEnvUnmanaged(t *testing.T) {
+	t.Setenv(EnvAgentID, "")
+	if LoadEnv().Managed() {
+		t.Error("expected env without agent id to be unmanaged")
+	}
+}
+
+func TestNewFromEnv(t *testing.T) {
+	t.Setenv(EnvAgentID, "agent-1")
+	t.Setenv(EnvHeartbeatSecret, "secret")
+	t.Setenv(EnvHeartbeatURL, "http://127.0.0.1:8080/heartbeat")
+	t.Setenv(EnvHeartbeatSocket, "")
+	t.Setenv(EnvControlSocket, "")
+
+	agent, err := New(Options{HeartbeatURL: "http://127.0.0.1:9090/heartbeat"})
+	if err != nil {
+		t.Fatalf("failed to create agent: %v", err)
+	}
+	if agent.AgentID() != "agent-1" {
+		t.Errorf("agent id should default to %s: %s", EnvAgentID, agent.AgentID())
+	}
+	if agent.opts.HeartbeatSecret != "secret" {
+		t.Errorf("heartbeat secret should default to %s", EnvHeartbeatSecret)
+	}
+	if agent.opts.HeartbeatURL != "http://127.0.0.1:9090/heartbeat" {
+		t.Errorf("explicit options should take precedence over env: %s", agent.opts.HeartbeatURL)
+	}
+	if agent.opts.HeartbeatInterval != defaultHeartbeatInterval || agent.opts.ShutdownTimeout != defaultShutdownTimeout {
+		t.Errorf("unexpected defaults: %+v", agent.opts)
+	}
+}
+
+func TestNewRequiresAgentID(t *testing.T) {
+	t.Setenv(EnvAgentID, "")
+	if _, err := New(Options{}); err == nil {
+		t.Fatal("expected error without agent id")
+	}
+}
+
+func TestNewRejectsUnsupportedSignalCommand(t *testing.T) {
+	t.Setenv(EnvAgentID, "agent-1")
+	if _, err := New(Options{SignalCommands: map[os.Signal]string{syscall.SIGHUP: CommandSetLogLevel}}); err == nil {
+		t.Fatal("expected error for unsupported signal command")
+	}
+}
@@ -0,0 +1,389 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+	"go.uber.org/zap"
+)
+
+// 心跳签名请求头（与 daemon/pkg/heartbeat 保持一致）
+const (
+	headerTimestamp = "X-Heartbeat-Timestamp"
+	headerSignature = "X-Heartbeat-Signature"
+)
+
+// 心跳上报方式
+const (
+	TransportHTTP   = "http"
+	TransportSocket = "socket"
+)
+
+// Heartbeat 心跳数据
+// 同时兼容 Daemon HTTP 心跳请求和 Unix Socket 心跳格式（与 daemon/pkg/types 中的 Heartbeat 保持一致）
+type Heartbeat struct {
+	AgentID   string             `json:"agent_id"`
+	PID       int                `json:"pid"`
+	Timestamp time.Time          `json:"timestamp"`
+	Version   string             `json:"version"`
+	Status    string             `json:"status"`
+	CPU       float64            `json:"cpu"`
+	Memory    uint64             `json:"memory"`
+	Metrics   map[string]float64 `json:"metrics,omitempty"`
+	Fields    map[string]string  `json:"fields,omitempty"`
+}
+
+// MetricsProvider 自定义指标提供函数，返回随心跳上报的数值指标和状态字段
+type MetricsProvider func() (metrics map[string]float64, fields map[string]string)
+
+// HeartbeatStats 心跳发送统计
+type HeartbeatStats struct {
+	Transport   string    // 上报方式: http/socket，未配置心跳地址时为空
+	Count       int64     // 发送成功次数
+	Failures    int64     // 发送失败次数
+	LastSuccess time.Time // 最后一次发送成功时间
+	LastError   string    // 最后一次发送失败原因
+	CPU         float64   // 最后一次采集的 CPU 使用率
+	Memory      uint64    // 最后一次采集的内存占用
+}
+
+// envelope Unix Socket 签名心跳信封（与 daemon/pkg/heartbeat 中的 Envelope 保持一致）
+type envelope struct {
+	AgentID   string `json:"agent_id"`
+	Timestamp int64  `json:"timestamp"`
+	Signature string `json:"signature"`
+	Payload   []byte `json:"payload"`
+}
+
+// signHeartbeat 计算心跳签名: hex(HMAC-SHA256(secret, "<timestamp>.<body>"))
+func signHeartbeat(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// heartbeater 心跳发送器
+// 配置了 HTTP 地址时通过 HTTP 上报，否则通过 Unix Socket 上报；配置了密钥时对心跳签名
+type heartbeater struct {
+	agentID    string
+	version    string
+	secret     string
+	url        string
+	socketPath string
+	interval   time.Duration
+	logger     *zap.Logger
+	client     *http.Client
+	proc       *process.Process
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	// sendMu 串行化发送，保护 Socket 连接
+	sendMu sync.Mutex
+	conn   net.Conn
+
+	mu       sync.RWMutex
+	status   string
+	provider MetricsProvider
+	stats    HeartbeatStats
+}
+
+// newHeartbeater 创建心跳发送器
+func newHeartbeater(opts *Options, logger *zap.Logger) *heartbeater {
+	ctx, cancel := context.WithCancel(context.Background())
+	h := &heartbeater{
+		agentID:    opts.AgentID,
+		version:    opts.Version,
+		secret:     opts.HeartbeatSecret,
+		url:        opts.HeartbeatURL,
+		socketPath: opts.HeartbeatSocket,
+		interval:   opts.HeartbeatInterval,
+		logger:     logger,
+		client:     &http.Client{Timeout: minDuration(opts.HeartbeatInterval, 10*time.Second)},
+		ctx:        ctx,
+		cancel:     cancel,
+		done:       make(chan struct{}),
+		status:     "running",
+	}
+	switch {
+	case h.url != "":
+		h.stats.Transport = TransportHTTP
+	case h.socketPath != "":
+		h.stats.Transport = TransportSocket
+	}
+	if proc, err := process.NewProcess(int32(os.Getpid())); err == nil {
+		h.proc = proc
+	}
+	return h
+}
+
+// enabled 是否配置了心跳地址
+func (h *heartbeater) enabled() bool {
+	return h.stats.Transport != ""
+}
+
+// setStatus 设置定时心跳上报的运行状态
+func (h *heartbeater) setStatus(status string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.status = status
+}
+
+// getStatus 获取定时心跳上报的运行状态
+func (h *heartbeater) getStatus() string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.status
+}
+
+// setProvider 设置自定义指标提供函数
+func (h *heartbeater) setProvider(provider MetricsProvider) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.provider = provider
+}
+
+// getProvider 获取自定义指标提供函数
+func (h *heartbeater) getProvider() MetricsProvider {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.provider
+}
+
+// getStats 获取心跳发送统计
+func (h *heartbeater) getStats() HeartbeatStats {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.stats
+}
+
+// start 立即发送一次心跳并启动心跳循环
+// 发送失败只记录日志，Daemon 暂不可用时在下一个周期重试
+func (h *heartbeater) start() {
+	if !h.enabled() {
+		h.logger.Warn("no heartbeat endpoint configured, heartbeats disabled")
+		close(h.done)
+		return
+	}
+
+	h.logger.Info("starting heartbeat",
+		zap.String("transport", h.stats.Transport),
+		zap.String("url", h.url),
+		zap.String("socket_path", h.socketPath),
+		zap.Duration("interval", h.interval),
+		zap.Bool("signed", h.secret != ""))
+
+	if err := h.send(h.getStatus()); err != nil {
+		h.logger.Warn("failed to send initial heartbeat", zap.Error(err))
+	}
+	go h.loop()
+}
+
+// stop 停止心跳循环并发送最后一次心跳（status="stopping"）
+func (h *heartbeater) stop() {
+	h.cancel()
+	<-h.done
+	if !h.enabled() {
+		return
+	}
+
+	if err := h.send("stopping"); err != nil {
+		h.logger.Warn("failed to send final heartbeat", zap.Error(err))
+	}
+
+	h.sendMu.Lock()
+	defer h.sendMu.Unlock()
+	if h.conn != nil {
+		h.conn.Close()
+		h.conn = nil
+	}
+}
+
+// loop 心跳发送循环
+func (h *heartbeater) loop() {
+	defer close(h.done)
+
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-h.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := h.send(h.getStatus()); err != nil {
+				h.logger.Error("failed to send heartbeat", zap.Error(err))
+			}
+		}
+	}
+}
+
+// send 采集资源使用并发送一次心跳
+func (h *heartbeater) send(status string) error {
+	h.sendMu.Lock()
+	defer h.sendMu.Unlock()
+
+	cpu, memory := h.collectResourceUsage()
+
+	hb := Heartbeat{
+		AgentID:   h.agentID,
+		PID:       os.Getpid(),
+		Timestamp: time.Now(),
+		Version:   h.version,
+		Status:    status,
+		CPU:       cpu,
+		Memory:    memory,
+	}
+	if provider := h.getProvider(); provider != nil {
+		hb.Metrics, hb.Fields = provider()
+	}
+
+	err := h.write(&hb)
+
+	h.mu.Lock()
+	h.stats.CPU = cpu
+	h.stats.Memory = memory
+	if err != nil {
+		h.stats.Failures++
+		h.stats.LastError = err.Error()
+	} else {
+		h.stats.Count++
+		h.stats.LastSuccess = time.Now()
+	}
+	h.mu.Unlock()
+
+	if err == nil {
+		h.logger.Debug("heartbeat sent",
+			zap.String("status", status),
+			zap.Float64("cpu", cpu),
+			zap.Uint64("memory", memory))
+	}
+	return err
+}
+
+// write 序列化并按上报方式发送心跳（需持有 sendMu）
+func (h *heartbeater) write(hb *Heartbeat) error {
+	data, err := json.Marshal(hb)
+	if err != nil {
+		return fmt.Errorf("failed to marshal heartbeat: %w", err)
+	}
+	if h.url != "" {
+		return h.writeHTTP(data)
+	}
+	return h.writeSocket(data)
+}
+
+// writeHTTP 通过 HTTP 发送心跳，签名放在请求头中
+func (h *heartbeater) writeHTTP(body []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), h.client.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create heartbeat request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if h.secret != "" {
+		timestamp := time.Now().UnixMilli()
+		req.Header.Set(headerTimestamp, strconv.FormatInt(timestamp, 10))
+		req.Header.Set(headerSignature, signHeartbeat(h.secret, timestamp, body))
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post heartbeat: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var result struct {
+			Message string `json:"message"`
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if json.Unmarshal(data, &result) != nil || result.Message == "" {
+			result.Message = string(data)
+		}
+		return fmt.Errorf("daemon rejected heartbeat: status %d: %s", resp.StatusCode, result.Message)
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// writeSocket 通过 Unix Socket 发送心跳（换行分隔），连接断开时在下次发送前重连
+func (h *heartbeater) writeSocket(payload []byte) error {
+	data := payload
+	if h.secret != "" {
+		timestamp := time.Now().UnixMilli()
+		var err error
+		data, err = json.Marshal(envelope{
+			AgentID:   h.agentID,
+			Timestamp: timestamp,
+			Signature: signHeartbeat(h.secret, timestamp, payload),
+			Payload:   payload,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to sign heartbeat: %w", err)
+		}
+	}
+
+	if h.conn == nil {
+		conn, err := net.DialTimeout("unix", h.socketPath, 5*time.Second)
+		if err != nil {
+			return fmt.Errorf("failed to connect to daemon socket: %w", err)
+		}
+		h.conn = conn
+		h.logger.Info("connected to daemon socket", zap.String("socket_path", h.socketPath))
+	}
+
+	h.conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+	if _, err := h.conn.Write(append(data, '\n')); err != nil {
+		h.conn.Close()
+		h.conn = nil
+		return fmt.Errorf("failed to write to socket: %w", err)
+	}
+	return nil
+}
+
+// collectResourceUsage 采集当前进程的 CPU 和内存使用
+func (h *heartbeater) collectResourceUsage() (cpu float64, memory uint64) {
+	if h.proc == nil {
+		return 0, 0
+	}
+
+	cpu, err := h.proc.CPUPercent()
+	if err != nil {
+		h.logger.Debug("failed to get cpu percent", zap.Error(err))
+		cpu = 0
+	}
+
+	memInfo, err := h.proc.MemoryInfo()
+	if err != nil {
+		h.logger.Debug("failed to get memory info", zap.Error(err))
+		return cpu, 0
+	}
+	return cpu, memInfo.RSS
+}
+
+// minDuration 返回较小的时长
+func minDuration(a, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+	return b
+}
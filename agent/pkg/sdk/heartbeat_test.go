@@ -0,0 +1,163 @@
+package sdk
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// 固定签名向量，与 daemon/pkg/heartbeat 的 TestSignFixture 使用同一组数据，
+// 保证 SDK 的签名能通过 heartbeat.Verify 校验
+const (
+	fixtureSecret    = "fixture-secret"
+	fixtureTimestamp = int64(1700000000000)
+	fixtureBody      = `{"agent_id":"agent-1","pid":4242,"status":"running"}`
+	fixtureSignature = "205018b241f37cd9a3a6731953d310d73565d9fe6cfcc3b91b4939f3e7a3af24"
+)
+
+func TestSignHeartbeatFixture(t *testing.T) {
+	if sig := signHeartbeat(fixtureSecret, fixtureTimestamp, []byte(fixtureBody)); sig != fixtureSignature {
+		t.Fatalf("signature does not match daemon fixture: %s", sig)
+	}
+	if sig := signHeartbeat("other", fixtureTimestamp, []byte(fixtureBody)); sig == fixtureSignature {
+		t.Error("signature should depend on the secret")
+	}
+	if sig := signHeartbeat(fixtureSecret, fixtureTimestamp+1, []byte(fixtureBody)); sig == fixtureSignature {
+		t.Error("signature should depend on the timestamp")
+	}
+}
+
+func TestHeartbeatHTTPSigned(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- r
+		bodies <- body
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	h := newHeartbeater(&Options{
+		AgentID:           "agent-1",
+		Version:           "1.0.0",
+		HeartbeatSecret:   fixtureSecret,
+		HeartbeatURL:      server.URL,
+		HeartbeatInterval: time.Second,
+	}, zap.NewNop())
+
+	if err := h.send("running"); err != nil {
+		t.Fatalf("failed to send heartbeat: %v", err)
+	}
+
+	req := <-received
+	body := <-bodies
+	timestamp, err := strconv.ParseInt(req.Header.Get(headerTimestamp), 10, 64)
+	if err != nil {
+		t.Fatalf("invalid timestamp header: %v", err)
+	}
+	if sig := req.Header.Get(headerSignature); sig != signHeartbeat(fixtureSecret, timestamp, body) {
+		t.Fatalf("signature header does not match body: %s", sig)
+	}
+
+	var hb Heartbeat
+	if err := json.Unmarshal(body, &hb); err != nil {
+		t.Fatalf("failed to decode heartbeat: %v", err)
+	}
+	if hb.AgentID != "agent-1" || hb.Version != "1.0.0" || hb.Status != "running" {
+		t.Errorf("unexpected heartbeat: %+v", hb)
+	}
+	if stats := h.getStats(); stats.Transport != TransportHTTP || stats.Count != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestHeartbeatHTTPRejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"message":"invalid signature"}`))
+	}))
+	defer server.Close()
+
+	h := newHeartbeater(&Options{
+		AgentID:           "agent-1",
+		HeartbeatURL:      server.URL,
+		HeartbeatInterval: time.Second,
+	}, zap.NewNop())
+
+	if err := h.send("running"); err == nil {
+		t.Fatal("expected rejected heartbeat to fail")
+	}
+	stats := h.getStats()
+	if stats.Failures != 1 || stats.LastError == "" {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestHeartbeatSocketEnvelope(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "hb.sock")
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	lines := make(chan []byte, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		scanner := bufio.NewScanner(conn)
+		if scanner.Scan() {
+			lines <- append([]byte(nil), scanner.Bytes()...)
+		}
+	}()
+
+	h := newHeartbeater(&Options{
+		AgentID:           "agent-1",
+		HeartbeatSecret:   fixtureSecret,
+		HeartbeatSocket:   socketPath,
+		HeartbeatInterval: time.Second,
+	}, zap.NewNop())
+	defer func() {
+		if h.conn != nil {
+			h.conn.Close()
+		}
+	}()
+
+	if err := h.send("running"); err != nil {
+		t.Fatalf("failed to send heartbeat: %v", err)
+	}
+
+	var env envelope
+	select {
+	case line := <-lines:
+		if err := json.Unmarshal(line, &env); err != nil {
+			t.Fatalf("failed to decode envelope: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for heartbeat")
+	}
+
+	if env.AgentID != "agent-1" {
+		t.Errorf("unexpected agent id: %s", env.AgentID)
+	}
+	if env.Signature != signHeartbeat(fixtureSecret, env.Timestamp, env.Payload) {
+		t.Error("envelope signature does not match payload")
+	}
+	var hb Heartbeat
+	if err := json.Unmarshal(env.Payload, &hb); err != nil || hb.Status != "running" {
+		t.Errorf("unexpected payload: %s (%v)", env.Payload, err)
+	}
+}
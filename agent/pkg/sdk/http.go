@@ -0,0 +1,142 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// registerRoutes 注册内置的 HTTP 接口
+func (a *Agent) registerRoutes() {
+	a.mux.HandleFunc("/health", a.handleHealth)
+	a.mux.HandleFunc("/reload", a.handleReload)
+	a.mux.HandleFunc("/metrics", a.handleMetrics)
+}
+
+// handleHealth 健康检查接口
+// 排空后或心跳连续失败超过 3 个周期时返回 503
+func (a *Agent) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]interface{}{"message": "method not allowed"})
+		return
+	}
+
+	status := a.status()
+	code := http.StatusOK
+	if status != "running" {
+		code = http.StatusServiceUnavailable
+	} else {
+		status = "healthy"
+	}
+
+	writeJSON(w, code, map[string]interface{}{
+		"status":         status,
+		"uptime":         int64(time.Since(a.startTime).Seconds()),
+		"last_heartbeat": formatTime(a.heartbeat.getStats().LastSuccess),
+		"agent_id":       a.opts.AgentID,
+	})
+}
+
+// handleReload 配置重载接口
+func (a *Agent) handleReload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]interface{}{"message": "method not allowed"})
+		return
+	}
+
+	a.logger.Info("received reload request")
+
+	if err := a.Reload(r.Context()); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]interface{}{
+			"success":     false,
+			"message":     fmt.Sprintf("config reload failed: %v", err),
+			"reloaded_at": time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success":     true,
+		"message":     "config reloaded successfully",
+		"reloaded_at": time.Now().Format(time.RFC3339),
+	})
+}
+
+// handleMetrics 指标暴露接口
+func (a *Agent) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]interface{}{"message": "method not allowed"})
+		return
+	}
+
+	stats := a.heartbeat.getStats()
+	body := map[string]interface{}{
+		"agent_id":           a.opts.AgentID,
+		"version":            a.opts.Version,
+		"uptime":             int64(time.Since(a.startTime).Seconds()),
+		"heartbeat_count":    stats.Count,
+		"heartbeat_failures": stats.Failures,
+		"last_heartbeat":     formatTime(stats.LastSuccess),
+		"cpu_percent":        stats.CPU,
+		"memory_bytes":       stats.Memory,
+		"status":             a.status(),
+	}
+
+	// 附带自定义指标（与心跳上报的内容一致）
+	if provider := a.heartbeat.getProvider(); provider != nil {
+		metrics, fields := provider()
+		if len(metrics) > 0 {
+			body["metrics"] = metrics
+		}
+		if len(fields) > 0 {
+			body["fields"] = fields
+		}
+	}
+
+	writeJSON(w, http.StatusOK, body)
+}
+
+// loggingMiddleware 日志中间件
+func (a *Agent) loggingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rec, r)
+
+		a.logger.Debug("http request",
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+			zap.Int("status", rec.status),
+			zap.Duration("latency", time.Since(start)))
+	})
+}
+
+// statusRecorder 记录响应状态码
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// writeJSON 写入 JSON 响应
+func writeJSON(w http.ResponseWriter, code int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(body)
+}
+
+// formatTime 格式化时间，零值返回空字符串
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,489 @@
+// Package sdk 编写由 Daemon 托管的 Agent 的 Go SDK
+//
+// SDK 封装了托管 Agent 需要实现的协议：
+//   - 从环境变量读取 Daemon 下发的 Agent ID、心跳密钥、心跳地址和控制 Socket
+//   - 通过 HTTP 或 Unix Socket 定时上报（签名）心跳，支持自定义指标
+//   - 控制通道（reload/set_log_level/dump_diagnostics/drain）和 SIGHUP 配置重载
+//   - /health、/reload、/metrics HTTP 接口
+//   - 收到 Daemon 的停止信号后优雅退出
+//
+// 最小示例：
+//
+//	agent, err := sdk.New(sdk.Options{Version: "1.0.0", HTTPAddr: ":8081"})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	agent.OnReload(func(ctx context.Context) error { return reloadMyConfig() })
+//	agent.SetMetricsProvider(func() (map[string]float64, map[string]string) {
+//		return map[string]float64{"queue_depth": float64(queue.Len())}, nil
+//	})
+//	if err := agent.Run(context.Background()); err != nil {
+//		log.Fatal(err)
+//	}
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// defaultHeartbeatInterval 默认心跳间隔
+	defaultHeartbeatInterval = 30 * time.Second
+
+	// defaultShutdownTimeout 默认优雅退出超时
+	defaultShutdownTimeout = 10 * time.Second
+
+	// unhealthyHeartbeats 心跳连续失败多少个周期后健康检查返回 unhealthy
+	unhealthyHeartbeats = 3
+)
+
+// Options Agent 选项
+// 未设置的 AgentID、HeartbeatSecret、HeartbeatURL、HeartbeatSocket、ControlSocket 从 Daemon 下发的环境变量读取
+type Options struct {
+	// AgentID Agent 唯一标识（默认 OPS_AGENT_ID）
+	AgentID string
+
+	// Version Agent 版本号，随心跳上报
+	Version string
+
+	// HeartbeatURL Daemon HTTP 心跳地址（默认 OPS_DAEMON_HEARTBEAT_URL），设置后优先于 HeartbeatSocket
+	HeartbeatURL string
+
+	// HeartbeatSocket Daemon Unix Socket 心跳路径（默认 OPS_DAEMON_HEARTBEAT_SOCKET）
+	HeartbeatSocket string
+
+	// HeartbeatSecret 心跳签名密钥（默认 OPS_AGENT_HEARTBEAT_SECRET），为空时发送未签名心跳
+	HeartbeatSecret string
+
+	// HeartbeatInterval 心跳间隔（默认 30 秒）
+	HeartbeatInterval time.Duration
+
+	// HTTPAddr /health、/reload、/metrics 的监听地址（为空时不启用 HTTP 接口）
+	HTTPAddr string
+
+	// ControlSocket 控制通道 Unix Socket 路径（默认 OPS_AGENT_CONTROL_SOCKET，为空时不启用控制通道）
+	ControlSocket string
+
+	// Logger 日志（默认不输出）
+	Logger *zap.Logger
+
+	// LogLevel 可运行时调整的日志级别，设置后支持 set_log_level 控制命令
+	LogLevel *zap.AtomicLevel
+
+	// StopSignals 触发优雅退出的信号（默认 SIGINT、SIGTERM、SIGQUIT）
+	StopSignals []os.Signal
+
+	// SignalCommands 信号到控制命令的映射，用于 Daemon 控制通道不可用时的信号回退（默认 SIGHUP → reload）
+	SignalCommands map[os.Signal]string
+
+	// ShutdownTimeout 优雅退出超时（默认 10 秒）
+	ShutdownTimeout time.Duration
+}
+
+// Agent 托管 Agent 运行时
+type Agent struct {
+	opts      Options
+	logger    *zap.Logger
+	startTime time.Time
+
+	heartbeat  *heartbeater
+	control    *controlServer
+	mux        *http.ServeMux
+	httpServer *http.Server
+
+	draining atomic.Bool
+
+	mu         sync.Mutex
+	onReload   []func(ctx context.Context) error
+	onDrain    []func(ctx context.Context) error
+	onShutdown []func(ctx context.Context) error
+	started    bool
+	stopped    bool
+}
+
+// New 创建 Agent
+func New(opts Options) (*Agent, error) {
+	env := LoadEnv()
+	if opts.AgentID == "" {
+		opts.AgentID = env.AgentID
+	}
+	if opts.HeartbeatSecret == "" {
+		opts.HeartbeatSecret = env.HeartbeatSecret
+	}
+	if opts.HeartbeatURL == "" {
+		opts.HeartbeatURL = env.HeartbeatURL
+	}
+	if opts.HeartbeatSocket == "" {
+		opts.HeartbeatSocket = env.HeartbeatSocket
+	}
+	if opts.ControlSocket == "" {
+		opts.ControlSocket = env.ControlSocket
+	}
+	if opts.HeartbeatInterval == 0 {
+		opts.HeartbeatInterval = defaultHeartbeatInterval
+	}
+	if opts.ShutdownTimeout == 0 {
+		opts.ShutdownTimeout = defaultShutdownTimeout
+	}
+	if opts.StopSignals == nil {
+		opts.StopSignals = []os.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT}
+	}
+	if opts.SignalCommands == nil {
+		opts.SignalCommands = map[os.Signal]string{syscall.SIGHUP: CommandReload}
+	}
+	if opts.Logger == nil {
+		opts.Logger = zap.NewNop()
+	}
+
+	if opts.AgentID == "" {
+		return nil, fmt.Errorf("agent id is required (set Options.AgentID or %s)", EnvAgentID)
+	}
+	if opts.HeartbeatInterval < 0 {
+		return nil, fmt.Errorf("heartbeat interval must be greater than 0")
+	}
+	for sig, command := range opts.SignalCommands {
+		if command != CommandReload && command != CommandDumpDiagnostics && command != CommandDrain {
+			return nil, fmt.Errorf("signal %s: unsupported command %q", sig, command)
+		}
+	}
+
+	a := &Agent{
+		opts:      opts,
+		logger:    opts.Logger.With(zap.String("agent_id", opts.AgentID)),
+		startTime: time.Now(),
+		mux:       http.NewServeMux(),
+	}
+	a.heartbeat = newHeartbeater(&a.opts, a.logger)
+	a.control = newControlServer(opts.ControlSocket, a.logger)
+	a.registerRoutes()
+	a.registerControlHandlers()
+
+	return a, nil
+}
+
+// AgentID 返回 Agent ID
+func (a *Agent) AgentID() string {
+	return a.opts.AgentID
+}
+
+// Logger 返回带 agent_id 字段的日志
+func (a *Agent) Logger() *zap.Logger {
+	return a.logger
+}
+
+// OnReload 注册配置重载回调（HTTP /reload、控制通道 reload 和 SIGHUP 共用），按注册顺序执行
+func (a *Agent) OnReload(fn func(ctx context.Context) error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.onReload = append(a.onReload, fn)
+}
+
+// OnDrain 注册排空回调（控制通道 drain），例如停止拉取新任务
+func (a *Agent) OnDrain(fn func(ctx context.Context) error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.onDrain = append(a.onDrain, fn)
+}
+
+// OnShutdown 注册退出回调，在发送最后一次心跳之后、HTTP 接口关闭之前执行
+func (a *Agent) OnShutdown(fn func(ctx context.Context) error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.onShutdown = append(a.onShutdown, fn)
+}
+
+// SetMetricsProvider 设置自定义指标提供函数，返回值随每次心跳上报并在 /metrics 中展示
+func (a *Agent) SetMetricsProvider(provider MetricsProvider) {
+	a.heartbeat.setProvider(provider)
+}
+
+// HandleControl 注册控制命令处理函数，可覆盖内置的 reload/set_log_level/dump_diagnostics/drain
+func (a *Agent) HandleControl(command string, handler ControlHandler) {
+	a.control.handle(command, handler)
+}
+
+// HandleHTTP 在 Agent 的 HTTP 服务上注册额外的接口（需在 Start 之前调用）
+func (a *Agent) HandleHTTP(pattern string, handler http.Handler) {
+	a.mux.Handle(pattern, handler)
+}
+
+// HeartbeatStats 返回心跳发送统计
+func (a *Agent) HeartbeatStats() HeartbeatStats {
+	return a.heartbeat.getStats()
+}
+
+// IsDraining 是否已排空
+func (a *Agent) IsDraining() bool {
+	return a.draining.Load()
+}
+
+// Reload 执行配置重载回调
+func (a *Agent) Reload(ctx context.Context) error {
+	a.logger.Info("reloading configuration...")
+	for _, fn := range a.callbacks(&a.onReload) {
+		if err := fn(ctx); err != nil {
+			a.logger.Error("config reload failed", zap.Error(err))
+			return err
+		}
+	}
+	a.logger.Info("configuration reloaded")
+	return nil
+}
+
+// Drain 排空 Agent：执行排空回调，之后健康检查返回 draining，心跳上报 draining 状态
+func (a *Agent) Drain(ctx context.Context) error {
+	for _, fn := range a.callbacks(&a.onDrain) {
+		if err := fn(ctx); err != nil {
+			return err
+		}
+	}
+	a.draining.Store(true)
+	a.heartbeat.setStatus("draining")
+	a.logger.Info("agent drained")
+	return nil
+}
+
+// Start 启动控制通道、HTTP 接口和心跳（非阻塞）
+func (a *Agent) Start() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.started {
+		return errors.New("agent already started")
+	}
+
+	if a.opts.ControlSocket != "" {
+		if err := a.control.start(); err != nil {
+			return err
+		}
+	}
+
+	if a.opts.HTTPAddr != "" {
+		listener, err := net.Listen("tcp", a.opts.HTTPAddr)
+		if err != nil {
+			if a.opts.ControlSocket != "" {
+				a.control.stop()
+			}
+			return fmt.Errorf("failed to listen on %s: %w", a.opts.HTTPAddr, err)
+		}
+		a.httpServer = &http.Server{Handler: a.loggingMiddleware(a.mux)}
+		a.logger.Info("starting HTTP server", zap.String("addr", listener.Addr().String()))
+		go func() {
+			if err := a.httpServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+				a.logger.Error("HTTP server error", zap.Error(err))
+			}
+		}()
+	}
+
+	a.heartbeat.start()
+	a.started = true
+
+	a.logger.Info("agent started",
+		zap.String("version", a.opts.Version),
+		zap.Int("pid", os.Getpid()),
+		zap.String("http_addr", a.opts.HTTPAddr),
+		zap.String("control_socket", a.opts.ControlSocket))
+	return nil
+}
+
+// Shutdown 优雅退出：发送最后一次心跳（status="stopping"），关闭控制通道，执行退出回调并关闭 HTTP 接口
+func (a *Agent) Shutdown(ctx context.Context) error {
+	a.mu.Lock()
+	if !a.started || a.stopped {
+		a.mu.Unlock()
+		return nil
+	}
+	a.stopped = true
+	a.mu.Unlock()
+
+	a.logger.Info("shutting down gracefully")
+
+	a.heartbeat.stop()
+	if a.opts.ControlSocket != "" {
+		a.control.stop()
+	}
+
+	// 返回第一个错误，其余错误只记录日志
+	var firstErr error
+	for _, fn := range a.callbacks(&a.onShutdown) {
+		if err := fn(ctx); err != nil {
+			a.logger.Error("shutdown callback failed", zap.Error(err))
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	if a.httpServer != nil {
+		if err := a.httpServer.Shutdown(ctx); err != nil {
+			a.logger.Error("failed to stop HTTP server gracefully", zap.Error(err))
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	a.logger.Info("graceful shutdown completed")
+	return firstErr
+}
+
+// Run 启动 Agent 并阻塞，直到 ctx 取消或收到停止信号后优雅退出
+// SignalCommands 中的信号（默认 SIGHUP）执行对应的控制命令
+func (a *Agent) Run(ctx context.Context) error {
+	if err := a.Start(); err != nil {
+		return err
+	}
+
+	stopCh := make(chan os.Signal, 1)
+	signal.Notify(stopCh, a.opts.StopSignals...)
+	defer signal.Stop(stopCh)
+
+	commandCh := make(chan os.Signal, 1)
+	if len(a.opts.SignalCommands) > 0 {
+		signals := make([]os.Signal, 0, len(a.opts.SignalCommands))
+		for sig := range a.opts.SignalCommands {
+			signals = append(signals, sig)
+		}
+		signal.Notify(commandCh, signals...)
+		defer signal.Stop(commandCh)
+	}
+
+wait:
+	for {
+		select {
+		case <-ctx.Done():
+			a.logger.Info("context canceled, stopping")
+			break wait
+		case sig := <-stopCh:
+			a.logger.Info("received shutdown signal", zap.String("signal", sig.String()))
+			break wait
+		case sig := <-commandCh:
+			command := a.opts.SignalCommands[sig]
+			a.logger.Info("received control signal",
+				zap.String("signal", sig.String()),
+				zap.String("command", command))
+			message, data, err := a.control.call(ctx, command, nil)
+			if err != nil {
+				a.logger.Error("control command failed",
+					zap.String("command", command),
+					zap.Error(err))
+				continue
+			}
+			a.logger.Info("control signal handled",
+				zap.String("command", command),
+				zap.String("message", message),
+				zap.Any("data", data))
+		}
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), a.opts.ShutdownTimeout)
+	defer cancel()
+	return a.Shutdown(shutdownCtx)
+}
+
+// status 返回当前运行状态: running/draining/unhealthy
+func (a *Agent) status() string {
+	if a.draining.Load() {
+		return "draining"
+	}
+	if a.heartbeat.enabled() {
+		last := a.heartbeat.getStats().LastSuccess
+		if last.IsZero() {
+			last = a.startTime
+		}
+		if time.Since(last) > unhealthyHeartbeats*a.opts.HeartbeatInterval {
+			return "unhealthy"
+		}
+	}
+	return "running"
+}
+
+// callbacks 复制回调列表，避免执行回调时持锁
+func (a *Agent) callbacks(list *[]func(ctx context.Context) error) []func(ctx context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]func(ctx context.Context) error(nil), (*list)...)
+}
+
+// registerControlHandlers 注册内置的控制命令
+func (a *Agent) registerControlHandlers() {
+	a.control.handle(CommandReload, func(ctx context.Context, args map[string]string) (string, map[string]string, error) {
+		if err := a.Reload(ctx); err != nil {
+			return "", nil, err
+		}
+		return "config reloaded", nil, nil
+	})
+
+	if a.opts.LogLevel != nil {
+		a.control.handle(CommandSetLogLevel, func(ctx context.Context, args map[string]string) (string, map[string]string, error) {
+			level := args["level"]
+			if level == "" {
+				return "", nil, &InvalidArgsError{Message: "args.level is required"}
+			}
+			var zapLevel zapcore.Level
+			if err := zapLevel.UnmarshalText([]byte(level)); err != nil {
+				return "", nil, &InvalidArgsError{Message: "invalid log level: " + level}
+			}
+			a.opts.LogLevel.SetLevel(zapLevel)
+			a.logger.Info("log level changed", zap.String("level", zapLevel.String()))
+			return "log level set to " + zapLevel.String(), map[string]string{"level": zapLevel.String()}, nil
+		})
+	}
+
+	a.control.handle(CommandDumpDiagnostics, func(ctx context.Context, args map[string]string) (string, map[string]string, error) {
+		return "diagnostics collected", a.Diagnostics(), nil
+	})
+
+	a.control.handle(CommandDrain, func(ctx context.Context, args map[string]string) (string, map[string]string, error) {
+		if err := a.Drain(ctx); err != nil {
+			return "", nil, err
+		}
+		return "agent drained", map[string]string{"draining": "true"}, nil
+	})
+}
+
+// Diagnostics 返回运行时诊断信息（dump_diagnostics 控制命令的默认输出）
+func (a *Agent) Diagnostics() map[string]string {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	stats := a.heartbeat.getStats()
+
+	data := map[string]string{
+		"version":             a.opts.Version,
+		"pid":                 strconv.Itoa(os.Getpid()),
+		"uptime_seconds":      strconv.FormatInt(int64(time.Since(a.startTime).Seconds()), 10),
+		"goroutines":          strconv.Itoa(runtime.NumGoroutine()),
+		"heap_alloc_bytes":    strconv.FormatUint(mem.HeapAlloc, 10),
+		"heap_objects":        strconv.FormatUint(mem.HeapObjects, 10),
+		"num_gc":              strconv.FormatUint(uint64(mem.NumGC), 10),
+		"cpu_percent":         strconv.FormatFloat(stats.CPU, 'f', 2, 64),
+		"memory_bytes":        strconv.FormatUint(stats.Memory, 10),
+		"heartbeat_transport": stats.Transport,
+		"heartbeat_count":     strconv.FormatInt(stats.Count, 10),
+		"heartbeat_failures":  strconv.FormatInt(stats.Failures, 10),
+		"last_heartbeat":      formatTime(stats.LastSuccess),
+		"status":              a.status(),
+		"draining":            strconv.FormatBool(a.IsDraining()),
+	}
+	if stats.LastError != "" {
+		data["last_heartbeat_error"] = stats.LastError
+	}
+	if a.opts.LogLevel != nil {
+		data["log_level"] = a.opts.LogLevel.Level().String()
+	}
+	return data
+}
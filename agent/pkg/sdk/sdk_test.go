@@ -0,0 +1,250 @@
+package sdk
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// heartbeatRecorder 记录收到的 HTTP 心跳状态
+type heartbeatRecorder struct {
+	mu       sync.Mutex
+	statuses []string
+}
+
+func (r *heartbeatRecorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var hb Heartbeat
+	body, _ := io.ReadAll(req.Body)
+	if err := json.Unmarshal(body, &hb); err == nil {
+		r.mu.Lock()
+		r.statuses = append(r.statuses, hb.Status)
+		r.mu.Unlock()
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (r *heartbeatRecorder) last() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.statuses) == 0 {
+		return ""
+	}
+	return r.statuses[len(r.statuses)-1]
+}
+
+// signalUntil 重复向本进程发送信号直到 done 关闭
+// 测试先自行 signal.Notify，避免 Run 注册前到达的信号触发默认行为
+func signalUntil(t *testing.T, sig syscall.Signal, done <-chan struct{}) {
+	t.Helper()
+	guard := make(chan os.Signal, 16)
+	signal.Notify(guard, sig)
+	defer signal.Stop(guard)
+
+	ticker := time.NewTicker(20 * time.Millisecond)
+	defer ticker.Stop()
+	timeout := time.After(5 * time.Second)
+	for {
+		syscall.Kill(os.Getpid(), sig)
+		select {
+		case <-done:
+			return
+		case <-timeout:
+			t.Fatalf("timed out waiting for %s to be handled", sig)
+		case <-ticker.C:
+		}
+	}
+}
+
+func newTestAgent(t *testing.T, opts Options) *Agent {
+	t.Helper()
+	if opts.AgentID == "" {
+		opts.AgentID = "agent-1"
+	}
+	for _, name := range []string{EnvHeartbeatSecret, EnvHeartbeatURL, EnvHeartbeatSocket, EnvControlSocket} {
+		t.Setenv(name, "")
+	}
+	agent, err := New(opts)
+	if err != nil {
+		t.Fatalf("failed to create agent: %v", err)
+	}
+	return agent
+}
+
+func TestReloadCallbacks(t *testing.T) {
+	agent := newTestAgent(t, Options{})
+
+	var calls []int
+	agent.OnReload(func(ctx context.Context) error {
+		calls = append(calls, 1)
+		return nil
+	})
+	agent.OnReload(func(ctx context.Context) error {
+		calls = append(calls, 2)
+		return nil
+	})
+	if err := agent.Reload(context.Background()); err != nil {
+		t.Fatalf("reload failed: %v", err)
+	}
+	if len(calls) != 2 || calls[0] != 1 || calls[1] != 2 {
+		t.Fatalf("callbacks should run in registration order: %v", calls)
+	}
+
+	reloadErr := errors.New("bad config")
+	agent.OnReload(func(ctx context.Context) error { return reloadErr })
+	if err := agent.Reload(context.Background()); !errors.Is(err, reloadErr) {
+		t.Fatalf("expected callback error, got %v", err)
+	}
+}
+
+func TestReloadHTTP(t *testing.T) {
+	agent := newTestAgent(t, Options{})
+	var reloaded atomic.Int32
+	agent.OnReload(func(ctx context.Context) error {
+		reloaded.Add(1)
+		return nil
+	})
+
+	rec := httptest.NewRecorder()
+	agent.mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/reload", nil))
+	if rec.Code != http.StatusOK || reloaded.Load() != 1 {
+		t.Fatalf("unexpected reload response: %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	agent.mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/reload", nil))
+	if rec.Code != http.StatusMethodNotAllowed || reloaded.Load() != 1 {
+		t.Fatalf("GET /reload should be rejected: %d", rec.Code)
+	}
+
+	agent.OnReload(func(ctx context.Context) error { return errors.New("bad config") })
+	rec = httptest.NewRecorder()
+	agent.mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/reload", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("failed reload should return 500: %d", rec.Code)
+	}
+}
+
+func TestReloadControlSocket(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "control.sock")
+	agent := newTestAgent(t, Options{ControlSocket: socketPath})
+	var reloaded atomic.Int32
+	agent.OnReload(func(ctx context.Context) error {
+		reloaded.Add(1)
+		return nil
+	})
+	if err := agent.Start(); err != nil {
+		t.Fatalf("failed to start agent: %v", err)
+	}
+	defer agent.Shutdown(context.Background())
+
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatalf("failed to dial control socket: %v", err)
+	}
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+
+	call := func(req controlRequest) controlResponse {
+		data, _ := json.Marshal(req)
+		if _, err := conn.Write(append(data, '\n')); err != nil {
+			t.Fatalf("failed to write request: %v", err)
+		}
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			t.Fatalf("failed to read response: %v", err)
+		}
+		var resp controlResponse
+		if err := json.Unmarshal(line, &resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return resp
+	}
+
+	resp := call(controlRequest{ID: "1", Command: CommandReload})
+	if !resp.Success || resp.ID != "1" || reloaded.Load() != 1 {
+		t.Fatalf("unexpected reload response: %+v", resp)
+	}
+
+	resp = call(controlRequest{ID: "2", Command: "unknown"})
+	if resp.Success || resp.Code != CodeUnknownCommand {
+		t.Fatalf("unexpected unknown command response: %+v", resp)
+	}
+}
+
+func TestRunReloadOnSignal(t *testing.T) {
+	agent := newTestAgent(t, Options{
+		StopSignals:    []os.Signal{syscall.SIGUSR1},
+		SignalCommands: map[os.Signal]string{syscall.SIGUSR2: CommandReload},
+	})
+	reloaded := make(chan struct{})
+	var once sync.Once
+	agent.OnReload(func(ctx context.Context) error {
+		once.Do(func() { close(reloaded) })
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() { result <- agent.Run(ctx) }()
+
+	signalUntil(t, syscall.SIGUSR2, reloaded)
+	cancel()
+	if err := <-result; err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+}
+
+func TestRunShutdownOnStopSignal(t *testing.T) {
+	recorder := &heartbeatRecorder{}
+	server := httptest.NewServer(recorder)
+	defer server.Close()
+
+	agent := newTestAgent(t, Options{
+		HeartbeatURL:      server.URL,
+		HeartbeatInterval: time.Hour,
+		HTTPAddr:          "127.0.0.1:0",
+		StopSignals:       []os.Signal{syscall.SIGUSR1},
+		SignalCommands:    map[os.Signal]string{},
+	})
+	var shutdownCalls atomic.Int32
+	agent.OnShutdown(func(ctx context.Context) error {
+		shutdownCalls.Add(1)
+		return nil
+	})
+
+	result := make(chan error, 1)
+	done := make(chan struct{})
+	go func() {
+		result <- agent.Run(context.Background())
+		close(done)
+	}()
+
+	signalUntil(t, syscall.SIGUSR1, done)
+	if err := <-result; err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if shutdownCalls.Load() != 1 {
+		t.Errorf("shutdown callback should run once, got %d", shutdownCalls.Load())
+	}
+	if status := recorder.last(); status != "stopping" {
+		t.Errorf("final heartbeat should report stopping, got %q", status)
+	}
+
+	// 重复调用 Shutdown 不再执行回调
+	if err := agent.Shutdown(context.Background()); err != nil || shutdownCalls.Load() != 1 {
+		t.Errorf("repeated shutdown should be a no-op: %v", err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/bingooyong/ops-scaffold-framework/daemon/internal/agent"
 	"github.com/bingooyong/ops-scaffold-framework/daemon/internal/config"
 	"github.com/bingooyong/ops-scaffold-framework/daemon/internal/daemon"
 	"github.com/bingooyong/ops-scaffold-framework/daemon/internal/logger"
@@ -17,6 +18,11 @@ var (
 )
 
 func main() {
+	// Agent启动辅助进程模式(daemon内部使用: 设置umask和资源限制后exec Agent二进制)
+	if len(os.Args) > 1 && os.Args[1] == agent.ExecHelperArg {
+		agent.RunExecHelper(os.Args[2:])
+	}
+
 	flag.Parse()
 
 	// 打印版本
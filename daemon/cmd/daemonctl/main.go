@@ -5,7 +5,10 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"google.golang.org/grpc"
@@ -16,11 +19,13 @@ import (
 
 const (
 	defaultAddress = "localhost:9091"
+	defaultSocket  = "/var/run/daemon.sock"
 	defaultTimeout = 30 * time.Second
 )
 
 var (
-	address = flag.String("address", defaultAddress, "Daemon gRPC server address")
+	address = flag.String("address", defaultAddress, "Daemon gRPC server address (TCP, used only when set explicitly)")
+	socket  = flag.String("socket", defaultSocket, "Daemon local unix socket path")
 	timeout = flag.Duration("timeout", defaultTimeout, "Request timeout")
 )
 
@@ -36,7 +41,11 @@ func main() {
   start <agent-id>       启动指定的Agent
   stop <agent-id>        停止指定的Agent
   restart <agent-id>     重启指定的Agent
+  reset <agent-id>       复位指定Agent的崩溃熔断器
   status <agent-id>      查看指定Agent的状态
+  crashes <agent-id> [n] 查看指定Agent最近n次崩溃记录(默认10)
+  logs [-n 行数] [-k 关键词] [-f] <agent-id>
+                         查看指定Agent日志末尾(默认100行)，-f 持续跟踪新增日志
 
 选项:
 `)
@@ -44,10 +53,15 @@ func main() {
 		fmt.Fprintf(os.Stderr, `
 示例:
   daemonctl list
+  daemonctl -socket ./tmp/daemon.sock list
   daemonctl start agent-001
   daemonctl stop agent-002
   daemonctl restart agent-001
+  daemonctl reset agent-001
   daemonctl status agent-001
+  daemonctl crashes agent-001 5
+  daemonctl logs -n 50 -k ERROR agent-001
+  daemonctl logs -f agent-001
 `)
 	}
 
@@ -60,13 +74,19 @@ func main() {
 
 	command := flag.Arg(0)
 
-	// 连接到Daemon
-	conn, err := grpc.Dial(*address,
+	// 连接到Daemon：默认通过本地Unix socket(按调用者uid授权)，显式指定-address时使用TCP
+	target := "unix://" + *socket
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "address" {
+			target = *address
+		}
+	})
+	conn, err := grpc.Dial(target,
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
 		grpc.WithBlock(),
 		grpc.WithTimeout(10*time.Second))
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "错误: 无法连接到Daemon (%s): %v\n", *address, err)
+		fmt.Fprintf(os.Stderr, "错误: 无法连接到Daemon (%s): %v\n", target, err)
 		os.Exit(1)
 	}
 	defer conn.Close()
@@ -98,12 +118,51 @@ func main() {
 			os.Exit(1)
 		}
 		err = operateAgent(ctx, client, flag.Arg(1), "restart")
+	case "reset":
+		if flag.NArg() < 2 {
+			fmt.Fprintf(os.Stderr, "错误: reset命令需要agent-id参数\n")
+			os.Exit(1)
+		}
+		err = operateAgent(ctx, client, flag.Arg(1), "reset")
 	case "status":
 		if flag.NArg() < 2 {
 			fmt.Fprintf(os.Stderr, "错误: status命令需要agent-id参数\n")
 			os.Exit(1)
 		}
 		err = getAgentStatus(ctx, client, flag.Arg(1))
+	case "crashes":
+		if flag.NArg() < 2 {
+			fmt.Fprintf(os.Stderr, "错误: crashes命令需要agent-id参数\n")
+			os.Exit(1)
+		}
+		limit := 10
+		if flag.NArg() >= 3 {
+			n, convErr := strconv.Atoi(flag.Arg(2))
+			if convErr != nil || n <= 0 {
+				fmt.Fprintf(os.Stderr, "错误: 无效的记录数 '%s'\n", flag.Arg(2))
+				os.Exit(1)
+			}
+			limit = n
+		}
+		err = getCrashReports(ctx, client, flag.Arg(1), limit)
+	case "logs":
+		logsFlags := flag.NewFlagSet("logs", flag.ExitOnError)
+		lines := logsFlags.Int("n", 100, "显示的行数")
+		keyword := logsFlags.String("k", "", "只显示包含关键词的行")
+		follow := logsFlags.Bool("f", false, "持续跟踪新增日志")
+		logsFlags.Parse(flag.Args()[1:])
+		if logsFlags.NArg() < 1 {
+			fmt.Fprintf(os.Stderr, "错误: logs命令需要agent-id参数\n")
+			os.Exit(1)
+		}
+		if *follow {
+			// 跟踪模式不设超时，Ctrl+C退出
+			followCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			err = followAgentLogs(followCtx, client, logsFlags.Arg(0), *lines, *keyword)
+			stop()
+		} else {
+			err = tailAgentLogs(ctx, client, logsFlags.Arg(0), *lines, *keyword)
+		}
 	default:
 		fmt.Fprintf(os.Stderr, "错误: 未知命令 '%s'\n", command)
 		flag.Usage()
@@ -225,3 +284,90 @@ func getAgentStatus(ctx context.Context, client pb.DaemonServiceClient, agentID
 
 	return fmt.Errorf("未找到Agent: %s", agentID)
 }
+
+// getCrashReports 获取指定Agent最近的崩溃记录
+func getCrashReports(ctx context.Context, client pb.DaemonServiceClient, agentID string, limit int) error {
+	resp, err := client.GetCrashReports(ctx, &pb.GetCrashReportsRequest{
+		AgentId: agentID,
+		Limit:   int32(limit),
+	})
+	if err != nil {
+		return fmt.Errorf("获取崩溃记录失败: %w", err)
+	}
+
+	if len(resp.Crashes) == 0 {
+		fmt.Printf("Agent '%s' 没有崩溃记录\n", agentID)
+		return nil
+	}
+
+	fmt.Printf("Agent '%s' 最近 %d 次崩溃记录:\n", agentID, len(resp.Crashes))
+	for i, crash := range resp.Crashes {
+		exitedAt := time.Unix(crash.ExitedAt, 0).Format("2006-01-02 15:04:05")
+		exitInfo := fmt.Sprintf("退出码 %d", crash.ExitCode)
+		if crash.Signal != "" {
+			exitInfo = fmt.Sprintf("信号 %s", crash.Signal)
+		}
+		duration := time.Duration(crash.DurationMs) * time.Millisecond
+
+		fmt.Printf("\n[%d] %s  %s  运行时长: %v", i+1, exitedAt, exitInfo, duration)
+		if crash.CircuitOpen {
+			fmt.Printf("  (触发熔断)")
+		}
+		fmt.Println()
+
+		if len(crash.LastLogLines) == 0 {
+			fmt.Println("  (无日志)")
+			continue
+		}
+		for _, line := range crash.LastLogLines {
+			fmt.Printf("  | %s\n", line)
+		}
+	}
+
+	return nil
+}
+
+// tailAgentLogs 显示Agent日志末尾
+func tailAgentLogs(ctx context.Context, client pb.DaemonServiceClient, agentID string, lines int, keyword string) error {
+	resp, err := client.TailAgentLogs(ctx, &pb.TailAgentLogsRequest{
+		AgentId: agentID,
+		Lines:   int32(lines),
+		Keyword: keyword,
+	})
+	if err != nil {
+		return fmt.Errorf("获取日志失败: %w", err)
+	}
+
+	for _, line := range resp.Lines {
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// followAgentLogs 持续跟踪Agent新增日志
+func followAgentLogs(ctx context.Context, client pb.DaemonServiceClient, agentID string, lines int, keyword string) error {
+	stream, err := client.FollowAgentLogs(ctx, &pb.FollowAgentLogsRequest{
+		AgentId:   agentID,
+		TailLines: int32(lines),
+		Keyword:   keyword,
+	})
+	if err != nil {
+		return fmt.Errorf("跟踪日志失败: %w", err)
+	}
+
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("跟踪日志失败: %w", err)
+		}
+		if resp.Rotated {
+			fmt.Fprintln(os.Stderr, "--- 日志文件已轮转 ---")
+		}
+		for _, line := range resp.Lines {
+			fmt.Println(line)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// adoptedProcessPollInterval 被接管进程的存活检查间隔
+// 被接管的进程不是daemon的子进程，无法通过Wait获取退出事件，只能轮询
+const adoptedProcessPollInterval = 500 * time.Millisecond
+
+// ProcessState Agent进程状态
+// 在Agent启动时持久化，daemon重启后用于识别并接管仍在运行的Agent进程
+type ProcessState struct {
+	// PID 进程ID
+	PID int `json:"pid"`
+
+	// StartTime 进程启动时间(/proc/<pid>/stat 的starttime字段)，用于识别PID复用
+	StartTime uint64 `json:"start_time"`
+
+	// BinaryPath 启动时配置的二进制路径
+	BinaryPath string `json:"binary_path"`
+
+	// Exe 启动后进程实际的可执行文件(/proc/<pid>/exe，脚本类Agent为解释器)
+	Exe string `json:"exe"`
+
+	// StartedAt 启动时间
+	StartedAt time.Time `json:"started_at"`
+
+	// HeartbeatNonce 派生心跳密钥使用的随机数(未启用心跳认证时为空)
+	HeartbeatNonce string `json:"heartbeat_nonce,omitempty"`
+}
+
+// processStat 从/proc读取的进程信息
+type processStat struct {
+	// State 进程状态(R/S/D/Z等)
+	State string
+
+	// StartTime 进程启动时间(系统启动后的时钟节拍数)
+	StartTime uint64
+}
+
+// readProcessStartTime 读取进程启动时间，已退出未回收的僵尸进程视为不存在
+func readProcessStartTime(pid int) (uint64, error) {
+	stat, err := readProcessStat(pid)
+	if err != nil {
+		return 0, err
+	}
+	if stat.State == "Z" {
+		return 0, fmt.Errorf("process %d is a zombie", pid)
+	}
+	return stat.StartTime, nil
+}
+
+// ProcessStateStore 进程状态存储接口
+type ProcessStateStore interface {
+	// SaveProcessState 保存指定Agent的进程状态
+	SaveProcessState(agentID string, state *ProcessState) error
+
+	// DeleteProcessState 删除指定Agent的进程状态
+	DeleteProcessState(agentID string) error
+}
+
+// AdoptResult 进程接管检查结果
+type AdoptResult string
+
+const (
+	// AdoptResultGone 进程已退出或PID已被其他进程复用，需要重新启动
+	AdoptResultGone AdoptResult = "gone"
+
+	// AdoptResultAdoptable 进程仍在运行且二进制未变化，可以直接接管
+	AdoptResultAdoptable AdoptResult = "adoptable"
+
+	// AdoptResultStale 进程仍在运行但二进制已变化(如已升级)，需要停止后重新启动
+	AdoptResultStale AdoptResult = "stale"
+)
+
+// newProcessState 读取运行中进程的启动时间和可执行文件，构建进程状态
+func newProcessState(pid int, binaryPath string, startedAt time.Time) (*ProcessState, error) {
+	startTime, err := readProcessStartTime(pid)
+	if err != nil {
+		return nil, err
+	}
+	exe, err := readProcessExe(pid)
+	if err != nil {
+		return nil, err
+	}
+	return &ProcessState{
+		PID:        pid,
+		StartTime:  startTime,
+		BinaryPath: binaryPath,
+		Exe:        exe,
+		StartedAt:  startedAt,
+	}, nil
+}
+
+// CheckProcessState 检查持久化的进程状态对应的进程是否可以接管
+// 依次检查PID是否存活、进程启动时间是否一致(排除PID复用)、二进制是否未变化
+func CheckProcessState(state *ProcessState, binaryPath string) AdoptResult {
+	if state == nil || state.PID <= 0 {
+		return AdoptResultGone
+	}
+
+	// 信号0检查进程是否存在(EPERM说明进程属于其他用户，不是daemon启动的进程)
+	if err := syscall.Kill(state.PID, syscall.Signal(0)); err != nil {
+		return AdoptResultGone
+	}
+
+	startTime, err := readProcessStartTime(state.PID)
+	if err != nil || startTime != state.StartTime {
+		return AdoptResultGone
+	}
+
+	// 配置的二进制路径变化，或可执行文件被替换/删除(路径带有" (deleted)"后缀)
+	exe, err := readProcessExe(state.PID)
+	if err != nil {
+		return AdoptResultGone
+	}
+	if state.BinaryPath != binaryPath || exe != state.Exe || strings.HasSuffix(exe, " (deleted)") {
+		return AdoptResultStale
+	}
+	return AdoptResultAdoptable
+}
+
+// SetProcessStateStore 设置进程状态存储
+func (ai *AgentInstance) SetProcessStateStore(store ProcessStateStore) {
+	ai.mu.Lock()
+	defer ai.mu.Unlock()
+	ai.processStore = store
+}
+
+// saveProcessStateLocked 持久化当前进程状态(需要持锁调用)
+// 无法读取进程信息时(非Linux平台)不保存，daemon重启后将重新启动Agent
+func (ai *AgentInstance) saveProcessStateLocked(pid int, startedAt time.Time) {
+	if ai.processStore == nil {
+		return
+	}
+
+	state, err := newProcessState(pid, ai.info.BinaryPath, startedAt)
+	if err != nil {
+		ai.logger.Debug("failed to read process info, process state not saved",
+			zap.String("agent_id", ai.info.ID),
+			zap.Int("pid", pid),
+			zap.Error(err))
+		return
+	}
+	state.HeartbeatNonce = ai.heartbeatNonce
+
+	if err := ai.processStore.SaveProcessState(ai.info.ID, state); err != nil {
+		ai.logger.Warn("failed to save process state",
+			zap.String("agent_id", ai.info.ID),
+			zap.Int("pid", pid),
+			zap.Error(err))
+	}
+}
+
+// deleteProcessStateLocked 删除持久化的进程状态(需要持锁调用)
+func (ai *AgentInstance) deleteProcessStateLocked() {
+	if ai.processStore == nil {
+		return
+	}
+	if err := ai.processStore.DeleteProcessState(ai.info.ID); err != nil {
+		ai.logger.Warn("failed to delete process state",
+			zap.String("agent_id", ai.info.ID),
+			zap.Error(err))
+	}
+}
+
+// Adopt 接管daemon重启前启动的、仍在运行的Agent进程
+// 调用者需要先通过CheckProcessState确认进程可以接管
+func (ai *AgentInstance) Adopt(state *ProcessState) error {
+	ai.mu.Lock()
+	defer ai.mu.Unlock()
+
+	if ai.isRunningLocked() {
+		return fmt.Errorf("agent already running with pid %d", ai.info.GetPID())
+	}
+
+	proc, err := os.FindProcess(state.PID)
+	if err != nil {
+		return fmt.Errorf("failed to find process %d: %w", state.PID, err)
+	}
+
+	exited := make(chan struct{})
+	ai.process = proc
+	ai.exited = exited
+	ai.manuallyStopped = false
+	ai.info.SetPID(state.PID)
+	ai.info.SetStatus(StatusRunning)
+
+	// 恢复心跳密钥，接管的Agent继续使用启动时下发的密钥
+	ai.heartbeatNonce = state.HeartbeatNonce
+	if ai.heartbeatAuth != nil {
+		ai.heartbeatAuth.Restore(ai.info.ID, state.HeartbeatNonce)
+	}
+
+	ai.logger.Info("agent process adopted",
+		zap.String("agent_id", ai.info.ID),
+		zap.String("agent_type", string(ai.info.Type)),
+		zap.Int("pid", state.PID),
+		zap.Time("started_at", state.StartedAt))
+
+	go ai.watchAdoptedProcess(proc, exited, state)
+
+	return nil
+}
+
+// watchAdoptedProcess 轮询被接管进程的存活状态，进程退出后按意外退出处理
+func (ai *AgentInstance) watchAdoptedProcess(proc *os.Process, exited chan struct{}, state *ProcessState) {
+	ticker := time.NewTicker(adoptedProcessPollInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if adoptedProcessAlive(state) {
+			continue
+		}
+
+		// 被接管的进程无法获取真实退出码
+		exit := newExitStatus(nil, state.StartedAt)
+		close(exited)
+		ai.handleProcessExit(proc, state.PID, exit)
+		return
+	}
+}
+
+// adoptedProcessAlive 检查被接管的进程是否仍在运行(PID存在且启动时间一致)
+func adoptedProcessAlive(state *ProcessState) bool {
+	if err := syscall.Kill(state.PID, syscall.Signal(0)); err != nil {
+		return false
+	}
+	startTime, err := readProcessStartTime(state.PID)
+	if err != nil {
+		return false
+	}
+	return startTime == state.StartTime
+}
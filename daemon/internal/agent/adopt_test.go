@@ -0,0 +1,167 @@
+//go:build linux
+
+package agent
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestReadProcessStartTime(t *testing.T) {
+	startTime, err := readProcessStartTime(os.Getpid())
+	if err != nil {
+		t.Fatalf("failed to read start time of current process: %v", err)
+	}
+	if startTime == 0 {
+		t.Error("expected non-zero start time")
+	}
+
+	// 重复读取结果应一致
+	again, err := readProcessStartTime(os.Getpid())
+	if err != nil || again != startTime {
+		t.Errorf("expected stable start time %d, got %d (err=%v)", startTime, again, err)
+	}
+
+	if _, err := readProcessStartTime(1 << 30); err == nil {
+		t.Error("expected error for non-existent pid")
+	}
+}
+
+func TestCheckProcessState(t *testing.T) {
+	sleepPath, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep not available")
+	}
+
+	cmd := exec.Command(sleepPath, "30")
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start process: %v", err)
+	}
+	defer func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+
+	state, err := newProcessState(cmd.Process.Pid, sleepPath, time.Now())
+	if err != nil {
+		t.Fatalf("failed to read process state: %v", err)
+	}
+
+	if got := CheckProcessState(state, sleepPath); got != AdoptResultAdoptable {
+		t.Errorf("expected %s, got %s", AdoptResultAdoptable, got)
+	}
+
+	// 配置的二进制路径变化
+	if got := CheckProcessState(state, "/usr/local/bin/other"); got != AdoptResultStale {
+		t.Errorf("expected %s for changed binary, got %s", AdoptResultStale, got)
+	}
+
+	// 启动时间不一致说明PID已被复用
+	reused := *state
+	reused.StartTime++
+	if got := CheckProcessState(&reused, sleepPath); got != AdoptResultGone {
+		t.Errorf("expected %s for reused pid, got %s", AdoptResultGone, got)
+	}
+
+	if got := CheckProcessState(nil, sleepPath); got != AdoptResultGone {
+		t.Errorf("expected %s for nil state, got %s", AdoptResultGone, got)
+	}
+
+	// 进程退出后不可接管
+	cmd.Process.Kill()
+	cmd.Wait()
+	if got := CheckProcessState(state, sleepPath); got != AdoptResultGone {
+		t.Errorf("expected %s after exit, got %s", AdoptResultGone, got)
+	}
+}
+
+func TestMultiAgentManager_AdoptRunningAgents(t *testing.T) {
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("/bin/sh not available")
+	}
+
+	workDir := t.TempDir()
+	binaryPath := writeTestAgentScript(t, workDir)
+	newInfo := func() *AgentInfo {
+		return &AgentInfo{
+			ID:         "adopt-agent",
+			Type:       TypeCustom,
+			BinaryPath: binaryPath,
+			WorkDir:    workDir,
+		}
+	}
+
+	// 第一个管理器启动Agent(模拟重启前的daemon)
+	first, err := NewMultiAgentManager(workDir, zap.NewNop())
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+	defer first.Close()
+	if _, err := first.RegisterAgent(newInfo()); err != nil {
+		t.Fatalf("failed to register agent: %v", err)
+	}
+	if err := first.StartAgent(context.Background(), "adopt-agent"); err != nil {
+		t.Fatalf("failed to start agent: %v", err)
+	}
+	pid := first.GetAgent("adopt-agent").GetPID()
+	defer syscall.Kill(-pid, syscall.SIGKILL)
+
+	// 第二个管理器接管仍在运行的Agent(模拟重启后的daemon)
+	second, err := NewMultiAgentManager(workDir, zap.NewNop())
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+	defer second.Close()
+	if _, err := second.RegisterAgent(newInfo()); err != nil {
+		t.Fatalf("failed to register agent: %v", err)
+	}
+
+	if adopted := second.AdoptRunningAgents(context.Background()); adopted != 1 {
+		t.Fatalf("expected 1 adopted agent, got %d", adopted)
+	}
+	instance := second.GetAgent("adopt-agent")
+	if instance.GetPID() != pid {
+		t.Errorf("expected adopted pid %d, got %d", pid, instance.GetPID())
+	}
+	if !instance.IsRunning() {
+		t.Error("expected adopted agent to be running")
+	}
+
+	// 已接管的Agent不会被重复启动
+	if err := instance.Start(context.Background()); err != nil {
+		t.Fatalf("unexpected start error: %v", err)
+	}
+	if instance.GetPID() != pid {
+		t.Errorf("expected agent not to be restarted, pid changed from %d to %d", pid, instance.GetPID())
+	}
+
+	// 被接管的进程退出后应被检测到
+	syscall.Kill(-pid, syscall.SIGKILL)
+	deadline := time.Now().Add(3 * time.Second)
+	for instance.GetLastExit() == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("expected adopted process exit to be detected")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if instance.GetPID() != 0 {
+		t.Errorf("expected pid to be cleared, got %d", instance.GetPID())
+	}
+	if instance.GetInfo().GetStatus() != StatusStopped {
+		t.Errorf("expected status %s, got %s", StatusStopped, instance.GetInfo().GetStatus())
+	}
+
+	state, err := second.metadataStore.GetProcessState("adopt-agent")
+	if err != nil {
+		t.Fatalf("failed to get process state: %v", err)
+	}
+	if state != nil {
+		t.Error("expected process state to be deleted after exit")
+	}
+}
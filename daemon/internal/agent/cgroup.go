@@ -0,0 +1,341 @@
+package agent
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/bingooyong/ops-scaffold-framework/daemon/internal/config"
+	"go.uber.org/zap"
+)
+
+const (
+	// cgroupCPUPeriod cpu.max使用的调度周期(微秒)
+	cgroupCPUPeriod = 100000
+
+	// defaultIOWeight io.weight默认权重
+	defaultIOWeight = 100
+)
+
+// cgroupControllers Agent cgroup需要启用的控制器
+var cgroupControllers = []string{"cpu", "memory", "pids", "io"}
+
+// CgroupLimits Agent cgroup资源限制(零值表示不限制)
+type CgroupLimits struct {
+	// CPUs CPU核数上限(cpu.max)
+	CPUs float64
+
+	// MemoryMax 内存硬上限(memory.max，字节)
+	MemoryMax uint64
+
+	// MemoryHigh 内存软上限(memory.high，字节)
+	MemoryHigh uint64
+
+	// PidsMax 最大进程/线程数(pids.max)
+	PidsMax int64
+
+	// IOWeight IO权重(io.weight，1-10000)
+	IOWeight uint16
+}
+
+// NewCgroupLimits 根据Agent资源配置创建cgroup资源限制
+func NewCgroupLimits(cfg *config.ResourcesConfig) *CgroupLimits {
+	return &CgroupLimits{
+		CPUs:       cfg.CPUs,
+		MemoryMax:  cfg.MemoryMax,
+		MemoryHigh: cfg.MemoryHigh,
+		PidsMax:    cfg.PidsMax,
+		IOWeight:   cfg.IOWeight,
+	}
+}
+
+// CgroupStats cgroup资源使用统计(包含cgroup内所有进程)
+type CgroupStats struct {
+	// CPUUsageUsec 累计CPU时间(cpu.stat usage_usec，微秒)
+	CPUUsageUsec uint64
+
+	// MemoryCurrent 当前内存占用(memory.current，字节，包含页缓存)
+	MemoryCurrent uint64
+
+	// MemoryAnon 匿名内存(memory.stat anon，字节)
+	MemoryAnon uint64
+
+	// MemoryFileMapped 映射到进程地址空间的文件页(memory.stat file_mapped，字节)
+	MemoryFileMapped uint64
+
+	// PidsCurrent 当前进程/线程数(pids.current)
+	PidsCurrent uint64
+
+	// IOReadBytes 累计读取字节数(io.stat rbytes之和)
+	IOReadBytes uint64
+
+	// IOWriteBytes 累计写入字节数(io.stat wbytes之和)
+	IOWriteBytes uint64
+}
+
+// MemoryRSS cgroup内所有进程的常驻内存(匿名内存+已映射的文件页)，不含未映射的页缓存
+func (s *CgroupStats) MemoryRSS() uint64 {
+	return s.MemoryAnon + s.MemoryFileMapped
+}
+
+// CgroupManager cgroup v2管理器
+// 每个Agent运行在 {root}/{parent}/{agent_id} 独立的cgroup中
+type CgroupManager struct {
+	// root cgroup v2挂载点(可指向测试用的伪cgroupfs目录)
+	root string
+
+	// parent Agent cgroup的父cgroup(相对root)
+	parent string
+
+	// logger 日志记录器
+	logger *zap.Logger
+}
+
+// NewCgroupManager 创建cgroup管理器
+// 检查root是否为cgroup v2层级，创建父cgroup并逐级启用cpu/memory/pids/io控制器
+func NewCgroupManager(root, parent string, logger *zap.Logger) (*CgroupManager, error) {
+	if _, err := os.Stat(filepath.Join(root, "cgroup.controllers")); err != nil {
+		return nil, fmt.Errorf("cgroup v2 not available at %s: %w", root, err)
+	}
+
+	m := &CgroupManager{
+		root:   root,
+		parent: parent,
+		logger: logger,
+	}
+
+	parentPath := filepath.Join(root, parent)
+	if err := os.MkdirAll(parentPath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create parent cgroup: %w", err)
+	}
+
+	// 控制器需要从root到父cgroup逐级在cgroup.subtree_control中启用，子cgroup才能使用
+	dir := root
+	m.enableControllers(dir)
+	for _, part := range strings.Split(filepath.Clean(parent), string(filepath.Separator)) {
+		if part == "" || part == "." {
+			continue
+		}
+		dir = filepath.Join(dir, part)
+		m.enableControllers(dir)
+	}
+
+	logger.Info("cgroup manager initialized",
+		zap.String("root", root),
+		zap.String("parent", parentPath))
+
+	return m, nil
+}
+
+// enableControllers 在指定cgroup的cgroup.subtree_control中启用可用的控制器
+// 启用失败只记录警告(如控制器未被委派)，对应的资源限制在写入时报错
+func (m *CgroupManager) enableControllers(dir string) {
+	data, err := os.ReadFile(filepath.Join(dir, "cgroup.controllers"))
+	if err != nil {
+		return
+	}
+	available := make(map[string]bool)
+	for _, name := range strings.Fields(string(data)) {
+		available[name] = true
+	}
+
+	subtreeControl := filepath.Join(dir, "cgroup.subtree_control")
+	for _, name := range cgroupControllers {
+		if !available[name] {
+			continue
+		}
+		if err := writeCgroupFile(subtreeControl, "+"+name); err != nil {
+			m.logger.Warn("failed to enable cgroup controller",
+				zap.String("cgroup", dir),
+				zap.String("controller", name),
+				zap.Error(err))
+		}
+	}
+}
+
+// Agent 返回指定Agent的cgroup(不会立即创建，启动Agent前通过Setup创建)
+func (m *CgroupManager) Agent(agentID string, limits *CgroupLimits) *AgentCgroup {
+	return &AgentCgroup{
+		path:   filepath.Join(m.root, m.parent, agentID),
+		limits: limits,
+	}
+}
+
+// AgentCgroup Agent独立的cgroup
+type AgentCgroup struct {
+	// path cgroup目录
+	path string
+
+	// limits 资源限制
+	limits *CgroupLimits
+}
+
+// Path 返回cgroup目录
+func (c *AgentCgroup) Path() string {
+	return c.path
+}
+
+// Setup 创建cgroup并写入资源限制(每次启动Agent前调用，使配置变更生效)
+// 未配置的限制重置为不限制，对应控制器不可用时忽略
+func (c *AgentCgroup) Setup() error {
+	if err := os.MkdirAll(c.path, 0755); err != nil {
+		return fmt.Errorf("failed to create cgroup %s: %w", c.path, err)
+	}
+
+	limits := c.limits
+	if limits == nil {
+		limits = &CgroupLimits{}
+	}
+
+	cpuMax := "max " + strconv.Itoa(cgroupCPUPeriod)
+	if limits.CPUs > 0 {
+		cpuMax = fmt.Sprintf("%d %d", int64(limits.CPUs*cgroupCPUPeriod), cgroupCPUPeriod)
+	}
+	ioWeight := uint16(defaultIOWeight)
+	if limits.IOWeight > 0 {
+		ioWeight = limits.IOWeight
+	}
+
+	settings := []struct {
+		file  string
+		value string
+		set   bool
+	}{
+		{"cpu.max", cpuMax, limits.CPUs > 0},
+		{"memory.max", cgroupLimitValue(int64(limits.MemoryMax)), limits.MemoryMax > 0},
+		{"memory.high", cgroupLimitValue(int64(limits.MemoryHigh)), limits.MemoryHigh > 0},
+		{"pids.max", cgroupLimitValue(limits.PidsMax), limits.PidsMax > 0},
+		{"io.weight", fmt.Sprintf("default %d", ioWeight), limits.IOWeight > 0},
+	}
+	for _, s := range settings {
+		if err := writeCgroupFile(filepath.Join(c.path, s.file), s.value); err != nil && s.set {
+			return fmt.Errorf("failed to set %s: %w", s.file, err)
+		}
+	}
+	return nil
+}
+
+// Stats 读取cgroup资源使用统计(文件不存在的项为0)
+func (c *AgentCgroup) Stats() (*CgroupStats, error) {
+	stats := &CgroupStats{}
+
+	cpuStat, err := readCgroupKeyValues(filepath.Join(c.path, "cpu.stat"))
+	if err != nil {
+		return nil, err
+	}
+	stats.CPUUsageUsec = cpuStat["usage_usec"]
+
+	if stats.MemoryCurrent, err = readCgroupUint(filepath.Join(c.path, "memory.current")); err != nil {
+		return nil, err
+	}
+	memStat, err := readCgroupKeyValues(filepath.Join(c.path, "memory.stat"))
+	if err != nil {
+		return nil, err
+	}
+	stats.MemoryAnon = memStat["anon"]
+	stats.MemoryFileMapped = memStat["file_mapped"]
+	if stats.PidsCurrent, err = readCgroupUint(filepath.Join(c.path, "pids.current")); err != nil {
+		return nil, err
+	}
+	if stats.IOReadBytes, stats.IOWriteBytes, err = readCgroupIOStat(filepath.Join(c.path, "io.stat")); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// cgroupLimitValue 格式化cgroup限制值(0表示不限制，写入"max")
+func cgroupLimitValue(v int64) string {
+	if v <= 0 {
+		return "max"
+	}
+	return strconv.FormatInt(v, 10)
+}
+
+// writeCgroupFile 写入cgroup控制文件
+func writeCgroupFile(path, value string) error {
+	return os.WriteFile(path, []byte(value), 0644)
+}
+
+// readCgroupUint 读取单值cgroup文件(文件不存在或值为"max"时返回0)
+func readCgroupUint(path string) (uint64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	value := strings.TrimSpace(string(data))
+	if value == "" || value == "max" {
+		return 0, nil
+	}
+	v, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value in %s: %w", path, err)
+	}
+	return v, nil
+}
+
+// readCgroupKeyValues 读取"key value"格式的cgroup文件(如cpu.stat，文件不存在时返回空)
+func readCgroupKeyValues(path string) (map[string]uint64, error) {
+	result := make(map[string]uint64)
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return result, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		if v, err := strconv.ParseUint(fields[1], 10, 64); err == nil {
+			result[fields[0]] = v
+		}
+	}
+	return result, scanner.Err()
+}
+
+// readCgroupIOStat 读取io.stat并汇总所有设备的读写字节数
+// 格式: "8:0 rbytes=1024 wbytes=2048 rios=1 wios=2 dbytes=0 dios=0"
+func readCgroupIOStat(path string) (uint64, uint64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, 0, nil
+		}
+		return 0, 0, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	defer file.Close()
+
+	var readBytes, writeBytes uint64
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		for _, field := range strings.Fields(scanner.Text()) {
+			key, value, ok := strings.Cut(field, "=")
+			if !ok {
+				continue
+			}
+			v, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				continue
+			}
+			switch key {
+			case "rbytes":
+				readBytes += v
+			case "wbytes":
+				writeBytes += v
+			}
+		}
+	}
+	return readBytes, writeBytes, scanner.Err()
+}
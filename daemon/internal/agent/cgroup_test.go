@@ -0,0 +1,200 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// newFakeCgroupRoot 创建伪cgroupfs目录(仅包含根cgroup的cgroup.controllers)
+func newFakeCgroupRoot(t *testing.T) string {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "cgroup.controllers"), []byte("cpuset cpu io memory pids\n"), 0644); err != nil {
+		t.Fatalf("failed to write cgroup.controllers: %v", err)
+	}
+	return root
+}
+
+// readCgroupTestFile 读取cgroup文件内容
+func readCgroupTestFile(t *testing.T, path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", path, err)
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func TestNewCgroupManager(t *testing.T) {
+	if _, err := NewCgroupManager(t.TempDir(), "ops-agents", zap.NewNop()); err == nil {
+		t.Error("expected error when cgroup.controllers is missing")
+	}
+
+	root := newFakeCgroupRoot(t)
+	if _, err := NewCgroupManager(root, "system.slice/ops-agents", zap.NewNop()); err != nil {
+		t.Fatalf("failed to create cgroup manager: %v", err)
+	}
+	if info, err := os.Stat(filepath.Join(root, "system.slice", "ops-agents")); err != nil || !info.IsDir() {
+		t.Errorf("expected parent cgroup to be created: %v", err)
+	}
+	// 伪cgroupfs中每次写入覆盖文件，保留最后启用的控制器
+	if got := readCgroupTestFile(t, filepath.Join(root, "cgroup.subtree_control")); got != "+io" {
+		t.Errorf("expected controllers to be enabled in root, got %q", got)
+	}
+}
+
+func TestAgentCgroup_Setup(t *testing.T) {
+	root := newFakeCgroupRoot(t)
+	mgr, err := NewCgroupManager(root, "ops-agents", zap.NewNop())
+	if err != nil {
+		t.Fatalf("failed to create cgroup manager: %v", err)
+	}
+
+	cg := mgr.Agent("agent-1", &CgroupLimits{
+		CPUs:       1.5,
+		MemoryMax:  512 << 20,
+		MemoryHigh: 256 << 20,
+		PidsMax:    64,
+		IOWeight:   500,
+	})
+	if cg.Path() != filepath.Join(root, "ops-agents", "agent-1") {
+		t.Errorf("unexpected cgroup path: %s", cg.Path())
+	}
+	if err := cg.Setup(); err != nil {
+		t.Fatalf("failed to setup cgroup: %v", err)
+	}
+
+	expected := map[string]string{
+		"cpu.max":     "150000 100000",
+		"memory.max":  strconv.Itoa(512 << 20),
+		"memory.high": strconv.Itoa(256 << 20),
+		"pids.max":    "64",
+		"io.weight":   "default 500",
+	}
+	for file, value := range expected {
+		if got := readCgroupTestFile(t, filepath.Join(cg.Path(), file)); got != value {
+			t.Errorf("%s: expected %q, got %q", file, value, got)
+		}
+	}
+
+	// 取消限制后重新Setup，应重置为不限制
+	cg.limits = &CgroupLimits{}
+	if err := cg.Setup(); err != nil {
+		t.Fatalf("failed to setup cgroup: %v", err)
+	}
+	reset := map[string]string{
+		"cpu.max":     "max 100000",
+		"memory.max":  "max",
+		"memory.high": "max",
+		"pids.max":    "max",
+		"io.weight":   "default 100",
+	}
+	for file, value := range reset {
+		if got := readCgroupTestFile(t, filepath.Join(cg.Path(), file)); got != value {
+			t.Errorf("%s: expected %q, got %q", file, value, got)
+		}
+	}
+}
+
+func TestAgentCgroup_Stats(t *testing.T) {
+	root := newFakeCgroupRoot(t)
+	mgr, err := NewCgroupManager(root, "ops-agents", zap.NewNop())
+	if err != nil {
+		t.Fatalf("failed to create cgroup manager: %v", err)
+	}
+	cg := mgr.Agent("agent-1", nil)
+	if err := cg.Setup(); err != nil {
+		t.Fatalf("failed to setup cgroup: %v", err)
+	}
+
+	files := map[string]string{
+		"cpu.stat":       "usage_usec 2500000\nuser_usec 2000000\nsystem_usec 500000\n",
+		"memory.current": "1048576\n",
+		"memory.stat":    "anon 409600\nfile 638976\nfile_mapped 40960\nshmem 0\n",
+		"pids.current":   "3\n",
+		"io.stat":        "8:0 rbytes=1024 wbytes=2048 rios=1 wios=2 dbytes=0 dios=0\n8:16 rbytes=100 wbytes=200 rios=1 wios=1 dbytes=0 dios=0\n",
+	}
+	for file, content := range files {
+		if err := os.WriteFile(filepath.Join(cg.Path(), file), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", file, err)
+		}
+	}
+
+	stats, err := cg.Stats()
+	if err != nil {
+		t.Fatalf("failed to read stats: %v", err)
+	}
+	if stats.CPUUsageUsec != 2500000 {
+		t.Errorf("expected usage_usec 2500000, got %d", stats.CPUUsageUsec)
+	}
+	if stats.MemoryCurrent != 1048576 {
+		t.Errorf("expected memory.current 1048576, got %d", stats.MemoryCurrent)
+	}
+	if stats.MemoryAnon != 409600 || stats.MemoryFileMapped != 40960 {
+		t.Errorf("expected memory.stat anon/file_mapped 409600/40960, got %d/%d", stats.MemoryAnon, stats.MemoryFileMapped)
+	}
+	if stats.PidsCurrent != 3 {
+		t.Errorf("expected pids.current 3, got %d", stats.PidsCurrent)
+	}
+	if stats.IOReadBytes != 1124 || stats.IOWriteBytes != 2248 {
+		t.Errorf("expected io 1124/2248, got %d/%d", stats.IOReadBytes, stats.IOWriteBytes)
+	}
+
+	// 资源监控器使用cgroup统计，CPU使用率由两次采样的增量计算
+	rm := NewResourceMonitor(nil, NewAgentRegistry(), zap.NewNop())
+	first := &ResourceDataPoint{Timestamp: time.Now(), CPU: 1}
+	rm.applyCgroupStats("agent-1", cg, first)
+	// 内存使用常驻内存(anon+file_mapped)，不包含memory.current中的页缓存
+	if first.MemoryRSS != 450560 || first.DiskReadBytes != 1124 || first.DiskWriteBytes != 2248 {
+		t.Errorf("expected cgroup stats to be applied, got %+v", first)
+	}
+	if first.CPU != 1 {
+		t.Errorf("expected first sample to keep process cpu, got %f", first.CPU)
+	}
+
+	if err := os.WriteFile(filepath.Join(cg.Path(), "cpu.stat"), []byte("usage_usec 3000000\n"), 0644); err != nil {
+		t.Fatalf("failed to write cpu.stat: %v", err)
+	}
+	second := &ResourceDataPoint{Timestamp: first.Timestamp.Add(time.Second)}
+	rm.applyCgroupStats("agent-1", cg, second)
+	if second.CPU != 50 {
+		t.Errorf("expected cpu 50%%, got %f", second.CPU)
+	}
+}
+
+func TestAgentInstance_Start_Cgroup(t *testing.T) {
+	root := newFakeCgroupRoot(t)
+	mgr, err := NewCgroupManager(root, "ops-agents", zap.NewNop())
+	if err != nil {
+		t.Fatalf("failed to create cgroup manager: %v", err)
+	}
+
+	workDir := t.TempDir()
+	info := &AgentInfo{
+		ID:         "cgroup-agent",
+		Type:       TypeCustom,
+		BinaryPath: writeTestAgentScript(t, workDir),
+		WorkDir:    workDir,
+	}
+	instance := NewAgentInstance(info, zap.NewNop())
+	cg := mgr.Agent(info.ID, &CgroupLimits{PidsMax: 32})
+	instance.SetCgroup(cg)
+
+	if err := instance.Start(context.Background()); err != nil {
+		t.Fatalf("failed to start agent: %v", err)
+	}
+	defer instance.Stop(context.Background(), false)
+
+	if got := readCgroupTestFile(t, filepath.Join(cg.Path(), "pids.max")); got != "32" {
+		t.Errorf("expected pids.max 32, got %q", got)
+	}
+	// 由启动辅助进程在exec前加入cgroup，cgroup.procs中为辅助进程(即exec后的Agent)的pid
+	if got := readCgroupTestFile(t, filepath.Join(cg.Path(), "cgroup.procs")); got != strconv.Itoa(info.GetPID()) {
+		t.Errorf("expected cgroup.procs to contain pid %d, got %q", info.GetPID(), got)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"sync"
 	"syscall"
 
+	"github.com/bingooyong/ops-scaffold-framework/daemon/pkg/control"
 	"github.com/fsnotify/fsnotify"
 	"go.uber.org/zap"
 	"gopkg.in/yaml.v3"
@@ -414,7 +415,7 @@ func (cm *ConfigManager) handleConfigChange(filePath string) {
 }
 
 // reloadAgentConfig 重载Agent配置
-// 通过发送SIGHUP信号触发Agent重载
+// 实例存在时通过控制通道下发reload命令，否则发送SIGHUP信号触发Agent重载
 func (cm *ConfigManager) reloadAgentConfig(agentID string) error {
 	cm.mu.RLock()
 	instance, exists := cm.agentInstances[agentID]
@@ -450,28 +451,24 @@ func (cm *ConfigManager) reloadAgentConfig(agentID string) error {
 		return nil
 	}
 
-	// 如果实例存在，通过实例发送信号
-	info := instance.GetInfo()
-	pid := info.GetPID()
-	if pid == 0 {
+	// 如果实例存在，通过控制通道下发reload(不支持时回退为SIGHUP)
+	if !instance.IsRunning() {
 		cm.logger.Debug("agent not running, skipping reload",
 			zap.String("agent_id", agentID))
 		return nil
 	}
 
-	// 获取进程并发送SIGHUP信号
-	process, err := os.FindProcess(pid)
+	result, err := instance.Control(context.Background(), control.CommandReload, nil)
 	if err != nil {
-		return fmt.Errorf("failed to find process: %w", err)
+		return err
 	}
-
-	if err := process.Signal(syscall.SIGHUP); err != nil {
-		return fmt.Errorf("failed to send SIGHUP: %w", err)
+	if !result.Success {
+		return fmt.Errorf("agent reload failed: %s", result.Message)
 	}
 
-	cm.logger.Info("sent SIGHUP to agent via instance",
+	cm.logger.Info("reload sent to agent via instance",
 		zap.String("agent_id", agentID),
-		zap.Int("pid", pid))
+		zap.String("method", result.Method))
 
 	return nil
 }
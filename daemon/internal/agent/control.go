@@ -0,0 +1,207 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/bingooyong/ops-scaffold-framework/daemon/internal/config"
+	"github.com/bingooyong/ops-scaffold-framework/daemon/pkg/control"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultControlTimeout 默认控制命令超时
+	defaultControlTimeout = 10 * time.Second
+
+	// ControlMethodSocket 通过控制Socket下发
+	ControlMethodSocket = "socket"
+
+	// ControlMethodSignal 通过回退信号下发
+	ControlMethodSignal = "signal"
+)
+
+// controlRequestSeq 控制请求序号(用作请求ID)
+var controlRequestSeq uint64
+
+// ControlConfig Agent控制通道配置
+type ControlConfig struct {
+	// Timeout 单条命令的超时
+	Timeout time.Duration
+
+	// FallbackSignals 控制Socket不可用或Agent不支持该命令时发送的信号
+	FallbackSignals map[string]syscall.Signal
+}
+
+// DefaultControlConfig 返回默认控制通道配置(10秒超时, reload回退为SIGHUP)
+func DefaultControlConfig() *ControlConfig {
+	return &ControlConfig{
+		Timeout: defaultControlTimeout,
+		FallbackSignals: map[string]syscall.Signal{
+			control.CommandReload: syscall.SIGHUP,
+		},
+	}
+}
+
+// NewControlConfig 根据Agent配置创建控制通道配置
+// 配置了fallback_signals时替换默认映射
+func NewControlConfig(cfg *config.AgentItemConfig) (*ControlConfig, error) {
+	cc := DefaultControlConfig()
+	if cfg == nil {
+		return cc, nil
+	}
+
+	if cfg.Control.Timeout > 0 {
+		cc.Timeout = cfg.Control.Timeout
+	}
+	if len(cfg.Control.FallbackSignals) > 0 {
+		cc.FallbackSignals = make(map[string]syscall.Signal, len(cfg.Control.FallbackSignals))
+		for command, name := range cfg.Control.FallbackSignals {
+			sig, err := ParseStopSignal(name)
+			if err != nil {
+				return nil, fmt.Errorf("invalid fallback signal for %s: %w", command, err)
+			}
+			cc.FallbackSignals[command] = sig
+		}
+	}
+
+	return cc, nil
+}
+
+// ControlResult 控制命令执行结果
+type ControlResult struct {
+	// Command 控制命令
+	Command string
+
+	// Method 下发方式: socket 或 signal
+	Method string
+
+	// Success Agent是否执行成功(信号方式下表示信号已发送)
+	Success bool
+
+	// Message Agent返回的消息
+	Message string
+
+	// Data Agent返回的数据(如诊断信息)
+	Data map[string]string
+}
+
+// ControlUnsupportedError Agent不支持该控制命令且没有配置回退信号
+type ControlUnsupportedError struct {
+	AgentID string
+	Command string
+	Reason  string
+}
+
+func (e *ControlUnsupportedError) Error() string {
+	return fmt.Sprintf("agent %s does not support control command %s: %s", e.AgentID, e.Command, e.Reason)
+}
+
+// SetControl 设置控制通道配置
+func (ai *AgentInstance) SetControl(cfg *ControlConfig) {
+	ai.mu.Lock()
+	defer ai.mu.Unlock()
+	ai.control = cfg
+}
+
+// Control 向Agent下发控制命令
+// 配置了socket_path时优先通过控制Socket下发；Socket无法连接或Agent返回unknown_command时
+// 改为发送配置的回退信号。已连接但读写失败时直接返回错误，避免命令被重复执行
+func (ai *AgentInstance) Control(ctx context.Context, command string, args map[string]string) (*ControlResult, error) {
+	if !control.IsValidCommand(command) {
+		return nil, fmt.Errorf("invalid control command: %s", command)
+	}
+
+	ai.mu.Lock()
+	running := ai.isRunningLocked()
+	process := ai.process
+	cfg := ai.control
+	ai.mu.Unlock()
+	if cfg == nil {
+		cfg = DefaultControlConfig()
+	}
+
+	agentID := ai.info.ID
+	if !running {
+		return nil, &AgentNotRunningError{ID: agentID}
+	}
+
+	reason := "no control socket configured"
+	if socketPath := ai.info.SocketPath; socketPath != "" {
+		callCtx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+		resp, err := control.Call(callCtx, socketPath, &control.Request{
+			ID:      strconv.FormatUint(atomic.AddUint64(&controlRequestSeq, 1), 10),
+			Command: command,
+			Args:    args,
+		})
+		cancel()
+
+		switch {
+		case err == nil && resp.Code != control.CodeUnknownCommand:
+			ai.logger.Info("control command sent to agent",
+				zap.String("agent_id", agentID),
+				zap.String("command", command),
+				zap.Bool("success", resp.Success),
+				zap.String("message", resp.Message))
+			return &ControlResult{
+				Command: command,
+				Method:  ControlMethodSocket,
+				Success: resp.Success,
+				Message: resp.Message,
+				Data:    resp.Data,
+			}, nil
+		case err == nil:
+			reason = "agent does not implement the command"
+		case isDialError(err):
+			reason = "control socket unavailable: " + err.Error()
+		default:
+			return nil, fmt.Errorf("control command %s failed: %w", command, err)
+		}
+	}
+
+	sig, ok := cfg.FallbackSignals[command]
+	if !ok {
+		return nil, &ControlUnsupportedError{AgentID: agentID, Command: command, Reason: reason}
+	}
+	if err := process.Signal(sig); err != nil {
+		return nil, fmt.Errorf("failed to send %s: %w", signalName(sig), err)
+	}
+
+	ai.logger.Info("control command sent to agent via fallback signal",
+		zap.String("agent_id", agentID),
+		zap.String("command", command),
+		zap.String("signal", signalName(sig)),
+		zap.String("reason", reason))
+
+	return &ControlResult{
+		Command: command,
+		Method:  ControlMethodSignal,
+		Success: true,
+		Message: "sent " + signalName(sig),
+	}, nil
+}
+
+// ControlAgent 向指定Agent下发控制命令
+func (mam *MultiAgentManager) ControlAgent(ctx context.Context, agentID, command string, args map[string]string) (*ControlResult, error) {
+	instance := mam.GetAgent(agentID)
+	if instance == nil {
+		return nil, &AgentNotFoundError{ID: agentID}
+	}
+	return instance.Control(ctx, command, args)
+}
+
+// isDialError 判断是否为连接控制Socket失败(Socket不存在或无人监听)
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// signalName 返回信号名称(如SIGHUP)
+func signalName(sig syscall.Signal) string {
+	return config.SignalName(sig)
+}
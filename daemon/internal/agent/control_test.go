@@ -0,0 +1,160 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/bingooyong/ops-scaffold-framework/daemon/internal/config"
+	"github.com/bingooyong/ops-scaffold-framework/daemon/pkg/control"
+	"go.uber.org/zap/zaptest"
+)
+
+// newControlTestInstance 创建以当前测试进程作为Agent进程的实例
+func newControlTestInstance(t *testing.T, socketPath string) *AgentInstance {
+	t.Helper()
+	info := &AgentInfo{ID: "control-agent", Type: TypeCustom, SocketPath: socketPath}
+	instance := NewAgentInstance(info, zaptest.NewLogger(t))
+	process, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatalf("failed to find test process: %v", err)
+	}
+	instance.process = process
+	return instance
+}
+
+// startControlServer 在socketPath上启动只实现部分命令的控制服务
+func startControlServer(t *testing.T, socketPath string) {
+	t.Helper()
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go control.Serve(context.Background(), listener, func(ctx context.Context, req *control.Request) *control.Response {
+		switch req.Command {
+		case control.CommandSetLogLevel:
+			return control.OK("log level set", map[string]string{"level": req.Args["level"]})
+		case control.CommandDrain:
+			return control.Fail(control.CodeFailed, "drain in progress")
+		}
+		return nil
+	})
+}
+
+func TestNewControlConfig(t *testing.T) {
+	cc, err := NewControlConfig(&config.AgentItemConfig{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cc.Timeout != defaultControlTimeout || cc.FallbackSignals[control.CommandReload] != syscall.SIGHUP {
+		t.Errorf("unexpected default control config: %+v", cc)
+	}
+
+	cc, err = NewControlConfig(&config.AgentItemConfig{Control: config.ControlConfig{
+		Timeout:         time.Second,
+		FallbackSignals: map[string]string{"dump_diagnostics": "usr1"},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cc.Timeout != time.Second || cc.FallbackSignals[control.CommandDumpDiagnostics] != syscall.SIGUSR1 {
+		t.Errorf("unexpected control config: %+v", cc)
+	}
+	if _, ok := cc.FallbackSignals[control.CommandReload]; ok {
+		t.Error("configured fallback_signals should replace the defaults")
+	}
+
+	if _, err := NewControlConfig(&config.AgentItemConfig{Control: config.ControlConfig{
+		FallbackSignals: map[string]string{"reload": "SIGBOGUS"},
+	}}); err == nil {
+		t.Error("expected error for invalid fallback signal")
+	}
+}
+
+func TestAgentInstance_Control_Socket(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "control.sock")
+	startControlServer(t, socketPath)
+	instance := newControlTestInstance(t, socketPath)
+
+	result, err := instance.Control(context.Background(), control.CommandSetLogLevel, map[string]string{"level": "debug"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Method != ControlMethodSocket || !result.Success || result.Data["level"] != "debug" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	// Agent执行失败时原样返回，不回退为信号
+	result, err = instance.Control(context.Background(), control.CommandDrain, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Method != ControlMethodSocket || result.Success || result.Message != "drain in progress" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+}
+
+func TestAgentInstance_Control_FallbackSignal(t *testing.T) {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGHUP)
+	defer signal.Stop(sigCh)
+
+	socketPath := filepath.Join(t.TempDir(), "control.sock")
+	startControlServer(t, socketPath)
+
+	tests := []struct {
+		name       string
+		socketPath string
+	}{
+		{"no control socket", ""},
+		{"socket unavailable", filepath.Join(t.TempDir(), "missing.sock")},
+		{"unknown command", socketPath},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instance := newControlTestInstance(t, tt.socketPath)
+			result, err := instance.Control(context.Background(), control.CommandReload, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Method != ControlMethodSignal || !result.Success {
+				t.Errorf("unexpected result: %+v", result)
+			}
+			select {
+			case <-sigCh:
+			case <-time.After(2 * time.Second):
+				t.Error("expected SIGHUP to be delivered")
+			}
+		})
+	}
+}
+
+func TestAgentInstance_Control_Errors(t *testing.T) {
+	instance := newControlTestInstance(t, "")
+
+	// 没有回退信号的命令
+	_, err := instance.Control(context.Background(), control.CommandDumpDiagnostics, nil)
+	var unsupported *ControlUnsupportedError
+	if !errors.As(err, &unsupported) {
+		t.Errorf("expected ControlUnsupportedError, got %v", err)
+	}
+
+	if _, err := instance.Control(context.Background(), "bogus", nil); err == nil {
+		t.Error("expected error for invalid command")
+	}
+
+	instance.process = nil
+	_, err = instance.Control(context.Background(), control.CommandReload, nil)
+	var notRunning *AgentNotRunningError
+	if !errors.As(err, &notRunning) {
+		t.Errorf("expected AgentNotRunningError, got %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+package agent
+
+import "time"
+
+const (
+	// defaultCrashLogLines 崩溃记录中保留的日志行数
+	defaultCrashLogLines = 50
+
+	// maxCrashRecords 每个Agent保留的最大崩溃记录数
+	maxCrashRecords = 50
+
+	// crashLogReadSize 读取崩溃日志时从文件末尾读取的最大字节数
+	crashLogReadSize = 64 * 1024
+)
+
+// CrashRecord Agent崩溃记录
+// 记录进程意外退出时的退出码、信号、运行时长以及退出前的最后若干行日志
+type CrashRecord struct {
+	// AgentID Agent唯一标识符
+	AgentID string `json:"agent_id"`
+
+	// ExitCode 退出码(被信号终止时为-1)
+	ExitCode int `json:"exit_code"`
+
+	// Signal 终止进程的信号名称(正常退出时为空)
+	Signal string `json:"signal,omitempty"`
+
+	// StartedAt 本次运行的启动时间
+	StartedAt time.Time `json:"started_at"`
+
+	// ExitedAt 退出时间
+	ExitedAt time.Time `json:"exited_at"`
+
+	// Duration 运行时长
+	Duration time.Duration `json:"duration"`
+
+	// LastLogLines 退出时日志的最后N行
+	LastLogLines []string `json:"last_log_lines"`
+
+	// CircuitOpen 本次崩溃是否触发熔断
+	CircuitOpen bool `json:"circuit_open"`
+}
+
+// newCrashRecord 根据退出状态构建崩溃记录
+func newCrashRecord(agentID string, exit *ExitStatus, logLines []string, tripped bool) *CrashRecord {
+	record := &CrashRecord{
+		AgentID:      agentID,
+		ExitCode:     exit.ExitCode,
+		StartedAt:    exit.StartedAt,
+		ExitedAt:     exit.ExitedAt,
+		Duration:     exit.Duration(),
+		LastLogLines: logLines,
+		CircuitOpen:  tripped,
+	}
+	if exit.Signal != 0 {
+		record.Signal = exit.Signal.String()
+	}
+	return record
+}
+
+// readLastLines 读取文件最后n行(只读取文件末尾crashLogReadSize字节)
+func readLastLines(path string, n int) ([]string, error) {
+	return readLogTail(path, n, "", crashLogReadSize)
+}
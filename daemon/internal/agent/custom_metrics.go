@@ -0,0 +1,92 @@
+package agent
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+)
+
+const (
+	// MaxCustomMetrics 单次心跳允许携带的自定义数值指标数量上限
+	MaxCustomMetrics = 64
+
+	// MaxCustomFields 单次心跳允许携带的自定义状态字段数量上限
+	MaxCustomFields = 32
+
+	// MaxCustomKeyLength 自定义指标/字段名称的最大长度
+	MaxCustomKeyLength = 64
+
+	// MaxCustomFieldValueLength 自定义状态字段值的最大长度
+	MaxCustomFieldValueLength = 256
+)
+
+// customKeyPattern 自定义指标/字段名称格式(字母或下划线开头,仅含字母、数字、下划线和点)
+var customKeyPattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_.]*$`)
+
+// ValidateCustomMetrics 校验心跳携带的自定义指标和状态字段
+// 返回的错误为*ValidationError,Field指明出错的字段
+func ValidateCustomMetrics(metrics map[string]float64, fields map[string]string) error {
+	if len(metrics) > MaxCustomMetrics {
+		return &ValidationError{Field: "metrics", Message: fmt.Sprintf("at most %d metrics are allowed, got %d", MaxCustomMetrics, len(metrics))}
+	}
+	for key, value := range metrics {
+		if err := validateCustomKey(key); err != nil {
+			return &ValidationError{Field: "metrics." + key, Message: err.Error()}
+		}
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return &ValidationError{Field: "metrics." + key, Message: "value must be a finite number"}
+		}
+	}
+
+	if len(fields) > MaxCustomFields {
+		return &ValidationError{Field: "fields", Message: fmt.Sprintf("at most %d fields are allowed, got %d", MaxCustomFields, len(fields))}
+	}
+	for key, value := range fields {
+		if err := validateCustomKey(key); err != nil {
+			return &ValidationError{Field: "fields." + key, Message: err.Error()}
+		}
+		if len(value) > MaxCustomFieldValueLength {
+			return &ValidationError{Field: "fields." + key, Message: fmt.Sprintf("value must be at most %d bytes", MaxCustomFieldValueLength)}
+		}
+	}
+
+	return nil
+}
+
+// validateCustomKey 校验自定义指标/字段名称
+func validateCustomKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("name must not be empty")
+	}
+	if len(key) > MaxCustomKeyLength {
+		return fmt.Errorf("name must be at most %d characters", MaxCustomKeyLength)
+	}
+	if !customKeyPattern.MatchString(key) {
+		return fmt.Errorf("name must match %s", customKeyPattern.String())
+	}
+	return nil
+}
+
+// copyCustomMetrics 复制自定义数值指标,空map返回nil
+func copyCustomMetrics(src map[string]float64) map[string]float64 {
+	if len(src) == 0 {
+		return nil
+	}
+	dst := make(map[string]float64, len(src))
+	for k, v := range src {
+		dst[k] = v
+	}
+	return dst
+}
+
+// copyCustomFields 复制自定义状态字段,空map返回nil
+func copyCustomFields(src map[string]string) map[string]string {
+	if len(src) == 0 {
+		return nil
+	}
+	dst := make(map[string]string, len(src))
+	for k, v := range src {
+		dst[k] = v
+	}
+	return dst
+}
@@ -7,7 +7,9 @@ import (
 	"net"
 	"os"
 	"sync"
+	"time"
 
+	"github.com/bingooyong/ops-scaffold-framework/daemon/pkg/heartbeat"
 	"github.com/bingooyong/ops-scaffold-framework/daemon/pkg/types"
 	"go.uber.org/zap"
 )
@@ -17,7 +19,8 @@ type HeartbeatReceiver struct {
 	socketPath    string
 	listener      net.Listener
 	healthChecker *HealthChecker
-	multiManager  *MultiAgentManager // 多Agent管理器引用(用于更新metadata)
+	multiManager  *MultiAgentManager      // 多Agent管理器引用(用于更新metadata)
+	auth          *HeartbeatAuthenticator // 心跳认证器(可选，仅多Agent模式)
 	logger        *zap.Logger
 	ctx           context.Context
 	cancel        context.CancelFunc
@@ -41,6 +44,11 @@ func (r *HeartbeatReceiver) SetMultiAgentManager(multiManager *MultiAgentManager
 	r.multiManager = multiManager
 }
 
+// SetAuthenticator 设置心跳认证器(需在Start之前调用)
+func (r *HeartbeatReceiver) SetAuthenticator(auth *HeartbeatAuthenticator) {
+	r.auth = auth
+}
+
 // Start 启动心跳接收
 func (r *HeartbeatReceiver) Start() error {
 	// 清理旧的socket文件
@@ -104,6 +112,18 @@ func (r *HeartbeatReceiver) handleConnection(conn net.Conn) {
 	defer r.wg.Done()
 	defer conn.Close()
 
+	// 读取对端进程PID(SO_PEERCRED)，用于确认心跳来自对应的Agent进程
+	// 启用心跳认证时无法确认对端进程则拒绝连接，避免其他本地进程绕过对端校验冒用Agent
+	peer, err := peerPID(conn)
+	if err != nil {
+		if r.auth != nil && r.auth.Mode() != HeartbeatAuthDisabled {
+			r.logger.Warn("rejecting heartbeat connection without peer credentials", zap.Error(err))
+			return
+		}
+		r.logger.Warn("peer credentials unavailable, matching agents by reported pid", zap.Error(err))
+		peer = 0
+	}
+
 	decoder := json.NewDecoder(conn)
 
 	for {
@@ -113,42 +133,121 @@ func (r *HeartbeatReceiver) handleConnection(conn net.Conn) {
 		default:
 		}
 
-		var hb types.Heartbeat
-		if err := decoder.Decode(&hb); err != nil {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
 			if err.Error() != "EOF" {
 				r.logger.Error("failed to decode heartbeat", zap.Error(err))
 			}
 			return
 		}
 
+		hb, agentID, err := r.decodeHeartbeat(raw, peer)
+		if err != nil {
+			r.logger.Warn("rejected heartbeat",
+				zap.String("agent_id", agentID),
+				zap.Int("peer_pid", peer),
+				zap.Error(err))
+			continue
+		}
+
 		// 将心跳传递给健康检查器
 		if r.healthChecker != nil {
-			r.healthChecker.ReceiveHeartbeat(&hb)
+			r.healthChecker.ReceiveHeartbeat(hb)
 		}
 
 		// 如果有多Agent管理器，也更新metadata
-		// 注意：Unix Socket心跳可能不包含agent_id，需要通过PID查找对应的Agent
-		if r.multiManager != nil {
-			// 通过PID查找对应的Agent实例
-			instances := r.multiManager.ListAgents()
-			for _, instance := range instances {
-				if instance.GetInfo().GetPID() == int(hb.PID) {
-					agentID := instance.GetInfo().ID
-					// 更新metadata中的心跳信息
-					if err := r.multiManager.UpdateHeartbeat(agentID, hb.Timestamp, hb.CPU, hb.Memory); err != nil {
-						r.logger.Warn("failed to update heartbeat in metadata",
-							zap.String("agent_id", agentID),
-							zap.Int("pid", int(hb.PID)),
-							zap.Error(err))
-					} else {
-						r.logger.Debug("updated heartbeat in metadata",
-							zap.String("agent_id", agentID),
-							zap.Int("pid", int(hb.PID)),
-							zap.Time("timestamp", hb.Timestamp))
-					}
-					break
-				}
+		if r.multiManager != nil && agentID != "" {
+			// 自定义指标校验失败时丢弃自定义部分,仍更新心跳
+			customMetrics, customFields := hb.Metrics, hb.Fields
+			if err := ValidateCustomMetrics(customMetrics, customFields); err != nil {
+				r.logger.Warn("dropping invalid custom metrics from heartbeat",
+					zap.String("agent_id", agentID),
+					zap.Error(err))
+				customMetrics, customFields = nil, nil
 			}
+			// 更新metadata中的心跳信息
+			if err := r.multiManager.UpdateHeartbeat(agentID, hb.Timestamp, hb.CPU, hb.Memory, customMetrics, customFields); err != nil {
+				r.logger.Warn("failed to update heartbeat in metadata",
+					zap.String("agent_id", agentID),
+					zap.Int("pid", hb.PID),
+					zap.Error(err))
+			} else {
+				r.logger.Debug("updated heartbeat in metadata",
+					zap.String("agent_id", agentID),
+					zap.Int("pid", hb.PID),
+					zap.Time("timestamp", hb.Timestamp))
+			}
+		}
+	}
+}
+
+// decodeHeartbeat 解析一条心跳消息，返回心跳内容和对应的Agent ID
+// 签名心跳为heartbeat.Envelope，未签名心跳为types.Heartbeat(通过PID查找Agent)
+func (r *HeartbeatReceiver) decodeHeartbeat(raw json.RawMessage, peer int) (*types.Heartbeat, string, error) {
+	var envelope heartbeat.Envelope
+	if err := json.Unmarshal(raw, &envelope); err == nil && envelope.Signature != "" {
+		return r.decodeSignedHeartbeat(&envelope, peer)
+	}
+
+	var hb types.Heartbeat
+	if err := json.Unmarshal(raw, &hb); err != nil {
+		return nil, "", fmt.Errorf("invalid heartbeat: %w", err)
+	}
+
+	// 优先使用内核提供的对端PID查找Agent，避免信任心跳中自报的PID
+	var agentID string
+	if r.multiManager != nil {
+		if peer > 0 {
+			agentID = r.findAgent(func(agentPID int) bool { return peerBelongsToAgent(peer, agentPID) })
+		} else if hb.PID > 0 {
+			agentID = r.findAgent(func(agentPID int) bool { return agentPID == hb.PID })
+		}
+	}
+	if r.auth != nil {
+		if err := r.auth.Unsigned(agentID); err != nil {
+			return nil, agentID, err
+		}
+	}
+	return &hb, agentID, nil
+}
+
+// decodeSignedHeartbeat 校验签名心跳信封并解析心跳内容
+func (r *HeartbeatReceiver) decodeSignedHeartbeat(envelope *heartbeat.Envelope, peer int) (*types.Heartbeat, string, error) {
+	agentID := envelope.AgentID
+	if agentID == "" {
+		return nil, "", fmt.Errorf("signed heartbeat without agent_id")
+	}
+
+	if r.multiManager != nil {
+		instance := r.multiManager.GetAgent(agentID)
+		if instance == nil {
+			return nil, agentID, &AgentNotFoundError{ID: agentID}
+		}
+		// 对端进程必须属于声明的Agent，防止其他本地进程冒用
+		if peer > 0 && !peerBelongsToAgent(peer, instance.GetInfo().GetPID()) {
+			return nil, agentID, &HeartbeatAuthError{AgentID: agentID, Reason: fmt.Sprintf("peer pid %d does not belong to agent", peer)}
+		}
+	}
+
+	if r.auth != nil {
+		if err := r.auth.Verify(agentID, envelope.Timestamp, envelope.Signature, envelope.Payload, time.Now()); err != nil {
+			return nil, agentID, err
+		}
+	}
+
+	var hb types.Heartbeat
+	if err := json.Unmarshal(envelope.Payload, &hb); err != nil {
+		return nil, agentID, fmt.Errorf("invalid heartbeat payload: %w", err)
+	}
+	return &hb, agentID, nil
+}
+
+// findAgent 查找进程PID满足条件的Agent，未找到时返回空字符串
+func (r *HeartbeatReceiver) findAgent(match func(agentPID int) bool) string {
+	for _, instance := range r.multiManager.ListAgents() {
+		if pid := instance.GetInfo().GetPID(); pid > 0 && match(pid) {
+			return instance.GetInfo().ID
 		}
 	}
+	return ""
 }
@@ -0,0 +1,218 @@
+package agent
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/bingooyong/ops-scaffold-framework/daemon/pkg/heartbeat"
+)
+
+// HeartbeatAuthMode 心跳认证模式
+type HeartbeatAuthMode string
+
+const (
+	// HeartbeatAuthDisabled 不下发密钥，也不校验签名
+	HeartbeatAuthDisabled HeartbeatAuthMode = "disabled"
+
+	// HeartbeatAuthOptional 下发密钥并校验携带签名的心跳，未签名的心跳仍然接受(用于迁移)
+	HeartbeatAuthOptional HeartbeatAuthMode = "optional"
+
+	// HeartbeatAuthRequired 拒绝未签名的心跳
+	HeartbeatAuthRequired HeartbeatAuthMode = "required"
+)
+
+const (
+	// heartbeatKeySize 主密钥长度(字节)
+	heartbeatKeySize = 32
+
+	// heartbeatNonceSize 每次启动随机数长度(字节)
+	heartbeatNonceSize = 16
+
+	// defaultHeartbeatMaxSkew 默认允许的心跳时间戳偏差
+	defaultHeartbeatMaxSkew = 60 * time.Second
+)
+
+// HeartbeatAuthError 心跳认证失败
+type HeartbeatAuthError struct {
+	AgentID string
+	Reason  string
+}
+
+func (e *HeartbeatAuthError) Error() string {
+	return fmt.Sprintf("heartbeat authentication failed for agent %s: %s", e.AgentID, e.Reason)
+}
+
+// HeartbeatAuthenticator Agent心跳认证器
+// 每次启动Agent时生成随机数，由主密钥派生该Agent本次运行的心跳密钥并通过环境变量下发；
+// 随机数随进程状态持久化，daemon重启接管Agent后可重新派生同一密钥
+type HeartbeatAuthenticator struct {
+	// mode 认证模式
+	mode HeartbeatAuthMode
+
+	// key 主密钥(持久化在key文件中)
+	key []byte
+
+	// maxSkew 心跳时间戳允许的最大偏差
+	maxSkew time.Duration
+
+	// mu 保护nonces和seen
+	mu sync.Mutex
+
+	// nonces 每个Agent当前运行使用的随机数
+	nonces map[string]string
+
+	// seen 时间窗口内已接受的签名(用于拒绝重放)，value为过期时间
+	seen map[string]time.Time
+
+	// lastPrune 上次清理seen的时间
+	lastPrune time.Time
+}
+
+// NewHeartbeatAuthenticator 创建心跳认证器
+// keyFile不存在时生成新的主密钥并以0600权限写入
+func NewHeartbeatAuthenticator(mode HeartbeatAuthMode, keyFile string, maxSkew time.Duration) (*HeartbeatAuthenticator, error) {
+	if maxSkew <= 0 {
+		maxSkew = defaultHeartbeatMaxSkew
+	}
+
+	key, err := loadOrCreateHeartbeatKey(keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &HeartbeatAuthenticator{
+		mode:    mode,
+		key:     key,
+		maxSkew: maxSkew,
+		nonces:  make(map[string]string),
+		seen:    make(map[string]time.Time),
+	}, nil
+}
+
+// loadOrCreateHeartbeatKey 读取主密钥，不存在时生成
+func loadOrCreateHeartbeatKey(keyFile string) ([]byte, error) {
+	data, err := os.ReadFile(keyFile)
+	if err == nil {
+		key, decodeErr := hex.DecodeString(string(data))
+		if decodeErr != nil || len(key) < heartbeatKeySize {
+			return nil, fmt.Errorf("invalid heartbeat key file %s", keyFile)
+		}
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read heartbeat key file: %w", err)
+	}
+
+	key := make([]byte, heartbeatKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate heartbeat key: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(keyFile), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create heartbeat key directory: %w", err)
+	}
+	if err := os.WriteFile(keyFile, []byte(hex.EncodeToString(key)), 0600); err != nil {
+		return nil, fmt.Errorf("failed to write heartbeat key file: %w", err)
+	}
+	return key, nil
+}
+
+// Mode 返回认证模式
+func (a *HeartbeatAuthenticator) Mode() HeartbeatAuthMode {
+	return a.mode
+}
+
+// Issue 为即将启动的Agent生成新的随机数和心跳密钥，之前的密钥随之失效
+func (a *HeartbeatAuthenticator) Issue(agentID string) (nonce, secret string, err error) {
+	buf := make([]byte, heartbeatNonceSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", fmt.Errorf("failed to generate heartbeat nonce: %w", err)
+	}
+	nonce = hex.EncodeToString(buf)
+
+	a.mu.Lock()
+	a.nonces[agentID] = nonce
+	a.mu.Unlock()
+
+	return nonce, a.deriveSecret(agentID, nonce), nil
+}
+
+// Restore 恢复接管的Agent使用的随机数(daemon重启后调用)
+func (a *HeartbeatAuthenticator) Restore(agentID, nonce string) {
+	if nonce == "" {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.nonces[agentID] = nonce
+}
+
+// deriveSecret 由主密钥、Agent ID和随机数派生心跳密钥
+func (a *HeartbeatAuthenticator) deriveSecret(agentID, nonce string) string {
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte(agentID))
+	mac.Write([]byte{0})
+	mac.Write([]byte(nonce))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify 校验签名心跳
+// timestamp为Unix毫秒时间戳，body为签名覆盖的原始心跳内容
+func (a *HeartbeatAuthenticator) Verify(agentID string, timestamp int64, signature string, body []byte, now time.Time) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	nonce, ok := a.nonces[agentID]
+	if !ok {
+		return &HeartbeatAuthError{AgentID: agentID, Reason: "no heartbeat secret issued"}
+	}
+
+	skew := now.Sub(time.UnixMilli(timestamp))
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > a.maxSkew {
+		return &HeartbeatAuthError{AgentID: agentID, Reason: "stale timestamp"}
+	}
+
+	if !heartbeat.Verify(a.deriveSecret(agentID, nonce), timestamp, body, signature) {
+		return &HeartbeatAuthError{AgentID: agentID, Reason: "invalid signature"}
+	}
+
+	a.pruneSeenLocked(now)
+	seenKey := agentID + "|" + signature
+	if _, replayed := a.seen[seenKey]; replayed {
+		return &HeartbeatAuthError{AgentID: agentID, Reason: "replayed heartbeat"}
+	}
+	// 签名在时间戳±maxSkew内有效，记录到窗口结束即可
+	a.seen[seenKey] = time.UnixMilli(timestamp).Add(a.maxSkew)
+
+	return nil
+}
+
+// pruneSeenLocked 清理已过期的签名记录(需要持锁调用，每秒最多一次)
+func (a *HeartbeatAuthenticator) pruneSeenLocked(now time.Time) {
+	if now.Sub(a.lastPrune) < time.Second {
+		return
+	}
+	a.lastPrune = now
+	for key, expiresAt := range a.seen {
+		if now.After(expiresAt) {
+			delete(a.seen, key)
+		}
+	}
+}
+
+// Unsigned 处理未签名的心跳，required模式下返回错误
+func (a *HeartbeatAuthenticator) Unsigned(agentID string) error {
+	if a.mode == HeartbeatAuthRequired {
+		return &HeartbeatAuthError{AgentID: agentID, Reason: "heartbeat is not signed"}
+	}
+	return nil
+}
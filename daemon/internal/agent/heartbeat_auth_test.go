@@ -0,0 +1,270 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/bingooyong/ops-scaffold-framework/daemon/pkg/heartbeat"
+	"go.uber.org/zap/zaptest"
+)
+
+func newTestAuthenticator(t *testing.T, mode HeartbeatAuthMode) *HeartbeatAuthenticator {
+	t.Helper()
+	auth, err := NewHeartbeatAuthenticator(mode, filepath.Join(t.TempDir(), "heartbeat.key"), time.Minute)
+	if err != nil {
+		t.Fatalf("failed to create authenticator: %v", err)
+	}
+	return auth
+}
+
+func TestHeartbeatAuthenticator_KeyPersistence(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "heartbeat.key")
+
+	first, err := NewHeartbeatAuthenticator(HeartbeatAuthRequired, keyFile, time.Minute)
+	if err != nil {
+		t.Fatalf("failed to create authenticator: %v", err)
+	}
+	info, err := os.Stat(keyFile)
+	if err != nil {
+		t.Fatalf("key file not created: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected key file mode 0600, got %v", info.Mode().Perm())
+	}
+
+	nonce, secret, err := first.Issue("agent-1")
+	if err != nil {
+		t.Fatalf("failed to issue secret: %v", err)
+	}
+
+	// daemon重启后通过持久化的随机数恢复同一密钥
+	second, err := NewHeartbeatAuthenticator(HeartbeatAuthRequired, keyFile, time.Minute)
+	if err != nil {
+		t.Fatalf("failed to reload authenticator: %v", err)
+	}
+	second.Restore("agent-1", nonce)
+
+	now := time.Now()
+	body := []byte(`{"agent_id":"agent-1"}`)
+	sig := heartbeat.Sign(secret, now.UnixMilli(), body)
+	if err := second.Verify("agent-1", now.UnixMilli(), sig, body, now); err != nil {
+		t.Errorf("expected restored secret to verify, got %v", err)
+	}
+}
+
+func TestHeartbeatAuthenticator_Verify(t *testing.T) {
+	auth := newTestAuthenticator(t, HeartbeatAuthRequired)
+	_, secret, err := auth.Issue("agent-1")
+	if err != nil {
+		t.Fatalf("failed to issue secret: %v", err)
+	}
+
+	now := time.Now()
+	body := []byte(`{"agent_id":"agent-1","pid":1}`)
+	ts := now.UnixMilli()
+	sig := heartbeat.Sign(secret, ts, body)
+
+	if err := auth.Verify("agent-1", ts, sig, body, now); err != nil {
+		t.Fatalf("expected valid heartbeat, got %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		agentID string
+		ts      int64
+		sig     string
+		body    []byte
+	}{
+		{"replayed", "agent-1", ts, sig, body},
+		{"tampered body", "agent-1", ts, sig, []byte(`{"agent_id":"agent-1","pid":2}`)},
+		{"wrong secret", "agent-1", ts, heartbeat.Sign("other", ts, body), body},
+		{"stale", "agent-1", now.Add(-2 * time.Minute).UnixMilli(), heartbeat.Sign(secret, now.Add(-2*time.Minute).UnixMilli(), body), body},
+		{"future", "agent-1", now.Add(2 * time.Minute).UnixMilli(), heartbeat.Sign(secret, now.Add(2*time.Minute).UnixMilli(), body), body},
+		{"unknown agent", "agent-2", ts, sig, body},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := auth.Verify(tt.agentID, tt.ts, tt.sig, tt.body, now)
+			var authErr *HeartbeatAuthError
+			if !errors.As(err, &authErr) {
+				t.Errorf("expected HeartbeatAuthError, got %v", err)
+			}
+		})
+	}
+
+	// 重新启动后旧密钥失效
+	if _, _, err := auth.Issue("agent-1"); err != nil {
+		t.Fatalf("failed to reissue secret: %v", err)
+	}
+	ts = now.Add(time.Second).UnixMilli()
+	if err := auth.Verify("agent-1", ts, heartbeat.Sign(secret, ts, body), body, now); err == nil {
+		t.Error("expected old secret to be rejected after reissue")
+	}
+}
+
+func TestHeartbeatAuthenticator_Unsigned(t *testing.T) {
+	if err := newTestAuthenticator(t, HeartbeatAuthRequired).Unsigned("agent-1"); err == nil {
+		t.Error("expected unsigned heartbeat to be rejected in required mode")
+	}
+	if err := newTestAuthenticator(t, HeartbeatAuthOptional).Unsigned("agent-1"); err != nil {
+		t.Errorf("expected unsigned heartbeat to be accepted in optional mode, got %v", err)
+	}
+}
+
+func TestHandleHeartbeat_Authentication(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	registry := NewAgentRegistry()
+	if _, err := registry.Register("test-agent", TypeCustom, "Test Agent", "/bin/test", "", "/tmp", ""); err != nil {
+		t.Fatalf("failed to register agent: %v", err)
+	}
+
+	multiManager, err := NewMultiAgentManager(t.TempDir(), logger)
+	if err != nil {
+		t.Fatalf("failed to create multi agent manager: %v", err)
+	}
+
+	auth := newTestAuthenticator(t, HeartbeatAuthRequired)
+	_, secret, err := auth.Issue("test-agent")
+	if err != nil {
+		t.Fatalf("failed to issue secret: %v", err)
+	}
+
+	receiver := NewHTTPHeartbeatReceiver(multiManager, registry, logger)
+	receiver.SetAuthenticator(auth)
+	defer receiver.Stop()
+
+	body, _ := json.Marshal(HeartbeatRequest{
+		AgentID:   "test-agent",
+		PID:       12345,
+		Status:    "running",
+		CPU:       1,
+		Memory:    1024,
+		Timestamp: time.Now().Format(time.RFC3339),
+	})
+	send := func(ts int64, sig string) int {
+		req := httptest.NewRequest(http.MethodPost, "/heartbeat", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if sig != "" {
+			req.Header.Set(heartbeat.HeaderTimestamp, strconv.FormatInt(ts, 10))
+			req.Header.Set(heartbeat.HeaderSignature, sig)
+		}
+		w := httptest.NewRecorder()
+		receiver.HandleHeartbeat(w, req)
+		return w.Code
+	}
+
+	if code := send(0, ""); code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for unsigned heartbeat, got %d", code)
+	}
+
+	ts := time.Now().UnixMilli()
+	sig := heartbeat.Sign(secret, ts, body)
+	if code := send(ts, sig); code != http.StatusOK {
+		t.Errorf("expected 200 for signed heartbeat, got %d", code)
+	}
+	if code := send(ts, sig); code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for replayed heartbeat, got %d", code)
+	}
+
+	stats := receiver.GetStats()
+	if stats.TotalRejected != 2 {
+		t.Errorf("expected 2 rejected heartbeats, got %d", stats.TotalRejected)
+	}
+	if stats.TotalReceived != 1 {
+		t.Errorf("expected 1 received heartbeat, got %d", stats.TotalReceived)
+	}
+}
+
+func TestHeartbeatReceiver_DecodeSignedHeartbeat(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	auth := newTestAuthenticator(t, HeartbeatAuthRequired)
+	_, secret, err := auth.Issue("test-agent")
+	if err != nil {
+		t.Fatalf("failed to issue secret: %v", err)
+	}
+
+	receiver := NewHeartbeatReceiver(filepath.Join(t.TempDir(), "hb.sock"), nil, logger)
+	receiver.SetAuthenticator(auth)
+
+	payload := []byte(`{"pid":12345,"status":"running","cpu":1,"memory":1024}`)
+	raw, _ := json.Marshal(heartbeat.NewEnvelope("test-agent", secret, payload, time.Now()))
+
+	hb, agentID, err := receiver.decodeHeartbeat(raw, 0)
+	if err != nil {
+		t.Fatalf("expected signed heartbeat to decode, got %v", err)
+	}
+	if agentID != "test-agent" || hb.PID != 12345 {
+		t.Errorf("unexpected decode result: agent=%s pid=%d", agentID, hb.PID)
+	}
+
+	if _, _, err := receiver.decodeHeartbeat(raw, 0); err == nil {
+		t.Error("expected replayed envelope to be rejected")
+	}
+	if _, _, err := receiver.decodeHeartbeat(payload, 0); err == nil {
+		t.Error("expected unsigned heartbeat to be rejected in required mode")
+	}
+}
+
+func TestHeartbeatReceiver_RejectsConnectionWithoutPeerCredentials(t *testing.T) {
+	// net.Pipe不是Unix Socket，无法读取对端进程凭据
+	tests := []struct {
+		mode     HeartbeatAuthMode
+		accepted bool
+	}{
+		{HeartbeatAuthRequired, false},
+		{HeartbeatAuthOptional, false},
+		{HeartbeatAuthDisabled, true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			receiver := NewHeartbeatReceiver(filepath.Join(t.TempDir(), "hb.sock"), nil, zaptest.NewLogger(t))
+			receiver.SetAuthenticator(newTestAuthenticator(t, tt.mode))
+			defer receiver.cancel()
+
+			server, client := net.Pipe()
+			defer client.Close()
+			receiver.wg.Add(1)
+			go receiver.handleConnection(server)
+
+			// 连接被拒绝时服务端直接关闭，写入失败；否则服务端读取心跳
+			client.SetWriteDeadline(time.Now().Add(2 * time.Second))
+			_, err := client.Write([]byte(`{"pid":12345,"status":"running"}` + "\n"))
+			if accepted := err == nil; accepted != tt.accepted {
+				t.Errorf("expected accepted=%v, got write error %v", tt.accepted, err)
+			}
+		})
+	}
+}
+
+func TestMultiAgentManager_SetHeartbeatEndpoints(t *testing.T) {
+	mam, err := NewMultiAgentManager(t.TempDir(), zaptest.NewLogger(t))
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+	existing, err := mam.RegisterAgent(&AgentInfo{ID: "existing", Type: TypeCustom})
+	if err != nil {
+		t.Fatalf("failed to register agent: %v", err)
+	}
+
+	mam.SetHeartbeatEndpoints("http://127.0.0.1:8084/heartbeat", "")
+	added, err := mam.RegisterAgent(&AgentInfo{ID: "added", Type: TypeCustom})
+	if err != nil {
+		t.Fatalf("failed to register agent: %v", err)
+	}
+
+	want := heartbeat.EnvHeartbeatURL + "=http://127.0.0.1:8084/heartbeat"
+	for _, instance := range []*AgentInstance{existing, added} {
+		if len(instance.heartbeatEnv) != 1 || instance.heartbeatEnv[0] != want {
+			t.Errorf("unexpected heartbeat env for %s: %v", instance.info.ID, instance.heartbeatEnv)
+		}
+	}
+}
@@ -2,22 +2,30 @@ package agent
 
 import (
 	"encoding/json"
+	"io"
 	"net/http"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/bingooyong/ops-scaffold-framework/daemon/pkg/heartbeat"
 	"go.uber.org/zap"
 )
 
+// maxHeartbeatBodySize 心跳请求体大小上限
+const maxHeartbeatBodySize = 64 << 10
+
 // Heartbeat Agent心跳数据结构体
 type Heartbeat struct {
-	AgentID   string    // Agent唯一标识符
-	PID       int       // Agent进程ID
-	Status    string    // Agent运行状态(running/stopped/error)
-	CPU       float64   // CPU使用率(百分比)
-	Memory    uint64    // 内存占用(字节)
-	Timestamp time.Time // 心跳时间戳
+	AgentID   string             // Agent唯一标识符
+	PID       int                // Agent进程ID
+	Status    string             // Agent运行状态(running/stopped/error)
+	CPU       float64            // CPU使用率(百分比)
+	Memory    uint64             // 内存占用(字节)
+	Timestamp time.Time          // 心跳时间戳
+	Metrics   map[string]float64 // 自定义数值指标
+	Fields    map[string]string  // 自定义状态字段
 }
 
 // HeartbeatRequest HTTP请求结构体(用于JSON解析)
@@ -28,6 +36,12 @@ type HeartbeatRequest struct {
 	CPU       float64 `json:"cpu"`       // CPU使用率(百分比)
 	Memory    uint64  `json:"memory"`    // 内存占用(字节)
 	Timestamp string  `json:"timestamp"` // 心跳时间戳(JSON时间格式,可选)
+
+	// Metrics 自定义数值指标(可选,如队列深度、每秒事件数),最多MaxCustomMetrics个
+	Metrics map[string]float64 `json:"metrics,omitempty"`
+
+	// Fields 自定义状态字段(可选),最多MaxCustomFields个
+	Fields map[string]string `json:"fields,omitempty"`
 }
 
 // HeartbeatResponse HTTP响应结构体
@@ -42,6 +56,8 @@ type HeartbeatStats struct {
 	TotalReceived    int64         `json:"total_received"`     // 总接收数量
 	TotalProcessed   int64         `json:"total_processed"`    // 总处理数量
 	TotalErrors      int64         `json:"total_errors"`       // 总错误数量
+	TotalDropped     int64         `json:"total_dropped"`      // 工作池已满时丢弃的数量
+	TotalRejected    int64         `json:"total_rejected"`     // 签名认证失败被拒绝的数量
 	LastReceivedTime time.Time     `json:"last_received_time"` // 最后接收时间
 	AverageLatency   time.Duration `json:"average_latency"`    // 平均处理延迟
 }
@@ -82,6 +98,12 @@ type HTTPHeartbeatReceiver struct {
 
 	// stopped 是否已停止
 	stopped atomic.Bool
+
+	// perfMetrics 性能指标(可选，用于记录丢弃数和处理延迟分布)
+	perfMetrics *PerformanceMetrics
+
+	// auth 心跳认证器(可选，未设置时不校验签名)
+	auth *HeartbeatAuthenticator
 }
 
 // NewHTTPHeartbeatReceiver 创建新的HTTP心跳接收器
@@ -113,6 +135,16 @@ func NewHTTPHeartbeatReceiver(
 	return hr
 }
 
+// SetPerformanceMetrics 设置性能指标收集器(需在接收心跳前调用)
+func (hr *HTTPHeartbeatReceiver) SetPerformanceMetrics(pm *PerformanceMetrics) {
+	hr.perfMetrics = pm
+}
+
+// SetAuthenticator 设置心跳认证器(需在接收心跳前调用)
+func (hr *HTTPHeartbeatReceiver) SetAuthenticator(auth *HeartbeatAuthenticator) {
+	hr.auth = auth
+}
+
 // HandleHeartbeat HTTP handler处理心跳请求
 func (hr *HTTPHeartbeatReceiver) HandleHeartbeat(w http.ResponseWriter, r *http.Request) {
 	// 检查HTTP方法
@@ -127,9 +159,16 @@ func (hr *HTTPHeartbeatReceiver) HandleHeartbeat(w http.ResponseWriter, r *http.
 		return
 	}
 
+	// 读取原始请求体(签名覆盖原始请求体)
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxHeartbeatBodySize))
+	if err != nil {
+		hr.writeErrorResponse(w, http.StatusBadRequest, "failed to read request body: "+err.Error())
+		return
+	}
+
 	// 解析JSON请求体
 	var req HeartbeatRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := json.Unmarshal(body, &req); err != nil {
 		hr.logger.Warn("failed to decode heartbeat request",
 			zap.Error(err))
 		hr.writeErrorResponse(w, http.StatusBadRequest, "invalid JSON format: "+err.Error())
@@ -145,6 +184,19 @@ func (hr *HTTPHeartbeatReceiver) HandleHeartbeat(w http.ResponseWriter, r *http.
 		return
 	}
 
+	// 校验心跳签名
+	if err := hr.authenticate(r, req.AgentID, body); err != nil {
+		hr.mu.Lock()
+		hr.stats.TotalRejected++
+		hr.mu.Unlock()
+		hr.logger.Warn("rejected unauthenticated heartbeat",
+			zap.String("agent_id", req.AgentID),
+			zap.String("remote_addr", r.RemoteAddr),
+			zap.Error(err))
+		hr.writeErrorResponse(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
 	// 转换为Heartbeat结构体
 	hb := hr.convertToHeartbeat(&req)
 
@@ -165,6 +217,12 @@ func (hr *HTTPHeartbeatReceiver) HandleHeartbeat(w http.ResponseWriter, r *http.
 		return
 	default:
 		// channel满,记录WARNING并返回503
+		hr.mu.Lock()
+		hr.stats.TotalDropped++
+		hr.mu.Unlock()
+		if hr.perfMetrics != nil {
+			hr.perfMetrics.RecordHeartbeat(0, true)
+		}
 		hr.logger.Warn("heartbeat worker pool is full, dropping heartbeat",
 			zap.String("agent_id", req.AgentID),
 			zap.Int("channel_capacity", cap(hr.workerPool)))
@@ -173,6 +231,23 @@ func (hr *HTTPHeartbeatReceiver) HandleHeartbeat(w http.ResponseWriter, r *http.
 	}
 }
 
+// authenticate 校验请求头携带的心跳签名
+func (hr *HTTPHeartbeatReceiver) authenticate(r *http.Request, agentID string, body []byte) error {
+	if hr.auth == nil {
+		return nil
+	}
+
+	signature := r.Header.Get(heartbeat.HeaderSignature)
+	if signature == "" {
+		return hr.auth.Unsigned(agentID)
+	}
+	timestamp, err := strconv.ParseInt(r.Header.Get(heartbeat.HeaderTimestamp), 10, 64)
+	if err != nil {
+		return &HeartbeatAuthError{AgentID: agentID, Reason: "invalid " + heartbeat.HeaderTimestamp + " header"}
+	}
+	return hr.auth.Verify(agentID, timestamp, signature, body, time.Now())
+}
+
 // validateRequest 验证心跳请求数据
 func (hr *HTTPHeartbeatReceiver) validateRequest(req *HeartbeatRequest) error {
 	// 验证agent_id非空
@@ -200,7 +275,8 @@ func (hr *HTTPHeartbeatReceiver) validateRequest(req *HeartbeatRequest) error {
 		return &ValidationError{Field: "memory", Message: "memory must be greater than or equal to 0"}
 	}
 
-	return nil
+	// 验证自定义指标和状态字段
+	return ValidateCustomMetrics(req.Metrics, req.Fields)
 }
 
 // convertToHeartbeat 将HeartbeatRequest转换为Heartbeat
@@ -211,6 +287,8 @@ func (hr *HTTPHeartbeatReceiver) convertToHeartbeat(req *HeartbeatRequest) *Hear
 		Status:  req.Status,
 		CPU:     req.CPU,
 		Memory:  req.Memory,
+		Metrics: req.Metrics,
+		Fields:  req.Fields,
 	}
 
 	// 解析时间戳(如果提供)
@@ -238,7 +316,7 @@ func (hr *HTTPHeartbeatReceiver) processHeartbeat(hb *Heartbeat) {
 	startTime := time.Now()
 
 	// 调用multiManager.UpdateHeartbeat更新元数据
-	if err := hr.multiManager.UpdateHeartbeat(hb.AgentID, hb.Timestamp, hb.CPU, hb.Memory); err != nil {
+	if err := hr.multiManager.UpdateHeartbeat(hb.AgentID, hb.Timestamp, hb.CPU, hb.Memory, hb.Metrics, hb.Fields); err != nil {
 		hr.logger.Error("failed to update heartbeat",
 			zap.String("agent_id", hb.AgentID),
 			zap.Error(err))
@@ -254,6 +332,9 @@ func (hr *HTTPHeartbeatReceiver) processHeartbeat(hb *Heartbeat) {
 	// 更新统计信息(处理成功)
 	atomic.AddInt64(&hr.stats.TotalProcessed, 1)
 	hr.totalLatency.Add(latency.Nanoseconds())
+	if hr.perfMetrics != nil {
+		hr.perfMetrics.RecordHeartbeat(latency, false)
+	}
 
 	// 更新平均延迟
 	hr.mu.Lock()
@@ -340,6 +421,8 @@ func (hr *HTTPHeartbeatReceiver) GetStats() HeartbeatStats {
 		TotalReceived:    hr.stats.TotalReceived,
 		TotalProcessed:   hr.stats.TotalProcessed,
 		TotalErrors:      hr.stats.TotalErrors,
+		TotalDropped:     hr.stats.TotalDropped,
+		TotalRejected:    hr.stats.TotalRejected,
 		LastReceivedTime: hr.stats.LastReceivedTime,
 		AverageLatency:   hr.stats.AverageLatency,
 	}
@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -351,6 +353,156 @@ func TestProcessHeartbeat_UpdateMetadata(t *testing.T) {
 	}
 }
 
+// TestProcessHeartbeat_CustomMetrics 测试心跳携带的自定义指标保存为最新值
+func TestProcessHeartbeat_CustomMetrics(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	tmpDir, err := os.MkdirTemp("", "heartbeat_test_*")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	multiManager, err := NewMultiAgentManager(tmpDir, logger)
+	if err != nil {
+		t.Fatalf("failed to create multi agent manager: %v", err)
+	}
+
+	registry := multiManager.GetRegistry()
+	_, err = multiManager.RegisterAgent(&AgentInfo{
+		ID:         "test-agent",
+		Type:       TypeCustom,
+		Name:       "Test Agent",
+		BinaryPath: "/bin/test",
+		WorkDir:    "/tmp",
+	})
+	if err != nil {
+		t.Fatalf("failed to register agent: %v", err)
+	}
+
+	receiver := NewHTTPHeartbeatReceiver(multiManager, registry, logger)
+	defer receiver.Stop()
+
+	ts := time.Now()
+	receiver.processHeartbeat(&Heartbeat{
+		AgentID:   "test-agent",
+		PID:       12345,
+		CPU:       10,
+		Memory:    1024,
+		Timestamp: ts,
+		Metrics:   map[string]float64{"queue_depth": 42, "events_per_second": 1200.5},
+		Fields:    map[string]string{"pipeline": "ok"},
+	})
+
+	metadata, err := multiManager.GetAgentMetadata("test-agent")
+	if err != nil {
+		t.Fatalf("failed to get metadata: %v", err)
+	}
+	if metadata.CustomMetrics["queue_depth"] != 42 || metadata.CustomMetrics["events_per_second"] != 1200.5 {
+		t.Errorf("unexpected custom metrics: %v", metadata.CustomMetrics)
+	}
+	if metadata.CustomFields["pipeline"] != "ok" {
+		t.Errorf("unexpected custom fields: %v", metadata.CustomFields)
+	}
+	if !metadata.CustomMetricsUpdatedAt.Equal(ts) {
+		t.Errorf("expected CustomMetricsUpdatedAt %v, got %v", ts, metadata.CustomMetricsUpdatedAt)
+	}
+
+	// 不携带自定义指标的心跳保留上一次的值
+	receiver.processHeartbeat(&Heartbeat{
+		AgentID:   "test-agent",
+		PID:       12345,
+		Timestamp: ts.Add(time.Second),
+	})
+
+	metadata, err = multiManager.GetAgentMetadata("test-agent")
+	if err != nil {
+		t.Fatalf("failed to get metadata: %v", err)
+	}
+	if metadata.CustomMetrics["queue_depth"] != 42 {
+		t.Errorf("expected custom metrics to be kept, got %v", metadata.CustomMetrics)
+	}
+}
+
+// TestValidateCustomMetrics 测试自定义指标校验
+func TestValidateCustomMetrics(t *testing.T) {
+	tooManyMetrics := make(map[string]float64)
+	for i := 0; i <= MaxCustomMetrics; i++ {
+		tooManyMetrics[fmt.Sprintf("m_%d", i)] = float64(i)
+	}
+
+	tests := []struct {
+		name    string
+		metrics map[string]float64
+		fields  map[string]string
+		field   string
+	}{
+		{name: "empty"},
+		{name: "valid", metrics: map[string]float64{"queue.depth": 1}, fields: map[string]string{"state": "ok"}},
+		{name: "too many metrics", metrics: tooManyMetrics, field: "metrics"},
+		{name: "invalid metric name", metrics: map[string]float64{"queue-depth": 1}, field: "metrics.queue-depth"},
+		{name: "NaN value", metrics: map[string]float64{"rate": math.NaN()}, field: "metrics.rate"},
+		{name: "Inf value", metrics: map[string]float64{"rate": math.Inf(1)}, field: "metrics.rate"},
+		{name: "empty field name", fields: map[string]string{"": "x"}, field: "fields."},
+		{name: "field value too long", fields: map[string]string{"state": strings.Repeat("x", MaxCustomFieldValueLength+1)}, field: "fields.state"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateCustomMetrics(tt.metrics, tt.fields)
+			if tt.field == "" {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				return
+			}
+			verr, ok := err.(*ValidationError)
+			if !ok {
+				t.Fatalf("expected *ValidationError, got %v", err)
+			}
+			if verr.Field != tt.field {
+				t.Errorf("expected field %q, got %q", tt.field, verr.Field)
+			}
+		})
+	}
+}
+
+// TestHandleHeartbeat_InvalidCustomMetrics 测试携带非法自定义指标的心跳被拒绝
+func TestHandleHeartbeat_InvalidCustomMetrics(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	registry := NewAgentRegistry()
+
+	_, err := registry.Register("test-agent", TypeCustom, "Test Agent", "/bin/test", "", "/tmp", "")
+	if err != nil {
+		t.Fatalf("failed to register agent: %v", err)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "heartbeat_test_*")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	multiManager, err := NewMultiAgentManager(tmpDir, logger)
+	if err != nil {
+		t.Fatalf("failed to create multi agent manager: %v", err)
+	}
+
+	receiver := NewHTTPHeartbeatReceiver(multiManager, registry, logger)
+	defer receiver.Stop()
+
+	body := []byte(`{"agent_id":"test-agent","pid":12345,"cpu":1,"memory":1,"metrics":{"bad name":1}}`)
+	req := httptest.NewRequest(http.MethodPost, "/heartbeat", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	receiver.HandleHeartbeat(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for invalid custom metric name, got %d", w.Code)
+	}
+}
+
 // TestWorkerPool_ConcurrentProcessing 测试并发处理
 func TestWorkerPool_ConcurrentProcessing(t *testing.T) {
 	logger := zaptest.NewLogger(t)
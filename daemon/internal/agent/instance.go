@@ -4,11 +4,13 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"sync"
 	"syscall"
 	"time"
 
+	"github.com/bingooyong/ops-scaffold-framework/daemon/internal/config"
+	"github.com/bingooyong/ops-scaffold-framework/daemon/pkg/control"
+	"github.com/bingooyong/ops-scaffold-framework/daemon/pkg/heartbeat"
 	"go.uber.org/zap"
 )
 
@@ -32,6 +34,49 @@ type AgentInstance struct {
 
 	// manuallyStopped 标记Agent是否被手动停止（用于防止健康检查器自动重启）
 	manuallyStopped bool
+
+	// exited 当前进程退出时关闭的通道(仅由本实例启动的进程有效)
+	exited chan struct{}
+
+	// lastExit 最近一次进程退出状态
+	lastExit *ExitStatus
+
+	// restartPolicy 重启策略和崩溃熔断器（可选）
+	restartPolicy *RestartPolicy
+
+	// lifecycle 停止信号、停止超时和生命周期钩子配置（可选，未设置时使用默认值）
+	lifecycle *LifecycleConfig
+
+	// processStore 进程状态存储（可选），用于daemon重启后接管仍在运行的Agent
+	processStore ProcessStateStore
+
+	// processAttrs 运行用户、环境变量、umask和资源限制（可选，未设置时与daemon相同）
+	processAttrs *ProcessAttrs
+
+	// cgroup Agent独立的cgroup v2（可选），用于资源限制和资源使用统计
+	cgroup *AgentCgroup
+
+	// dependsOn 依赖的Agent ID，批量启动时依赖就绪后才启动，停止顺序相反
+	dependsOn []string
+
+	// readiness 就绪条件（可选），未设置时进程启动即视为就绪
+	readiness *config.ReadinessConfig
+
+	// heartbeatAuth 心跳认证器（可选），启动时为Agent下发心跳密钥
+	heartbeatAuth *HeartbeatAuthenticator
+
+	// heartbeatNonce 当前运行派生心跳密钥使用的随机数，随进程状态持久化
+	heartbeatNonce string
+
+	// heartbeatEnv 下发给Agent的心跳地址环境变量
+	heartbeatEnv []string
+
+	// control 控制通道配置（可选），未设置时使用默认配置
+	control *ControlConfig
+
+	// exitCallback 进程意外退出时的回调(可选)
+	// tripped 表示本次退出是否触发了崩溃熔断
+	exitCallback func(exit *ExitStatus, tripped bool)
 }
 
 // NewAgentInstance 创建新的Agent实例管理器
@@ -88,15 +133,57 @@ func (ai *AgentInstance) startInternal(ctx context.Context, clearManuallyStopped
 
 	// 生成启动参数
 	args := ai.generateArgs()
-	cmd := exec.CommandContext(ctx, ai.info.BinaryPath, args...)
+	// 不使用CommandContext: Agent进程的生命周期由实例管理，不随调用方上下文取消而终止，
+	// 以便daemon退出时可以选择保留Agent运行
+	attrs := ai.processAttrsLocked()
+	// 启用cgroup时由启动辅助进程在exec Agent二进制前加入cgroup，避免Agent启动后立即创建的子进程逃逸
+	var cgroupDir string
+	if ai.cgroup != nil {
+		cgroupDir = ai.cgroup.Path()
+	}
+	cmd, helper, err := attrs.command(ai.info.BinaryPath, args, cgroupDir)
+	if err != nil {
+		ai.info.SetStatus(StatusFailed)
+		return fmt.Errorf("failed to prepare agent command: %w", err)
+	}
 	cmd.Dir = ai.info.WorkDir
 
-	// 设置进程组，确保Agent独立运行
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		Setpgid: true,
-		Pgid:    0,
+	// 下发Agent ID和心跳密钥，Agent使用密钥为心跳签名
+	if ai.heartbeatAuth != nil {
+		nonce, secret, err := ai.heartbeatAuth.Issue(ai.info.ID)
+		if err != nil {
+			if helper != nil {
+				helper.close()
+			}
+			ai.info.SetStatus(StatusFailed)
+			return fmt.Errorf("failed to issue heartbeat secret: %w", err)
+		}
+		cmd.Env = append(cmd.Env, heartbeat.EnvAgentID+"="+ai.info.ID, heartbeat.EnvSecret+"="+secret)
+		ai.heartbeatNonce = nonce
+	}
+	// 下发心跳上报地址
+	cmd.Env = append(cmd.Env, ai.heartbeatEnv...)
+
+	// 下发控制Socket路径，Agent在该路径上监听控制命令
+	if ai.info.SocketPath != "" {
+		cmd.Env = append(cmd.Env, control.EnvSocket+"="+ai.info.SocketPath)
+	}
+
+	// 创建cgroup并写入资源限制
+	if ai.cgroup != nil {
+		if err := ai.cgroup.Setup(); err != nil {
+			if helper != nil {
+				helper.close()
+			}
+			ai.info.SetStatus(StatusFailed)
+			return fmt.Errorf("failed to setup cgroup: %w", err)
+		}
 	}
 
+	// 设置进程组，确保Agent独立运行
+	cmd.SysProcAttr.Setpgid = true
+	cmd.SysProcAttr.Pgid = 0
+
 	// 重定向输出到日志文件
 	logFilePath := ai.getLogFilePath()
 
@@ -106,6 +193,9 @@ func (ai *AgentInstance) startInternal(ctx context.Context, clearManuallyStopped
 		logDir = fmt.Sprintf("/tmp/agents/%s/logs", ai.info.ID)
 	}
 	if err := os.MkdirAll(logDir, 0755); err != nil {
+		if helper != nil {
+			helper.close()
+		}
 		ai.info.SetStatus(StatusFailed)
 		return fmt.Errorf("failed to create log directory: %w", err)
 	}
@@ -126,6 +216,9 @@ func (ai *AgentInstance) startInternal(ctx context.Context, clearManuallyStopped
 		0644,
 	)
 	if err != nil {
+		if helper != nil {
+			helper.close()
+		}
 		ai.info.SetStatus(StatusFailed)
 		return fmt.Errorf("failed to open log file: %w", err)
 	}
@@ -139,12 +232,29 @@ func (ai *AgentInstance) startInternal(ctx context.Context, clearManuallyStopped
 
 	// 启动进程
 	if err := cmd.Start(); err != nil {
+		if helper != nil {
+			helper.close()
+		}
 		logFile.Close()
 		ai.info.SetStatus(StatusFailed)
 		return fmt.Errorf("failed to start agent: %w", err)
 	}
 
-	ai.process = cmd.Process
+	// 通过启动辅助进程启动时，等待其加入cgroup、设置umask/资源限制并exec Agent二进制
+	if helper != nil {
+		if err := helper.wait(); err != nil {
+			cmd.Wait()
+			logFile.Close()
+			ai.info.SetStatus(StatusFailed)
+			return fmt.Errorf("failed to start agent: %w", err)
+		}
+	}
+
+	startedAt := time.Now()
+	exited := make(chan struct{})
+	proc := cmd.Process
+	ai.process = proc
+	ai.exited = exited
 	pid := cmd.Process.Pid
 	ai.info.SetPID(pid)
 	ai.info.SetStatus(StatusRunning)
@@ -157,40 +267,85 @@ func (ai *AgentInstance) startInternal(ctx context.Context, clearManuallyStopped
 		zap.String("binary", ai.info.BinaryPath),
 		zap.Strings("args", args))
 
+	// 执行启动后钩子(异步，不阻塞启动流程)
+	if hook := ai.lifecycleLocked().PostStart; hook != nil {
+		go ai.runLifecycleHook(context.Background(), "post_start", hook, pid)
+	}
+
+	// 持久化进程状态，daemon重启后据此接管仍在运行的进程
+	ai.saveProcessStateLocked(pid, startedAt)
+
 	// 在后台等待进程退出
+	// 该goroutine是进程唯一的Wait调用者，Stop通过exited通道等待进程退出
 	go func() {
 		cmd.Wait()
 		logFile.Close()
+		exit := newExitStatus(cmd.ProcessState, startedAt)
+		close(exited)
+		ai.handleProcessExit(proc, pid, exit)
+	}()
 
-		// 更新状态
-		ai.mu.Lock()
-		// 进程退出时更新状态
-		// 注意：如果用户调用了 Stop，Stop 方法会设置 manuallyStopped = true
-		// 如果进程意外退出，manuallyStopped 应该是 false（因为不是手动停止）
-		// 我们保持 manuallyStopped 标志不变，让健康检查器根据状态判断是否需要重启
+	return nil
+}
+
+// handleProcessExit 处理进程退出(由等待进程退出的goroutine在关闭exited通道后调用)
+func (ai *AgentInstance) handleProcessExit(proc *os.Process, pid int, exit *ExitStatus) {
+	// 更新状态
+	ai.mu.Lock()
+	// 如果process已被Stop清理或被新进程替换，说明是手动停止，不再修改状态
+	// 如果进程意外退出（manuallyStopped 为 false），健康检查器可以根据重启策略自动重启
+	unexpected := ai.process == proc
+	tripped := false
+	if unexpected {
 		ai.process = nil
+		ai.exited = nil
 		ai.info.SetPID(0)
-		ai.info.SetStatus(StatusStopped)
-		// 如果进程意外退出（manuallyStopped 为 false），健康检查器可以自动重启
-		// 如果用户手动停止（manuallyStopped 为 true），健康检查器不会自动重启
-		ai.mu.Unlock()
+		ai.deleteProcessStateLocked()
+		// 仅非零退出码或被信号终止计入崩溃窗口，正常退出(如on-failure下完成任务)不触发熔断
+		if ai.restartPolicy != nil && !exit.Success() && ai.restartPolicy.RecordCrash(exit.ExitedAt) {
+			tripped = true
+			ai.info.SetStatus(StatusFailed)
+		} else {
+			ai.info.SetStatus(StatusStopped)
+		}
+	} else {
+		exit.ManuallyStopped = true
+	}
+	ai.lastExit = exit
+	callback := ai.exitCallback
+	ai.mu.Unlock()
 
-		ai.logger.Warn("agent process exited",
+	if !unexpected {
+		ai.logger.Info("agent process exited after stop",
 			zap.String("agent_id", ai.info.ID),
-			zap.String("agent_type", string(ai.info.Type)),
-			zap.Int("pid", pid))
-	}()
+			zap.Int("pid", pid),
+			zap.String("exit_status", exit.String()))
+		return
+	}
 
-	return nil
+	ai.logger.Warn("agent process exited",
+		zap.String("agent_id", ai.info.ID),
+		zap.String("agent_type", string(ai.info.Type)),
+		zap.Int("pid", pid),
+		zap.String("exit_status", exit.String()),
+		zap.Duration("run_duration", exit.Duration()),
+		zap.Bool("circuit_open", tripped))
+
+	if callback != nil {
+		callback(exit, tripped)
+	}
 }
 
+// logRotateCheckInterval 日志轮转检查间隔(需小于按小时轮转的周期)
+const logRotateCheckInterval = time.Minute
+
 // periodicRotateCheck 定期检查日志轮转
 func (ai *AgentInstance) periodicRotateCheck() {
 	if ai.logRotator == nil {
 		return
 	}
 
-	ticker := time.NewTicker(5 * time.Minute)
+	ticker := time.NewTicker(logRotateCheckInterval)
 	defer ticker.Stop()
 
 	for {
@@ -260,6 +415,7 @@ func (ai *AgentInstance) Stop(ctx context.Context, graceful bool) error {
 		}
 
 		ai.process = process
+		ai.exited = nil
 		ai.logger.Info("found process by PID",
 			zap.String("agent_id", ai.info.ID),
 			zap.Int("pid", pid))
@@ -274,6 +430,7 @@ func (ai *AgentInstance) Stop(ctx context.Context, graceful bool) error {
 		ai.info.SetPID(0)
 		ai.info.SetStatus(StatusStopped)
 		ai.manuallyStopped = true
+		ai.deleteProcessStateLocked()
 		return nil
 	}
 
@@ -287,69 +444,101 @@ func (ai *AgentInstance) Stop(ctx context.Context, graceful bool) error {
 	ai.info.SetStatus(StatusStopping)
 
 	if graceful {
-		// 发送SIGTERM，等待优雅退出
-		if err := ai.process.Signal(syscall.SIGTERM); err != nil {
+		lifecycle := ai.lifecycleLocked()
+
+		// 执行停止前钩子(如通知Agent刷新队列)，失败不影响停止流程
+		// 钩子可能运行较久，执行期间释放锁，避免阻塞状态查询和健康检查；
+		// 先标记手动停止，防止健康检查在此期间重启进程
+		if lifecycle.PreStop != nil {
+			process := ai.process
+			ai.manuallyStopped = true
+			ai.mu.Unlock()
+			ai.runLifecycleHook(ctx, "pre_stop", lifecycle.PreStop, pid)
+			ai.mu.Lock()
+
+			// 钩子执行期间进程可能已退出或被重新启动，只向原进程发送停止信号
+			if ai.info.GetPID() != pid || ai.process != process {
+				if ai.process != nil {
+					return fmt.Errorf("agent %s was restarted while running pre_stop hook (pid %d -> %d)",
+						ai.info.ID, pid, ai.info.GetPID())
+				}
+				ai.logger.Info("agent exited while running pre_stop hook",
+					zap.String("agent_id", ai.info.ID),
+					zap.Int("pid", pid))
+				return nil
+			}
+			if !ai.isRunningLocked() {
+				ai.logger.Info("agent exited while running pre_stop hook",
+					zap.String("agent_id", ai.info.ID),
+					zap.Int("pid", pid))
+				ai.process = nil
+				ai.exited = nil
+				ai.info.SetPID(0)
+				ai.info.SetStatus(StatusStopped)
+				ai.deleteProcessStateLocked()
+				return nil
+			}
+		}
+
+		// 发送停止信号，等待优雅退出
+		if err := ai.process.Signal(lifecycle.StopSignal); err != nil {
 			// 如果进程已经不存在,直接标记为已停止
-			ai.logger.Warn("failed to send SIGTERM, process may have exited",
+			ai.logger.Warn("failed to send stop signal, process may have exited",
 				zap.String("agent_id", ai.info.ID),
+				zap.String("signal", lifecycle.StopSignal.String()),
 				zap.Error(err))
 			// 进程已退出,直接返回
 			ai.process = nil
 			ai.info.SetPID(0)
 			ai.info.SetStatus(StatusStopped)
 			ai.manuallyStopped = true
+			ai.deleteProcessStateLocked()
 			return nil
 		}
 
-		// 等待最多30秒
-		done := make(chan error)
-		go func() {
-			_, err := ai.process.Wait()
-			done <- err
-		}()
+		// 等待最多StopTimeout
+		done := ai.exitChanLocked()
 
 		select {
-		case waitErr := <-done:
-			if waitErr != nil {
-				ai.logger.Debug("agent process wait returned error",
-					zap.String("agent_id", ai.info.ID),
-					zap.Error(waitErr))
-			}
+		case <-done:
 			ai.logger.Info("agent stopped gracefully",
 				zap.String("agent_id", ai.info.ID),
 				zap.String("agent_type", string(ai.info.Type)))
-		case <-time.After(30 * time.Second):
-			ai.logger.Warn("agent graceful shutdown timeout, killing",
+		case <-time.After(lifecycle.StopTimeout):
+			ai.logger.Warn("agent graceful shutdown timeout, killing process group",
 				zap.String("agent_id", ai.info.ID),
-				zap.String("agent_type", string(ai.info.Type)))
-			ai.process.Kill()
+				zap.String("agent_type", string(ai.info.Type)),
+				zap.Duration("stop_timeout", lifecycle.StopTimeout))
+			ai.killProcessGroupLocked(pid)
 			// 等待Kill完成
 			<-done
 		case <-ctx.Done():
-			ai.logger.Warn("context cancelled, killing agent",
+			ai.logger.Warn("context cancelled, killing agent process group",
 				zap.String("agent_id", ai.info.ID),
 				zap.String("agent_type", string(ai.info.Type)))
-			ai.process.Kill()
+			ai.killProcessGroupLocked(pid)
 			// 等待Kill完成
 			<-done
 		}
 	} else {
-		// 强制杀死
-		if err := ai.process.Kill(); err != nil {
+		// 强制杀死整个进程组
+		if err := ai.killProcessGroupLocked(pid); err != nil {
 			// 如果进程已经不存在,仅记录警告
 			ai.logger.Warn("failed to kill process, may have already exited",
 				zap.String("agent_id", ai.info.ID),
 				zap.Error(err))
 		} else {
 			// 等待进程退出
-			ai.process.Wait()
+			<-ai.exitChanLocked()
 		}
 	}
 
 	ai.process = nil
+	ai.exited = nil
 	ai.info.SetPID(0)
 	ai.info.SetStatus(StatusStopped)
 	ai.manuallyStopped = true // 标记为手动停止
+	ai.deleteProcessStateLocked()
 
 	return nil
 }
@@ -435,6 +624,213 @@ func (ai *AgentInstance) IsManuallyStopped() bool {
 	return ai.manuallyStopped
 }
 
+// exitChanLocked 返回当前进程退出时关闭的通道(需要持锁调用)
+// 由本实例启动的进程直接使用后台Wait goroutine的通道，避免重复Wait丢失退出状态；
+// 通过PID找回的进程则单独等待
+func (ai *AgentInstance) exitChanLocked() <-chan struct{} {
+	if ai.exited != nil {
+		return ai.exited
+	}
+
+	done := make(chan struct{})
+	proc := ai.process
+	go func() {
+		if _, err := proc.Wait(); err != nil {
+			ai.logger.Debug("agent process wait returned error",
+				zap.String("agent_id", ai.info.ID),
+				zap.Error(err))
+		}
+		close(done)
+	}()
+	return done
+}
+
+// killProcessGroupLocked 向Agent进程组发送SIGKILL(需要持锁调用)
+// 仅当进程是自身进程组的组长时(由daemon以Setpgid启动)才杀整个进程组，
+// 避免误杀与daemon同组的进程；否则只杀主进程
+func (ai *AgentInstance) killProcessGroupLocked(pid int) error {
+	if pgid, err := syscall.Getpgid(pid); err == nil && pgid == pid {
+		if err := syscall.Kill(-pgid, syscall.SIGKILL); err == nil {
+			return nil
+		}
+	}
+	return ai.process.Kill()
+}
+
+// SetLifecycle 设置停止信号、停止超时和生命周期钩子
+func (ai *AgentInstance) SetLifecycle(lifecycle *LifecycleConfig) {
+	ai.mu.Lock()
+	defer ai.mu.Unlock()
+	ai.lifecycle = lifecycle
+}
+
+// SetProcessAttrs 设置运行用户、环境变量、umask和资源限制
+func (ai *AgentInstance) SetProcessAttrs(attrs *ProcessAttrs) {
+	ai.mu.Lock()
+	defer ai.mu.Unlock()
+	ai.processAttrs = attrs
+}
+
+// SetHeartbeatAuthenticator 设置心跳认证器(下次启动时生效)
+func (ai *AgentInstance) SetHeartbeatAuthenticator(auth *HeartbeatAuthenticator) {
+	ai.mu.Lock()
+	defer ai.mu.Unlock()
+	ai.heartbeatAuth = auth
+}
+
+// SetHeartbeatEnv 设置下发给Agent的心跳地址环境变量(下次启动时生效)
+func (ai *AgentInstance) SetHeartbeatEnv(env []string) {
+	ai.mu.Lock()
+	defer ai.mu.Unlock()
+	ai.heartbeatEnv = env
+}
+
+// SetCgroup 设置Agent独立的cgroup(下次启动时生效)
+func (ai *AgentInstance) SetCgroup(cgroup *AgentCgroup) {
+	ai.mu.Lock()
+	defer ai.mu.Unlock()
+	ai.cgroup = cgroup
+}
+
+// GetCgroup 获取Agent独立的cgroup，未启用时返回nil
+func (ai *AgentInstance) GetCgroup() *AgentCgroup {
+	ai.mu.Lock()
+	defer ai.mu.Unlock()
+	return ai.cgroup
+}
+
+// processAttrsLocked 获取进程属性，未设置时返回默认值(需要持锁调用)
+func (ai *AgentInstance) processAttrsLocked() *ProcessAttrs {
+	if ai.processAttrs == nil {
+		return DefaultProcessAttrs()
+	}
+	return ai.processAttrs
+}
+
+// lifecycleLocked 获取生命周期配置，未设置时返回默认值(需要持锁调用)
+func (ai *AgentInstance) lifecycleLocked() *LifecycleConfig {
+	if ai.lifecycle == nil {
+		return DefaultLifecycleConfig()
+	}
+	return ai.lifecycle
+}
+
+// runLifecycleHook 执行生命周期钩子并记录结果
+func (ai *AgentInstance) runLifecycleHook(ctx context.Context, name string, hook *config.HookConfig, pid int) {
+	start := time.Now()
+	output, err := runHook(ctx, hook, ai.info, pid)
+	if err != nil {
+		ai.logger.Warn("agent lifecycle hook failed",
+			zap.String("agent_id", ai.info.ID),
+			zap.String("hook", name),
+			zap.Duration("duration", time.Since(start)),
+			zap.String("output", output),
+			zap.Error(err))
+		return
+	}
+
+	ai.logger.Info("agent lifecycle hook completed",
+		zap.String("agent_id", ai.info.ID),
+		zap.String("hook", name),
+		zap.Duration("duration", time.Since(start)),
+		zap.String("output", output))
+}
+
+// SetRestartPolicy 设置重启策略
+func (ai *AgentInstance) SetRestartPolicy(policy *RestartPolicy) {
+	ai.mu.Lock()
+	defer ai.mu.Unlock()
+	ai.restartPolicy = policy
+}
+
+// GetRestartPolicy 获取重启策略(未设置时返回nil)
+func (ai *AgentInstance) GetRestartPolicy() *RestartPolicy {
+	ai.mu.Lock()
+	defer ai.mu.Unlock()
+	return ai.restartPolicy
+}
+
+// SetExitCallback 设置进程意外退出回调
+func (ai *AgentInstance) SetExitCallback(callback func(exit *ExitStatus, tripped bool)) {
+	ai.mu.Lock()
+	defer ai.mu.Unlock()
+	ai.exitCallback = callback
+}
+
+// GetLastExit 获取最近一次进程退出状态(从未退出时返回nil)
+func (ai *AgentInstance) GetLastExit() *ExitStatus {
+	ai.mu.Lock()
+	defer ai.mu.Unlock()
+	return ai.lastExit
+}
+
+// ShouldAutoRestart 根据重启策略和熔断状态判断是否允许自动重启
+// 返回是否允许以及不允许时的原因
+func (ai *AgentInstance) ShouldAutoRestart() (bool, string) {
+	ai.mu.Lock()
+	defer ai.mu.Unlock()
+
+	if ai.manuallyStopped {
+		return false, "manually stopped"
+	}
+	if ai.restartPolicy == nil {
+		return true, ""
+	}
+	if !ai.restartPolicy.Allow(time.Now()) {
+		return false, "circuit breaker open"
+	}
+
+	// 进程仍在运行(心跳超时/资源超限)时不以上次退出状态为准
+	exit := ai.lastExit
+	if ai.process != nil {
+		exit = nil
+	}
+	if !ai.restartPolicy.ShouldRestart(exit) {
+		return false, fmt.Sprintf("restart policy %s", ai.restartPolicy.Policy)
+	}
+	return true, ""
+}
+
+// RecordCrash 记录一次未导致进程退出的故障(如心跳超时、资源超限)，返回是否触发熔断
+func (ai *AgentInstance) RecordCrash() bool {
+	ai.mu.Lock()
+	defer ai.mu.Unlock()
+
+	if ai.restartPolicy == nil {
+		return false
+	}
+	return ai.restartPolicy.RecordCrash(time.Now())
+}
+
+// MarkFailed 将Agent标记为失败状态
+// 与手动停止不同，失败状态的Agent在熔断冷却结束后仍可被自动重启
+func (ai *AgentInstance) MarkFailed() {
+	ai.mu.Lock()
+	defer ai.mu.Unlock()
+	ai.manuallyStopped = false
+	ai.info.SetStatus(StatusFailed)
+}
+
+// ResetCircuitBreaker 复位崩溃熔断器，返回复位前是否处于熔断状态
+func (ai *AgentInstance) ResetCircuitBreaker() bool {
+	ai.mu.Lock()
+	defer ai.mu.Unlock()
+
+	if ai.restartPolicy == nil {
+		return false
+	}
+	tripped := ai.restartPolicy.IsTripped()
+	ai.restartPolicy.Reset()
+	return tripped
+}
+
+// IsCircuitOpen 崩溃熔断器是否处于熔断状态
+func (ai *AgentInstance) IsCircuitOpen() bool {
+	ai.mu.Lock()
+	defer ai.mu.Unlock()
+	return ai.restartPolicy != nil && ai.restartPolicy.IsTripped()
+}
+
 // isRunningLocked 检查进程是否运行(需要持锁调用)
 func (ai *AgentInstance) isRunningLocked() bool {
 	if ai.process == nil {
@@ -526,6 +922,14 @@ func (ai *AgentInstance) calculateBackoff() time.Duration {
 		return 0
 	}
 
+	// 配置了退避参数时使用指数退避
+	ai.mu.Lock()
+	policy := ai.restartPolicy
+	ai.mu.Unlock()
+	if policy != nil && policy.BackoffBase > 0 {
+		return policy.Backoff(restartCount)
+	}
+
 	switch {
 	case restartCount < 1:
 		return 0
@@ -764,3 +764,47 @@ func TestAgentInstance_LogFilePath(t *testing.T) {
 		t.Errorf("expected log path %s, got %s", expectedPath, logPath)
 	}
 }
+
+func TestAgentInstance_CleanExitDoesNotTripCircuit(t *testing.T) {
+	instance := NewAgentInstance(&AgentInfo{ID: "test-agent", Type: TypeCustom}, zap.NewNop())
+	instance.SetRestartPolicy(&RestartPolicy{
+		Policy:     RestartOnFailure,
+		MaxCrashes: 1,
+		Window:     time.Minute,
+		Cooldown:   time.Minute,
+	})
+	var trippedCalls []bool
+	instance.SetExitCallback(func(exit *ExitStatus, tripped bool) {
+		trippedCalls = append(trippedCalls, tripped)
+	})
+
+	exitWith := func(code int) {
+		proc := &os.Process{Pid: 4242}
+		instance.mu.Lock()
+		instance.process = proc
+		instance.mu.Unlock()
+		now := time.Now()
+		instance.handleProcessExit(proc, proc.Pid, &ExitStatus{ExitCode: code, StartedAt: now.Add(-time.Second), ExitedAt: now})
+	}
+
+	// on-failure 下正常退出不计入崩溃窗口
+	exitWith(0)
+	if instance.GetRestartPolicy().IsTripped() {
+		t.Fatal("clean exit should not trip the circuit breaker")
+	}
+	if status := instance.GetInfo().GetStatus(); status != StatusStopped {
+		t.Errorf("expected status %s, got %s", StatusStopped, status)
+	}
+
+	// 非零退出码计入崩溃窗口
+	exitWith(1)
+	if !instance.GetRestartPolicy().IsTripped() {
+		t.Fatal("failed exit should trip the circuit breaker")
+	}
+	if status := instance.GetInfo().GetStatus(); status != StatusFailed {
+		t.Errorf("expected status %s, got %s", StatusFailed, status)
+	}
+	if len(trippedCalls) != 2 || trippedCalls[0] || !trippedCalls[1] {
+		t.Errorf("unexpected exit callbacks: %v", trippedCalls)
+	}
+}
@@ -0,0 +1,165 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/bingooyong/ops-scaffold-framework/daemon/internal/config"
+)
+
+const (
+	// defaultStopTimeout 默认优雅停止超时
+	defaultStopTimeout = 30 * time.Second
+
+	// defaultHookTimeout 默认钩子执行超时
+	defaultHookTimeout = 10 * time.Second
+
+	// maxHookOutput 钩子输出记录到日志的最大字节数
+	maxHookOutput = 1024
+)
+
+// LifecycleConfig Agent生命周期配置
+// 控制优雅停止使用的信号和超时，以及停止前/启动后执行的钩子
+type LifecycleConfig struct {
+	// StopSignal 优雅停止时发送的信号
+	StopSignal syscall.Signal
+
+	// StopTimeout 优雅停止超时，超时后SIGKILL整个进程组
+	StopTimeout time.Duration
+
+	// PreStop 停止前钩子(可选)，例如通过HTTP调用让Agent先刷新队列
+	PreStop *config.HookConfig
+
+	// PostStart 启动后钩子(可选)
+	PostStart *config.HookConfig
+}
+
+// DefaultLifecycleConfig 返回默认生命周期配置(SIGTERM, 30秒超时, 无钩子)
+func DefaultLifecycleConfig() *LifecycleConfig {
+	return &LifecycleConfig{
+		StopSignal:  syscall.SIGTERM,
+		StopTimeout: defaultStopTimeout,
+	}
+}
+
+// NewLifecycleConfig 根据Agent配置创建生命周期配置
+func NewLifecycleConfig(cfg *config.AgentItemConfig) (*LifecycleConfig, error) {
+	lc := DefaultLifecycleConfig()
+	if cfg == nil {
+		return lc, nil
+	}
+
+	if cfg.StopSignal != "" {
+		sig, err := ParseStopSignal(cfg.StopSignal)
+		if err != nil {
+			return nil, err
+		}
+		lc.StopSignal = sig
+	}
+	if cfg.StopTimeout > 0 {
+		lc.StopTimeout = cfg.StopTimeout
+	}
+	if cfg.PreStop.IsSet() {
+		hook := cfg.PreStop
+		lc.PreStop = &hook
+	}
+	if cfg.PostStart.IsSet() {
+		hook := cfg.PostStart
+		lc.PostStart = &hook
+	}
+
+	return lc, nil
+}
+
+// ParseStopSignal 解析停止信号名称(支持省略SIG前缀，不区分大小写)
+func ParseStopSignal(name string) (syscall.Signal, error) {
+	return config.ParseSignal(name)
+}
+
+// runHook 执行生命周期钩子，返回钩子输出(用于日志)
+func runHook(ctx context.Context, hook *config.HookConfig, info *AgentInfo, pid int) (string, error) {
+	timeout := hook.Timeout
+	if timeout <= 0 {
+		timeout = defaultHookTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if len(hook.Command) > 0 {
+		return runCommandHook(ctx, hook.Command, info, pid)
+	}
+	return runHTTPHook(ctx, &hook.HTTP)
+}
+
+// runCommandHook 执行命令钩子
+// 命令在Agent工作目录中执行，并通过环境变量传入Agent ID、类型和PID
+func runCommandHook(ctx context.Context, command []string, info *AgentInfo, pid int) (string, error) {
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	if info.WorkDir != "" {
+		if stat, err := os.Stat(info.WorkDir); err == nil && stat.IsDir() {
+			cmd.Dir = info.WorkDir
+		}
+	}
+	cmd.Env = append(os.Environ(),
+		"AGENT_ID="+info.ID,
+		"AGENT_TYPE="+string(info.Type),
+		"AGENT_PID="+strconv.Itoa(pid),
+	)
+
+	output, err := cmd.CombinedOutput()
+	out := truncateHookOutput(output)
+	if err != nil {
+		return out, fmt.Errorf("hook command failed: %w", err)
+	}
+	return out, nil
+}
+
+// runHTTPHook 执行HTTP钩子，非2xx响应视为失败
+func runHTTPHook(ctx context.Context, hook *config.HTTPHookConfig) (string, error) {
+	method := hook.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+
+	var body io.Reader
+	if hook.Body != "" {
+		body = strings.NewReader(hook.Body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(method), hook.URL, body)
+	if err != nil {
+		return "", fmt.Errorf("failed to create hook request: %w", err)
+	}
+	for k, v := range hook.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("hook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxHookOutput))
+	out := truncateHookOutput(respBody)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return out, fmt.Errorf("hook returned status %d", resp.StatusCode)
+	}
+	return out, nil
+}
+
+// truncateHookOutput 截断钩子输出
+func truncateHookOutput(output []byte) string {
+	if len(output) > maxHookOutput {
+		output = output[:maxHookOutput]
+	}
+	return strings.TrimSpace(string(output))
+}
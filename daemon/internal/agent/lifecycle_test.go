@@ -0,0 +1,266 @@
+package agent
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/bingooyong/ops-scaffold-framework/daemon/internal/config"
+	"go.uber.org/zap"
+)
+
+func TestParseStopSignal(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    syscall.Signal
+		wantErr bool
+	}{
+		{"SIGTERM", syscall.SIGTERM, false},
+		{"sigint", syscall.SIGINT, false},
+		{"QUIT", syscall.SIGQUIT, false},
+		{"SIGSTOP", 0, true},
+		{"", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseStopSignal(tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseStopSignal(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseStopSignal(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNewLifecycleConfig(t *testing.T) {
+	lc, err := NewLifecycleConfig(&config.AgentItemConfig{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if lc.StopSignal != syscall.SIGTERM || lc.StopTimeout != defaultStopTimeout {
+		t.Errorf("unexpected defaults: %+v", lc)
+	}
+	if lc.PreStop != nil || lc.PostStart != nil {
+		t.Error("expected no hooks by default")
+	}
+
+	lc, err = NewLifecycleConfig(&config.AgentItemConfig{
+		StopSignal:  "SIGQUIT",
+		StopTimeout: 5 * time.Second,
+		PreStop:     config.HookConfig{HTTP: config.HTTPHookConfig{URL: "http://127.0.0.1/flush"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if lc.StopSignal != syscall.SIGQUIT || lc.StopTimeout != 5*time.Second {
+		t.Errorf("unexpected config: %+v", lc)
+	}
+	if lc.PreStop == nil || lc.PreStop.HTTP.URL != "http://127.0.0.1/flush" {
+		t.Error("expected pre_stop hook")
+	}
+
+	if _, err := NewLifecycleConfig(&config.AgentItemConfig{StopSignal: "SIGFOO"}); err == nil {
+		t.Error("expected error for invalid stop signal")
+	}
+}
+
+func TestRunHook_HTTP(t *testing.T) {
+	var method string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte("flushed"))
+	}))
+	defer server.Close()
+
+	info := &AgentInfo{ID: "test-agent", Type: TypeCustom}
+
+	output, err := runHook(context.Background(), &config.HookConfig{
+		HTTP: config.HTTPHookConfig{URL: server.URL + "/flush"},
+	}, info, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if method != http.MethodPost || output != "flushed" {
+		t.Errorf("unexpected hook result: method=%s output=%q", method, output)
+	}
+
+	if _, err := runHook(context.Background(), &config.HookConfig{
+		HTTP: config.HTTPHookConfig{URL: server.URL + "/fail"},
+	}, info, 0); err == nil {
+		t.Error("expected error for non-2xx response")
+	}
+}
+
+func TestRunHook_Command(t *testing.T) {
+	info := &AgentInfo{ID: "test-agent", Type: TypeCustom, WorkDir: t.TempDir()}
+
+	output, err := runHook(context.Background(), &config.HookConfig{
+		Command: []string{"sh", "-c", "echo $AGENT_ID $AGENT_PID"},
+	}, info, 42)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if output != "test-agent 42" {
+		t.Errorf("unexpected output: %q", output)
+	}
+
+	_, err = runHook(context.Background(), &config.HookConfig{
+		Command: []string{"sleep", "5"},
+		Timeout: 100 * time.Millisecond,
+	}, info, 0)
+	if err == nil {
+		t.Error("expected timeout error")
+	}
+}
+
+// writeTestAgentScript 写入一个忽略SIGTERM、收到SIGINT时退出的测试Agent脚本
+func writeTestAgentScript(t *testing.T, dir string) string {
+	t.Helper()
+	script := filepath.Join(dir, "agent.sh")
+	content := "#!/bin/sh\ntrap 'exit 0' INT\ntrap '' TERM\nwhile true; do sleep 1; done\n"
+	if err := os.WriteFile(script, []byte(content), 0755); err != nil {
+		t.Fatalf("failed to write script: %v", err)
+	}
+	return script
+}
+
+func TestAgentInstance_Stop_CustomSignalAndPreStop(t *testing.T) {
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("/bin/sh not available")
+	}
+
+	var preStopCalls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&preStopCalls, 1)
+	}))
+	defer server.Close()
+
+	workDir := t.TempDir()
+	info := &AgentInfo{
+		ID:         "test-agent",
+		Type:       TypeCustom,
+		BinaryPath: writeTestAgentScript(t, workDir),
+		WorkDir:    workDir,
+	}
+	instance := NewAgentInstance(info, zap.NewNop())
+	instance.SetLifecycle(&LifecycleConfig{
+		StopSignal:  syscall.SIGINT,
+		StopTimeout: 10 * time.Second,
+		PreStop:     &config.HookConfig{HTTP: config.HTTPHookConfig{URL: server.URL}},
+	})
+
+	if err := instance.Start(context.Background()); err != nil {
+		t.Fatalf("failed to start agent: %v", err)
+	}
+
+	start := time.Now()
+	if err := instance.Stop(context.Background(), true); err != nil {
+		t.Fatalf("failed to stop agent: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("stop with SIGINT took too long: %v", elapsed)
+	}
+	if atomic.LoadInt32(&preStopCalls) != 1 {
+		t.Errorf("expected pre_stop hook to be called once, got %d", preStopCalls)
+	}
+	if instance.IsRunning() {
+		t.Error("expected agent to be stopped")
+	}
+}
+
+func TestAgentInstance_Stop_PreStopDoesNotHoldLock(t *testing.T) {
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("/bin/sh not available")
+	}
+
+	workDir := t.TempDir()
+	info := &AgentInfo{
+		ID:         "test-agent",
+		Type:       TypeCustom,
+		BinaryPath: writeTestAgentScript(t, workDir),
+		WorkDir:    workDir,
+	}
+	instance := NewAgentInstance(info, zap.NewNop())
+
+	// 钩子执行期间查询实例状态，Stop持有锁时查询会阻塞到钩子超时
+	var runningDuringHook atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := make(chan bool, 1)
+		go func() { result <- instance.IsRunning() }()
+		select {
+		case running := <-result:
+			runningDuringHook.Store(running)
+		case <-time.After(2 * time.Second):
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	instance.SetLifecycle(&LifecycleConfig{
+		StopSignal:  syscall.SIGINT,
+		StopTimeout: 10 * time.Second,
+		PreStop:     &config.HookConfig{HTTP: config.HTTPHookConfig{URL: server.URL}, Timeout: 5 * time.Second},
+	})
+
+	if err := instance.Start(context.Background()); err != nil {
+		t.Fatalf("failed to start agent: %v", err)
+	}
+	if err := instance.Stop(context.Background(), true); err != nil {
+		t.Fatalf("failed to stop agent: %v", err)
+	}
+	if !runningDuringHook.Load() {
+		t.Error("instance state should be readable while pre_stop hook runs")
+	}
+	if instance.IsRunning() || info.GetStatus() != StatusStopped {
+		t.Errorf("expected agent to be stopped, got %s", info.GetStatus())
+	}
+}
+
+func TestAgentInstance_Stop_TimeoutKillsProcessGroup(t *testing.T) {
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("/bin/sh not available")
+	}
+
+	workDir := t.TempDir()
+	info := &AgentInfo{
+		ID:         "test-agent",
+		Type:       TypeCustom,
+		BinaryPath: writeTestAgentScript(t, workDir),
+		WorkDir:    workDir,
+	}
+	instance := NewAgentInstance(info, zap.NewNop())
+	instance.SetLifecycle(&LifecycleConfig{
+		StopSignal:  syscall.SIGTERM, // 脚本忽略SIGTERM，必须超时后SIGKILL
+		StopTimeout: 200 * time.Millisecond,
+	})
+
+	if err := instance.Start(context.Background()); err != nil {
+		t.Fatalf("failed to start agent: %v", err)
+	}
+	pid := info.GetPID()
+
+	if err := instance.Stop(context.Background(), true); err != nil {
+		t.Fatalf("failed to stop agent: %v", err)
+	}
+
+	// 进程组中不应再有存活的进程(子进程由init回收，稍等片刻)
+	deadline := time.Now().Add(2 * time.Second)
+	for syscall.Kill(-pid, 0) == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("expected process group to be killed")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
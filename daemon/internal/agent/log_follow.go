@@ -0,0 +1,315 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultLogTailLines 获取日志末尾时默认返回的行数
+	defaultLogTailLines = 100
+
+	// maxLogTailLines 获取日志末尾时允许返回的最大行数
+	maxLogTailLines = 5000
+
+	// logTailReadSize 获取日志末尾时从文件末尾读取的最大字节数
+	logTailReadSize = 10 * 1024 * 1024
+
+	// logFollowPollInterval 跟踪日志时检查新增内容的间隔
+	logFollowPollInterval = 250 * time.Millisecond
+
+	// logFollowReadSize 跟踪日志时单次读取的最大字节数(剩余内容在下一次读取)
+	logFollowReadSize = 1024 * 1024
+
+	// maxLogLineSize 单行最大长度，超过时不再等待换行符直接输出
+	maxLogLineSize = 64 * 1024
+)
+
+// TailAgentLogs 获取Agent日志末尾lines行(keyword非空时只返回包含关键词的行)
+func (mam *MultiAgentManager) TailAgentLogs(agentID string, lines int, keyword string) ([]string, error) {
+	instance := mam.GetAgent(agentID)
+	if instance == nil {
+		return nil, &AgentNotFoundError{ID: agentID}
+	}
+	if lines <= 0 {
+		lines = defaultLogTailLines
+	}
+	if lines > maxLogTailLines {
+		lines = maxLogTailLines
+	}
+	return readLogTail(instance.getLogFilePath(), lines, keyword, logTailReadSize)
+}
+
+// FollowAgentLogs 创建Agent日志跟踪器(从日志文件当前末尾开始跟踪)
+func (mam *MultiAgentManager) FollowAgentLogs(agentID string, keyword string) (*LogFollower, error) {
+	instance := mam.GetAgent(agentID)
+	if instance == nil {
+		return nil, &AgentNotFoundError{ID: agentID}
+	}
+	follower := NewLogFollower(instance.getLogFilePath(), keyword)
+	if err := follower.Open(); err != nil {
+		return nil, err
+	}
+	return follower, nil
+}
+
+// readLogTail 读取文件最后n行(只读取文件末尾maxRead字节，keyword非空时只保留包含关键词的行)
+func readLogTail(path string, n int, keyword string, maxRead int64) ([]string, error) {
+	if n <= 0 {
+		return []string{}, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat log file: %w", err)
+	}
+
+	startPos := int64(0)
+	if stat.Size() > maxRead {
+		startPos = stat.Size() - maxRead
+	}
+	if _, err := file.Seek(startPos, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to seek: %w", err)
+	}
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log file: %w", err)
+	}
+
+	// 从中间开始读取时跳过第一行(可能不完整)
+	if startPos > 0 {
+		if idx := bytes.IndexByte(content, '\n'); idx >= 0 {
+			content = content[idx+1:]
+		}
+	}
+
+	allLines := strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+	if len(allLines) == 1 && allLines[0] == "" {
+		return []string{}, nil
+	}
+	allLines = filterLogLines(allLines, keyword)
+	if len(allLines) > n {
+		allLines = allLines[len(allLines)-n:]
+	}
+	return allLines, nil
+}
+
+// filterLogLines 保留包含关键词的行(关键词为空时不过滤)
+func filterLogLines(lines []string, keyword string) []string {
+	if keyword == "" {
+		return lines
+	}
+	result := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if strings.Contains(line, keyword) {
+			result = append(result, line)
+		}
+	}
+	return result
+}
+
+// LogFollower 日志跟踪器(类似tail -F)
+// 通过比较文件标识检测轮转(重命名后在原路径创建新文件)，读完旧文件剩余内容后切换到新文件；
+// 通过文件变小检测截断(copytruncate)，从头开始读取
+type LogFollower struct {
+	path     string
+	keyword  string
+	interval time.Duration
+
+	file    *os.File
+	offset  int64
+	partial []byte
+}
+
+// NewLogFollower 创建日志跟踪器
+func NewLogFollower(path, keyword string) *LogFollower {
+	return &LogFollower{
+		path:     path,
+		keyword:  keyword,
+		interval: logFollowPollInterval,
+	}
+}
+
+// Open 打开日志文件并定位到末尾(文件不存在时等待其被创建)
+func (f *LogFollower) Open() error {
+	file, err := os.Open(f.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	offset, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to seek: %w", err)
+	}
+	f.file = file
+	f.offset = offset
+	return nil
+}
+
+// Close 关闭日志文件
+func (f *LogFollower) Close() error {
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// Follow 持续跟踪新增日志，直到ctx取消(返回nil)或handler返回错误
+// rotated表示本批次中发生了轮转或截断
+func (f *LogFollower) Follow(ctx context.Context, handler func(lines []string, rotated bool) error) error {
+	defer f.Close()
+
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+
+	for {
+		lines, rotated, err := f.Poll()
+		if err != nil {
+			return err
+		}
+		if len(lines) > 0 || rotated {
+			if err := handler(lines, rotated); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll 读取自上次以来的新增日志行
+func (f *LogFollower) Poll() ([]string, bool, error) {
+	rotated := false
+	if f.file == nil {
+		// 文件尚未创建，或轮转后尚未重新创建
+		file, err := os.Open(f.path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, false, nil
+			}
+			return nil, false, fmt.Errorf("failed to open log file: %w", err)
+		}
+		f.file = file
+		f.offset = 0
+		f.partial = nil
+	}
+
+	lines, err := f.readNew()
+	if err != nil {
+		return nil, false, err
+	}
+
+	pathInfo, err := os.Stat(f.path)
+	if err != nil {
+		// 文件已被重命名但尚未创建新文件，继续读取旧文件(进程可能仍在写入)
+		if os.IsNotExist(err) {
+			return filterLogLines(lines, f.keyword), false, nil
+		}
+		return nil, false, fmt.Errorf("failed to stat log file: %w", err)
+	}
+	fileInfo, err := f.file.Stat()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to stat log file: %w", err)
+	}
+
+	switch {
+	case !os.SameFile(pathInfo, fileInfo):
+		// 已轮转: 读完旧文件剩余内容(单次读取有上限)并输出不完整的最后一行，切换到新文件从头读取
+		for {
+			offset := f.offset
+			rest, err := f.readNew()
+			if err != nil {
+				return nil, false, err
+			}
+			lines = append(lines, rest...)
+			if f.offset == offset {
+				break
+			}
+		}
+		if len(f.partial) > 0 {
+			lines = append(lines, string(f.partial))
+		}
+		f.Close()
+		file, err := os.Open(f.path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return filterLogLines(lines, f.keyword), true, nil
+			}
+			return nil, false, fmt.Errorf("failed to open log file: %w", err)
+		}
+		f.file = file
+		f.offset = 0
+		f.partial = nil
+		rotated = true
+	case fileInfo.Size() < f.offset:
+		// 已截断: 从头读取
+		f.offset = 0
+		f.partial = nil
+		rotated = true
+	default:
+		return filterLogLines(lines, f.keyword), false, nil
+	}
+
+	more, err := f.readNew()
+	if err != nil {
+		return nil, false, err
+	}
+	lines = append(lines, more...)
+	return filterLogLines(lines, f.keyword), rotated, nil
+}
+
+// readNew 从当前偏移读取新增内容并按行切分(最后不完整的行保留到下次读取)
+func (f *LogFollower) readNew() ([]string, error) {
+	buf := make([]byte, logFollowReadSize)
+	n, err := f.file.ReadAt(buf, f.offset)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read log file: %w", err)
+	}
+	if n == 0 {
+		return nil, nil
+	}
+	f.offset += int64(n)
+
+	data := append(f.partial, buf[:n]...)
+	f.partial = nil
+
+	var lines []string
+	for {
+		idx := bytes.IndexByte(data, '\n')
+		if idx < 0 {
+			break
+		}
+		lines = append(lines, strings.TrimRight(string(data[:idx]), "\r"))
+		data = data[idx+1:]
+	}
+	if len(data) > maxLogLineSize {
+		lines = append(lines, string(data))
+	} else if len(data) > 0 {
+		f.partial = append([]byte(nil), data...)
+	}
+	return lines, nil
+}
@@ -0,0 +1,215 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// appendLog 向日志文件追加内容
+func appendLog(t *testing.T, path, content string) {
+	t.Helper()
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("failed to open log file: %v", err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString(content); err != nil {
+		t.Fatalf("failed to write log file: %v", err)
+	}
+}
+
+func TestReadLogTail_Keyword(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.log")
+	appendLog(t, path, "INFO start\nERROR one\nINFO tick\nERROR two\nERROR three\n")
+
+	lines, err := readLogTail(path, 2, "ERROR", logTailReadSize)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(lines, []string{"ERROR two", "ERROR three"}) {
+		t.Errorf("unexpected lines: %v", lines)
+	}
+
+	lines, err = readLogTail(filepath.Join(t.TempDir(), "missing.log"), 10, "", logTailReadSize)
+	if err != nil || len(lines) != 0 {
+		t.Errorf("expected no lines for missing file, got %v, %v", lines, err)
+	}
+}
+
+func TestLogFollower_Poll(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.log")
+	appendLog(t, path, "old line\n")
+
+	follower := NewLogFollower(path, "")
+	if err := follower.Open(); err != nil {
+		t.Fatalf("failed to open follower: %v", err)
+	}
+	defer follower.Close()
+
+	poll := func() ([]string, bool) {
+		t.Helper()
+		lines, rotated, err := follower.Poll()
+		if err != nil {
+			t.Fatalf("unexpected poll error: %v", err)
+		}
+		return lines, rotated
+	}
+
+	// 只返回打开之后新增的行，不完整的行等待换行符
+	appendLog(t, path, "line 1\nline 2\npart")
+	if lines, rotated := poll(); !reflect.DeepEqual(lines, []string{"line 1", "line 2"}) || rotated {
+		t.Errorf("unexpected lines: %v (rotated=%v)", lines, rotated)
+	}
+	appendLog(t, path, "ial\n")
+	if lines, _ := poll(); !reflect.DeepEqual(lines, []string{"partial"}) {
+		t.Errorf("expected partial line to be completed, got %v", lines)
+	}
+
+	// 轮转(重命名后创建新文件): 先读完旧文件剩余内容，再从头读取新文件
+	appendLog(t, path, "before rotate\n")
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatalf("failed to rename log file: %v", err)
+	}
+	appendLog(t, path+".1", "late write\n")
+	if lines, rotated := poll(); !reflect.DeepEqual(lines, []string{"before rotate", "late write"}) || rotated {
+		t.Errorf("expected old file to be drained before new file exists, got %v (rotated=%v)", lines, rotated)
+	}
+	appendLog(t, path, "after rotate\n")
+	if lines, rotated := poll(); !reflect.DeepEqual(lines, []string{"after rotate"}) || !rotated {
+		t.Errorf("expected rotation to be detected, got %v (rotated=%v)", lines, rotated)
+	}
+
+	// 截断(copytruncate): 从头读取
+	if err := os.Truncate(path, 0); err != nil {
+		t.Fatalf("failed to truncate log file: %v", err)
+	}
+	appendLog(t, path, "new\n")
+	if lines, rotated := poll(); !reflect.DeepEqual(lines, []string{"new"}) || !rotated {
+		t.Errorf("expected truncation to be detected, got %v (rotated=%v)", lines, rotated)
+	}
+}
+
+func TestLogFollower_PollDrainsLargeRotatedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.log")
+	appendLog(t, path, "")
+
+	follower := NewLogFollower(path, "")
+	if err := follower.Open(); err != nil {
+		t.Fatalf("failed to open follower: %v", err)
+	}
+	defer follower.Close()
+
+	// 轮转前写入超过单次读取上限的内容，切换到新文件前必须全部读完
+	line := strings.Repeat("x", 1023) + "\n"
+	count := logFollowReadSize/len(line)*2 + 10
+	appendLog(t, path, strings.Repeat(line, count)+"last old line\n")
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatalf("failed to rename log file: %v", err)
+	}
+	appendLog(t, path, "new line\n")
+
+	lines, rotated, err := follower.Poll()
+	if err != nil {
+		t.Fatalf("unexpected poll error: %v", err)
+	}
+	if !rotated || len(lines) != count+2 {
+		t.Fatalf("expected %d lines with rotation, got %d (rotated=%v)", count+2, len(lines), rotated)
+	}
+	if lines[count] != "last old line" || lines[count+1] != "new line" {
+		t.Errorf("unexpected tail: %v", lines[count:])
+	}
+}
+
+func TestLogFollower_FollowWithRotator(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.log")
+	follower := NewLogFollower(path, "ERROR")
+	follower.interval = 10 * time.Millisecond
+	if err := follower.Open(); err != nil {
+		t.Fatalf("failed to open follower: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	received := make(chan string, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- follower.Follow(ctx, func(lines []string, rotated bool) error {
+			for _, line := range lines {
+				received <- line
+			}
+			return nil
+		})
+	}()
+
+	expect := func(want string) {
+		t.Helper()
+		select {
+		case got := <-received:
+			if got != want {
+				t.Errorf("expected %q, got %q", want, got)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for %q", want)
+		}
+	}
+
+	// 文件在开始跟踪后才创建
+	appendLog(t, path, "INFO skipped\nERROR first\n")
+	expect("ERROR first")
+
+	// 使用LogRotator轮转后写入新文件
+	rotator := NewLogRotator(path, 1, 3, zap.NewNop())
+	if err := rotator.RotateIfNeeded(); err != nil {
+		t.Fatalf("failed to rotate: %v", err)
+	}
+	appendLog(t, path, "ERROR second\n")
+	expect("ERROR second")
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("expected nil error after cancel, got %v", err)
+	}
+}
+
+func TestMultiAgentManager_TailAgentLogs(t *testing.T) {
+	workDir := t.TempDir()
+	mam, err := NewMultiAgentManager(workDir, zap.NewNop())
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+	defer mam.Close()
+
+	instance, err := mam.RegisterAgent(&AgentInfo{ID: "tail-agent", Type: TypeCustom, BinaryPath: "/bin/true", WorkDir: workDir})
+	if err != nil {
+		t.Fatalf("failed to register agent: %v", err)
+	}
+	logPath := instance.getLogFilePath()
+	if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+		t.Fatalf("failed to create log dir: %v", err)
+	}
+	var content strings.Builder
+	for i := 0; i < 200; i++ {
+		content.WriteString("line\n")
+	}
+	appendLog(t, logPath, content.String())
+
+	lines, err := mam.TailAgentLogs("tail-agent", 0, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(lines) != defaultLogTailLines {
+		t.Errorf("expected %d lines by default, got %d", defaultLogTailLines, len(lines))
+	}
+
+	if _, err := mam.TailAgentLogs("missing", 10, ""); err == nil {
+		t.Error("expected error for unknown agent")
+	} else if _, ok := err.(*AgentNotFoundError); !ok {
+		t.Errorf("expected AgentNotFoundError, got %T", err)
+	}
+}
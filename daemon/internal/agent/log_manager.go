@@ -7,6 +7,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
 	"sync"
@@ -15,6 +16,20 @@ import (
 	"go.uber.org/zap"
 )
 
+const (
+	// agentLogFileName Agent当前日志文件名(轮转文件为 agent.log.N 或 agent.log.{日期})
+	agentLogFileName = "agent.log"
+
+	// RotationPeriodHourly 每小时轮转
+	RotationPeriodHourly = "hourly"
+
+	// RotationPeriodDaily 每天轮转
+	RotationPeriodDaily = "daily"
+)
+
+// rotatedLogSuffixPattern 轮转文件后缀: 序号(1)或日期(2024-03-01、2024-03-01T15、2024-03-01T150405)，可带冲突序号(-1)
+var rotatedLogSuffixPattern = regexp.MustCompile(`^(\d+|\d{4}-\d{2}-\d{2}(T\d{2}(\d{4})?)?(-\d+)?)$`)
+
 // LogRotator 日志轮转器
 type LogRotator struct {
 	logPath          string
@@ -22,6 +37,9 @@ type LogRotator struct {
 	maxFiles         int
 	rotateByTime     bool
 	rotateInterval   time.Duration
+	period           string
+	copyTruncate     bool
+	dateSuffix       bool
 	compressOldFiles bool
 	lastRotateTime   time.Time
 	mu               sync.Mutex
@@ -42,6 +60,30 @@ func NewLogRotator(logPath string, maxSize int64, maxFiles int, logger *zap.Logg
 	}
 }
 
+// SetPeriod 设置按自然时间段轮转(hourly/daily，为空时只按大小轮转)
+// 跨越整点或零点后的第一次检查触发轮转，空文件不轮转
+func (lr *LogRotator) SetPeriod(period string) {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	lr.period = period
+}
+
+// SetCopyTruncate 设置是否使用copytruncate方式轮转
+// 复制当前文件后将其截断，适用于一直持有文件句柄的Agent(如stdout重定向)；
+// 复制与截断之间写入的少量日志可能丢失
+func (lr *LogRotator) SetCopyTruncate(copyTruncate bool) {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	lr.copyTruncate = copyTruncate
+}
+
+// SetDateSuffix 设置轮转文件名是否使用日期后缀(agent.log.2024-03-01)
+func (lr *LogRotator) SetDateSuffix(dateSuffix bool) {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	lr.dateSuffix = dateSuffix
+}
+
 // RotateIfNeeded 检查是否需要轮转，如果需要则执行轮转
 func (lr *LogRotator) RotateIfNeeded() error {
 	lr.mu.Lock()
@@ -59,7 +101,7 @@ func (lr *LogRotator) RotateIfNeeded() error {
 
 	// 检查文件大小
 	shouldRotate := false
-	if info.Size() >= lr.maxSize {
+	if lr.maxSize > 0 && info.Size() >= lr.maxSize {
 		shouldRotate = true
 		lr.logger.Debug("log rotation triggered by size",
 			zap.String("log_path", lr.logPath),
@@ -77,6 +119,17 @@ func (lr *LogRotator) RotateIfNeeded() error {
 		}
 	}
 
+	// 检查自然时间段(上次轮转或最后写入发生在之前的时间段)
+	if lr.period != "" && info.Size() > 0 {
+		current := periodStart(time.Now(), lr.period)
+		if periodStart(lr.lastRotateTime, lr.period).Before(current) || periodStart(info.ModTime(), lr.period).Before(current) {
+			shouldRotate = true
+			lr.logger.Debug("log rotation triggered by period",
+				zap.String("log_path", lr.logPath),
+				zap.String("period", lr.period))
+		}
+	}
+
 	if !shouldRotate {
 		return nil
 	}
@@ -85,63 +138,55 @@ func (lr *LogRotator) RotateIfNeeded() error {
 	return lr.rotate()
 }
 
+// periodStart 返回t所在时间段的起点
+func periodStart(t time.Time, period string) time.Time {
+	switch period {
+	case RotationPeriodHourly:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+	case RotationPeriodDaily:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	default:
+		return t
+	}
+}
+
 // rotate 执行日志轮转
 func (lr *LogRotator) rotate() error {
-	// 重命名现有文件
-	rotatedFiles := make([]string, 0)
-	for i := lr.maxFiles - 1; i >= 1; i-- {
-		oldPath := fmt.Sprintf("%s.%d", lr.logPath, i)
-		newPath := fmt.Sprintf("%s.%d", lr.logPath, i+1)
-
-		// 检查压缩文件
-		oldPathGz := oldPath + ".gz"
-		newPathGz := newPath + ".gz"
-
-		// 移动压缩文件
-		if _, err := os.Stat(oldPathGz); err == nil {
-			if err := os.Rename(oldPathGz, newPathGz); err != nil && !os.IsNotExist(err) {
-				lr.logger.Warn("failed to rename compressed log file",
-					zap.String("old", oldPathGz),
-					zap.String("new", newPathGz),
-					zap.Error(err))
-			}
-		}
-
-		// 移动未压缩文件
-		if _, err := os.Stat(oldPath); err == nil {
-			if err := os.Rename(oldPath, newPath); err != nil && !os.IsNotExist(err) {
-				lr.logger.Warn("failed to rename log file",
-					zap.String("old", oldPath),
-					zap.String("new", newPath),
-					zap.Error(err))
-			} else {
-				rotatedFiles = append(rotatedFiles, newPath)
-			}
-		}
+	var rotatedPath string
+	if lr.dateSuffix {
+		rotatedPath = lr.datedRotatePath()
+	} else {
+		lr.shiftNumberedFiles()
+		rotatedPath = fmt.Sprintf("%s.1", lr.logPath)
 	}
 
-	// 重命名当前日志文件为 .1
-	if err := os.Rename(lr.logPath, fmt.Sprintf("%s.1", lr.logPath)); err != nil {
-		return fmt.Errorf("failed to rename current log file: %w", err)
+	if lr.copyTruncate {
+		// 复制后截断，Agent持有的文件句柄(O_APPEND)继续从文件开头写入
+		if err := copyLogFile(lr.logPath, rotatedPath); err != nil {
+			return fmt.Errorf("failed to copy current log file: %w", err)
+		}
+		if err := os.Truncate(lr.logPath, 0); err != nil {
+			return fmt.Errorf("failed to truncate current log file: %w", err)
+		}
+	} else {
+		// 重命名当前日志文件，并在原路径创建新文件
+		if err := os.Rename(lr.logPath, rotatedPath); err != nil {
+			return fmt.Errorf("failed to rename current log file: %w", err)
+		}
+		if file, err := os.OpenFile(lr.logPath, os.O_CREATE|os.O_WRONLY, 0644); err != nil {
+			lr.logger.Warn("failed to recreate log file",
+				zap.String("log_path", lr.logPath),
+				zap.Error(err))
+		} else {
+			file.Close()
+		}
 	}
 
-	// 压缩旧文件
+	// 压缩刚轮转出的文件
 	if lr.compressOldFiles {
-		for _, filePath := range rotatedFiles {
-			// 只压缩 .1 文件，其他文件应该已经被压缩
-			if strings.HasSuffix(filePath, ".1") {
-				if err := lr.compressFile(filePath); err != nil {
-					lr.logger.Warn("failed to compress log file",
-						zap.String("file", filePath),
-						zap.Error(err))
-				}
-			}
-		}
-		// 压缩新创建的 .1 文件
-		newRotatedFile := fmt.Sprintf("%s.1", lr.logPath)
-		if err := lr.compressFile(newRotatedFile); err != nil {
-			lr.logger.Warn("failed to compress new rotated log file",
-				zap.String("file", newRotatedFile),
+		if err := lr.compressFile(rotatedPath); err != nil {
+			lr.logger.Warn("failed to compress rotated log file",
+				zap.String("file", rotatedPath),
 				zap.Error(err))
 		}
 	}
@@ -153,12 +198,82 @@ func (lr *LogRotator) rotate() error {
 	lr.lastRotateTime = time.Now()
 
 	lr.logger.Info("log rotation completed",
-		zap.String("log_path", lr.logPath))
+		zap.String("log_path", lr.logPath),
+		zap.String("rotated_path", rotatedPath),
+		zap.Bool("copytruncate", lr.copyTruncate))
 
 	return nil
 }
 
-// compressFile 压缩文件
+// shiftNumberedFiles 将序号轮转文件依次后移(agent.log.N -> agent.log.N+1)，为新的agent.log.1腾出位置
+func (lr *LogRotator) shiftNumberedFiles() {
+	for i := lr.maxFiles - 1; i >= 1; i-- {
+		for _, ext := range []string{".gz", ""} {
+			oldPath := fmt.Sprintf("%s.%d%s", lr.logPath, i, ext)
+			newPath := fmt.Sprintf("%s.%d%s", lr.logPath, i+1, ext)
+			if _, err := os.Stat(oldPath); err != nil {
+				continue
+			}
+			if err := os.Rename(oldPath, newPath); err != nil && !os.IsNotExist(err) {
+				lr.logger.Warn("failed to rename log file",
+					zap.String("old", oldPath),
+					zap.String("new", newPath),
+					zap.Error(err))
+			}
+		}
+	}
+}
+
+// datedRotatePath 返回带日期后缀的轮转文件路径
+// 日期为轮转出的内容所属时间段(上次轮转时间)，同一时间段内多次轮转时追加-1、-2
+func (lr *LogRotator) datedRotatePath() string {
+	layout := "2006-01-02T150405"
+	switch lr.period {
+	case RotationPeriodHourly:
+		layout = "2006-01-02T15"
+	case RotationPeriodDaily:
+		layout = "2006-01-02"
+	}
+	base := fmt.Sprintf("%s.%s", lr.logPath, lr.lastRotateTime.Format(layout))
+
+	path := base
+	for i := 1; ; i++ {
+		_, errPlain := os.Stat(path)
+		_, errGz := os.Stat(path + ".gz")
+		if os.IsNotExist(errPlain) && os.IsNotExist(errGz) {
+			return path
+		}
+		path = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+// copyLogFile 复制日志文件(保留修改时间)
+func copyLogFile(src, dst string) error {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+
+	dstFile, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dstFile, srcFile); err != nil {
+		dstFile.Close()
+		return err
+	}
+	if err := dstFile.Close(); err != nil {
+		return err
+	}
+
+	if info, err := srcFile.Stat(); err == nil {
+		os.Chtimes(dst, info.ModTime(), info.ModTime())
+	}
+	return nil
+}
+
+// compressFile 压缩文件(压缩文件保留原文件的修改时间，保留期按原文件计算)
 func (lr *LogRotator) compressFile(filePath string) error {
 	// 打开源文件
 	srcFile, err := os.Open(filePath)
@@ -176,13 +291,20 @@ func (lr *LogRotator) compressFile(filePath string) error {
 
 	// 创建 gzip writer
 	gzWriter := gzip.NewWriter(dstFile)
-	defer gzWriter.Close()
 
 	// 复制数据
 	if _, err := io.Copy(gzWriter, srcFile); err != nil {
+		gzWriter.Close()
+		return fmt.Errorf("failed to compress file: %w", err)
+	}
+	if err := gzWriter.Close(); err != nil {
 		return fmt.Errorf("failed to compress file: %w", err)
 	}
 
+	if info, err := srcFile.Stat(); err == nil {
+		os.Chtimes(filePath+".gz", info.ModTime(), info.ModTime())
+	}
+
 	// 删除原始文件
 	if err := os.Remove(filePath); err != nil {
 		return fmt.Errorf("failed to remove original file: %w", err)
@@ -191,39 +313,13 @@ func (lr *LogRotator) compressFile(filePath string) error {
 	return nil
 }
 
-// cleanupOldFiles 清理超过最大文件数的旧文件
+// cleanupOldFiles 清理超过最大文件数的旧文件(从最旧的开始删除)
 func (lr *LogRotator) cleanupOldFiles() {
-	logDir := filepath.Dir(lr.logPath)
-	logBase := filepath.Base(lr.logPath)
-
-	// 读取目录中的所有日志文件
-	files, err := os.ReadDir(logDir)
-	if err != nil {
-		lr.logger.Warn("failed to read log directory",
-			zap.String("dir", logDir),
-			zap.Error(err))
+	if lr.maxFiles <= 0 {
 		return
 	}
 
-	// 收集所有轮转的日志文件
-	rotatedFiles := make([]string, 0)
-	for _, file := range files {
-		name := file.Name()
-		// 匹配 agent.log.N 或 agent.log.N.gz 格式
-		if strings.HasPrefix(name, logBase+".") {
-			rotatedFiles = append(rotatedFiles, filepath.Join(logDir, name))
-		}
-	}
-
-	// 按文件名排序（数字越大越新）
-	sort.Slice(rotatedFiles, func(i, j int) bool {
-		// 提取文件编号进行比较
-		numI := extractFileNumber(rotatedFiles[i])
-		numJ := extractFileNumber(rotatedFiles[j])
-		return numI > numJ
-	})
-
-	// 删除超过最大文件数的文件
+	rotatedFiles := rotatedLogFiles(lr.logPath)[1:]
 	for i := lr.maxFiles; i < len(rotatedFiles); i++ {
 		if err := os.Remove(rotatedFiles[i]); err != nil {
 			lr.logger.Warn("failed to remove old log file",
@@ -236,6 +332,56 @@ func (lr *LogRotator) cleanupOldFiles() {
 	}
 }
 
+// isRotatedLogFile 判断文件是否为logPath的轮转文件(agent.log.N[.gz] 或 agent.log.{日期}[.gz])
+func isRotatedLogFile(logPath, file string) bool {
+	prefix := filepath.Base(logPath) + "."
+	name := filepath.Base(file)
+	if filepath.Dir(file) != filepath.Dir(logPath) || !strings.HasPrefix(name, prefix) {
+		return false
+	}
+	return rotatedLogSuffixPattern.MatchString(strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".gz"))
+}
+
+// rotatedLogFiles 返回日志文件及其轮转文件，从新到旧排序(第一个为logPath本身)
+// 轮转文件按修改时间排序，时间相同时序号小的、日期大的更新
+func rotatedLogFiles(logPath string) []string {
+	matches, _ := filepath.Glob(logPath + ".*")
+
+	type rotatedFile struct {
+		path    string
+		modTime time.Time
+	}
+	var rotated []rotatedFile
+	for _, file := range matches {
+		if !isRotatedLogFile(logPath, file) {
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		rotated = append(rotated, rotatedFile{path: file, modTime: info.ModTime()})
+	}
+
+	sort.SliceStable(rotated, func(i, j int) bool {
+		a, b := rotated[i], rotated[j]
+		if !a.modTime.Equal(b.modTime) {
+			return a.modTime.After(b.modTime)
+		}
+		numA, numB := extractFileNumber(a.path), extractFileNumber(b.path)
+		if numA != numB {
+			return numA < numB
+		}
+		return a.path > b.path
+	})
+
+	files := []string{logPath}
+	for _, file := range rotated {
+		files = append(files, file.path)
+	}
+	return files
+}
+
 // extractFileNumber 从文件名中提取编号
 func extractFileNumber(filePath string) int {
 	base := filepath.Base(filePath)
@@ -258,6 +404,34 @@ type LogEntry struct {
 	Timestamp  time.Time
 }
 
+// LogCleanupStats 日志清理结果
+type LogCleanupStats struct {
+	// ExpiredFiles 因超过保留天数删除的文件数
+	ExpiredFiles int
+
+	// ExpiredBytes 因超过保留天数释放的字节数
+	ExpiredBytes int64
+
+	// QuotaFiles 因超过磁盘配额删除的文件数
+	QuotaFiles int
+
+	// QuotaBytes 因超过磁盘配额释放的字节数
+	QuotaBytes int64
+
+	// TotalBytes 清理后所有Agent日志目录的总字节数
+	TotalBytes int64
+}
+
+// DeletedFiles 删除的文件总数
+func (s LogCleanupStats) DeletedFiles() int {
+	return s.ExpiredFiles + s.QuotaFiles
+}
+
+// FreedBytes 释放的总字节数
+func (s LogCleanupStats) FreedBytes() int64 {
+	return s.ExpiredBytes + s.QuotaBytes
+}
+
 // LogManager 日志管理器
 type LogManager struct {
 	workDir       string
@@ -266,6 +440,21 @@ type LogManager struct {
 	ctx           context.Context
 	cancel        context.CancelFunc
 	wg            sync.WaitGroup
+
+	// mu 保护以下字段
+	mu sync.Mutex
+
+	// logDirs 额外的Agent日志目录(Agent使用独立work_dir时不在{workDir}/agents下)
+	logDirs []string
+
+	// maxTotalSize 所有Agent日志目录的总字节数上限(0表示不限制)
+	maxTotalSize int64
+
+	// quotaCheckInterval 配额检查间隔
+	quotaCheckInterval time.Duration
+
+	// lastCleanup 最近一次清理结果
+	lastCleanup LogCleanupStats
 }
 
 // NewLogManager 创建新的日志管理器
@@ -417,11 +606,23 @@ func (lm *LogManager) SearchLogs(agentID string, keyword string, limit int) ([]L
 }
 
 // StartCleanupTask 启动日志清理任务
+// 每天凌晨2点清理过期日志；配置了磁盘配额时另外按间隔检查配额
 func (lm *LogManager) StartCleanupTask() {
 	lm.wg.Add(1)
 	go lm.cleanupLoop()
+
+	lm.mu.Lock()
+	maxTotalSize := lm.maxTotalSize
+	interval := lm.quotaCheckInterval
+	lm.mu.Unlock()
+	if maxTotalSize > 0 && interval > 0 {
+		lm.wg.Add(1)
+		go lm.quotaLoop(interval)
+	}
+
 	lm.logger.Info("log cleanup task started",
-		zap.Int("retention_days", lm.retentionDays))
+		zap.Int("retention_days", lm.retentionDays),
+		zap.Int64("max_total_size", maxTotalSize))
 }
 
 // StopCleanupTask 停止日志清理任务
@@ -470,39 +671,36 @@ func (lm *LogManager) cleanupLoop() {
 	}
 }
 
-// cleanupOldLogs 清理过期日志
-func (lm *LogManager) cleanupOldLogs() {
-	agentsLogDir := fmt.Sprintf("%s/agents", lm.workDir)
+// quotaLoop 磁盘配额检查循环
+func (lm *LogManager) quotaLoop(interval time.Duration) {
+	defer lm.wg.Done()
 
-	// 检查目录是否存在
-	if _, err := os.Stat(agentsLogDir); os.IsNotExist(err) {
-		return
-	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 
-	// 读取所有Agent目录
-	agents, err := os.ReadDir(agentsLogDir)
-	if err != nil {
-		lm.logger.Warn("failed to read agents directory",
-			zap.String("dir", agentsLogDir),
-			zap.Error(err))
-		return
+	for {
+		select {
+		case <-lm.ctx.Done():
+			return
+		case <-ticker.C:
+			files, freed, total := lm.enforceQuota()
+			if files > 0 {
+				lm.mu.Lock()
+				lm.lastCleanup = LogCleanupStats{QuotaFiles: files, QuotaBytes: freed, TotalBytes: total}
+				lm.mu.Unlock()
+			}
+		}
 	}
+}
 
-	var deletedFiles int
-	var freedSpace int64
+// cleanupOldLogs 清理过期日志并执行磁盘配额，返回删除的文件数和释放的空间
+// 当前日志文件(agent.log)由日志轮转控制大小，不会被删除
+func (lm *LogManager) cleanupOldLogs() LogCleanupStats {
+	var stats LogCleanupStats
 	cutoffTime := time.Now().AddDate(0, 0, -lm.retentionDays)
 
 	// 遍历每个Agent的日志目录
-	for _, agentDir := range agents {
-		if !agentDir.IsDir() {
-			continue
-		}
-
-		logsDir := filepath.Join(agentsLogDir, agentDir.Name(), "logs")
-		if _, err := os.Stat(logsDir); os.IsNotExist(err) {
-			continue
-		}
-
+	for _, logsDir := range lm.agentLogDirs() {
 		// 读取日志文件
 		logFiles, err := os.ReadDir(logsDir)
 		if err != nil {
@@ -514,7 +712,7 @@ func (lm *LogManager) cleanupOldLogs() {
 
 		// 检查每个日志文件
 		for _, logFile := range logFiles {
-			if logFile.IsDir() {
+			if logFile.IsDir() || logFile.Name() == agentLogFileName {
 				continue
 			}
 
@@ -532,8 +730,8 @@ func (lm *LogManager) cleanupOldLogs() {
 						zap.String("file", filePath),
 						zap.Error(err))
 				} else {
-					deletedFiles++
-					freedSpace += info.Size()
+					stats.ExpiredFiles++
+					stats.ExpiredBytes += info.Size()
 					lm.logger.Debug("deleted old log file",
 						zap.String("file", filePath),
 						zap.Time("mod_time", info.ModTime()))
@@ -542,15 +740,168 @@ func (lm *LogManager) cleanupOldLogs() {
 		}
 	}
 
-	if deletedFiles > 0 {
-		lm.logger.Info("log cleanup completed",
-			zap.Int("deleted_files", deletedFiles),
-			zap.Int64("freed_space_bytes", freedSpace),
-			zap.Int64("freed_space_mb", freedSpace/(1024*1024)))
+	stats.QuotaFiles, stats.QuotaBytes, stats.TotalBytes = lm.enforceQuota()
+
+	lm.mu.Lock()
+	lm.lastCleanup = stats
+	lm.mu.Unlock()
+
+	lm.logger.Info("log cleanup completed",
+		zap.Int("deleted_files", stats.DeletedFiles()),
+		zap.Int64("freed_space_bytes", stats.FreedBytes()),
+		zap.Int64("freed_space_mb", stats.FreedBytes()/(1024*1024)),
+		zap.Int("expired_files", stats.ExpiredFiles),
+		zap.Int("quota_files", stats.QuotaFiles),
+		zap.Int64("total_bytes", stats.TotalBytes))
+
+	return stats
+}
+
+// agentLogFile 配额检查使用的日志文件信息
+type agentLogFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// enforceQuota 所有Agent日志目录总大小超过配额时删除旧文件，返回删除的文件数、释放的字节数和清理后的总字节数
+// 先删除最旧的压缩文件，仍超过时再删除最旧的未压缩轮转文件；当前日志文件不会被删除
+func (lm *LogManager) enforceQuota() (int, int64, int64) {
+	lm.mu.Lock()
+	maxTotalSize := lm.maxTotalSize
+	lm.mu.Unlock()
+
+	var total int64
+	var compressed, others []agentLogFile
+	for _, logsDir := range lm.agentLogDirs() {
+		entries, err := os.ReadDir(logsDir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			total += info.Size()
+			if entry.Name() == agentLogFileName {
+				continue
+			}
+			file := agentLogFile{path: filepath.Join(logsDir, entry.Name()), size: info.Size(), modTime: info.ModTime()}
+			if strings.HasSuffix(entry.Name(), ".gz") {
+				compressed = append(compressed, file)
+			} else {
+				others = append(others, file)
+			}
+		}
+	}
+	if maxTotalSize <= 0 || total <= maxTotalSize {
+		return 0, 0, total
+	}
+
+	byAge := func(files []agentLogFile) {
+		sort.Slice(files, func(i, j int) bool {
+			return files[i].modTime.Before(files[j].modTime)
+		})
+	}
+	byAge(compressed)
+	byAge(others)
+
+	var deleted int
+	var freed int64
+	for _, file := range append(compressed, others...) {
+		if total <= maxTotalSize {
+			break
+		}
+		if err := os.Remove(file.path); err != nil {
+			lm.logger.Warn("failed to delete log file for quota",
+				zap.String("file", file.path),
+				zap.Error(err))
+			continue
+		}
+		deleted++
+		freed += file.size
+		total -= file.size
+	}
+
+	if total > maxTotalSize {
+		lm.logger.Warn("agent logs still exceed disk quota after deleting rotated files",
+			zap.Int64("total_bytes", total),
+			zap.Int64("max_total_size", maxTotalSize))
+	}
+	lm.logger.Info("agent log disk quota enforced",
+		zap.Int("deleted_files", deleted),
+		zap.Int64("freed_space_bytes", freed),
+		zap.Int64("total_bytes", total),
+		zap.Int64("max_total_size", maxTotalSize))
+
+	return deleted, freed, total
+}
+
+// agentLogDirs 返回所有Agent日志目录({workDir}/agents/*/logs 以及通过AddLogDir添加的目录)
+func (lm *LogManager) agentLogDirs() []string {
+	seen := make(map[string]bool)
+	var dirs []string
+	add := func(dir string) {
+		dir = filepath.Clean(dir)
+		if seen[dir] {
+			return
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return
+		}
+		seen[dir] = true
+		dirs = append(dirs, dir)
+	}
+
+	agentsLogDir := filepath.Join(lm.workDir, "agents")
+	if agents, err := os.ReadDir(agentsLogDir); err == nil {
+		for _, agentDir := range agents {
+			if agentDir.IsDir() {
+				add(filepath.Join(agentsLogDir, agentDir.Name(), "logs"))
+			}
+		}
+	} else if !os.IsNotExist(err) {
+		lm.logger.Warn("failed to read agents directory",
+			zap.String("dir", agentsLogDir),
+			zap.Error(err))
+	}
+
+	lm.mu.Lock()
+	extra := append([]string(nil), lm.logDirs...)
+	lm.mu.Unlock()
+	for _, dir := range extra {
+		add(dir)
 	}
+	return dirs
 }
 
 // SetRetentionDays 设置日志保留天数
 func (lm *LogManager) SetRetentionDays(days int) {
 	lm.retentionDays = days
 }
+
+// SetDiskQuota 设置所有Agent日志目录的总字节数上限(0表示不限制)及检查间隔
+func (lm *LogManager) SetDiskQuota(maxTotalSize int64, checkInterval time.Duration) {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	lm.maxTotalSize = maxTotalSize
+	lm.quotaCheckInterval = checkInterval
+}
+
+// AddLogDir 添加需要清理和计入配额的Agent日志目录
+func (lm *LogManager) AddLogDir(dir string) {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	lm.logDirs = append(lm.logDirs, dir)
+}
+
+// LastCleanupStats 返回最近一次清理结果
+func (lm *LogManager) LastCleanupStats() LogCleanupStats {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	return lm.lastCleanup
+}
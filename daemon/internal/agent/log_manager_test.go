@@ -701,3 +701,184 @@ func TestCleanupOldLogs_ManyFiles(t *testing.T) {
 
 	t.Logf("cleaned up %d files in %v, %d files remaining", numFiles-remainingFiles, duration, remainingFiles)
 }
+
+func TestLogRotator_CopyTruncate(t *testing.T) {
+	logDir := t.TempDir()
+	logPath := filepath.Join(logDir, "agent.log")
+
+	// 模拟Agent一直持有日志文件句柄(O_APPEND)
+	file, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatalf("failed to open log file: %v", err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString(strings.Repeat("x", 200) + "\n"); err != nil {
+		t.Fatalf("failed to write log: %v", err)
+	}
+
+	rotator := NewLogRotator(logPath, 100, 3, zaptest.NewLogger(t))
+	rotator.SetCopyTruncate(true)
+	if err := rotator.RotateIfNeeded(); err != nil {
+		t.Fatalf("failed to rotate: %v", err)
+	}
+
+	// 原文件被截断，Agent继续写入同一个文件
+	if _, err := file.WriteString("after rotation\n"); err != nil {
+		t.Fatalf("failed to write log: %v", err)
+	}
+	held, _ := file.Stat()
+	current, err := os.Stat(logPath)
+	if err != nil {
+		t.Fatalf("expected log file to exist: %v", err)
+	}
+	if !os.SameFile(held, current) {
+		t.Error("expected log file to keep the same inode after copytruncate")
+	}
+	content, _ := os.ReadFile(logPath)
+	if string(content) != "after rotation\n" {
+		t.Errorf("expected truncated log file, got %q", content)
+	}
+	if _, err := os.Stat(logPath + ".1.gz"); err != nil {
+		t.Errorf("expected compressed copy: %v", err)
+	}
+}
+
+func TestLogRotator_DailyPeriodWithDateSuffix(t *testing.T) {
+	logDir := t.TempDir()
+	logPath := filepath.Join(logDir, "agent.log")
+	if err := os.WriteFile(logPath, []byte("yesterday\n"), 0644); err != nil {
+		t.Fatalf("failed to write log file: %v", err)
+	}
+
+	rotator := NewLogRotator(logPath, 0, 3, zaptest.NewLogger(t))
+	rotator.SetPeriod(RotationPeriodDaily)
+	rotator.SetDateSuffix(true)
+
+	// 同一天内不轮转
+	if err := rotator.RotateIfNeeded(); err != nil {
+		t.Fatalf("failed to check rotation: %v", err)
+	}
+	if _, err := os.Stat(logPath); err != nil {
+		t.Fatalf("expected log file to exist: %v", err)
+	}
+	if content, _ := os.ReadFile(logPath); string(content) != "yesterday\n" {
+		t.Fatalf("expected no rotation within the same day, got %q", content)
+	}
+
+	// 上次轮转在昨天: 轮转为 agent.log.{昨天日期}.gz
+	yesterday := time.Now().AddDate(0, 0, -1)
+	rotator.lastRotateTime = yesterday
+	if err := rotator.RotateIfNeeded(); err != nil {
+		t.Fatalf("failed to rotate: %v", err)
+	}
+	rotatedPath := fmt.Sprintf("%s.%s.gz", logPath, yesterday.Format("2006-01-02"))
+	if _, err := os.Stat(rotatedPath); err != nil {
+		t.Errorf("expected dated rotated file %s: %v", rotatedPath, err)
+	}
+	if !isRotatedLogFile(logPath, rotatedPath) {
+		t.Errorf("expected %s to be recognized as rotated log file", rotatedPath)
+	}
+	if info, err := os.Stat(logPath); err != nil || info.Size() != 0 {
+		t.Errorf("expected empty current log file after rotation, got %v (%v)", info, err)
+	}
+}
+
+func TestLogManager_DiskQuota(t *testing.T) {
+	tmpDir := t.TempDir()
+	lm := NewLogManager(tmpDir, zaptest.NewLogger(t))
+	lm.SetRetentionDays(30)
+	lm.SetDiskQuota(2500, time.Minute)
+
+	// 两个Agent共 3500 字节: 当前文件 + 压缩文件 + 未压缩的轮转文件
+	now := time.Now()
+	writeFile := func(agentID, name string, size int, age time.Duration) string {
+		t.Helper()
+		logDir := filepath.Join(tmpDir, "agents", agentID, "logs")
+		if err := os.MkdirAll(logDir, 0755); err != nil {
+			t.Fatalf("failed to create log dir: %v", err)
+		}
+		path := filepath.Join(logDir, name)
+		if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+			t.Fatalf("failed to write log file: %v", err)
+		}
+		modTime := now.Add(-age)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("failed to set file time: %v", err)
+		}
+		return path
+	}
+	activeA := writeFile("agent-a", "agent.log", 500, 72*time.Hour)
+	plainA := writeFile("agent-a", "agent.log.1", 500, 96*time.Hour)
+	oldestGz := writeFile("agent-a", "agent.log.3.gz", 500, 48*time.Hour)
+	newerGz := writeFile("agent-b", "agent.log.1.gz", 500, 24*time.Hour)
+	newestGz := writeFile("agent-b", "agent.log.2.gz", 500, 12*time.Hour)
+	activeB := writeFile("agent-b", "agent.log", 1000, 0)
+
+	stats := lm.cleanupOldLogs()
+
+	// 先删除最旧的压缩文件，即使未压缩的轮转文件更旧
+	if stats.QuotaFiles != 2 || stats.QuotaBytes != 1000 {
+		t.Errorf("expected 2 files / 1000 bytes reclaimed by quota, got %+v", stats)
+	}
+	if stats.FreedBytes() != 1000 || stats.DeletedFiles() != 2 || stats.TotalBytes != 2500 {
+		t.Errorf("unexpected cleanup stats: %+v", stats)
+	}
+	for _, path := range []string{oldestGz, newerGz} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be deleted", path)
+		}
+	}
+	for _, path := range []string{activeA, plainA, newestGz, activeB} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("expected %s to be kept: %v", path, err)
+		}
+	}
+	if got := lm.LastCleanupStats(); got != stats {
+		t.Errorf("expected last cleanup stats %+v, got %+v", stats, got)
+	}
+
+	// 压缩文件删完后删除未压缩的轮转文件，当前文件始终保留
+	lm.SetDiskQuota(1000, time.Minute)
+	stats = lm.cleanupOldLogs()
+	if stats.QuotaFiles != 2 || stats.TotalBytes != 1500 {
+		t.Errorf("expected remaining rotated files to be deleted, got %+v", stats)
+	}
+	for _, path := range []string{activeA, activeB} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("expected active log %s to be kept: %v", path, err)
+		}
+	}
+}
+
+func TestCleanupOldLogs_ReportsFreedSpace(t *testing.T) {
+	tmpDir := t.TempDir()
+	externalDir := filepath.Join(t.TempDir(), "custom", "logs")
+	if err := os.MkdirAll(externalDir, 0755); err != nil {
+		t.Fatalf("failed to create log dir: %v", err)
+	}
+
+	lm := NewLogManager(tmpDir, zaptest.NewLogger(t))
+	lm.SetRetentionDays(1)
+	lm.AddLogDir(externalDir)
+
+	oldTime := time.Now().AddDate(0, 0, -2)
+	expired := filepath.Join(externalDir, "agent.log.1.gz")
+	active := filepath.Join(externalDir, "agent.log")
+	for _, path := range []string{expired, active} {
+		if err := os.WriteFile(path, make([]byte, 300), 0644); err != nil {
+			t.Fatalf("failed to write log file: %v", err)
+		}
+		if err := os.Chtimes(path, oldTime, oldTime); err != nil {
+			t.Fatalf("failed to set file time: %v", err)
+		}
+	}
+
+	stats := lm.cleanupOldLogs()
+	if stats.ExpiredFiles != 1 || stats.ExpiredBytes != 300 || stats.QuotaFiles != 0 {
+		t.Errorf("expected 1 expired file / 300 bytes, got %+v", stats)
+	}
+	// 长时间未写入的当前日志文件不会被删除
+	if _, err := os.Stat(active); err != nil {
+		t.Errorf("expected active log file to be kept: %v", err)
+	}
+}
@@ -0,0 +1,311 @@
+package agent
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultLogSearchLimit 日志搜索默认返回的最大条数
+	defaultLogSearchLimit = 100
+
+	// maxLogSearchLimit 日志搜索允许返回的最大条数
+	maxLogSearchLimit = 5000
+
+	// logSearchScanSize 搜索未压缩日志文件时从文件末尾扫描的最大字节数
+	logSearchScanSize = 100 * 1024 * 1024
+)
+
+// ErrInvalidLogQuery 日志搜索条件无效(如正则表达式无法编译)
+var ErrInvalidLogQuery = errors.New("invalid log query")
+
+// logTimestampPattern 匹配行首的时间戳，如 "2024-01-02T15:04:05.123Z"、"[2024/01/02 15:04:05,123 +0800]"
+var logTimestampPattern = regexp.MustCompile(`^\[?(\d{4})[-/](\d{2})[-/](\d{2})[T ](\d{2}:\d{2}:\d{2})(?:[.,](\d{1,9}))?\s?(Z|[+-]\d{2}:?\d{2})?`)
+
+// logJSONTimestampKeys JSON格式日志中常见的时间戳字段
+var logJSONTimestampKeys = []string{"ts", "time", "timestamp", "@timestamp"}
+
+// LogSearchQuery 日志搜索条件
+type LogSearchQuery struct {
+	// AgentID 只搜索指定Agent(为空时搜索所有Agent)
+	AgentID string
+
+	// AgentType 只搜索指定类型的Agent(为空时不过滤)
+	AgentType string
+
+	// Keyword 关键词，Regex为true时为正则表达式(为空时匹配所有行)
+	Keyword string
+
+	// Regex Keyword是否为正则表达式
+	Regex bool
+
+	// Since 开始时间(零值表示不限制)
+	Since time.Time
+
+	// Until 结束时间(零值表示不限制)
+	Until time.Time
+
+	// Limit 最大返回条数(<=0时使用默认值)
+	Limit int
+}
+
+// LogSearchMatch 日志搜索命中的行
+type LogSearchMatch struct {
+	LogEntry
+
+	// AgentID Agent ID
+	AgentID string
+
+	// AgentType Agent类型
+	AgentType string
+
+	// File 命中行所在的日志文件(包括已轮转的文件)
+	File string
+}
+
+// SearchAgentLogs 按关键词/正则和时间范围搜索Agent日志，包括已轮转(含压缩)的日志文件
+// 返回按时间倒序(最新的在前)的命中行；truncated表示还有更多命中未返回
+func (mam *MultiAgentManager) SearchAgentLogs(ctx context.Context, query LogSearchQuery) ([]LogSearchMatch, bool, error) {
+	match, err := newLogMatcher(query.Keyword, query.Regex)
+	if err != nil {
+		return nil, false, err
+	}
+	if !query.Since.IsZero() && !query.Until.IsZero() && query.Until.Before(query.Since) {
+		return nil, false, fmt.Errorf("%w: until is before since", ErrInvalidLogQuery)
+	}
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultLogSearchLimit
+	}
+	if limit > maxLogSearchLimit {
+		limit = maxLogSearchLimit
+	}
+
+	var instances []*AgentInstance
+	if query.AgentID != "" {
+		instance := mam.GetAgent(query.AgentID)
+		if instance == nil {
+			return nil, false, &AgentNotFoundError{ID: query.AgentID}
+		}
+		instances = append(instances, instance)
+	} else {
+		instances = mam.ListAgents()
+	}
+
+	var results []LogSearchMatch
+	truncated := false
+	for _, instance := range instances {
+		info := instance.GetInfo()
+		if query.AgentType != "" && string(info.Type) != query.AgentType {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, false, err
+		}
+
+		matches, more, err := searchLogFiles(ctx, instance.getLogFilePath(), match, query.Since, query.Until, limit)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to search logs of agent %s: %w", info.ID, err)
+		}
+		for i := range matches {
+			matches[i].AgentID = info.ID
+			matches[i].AgentType = string(info.Type)
+		}
+		results = append(results, matches...)
+		truncated = truncated || more
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Timestamp.After(results[j].Timestamp)
+	})
+	if len(results) > limit {
+		results = results[:limit]
+		truncated = true
+	}
+	return results, truncated, nil
+}
+
+// newLogMatcher 根据关键词或正则表达式创建行匹配函数
+func newLogMatcher(keyword string, regex bool) (func(string) bool, error) {
+	if keyword == "" {
+		return func(string) bool { return true }, nil
+	}
+	if !regex {
+		return func(line string) bool { return strings.Contains(line, keyword) }, nil
+	}
+	re, err := regexp.Compile(keyword)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidLogQuery, err)
+	}
+	return re.MatchString, nil
+}
+
+// searchLogFiles 从新到旧搜索日志文件及其轮转文件，返回最近的最多limit条命中(按时间正序)
+// 文件最后修改时间早于since时，该文件及更旧的文件不再搜索
+func searchLogFiles(ctx context.Context, logPath string, match func(string) bool, since, until time.Time, limit int) ([]LogSearchMatch, bool, error) {
+	var results []LogSearchMatch
+	truncated := false
+
+	for _, file := range rotatedLogFiles(logPath) {
+		if err := ctx.Err(); err != nil {
+			return nil, false, err
+		}
+		if len(results) >= limit {
+			// 更旧的文件中即使有命中也不会返回
+			truncated = true
+			break
+		}
+
+		stat, err := os.Stat(file)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, false, fmt.Errorf("failed to stat log file: %w", err)
+		}
+		if !since.IsZero() && stat.ModTime().Before(since) {
+			break
+		}
+
+		matches, more, err := searchLogFile(file, stat, match, since, until, limit-len(results))
+		if err != nil {
+			return nil, false, err
+		}
+		results = append(matches, results...)
+		truncated = truncated || more
+	}
+	return results, truncated, nil
+}
+
+// searchLogFile 搜索单个日志文件，返回最后的最多limit条命中(按时间正序)
+// 没有时间戳的行(如多行堆栈)沿用前一行的时间戳，文件开头没有时间戳的行使用文件修改时间
+func searchLogFile(path string, stat os.FileInfo, match func(string) bool, since, until time.Time, limit int) ([]LogSearchMatch, bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	skipFirst := false
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to open compressed log file: %w", err)
+		}
+		defer gz.Close()
+		reader = gz
+	} else if stat.Size() > logSearchScanSize {
+		if _, err := file.Seek(stat.Size()-logSearchScanSize, io.SeekStart); err != nil {
+			return nil, false, fmt.Errorf("failed to seek: %w", err)
+		}
+		// 从中间开始读取时跳过第一行(可能不完整)
+		skipFirst = true
+	}
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), maxLogLineSize)
+
+	var results []LogSearchMatch
+	truncated := false
+	lineNumber := 0
+	lastTimestamp := stat.ModTime()
+	for scanner.Scan() {
+		lineNumber++
+		if skipFirst {
+			skipFirst = false
+			continue
+		}
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if ts, ok := parseLogTimestamp(line); ok {
+			lastTimestamp = ts
+		}
+		if line == "" || !match(line) {
+			continue
+		}
+		if !since.IsZero() && lastTimestamp.Before(since) {
+			continue
+		}
+		if !until.IsZero() && lastTimestamp.After(until) {
+			continue
+		}
+
+		results = append(results, LogSearchMatch{
+			LogEntry: LogEntry{
+				LineNumber: lineNumber,
+				Content:    line,
+				Timestamp:  lastTimestamp,
+			},
+			File: path,
+		})
+		if len(results) > limit {
+			results = results[1:]
+			truncated = true
+		}
+	}
+	if err := scanner.Err(); err != nil && err != bufio.ErrTooLong {
+		return nil, false, fmt.Errorf("failed to read log file: %w", err)
+	}
+	return results, truncated, nil
+}
+
+// parseLogTimestamp 解析日志行中的时间戳
+// 支持行首的ISO8601/常见日期时间格式，以及JSON日志中的ts/time/timestamp/@timestamp字段
+func parseLogTimestamp(line string) (time.Time, bool) {
+	if strings.HasPrefix(line, "{") {
+		var fields map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &fields); err != nil {
+			return time.Time{}, false
+		}
+		for _, key := range logJSONTimestampKeys {
+			switch v := fields[key].(type) {
+			case string:
+				if ts, ok := parseTimestampPrefix(v); ok {
+					return ts, true
+				}
+			case float64:
+				// zap等日志库的默认格式: Unix秒(带小数)
+				sec := int64(v)
+				return time.Unix(sec, int64((v-float64(sec))*1e9)), true
+			}
+		}
+		return time.Time{}, false
+	}
+	return parseTimestampPrefix(line)
+}
+
+// parseTimestampPrefix 解析字符串开头的日期时间(没有时区时按本地时间)
+func parseTimestampPrefix(s string) (time.Time, bool) {
+	m := logTimestampPattern.FindStringSubmatch(s)
+	if m == nil {
+		return time.Time{}, false
+	}
+	value := fmt.Sprintf("%s-%s-%sT%s", m[1], m[2], m[3], m[4])
+	if m[5] != "" {
+		value += "." + m[5]
+	}
+
+	zone := m[6]
+	if zone == "" {
+		ts, err := time.ParseInLocation("2006-01-02T15:04:05.999999999", value, time.Local)
+		return ts, err == nil
+	}
+	if zone != "Z" && !strings.Contains(zone, ":") {
+		zone = zone[:3] + ":" + zone[3:]
+	}
+	ts, err := time.Parse(time.RFC3339Nano, value+zone)
+	return ts, err == nil
+}
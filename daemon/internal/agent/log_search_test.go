@@ -0,0 +1,159 @@
+package agent
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// writeGzipLog 写入gzip压缩的日志文件
+func writeGzipLog(t *testing.T, path, content string) {
+	t.Helper()
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("failed to create log file: %v", err)
+	}
+	defer file.Close()
+	gz := gzip.NewWriter(file)
+	if _, err := gz.Write([]byte(content)); err != nil {
+		t.Fatalf("failed to write log file: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("failed to close gzip writer: %v", err)
+	}
+}
+
+func TestParseLogTimestamp(t *testing.T) {
+	utc := func(s string) time.Time {
+		ts, _ := time.Parse(time.RFC3339Nano, s)
+		return ts
+	}
+
+	tests := []struct {
+		line string
+		want time.Time
+		ok   bool
+	}{
+		{"2024-03-01T10:00:00Z INFO started", utc("2024-03-01T10:00:00Z"), true},
+		{"2024-03-01T10:00:00.123+08:00 ERROR connection refused", utc("2024-03-01T02:00:00.123Z"), true},
+		{"[2024/03/01 10:00:00,500 +0000] WARN slow", utc("2024-03-01T10:00:00.5Z"), true},
+		{`{"level":"error","ts":1709287200.25,"msg":"boom"}`, time.Unix(1709287200, 250000000), true},
+		{`{"@timestamp":"2024-03-01T10:00:00Z","message":"x"}`, utc("2024-03-01T10:00:00Z"), true},
+		{"\tat com.example.Main.run(Main.java:10)", time.Time{}, false},
+		{"", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseLogTimestamp(tt.line)
+		if ok != tt.ok || !got.Equal(tt.want) {
+			t.Errorf("parseLogTimestamp(%q) = %v, %v; want %v, %v", tt.line, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	// 没有时区的时间按本地时间解析
+	got, ok := parseLogTimestamp("2024-03-01 10:00:00 INFO local")
+	want := time.Date(2024, 3, 1, 10, 0, 0, 0, time.Local)
+	if !ok || !got.Equal(want) {
+		t.Errorf("expected local time %v, got %v (%v)", want, got, ok)
+	}
+}
+
+func TestMultiAgentManager_SearchAgentLogs(t *testing.T) {
+	workDir := t.TempDir()
+	mam, err := NewMultiAgentManager(workDir, zap.NewNop())
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+	defer mam.Close()
+
+	register := func(id string, agentType AgentType) string {
+		t.Helper()
+		instance, err := mam.RegisterAgent(&AgentInfo{ID: id, Type: agentType, BinaryPath: "/bin/true", WorkDir: workDir})
+		if err != nil {
+			t.Fatalf("failed to register agent: %v", err)
+		}
+		logPath := instance.getLogFilePath()
+		if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+			t.Fatalf("failed to create log dir: %v", err)
+		}
+		return logPath
+	}
+
+	// filebeat: 当前文件 + 已压缩的轮转文件，堆栈行沿用上一行时间戳
+	fbLog := register("filebeat-1", TypeFilebeat)
+	writeGzipLog(t, fbLog+".1.gz",
+		"2024-03-01T08:00:00Z ERROR connection refused old\n"+
+			"2024-03-01T09:10:00Z ERROR connection refused rotated\n")
+	appendLog(t, fbLog,
+		"2024-03-01T09:30:00Z INFO harvester started\n"+
+			"2024-03-01T09:45:00Z ERROR connection refused current\n"+
+			"  caused by: connection refused\n")
+	telegrafLog := register("telegraf-1", TypeTelegraf)
+	appendLog(t, telegrafLog, "2024-03-01T09:50:00Z ERROR connection refused telegraf\n")
+
+	since, _ := time.Parse(time.RFC3339, "2024-03-01T09:00:00Z")
+	until, _ := time.Parse(time.RFC3339, "2024-03-01T10:00:00Z")
+	// 轮转文件修改时间需晚于since，否则会被直接跳过
+	os.Chtimes(fbLog+".1.gz", until, until)
+
+	matches, truncated, err := mam.SearchAgentLogs(context.Background(), LogSearchQuery{
+		AgentType: string(TypeFilebeat),
+		Keyword:   "connection refused",
+		Since:     since,
+		Until:     until,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if truncated {
+		t.Error("expected complete results")
+	}
+	var lines []string
+	for _, m := range matches {
+		if m.AgentID != "filebeat-1" || m.AgentType != "filebeat" {
+			t.Errorf("unexpected agent on match: %+v", m)
+		}
+		lines = append(lines, m.Content)
+	}
+	want := []string{
+		"2024-03-01T09:45:00Z ERROR connection refused current",
+		"  caused by: connection refused",
+		"2024-03-01T09:10:00Z ERROR connection refused rotated",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("expected %v, got %v", want, lines)
+	}
+	// 堆栈行与上一行时间戳相同，倒序排序后保持文件中的相对顺序
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("match %d: expected %q, got %q", i, want[i], lines[i])
+		}
+	}
+
+	// 正则 + 限制条数: 返回最新的命中并标记截断
+	matches, truncated, err = mam.SearchAgentLogs(context.Background(), LogSearchQuery{
+		Keyword: `ERROR connection refused (current|telegraf)`,
+		Regex:   true,
+		Limit:   1,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(matches) != 1 || matches[0].AgentID != "telegraf-1" || !truncated {
+		t.Errorf("expected only newest telegraf match with truncation, got %+v (truncated=%v)", matches, truncated)
+	}
+
+	if _, _, err := mam.SearchAgentLogs(context.Background(), LogSearchQuery{Keyword: "(", Regex: true}); !errors.Is(err, ErrInvalidLogQuery) {
+		t.Errorf("expected ErrInvalidLogQuery for bad regex, got %v", err)
+	}
+	if _, _, err := mam.SearchAgentLogs(context.Background(), LogSearchQuery{AgentID: "missing"}); err == nil {
+		t.Error("expected error for unknown agent")
+	} else if _, ok := err.(*AgentNotFoundError); !ok {
+		t.Errorf("expected AgentNotFoundError, got %T", err)
+	}
+}
@@ -37,6 +37,15 @@ type AgentMetadata struct {
 
 	// ResourceUsage 资源使用历史记录(CPU/Memory)
 	ResourceUsage ResourceUsageHistory `json:"resource_usage"`
+
+	// CustomMetrics 最近一次心跳携带的自定义数值指标
+	CustomMetrics map[string]float64 `json:"custom_metrics,omitempty"`
+
+	// CustomFields 最近一次心跳携带的自定义状态字段
+	CustomFields map[string]string `json:"custom_fields,omitempty"`
+
+	// CustomMetricsUpdatedAt 自定义指标最后更新时间
+	CustomMetricsUpdatedAt time.Time `json:"custom_metrics_updated_at"`
 }
 
 // ResourceUsageHistory 资源使用历史记录
@@ -146,6 +155,21 @@ type MetadataStore interface {
 
 	// DeleteMetadata 删除指定Agent的元数据
 	DeleteMetadata(agentID string) error
+
+	// AddCrashRecord 追加指定Agent的崩溃记录
+	AddCrashRecord(agentID string, record *CrashRecord) error
+
+	// GetCrashRecords 获取指定Agent最近的崩溃记录(按时间倒序,limit<=0表示全部)
+	GetCrashRecords(agentID string, limit int) ([]*CrashRecord, error)
+
+	// SaveProcessState 保存指定Agent的进程状态(PID和进程启动时间)
+	SaveProcessState(agentID string, state *ProcessState) error
+
+	// GetProcessState 获取指定Agent的进程状态(不存在时返回nil)
+	GetProcessState(agentID string) (*ProcessState, error)
+
+	// DeleteProcessState 删除指定Agent的进程状态
+	DeleteProcessState(agentID string) error
 }
 
 // FileMetadataStore 基于文件的元数据存储实现
@@ -173,6 +197,17 @@ func NewFileMetadataStore(workDir string, logger *zap.Logger) (*FileMetadataStor
 	}, nil
 }
 
+// getCrashRecordsPath 获取崩溃记录文件路径
+// 崩溃记录单独存放在子目录中,避免与元数据的读改写互相覆盖
+func (f *FileMetadataStore) getCrashRecordsPath(agentID string) string {
+	return filepath.Join(f.workDir, "metadata", "crashes", agentID+".json")
+}
+
+// getProcessStatePath 获取进程状态文件路径
+func (f *FileMetadataStore) getProcessStatePath(agentID string) string {
+	return filepath.Join(f.workDir, "metadata", "processes", agentID+".json")
+}
+
 // getMetadataPath 获取元数据文件路径
 func (f *FileMetadataStore) getMetadataPath(agentID string) string {
 	return filepath.Join(f.workDir, "metadata", agentID+".json")
@@ -183,6 +218,11 @@ func (f *FileMetadataStore) SaveMetadata(agentID string, metadata *AgentMetadata
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
+	return f.saveMetadataUnlocked(agentID, metadata)
+}
+
+// saveMetadataUnlocked 保存元数据的内部方法(不加锁,供已持有锁的方法调用)
+func (f *FileMetadataStore) saveMetadataUnlocked(agentID string, metadata *AgentMetadata) error {
 	metadataPath := f.getMetadataPath(agentID)
 	tmpPath := metadataPath + ".tmp"
 
@@ -326,11 +366,8 @@ func (f *FileMetadataStore) UpdateMetadata(agentID string, updates *AgentMetadat
 	current, err := f.getMetadataUnlocked(agentID)
 	if err != nil {
 		if os.IsNotExist(err) {
-			// 如果不存在,创建新记录(注意:这里需要先释放锁再调用SaveMetadata)
-			f.mu.Unlock()
-			result := f.SaveMetadata(agentID, updates)
-			f.mu.Lock() // 重新获取锁以保证defer正常工作
-			return result
+			// 如果不存在,创建新记录
+			return f.saveMetadataUnlocked(agentID, updates)
 		}
 		return fmt.Errorf("failed to get current metadata: %w", err)
 	}
@@ -363,8 +400,8 @@ func (f *FileMetadataStore) UpdateMetadata(agentID string, updates *AgentMetadat
 	}
 	// ResourceUsage: 不在这里合并,应该通过AddResourceData方法单独更新
 
-	// 保存更新后的元数据
-	return f.SaveMetadata(agentID, current)
+	// 保存更新后的元数据(已持有锁,使用不加锁的内部方法)
+	return f.saveMetadataUnlocked(agentID, current)
 }
 
 // DeleteMetadata 删除元数据
@@ -383,9 +420,167 @@ func (f *FileMetadataStore) DeleteMetadata(agentID string) error {
 		return fmt.Errorf("failed to delete metadata file: %w", err)
 	}
 
+	// 同时删除崩溃记录
+	if err := os.Remove(f.getCrashRecordsPath(agentID)); err != nil && !os.IsNotExist(err) {
+		f.logger.Warn("failed to delete crash records file",
+			zap.String("agent_id", agentID),
+			zap.Error(err))
+	}
+
+	// 同时删除进程状态
+	if err := os.Remove(f.getProcessStatePath(agentID)); err != nil && !os.IsNotExist(err) {
+		f.logger.Warn("failed to delete process state file",
+			zap.String("agent_id", agentID),
+			zap.Error(err))
+	}
+
 	f.logger.Debug("metadata deleted",
 		zap.String("agent_id", agentID),
 		zap.String("path", metadataPath))
 
 	return nil
 }
+
+// readCrashRecordsUnlocked 读取崩溃记录(不加锁,按时间正序)
+func (f *FileMetadataStore) readCrashRecordsUnlocked(agentID string) ([]*CrashRecord, error) {
+	data, err := os.ReadFile(f.getCrashRecordsPath(agentID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*CrashRecord{}, nil
+		}
+		return nil, fmt.Errorf("failed to read crash records file: %w", err)
+	}
+
+	var records []*CrashRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal crash records: %w", err)
+	}
+	return records, nil
+}
+
+// AddCrashRecord 追加崩溃记录(原子性写入,只保留最近maxCrashRecords条)
+func (f *FileMetadataStore) AddCrashRecord(agentID string, record *CrashRecord) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	records, err := f.readCrashRecordsUnlocked(agentID)
+	if err != nil {
+		// 损坏的记录文件不应阻止新记录写入
+		f.logger.Warn("failed to read crash records, starting fresh",
+			zap.String("agent_id", agentID),
+			zap.Error(err))
+		records = []*CrashRecord{}
+	}
+
+	records = append(records, record)
+	if len(records) > maxCrashRecords {
+		records = records[len(records)-maxCrashRecords:]
+	}
+
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal crash records: %w", err)
+	}
+
+	path := f.getCrashRecordsPath(agentID)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create crash records directory: %w", err)
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath) // 清理临时文件
+		return fmt.Errorf("failed to rename temporary file: %w", err)
+	}
+
+	f.logger.Debug("crash record saved",
+		zap.String("agent_id", agentID),
+		zap.Int("total", len(records)))
+
+	return nil
+}
+
+// GetCrashRecords 获取最近的崩溃记录(按时间倒序)
+func (f *FileMetadataStore) GetCrashRecords(agentID string, limit int) ([]*CrashRecord, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	records, err := f.readCrashRecordsUnlocked(agentID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*CrashRecord, 0, len(records))
+	for i := len(records) - 1; i >= 0; i-- {
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+		result = append(result, records[i])
+	}
+	return result, nil
+}
+
+// SaveProcessState 保存进程状态(原子性写入)
+func (f *FileMetadataStore) SaveProcessState(agentID string, state *ProcessState) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal process state: %w", err)
+	}
+
+	path := f.getProcessStatePath(agentID)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create process state directory: %w", err)
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath) // 清理临时文件
+		return fmt.Errorf("failed to rename temporary file: %w", err)
+	}
+
+	f.logger.Debug("process state saved",
+		zap.String("agent_id", agentID),
+		zap.Int("pid", state.PID))
+
+	return nil
+}
+
+// GetProcessState 获取进程状态(不存在时返回nil)
+func (f *FileMetadataStore) GetProcessState(agentID string) (*ProcessState, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	data, err := os.ReadFile(f.getProcessStatePath(agentID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read process state file: %w", err)
+	}
+
+	var state ProcessState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal process state: %w", err)
+	}
+	return &state, nil
+}
+
+// DeleteProcessState 删除进程状态(幂等操作)
+func (f *FileMetadataStore) DeleteProcessState(agentID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := os.Remove(f.getProcessStatePath(agentID)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete process state file: %w", err)
+	}
+	return nil
+}
@@ -728,3 +728,151 @@ func TestUpdateMetadata_RaceCondition(t *testing.T) {
 
 	t.Logf("concurrent updates completed: %d successful, final restart count: %d", successCount, finalMetadata.RestartCount)
 }
+
+func TestCrashRecords_AddAndGet(t *testing.T) {
+	tmpDir := t.TempDir()
+	logger := zap.NewNop()
+	store, err := NewFileMetadataStore(tmpDir, logger)
+	if err != nil {
+		t.Fatalf("failed to create metadata store: %v", err)
+	}
+
+	for i := 0; i < maxCrashRecords+5; i++ {
+		record := &CrashRecord{
+			AgentID:      "test-agent",
+			ExitCode:     i,
+			ExitedAt:     time.Now(),
+			LastLogLines: []string{fmt.Sprintf("line %d", i)},
+		}
+		if err := store.AddCrashRecord("test-agent", record); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	records, err := store.GetCrashRecords("test-agent", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(records) != maxCrashRecords {
+		t.Fatalf("expected %d records, got %d", maxCrashRecords, len(records))
+	}
+	if records[0].ExitCode != maxCrashRecords+4 {
+		t.Errorf("expected newest record first, got exit code %d", records[0].ExitCode)
+	}
+
+	limited, err := store.GetCrashRecords("test-agent", 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(limited) != 3 {
+		t.Errorf("expected 3 records, got %d", len(limited))
+	}
+
+	all, err := store.ListAllMetadata()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(all) != 0 {
+		t.Errorf("crash records should not be listed as metadata, got %d entries", len(all))
+	}
+}
+
+func TestCrashRecords_NotFound(t *testing.T) {
+	tmpDir := t.TempDir()
+	logger := zap.NewNop()
+	store, err := NewFileMetadataStore(tmpDir, logger)
+	if err != nil {
+		t.Fatalf("failed to create metadata store: %v", err)
+	}
+
+	records, err := store.GetCrashRecords("missing-agent", 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(records) != 0 {
+		t.Errorf("expected no records, got %d", len(records))
+	}
+}
+
+func TestReadLastLines(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "agent.log")
+	content := ""
+	for i := 1; i <= 100; i++ {
+		content += fmt.Sprintf("line %d\n", i)
+	}
+	if err := os.WriteFile(logPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write log file: %v", err)
+	}
+
+	lines, err := readLastLines(logPath, 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(lines) != 5 || lines[0] != "line 96" || lines[4] != "line 100" {
+		t.Errorf("unexpected lines: %v", lines)
+	}
+
+	lines, err = readLastLines(filepath.Join(t.TempDir(), "missing.log"), 5)
+	if err != nil {
+		t.Fatalf("unexpected error for missing file: %v", err)
+	}
+	if len(lines) != 0 {
+		t.Errorf("expected no lines for missing file, got %v", lines)
+	}
+}
+
+func TestProcessState_SaveGetDelete(t *testing.T) {
+	tmpDir := t.TempDir()
+	logger := zap.NewNop()
+	store, err := NewFileMetadataStore(tmpDir, logger)
+	if err != nil {
+		t.Fatalf("failed to create metadata store: %v", err)
+	}
+
+	state, err := store.GetProcessState("test-agent")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if state != nil {
+		t.Fatal("expected nil state for missing agent")
+	}
+
+	saved := &ProcessState{
+		PID:        1234,
+		StartTime:  56789,
+		BinaryPath: "/usr/bin/filebeat",
+		Exe:        "/usr/bin/filebeat",
+		StartedAt:  time.Now().Truncate(time.Second),
+	}
+	if err := store.SaveProcessState("test-agent", saved); err != nil {
+		t.Fatalf("failed to save process state: %v", err)
+	}
+
+	state, err = store.GetProcessState("test-agent")
+	if err != nil {
+		t.Fatalf("failed to get process state: %v", err)
+	}
+	if state.PID != saved.PID || state.StartTime != saved.StartTime || state.Exe != saved.Exe {
+		t.Errorf("expected %+v, got %+v", saved, state)
+	}
+
+	// 进程状态文件不应被当作元数据列出
+	all, err := store.ListAllMetadata()
+	if err != nil {
+		t.Fatalf("failed to list metadata: %v", err)
+	}
+	if len(all) != 0 {
+		t.Errorf("expected no metadata, got %d", len(all))
+	}
+
+	if err := store.DeleteProcessState("test-agent"); err != nil {
+		t.Fatalf("failed to delete process state: %v", err)
+	}
+	if err := store.DeleteProcessState("test-agent"); err != nil {
+		t.Fatalf("delete should be idempotent: %v", err)
+	}
+	state, err = store.GetProcessState("test-agent")
+	if err != nil || state != nil {
+		t.Errorf("expected nil state after delete, got %+v (err=%v)", state, err)
+	}
+}
@@ -33,7 +33,12 @@ type MultiHealthChecker struct {
 	// value: 最后心跳时间
 	heartbeats map[string]time.Time
 
-	// mu 保护healthStatuses和heartbeats的并发访问锁
+	// checkCounts 每个Agent各健康检查结果的累计次数
+	// key: Agent ID
+	// value: 健康状态 -> 次数
+	checkCounts map[string]map[types.HealthStatus]uint64
+
+	// mu 保护healthStatuses、heartbeats和checkCounts的并发访问锁
 	mu sync.RWMutex
 
 	// logger 日志记录器
@@ -61,6 +66,13 @@ type AgentHealthStatus struct {
 	mu                 sync.RWMutex
 }
 
+// GetStatus 获取当前健康状态(线程安全)
+func (s *AgentHealthStatus) GetStatus() types.HealthStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Status
+}
+
 // MultiHealthCheckerConfig 多Agent健康检查器配置
 type MultiHealthCheckerConfig struct {
 	// AgentConfigs 每个Agent的健康检查配置
@@ -83,6 +95,7 @@ func NewMultiHealthChecker(multiAgentManager *MultiAgentManager, cfg *MultiHealt
 		agentConfigs:      agentConfigs,
 		healthStatuses:    make(map[string]*AgentHealthStatus),
 		heartbeats:        make(map[string]time.Time),
+		checkCounts:       make(map[string]map[types.HealthStatus]uint64),
 		logger:            logger,
 		ctx:               ctx,
 		cancel:            cancel,
@@ -226,7 +239,7 @@ func (mhc *MultiHealthChecker) checkAgentHealth(agentID string, healthCheckCfg *
 				}
 				mhc.logger.Warn("agent process not running, restarting",
 					zap.String("agent_id", agentID))
-				if err := mhc.multiAgentManager.RestartAgent(mhc.ctx, agentID, false); err != nil {
+				if _, err := mhc.multiAgentManager.AutoRestartAgent(mhc.ctx, agentID, "process exited"); err != nil {
 					mhc.logger.Error("failed to restart agent",
 						zap.String("agent_id", agentID),
 						zap.Error(err))
@@ -244,7 +257,7 @@ func (mhc *MultiHealthChecker) checkAgentHealth(agentID string, healthCheckCfg *
 				mhc.logger.Warn("agent heartbeat timeout, restarting",
 					zap.String("agent_id", agentID),
 					zap.Time("last_heartbeat", lastHB))
-				if err := mhc.multiAgentManager.RestartAgent(mhc.ctx, agentID, false); err != nil {
+				if _, err := mhc.multiAgentManager.AutoRestartAgent(mhc.ctx, agentID, "heartbeat timeout"); err != nil {
 					mhc.logger.Error("failed to restart agent",
 						zap.String("agent_id", agentID),
 						zap.Error(err))
@@ -260,7 +273,7 @@ func (mhc *MultiHealthChecker) checkAgentHealth(agentID string, healthCheckCfg *
 					mhc.logger.Warn("agent resource over threshold for too long, restarting",
 						zap.String("agent_id", agentID),
 						zap.Duration("duration", time.Since(overThresholdSince)))
-					if err := mhc.multiAgentManager.RestartAgent(mhc.ctx, agentID, false); err != nil {
+					if _, err := mhc.multiAgentManager.AutoRestartAgent(mhc.ctx, agentID, "resource over threshold"); err != nil {
 						mhc.logger.Error("failed to restart agent",
 							zap.String("agent_id", agentID),
 							zap.Error(err))
@@ -438,6 +451,11 @@ func (mhc *MultiHealthChecker) updateHealthStatus(agentID string, status types.H
 	mhc.mu.Lock()
 	defer mhc.mu.Unlock()
 
+	if mhc.checkCounts[agentID] == nil {
+		mhc.checkCounts[agentID] = make(map[types.HealthStatus]uint64)
+	}
+	mhc.checkCounts[agentID][status]++
+
 	if healthStatus, exists := mhc.healthStatuses[agentID]; exists {
 		healthStatus.mu.Lock()
 		healthStatus.Status = status
@@ -451,6 +469,22 @@ func (mhc *MultiHealthChecker) updateHealthStatus(agentID string, status types.H
 	}
 }
 
+// GetCheckCounts 获取每个Agent各健康检查结果的累计次数
+func (mhc *MultiHealthChecker) GetCheckCounts() map[string]map[types.HealthStatus]uint64 {
+	mhc.mu.RLock()
+	defer mhc.mu.RUnlock()
+
+	result := make(map[string]map[types.HealthStatus]uint64, len(mhc.checkCounts))
+	for agentID, counts := range mhc.checkCounts {
+		copied := make(map[types.HealthStatus]uint64, len(counts))
+		for status, count := range counts {
+			copied[status] = count
+		}
+		result[agentID] = copied
+	}
+	return result
+}
+
 // updateResourceInfo 更新资源信息
 func (mhc *MultiHealthChecker) updateResourceInfo(agentID string, instance *AgentInstance) {
 	pid := instance.GetPID()
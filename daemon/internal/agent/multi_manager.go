@@ -7,12 +7,38 @@ import (
 	"sync"
 	"time"
 
+	"github.com/bingooyong/ops-scaffold-framework/daemon/pkg/heartbeat"
 	"go.uber.org/zap"
 )
 
 // AgentStateChangeCallback Agent状态变化回调函数类型
 type AgentStateChangeCallback func(agentID string, status AgentStatus, pid int, lastHeartbeat time.Time)
 
+// AgentEventType Agent事件类型
+type AgentEventType string
+
+const (
+	// EventCircuitOpen 崩溃次数超过阈值，熔断器打开，Agent进入failed状态
+	EventCircuitOpen AgentEventType = "circuit_open"
+	// EventCircuitReset 熔断器被复位
+	EventCircuitReset AgentEventType = "circuit_reset"
+)
+
+// AgentEvent Agent事件(用于向Manager上报)
+type AgentEvent struct {
+	AgentID   string
+	Type      AgentEventType
+	Message   string
+	Timestamp time.Time
+	Details   map[string]string
+}
+
+// AgentEventCallback Agent事件回调函数类型
+type AgentEventCallback func(event *AgentEvent)
+
+// AgentCrashCallback Agent崩溃记录回调函数类型
+type AgentCrashCallback func(record *CrashRecord)
+
 // MultiAgentManager 多Agent管理器
 // 使用AgentRegistry管理多个AgentInstance，提供批量操作和单个Agent操作
 type MultiAgentManager struct {
@@ -36,6 +62,21 @@ type MultiAgentManager struct {
 	// stateChangeCallback Agent状态变化回调函数(可选)
 	stateChangeCallback AgentStateChangeCallback
 
+	// eventCallback Agent事件回调函数(可选)
+	eventCallback AgentEventCallback
+
+	// crashCallback Agent崩溃记录回调函数(可选)
+	crashCallback AgentCrashCallback
+
+	// startStagger 批量启动时相邻两次启动的最小间隔
+	startStagger time.Duration
+
+	// heartbeatAuth 心跳认证器(可选)，新注册的Agent实例启动时下发心跳密钥
+	heartbeatAuth *HeartbeatAuthenticator
+
+	// heartbeatEnv 下发给Agent的心跳地址环境变量
+	heartbeatEnv []string
+
 	// logger 日志记录器
 	logger *zap.Logger
 }
@@ -65,6 +106,74 @@ func (mam *MultiAgentManager) SetStateChangeCallback(callback AgentStateChangeCa
 	mam.stateChangeCallback = callback
 }
 
+// SetEventCallback 设置Agent事件回调函数
+func (mam *MultiAgentManager) SetEventCallback(callback AgentEventCallback) {
+	mam.eventCallback = callback
+}
+
+// SetCrashCallback 设置Agent崩溃记录回调函数
+func (mam *MultiAgentManager) SetCrashCallback(callback AgentCrashCallback) {
+	mam.crashCallback = callback
+}
+
+// SetHeartbeatAuthenticator 设置心跳认证器，同时应用于已注册的Agent实例
+func (mam *MultiAgentManager) SetHeartbeatAuthenticator(auth *HeartbeatAuthenticator) {
+	mam.mu.Lock()
+	defer mam.mu.Unlock()
+	mam.heartbeatAuth = auth
+	for _, instance := range mam.instances {
+		instance.SetHeartbeatAuthenticator(auth)
+	}
+}
+
+// SetHeartbeatEndpoints 设置Agent心跳上报地址(HTTP地址和Unix Socket路径，为空表示未启用)
+// 地址在Agent启动时通过环境变量下发，同时应用于已注册的Agent实例
+func (mam *MultiAgentManager) SetHeartbeatEndpoints(url, socketPath string) {
+	var env []string
+	if url != "" {
+		env = append(env, heartbeat.EnvHeartbeatURL+"="+url)
+	}
+	if socketPath != "" {
+		env = append(env, heartbeat.EnvHeartbeatSocket+"="+socketPath)
+	}
+
+	mam.mu.Lock()
+	defer mam.mu.Unlock()
+	mam.heartbeatEnv = env
+	for _, instance := range mam.instances {
+		instance.SetHeartbeatEnv(env)
+	}
+}
+
+// GetHeartbeatAuthenticator 获取心跳认证器，未启用时返回nil
+func (mam *MultiAgentManager) GetHeartbeatAuthenticator() *HeartbeatAuthenticator {
+	mam.mu.RLock()
+	defer mam.mu.RUnlock()
+	return mam.heartbeatAuth
+}
+
+// newInstance 创建AgentInstance并挂载进程退出回调(需要持锁调用)
+func (mam *MultiAgentManager) newInstance(info *AgentInfo) *AgentInstance {
+	instance := NewAgentInstance(info, mam.logger)
+	instance.SetProcessStateStore(mam.metadataStore)
+	if mam.heartbeatAuth != nil {
+		instance.SetHeartbeatAuthenticator(mam.heartbeatAuth)
+	}
+	instance.SetHeartbeatEnv(mam.heartbeatEnv)
+	agentID := info.ID
+	instance.SetExitCallback(func(exit *ExitStatus, tripped bool) {
+		// 正常退出(退出码0)不是崩溃，不生成崩溃记录
+		if exit.Success() {
+			return
+		}
+		mam.recordCrash(agentID, instance, exit, tripped)
+		if tripped {
+			mam.onCircuitOpen(agentID, instance, exit.String())
+		}
+	})
+	return instance
+}
+
 // RegisterAgent 注册一个新的Agent
 // 从AgentInfo创建AgentInstance并注册到管理器
 func (mam *MultiAgentManager) RegisterAgent(info *AgentInfo) (*AgentInstance, error) {
@@ -77,7 +186,7 @@ func (mam *MultiAgentManager) RegisterAgent(info *AgentInfo) (*AgentInstance, er
 	}
 
 	// 创建AgentInstance
-	instance := NewAgentInstance(info, mam.logger)
+	instance := mam.newInstance(info)
 
 	// 存储到instances map
 	mam.instances[info.ID] = instance
@@ -295,8 +404,8 @@ func (mam *MultiAgentManager) UpdateAgentStatusWhenProcessExits(agentID string)
 
 	info := instance.GetInfo()
 	currentStatus := info.GetStatus()
-	if currentStatus == StatusStopped {
-		// 状态已经是stopped，不需要更新
+	if currentStatus == StatusStopped || currentStatus == StatusFailed {
+		// 状态已经是stopped或failed(熔断)，不需要更新
 		return
 	}
 
@@ -315,45 +424,328 @@ func (mam *MultiAgentManager) UpdateAgentStatusWhenProcessExits(agentID string)
 	mam.updateMetadataAndNotify(agentID, instance, updates)
 }
 
-// StartAll 启动所有已注册的Agent
+// AutoRestartAgent 按重启策略自动重启Agent(供健康检查器调用)
+// reason 为触发重启的原因，用于日志和事件
+// 返回是否实际执行了重启
+func (mam *MultiAgentManager) AutoRestartAgent(ctx context.Context, id string, reason string) (bool, error) {
+	instance := mam.GetAgent(id)
+	if instance == nil {
+		return false, &AgentNotFoundError{ID: id}
+	}
+
+	// 进程仍在运行(心跳超时/资源超限)时由这里计入崩溃次数，进程退出的崩溃已在退出时记录
+	if instance.IsRunning() && instance.RecordCrash() {
+		if err := instance.Stop(ctx, true); err != nil {
+			mam.logger.Error("failed to stop agent after circuit breaker opened",
+				zap.String("agent_id", id),
+				zap.Error(err))
+		}
+		instance.MarkFailed()
+		mam.onCircuitOpen(id, instance, reason)
+		return false, nil
+	}
+
+	if allowed, why := instance.ShouldAutoRestart(); !allowed {
+		mam.logger.Debug("auto-restart skipped",
+			zap.String("agent_id", id),
+			zap.String("reason", reason),
+			zap.String("skip_reason", why))
+		return false, nil
+	}
+
+	mam.logger.Info("auto-restarting agent",
+		zap.String("agent_id", id),
+		zap.String("reason", reason))
+
+	if err := mam.RestartAgent(ctx, id, false); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ResetCircuitBreaker 复位指定Agent的崩溃熔断器
+// 复位后failed状态的Agent会回到stopped状态，由健康检查器按重启策略重新拉起
+func (mam *MultiAgentManager) ResetCircuitBreaker(id string) error {
+	instance := mam.GetAgent(id)
+	if instance == nil {
+		return &AgentNotFoundError{ID: id}
+	}
+
+	wasOpen := instance.ResetCircuitBreaker()
+	info := instance.GetInfo()
+	info.ResetRestartCount()
+
+	if info.GetStatus() == StatusFailed && !instance.IsRunning() {
+		info.SetStatus(StatusStopped)
+		mam.updateMetadataAndNotify(id, instance, &AgentMetadata{Status: string(StatusStopped)})
+	}
+
+	mam.logger.Info("agent circuit breaker reset",
+		zap.String("agent_id", id),
+		zap.Bool("was_open", wasOpen))
+
+	mam.emitEvent(&AgentEvent{
+		AgentID:   id,
+		Type:      EventCircuitReset,
+		Message:   "circuit breaker reset by operator",
+		Timestamp: time.Now(),
+	})
+
+	return nil
+}
+
+// onCircuitOpen 熔断器打开时更新元数据并上报事件
+func (mam *MultiAgentManager) onCircuitOpen(agentID string, instance *AgentInstance, reason string) {
+	policy := instance.GetRestartPolicy()
+	details := map[string]string{
+		"reason": reason,
+	}
+	if policy != nil {
+		now := time.Now()
+		details["crash_count"] = fmt.Sprintf("%d", policy.CrashCount(now))
+		details["window"] = policy.Window.String()
+		details["cooldown"] = policy.CooldownRemaining(now).String()
+	}
+
+	mam.logger.Error("agent crash loop detected, circuit breaker opened",
+		zap.String("agent_id", agentID),
+		zap.String("reason", reason),
+		zap.Any("details", details))
+
+	mam.updateMetadataAndNotify(agentID, instance, &AgentMetadata{Status: string(StatusFailed)})
+
+	mam.emitEvent(&AgentEvent{
+		AgentID:   agentID,
+		Type:      EventCircuitOpen,
+		Message:   fmt.Sprintf("agent crashed too many times, last: %s", reason),
+		Timestamp: time.Now(),
+		Details:   details,
+	})
+}
+
+// recordCrash 记录进程意外退出的崩溃信息(退出状态及最后若干行日志)并上报
+func (mam *MultiAgentManager) recordCrash(agentID string, instance *AgentInstance, exit *ExitStatus, tripped bool) {
+	logLines, err := readLastLines(instance.getLogFilePath(), defaultCrashLogLines)
+	if err != nil {
+		mam.logger.Warn("failed to read agent log for crash record",
+			zap.String("agent_id", agentID),
+			zap.Error(err))
+	}
+
+	record := newCrashRecord(agentID, exit, logLines, tripped)
+	if err := mam.metadataStore.AddCrashRecord(agentID, record); err != nil {
+		mam.logger.Warn("failed to save crash record",
+			zap.String("agent_id", agentID),
+			zap.Error(err))
+	}
+
+	if mam.crashCallback != nil {
+		go mam.crashCallback(record)
+	}
+}
+
+// GetCrashRecords 获取指定Agent最近的崩溃记录(按时间倒序)
+func (mam *MultiAgentManager) GetCrashRecords(agentID string, limit int) ([]*CrashRecord, error) {
+	if mam.GetAgent(agentID) == nil {
+		return nil, &AgentNotFoundError{ID: agentID}
+	}
+	return mam.metadataStore.GetCrashRecords(agentID, limit)
+}
+
+// emitEvent 异步调用事件回调
+func (mam *MultiAgentManager) emitEvent(event *AgentEvent) {
+	if mam.eventCallback == nil {
+		return
+	}
+	go mam.eventCallback(event)
+}
+
+// AdoptRunningAgents 接管daemon重启前启动的、仍在运行的Agent进程
+// 应在StartAll之前调用，避免重复启动Agent。对每个Agent检查持久化的进程状态:
+// PID存活、进程启动时间一致且二进制未变化时直接接管；二进制已变化时停止旧进程，由StartAll重新启动。
+// 返回成功接管的Agent数量
+func (mam *MultiAgentManager) AdoptRunningAgents(ctx context.Context) int {
+	mam.mu.RLock()
+	instances := make(map[string]*AgentInstance)
+	for id, instance := range mam.instances {
+		instances[id] = instance
+	}
+	mam.mu.RUnlock()
+
+	adopted := 0
+	for id, instance := range instances {
+		state, err := mam.metadataStore.GetProcessState(id)
+		if err != nil {
+			mam.logger.Warn("failed to read process state",
+				zap.String("agent_id", id),
+				zap.Error(err))
+			continue
+		}
+		if state == nil {
+			continue
+		}
+
+		info := instance.GetInfo()
+		switch CheckProcessState(state, info.BinaryPath) {
+		case AdoptResultAdoptable:
+			if err := instance.Adopt(state); err != nil {
+				mam.logger.Warn("failed to adopt agent process",
+					zap.String("agent_id", id),
+					zap.Int("pid", state.PID),
+					zap.Error(err))
+				continue
+			}
+			adopted++
+			mam.updateMetadataAndNotify(id, instance, &AgentMetadata{
+				Status:    "running",
+				StartTime: state.StartedAt,
+			})
+
+		case AdoptResultStale:
+			// 二进制已变化，停止旧进程，由StartAll使用新二进制重新启动
+			mam.logger.Info("agent binary changed, stopping stale process",
+				zap.String("agent_id", id),
+				zap.Int("pid", state.PID),
+				zap.String("binary", info.BinaryPath))
+			if err := instance.Adopt(state); err != nil {
+				mam.logger.Warn("failed to adopt stale agent process",
+					zap.String("agent_id", id),
+					zap.Int("pid", state.PID),
+					zap.Error(err))
+				continue
+			}
+			if err := instance.Stop(ctx, true); err != nil {
+				mam.logger.Warn("failed to stop stale agent process",
+					zap.String("agent_id", id),
+					zap.Int("pid", state.PID),
+					zap.Error(err))
+			}
+
+		default:
+			// 进程已退出或PID已被复用
+			mam.logger.Debug("previous agent process not running",
+				zap.String("agent_id", id),
+				zap.Int("pid", state.PID))
+			if err := mam.metadataStore.DeleteProcessState(id); err != nil {
+				mam.logger.Warn("failed to delete process state",
+					zap.String("agent_id", id),
+					zap.Error(err))
+			}
+		}
+	}
+
+	if adopted > 0 {
+		mam.logger.Info("adopted running agents",
+			zap.Int("adopted", adopted),
+			zap.Int("total", len(instances)))
+	}
+
+	return adopted
+}
+
+// StartAll 按依赖关系启动所有已注册的Agent
+// 每个Agent在其依赖全部就绪后才启动，无依赖关系的Agent并发启动；
+// 依赖启动失败或存在依赖环的Agent不会被启动。配置了启动间隔时相邻两次启动至少间隔该时长
 func (mam *MultiAgentManager) StartAll(ctx context.Context) map[string]error {
 	mam.mu.RLock()
 	instances := make(map[string]*AgentInstance)
 	for id, instance := range mam.instances {
 		instances[id] = instance
 	}
+	staggerer := &startStaggerer{stagger: mam.startStagger}
 	mam.mu.RUnlock()
 
-	results := make(map[string]error)
-	var wg sync.WaitGroup
-	var mu sync.Mutex
+	graph := mam.dependencyGraph(instances)
+	_, cyclic := dependencyOrder(graph)
 
+	// 每个Agent启动并就绪(或失败)后关闭done，依赖它的Agent据此继续
+	type startState struct {
+		done chan struct{}
+		err  error
+	}
+	states := make(map[string]*startState, len(instances))
+	for id := range instances {
+		states[id] = &startState{done: make(chan struct{})}
+	}
+	isCyclic := make(map[string]bool, len(cyclic))
+	for _, id := range cyclic {
+		isCyclic[id] = true
+		states[id].err = fmt.Errorf("dependency cycle detected involving agent %s", id)
+		close(states[id].done)
+		mam.logger.Error("failed to start agent",
+			zap.String("agent_id", id),
+			zap.Error(states[id].err))
+	}
+
+	var wg sync.WaitGroup
 	for id, instance := range instances {
+		if isCyclic[id] {
+			continue
+		}
 		wg.Add(1)
 		go func(agentID string, inst *AgentInstance) {
 			defer wg.Done()
-			err := inst.Start(ctx)
-			mu.Lock()
-			results[agentID] = err
-			mu.Unlock()
-			if err != nil {
+			state := states[agentID]
+			defer close(state.done)
+
+			// 等待所有依赖就绪
+			for _, dep := range graph[agentID] {
+				depState := states[dep]
+				select {
+				case <-depState.done:
+				case <-ctx.Done():
+					state.err = ctx.Err()
+					return
+				}
+				if depState.err != nil {
+					state.err = fmt.Errorf("dependency %s failed to start: %w", dep, depState.err)
+					mam.logger.Error("failed to start agent",
+						zap.String("agent_id", agentID),
+						zap.Error(state.err))
+					return
+				}
+			}
+
+			if err := staggerer.wait(ctx); err != nil {
+				state.err = err
+				return
+			}
+			if err := inst.Start(ctx); err != nil {
+				state.err = err
 				mam.logger.Error("failed to start agent",
 					zap.String("agent_id", agentID),
 					zap.Error(err))
+				return
+			}
+			if err := inst.WaitReady(ctx); err != nil {
+				state.err = err
+				mam.logger.Error("agent failed to become ready",
+					zap.String("agent_id", agentID),
+					zap.Error(err))
 			}
 		}(id, instance)
 	}
 
 	wg.Wait()
 
+	results := make(map[string]error, len(states))
+	failed := 0
+	for id, state := range states {
+		results[id] = state.err
+		if state.err != nil {
+			failed++
+		}
+	}
+
 	mam.logger.Info("started all agents",
 		zap.Int("total", len(instances)),
-		zap.Int("success", len(instances)-len(results)))
+		zap.Int("success", len(instances)-failed))
 
 	return results
 }
 
-// StopAll 停止所有已注册的Agent
+// StopAll 按依赖关系的逆序停止所有已注册的Agent
+// 每个Agent在依赖它的Agent全部停止后才停止(存在依赖环的Agent不受顺序约束)
 func (mam *MultiAgentManager) StopAll(ctx context.Context, graceful bool) map[string]error {
 	mam.mu.RLock()
 	instances := make(map[string]*AgentInstance)
@@ -362,6 +754,27 @@ func (mam *MultiAgentManager) StopAll(ctx context.Context, graceful bool) map[st
 	}
 	mam.mu.RUnlock()
 
+	graph := mam.dependencyGraph(instances)
+	_, cyclic := dependencyOrder(graph)
+	isCyclic := make(map[string]bool, len(cyclic))
+	for _, id := range cyclic {
+		isCyclic[id] = true
+	}
+	dependents := make(map[string][]string, len(instances))
+	for id, deps := range graph {
+		if isCyclic[id] {
+			continue
+		}
+		for _, dep := range deps {
+			dependents[dep] = append(dependents[dep], id)
+		}
+	}
+
+	stopped := make(map[string]chan struct{}, len(instances))
+	for id := range instances {
+		stopped[id] = make(chan struct{})
+	}
+
 	results := make(map[string]error)
 	var wg sync.WaitGroup
 	var mu sync.Mutex
@@ -370,6 +783,13 @@ func (mam *MultiAgentManager) StopAll(ctx context.Context, graceful bool) map[st
 		wg.Add(1)
 		go func(agentID string, inst *AgentInstance) {
 			defer wg.Done()
+			defer close(stopped[agentID])
+
+			// 等待依赖本Agent的Agent先停止
+			for _, dependent := range dependents[agentID] {
+				<-stopped[dependent]
+			}
+
 			err := inst.Stop(ctx, graceful)
 			mu.Lock()
 			results[agentID] = err
@@ -452,7 +872,7 @@ func (mam *MultiAgentManager) LoadAgentsFromRegistry() error {
 		}
 
 		// 创建AgentInstance
-		instance := NewAgentInstance(info, mam.logger)
+		instance := mam.newInstance(info)
 		mam.instances[info.ID] = instance
 
 		mam.logger.Debug("agent instance created from registry",
@@ -480,6 +900,7 @@ func (mam *MultiAgentManager) GetAgentStatus(id string) (*AgentStatusInfo, error
 		RestartCount: info.GetRestartCount(),
 		LastRestart:  info.GetLastRestart(),
 		IsRunning:    instance.IsRunning(),
+		CircuitOpen:  instance.IsCircuitOpen(),
 	}, nil
 }
 
@@ -504,6 +925,7 @@ func (mam *MultiAgentManager) GetAllAgentStatus() []*AgentStatusInfo {
 			RestartCount: info.GetRestartCount(),
 			LastRestart:  info.GetLastRestart(),
 			IsRunning:    instance.IsRunning(),
+			CircuitOpen:  instance.IsCircuitOpen(),
 		})
 	}
 
@@ -521,10 +943,12 @@ type AgentStatusInfo struct {
 	RestartCount int         `json:"restart_count"`
 	LastRestart  time.Time   `json:"last_restart"`
 	IsRunning    bool        `json:"is_running"`
+	CircuitOpen  bool        `json:"circuit_open"`
 }
 
 // UpdateHeartbeat 更新Agent心跳信息(供心跳接收器调用)
-func (mam *MultiAgentManager) UpdateHeartbeat(agentID string, timestamp time.Time, cpu float64, memory uint64) error {
+// customMetrics/customFields为心跳携带的自定义指标,均为空时保留上一次的值
+func (mam *MultiAgentManager) UpdateHeartbeat(agentID string, timestamp time.Time, cpu float64, memory uint64, customMetrics map[string]float64, customFields map[string]string) error {
 	// 获取元数据
 	metadata, err := mam.metadataStore.GetMetadata(agentID)
 	if err != nil {
@@ -555,6 +979,13 @@ func (mam *MultiAgentManager) UpdateHeartbeat(agentID string, timestamp time.Tim
 	// 添加资源使用数据点
 	metadata.ResourceUsage.AddResourceData(cpu, memory)
 
+	// 更新自定义指标(整体替换为最新一次上报的值)
+	if len(customMetrics) > 0 || len(customFields) > 0 {
+		metadata.CustomMetrics = copyCustomMetrics(customMetrics)
+		metadata.CustomFields = copyCustomFields(customFields)
+		metadata.CustomMetricsUpdatedAt = timestamp
+	}
+
 	// 保存更新后的元数据
 	if err := mam.metadataStore.SaveMetadata(agentID, metadata); err != nil {
 		return fmt.Errorf("failed to save metadata: %w", err)
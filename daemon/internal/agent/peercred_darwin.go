@@ -0,0 +1,48 @@
+//go:build darwin
+
+package agent
+
+import (
+	"fmt"
+	"net"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// peerPID 通过LOCAL_PEERPID读取Unix Socket对端进程的PID
+func peerPID(conn net.Conn) (int, error) {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return 0, fmt.Errorf("not a unix socket connection")
+	}
+	rawConn, err := unixConn.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+
+	var pid int
+	var credErr error
+	if err := rawConn.Control(func(fd uintptr) {
+		pid, credErr = unix.GetsockoptInt(int(fd), unix.SOL_LOCAL, unix.LOCAL_PEERPID)
+	}); err != nil {
+		return 0, err
+	}
+	if credErr != nil {
+		return 0, fmt.Errorf("failed to get peer credentials: %w", credErr)
+	}
+	return pid, nil
+}
+
+// peerBelongsToAgent 对端进程是否为Agent进程本身或其进程组内的子进程
+// Agent以独立进程组启动(pgid等于Agent PID)
+func peerBelongsToAgent(peer, agentPID int) bool {
+	if peer <= 0 || agentPID <= 0 {
+		return false
+	}
+	if peer == agentPID {
+		return true
+	}
+	pgid, err := syscall.Getpgid(peer)
+	return err == nil && pgid == agentPID
+}
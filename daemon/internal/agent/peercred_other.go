@@ -0,0 +1,18 @@
+//go:build !linux && !darwin
+
+package agent
+
+import (
+	"errors"
+	"net"
+)
+
+// peerPID 当前平台不支持读取对端进程PID，启用心跳认证时Unix Socket心跳连接会被拒绝
+func peerPID(conn net.Conn) (int, error) {
+	return 0, errors.New("peer credentials are not supported on this platform")
+}
+
+// peerBelongsToAgent 对端进程是否为Agent进程本身
+func peerBelongsToAgent(peer, agentPID int) bool {
+	return peer > 0 && peer == agentPID
+}
@@ -5,6 +5,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/bingooyong/ops-scaffold-framework/daemon/internal/metrics"
 	"go.uber.org/zap"
 )
 
@@ -32,12 +33,19 @@ type PerformanceMetrics struct {
 	ResourceCheckTotal   atomic.Int64 // 总检查次数
 	ResourceCheckLatency atomic.Int64 // 总延迟（纳秒）
 
+	// 延迟分布(用于Prometheus直方图)
+	heartbeatLatencyHist *metrics.Histogram
+	stateSyncLatencyHist *metrics.Histogram
+
 	mu sync.RWMutex
 }
 
 // NewPerformanceMetrics 创建性能指标收集器
 func NewPerformanceMetrics() *PerformanceMetrics {
-	return &PerformanceMetrics{}
+	return &PerformanceMetrics{
+		heartbeatLatencyHist: metrics.NewHistogram(metrics.DefaultLatencyBuckets),
+		stateSyncLatencyHist: metrics.NewHistogram(metrics.DefaultLatencyBuckets),
+	}
 }
 
 // RecordHeartbeat 记录心跳处理
@@ -50,6 +58,7 @@ func (pm *PerformanceMetrics) RecordHeartbeat(latency time.Duration, dropped boo
 	pm.HeartbeatProcessed.Add(1)
 	latencyNs := latency.Nanoseconds()
 	pm.HeartbeatLatency.Add(latencyNs)
+	pm.heartbeatLatencyHist.Observe(latency.Seconds())
 
 	// 更新最大延迟
 	for {
@@ -83,6 +92,7 @@ func (pm *PerformanceMetrics) RecordStateSync(success bool, latency time.Duratio
 		pm.StateSyncFailed.Add(1)
 	}
 	pm.StateSyncLatency.Add(latency.Nanoseconds())
+	pm.stateSyncLatencyHist.Observe(latency.Seconds())
 }
 
 // RecordResourceCheck 记录资源检查
@@ -91,6 +101,16 @@ func (pm *PerformanceMetrics) RecordResourceCheck(latency time.Duration) {
 	pm.ResourceCheckLatency.Add(latency.Nanoseconds())
 }
 
+// HeartbeatLatencyHistogram 获取心跳处理延迟分布快照
+func (pm *PerformanceMetrics) HeartbeatLatencyHistogram() metrics.HistogramSnapshot {
+	return pm.heartbeatLatencyHist.Snapshot()
+}
+
+// StateSyncLatencyHistogram 获取状态同步延迟分布快照
+func (pm *PerformanceMetrics) StateSyncLatencyHistogram() metrics.HistogramSnapshot {
+	return pm.stateSyncLatencyHist.Snapshot()
+}
+
 // GetStats 获取统计信息
 func (pm *PerformanceMetrics) GetStats() map[string]interface{} {
 	pm.mu.RLock()
@@ -0,0 +1,522 @@
+package agent
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/bingooyong/ops-scaffold-framework/daemon/internal/config"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// ExecHelperArg daemon以该参数作为第一个参数启动时，作为Agent启动辅助进程运行
+	// Go无法在fork和exec之间执行代码，因此umask和资源限制由辅助进程设置后再exec Agent二进制
+	ExecHelperArg = "__agent-exec"
+
+	// execHelperStatusFD 辅助进程状态管道的文件描述符(ExtraFiles[0])
+	// exec成功时管道随close-on-exec关闭，失败时写入错误信息
+	execHelperStatusFD = 3
+
+	// defaultAgentPath 不继承daemon环境变量时Agent的PATH
+	defaultAgentPath = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+)
+
+// rlimitResources 支持的资源限制
+var rlimitResources = map[string]int{
+	"nofile": unix.RLIMIT_NOFILE,
+	"nproc":  unix.RLIMIT_NPROC,
+	"core":   unix.RLIMIT_CORE,
+}
+
+// ProcessAttrs Agent进程运行属性
+// 控制Agent进程的运行用户、环境变量、umask和资源限制
+type ProcessAttrs struct {
+	// Credential 运行用户和用户组(nil表示与daemon相同)
+	Credential *syscall.Credential
+
+	// Username 运行用户名(用于USER/LOGNAME环境变量)
+	Username string
+
+	// HomeDir 运行用户主目录(用于HOME环境变量)
+	HomeDir string
+
+	// Env 额外环境变量(KEY=VALUE)，优先于EnvFile
+	Env []string
+
+	// EnvFile 环境变量文件，每次启动时读取
+	EnvFile string
+
+	// InheritEnv 是否继承daemon的环境变量
+	InheritEnv bool
+
+	// Umask 文件创建掩码(-1表示不设置，继承daemon的umask)
+	Umask int
+
+	// Rlimits 资源限制(资源名 -> 软硬限制值)
+	Rlimits map[string]uint64
+}
+
+// DefaultProcessAttrs 返回默认进程属性(与daemon相同的用户、环境变量、umask和资源限制)
+func DefaultProcessAttrs() *ProcessAttrs {
+	return &ProcessAttrs{
+		InheritEnv: true,
+		Umask:      -1,
+	}
+}
+
+// NewProcessAttrs 根据Agent配置创建进程属性
+// 与DefaultProcessAttrs不同，未开启inherit_env时不继承daemon的环境变量
+func NewProcessAttrs(cfg *config.AgentItemConfig) (*ProcessAttrs, error) {
+	attrs := &ProcessAttrs{
+		Env:        cfg.Env,
+		EnvFile:    cfg.EnvFile,
+		InheritEnv: cfg.InheritEnv,
+		Umask:      -1,
+		Rlimits:    make(map[string]uint64),
+	}
+
+	if cfg.User != "" || cfg.Group != "" {
+		cred, u, err := lookupCredential(cfg.User, cfg.Group)
+		if err != nil {
+			return nil, err
+		}
+		attrs.Credential = cred
+		if u != nil {
+			attrs.Username = u.Username
+			attrs.HomeDir = u.HomeDir
+		}
+	}
+
+	if cfg.Umask != "" {
+		mask, err := strconv.ParseUint(cfg.Umask, 8, 32)
+		if err != nil || mask > 0777 {
+			return nil, fmt.Errorf("invalid umask: %s", cfg.Umask)
+		}
+		attrs.Umask = int(mask)
+	}
+
+	if cfg.Rlimits.Nofile != nil {
+		attrs.Rlimits["nofile"] = *cfg.Rlimits.Nofile
+	}
+	if cfg.Rlimits.Nproc != nil {
+		attrs.Rlimits["nproc"] = *cfg.Rlimits.Nproc
+	}
+	if cfg.Rlimits.Core != nil {
+		attrs.Rlimits["core"] = *cfg.Rlimits.Core
+	}
+
+	return attrs, nil
+}
+
+// lookupCredential 解析运行用户和用户组(支持名称或数字ID)
+// 未指定用户组时使用用户的主组；同时设置用户的附加组，避免继承daemon(root)的附加组
+func lookupCredential(userName, groupName string) (*syscall.Credential, *user.User, error) {
+	cred := &syscall.Credential{
+		Uid:    uint32(os.Getuid()),
+		Gid:    uint32(os.Getgid()),
+		Groups: []uint32{},
+	}
+
+	var u *user.User
+	if userName != "" {
+		var err error
+		u, err = lookupUser(userName)
+		if err != nil {
+			return nil, nil, err
+		}
+		uid, err := strconv.ParseUint(u.Uid, 10, 32)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid uid for user %s: %s", userName, u.Uid)
+		}
+		gid, err := strconv.ParseUint(u.Gid, 10, 32)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid gid for user %s: %s", userName, u.Gid)
+		}
+		cred.Uid = uint32(uid)
+		cred.Gid = uint32(gid)
+
+		if groupIDs, err := u.GroupIds(); err == nil {
+			for _, id := range groupIDs {
+				if gid, err := strconv.ParseUint(id, 10, 32); err == nil {
+					cred.Groups = append(cred.Groups, uint32(gid))
+				}
+			}
+		}
+	}
+
+	if groupName != "" {
+		g, err := user.LookupGroup(groupName)
+		if err != nil {
+			if g, err = user.LookupGroupId(groupName); err != nil {
+				return nil, nil, fmt.Errorf("unknown group: %s", groupName)
+			}
+		}
+		gid, err := strconv.ParseUint(g.Gid, 10, 32)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid gid for group %s: %s", groupName, g.Gid)
+		}
+		cred.Gid = uint32(gid)
+	}
+
+	return cred, u, nil
+}
+
+// lookupUser 按用户名或UID查找用户
+func lookupUser(name string) (*user.User, error) {
+	u, err := user.Lookup(name)
+	if err == nil {
+		return u, nil
+	}
+	if _, convErr := strconv.ParseUint(name, 10, 32); convErr == nil {
+		if u, err := user.LookupId(name); err == nil {
+			return u, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown user: %s", name)
+}
+
+// environ 构建Agent进程的环境变量
+// 优先级(由低到高): daemon环境变量(inherit_env)或基础变量 < 运行用户的HOME/USER < env_file < env
+func (pa *ProcessAttrs) environ() ([]string, error) {
+	var base []string
+	if pa.InheritEnv {
+		base = os.Environ()
+	} else {
+		base = []string{"PATH=" + defaultAgentPath}
+		if home := os.Getenv("HOME"); home != "" && pa.Credential == nil {
+			base = append(base, "HOME="+home)
+		}
+	}
+
+	env := newEnvList(base)
+	if pa.Username != "" {
+		env.set("USER", pa.Username)
+		env.set("LOGNAME", pa.Username)
+	}
+	if pa.HomeDir != "" {
+		env.set("HOME", pa.HomeDir)
+	}
+
+	if pa.EnvFile != "" {
+		fileEnv, err := parseEnvFile(pa.EnvFile)
+		if err != nil {
+			return nil, err
+		}
+		for _, kv := range fileEnv {
+			key, value, _ := strings.Cut(kv, "=")
+			env.set(key, value)
+		}
+	}
+
+	for _, kv := range pa.Env {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid env entry: %q", kv)
+		}
+		env.set(key, value)
+	}
+
+	return env.list(), nil
+}
+
+// needsHelper 是否需要通过启动辅助进程设置umask或资源限制
+func (pa *ProcessAttrs) needsHelper() bool {
+	return pa.Umask >= 0 || len(pa.Rlimits) > 0
+}
+
+// command 构建Agent启动命令(已设置SysProcAttr的运行用户)
+// 需要设置umask、资源限制或加入cgroup(cgroupDir非空)时，改为启动daemon自身作为辅助进程，
+// 由其加入cgroup、设置后降权并exec Agent二进制，保证Agent及其子进程从第一条指令起受cgroup限制；
+// 此时返回的execHelper用于在cmd.Start之后等待exec完成
+func (pa *ProcessAttrs) command(binary string, args []string, cgroupDir string) (*exec.Cmd, *execHelper, error) {
+	env, err := pa.environ()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !pa.needsHelper() && cgroupDir == "" {
+		cmd := exec.Command(binary, args...)
+		cmd.Env = env
+		cmd.SysProcAttr = &syscall.SysProcAttr{Credential: pa.Credential}
+		return cmd, nil, nil
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to locate daemon executable: %w", err)
+	}
+
+	// 辅助进程以daemon身份运行(需要权限设置资源限制)，exec前再切换到运行用户
+	helperArgs := []string{ExecHelperArg}
+	if cgroupDir != "" {
+		helperArgs = append(helperArgs, "--cgroup", cgroupDir)
+	}
+	if cred := pa.Credential; cred != nil {
+		groups := make([]string, 0, len(cred.Groups))
+		for _, gid := range cred.Groups {
+			groups = append(groups, strconv.FormatUint(uint64(gid), 10))
+		}
+		helperArgs = append(helperArgs,
+			"--uid", strconv.FormatUint(uint64(cred.Uid), 10),
+			"--gid", strconv.FormatUint(uint64(cred.Gid), 10),
+			"--groups", strings.Join(groups, ","))
+	}
+	if pa.Umask >= 0 {
+		helperArgs = append(helperArgs, "--umask", strconv.FormatInt(int64(pa.Umask), 8))
+	}
+	names := make([]string, 0, len(pa.Rlimits))
+	for name := range pa.Rlimits {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		helperArgs = append(helperArgs, "--rlimit", fmt.Sprintf("%s=%d", name, pa.Rlimits[name]))
+	}
+	helperArgs = append(helperArgs, "--", binary)
+	helperArgs = append(helperArgs, args...)
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create exec helper pipe: %w", err)
+	}
+
+	cmd := exec.Command(self, helperArgs...)
+	cmd.Env = env
+	cmd.ExtraFiles = []*os.File{w}
+	cmd.SysProcAttr = &syscall.SysProcAttr{}
+	return cmd, &execHelper{r: r, w: w}, nil
+}
+
+// execHelper 启动辅助进程的状态管道
+type execHelper struct {
+	r *os.File
+	w *os.File
+}
+
+// wait 等待辅助进程exec Agent二进制(需要在cmd.Start成功后调用)，返回辅助进程报告的错误
+func (h *execHelper) wait() error {
+	h.w.Close()
+	defer h.r.Close()
+
+	msg, err := io.ReadAll(h.r)
+	if err != nil {
+		return fmt.Errorf("failed to read exec helper status: %w", err)
+	}
+	if len(msg) > 0 {
+		return errors.New(string(msg))
+	}
+	return nil
+}
+
+// close 关闭状态管道(cmd.Start失败时调用)
+func (h *execHelper) close() {
+	h.w.Close()
+	h.r.Close()
+}
+
+// RunExecHelper 作为Agent启动辅助进程运行(由daemon的main函数在第一个参数为ExecHelperArg时调用)
+// 加入cgroup、设置umask和资源限制、切换运行用户后exec Agent二进制，成功时不返回；失败时通过状态管道报告错误并退出
+func RunExecHelper(args []string) {
+	err := execAgent(args)
+	status := os.NewFile(execHelperStatusFD, "exec-status")
+	fmt.Fprintf(status, "exec helper: %v", err)
+	os.Exit(127)
+}
+
+// execAgent 解析辅助进程参数，加入cgroup、设置umask和资源限制、切换运行用户后exec Agent二进制
+// 参数格式: [--cgroup <dir>] [--uid <uid> --gid <gid> --groups <gid,...>] [--umask <八进制>] [--rlimit <name>=<value>]... -- <binary> [args...]
+func execAgent(args []string) error {
+	var argv []string
+	var cred *syscall.Credential
+	for i := 0; i < len(args) && argv == nil; i++ {
+		flag := args[i]
+		if flag == "--" {
+			argv = args[i+1:]
+			break
+		}
+		if i+1 >= len(args) {
+			return fmt.Errorf("missing value for %s", flag)
+		}
+		i++
+		value := args[i]
+
+		switch flag {
+		case "--cgroup":
+			// 降权前加入cgroup(写cgroup.procs需要daemon权限)，exec后的Agent进程继承该cgroup
+			if err := writeCgroupFile(filepath.Join(value, "cgroup.procs"), strconv.Itoa(os.Getpid())); err != nil {
+				return fmt.Errorf("failed to join cgroup %s: %w", value, err)
+			}
+		case "--uid", "--gid":
+			id, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %s", flag, value)
+			}
+			if cred == nil {
+				cred = &syscall.Credential{}
+			}
+			if flag == "--uid" {
+				cred.Uid = uint32(id)
+			} else {
+				cred.Gid = uint32(id)
+			}
+		case "--groups":
+			if cred == nil {
+				cred = &syscall.Credential{}
+			}
+			for _, g := range strings.Split(value, ",") {
+				if g == "" {
+					continue
+				}
+				gid, err := strconv.ParseUint(g, 10, 32)
+				if err != nil {
+					return fmt.Errorf("invalid group id: %s", g)
+				}
+				cred.Groups = append(cred.Groups, uint32(gid))
+			}
+		case "--umask":
+			mask, err := strconv.ParseUint(value, 8, 32)
+			if err != nil {
+				return fmt.Errorf("invalid umask: %s", value)
+			}
+			unix.Umask(int(mask))
+		case "--rlimit":
+			if err := applyRlimit(value); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unknown argument: %s", flag)
+		}
+	}
+	if len(argv) == 0 {
+		return fmt.Errorf("missing agent binary")
+	}
+
+	// 资源限制设置完成后再降权(提高硬限制需要root权限)
+	if cred != nil {
+		if err := dropPrivileges(cred); err != nil {
+			return err
+		}
+	}
+
+	syscall.CloseOnExec(execHelperStatusFD)
+	if err := syscall.Exec(argv[0], argv, os.Environ()); err != nil {
+		return fmt.Errorf("failed to exec %s: %w", argv[0], err)
+	}
+	return nil
+}
+
+// dropPrivileges 切换到指定的用户、用户组和附加组
+func dropPrivileges(cred *syscall.Credential) error {
+	groups := make([]int, 0, len(cred.Groups))
+	for _, gid := range cred.Groups {
+		groups = append(groups, int(gid))
+	}
+	if err := syscall.Setgroups(groups); err != nil {
+		return fmt.Errorf("failed to set groups: %w", err)
+	}
+	if err := syscall.Setgid(int(cred.Gid)); err != nil {
+		return fmt.Errorf("failed to set gid %d: %w", cred.Gid, err)
+	}
+	if err := syscall.Setuid(int(cred.Uid)); err != nil {
+		return fmt.Errorf("failed to set uid %d: %w", cred.Uid, err)
+	}
+	return nil
+}
+
+// applyRlimit 设置资源限制(格式: name=value，软硬限制设为相同值)
+func applyRlimit(spec string) error {
+	name, value, ok := strings.Cut(spec, "=")
+	resource, known := rlimitResources[name]
+	if !ok || !known {
+		return fmt.Errorf("invalid rlimit: %s", spec)
+	}
+	limit, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid rlimit value: %s", spec)
+	}
+	if err := unix.Setrlimit(resource, &unix.Rlimit{Cur: limit, Max: limit}); err != nil {
+		return fmt.Errorf("failed to set rlimit %s=%d: %w", name, limit, err)
+	}
+	return nil
+}
+
+// parseEnvFile 解析环境变量文件
+// 每行一个KEY=VALUE，支持#注释、空行、export前缀以及成对的单/双引号
+func parseEnvFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open env file: %w", err)
+	}
+	defer file.Close()
+
+	var env []string
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid line %d in env file %s", lineNum, path)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		env = append(env, key+"="+value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read env file: %w", err)
+	}
+	return env, nil
+}
+
+// envList 保持顺序的环境变量列表(后设置的同名变量覆盖先前的值)
+type envList struct {
+	keys   []string
+	values map[string]string
+}
+
+// newEnvList 从KEY=VALUE列表创建环境变量列表
+func newEnvList(base []string) *envList {
+	env := &envList{values: make(map[string]string)}
+	for _, kv := range base {
+		if key, value, ok := strings.Cut(kv, "="); ok {
+			env.set(key, value)
+		}
+	}
+	return env
+}
+
+// set 设置环境变量
+func (e *envList) set(key, value string) {
+	if _, exists := e.values[key]; !exists {
+		e.keys = append(e.keys, key)
+	}
+	e.values[key] = value
+}
+
+// list 返回KEY=VALUE列表
+func (e *envList) list() []string {
+	result := make([]string, 0, len(e.keys))
+	for _, key := range e.keys {
+		result = append(result, key+"="+e.values[key])
+	}
+	return result
+}
@@ -0,0 +1,222 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bingooyong/ops-scaffold-framework/daemon/internal/config"
+	"go.uber.org/zap"
+)
+
+// TestMain 测试二进制同样需要支持作为Agent启动辅助进程运行
+func TestMain(m *testing.M) {
+	if len(os.Args) > 1 && os.Args[1] == ExecHelperArg {
+		RunExecHelper(os.Args[2:])
+	}
+	os.Exit(m.Run())
+}
+
+func TestParseEnvFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.env")
+	content := "# comment\n\nFOO=bar\nexport BAZ=\"quoted value\"\nSINGLE='x=y'\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write env file: %v", err)
+	}
+
+	env, err := parseEnvFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{"FOO=bar", "BAZ=quoted value", "SINGLE=x=y"}
+	if strings.Join(env, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected %v, got %v", expected, env)
+	}
+
+	if err := os.WriteFile(path, []byte("INVALID\n"), 0644); err != nil {
+		t.Fatalf("failed to write env file: %v", err)
+	}
+	if _, err := parseEnvFile(path); err == nil {
+		t.Error("expected error for invalid line")
+	}
+}
+
+func TestProcessAttrs_Environ(t *testing.T) {
+	t.Setenv("DAEMON_SECRET", "secret")
+
+	envFile := filepath.Join(t.TempDir(), "agent.env")
+	if err := os.WriteFile(envFile, []byte("FROM_FILE=1\nOVERRIDE=file\n"), 0644); err != nil {
+		t.Fatalf("failed to write env file: %v", err)
+	}
+
+	attrs, err := NewProcessAttrs(&config.AgentItemConfig{
+		Env:     []string{"OVERRIDE=env", "EXTRA=2"},
+		EnvFile: envFile,
+	})
+	if err != nil {
+		t.Fatalf("failed to create process attrs: %v", err)
+	}
+
+	env, err := attrs.environ()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	values := make(map[string]string)
+	for _, kv := range env {
+		key, value, _ := strings.Cut(kv, "=")
+		values[key] = value
+	}
+
+	if _, ok := values["DAEMON_SECRET"]; ok {
+		t.Error("daemon environment should not be inherited by default")
+	}
+	if values["PATH"] != defaultAgentPath {
+		t.Errorf("expected default PATH, got %q", values["PATH"])
+	}
+	if values["FROM_FILE"] != "1" || values["EXTRA"] != "2" {
+		t.Errorf("expected env file and env entries, got %v", values)
+	}
+	if values["OVERRIDE"] != "env" {
+		t.Errorf("expected env to override env_file, got %q", values["OVERRIDE"])
+	}
+
+	attrs.InheritEnv = true
+	env, err = attrs.environ()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !containsString(env, "DAEMON_SECRET=secret") {
+		t.Error("expected daemon environment to be inherited with inherit_env")
+	}
+}
+
+func TestNewProcessAttrs_Invalid(t *testing.T) {
+	if _, err := NewProcessAttrs(&config.AgentItemConfig{User: "no-such-user-for-test"}); err == nil {
+		t.Error("expected error for unknown user")
+	}
+	if _, err := NewProcessAttrs(&config.AgentItemConfig{Group: "no-such-group-for-test"}); err == nil {
+		t.Error("expected error for unknown group")
+	}
+	if _, err := NewProcessAttrs(&config.AgentItemConfig{Umask: "999"}); err == nil {
+		t.Error("expected error for invalid umask")
+	}
+}
+
+func TestAgentInstance_Start_ProcessAttrs(t *testing.T) {
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("/bin/sh not available")
+	}
+
+	workDir := t.TempDir()
+	// 输出运行身份、umask、资源限制和环境变量后退出
+	script := filepath.Join(workDir, "agent.sh")
+	content := "#!/bin/sh\necho \"uid=$(id -u) umask=$(umask) nofile=$(ulimit -n) core=$(ulimit -c) env=$AGENT_ENV secret=$DAEMON_SECRET\"\n"
+	if err := os.WriteFile(script, []byte(content), 0755); err != nil {
+		t.Fatalf("failed to write script: %v", err)
+	}
+	t.Setenv("DAEMON_SECRET", "secret")
+
+	nofile := uint64(256)
+	core := uint64(0)
+	cfg := &config.AgentItemConfig{
+		Env:   []string{"AGENT_ENV=configured"},
+		Umask: "0027",
+		Rlimits: config.RlimitsConfig{
+			Nofile: &nofile,
+			Core:   &core,
+		},
+	}
+	if os.Getuid() == 0 {
+		// 以root运行测试时验证切换用户(脚本路径需要对该用户可访问)
+		cfg.User = "nobody"
+		for _, dir := range []string{filepath.Dir(workDir), workDir} {
+			if err := os.Chmod(dir, 0755); err != nil {
+				t.Fatalf("failed to chmod %s: %v", dir, err)
+			}
+		}
+	}
+	attrs, err := NewProcessAttrs(cfg)
+	if err != nil {
+		if cfg.User != "" {
+			t.Skipf("user nobody not available: %v", err)
+		}
+		t.Fatalf("failed to create process attrs: %v", err)
+	}
+	expectedUID := strconv.Itoa(os.Getuid())
+	if attrs.Credential != nil {
+		expectedUID = strconv.FormatUint(uint64(attrs.Credential.Uid), 10)
+	}
+
+	info := &AgentInfo{
+		ID:         "attrs-agent",
+		Type:       TypeCustom,
+		BinaryPath: script,
+		WorkDir:    workDir,
+	}
+	instance := NewAgentInstance(info, zap.NewNop())
+	instance.SetProcessAttrs(attrs)
+
+	if err := instance.Start(context.Background()); err != nil {
+		t.Fatalf("failed to start agent: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for instance.GetLastExit() == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("expected agent to exit")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	output, err := os.ReadFile(instance.getLogFilePath())
+	if err != nil {
+		t.Fatalf("failed to read agent log: %v", err)
+	}
+	expected := "uid=" + expectedUID + " umask=0027 nofile=256 core=0 env=configured secret="
+	if strings.TrimSpace(string(output)) != expected {
+		t.Errorf("expected %q, got %q", expected, strings.TrimSpace(string(output)))
+	}
+}
+
+func TestAgentInstance_Start_ExecHelperError(t *testing.T) {
+	workDir := t.TempDir()
+	info := &AgentInfo{
+		ID:         "attrs-agent",
+		Type:       TypeCustom,
+		BinaryPath: writeTestAgentScript(t, workDir),
+		WorkDir:    workDir,
+	}
+	instance := NewAgentInstance(info, zap.NewNop())
+
+	// 资源名称无效时辅助进程在exec前失败，错误应返回给调用方
+	instance.SetProcessAttrs(&ProcessAttrs{
+		Umask:   -1,
+		Rlimits: map[string]uint64{"invalid": 1},
+	})
+
+	err := instance.Start(context.Background())
+	if err == nil {
+		instance.Stop(context.Background(), false)
+		t.Fatal("expected start error")
+	}
+	if !strings.Contains(err.Error(), "invalid rlimit") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if instance.IsRunning() {
+		t.Error("expected agent not to be running")
+	}
+}
+
+// containsString 判断列表是否包含指定字符串
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
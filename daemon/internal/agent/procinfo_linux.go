@@ -0,0 +1,52 @@
+//go:build linux
+
+package agent
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// readProcessStat 读取进程状态和启动时间(Linux专用实现)
+// 启动时间为 /proc/<pid>/stat 第22个字段 starttime(系统启动后的时钟节拍数)，
+// 同一PID被复用时该值不同，可用于识别进程是否仍是同一个
+func readProcessStat(pid int) (*processStat, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read process stat: %w", err)
+	}
+
+	// 进程名(第2个字段)可能包含空格和括号，从最后一个')'之后开始解析
+	content := string(data)
+	idx := strings.LastIndexByte(content, ')')
+	if idx < 0 {
+		return nil, fmt.Errorf("invalid stat format for pid %d", pid)
+	}
+
+	// fields[0] 为第3个字段(state)，starttime为第22个字段
+	fields := strings.Fields(content[idx+1:])
+	if len(fields) < 20 {
+		return nil, fmt.Errorf("invalid stat format for pid %d", pid)
+	}
+
+	startTime, err := strconv.ParseUint(fields[19], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start time for pid %d: %w", pid, err)
+	}
+	return &processStat{
+		State:     fields[0],
+		StartTime: startTime,
+	}, nil
+}
+
+// readProcessExe 读取进程可执行文件路径(Linux专用实现)
+// 可执行文件被替换或删除时，路径带有" (deleted)"后缀
+func readProcessExe(pid int) (string, error) {
+	exe, err := os.Readlink(fmt.Sprintf("/proc/%d/exe", pid))
+	if err != nil {
+		return "", fmt.Errorf("failed to read process exe: %w", err)
+	}
+	return exe, nil
+}
@@ -0,0 +1,18 @@
+//go:build !linux
+
+package agent
+
+import (
+	"fmt"
+)
+
+// readProcessStat 读取进程状态和启动时间(非Linux平台不支持)
+// 返回错误时daemon不会接管之前启动的Agent进程
+func readProcessStat(pid int) (*processStat, error) {
+	return nil, fmt.Errorf("reading process stat not implemented on this platform")
+}
+
+// readProcessExe 读取进程可执行文件路径(非Linux平台不支持)
+func readProcessExe(pid int) (string, error) {
+	return "", fmt.Errorf("reading process executable not implemented on this platform")
+}
@@ -311,3 +311,12 @@ type AgentRunningError struct {
 func (e *AgentRunningError) Error() string {
 	return "agent is running, cannot unregister: " + e.ID
 }
+
+// AgentNotRunningError Agent未运行错误
+type AgentNotRunningError struct {
+	ID string
+}
+
+func (e *AgentNotRunningError) Error() string {
+	return "agent is not running: " + e.ID
+}
@@ -0,0 +1,393 @@
+package agent
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// resourceSegmentPrefix 段文件名前缀
+	resourceSegmentPrefix = "seg-"
+
+	// resourceSegmentSuffix 未压缩段文件后缀
+	resourceSegmentSuffix = ".jsonl"
+
+	// resourceCompactSuffix 已压缩(降采样)段文件后缀
+	resourceCompactSuffix = ".compact.jsonl"
+
+	// resourceSegmentTimeLayout 段文件名中的起始时间格式(UTC，按字典序即时间序)
+	resourceSegmentTimeLayout = "20060102T150405Z"
+)
+
+// ResourceHistoryOptions 资源历史存储配置
+type ResourceHistoryOptions struct {
+	// Retention 保留时长(最后写入早于该时长的段文件被删除)，默认7天
+	Retention time.Duration
+
+	// SegmentDuration 每个段文件覆盖的时长，默认1小时
+	SegmentDuration time.Duration
+
+	// CompactAfter 最后写入早于该时长的段文件被降采样压缩，默认24小时
+	CompactAfter time.Duration
+
+	// CompactResolution 压缩后每个Agent的采样间隔，默认5分钟
+	CompactResolution time.Duration
+}
+
+// resourceHistoryRecord 段文件中的一条记录(一行JSON)
+type resourceHistoryRecord struct {
+	AgentID string `json:"agent_id"`
+	ResourceDataPoint
+}
+
+// resourceSegment 段文件信息
+type resourceSegment struct {
+	path      string
+	start     time.Time
+	modTime   time.Time
+	compacted bool
+}
+
+// ResourceHistoryStore Agent资源历史的本地持久化存储
+// 数据点以JSON行追加写入按时间分段的文件，daemon重启或升级后历史数据不丢失；
+// 超过保留时长的段文件被删除，较旧的段文件按CompactResolution降采样压缩
+type ResourceHistoryStore struct {
+	dir    string
+	opts   ResourceHistoryOptions
+	logger *zap.Logger
+
+	// mu 保护当前段文件
+	mu           sync.Mutex
+	current      *os.File
+	currentStart time.Time
+}
+
+// NewResourceHistoryStore 创建资源历史存储(目录不存在时自动创建)，并执行一次保留和压缩
+func NewResourceHistoryStore(dir string, opts ResourceHistoryOptions, logger *zap.Logger) (*ResourceHistoryStore, error) {
+	if opts.Retention <= 0 {
+		opts.Retention = 7 * 24 * time.Hour
+	}
+	if opts.SegmentDuration <= 0 {
+		opts.SegmentDuration = time.Hour
+	}
+	if opts.CompactAfter <= 0 {
+		opts.CompactAfter = 24 * time.Hour
+	}
+	if opts.CompactResolution <= 0 {
+		opts.CompactResolution = 5 * time.Minute
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create resource history directory: %w", err)
+	}
+
+	store := &ResourceHistoryStore{
+		dir:    dir,
+		opts:   opts,
+		logger: logger,
+	}
+	store.mu.Lock()
+	store.maintainLocked(time.Now())
+	store.mu.Unlock()
+
+	logger.Info("resource history store opened",
+		zap.String("dir", dir),
+		zap.Duration("retention", opts.Retention),
+		zap.Duration("segment_duration", opts.SegmentDuration),
+		zap.Duration("compact_after", opts.CompactAfter),
+		zap.Duration("compact_resolution", opts.CompactResolution))
+	return store, nil
+}
+
+// Append 追加一个数据点
+// 数据点进入新的时间段时切换段文件，并执行保留和压缩
+func (s *ResourceHistoryStore) Append(agentID string, point *ResourceDataPoint) error {
+	line, err := json.Marshal(resourceHistoryRecord{AgentID: agentID, ResourceDataPoint: *point})
+	if err != nil {
+		return fmt.Errorf("failed to marshal resource data point: %w", err)
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	start := point.Timestamp.UTC().Truncate(s.opts.SegmentDuration)
+	if s.current == nil || !start.Equal(s.currentStart) {
+		if err := s.openSegmentLocked(start); err != nil {
+			return err
+		}
+		s.maintainLocked(time.Now())
+	}
+
+	if _, err := s.current.Write(line); err != nil {
+		return fmt.Errorf("failed to write resource data point: %w", err)
+	}
+	return nil
+}
+
+// openSegmentLocked 关闭当前段文件并打开start对应的段文件(追加写入)
+func (s *ResourceHistoryStore) openSegmentLocked(start time.Time) error {
+	if s.current != nil {
+		s.current.Close()
+		s.current = nil
+	}
+
+	path := filepath.Join(s.dir, resourceSegmentPrefix+start.Format(resourceSegmentTimeLayout)+resourceSegmentSuffix)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open resource history segment: %w", err)
+	}
+
+	// 上次异常退出可能留下不完整的最后一行，补换行避免与新记录拼接
+	if info, err := file.Stat(); err == nil && info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := file.ReadAt(last, info.Size()-1); err == nil && last[0] != '\n' {
+			file.Write([]byte{'\n'})
+		}
+	}
+
+	s.current = file
+	s.currentStart = start
+	return nil
+}
+
+// Query 查询指定Agent在[since, until]内的数据点(按时间正序)
+// 零值的since/until表示不限制
+func (s *ResourceHistoryStore) Query(agentID string, since, until time.Time) ([]ResourceDataPoint, error) {
+	// 持有锁读取，避免与压缩同时进行
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	segments, err := s.listSegments()
+	if err != nil {
+		return nil, err
+	}
+
+	// 记录以 {"agent_id":"..." 开头，先按前缀过滤再解析
+	quoted, _ := json.Marshal(agentID)
+	prefix := append([]byte(`{"agent_id":`), quoted...)
+	prefix = append(prefix, ',')
+
+	result := make([]ResourceDataPoint, 0)
+	for _, seg := range segments {
+		// 段文件最后写入早于since时，其中的数据都早于since
+		if !since.IsZero() && seg.modTime.Before(since) {
+			continue
+		}
+		if !until.IsZero() && seg.start.After(until) {
+			continue
+		}
+		if err := readResourceSegment(seg.path, func(line []byte) {
+			if !bytes.HasPrefix(line, prefix) {
+				return
+			}
+			var record resourceHistoryRecord
+			if err := json.Unmarshal(line, &record); err != nil {
+				return
+			}
+			ts := record.Timestamp
+			if (!since.IsZero() && ts.Before(since)) || (!until.IsZero() && ts.After(until)) {
+				return
+			}
+			result = append(result, record.ResourceDataPoint)
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Timestamp.Before(result[j].Timestamp)
+	})
+	return result, nil
+}
+
+// Maintain 删除超过保留时长的段文件，并降采样压缩较旧的段文件
+func (s *ResourceHistoryStore) Maintain() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maintainLocked(time.Now())
+}
+
+// maintainLocked 执行保留和压缩(调用方需持有mu)
+func (s *ResourceHistoryStore) maintainLocked(now time.Time) {
+	segments, err := s.listSegments()
+	if err != nil {
+		s.logger.Warn("failed to list resource history segments", zap.Error(err))
+		return
+	}
+
+	retentionCutoff := now.Add(-s.opts.Retention)
+	compactCutoff := now.Add(-s.opts.CompactAfter)
+	for _, seg := range segments {
+		if seg.modTime.Before(retentionCutoff) {
+			if err := os.Remove(seg.path); err != nil {
+				s.logger.Warn("failed to remove expired resource history segment",
+					zap.String("segment", seg.path),
+					zap.Error(err))
+			} else {
+				s.logger.Debug("removed expired resource history segment",
+					zap.String("segment", seg.path))
+			}
+			continue
+		}
+
+		isCurrent := s.current != nil && seg.start.Equal(s.currentStart)
+		if seg.compacted || isCurrent || !seg.modTime.Before(compactCutoff) {
+			continue
+		}
+		if err := s.compactSegment(seg); err != nil {
+			s.logger.Warn("failed to compact resource history segment",
+				zap.String("segment", seg.path),
+				zap.Error(err))
+		}
+	}
+}
+
+// compactSegment 将段文件中每个Agent的数据点按CompactResolution取平均，写入压缩段文件后删除原文件
+func (s *ResourceHistoryStore) compactSegment(seg resourceSegment) error {
+	type windowKey struct {
+		agentID string
+		window  time.Time
+	}
+	windows := make(map[windowKey][]ResourceDataPoint)
+	var keys []windowKey
+	var total int
+	err := readResourceSegment(seg.path, func(line []byte) {
+		var record resourceHistoryRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return
+		}
+		total++
+		key := windowKey{agentID: record.AgentID, window: record.Timestamp.Truncate(s.opts.CompactResolution)}
+		if _, ok := windows[key]; !ok {
+			keys = append(keys, key)
+		}
+		windows[key] = append(windows[key], record.ResourceDataPoint)
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool {
+		return keys[i].window.Before(keys[j].window)
+	})
+	var buf bytes.Buffer
+	for _, key := range keys {
+		line, err := json.Marshal(resourceHistoryRecord{
+			AgentID:           key.agentID,
+			ResourceDataPoint: aggregateResourceDataPoints(windows[key], key.window),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to marshal compacted data point: %w", err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	compactPath := strings.TrimSuffix(seg.path, resourceSegmentSuffix) + resourceCompactSuffix
+	tmpPath := compactPath + ".tmp"
+	if err := os.WriteFile(tmpPath, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write compacted segment: %w", err)
+	}
+	if err := os.Rename(tmpPath, compactPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to rename compacted segment: %w", err)
+	}
+	// 保留原修改时间，保留时长仍按最后写入时间计算
+	os.Chtimes(compactPath, seg.modTime, seg.modTime)
+	if err := os.Remove(seg.path); err != nil {
+		return fmt.Errorf("failed to remove compacted source segment: %w", err)
+	}
+
+	s.logger.Debug("compacted resource history segment",
+		zap.String("segment", compactPath),
+		zap.Int("data_points", total),
+		zap.Int("compacted_points", len(keys)))
+	return nil
+}
+
+// listSegments 列出所有段文件(按起始时间正序)
+func (s *ResourceHistoryStore) listSegments() ([]resourceSegment, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read resource history directory: %w", err)
+	}
+
+	var segments []resourceSegment
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, resourceSegmentPrefix) {
+			continue
+		}
+		seg := resourceSegment{path: filepath.Join(s.dir, name)}
+		var stamp string
+		switch {
+		case strings.HasSuffix(name, resourceCompactSuffix):
+			seg.compacted = true
+			stamp = strings.TrimSuffix(strings.TrimPrefix(name, resourceSegmentPrefix), resourceCompactSuffix)
+		case strings.HasSuffix(name, resourceSegmentSuffix):
+			stamp = strings.TrimSuffix(strings.TrimPrefix(name, resourceSegmentPrefix), resourceSegmentSuffix)
+		default:
+			continue
+		}
+		start, err := time.Parse(resourceSegmentTimeLayout, stamp)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		seg.start = start
+		seg.modTime = info.ModTime()
+		segments = append(segments, seg)
+	}
+
+	sort.Slice(segments, func(i, j int) bool {
+		return segments[i].start.Before(segments[j].start)
+	})
+	return segments, nil
+}
+
+// readResourceSegment 逐行读取段文件
+func readResourceSegment(path string, fn func(line []byte)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to open resource history segment: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := scanner.Bytes(); len(line) > 0 {
+			fn(line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read resource history segment: %w", err)
+	}
+	return nil
+}
+
+// Close 关闭当前段文件
+func (s *ResourceHistoryStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.current == nil {
+		return nil
+	}
+	err := s.current.Close()
+	s.current = nil
+	return err
+}
@@ -0,0 +1,212 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zaptest"
+)
+
+func TestResourceHistoryStore_AppendQueryReopen(t *testing.T) {
+	dir := t.TempDir()
+	logger := zaptest.NewLogger(t)
+	opts := ResourceHistoryOptions{SegmentDuration: time.Hour}
+
+	store, err := NewResourceHistoryStore(dir, opts, logger)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+
+	// 跨越两个段文件，两个Agent交错写入
+	base := time.Now().Add(-90 * time.Minute).Truncate(time.Minute)
+	for i := 0; i < 90; i += 10 {
+		ts := base.Add(time.Duration(i) * time.Minute)
+		if err := store.Append("agent-a", &ResourceDataPoint{Timestamp: ts, CPU: float64(i), MemoryRSS: uint64(i) * 1024, OpenFiles: 7}); err != nil {
+			t.Fatalf("failed to append: %v", err)
+		}
+		if err := store.Append("agent-b", &ResourceDataPoint{Timestamp: ts, CPU: 99}); err != nil {
+			t.Fatalf("failed to append: %v", err)
+		}
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("failed to close store: %v", err)
+	}
+
+	segments, _ := filepath.Glob(filepath.Join(dir, "seg-*.jsonl"))
+	if len(segments) < 2 {
+		t.Errorf("expected data split into at least 2 segments, got %v", segments)
+	}
+
+	// 模拟daemon重启: 重新打开后历史数据仍在，且模拟异常退出留下的不完整行不影响读取
+	if err := appendRaw(segments[len(segments)-1], `{"agent_id":"agent-a","timest`); err != nil {
+		t.Fatalf("failed to append partial line: %v", err)
+	}
+	store, err = NewResourceHistoryStore(dir, opts, logger)
+	if err != nil {
+		t.Fatalf("failed to reopen store: %v", err)
+	}
+	defer store.Close()
+	if err := store.Append("agent-a", &ResourceDataPoint{Timestamp: base.Add(85 * time.Minute), CPU: 85}); err != nil {
+		t.Fatalf("failed to append after reopen: %v", err)
+	}
+
+	points, err := store.Query("agent-a", base.Add(30*time.Minute), time.Now())
+	if err != nil {
+		t.Fatalf("failed to query: %v", err)
+	}
+	var cpus []float64
+	for _, p := range points {
+		cpus = append(cpus, p.CPU)
+	}
+	want := []float64{30, 40, 50, 60, 70, 80, 85}
+	if len(cpus) != len(want) {
+		t.Fatalf("expected cpu %v, got %v", want, cpus)
+	}
+	for i := range want {
+		if cpus[i] != want[i] {
+			t.Errorf("point %d: expected cpu %v, got %v", i, want[i], cpus[i])
+		}
+	}
+	if points[0].MemoryRSS != 30*1024 || points[0].OpenFiles != 7 {
+		t.Errorf("expected all fields persisted, got %+v", points[0])
+	}
+
+	if points, _ := store.Query("missing", time.Time{}, time.Time{}); len(points) != 0 {
+		t.Errorf("expected no points for unknown agent, got %d", len(points))
+	}
+}
+
+func TestResourceHistoryStore_CompactionAndRetention(t *testing.T) {
+	dir := t.TempDir()
+	logger := zaptest.NewLogger(t)
+	now := time.Now()
+
+	// 写入三个段: 过期(8天前)、需要压缩(2天前)、最近
+	writeSegment := func(start time.Time, minutes int) string {
+		t.Helper()
+		// 写入时不触发保留和压缩
+		store, err := NewResourceHistoryStore(dir, ResourceHistoryOptions{
+			Retention:       365 * 24 * time.Hour,
+			SegmentDuration: time.Hour,
+			CompactAfter:    365 * 24 * time.Hour,
+		}, logger)
+		if err != nil {
+			t.Fatalf("failed to open store: %v", err)
+		}
+		for i := 0; i < minutes; i++ {
+			ts := start.Add(time.Duration(i) * time.Minute)
+			if err := store.Append("agent-a", &ResourceDataPoint{Timestamp: ts, CPU: float64(i % 5), MemoryRSS: 1000}); err != nil {
+				t.Fatalf("failed to append: %v", err)
+			}
+		}
+		store.Close()
+		path := filepath.Join(dir, "seg-"+start.UTC().Truncate(time.Hour).Format(resourceSegmentTimeLayout)+".jsonl")
+		last := start.Add(time.Duration(minutes-1) * time.Minute)
+		if err := os.Chtimes(path, last, last); err != nil {
+			t.Fatalf("failed to set segment time: %v", err)
+		}
+		return path
+	}
+	expired := writeSegment(now.Add(-8*24*time.Hour).Truncate(time.Hour), 10)
+	old := writeSegment(now.Add(-48*time.Hour).Truncate(time.Hour), 60)
+	recent := writeSegment(now.Add(-time.Hour).Truncate(time.Hour), 10)
+
+	store, err := NewResourceHistoryStore(dir, ResourceHistoryOptions{
+		Retention:         7 * 24 * time.Hour,
+		SegmentDuration:   time.Hour,
+		CompactAfter:      24 * time.Hour,
+		CompactResolution: 5 * time.Minute,
+	}, logger)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer store.Close()
+
+	if _, err := os.Stat(expired); !os.IsNotExist(err) {
+		t.Error("expected expired segment to be removed")
+	}
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Error("expected old segment to be replaced by its compacted version")
+	}
+	compacted := strings.TrimSuffix(old, ".jsonl") + ".compact.jsonl"
+	if _, err := os.Stat(compacted); err != nil {
+		t.Errorf("expected compacted segment: %v", err)
+	}
+	if _, err := os.Stat(recent); err != nil {
+		t.Errorf("expected recent segment to be kept as is: %v", err)
+	}
+
+	// 压缩段: 60个每分钟的点降采样为12个5分钟平均值
+	oldStart := now.Add(-48 * time.Hour).Truncate(time.Hour)
+	points, err := store.Query("agent-a", oldStart, oldStart.Add(time.Hour-time.Nanosecond))
+	if err != nil {
+		t.Fatalf("failed to query: %v", err)
+	}
+	if len(points) != 12 {
+		t.Fatalf("expected 12 compacted points, got %d", len(points))
+	}
+	if points[0].CPU != 2 || points[0].MemoryRSS != 1000 {
+		t.Errorf("expected averaged point, got %+v", points[0])
+	}
+
+	// 一周内的所有数据(压缩 + 最近)均可查询
+	points, err = store.Query("agent-a", now.Add(-7*24*time.Hour), now)
+	if err != nil {
+		t.Fatalf("failed to query: %v", err)
+	}
+	if len(points) != 22 {
+		t.Errorf("expected 22 points within a week, got %d", len(points))
+	}
+}
+
+func TestResourceMonitor_GetResourceHistoryFromStore(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	workDir := t.TempDir()
+	multiManager, err := NewMultiAgentManager(workDir, logger)
+	if err != nil {
+		t.Fatalf("failed to create multi agent manager: %v", err)
+	}
+
+	store, err := NewResourceHistoryStore(filepath.Join(workDir, "resource_history"), ResourceHistoryOptions{}, logger)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	// 模拟上次daemon运行时写入的历史数据(内存中的元数据为空)
+	for i := 3; i >= 1; i-- {
+		store.Append("test-agent", &ResourceDataPoint{Timestamp: time.Now().Add(-time.Duration(i) * 10 * time.Minute), CPU: float64(i)})
+	}
+
+	monitor := NewResourceMonitor(multiManager, multiManager.GetRegistry(), logger)
+	monitor.SetHistoryStore(store)
+	defer monitor.Stop()
+
+	history, err := monitor.GetResourceHistory("test-agent", time.Hour)
+	if err != nil {
+		t.Fatalf("failed to get resource history: %v", err)
+	}
+	if len(history) != 3 || history[0].CPU != 3 {
+		t.Errorf("expected 3 points from store oldest first, got %+v", history)
+	}
+
+	aggregated, err := monitor.GetResourceHistoryAggregated("test-agent", 15*time.Minute, time.Hour)
+	if err != nil {
+		t.Fatalf("failed to get aggregated history: %v", err)
+	}
+	if len(aggregated) == 0 {
+		t.Error("expected aggregated history from store")
+	}
+}
+
+// appendRaw 向文件末尾追加原始内容
+func appendRaw(path, content string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.WriteString(content)
+	return err
+}
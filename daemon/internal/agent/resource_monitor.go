@@ -54,8 +54,28 @@ type ResourceThreshold struct {
 
 	// ThresholdDuration 超过阈值持续时间(触发告警/重启)
 	ThresholdDuration time.Duration `json:"threshold_duration"`
+
+	// Action 超过阈值持续时间后执行的动作(none/restart/stop，为空时同none)
+	Action ResourceAlertAction `json:"action"`
 }
 
+// ResourceAlertAction 资源告警触发后执行的动作
+type ResourceAlertAction string
+
+const (
+	// ResourceActionNone 只告警
+	ResourceActionNone ResourceAlertAction = "none"
+
+	// ResourceActionRestart 告警并重启Agent(如内存泄露)
+	ResourceActionRestart ResourceAlertAction = "restart"
+
+	// ResourceActionStop 告警并停止Agent
+	ResourceActionStop ResourceAlertAction = "stop"
+)
+
+// resourceActionTimeout 执行告警动作(重启/停止)的超时
+const resourceActionTimeout = 60 * time.Second
+
 // ResourceAlert 资源告警
 // 表示资源使用超过阈值时的告警信息
 type ResourceAlert struct {
@@ -76,6 +96,12 @@ type ResourceAlert struct {
 
 	// Timestamp 告警时间
 	Timestamp time.Time `json:"timestamp"`
+
+	// Action 执行的动作
+	Action ResourceAlertAction `json:"action"`
+
+	// ActionError 动作执行失败时的错误信息
+	ActionError string `json:"action_error,omitempty"`
 }
 
 // ResourceMonitor 资源监控器
@@ -100,6 +126,25 @@ type ResourceMonitor struct {
 	// key: agent_id, value: map[resourceType]time.Time
 	exceededSince map[string]map[string]time.Time
 
+	// cgroupCPU 记录每个Agent上一次的cgroup CPU累计时间(用于计算CPU使用率)
+	cgroupCPU map[string]cgroupCPUSample
+
+	// historyStore 资源历史持久化存储(可选)，设置后历史查询从存储读取
+	historyStore *ResourceHistoryStore
+
+	// latest 每个Agent最近一次采集的资源数据
+	latest map[string]*ResourceDataPoint
+
+	// alerted 记录每个Agent本次超阈值期间已告警的资源类型(恢复正常后清除，避免重复告警)
+	// key: agent_id, value: map[resourceType]bool
+	alerted map[string]map[string]bool
+
+	// alertCallback 资源告警回调(可选，用于上报Manager)
+	alertCallback func(alert *ResourceAlert)
+
+	// actionsInFlight 正在执行告警动作的Agent(每个Agent同时只执行一个动作)
+	actionsInFlight map[string]bool
+
 	// ctx 上下文(用于停止监控)
 	ctx context.Context
 
@@ -124,8 +169,13 @@ func NewResourceMonitor(multiManager *MultiAgentManager, registry *AgentRegistry
 		interval:      60 * time.Second,
 		thresholds:    make(map[string]*ResourceThreshold),
 		exceededSince: make(map[string]map[string]time.Time),
+		cgroupCPU:     make(map[string]cgroupCPUSample),
+		latest:        make(map[string]*ResourceDataPoint),
+		alerted:       make(map[string]map[string]bool),
 		ctx:           ctx,
 		cancel:        cancel,
+
+		actionsInFlight: make(map[string]bool),
 	}
 }
 
@@ -149,6 +199,30 @@ func (rm *ResourceMonitor) SetThreshold(agentID string, threshold *ResourceThres
 		zap.Duration("threshold_duration", threshold.ThresholdDuration))
 }
 
+// SetAlertCallback 设置资源告警回调
+// 资源超过阈值的持续时间达到ThresholdDuration时调用(每次超阈值期间每种资源只告警一次)
+func (rm *ResourceMonitor) SetAlertCallback(callback func(alert *ResourceAlert)) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	rm.alertCallback = callback
+}
+
+// SetHistoryStore 设置资源历史持久化存储
+// 设置后每个采集的数据点都会写入存储，GetResourceHistory从存储读取(daemon重启后仍可查询)；
+// Stop时关闭存储
+func (rm *ResourceMonitor) SetHistoryStore(store *ResourceHistoryStore) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	rm.historyStore = store
+}
+
+// getHistoryStore 获取资源历史持久化存储
+func (rm *ResourceMonitor) getHistoryStore() *ResourceHistoryStore {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+	return rm.historyStore
+}
+
 // collectAgentResources 采集指定Agent的资源使用情况
 func (rm *ResourceMonitor) collectAgentResources(agentID string) (*ResourceDataPoint, error) {
 	// 从registry获取Agent信息
@@ -249,9 +323,52 @@ func (rm *ResourceMonitor) collectAgentResources(agentID string) (*ResourceDataP
 		dataPoint.NumThreads = int(numThreads)
 	}
 
+	// 运行在独立cgroup中的Agent，使用cgroup统计(包含所有子进程)覆盖进程级指标
+	if rm.multiManager != nil {
+		if instance := rm.multiManager.GetAgent(agentID); instance != nil {
+			if cg := instance.GetCgroup(); cg != nil {
+				rm.applyCgroupStats(agentID, cg, dataPoint)
+			}
+		}
+	}
+
 	return dataPoint, nil
 }
 
+// cgroupCPUSample cgroup CPU累计时间采样
+type cgroupCPUSample struct {
+	usageUsec uint64
+	at        time.Time
+}
+
+// applyCgroupStats 使用cgroup统计覆盖内存、磁盘I/O和CPU使用率
+// CPU使用率根据两次采样间cpu.stat usage_usec的增量计算，首次采样保留进程级的值
+func (rm *ResourceMonitor) applyCgroupStats(agentID string, cg *AgentCgroup, dataPoint *ResourceDataPoint) {
+	stats, err := cg.Stats()
+	if err != nil {
+		rm.logger.Warn("failed to read cgroup stats",
+			zap.String("agent_id", agentID),
+			zap.String("cgroup", cg.Path()),
+			zap.Error(err))
+		return
+	}
+
+	// memory.current包含页缓存，会把日志写入等文件缓存算作Agent内存，因此使用常驻内存
+	dataPoint.MemoryRSS = stats.MemoryRSS()
+	dataPoint.DiskReadBytes = stats.IOReadBytes
+	dataPoint.DiskWriteBytes = stats.IOWriteBytes
+
+	rm.mu.Lock()
+	prev, ok := rm.cgroupCPU[agentID]
+	rm.cgroupCPU[agentID] = cgroupCPUSample{usageUsec: stats.CPUUsageUsec, at: dataPoint.Timestamp}
+	rm.mu.Unlock()
+
+	elapsed := dataPoint.Timestamp.Sub(prev.at).Microseconds()
+	if ok && elapsed > 0 && stats.CPUUsageUsec >= prev.usageUsec {
+		dataPoint.CPU = float64(stats.CPUUsageUsec-prev.usageUsec) / float64(elapsed) * 100
+	}
+}
+
 // collectAllAgents 采集所有运行中Agent的资源使用情况
 func (rm *ResourceMonitor) collectAllAgents() {
 	// 从multiManager获取所有运行中的Agent列表
@@ -260,14 +377,17 @@ func (rm *ResourceMonitor) collectAllAgents() {
 	// 并发采集以提高效率
 	var wg sync.WaitGroup
 	for _, instance := range instances {
-		// 只采集运行中的Agent
+		info := instance.GetInfo()
+		agentID := info.ID
+
+		// 只采集运行中的Agent，已停止的Agent不再保留最近资源数据
 		if !instance.IsRunning() {
+			rm.mu.Lock()
+			delete(rm.latest, agentID)
+			rm.mu.Unlock()
 			continue
 		}
 
-		info := instance.GetInfo()
-		agentID := info.ID
-
 		wg.Add(1)
 		go func(id string) {
 			defer wg.Done()
@@ -281,6 +401,19 @@ func (rm *ResourceMonitor) collectAllAgents() {
 				return
 			}
 
+			// 持久化数据点
+			if store := rm.getHistoryStore(); store != nil {
+				if err := store.Append(id, dataPoint); err != nil {
+					rm.logger.Warn("failed to persist resource data point",
+						zap.String("agent_id", id),
+						zap.Error(err))
+				}
+			}
+
+			rm.mu.Lock()
+			rm.latest[id] = dataPoint
+			rm.mu.Unlock()
+
 			// 更新元数据
 			rm.updateAgentResourceData(id, dataPoint)
 		}(agentID)
@@ -352,7 +485,7 @@ func (rm *ResourceMonitor) checkResourceThresholds(agentID string, dataPoint *Re
 	now := time.Now()
 
 	// 检查CPU使用率
-	if dataPoint.CPU > threshold.CPUThreshold {
+	if threshold.CPUThreshold > 0 && dataPoint.CPU > threshold.CPUThreshold {
 		duration := rm.getExceededDuration(agentID, "cpu", now)
 		if duration >= threshold.ThresholdDuration {
 			rm.logger.Error("agent cpu over threshold for too long",
@@ -361,8 +494,7 @@ func (rm *ResourceMonitor) checkResourceThresholds(agentID string, dataPoint *Re
 				zap.Float64("threshold", threshold.CPUThreshold),
 				zap.Duration("duration", duration))
 
-			// 可选:调用MultiAgentManager触发重启
-			// 这里只记录告警,实际重启逻辑由HealthChecker处理
+			rm.raiseAlert(agentID, "cpu", dataPoint.CPU, threshold.CPUThreshold, duration, threshold.Action)
 		} else {
 			rm.logger.Warn("agent cpu over threshold",
 				zap.String("agent_id", agentID),
@@ -376,7 +508,7 @@ func (rm *ResourceMonitor) checkResourceThresholds(agentID string, dataPoint *Re
 	}
 
 	// 检查内存占用
-	if dataPoint.MemoryRSS > threshold.MemoryThreshold {
+	if threshold.MemoryThreshold > 0 && dataPoint.MemoryRSS > threshold.MemoryThreshold {
 		duration := rm.getExceededDuration(agentID, "memory", now)
 		if duration >= threshold.ThresholdDuration {
 			rm.logger.Error("agent memory over threshold for too long",
@@ -385,8 +517,7 @@ func (rm *ResourceMonitor) checkResourceThresholds(agentID string, dataPoint *Re
 				zap.Uint64("threshold", threshold.MemoryThreshold),
 				zap.Duration("duration", duration))
 
-			// 可选:调用MultiAgentManager触发重启
-			// 这里只记录告警,实际重启逻辑由HealthChecker处理
+			rm.raiseAlert(agentID, "memory", float64(dataPoint.MemoryRSS), float64(threshold.MemoryThreshold), duration, threshold.Action)
 		} else {
 			rm.logger.Warn("agent memory over threshold",
 				zap.String("agent_id", agentID),
@@ -411,6 +542,7 @@ func (rm *ResourceMonitor) checkResourceThresholds(agentID string, dataPoint *Re
 				zap.String("warning", "file descriptor leak detected"))
 
 			// 文件描述符泄露是严重问题,可能需要重启Agent
+			rm.raiseAlert(agentID, "open_files", float64(dataPoint.OpenFiles), float64(threshold.OpenFilesThreshold), duration, threshold.Action)
 		} else {
 			rm.logger.Warn("agent open files over threshold",
 				zap.String("agent_id", agentID),
@@ -424,6 +556,123 @@ func (rm *ResourceMonitor) checkResourceThresholds(agentID string, dataPoint *Re
 	}
 }
 
+// raiseAlert 生成资源告警，异步执行配置的动作并在完成后通知告警回调
+// 同一次超阈值期间每种资源只告警一次；执行重启/停止后清除该Agent的超阈值记录
+func (rm *ResourceMonitor) raiseAlert(agentID, resourceType string, value, thresholdValue float64, duration time.Duration, action ResourceAlertAction) {
+	rm.mu.Lock()
+	if rm.alerted[agentID] == nil {
+		rm.alerted[agentID] = make(map[string]bool)
+	}
+	if rm.alerted[agentID][resourceType] {
+		rm.mu.Unlock()
+		return
+	}
+	rm.alerted[agentID][resourceType] = true
+	callback := rm.alertCallback
+	rm.mu.Unlock()
+
+	if action == "" {
+		action = ResourceActionNone
+	}
+	alert := &ResourceAlert{
+		AgentID:   agentID,
+		Type:      resourceType,
+		Value:     value,
+		Threshold: thresholdValue,
+		Duration:  duration,
+		Timestamp: time.Now(),
+		Action:    action,
+	}
+
+	if action == ResourceActionNone {
+		if callback != nil {
+			callback(alert)
+		}
+		return
+	}
+
+	// 动作可能耗时较长(停止Agent最长等待停止超时)，异步执行以免阻塞其他Agent的采集和阈值检查
+	rm.mu.Lock()
+	busy := rm.actionsInFlight[agentID]
+	if !busy {
+		rm.actionsInFlight[agentID] = true
+	}
+	rm.mu.Unlock()
+	if busy {
+		alert.ActionError = "another resource alert action is in progress for this agent"
+		rm.logger.Warn("resource alert action skipped, another action is in progress",
+			zap.String("agent_id", agentID),
+			zap.String("type", resourceType),
+			zap.String("action", string(action)))
+		if callback != nil {
+			callback(alert)
+		}
+		return
+	}
+
+	rm.wg.Add(1)
+	go func() {
+		defer rm.wg.Done()
+		rm.runAlertAction(alert, callback)
+	}()
+}
+
+// runAlertAction 执行告警动作，完成后连同执行结果上报告警
+func (rm *ResourceMonitor) runAlertAction(alert *ResourceAlert, callback func(alert *ResourceAlert)) {
+	agentID := alert.AgentID
+	defer func() {
+		rm.mu.Lock()
+		delete(rm.actionsInFlight, agentID)
+		rm.mu.Unlock()
+	}()
+
+	if err := rm.executeAlertAction(agentID, alert.Action, alert.Type); err != nil {
+		alert.ActionError = err.Error()
+		rm.logger.Error("failed to execute resource alert action",
+			zap.String("agent_id", agentID),
+			zap.String("type", alert.Type),
+			zap.String("action", string(alert.Action)),
+			zap.Error(err))
+	} else {
+		rm.logger.Warn("resource alert action executed",
+			zap.String("agent_id", agentID),
+			zap.String("type", alert.Type),
+			zap.String("action", string(alert.Action)))
+		// 进程已重启或停止，重新开始计算超阈值时间
+		rm.mu.Lock()
+		delete(rm.exceededSince, agentID)
+		delete(rm.alerted, agentID)
+		rm.mu.Unlock()
+	}
+
+	if callback != nil {
+		callback(alert)
+	}
+}
+
+// executeAlertAction 执行资源告警动作(重启/停止Agent)
+// 重启按Agent的重启策略执行并计入崩溃窗口，反复超限的Agent会触发熔断而不是无限重启
+func (rm *ResourceMonitor) executeAlertAction(agentID string, action ResourceAlertAction, resourceType string) error {
+	ctx, cancel := context.WithTimeout(rm.ctx, resourceActionTimeout)
+	defer cancel()
+
+	switch action {
+	case ResourceActionRestart:
+		restarted, err := rm.multiManager.AutoRestartAgent(ctx, agentID, resourceType+" threshold exceeded")
+		if err != nil {
+			return err
+		}
+		if !restarted {
+			return fmt.Errorf("restart not performed (circuit breaker open or disallowed by restart policy)")
+		}
+		return nil
+	case ResourceActionStop:
+		return rm.multiManager.StopAgent(ctx, agentID, true)
+	default:
+		return fmt.Errorf("unknown resource alert action: %s", action)
+	}
+}
+
 // getExceededDuration 获取资源超阈值持续时间
 func (rm *ResourceMonitor) getExceededDuration(agentID string, resourceType string, now time.Time) time.Duration {
 	rm.mu.Lock()
@@ -452,6 +701,9 @@ func (rm *ResourceMonitor) clearExceededSince(agentID string, resourceType strin
 	if rm.exceededSince[agentID] != nil {
 		rm.exceededSince[agentID][resourceType] = time.Time{}
 	}
+	if rm.alerted[agentID] != nil {
+		delete(rm.alerted[agentID], resourceType)
+	}
 }
 
 // Start 启动监控循环
@@ -468,6 +720,11 @@ func (rm *ResourceMonitor) Stop() {
 	rm.logger.Info("stopping resource monitor")
 	rm.cancel()
 	rm.wg.Wait()
+	if store := rm.getHistoryStore(); store != nil {
+		if err := store.Close(); err != nil {
+			rm.logger.Warn("failed to close resource history store", zap.Error(err))
+		}
+	}
 	rm.logger.Info("resource monitor stopped")
 }
 
@@ -492,7 +749,21 @@ func (rm *ResourceMonitor) monitorLoop() {
 }
 
 // GetResourceHistory 获取指定Agent的资源使用历史数据
+// 设置了持久化存储时从存储读取，否则从内存中的元数据读取
 func (rm *ResourceMonitor) GetResourceHistory(agentID string, duration time.Duration) ([]ResourceDataPoint, error) {
+	if store := rm.getHistoryStore(); store != nil {
+		now := time.Now()
+		result, err := store.Query(agentID, now.Add(-duration), now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query resource history: %w", err)
+		}
+		rm.logger.Debug("retrieved resource history from store",
+			zap.String("agent_id", agentID),
+			zap.Duration("duration", duration),
+			zap.Int("data_points", len(result)))
+		return result, nil
+	}
+
 	// 获取Agent元数据
 	metadata, err := rm.multiManager.GetAgentMetadata(agentID)
 	if err != nil {
@@ -596,6 +867,11 @@ func (rm *ResourceMonitor) GetResourceHistoryAggregated(agentID string, duration
 
 // aggregateDataPoints 聚合数据点(计算平均值)
 func (rm *ResourceMonitor) aggregateDataPoints(points []ResourceDataPoint, timestamp time.Time) ResourceDataPoint {
+	return aggregateResourceDataPoints(points, timestamp)
+}
+
+// aggregateResourceDataPoints 聚合数据点(计算平均值)，时间戳为timestamp
+func aggregateResourceDataPoints(points []ResourceDataPoint, timestamp time.Time) ResourceDataPoint {
 	if len(points) == 0 {
 		return ResourceDataPoint{Timestamp: timestamp}
 	}
@@ -631,6 +907,18 @@ func (rm *ResourceMonitor) aggregateDataPoints(points []ResourceDataPoint, times
 	}
 }
 
+// GetLatestResources 获取所有Agent最近一次采集的资源数据(不触发采集)
+func (rm *ResourceMonitor) GetLatestResources() map[string]ResourceDataPoint {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+
+	result := make(map[string]ResourceDataPoint, len(rm.latest))
+	for agentID, dataPoint := range rm.latest {
+		result[agentID] = *dataPoint
+	}
+	return result
+}
+
 // GetCurrentResources 立即采集指定Agent的当前资源使用情况
 func (rm *ResourceMonitor) GetCurrentResources(agentID string) (*ResourceDataPoint, error) {
 	return rm.collectAgentResources(agentID)
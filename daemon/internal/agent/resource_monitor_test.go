@@ -1,10 +1,14 @@
 package agent
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"go.uber.org/zap"
 	"go.uber.org/zap/zaptest"
 )
 
@@ -810,3 +814,171 @@ func TestGetResourceHistory_LargeHistory(t *testing.T) {
 
 	t.Logf("queried %d data points in %v", len(history), duration)
 }
+
+func TestCheckResourceThresholds_RaiseAlertOncePerEpisode(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	multiManager, err := NewMultiAgentManager(t.TempDir(), logger)
+	if err != nil {
+		t.Fatalf("failed to create multi agent manager: %v", err)
+	}
+
+	monitor := NewResourceMonitor(multiManager, multiManager.GetRegistry(), logger)
+	var alerts []*ResourceAlert
+	monitor.SetAlertCallback(func(alert *ResourceAlert) {
+		alerts = append(alerts, alert)
+	})
+
+	agentID := "test-agent"
+	monitor.SetThreshold(agentID, &ResourceThreshold{
+		CPUThreshold:      50.0,
+		ThresholdDuration: 0, // 超过阈值立即告警
+	})
+
+	high := &ResourceDataPoint{Timestamp: time.Now(), CPU: 80.0}
+	normal := &ResourceDataPoint{Timestamp: time.Now(), CPU: 10.0}
+
+	// 同一次超阈值期间只告警一次
+	monitor.checkResourceThresholds(agentID, high)
+	monitor.checkResourceThresholds(agentID, high)
+	if len(alerts) != 1 {
+		t.Fatalf("expected 1 alert, got %d", len(alerts))
+	}
+	alert := alerts[0]
+	if alert.AgentID != agentID || alert.Type != "cpu" || alert.Value != 80.0 || alert.Threshold != 50.0 {
+		t.Errorf("unexpected alert: %+v", alert)
+	}
+	if alert.Action != ResourceActionNone || alert.ActionError != "" {
+		t.Errorf("expected action none without error, got %+v", alert)
+	}
+
+	// 恢复正常后再次超阈值，产生新的告警
+	monitor.checkResourceThresholds(agentID, normal)
+	monitor.checkResourceThresholds(agentID, high)
+	if len(alerts) != 2 {
+		t.Errorf("expected new alert after recovery, got %d alerts", len(alerts))
+	}
+}
+
+func TestCheckResourceThresholds_AlertActionFailure(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	multiManager, err := NewMultiAgentManager(t.TempDir(), logger)
+	if err != nil {
+		t.Fatalf("failed to create multi agent manager: %v", err)
+	}
+
+	monitor := NewResourceMonitor(multiManager, multiManager.GetRegistry(), logger)
+	alertCh := make(chan *ResourceAlert, 1)
+	monitor.SetAlertCallback(func(alert *ResourceAlert) {
+		alertCh <- alert
+	})
+
+	// Agent未注册，重启动作失败，失败原因随告警上报
+	agentID := "missing-agent"
+	monitor.SetThreshold(agentID, &ResourceThreshold{
+		MemoryThreshold: 1024,
+		Action:          ResourceActionRestart,
+	})
+	monitor.checkResourceThresholds(agentID, &ResourceDataPoint{Timestamp: time.Now(), MemoryRSS: 4096})
+
+	// 动作异步执行，完成后连同结果上报
+	alerts := []*ResourceAlert{waitForAlert(t, alertCh)}
+	if alerts[0].Type != "memory" || alerts[0].Action != ResourceActionRestart {
+		t.Errorf("unexpected alert: %+v", alerts[0])
+	}
+	if alerts[0].ActionError == "" {
+		t.Error("expected action error for unknown agent")
+	}
+}
+
+// waitForAlert 等待异步执行的告警动作完成并上报告警
+func waitForAlert(t *testing.T, alertCh <-chan *ResourceAlert) *ResourceAlert {
+	t.Helper()
+	select {
+	case alert := <-alertCh:
+		return alert
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for resource alert")
+		return nil
+	}
+}
+
+func TestCheckResourceThresholds_RestartActionUsesRestartPolicy(t *testing.T) {
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("/bin/sh not available")
+	}
+
+	workDir := t.TempDir()
+	script := filepath.Join(workDir, "agent.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\nexec sleep 60\n"), 0755); err != nil {
+		t.Fatalf("failed to write script: %v", err)
+	}
+
+	multiManager, err := NewMultiAgentManager(workDir, zap.NewNop())
+	if err != nil {
+		t.Fatalf("failed to create multi agent manager: %v", err)
+	}
+	defer multiManager.Close()
+
+	agentID := "leaky-agent"
+	instance, err := multiManager.RegisterAgent(&AgentInfo{ID: agentID, Type: TypeCustom, BinaryPath: script, WorkDir: workDir})
+	if err != nil {
+		t.Fatalf("failed to register agent: %v", err)
+	}
+	instance.SetRestartPolicy(&RestartPolicy{Policy: RestartAlways, MaxCrashes: 2, Window: time.Minute, Cooldown: time.Minute})
+	if err := multiManager.StartAgent(context.Background(), agentID); err != nil {
+		t.Fatalf("failed to start agent: %v", err)
+	}
+	defer instance.Stop(context.Background(), false)
+
+	monitor := NewResourceMonitor(multiManager, multiManager.GetRegistry(), zap.NewNop())
+	alertCh := make(chan *ResourceAlert, 2)
+	monitor.SetAlertCallback(func(alert *ResourceAlert) {
+		alertCh <- alert
+	})
+	monitor.SetThreshold(agentID, &ResourceThreshold{MemoryThreshold: 1024, Action: ResourceActionRestart})
+	high := &ResourceDataPoint{Timestamp: time.Now(), MemoryRSS: 4096}
+
+	// 第一次超限: 按重启策略重启，计入崩溃窗口
+	monitor.checkResourceThresholds(agentID, high)
+	if alert := waitForAlert(t, alertCh); alert.ActionError != "" {
+		t.Fatalf("expected restart to succeed, got %q", alert.ActionError)
+	}
+	if !instance.IsRunning() {
+		t.Fatal("expected agent to be running after restart")
+	}
+
+	// 第二次超限: 达到崩溃次数上限，熔断并停止Agent而不是继续重启
+	monitor.checkResourceThresholds(agentID, high)
+	if alert := waitForAlert(t, alertCh); alert.ActionError == "" {
+		t.Fatal("expected restart to be refused once the circuit breaker opens")
+	}
+	if !instance.GetRestartPolicy().IsTripped() {
+		t.Fatal("expected resource restarts to trip the circuit breaker")
+	}
+	if status := instance.GetInfo().GetStatus(); status != StatusFailed {
+		t.Errorf("expected status %s, got %s", StatusFailed, status)
+	}
+}
+
+func TestCheckResourceThresholds_OneActionPerAgent(t *testing.T) {
+	multiManager, err := NewMultiAgentManager(t.TempDir(), zap.NewNop())
+	if err != nil {
+		t.Fatalf("failed to create multi agent manager: %v", err)
+	}
+
+	monitor := NewResourceMonitor(multiManager, multiManager.GetRegistry(), zap.NewNop())
+	alertCh := make(chan *ResourceAlert, 2)
+	monitor.SetAlertCallback(func(alert *ResourceAlert) {
+		alertCh <- alert
+	})
+
+	// 同一Agent已有动作在执行时，新的动作被跳过并随告警说明
+	agentID := "busy-agent"
+	monitor.actionsInFlight[agentID] = true
+	monitor.SetThreshold(agentID, &ResourceThreshold{MemoryThreshold: 1024, Action: ResourceActionStop})
+
+	monitor.checkResourceThresholds(agentID, &ResourceDataPoint{Timestamp: time.Now(), MemoryRSS: 4096})
+	if alert := waitForAlert(t, alertCh); alert.ActionError == "" {
+		t.Error("expected alert to report that another action is in progress")
+	}
+}
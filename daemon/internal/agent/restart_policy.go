@@ -0,0 +1,263 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/bingooyong/ops-scaffold-framework/daemon/internal/config"
+)
+
+// RestartPolicyType 重启策略类型
+type RestartPolicyType string
+
+const (
+	// RestartAlways 无论退出状态如何都重启
+	RestartAlways RestartPolicyType = "always"
+	// RestartOnFailure 仅在非正常退出(退出码非0或被信号终止)时重启
+	RestartOnFailure RestartPolicyType = "on-failure"
+	// RestartNever 从不自动重启
+	RestartNever RestartPolicyType = "never"
+)
+
+const (
+	// defaultCrashWindow 默认崩溃统计滑动窗口
+	defaultCrashWindow = 10 * time.Minute
+	// defaultCrashCooldown 默认熔断冷却时间
+	defaultCrashCooldown = 5 * time.Minute
+)
+
+// ExitStatus Agent进程退出状态
+type ExitStatus struct {
+	// ExitCode 退出码(被信号终止时为-1)
+	ExitCode int
+
+	// Signal 终止进程的信号(正常退出时为0)
+	Signal syscall.Signal
+
+	// ExitedAt 退出时间
+	ExitedAt time.Time
+
+	// StartedAt 本次运行的启动时间
+	StartedAt time.Time
+
+	// ManuallyStopped 是否为手动停止导致的退出
+	ManuallyStopped bool
+}
+
+// Success 是否正常退出(退出码为0且未被信号终止)
+func (e *ExitStatus) Success() bool {
+	return e.ExitCode == 0 && e.Signal == 0
+}
+
+// Duration 本次运行时长
+func (e *ExitStatus) Duration() time.Duration {
+	if e.StartedAt.IsZero() || e.ExitedAt.IsZero() {
+		return 0
+	}
+	return e.ExitedAt.Sub(e.StartedAt)
+}
+
+// String 返回退出状态的可读描述
+func (e *ExitStatus) String() string {
+	if e.Signal != 0 {
+		return fmt.Sprintf("killed by signal %s", e.Signal)
+	}
+	return fmt.Sprintf("exit code %d", e.ExitCode)
+}
+
+// newExitStatus 从进程状态构建退出状态
+func newExitStatus(state *os.ProcessState, startedAt time.Time) *ExitStatus {
+	status := &ExitStatus{
+		ExitCode:  -1,
+		ExitedAt:  time.Now(),
+		StartedAt: startedAt,
+	}
+	if state == nil {
+		return status
+	}
+
+	status.ExitCode = state.ExitCode()
+	if ws, ok := state.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		status.Signal = ws.Signal()
+	}
+	return status
+}
+
+// RestartPolicy Agent重启策略
+// 根据真实退出状态决定是否重启，并通过滑动窗口统计崩溃次数实现熔断:
+// 在Window时间内崩溃达到MaxCrashes次后进入熔断(failed)状态，冷却Cooldown后才允许再次重启
+type RestartPolicy struct {
+	// Policy 重启策略(always/on-failure/never)
+	Policy RestartPolicyType
+
+	// MaxCrashes 滑动窗口内允许的最大崩溃次数，0表示不启用熔断
+	MaxCrashes int
+
+	// Window 崩溃统计滑动窗口
+	Window time.Duration
+
+	// Cooldown 熔断后的冷却时间
+	Cooldown time.Duration
+
+	// BackoffBase 重启退避基础时间
+	BackoffBase time.Duration
+
+	// BackoffMax 重启退避最大时间
+	BackoffMax time.Duration
+
+	// crashes 滑动窗口内的崩溃时间
+	crashes []time.Time
+
+	// trippedAt 熔断触发时间(零值表示未熔断)
+	trippedAt time.Time
+
+	// mu 保护并发访问
+	mu sync.Mutex
+}
+
+// NewRestartPolicy 根据重启配置创建重启策略
+func NewRestartPolicy(cfg *config.RestartConfig) *RestartPolicy {
+	rp := &RestartPolicy{
+		Policy:   RestartAlways,
+		Window:   defaultCrashWindow,
+		Cooldown: defaultCrashCooldown,
+	}
+	if cfg == nil {
+		return rp
+	}
+
+	if cfg.Policy != "" {
+		rp.Policy = RestartPolicyType(cfg.Policy)
+	}
+	rp.MaxCrashes = cfg.MaxRetries
+	if cfg.Window > 0 {
+		rp.Window = cfg.Window
+	}
+	if cfg.Cooldown > 0 {
+		rp.Cooldown = cfg.Cooldown
+	}
+	rp.BackoffBase = cfg.BackoffBase
+	rp.BackoffMax = cfg.BackoffMax
+
+	return rp
+}
+
+// ShouldRestart 根据退出状态判断策略是否允许重启(不考虑熔断)
+func (rp *RestartPolicy) ShouldRestart(exit *ExitStatus) bool {
+	switch rp.Policy {
+	case RestartNever:
+		return false
+	case RestartOnFailure:
+		// 没有退出信息(如心跳超时、资源超限)视为故障
+		return exit == nil || !exit.Success()
+	default:
+		return true
+	}
+}
+
+// RecordCrash 记录一次崩溃，返回本次记录是否触发熔断
+func (rp *RestartPolicy) RecordCrash(now time.Time) bool {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+
+	if rp.MaxCrashes <= 0 || !rp.trippedAt.IsZero() {
+		return false
+	}
+
+	rp.crashes = append(rp.pruneLocked(now), now)
+	if len(rp.crashes) >= rp.MaxCrashes {
+		rp.trippedAt = now
+		return true
+	}
+	return false
+}
+
+// Allow 检查熔断器是否允许重启
+// 熔断冷却期结束后自动复位并允许一次重启
+func (rp *RestartPolicy) Allow(now time.Time) bool {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+
+	if rp.trippedAt.IsZero() {
+		return true
+	}
+	if now.Sub(rp.trippedAt) < rp.Cooldown {
+		return false
+	}
+
+	rp.trippedAt = time.Time{}
+	rp.crashes = nil
+	return true
+}
+
+// IsTripped 熔断器是否处于熔断状态
+func (rp *RestartPolicy) IsTripped() bool {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	return !rp.trippedAt.IsZero()
+}
+
+// CooldownRemaining 返回熔断剩余冷却时间
+func (rp *RestartPolicy) CooldownRemaining(now time.Time) time.Duration {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+
+	if rp.trippedAt.IsZero() {
+		return 0
+	}
+	remaining := rp.Cooldown - now.Sub(rp.trippedAt)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// CrashCount 返回滑动窗口内的崩溃次数
+func (rp *RestartPolicy) CrashCount(now time.Time) int {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	rp.crashes = rp.pruneLocked(now)
+	return len(rp.crashes)
+}
+
+// Reset 复位熔断器并清空崩溃记录
+func (rp *RestartPolicy) Reset() {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	rp.trippedAt = time.Time{}
+	rp.crashes = nil
+}
+
+// Backoff 根据重启次数计算指数退避时间
+func (rp *RestartPolicy) Backoff(restartCount int) time.Duration {
+	if restartCount < 1 || rp.BackoffBase <= 0 {
+		return 0
+	}
+
+	backoff := rp.BackoffBase
+	for i := 1; i < restartCount; i++ {
+		backoff *= 2
+		if rp.BackoffMax > 0 && backoff >= rp.BackoffMax {
+			return rp.BackoffMax
+		}
+	}
+	if rp.BackoffMax > 0 && backoff > rp.BackoffMax {
+		return rp.BackoffMax
+	}
+	return backoff
+}
+
+// pruneLocked 移除滑动窗口之外的崩溃记录(需要持锁调用)
+func (rp *RestartPolicy) pruneLocked(now time.Time) []time.Time {
+	cutoff := now.Add(-rp.Window)
+	kept := rp.crashes[:0]
+	for _, t := range rp.crashes {
+		if t.After(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	return kept
+}
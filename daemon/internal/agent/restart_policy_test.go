@@ -0,0 +1,76 @@
+package agent
+
+import (
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/bingooyong/ops-scaffold-framework/daemon/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRestartPolicy_ShouldRestart(t *testing.T) {
+	success := &ExitStatus{ExitCode: 0}
+	failure := &ExitStatus{ExitCode: 1}
+	killed := &ExitStatus{ExitCode: -1, Signal: syscall.SIGKILL}
+
+	always := NewRestartPolicy(&config.RestartConfig{Policy: "always"})
+	assert.True(t, always.ShouldRestart(success))
+	assert.True(t, always.ShouldRestart(failure))
+
+	onFailure := NewRestartPolicy(&config.RestartConfig{Policy: "on-failure"})
+	assert.False(t, onFailure.ShouldRestart(success))
+	assert.True(t, onFailure.ShouldRestart(failure))
+	assert.True(t, onFailure.ShouldRestart(killed))
+	assert.True(t, onFailure.ShouldRestart(nil), "missing exit status is treated as failure")
+
+	never := NewRestartPolicy(&config.RestartConfig{Policy: "never"})
+	assert.False(t, never.ShouldRestart(failure))
+}
+
+func TestRestartPolicy_CircuitBreaker(t *testing.T) {
+	rp := NewRestartPolicy(&config.RestartConfig{
+		MaxRetries: 3,
+		Window:     time.Minute,
+		Cooldown:   5 * time.Minute,
+	})
+	now := time.Now()
+
+	assert.False(t, rp.RecordCrash(now))
+	assert.False(t, rp.RecordCrash(now.Add(10*time.Second)))
+	assert.True(t, rp.RecordCrash(now.Add(20*time.Second)), "third crash within window should trip")
+	assert.True(t, rp.IsTripped())
+	assert.False(t, rp.Allow(now.Add(time.Minute)))
+	assert.Equal(t, 4*time.Minute+20*time.Second, rp.CooldownRemaining(now.Add(time.Minute)))
+
+	// 冷却结束后自动复位
+	assert.True(t, rp.Allow(now.Add(6*time.Minute)))
+	assert.False(t, rp.IsTripped())
+	assert.Equal(t, 0, rp.CrashCount(now.Add(6*time.Minute)))
+}
+
+func TestRestartPolicy_CrashesOutsideWindow(t *testing.T) {
+	rp := NewRestartPolicy(&config.RestartConfig{MaxRetries: 2, Window: time.Minute})
+	now := time.Now()
+
+	assert.False(t, rp.RecordCrash(now))
+	assert.False(t, rp.RecordCrash(now.Add(2*time.Minute)), "expired crash should not count")
+	assert.Equal(t, 1, rp.CrashCount(now.Add(2*time.Minute)))
+
+	rp.Reset()
+	assert.Equal(t, 0, rp.CrashCount(now.Add(2*time.Minute)))
+}
+
+func TestRestartPolicy_Backoff(t *testing.T) {
+	rp := NewRestartPolicy(&config.RestartConfig{
+		BackoffBase: time.Second,
+		BackoffMax:  5 * time.Second,
+	})
+
+	assert.Equal(t, time.Duration(0), rp.Backoff(0))
+	assert.Equal(t, time.Second, rp.Backoff(1))
+	assert.Equal(t, 2*time.Second, rp.Backoff(2))
+	assert.Equal(t, 4*time.Second, rp.Backoff(3))
+	assert.Equal(t, 5*time.Second, rp.Backoff(4))
+	assert.Equal(t, 5*time.Second, rp.Backoff(10))
+}
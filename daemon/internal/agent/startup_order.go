@@ -0,0 +1,196 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bingooyong/ops-scaffold-framework/daemon/internal/config"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultReadinessInterval 默认就绪探测间隔
+	defaultReadinessInterval = time.Second
+
+	// defaultReadinessTimeout 默认等待就绪超时
+	defaultReadinessTimeout = 60 * time.Second
+)
+
+// SetDependencies 设置Agent依赖的其他Agent ID(批量启动时依赖就绪后才启动)
+func (ai *AgentInstance) SetDependencies(dependsOn []string) {
+	ai.mu.Lock()
+	defer ai.mu.Unlock()
+	ai.dependsOn = append([]string(nil), dependsOn...)
+}
+
+// GetDependencies 获取Agent依赖的其他Agent ID
+func (ai *AgentInstance) GetDependencies() []string {
+	ai.mu.Lock()
+	defer ai.mu.Unlock()
+	return append([]string(nil), ai.dependsOn...)
+}
+
+// SetReadiness 设置就绪条件(nil或未配置探针表示进程启动即就绪)
+func (ai *AgentInstance) SetReadiness(readiness *config.ReadinessConfig) {
+	ai.mu.Lock()
+	defer ai.mu.Unlock()
+	ai.readiness = readiness
+}
+
+// WaitReady 等待Agent就绪(就绪探针通过)
+// 未配置探针时直接返回；进程退出、超时或ctx取消时返回错误
+func (ai *AgentInstance) WaitReady(ctx context.Context) error {
+	ai.mu.Lock()
+	readiness := ai.readiness
+	ai.mu.Unlock()
+	if readiness == nil || !readiness.IsSet() {
+		return nil
+	}
+
+	interval := readiness.Interval
+	if interval <= 0 {
+		interval = defaultReadinessInterval
+	}
+	timeout := readiness.Timeout
+	if timeout <= 0 {
+		timeout = defaultReadinessTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// 复用生命周期钩子的执行逻辑，HTTP探针默认使用GET
+	probe := &config.HookConfig{
+		Command: readiness.Command,
+		HTTP:    readiness.HTTP,
+	}
+	if probe.HTTP.URL != "" && probe.HTTP.Method == "" {
+		probe.HTTP.Method = http.MethodGet
+	}
+
+	var lastErr error
+	for {
+		if !ai.IsRunning() {
+			return fmt.Errorf("agent exited before becoming ready")
+		}
+		_, err := runHook(ctx, probe, ai.info, ai.info.GetPID())
+		if err == nil {
+			ai.logger.Info("agent is ready",
+				zap.String("agent_id", ai.info.ID))
+			return nil
+		}
+		lastErr = err
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("agent not ready within %s: %w", timeout, lastErr)
+		case <-time.After(interval):
+		}
+	}
+}
+
+// SetStartStagger 设置批量启动时相邻两次启动的最小间隔(0表示不错开)
+func (mam *MultiAgentManager) SetStartStagger(stagger time.Duration) {
+	mam.mu.Lock()
+	defer mam.mu.Unlock()
+	mam.startStagger = stagger
+}
+
+// dependencyGraph 根据实例构建依赖图(key为Agent ID，value为其依赖的Agent ID)
+// 依赖未注册的Agent(如被禁用)时忽略该依赖并记录警告
+func (mam *MultiAgentManager) dependencyGraph(instances map[string]*AgentInstance) map[string][]string {
+	graph := make(map[string][]string, len(instances))
+	for id, instance := range instances {
+		var deps []string
+		for _, dep := range instance.GetDependencies() {
+			if _, ok := instances[dep]; !ok {
+				mam.logger.Warn("ignoring dependency on unregistered agent",
+					zap.String("agent_id", id),
+					zap.String("depends_on", dep))
+				continue
+			}
+			deps = append(deps, dep)
+		}
+		graph[id] = deps
+	}
+	return graph
+}
+
+// dependencyOrder 对依赖图进行拓扑排序
+// 返回依赖在前的启动顺序，以及因存在环而无法排序的Agent(包括依赖环上Agent的Agent)
+func dependencyOrder(graph map[string][]string) (order []string, cyclic []string) {
+	indegree := make(map[string]int, len(graph))
+	dependents := make(map[string][]string, len(graph))
+	for id, deps := range graph {
+		indegree[id] += 0
+		for _, dep := range deps {
+			indegree[id]++
+			dependents[dep] = append(dependents[dep], id)
+		}
+	}
+
+	var queue []string
+	for id, n := range indegree {
+		if n == 0 {
+			queue = append(queue, id)
+		}
+	}
+	sort.Strings(queue)
+
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		order = append(order, id)
+		next := dependents[id]
+		sort.Strings(next)
+		for _, dependent := range next {
+			indegree[dependent]--
+			if indegree[dependent] == 0 {
+				queue = append(queue, dependent)
+			}
+		}
+	}
+
+	for id, n := range indegree {
+		if n > 0 {
+			cyclic = append(cyclic, id)
+		}
+	}
+	sort.Strings(cyclic)
+	return order, cyclic
+}
+
+// startStaggerer 保证批量启动时相邻两次启动至少间隔stagger
+type startStaggerer struct {
+	stagger time.Duration
+	mu      sync.Mutex
+	next    time.Time
+}
+
+// wait 预留下一个启动时间点并等待到达
+func (s *startStaggerer) wait(ctx context.Context) error {
+	if s.stagger <= 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	now := time.Now()
+	at := s.next
+	if at.Before(now) {
+		at = now
+	}
+	s.next = at.Add(s.stagger)
+	s.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(at))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
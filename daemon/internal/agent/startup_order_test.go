@@ -0,0 +1,197 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bingooyong/ops-scaffold-framework/daemon/internal/config"
+	"go.uber.org/zap"
+)
+
+func TestDependencyOrder(t *testing.T) {
+	order, cyclic := dependencyOrder(map[string][]string{
+		"app":     {"shipper", "proxy"},
+		"proxy":   {"shipper"},
+		"shipper": nil,
+		"other":   nil,
+	})
+	if !reflect.DeepEqual(order, []string{"other", "shipper", "proxy", "app"}) {
+		t.Errorf("unexpected order: %v", order)
+	}
+	if len(cyclic) != 0 {
+		t.Errorf("expected no cycle, got %v", cyclic)
+	}
+
+	order, cyclic = dependencyOrder(map[string][]string{
+		"a": {"b"},
+		"b": {"a"},
+		"c": {"a"},
+		"d": nil,
+	})
+	if !reflect.DeepEqual(order, []string{"d"}) {
+		t.Errorf("unexpected order: %v", order)
+	}
+	if !reflect.DeepEqual(cyclic, []string{"a", "b", "c"}) {
+		t.Errorf("expected a, b and c to be rejected, got %v", cyclic)
+	}
+}
+
+func TestStartStaggerer(t *testing.T) {
+	s := &startStaggerer{stagger: 50 * time.Millisecond}
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := s.wait(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("expected starts to be staggered, took %s", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s = &startStaggerer{stagger: time.Hour, next: time.Now().Add(time.Hour)}
+	if err := s.wait(ctx); err == nil {
+		t.Error("expected error when context is cancelled")
+	}
+}
+
+// writeOrderTestScript 写入测试脚本，收到SIGTERM时执行onStop后退出
+func writeOrderTestScript(t *testing.T, dir, name, onStart, onStop string) string {
+	t.Helper()
+	script := filepath.Join(dir, name+".sh")
+	content := "#!/bin/sh\ncd " + dir + "\ntrap '" + onStop + "; exit 0' TERM\n" + onStart + "\nwhile true; do sleep 1; done\n"
+	if err := os.WriteFile(script, []byte(content), 0755); err != nil {
+		t.Fatalf("failed to write script: %v", err)
+	}
+	return script
+}
+
+func TestMultiAgentManager_StartAll_DependencyOrder(t *testing.T) {
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("/bin/sh not available")
+	}
+
+	workDir := t.TempDir()
+	mam, err := NewMultiAgentManager(workDir, zap.NewNop())
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+	defer mam.Close()
+
+	// shipper启动一段时间后才就绪；app启动时记录shipper是否已就绪，
+	// shipper停止时记录app是否已先停止
+	shipperScript := writeOrderTestScript(t, workDir, "shipper",
+		"(sleep 0.3; touch shipper.ready) &",
+		"if [ -f app.stopped ]; then echo ok > shipper.stop; else echo early > shipper.stop; fi")
+	appScript := writeOrderTestScript(t, workDir, "app",
+		"if [ -f shipper.ready ]; then echo ok > app.start; else echo early > app.start; fi",
+		"touch app.stopped")
+
+	shipper, err := mam.RegisterAgent(&AgentInfo{ID: "shipper", Type: TypeCustom, BinaryPath: shipperScript, WorkDir: workDir})
+	if err != nil {
+		t.Fatalf("failed to register agent: %v", err)
+	}
+	shipper.SetReadiness(&config.ReadinessConfig{
+		Command:  []string{"test", "-f", filepath.Join(workDir, "shipper.ready")},
+		Interval: 50 * time.Millisecond,
+		Timeout:  5 * time.Second,
+	})
+	app, err := mam.RegisterAgent(&AgentInfo{ID: "app", Type: TypeCustom, BinaryPath: appScript, WorkDir: workDir})
+	if err != nil {
+		t.Fatalf("failed to register agent: %v", err)
+	}
+	app.SetDependencies([]string{"shipper"})
+
+	for id, err := range mam.StartAll(context.Background()) {
+		if err != nil {
+			t.Errorf("failed to start %s: %v", id, err)
+		}
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if data, err := os.ReadFile(filepath.Join(workDir, "app.start")); err == nil && len(data) > 0 {
+			if got := strings.TrimSpace(string(data)); got != "ok" {
+				t.Errorf("expected app to start after shipper is ready, got %q", got)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("app did not start")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	for id, err := range mam.StopAll(context.Background(), true) {
+		if err != nil {
+			t.Errorf("failed to stop %s: %v", id, err)
+		}
+	}
+	data, err := os.ReadFile(filepath.Join(workDir, "shipper.stop"))
+	if err != nil {
+		t.Fatalf("failed to read stop marker: %v", err)
+	}
+	if got := strings.TrimSpace(string(data)); got != "ok" {
+		t.Errorf("expected shipper to stop after app, got %q", got)
+	}
+}
+
+func TestMultiAgentManager_StartAll_DependencyFailures(t *testing.T) {
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("/bin/sh not available")
+	}
+
+	workDir := t.TempDir()
+	mam, err := NewMultiAgentManager(workDir, zap.NewNop())
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+	defer mam.Close()
+
+	register := func(id string, deps ...string) *AgentInstance {
+		script := writeOrderTestScript(t, workDir, id, ":", ":")
+		instance, err := mam.RegisterAgent(&AgentInfo{ID: id, Type: TypeCustom, BinaryPath: script, WorkDir: workDir})
+		if err != nil {
+			t.Fatalf("failed to register agent: %v", err)
+		}
+		instance.SetDependencies(deps)
+		return instance
+	}
+
+	// a和b互相依赖，不应启动
+	register("a", "b")
+	register("b", "a")
+	// notready的就绪探针始终失败，依赖它的dependent不应启动
+	notReady := register("notready")
+	notReady.SetReadiness(&config.ReadinessConfig{
+		Command:  []string{"false"},
+		Interval: 50 * time.Millisecond,
+		Timeout:  200 * time.Millisecond,
+	})
+	register("dependent", "notready")
+	defer mam.StopAll(context.Background(), false)
+
+	results := mam.StartAll(context.Background())
+	for _, id := range []string{"a", "b"} {
+		if err := results[id]; err == nil || !strings.Contains(err.Error(), "dependency cycle") {
+			t.Errorf("expected cycle error for %s, got %v", id, err)
+		}
+		if mam.GetAgent(id).IsRunning() {
+			t.Errorf("expected %s not to be started", id)
+		}
+	}
+	if err := results["notready"]; err == nil || !strings.Contains(err.Error(), "not ready") {
+		t.Errorf("expected readiness error, got %v", err)
+	}
+	if err := results["dependent"]; err == nil || !strings.Contains(err.Error(), "dependency notready failed") {
+		t.Errorf("expected dependency error, got %v", err)
+	}
+	if mam.GetAgent("dependent").IsRunning() {
+		t.Error("expected dependent not to be started")
+	}
+}
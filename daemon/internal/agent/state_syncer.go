@@ -2,10 +2,12 @@ package agent
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"sync"
 	"time"
 
+	"github.com/bingooyong/ops-scaffold-framework/daemon/internal/spool"
 	"go.uber.org/zap"
 )
 
@@ -17,6 +19,13 @@ type AgentState struct {
 	LastHeartbeat time.Time
 	Type          string // Agent类型(filebeat/telegraf/node_exporter等)
 	Version       string // Agent版本号
+
+	// CustomMetrics 最近一次心跳携带的自定义数值指标
+	CustomMetrics map[string]float64
+	// CustomFields 最近一次心跳携带的自定义状态字段
+	CustomFields map[string]string
+	// CustomMetricsUpdatedAt 自定义指标上报时间(零值表示无)
+	CustomMetricsUpdatedAt time.Time
 }
 
 // StateSyncer Agent状态同步器
@@ -54,11 +63,54 @@ type StateSyncer struct {
 
 	// managerClient Manager gRPC客户端(用于调用Manager的SyncAgentStates方法)
 	managerClient ManagerClient
+
+	// pendingEvents 待上报的Agent事件(按发生顺序)
+	pendingEvents []*AgentEvent
+
+	// pendingCrashes 待上报的崩溃记录(按发生顺序)
+	pendingCrashes []*CrashRecord
+
+	// pendingAlerts 待上报的资源告警(按发生顺序)
+	pendingAlerts []*ResourceAlert
+
+	// eventNotify 有新事件或崩溃记录时通知同步循环立即上报
+	eventNotify chan struct{}
+
+	// perfMetrics 性能指标(可选，用于记录状态同步成功/失败次数和延迟)
+	perfMetrics *PerformanceMetrics
+
+	// forwarder 本地缓存队列(可选)，设置后状态快照、事件、崩溃记录和资源告警先写入磁盘，
+	// Manager可达时按原始顺序补发；未设置时仅在内存中缓存
+	forwarder *spool.Forwarder
+
+	// nodeID 同步使用的节点ID(Start时设置)
+	nodeID string
 }
 
+// maxPendingEvents 待上报事件的最大缓存数量，超出时丢弃最旧的事件
+const maxPendingEvents = 1000
+
+// maxPendingCrashes 待上报崩溃记录的最大缓存数量，超出时丢弃最旧的记录
+const maxPendingCrashes = 200
+
+// maxPendingAlerts 待上报资源告警的最大缓存数量，超出时丢弃最旧的告警
+const maxPendingAlerts = 200
+
+// 本地缓存队列中的记录类型
+const (
+	spoolKindAgentStates    = "agent_states"
+	spoolKindAgentEvents    = "agent_events"
+	spoolKindCrashes        = "agent_crashes"
+	spoolKindResourceAlerts = "resource_alerts"
+)
+
 // ManagerClient Manager gRPC客户端接口
 type ManagerClient interface {
-	SyncAgentStates(ctx context.Context, nodeID string, states []*AgentState) error
+	// SyncAgentStates 同步Agent状态快照，collectedAt为快照采集时间(补发缓存的快照时早于当前时间)
+	SyncAgentStates(ctx context.Context, nodeID string, states []*AgentState, collectedAt time.Time) error
+	ReportAgentEvents(ctx context.Context, nodeID string, events []*AgentEvent) error
+	ReportCrashes(ctx context.Context, nodeID string, crashes []*CrashRecord) error
+	ReportResourceAlerts(ctx context.Context, nodeID string, alerts []*ResourceAlert) error
 }
 
 // NewStateSyncer 创建新的状态同步器
@@ -76,6 +128,7 @@ func NewStateSyncer(
 		managerAddress: managerAddress,
 		syncInterval:   30 * time.Second, // 默认30秒
 		pendingStates:  make(map[string]*AgentState),
+		eventNotify:    make(chan struct{}, 1),
 		logger:         logger,
 		ctx:            ctx,
 		cancel:         cancel,
@@ -87,6 +140,22 @@ func (ss *StateSyncer) SetManagerClient(client ManagerClient) {
 	ss.managerClient = client
 }
 
+// SetPerformanceMetrics 设置性能指标收集器
+func (ss *StateSyncer) SetPerformanceMetrics(pm *PerformanceMetrics) {
+	ss.perfMetrics = pm
+}
+
+// SetSpool 设置本地缓存队列并注册各类记录的发送器
+// 需要在Start之前调用
+func (ss *StateSyncer) SetSpool(forwarder *spool.Forwarder) {
+	ss.forwarder = forwarder
+	// 状态快照逐条发送，保留每个快照的采集时间
+	forwarder.Register(spoolKindAgentStates, 1, ss.sendSpooledStates)
+	forwarder.Register(spoolKindAgentEvents, maxPendingEvents, ss.sendSpooledEvents)
+	forwarder.Register(spoolKindCrashes, maxPendingCrashes, ss.sendSpooledCrashes)
+	forwarder.Register(spoolKindResourceAlerts, maxPendingAlerts, ss.sendSpooledAlerts)
+}
+
 // SetSyncInterval 设置同步间隔
 func (ss *StateSyncer) SetSyncInterval(interval time.Duration) {
 	ss.syncInterval = interval
@@ -139,6 +208,194 @@ func (ss *StateSyncer) OnAgentStateChange(agentID string, status AgentStatus, pi
 	// 这里不立即触发,而是等待定时同步,避免频繁同步
 }
 
+// OnAgentEvent 接收Agent事件并通知同步循环尽快上报
+func (ss *StateSyncer) OnAgentEvent(event *AgentEvent) {
+	if ss.spoolRecord(spoolKindAgentEvents, event.Timestamp, event) {
+		return
+	}
+
+	ss.mu.Lock()
+	ss.pendingEvents = append(ss.pendingEvents, event)
+	if len(ss.pendingEvents) > maxPendingEvents {
+		dropped := len(ss.pendingEvents) - maxPendingEvents
+		ss.pendingEvents = ss.pendingEvents[dropped:]
+		ss.logger.Warn("pending agent events overflow, dropping oldest",
+			zap.Int("dropped", dropped))
+	}
+	ss.mu.Unlock()
+
+	ss.logger.Debug("agent event queued",
+		zap.String("agent_id", event.AgentID),
+		zap.String("type", string(event.Type)))
+
+	select {
+	case ss.eventNotify <- struct{}{}:
+	default:
+	}
+}
+
+// flushEvents 向Manager上报所有待上报事件，失败时保留以便下次重试
+func (ss *StateSyncer) flushEvents(nodeID string) error {
+	ss.mu.Lock()
+	events := ss.pendingEvents
+	ss.pendingEvents = nil
+	ss.mu.Unlock()
+
+	if len(events) == 0 {
+		return nil
+	}
+	if ss.managerClient == nil {
+		return fmt.Errorf("manager client not set")
+	}
+
+	ctx, cancel := context.WithTimeout(ss.ctx, 10*time.Second)
+	defer cancel()
+
+	if err := ss.managerClient.ReportAgentEvents(ctx, nodeID, events); err != nil {
+		// 上报失败，放回队首保持事件顺序
+		ss.mu.Lock()
+		ss.pendingEvents = append(events, ss.pendingEvents...)
+		if len(ss.pendingEvents) > maxPendingEvents {
+			ss.pendingEvents = ss.pendingEvents[len(ss.pendingEvents)-maxPendingEvents:]
+		}
+		ss.mu.Unlock()
+
+		ss.logger.Warn("failed to report agent events to manager",
+			zap.String("node_id", nodeID),
+			zap.Int("count", len(events)),
+			zap.Error(err))
+		return err
+	}
+
+	ss.logger.Info("reported agent events to manager",
+		zap.String("node_id", nodeID),
+		zap.Int("count", len(events)))
+
+	return nil
+}
+
+// OnAgentCrash 接收Agent崩溃记录并通知同步循环尽快上报
+func (ss *StateSyncer) OnAgentCrash(record *CrashRecord) {
+	if ss.spoolRecord(spoolKindCrashes, record.ExitedAt, record) {
+		return
+	}
+
+	ss.mu.Lock()
+	ss.pendingCrashes = append(ss.pendingCrashes, record)
+	if len(ss.pendingCrashes) > maxPendingCrashes {
+		dropped := len(ss.pendingCrashes) - maxPendingCrashes
+		ss.pendingCrashes = ss.pendingCrashes[dropped:]
+		ss.logger.Warn("pending crash records overflow, dropping oldest",
+			zap.Int("dropped", dropped))
+	}
+	ss.mu.Unlock()
+
+	select {
+	case ss.eventNotify <- struct{}{}:
+	default:
+	}
+}
+
+// flushCrashes 向Manager上报所有待上报崩溃记录，失败时保留以便下次重试
+func (ss *StateSyncer) flushCrashes(nodeID string) error {
+	ss.mu.Lock()
+	crashes := ss.pendingCrashes
+	ss.pendingCrashes = nil
+	ss.mu.Unlock()
+
+	if len(crashes) == 0 {
+		return nil
+	}
+	if ss.managerClient == nil {
+		return fmt.Errorf("manager client not set")
+	}
+
+	ctx, cancel := context.WithTimeout(ss.ctx, 10*time.Second)
+	defer cancel()
+
+	if err := ss.managerClient.ReportCrashes(ctx, nodeID, crashes); err != nil {
+		ss.mu.Lock()
+		ss.pendingCrashes = append(crashes, ss.pendingCrashes...)
+		if len(ss.pendingCrashes) > maxPendingCrashes {
+			ss.pendingCrashes = ss.pendingCrashes[len(ss.pendingCrashes)-maxPendingCrashes:]
+		}
+		ss.mu.Unlock()
+
+		ss.logger.Warn("failed to report crash records to manager",
+			zap.String("node_id", nodeID),
+			zap.Int("count", len(crashes)),
+			zap.Error(err))
+		return err
+	}
+
+	ss.logger.Info("reported crash records to manager",
+		zap.String("node_id", nodeID),
+		zap.Int("count", len(crashes)))
+
+	return nil
+}
+
+// OnResourceAlert 接收资源告警并通知同步循环尽快上报
+func (ss *StateSyncer) OnResourceAlert(alert *ResourceAlert) {
+	if ss.spoolRecord(spoolKindResourceAlerts, alert.Timestamp, alert) {
+		return
+	}
+
+	ss.mu.Lock()
+	ss.pendingAlerts = append(ss.pendingAlerts, alert)
+	if len(ss.pendingAlerts) > maxPendingAlerts {
+		dropped := len(ss.pendingAlerts) - maxPendingAlerts
+		ss.pendingAlerts = ss.pendingAlerts[dropped:]
+		ss.logger.Warn("pending resource alerts overflow, dropping oldest",
+			zap.Int("dropped", dropped))
+	}
+	ss.mu.Unlock()
+
+	select {
+	case ss.eventNotify <- struct{}{}:
+	default:
+	}
+}
+
+// flushAlerts 向Manager上报所有待上报资源告警，失败时保留以便下次重试
+func (ss *StateSyncer) flushAlerts(nodeID string) error {
+	ss.mu.Lock()
+	alerts := ss.pendingAlerts
+	ss.pendingAlerts = nil
+	ss.mu.Unlock()
+
+	if len(alerts) == 0 {
+		return nil
+	}
+	if ss.managerClient == nil {
+		return fmt.Errorf("manager client not set")
+	}
+
+	ctx, cancel := context.WithTimeout(ss.ctx, 10*time.Second)
+	defer cancel()
+
+	if err := ss.managerClient.ReportResourceAlerts(ctx, nodeID, alerts); err != nil {
+		ss.mu.Lock()
+		ss.pendingAlerts = append(alerts, ss.pendingAlerts...)
+		if len(ss.pendingAlerts) > maxPendingAlerts {
+			ss.pendingAlerts = ss.pendingAlerts[len(ss.pendingAlerts)-maxPendingAlerts:]
+		}
+		ss.mu.Unlock()
+
+		ss.logger.Warn("failed to report resource alerts to manager",
+			zap.String("node_id", nodeID),
+			zap.Int("count", len(alerts)),
+			zap.Error(err))
+		return err
+	}
+
+	ss.logger.Info("reported resource alerts to manager",
+		zap.String("node_id", nodeID),
+		zap.Int("count", len(alerts)))
+
+	return nil
+}
+
 // collectAgentStates 收集所有Agent的状态
 func (ss *StateSyncer) collectAgentStates() []*AgentState {
 	ss.mu.RLock()
@@ -172,6 +429,9 @@ func (ss *StateSyncer) collectAgentStates() []*AgentState {
 		// 从metadata获取Version
 		// 使用超时避免阻塞状态同步循环
 		var version string
+		var customMetrics map[string]float64
+		var customFields map[string]string
+		var customUpdatedAt time.Time
 		// last_heartbeat 使用当前时间，代表"Daemon 最后一次报告该 Agent 状态的时间"
 		lastHeartbeat := time.Now()
 
@@ -190,6 +450,9 @@ func (ss *StateSyncer) collectAgentStates() []*AgentState {
 		case result := <-metadataChan:
 			if result.err == nil && result.metadata != nil {
 				version = result.metadata.Version
+				customMetrics = result.metadata.CustomMetrics
+				customFields = result.metadata.CustomFields
+				customUpdatedAt = result.metadata.CustomMetricsUpdatedAt
 				// 注意：不再从 metadata 获取 lastHeartbeat
 				// lastHeartbeat 应该是 Daemon 报告状态的时间，而不是 Agent 自己的心跳时间
 			}
@@ -214,6 +477,10 @@ func (ss *StateSyncer) collectAgentStates() []*AgentState {
 			LastHeartbeat: lastHeartbeat,
 			Type:          agentType,
 			Version:       version,
+
+			CustomMetrics:          customMetrics,
+			CustomFields:           customFields,
+			CustomMetricsUpdatedAt: customUpdatedAt,
 		}
 	}
 
@@ -268,7 +535,11 @@ func (ss *StateSyncer) syncToManager(states []*AgentState, nodeID string) error
 	ctx, cancel := context.WithTimeout(ss.ctx, 10*time.Second)
 	defer cancel()
 
-	err := ss.managerClient.SyncAgentStates(ctx, nodeID, states)
+	startTime := time.Now()
+	err := ss.managerClient.SyncAgentStates(ctx, nodeID, states, startTime)
+	if ss.perfMetrics != nil {
+		ss.perfMetrics.RecordStateSync(err == nil, time.Since(startTime))
+	}
 	if err != nil {
 		// 同步失败,保留在pendingStates中
 		ss.mu.Lock()
@@ -312,6 +583,11 @@ func (ss *StateSyncer) syncLoop(nodeID string) {
 		case <-ss.ctx.Done():
 			ss.logger.Info("state syncer loop stopped")
 			return
+		case <-ss.eventNotify:
+			// 事件优先上报，失败时等待下一个同步周期重试
+			_ = ss.flushCrashes(nodeID)
+			_ = ss.flushEvents(nodeID)
+			_ = ss.flushAlerts(nodeID)
 		case <-ticker.C:
 			// 收集所有Agent状态
 			states := ss.collectAgentStates()
@@ -321,11 +597,18 @@ func (ss *StateSyncer) syncLoop(nodeID string) {
 				ss.logger.Debug("no agent states to sync, but syncing to update node status")
 			}
 
-			// 同步到Manager
-			if err := ss.syncToManager(states, nodeID); err != nil {
-				ss.logger.Warn("sync failed, will retry on next interval",
-					zap.Error(err))
+			// 设置了本地缓存队列时写入队列，由队列按顺序补发；否则直接同步到Manager
+			if !ss.spoolStates(states) {
+				if err := ss.syncToManager(states, nodeID); err != nil {
+					ss.logger.Warn("sync failed, will retry on next interval",
+						zap.Error(err))
+				}
 			}
+
+			// 重试之前上报失败的崩溃记录、事件和资源告警
+			_ = ss.flushCrashes(nodeID)
+			_ = ss.flushEvents(nodeID)
+			_ = ss.flushAlerts(nodeID)
 		}
 	}
 }
@@ -337,6 +620,10 @@ func (ss *StateSyncer) Start(nodeID string) {
 		return
 	}
 
+	ss.mu.Lock()
+	ss.nodeID = nodeID
+	ss.mu.Unlock()
+
 	ss.wg.Add(1)
 	go ss.syncLoop(nodeID)
 
@@ -352,3 +639,145 @@ func (ss *StateSyncer) Stop() {
 	ss.wg.Wait()
 	ss.logger.Info("state syncer stopped")
 }
+
+// spoolRecord 将记录写入本地缓存队列，未设置队列或写入失败时返回false(调用方回退到内存缓存)
+func (ss *StateSyncer) spoolRecord(kind string, t time.Time, v interface{}) bool {
+	if ss.forwarder == nil {
+		return false
+	}
+	if t.IsZero() {
+		t = time.Now()
+	}
+	data, err := json.Marshal(v)
+	if err == nil {
+		err = ss.forwarder.Enqueue(kind, t, data)
+	}
+	if err != nil {
+		ss.logger.Warn("failed to spool record, falling back to in-memory buffer",
+			zap.String("kind", kind),
+			zap.Error(err))
+		return false
+	}
+	return true
+}
+
+// spoolStates 将状态快照写入本地缓存队列，成功后清空待同步状态
+func (ss *StateSyncer) spoolStates(states []*AgentState) bool {
+	if !ss.spoolRecord(spoolKindAgentStates, time.Now(), states) {
+		return false
+	}
+	ss.mu.Lock()
+	ss.pendingStates = make(map[string]*AgentState)
+	ss.mu.Unlock()
+	return true
+}
+
+// spoolNodeID 返回发送缓存记录使用的节点ID
+func (ss *StateSyncer) spoolNodeID() (string, error) {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+	if ss.managerClient == nil {
+		return "", fmt.Errorf("manager client not set")
+	}
+	if ss.nodeID == "" {
+		return "", fmt.Errorf("state syncer not started")
+	}
+	return ss.nodeID, nil
+}
+
+// sendSpooledStates 发送缓存的状态快照(逐条发送，携带快照采集时间)
+func (ss *StateSyncer) sendSpooledStates(ctx context.Context, records []spool.Record) error {
+	nodeID, err := ss.spoolNodeID()
+	if err != nil {
+		return err
+	}
+	decoded := 0
+	for _, rec := range records {
+		var states []*AgentState
+		if err := json.Unmarshal(rec.Data, &states); err != nil {
+			ss.logger.Warn("dropping undecodable spooled agent states", zap.Error(err))
+			continue
+		}
+		decoded++
+
+		startTime := time.Now()
+		err := ss.managerClient.SyncAgentStates(ctx, nodeID, states, rec.Time)
+		if ss.perfMetrics != nil {
+			ss.perfMetrics.RecordStateSync(err == nil, time.Since(startTime))
+		}
+		if err != nil {
+			return err
+		}
+		ss.logger.Debug("synced spooled agent states to manager",
+			zap.String("node_id", nodeID),
+			zap.Int("count", len(states)),
+			zap.Time("collected_at", rec.Time))
+	}
+	if decoded == 0 {
+		return fmt.Errorf("all %d spooled agent states are undecodable: %w", len(records), spool.ErrPermanent)
+	}
+	return nil
+}
+
+// sendSpooledEvents 发送缓存的Agent事件
+func (ss *StateSyncer) sendSpooledEvents(ctx context.Context, records []spool.Record) error {
+	nodeID, err := ss.spoolNodeID()
+	if err != nil {
+		return err
+	}
+	events := make([]*AgentEvent, 0, len(records))
+	for _, rec := range records {
+		var event AgentEvent
+		if err := json.Unmarshal(rec.Data, &event); err != nil {
+			ss.logger.Warn("dropping undecodable spooled agent event", zap.Error(err))
+			continue
+		}
+		events = append(events, &event)
+	}
+	if len(events) == 0 {
+		return fmt.Errorf("all %d spooled agent events are undecodable: %w", len(records), spool.ErrPermanent)
+	}
+	return ss.managerClient.ReportAgentEvents(ctx, nodeID, events)
+}
+
+// sendSpooledCrashes 发送缓存的崩溃记录
+func (ss *StateSyncer) sendSpooledCrashes(ctx context.Context, records []spool.Record) error {
+	nodeID, err := ss.spoolNodeID()
+	if err != nil {
+		return err
+	}
+	crashes := make([]*CrashRecord, 0, len(records))
+	for _, rec := range records {
+		var crash CrashRecord
+		if err := json.Unmarshal(rec.Data, &crash); err != nil {
+			ss.logger.Warn("dropping undecodable spooled crash record", zap.Error(err))
+			continue
+		}
+		crashes = append(crashes, &crash)
+	}
+	if len(crashes) == 0 {
+		return fmt.Errorf("all %d spooled crash records are undecodable: %w", len(records), spool.ErrPermanent)
+	}
+	return ss.managerClient.ReportCrashes(ctx, nodeID, crashes)
+}
+
+// sendSpooledAlerts 发送缓存的资源告警
+func (ss *StateSyncer) sendSpooledAlerts(ctx context.Context, records []spool.Record) error {
+	nodeID, err := ss.spoolNodeID()
+	if err != nil {
+		return err
+	}
+	alerts := make([]*ResourceAlert, 0, len(records))
+	for _, rec := range records {
+		var alert ResourceAlert
+		if err := json.Unmarshal(rec.Data, &alert); err != nil {
+			ss.logger.Warn("dropping undecodable spooled resource alert", zap.Error(err))
+			continue
+		}
+		alerts = append(alerts, &alert)
+	}
+	if len(alerts) == 0 {
+		return fmt.Errorf("all %d spooled resource alerts are undecodable: %w", len(records), spool.ErrPermanent)
+	}
+	return ss.managerClient.ReportResourceAlerts(ctx, nodeID, alerts)
+}
@@ -0,0 +1,127 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bingooyong/ops-scaffold-framework/daemon/internal/spool"
+	"go.uber.org/zap"
+)
+
+// fakeManagerClient 记录上报内容的ManagerClient，可模拟Manager不可达
+type fakeManagerClient struct {
+	mu          sync.Mutex
+	unreachable bool
+	events      []*AgentEvent
+	crashes     []*CrashRecord
+	syncTimes   []time.Time
+}
+
+func (c *fakeManagerClient) err() error {
+	if c.unreachable {
+		return errors.New("manager unreachable")
+	}
+	return nil
+}
+
+func (c *fakeManagerClient) SyncAgentStates(ctx context.Context, nodeID string, states []*AgentState, collectedAt time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.err(); err != nil {
+		return err
+	}
+	c.syncTimes = append(c.syncTimes, collectedAt)
+	return nil
+}
+
+func (c *fakeManagerClient) ReportAgentEvents(ctx context.Context, nodeID string, events []*AgentEvent) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.err(); err != nil {
+		return err
+	}
+	c.events = append(c.events, events...)
+	return nil
+}
+
+func (c *fakeManagerClient) ReportCrashes(ctx context.Context, nodeID string, crashes []*CrashRecord) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.err(); err != nil {
+		return err
+	}
+	c.crashes = append(c.crashes, crashes...)
+	return nil
+}
+
+func (c *fakeManagerClient) ReportResourceAlerts(ctx context.Context, nodeID string, alerts []*ResourceAlert) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err()
+}
+
+func TestStateSyncer_SpoolReplaysAfterOutage(t *testing.T) {
+	queue, err := spool.Open(t.TempDir(), spool.Options{}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("failed to open spool: %v", err)
+	}
+	defer queue.Close()
+	forwarder := spool.NewForwarder(queue, 20*time.Millisecond, zap.NewNop())
+
+	client := &fakeManagerClient{unreachable: true}
+	ss := NewStateSyncer(nil, nil, "manager:9090", zap.NewNop())
+	ss.SetManagerClient(client)
+	ss.SetSpool(forwarder)
+	ss.nodeID = "node-1"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go forwarder.Run(ctx)
+
+	// Manager不可达期间产生的事件、快照和崩溃记录写入队列
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+	ss.OnAgentEvent(&AgentEvent{AgentID: "agent-1", Type: EventCircuitOpen, Timestamp: start})
+	if !ss.spoolStates([]*AgentState{{AgentID: "agent-1", Status: StatusFailed}}) {
+		t.Fatal("expected states to be spooled")
+	}
+	ss.OnAgentCrash(&CrashRecord{AgentID: "agent-1", ExitCode: 2, ExitedAt: start.Add(time.Minute)})
+	ss.OnAgentEvent(&AgentEvent{AgentID: "agent-1", Type: EventCircuitReset, Timestamp: start.Add(2 * time.Minute)})
+
+	if len(ss.pendingEvents) != 0 || len(ss.pendingCrashes) != 0 {
+		t.Fatal("spooled records should not be kept in memory")
+	}
+	if stats := forwarder.Stats(); stats.Queue.Records != 4 {
+		t.Fatalf("expected 4 spooled records, got %d", stats.Queue.Records)
+	}
+
+	client.mu.Lock()
+	client.unreachable = false
+	client.mu.Unlock()
+	forwarder.Wake()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for forwarder.Stats().Queue.Records != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for spool to drain")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if len(client.events) != 2 || client.events[0].Type != EventCircuitOpen || client.events[1].Type != EventCircuitReset {
+		t.Fatalf("unexpected events: %+v", client.events)
+	}
+	if !client.events[0].Timestamp.Equal(start) {
+		t.Fatalf("event lost its original timestamp: %v", client.events[0].Timestamp)
+	}
+	if len(client.crashes) != 1 || client.crashes[0].ExitCode != 2 {
+		t.Fatalf("unexpected crashes: %+v", client.crashes)
+	}
+	if len(client.syncTimes) != 1 || time.Since(client.syncTimes[0]) > time.Minute {
+		t.Fatalf("state snapshot should carry its collection time, got %v", client.syncTimes)
+	}
+}
@@ -2,6 +2,7 @@ package collector
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/bingooyong/ops-scaffold-framework/daemon/pkg/types"
@@ -48,6 +49,11 @@ func (c *CPUCollector) Collect(ctx context.Context) (*types.Metrics, error) {
 		c.logger.Error("failed to get cpu percent", zap.Error(err))
 		return nil, err
 	}
+	// 每个核的使用率（不额外采样，取距上次采集期间的平均值）
+	perCPU, err := cpu.PercentWithContext(ctx, 0, true)
+	if err != nil {
+		c.logger.Debug("failed to get per-cpu percent", zap.Error(err))
+	}
 
 	// 获取CPU信息
 	info, err := cpu.InfoWithContext(ctx)
@@ -95,10 +101,20 @@ func (c *CPUCollector) Collect(ctx context.Context) (*types.Metrics, error) {
 		values["iowait"] = times[0].Iowait
 	}
 
+	// 每个核的使用率，以 cpu 标签区分
+	series := make([]types.LabeledValues, 0, len(perCPU))
+	for i, p := range perCPU {
+		series = append(series, types.LabeledValues{
+			Labels: map[string]string{"cpu": fmt.Sprintf("cpu%d", i)},
+			Values: map[string]interface{}{"usage_percent": p},
+		})
+	}
+
 	metrics := &types.Metrics{
 		Name:      c.Name(),
 		Timestamp: time.Now(),
 		Values:    values,
+		Series:    series,
 	}
 
 	return metrics, nil
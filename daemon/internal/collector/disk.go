@@ -59,8 +59,8 @@ func (c *DiskCollector) Collect(ctx context.Context) (*types.Metrics, error) {
 	var totalReadBytes uint64
 	var totalWriteBytes uint64
 
-	// 明细数据（保留完整信息供未来扩展）
-	diskDetails := make([]map[string]interface{}, 0)
+	// 每个分区的明细，以 device/mountpoint/fstype 标签区分
+	series := make([]types.LabeledValues, 0)
 
 	// 用于去重的 map，key 为设备基础名称（去除分区号），value 为已处理的最大总空间
 	// 在 macOS 上，同一个物理磁盘可能有多个虚拟挂载点，需要去重
@@ -163,18 +163,21 @@ func (c *DiskCollector) Collect(ctx context.Context) (*types.Metrics, error) {
 		}
 
 		// 保存明细数据
-		diskInfo := map[string]interface{}{
-			"mountpoint":   partition.Mountpoint,
-			"device":       partition.Device,
-			"fstype":       partition.Fstype,
-			"total":        usage.Total,
-			"used":         usage.Used,
-			"free":         usage.Free,
-			"used_percent": usage.UsedPercent,
-			"read_bytes":   readBytes,
-			"write_bytes":  writeBytes,
-		}
-		diskDetails = append(diskDetails, diskInfo)
+		series = append(series, types.LabeledValues{
+			Labels: map[string]string{
+				"device":     partition.Device,
+				"mountpoint": partition.Mountpoint,
+				"fstype":     partition.Fstype,
+			},
+			Values: map[string]interface{}{
+				"total_bytes":   usage.Total,
+				"used_bytes":    usage.Used,
+				"free_bytes":    usage.Free,
+				"usage_percent": usage.UsedPercent,
+				"read_bytes":    readBytes,
+				"write_bytes":   writeBytes,
+			},
+		})
 	}
 
 	// 计算使用率
@@ -183,7 +186,7 @@ func (c *DiskCollector) Collect(ctx context.Context) (*types.Metrics, error) {
 		usagePercent = float64(usedBytes) / float64(totalBytes) * 100
 	}
 
-	// 返回数据：同时包含汇总数据（扁平化）和明细数据（数组）
+	// 返回数据：汇总数据（扁平化）和带标签的分区明细
 	metrics := &types.Metrics{
 		Name:      c.Name(),
 		Timestamp: time.Now(),
@@ -195,14 +198,13 @@ func (c *DiskCollector) Collect(ctx context.Context) (*types.Metrics, error) {
 			"usage_percent":   usagePercent,
 			"read_bytes":      totalReadBytes,
 			"write_bytes":     totalWriteBytes,
-			"partition_count": len(diskDetails),
-			// 明细数据（数组，供未来扩展使用）
-			"details": diskDetails,
+			"partition_count": len(series),
 		},
+		Series: series,
 	}
 
 	c.logger.Debug("disk metrics collected",
-		zap.Int("partitions", len(diskDetails)),
+		zap.Int("partitions", len(series)),
 		zap.Float64("usage_percent", usagePercent),
 	)
 
@@ -67,8 +67,8 @@ func (c *NetworkCollector) Collect(ctx context.Context) (*types.Metrics, error)
 	var totalDropIn uint64
 	var totalDropOut uint64
 
-	// 明细数据（保留完整信息供未来扩展）
-	interfaceDetails := make([]map[string]interface{}, 0)
+	// 每个网卡的明细，以 interface 标签区分
+	series := make([]types.LabeledValues, 0)
 
 	for _, io := range ioCounters {
 		// 如果指定了网卡，则只采集指定的
@@ -96,18 +96,19 @@ func (c *NetworkCollector) Collect(ctx context.Context) (*types.Metrics, error)
 		totalDropOut += io.Dropout
 
 		// 保存明细数据
-		interfaceInfo := map[string]interface{}{
-			"interface":    io.Name,
-			"bytes_sent":   io.BytesSent,
-			"bytes_recv":   io.BytesRecv,
-			"packets_sent": io.PacketsSent,
-			"packets_recv": io.PacketsRecv,
-			"error_in":     io.Errin,
-			"error_out":    io.Errout,
-			"drop_in":      io.Dropin,
-			"drop_out":     io.Dropout,
-		}
-		interfaceDetails = append(interfaceDetails, interfaceInfo)
+		series = append(series, types.LabeledValues{
+			Labels: map[string]string{"interface": io.Name},
+			Values: map[string]interface{}{
+				"bytes_sent":   io.BytesSent,
+				"bytes_recv":   io.BytesRecv,
+				"packets_sent": io.PacketsSent,
+				"packets_recv": io.PacketsRecv,
+				"error_in":     io.Errin,
+				"error_out":    io.Errout,
+				"drop_in":      io.Dropin,
+				"drop_out":     io.Dropout,
+			},
+		})
 	}
 
 	// 计算本次采集的流量差值（相对于上一次采集）
@@ -154,7 +155,7 @@ func (c *NetworkCollector) Collect(ctx context.Context) (*types.Metrics, error)
 	c.lastTime = now
 	c.mu.Unlock()
 
-	// 返回数据：同时包含汇总数据（扁平化）和明细数据（数组）
+	// 返回数据：汇总数据（扁平化）和带标签的网卡明细
 	// 注意：rx_bytes 和 tx_bytes 现在表示本次采集期间的流量差值，而不是累计值
 	metrics := &types.Metrics{
 		Name:      c.Name(),
@@ -172,16 +173,15 @@ func (c *NetworkCollector) Collect(ctx context.Context) (*types.Metrics, error)
 			"error_out":       totalErrOut,
 			"drop_in":         totalDropIn,
 			"drop_out":        totalDropOut,
-			"interface_count": len(interfaceDetails),
+			"interface_count": len(series),
 			// 采集间隔（秒），用于计算速率
 			"interval_seconds": timeDelta.Seconds(),
-			// 明细数据（数组，供未来扩展使用）
-			"details": interfaceDetails,
 		},
+		Series: series,
 	}
 
 	c.logger.Debug("network metrics collected",
-		zap.Int("interfaces", len(interfaceDetails)),
+		zap.Int("interfaces", len(series)),
 		zap.Uint64("delta_tx_bytes", deltaTxBytes),
 		zap.Uint64("delta_rx_bytes", deltaRxBytes),
 		zap.Duration("time_delta", timeDelta),
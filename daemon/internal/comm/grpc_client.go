@@ -9,16 +9,21 @@ import (
 	"crypto/x509"
 	"fmt"
 	"os"
+	"sync/atomic"
 	"time"
 
 	"github.com/bingooyong/ops-scaffold-framework/daemon/internal/config"
+	"github.com/bingooyong/ops-scaffold-framework/daemon/internal/spool"
 	managerpb "github.com/bingooyong/ops-scaffold-framework/daemon/pkg/proto/manager"
 	"github.com/bingooyong/ops-scaffold-framework/daemon/pkg/types"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/encoding/gzip"
 	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
 )
 
 // GRPCClient gRPC客户端
@@ -33,6 +38,17 @@ type GRPCClient struct {
 
 	// 为了支持后台 loop 与自动重连
 	reconnectInterval time.Duration
+
+	// creds 动态mTLS凭证(证书申请/续期后无需重建客户端)，未设置时使用TLS配置中的证书文件
+	creds credentials.TransportCredentials
+
+	// fingerprint 注册时上报的主机指纹，心跳时一并上报
+	fingerprint string
+	// approval Manager返回的注册审批状态
+	approval string
+
+	// compressionUnsupported Manager未注册gzip解压器，指标上报不再压缩
+	compressionUnsupported atomic.Bool
 }
 
 // NewGRPCClient 创建gRPC客户端
@@ -44,6 +60,11 @@ func NewGRPCClient(cfg *config.ManagerConfig, logger *zap.Logger) *GRPCClient {
 	}
 }
 
+// SetCredentials 设置连接Manager使用的传输凭证
+func (c *GRPCClient) SetCredentials(creds credentials.TransportCredentials) {
+	c.creds = creds
+}
+
 // Connect 连接到Manager(建立或重新建立连接)
 func (c *GRPCClient) Connect(ctx context.Context) error {
 	// 如果已有连接且健康, 直接返回
@@ -61,7 +82,9 @@ func (c *GRPCClient) Connect(ctx context.Context) error {
 	// 加载TLS证书
 	var opts []grpc.DialOption
 
-	if c.config.TLS.CertFile != "" && c.config.TLS.KeyFile != "" && c.config.TLS.CAFile != "" {
+	if c.creds != nil {
+		opts = append(opts, grpc.WithTransportCredentials(c.creds))
+	} else if c.config.TLS.CertFile != "" && c.config.TLS.KeyFile != "" && c.config.TLS.CAFile != "" {
 		cert, err := tls.LoadX509KeyPair(c.config.TLS.CertFile, c.config.TLS.KeyFile)
 		if err != nil {
 			return fmt.Errorf("failed to load client cert: %w", err)
@@ -139,6 +162,7 @@ func (c *GRPCClient) Register(ctx context.Context, nodeID string, info *types.No
 		Labels:        info.Labels,
 		DaemonVersion: info.DaemonVer,
 		AgentVersion:  info.AgentVer,
+		Fingerprint:   info.Fingerprint,
 	}
 
 	// 调用gRPC服务
@@ -149,12 +173,16 @@ func (c *GRPCClient) Register(ctx context.Context, nodeID string, info *types.No
 	}
 
 	if !resp.Success {
-		c.logger.Error("node registration failed", zap.String("message", resp.Message))
+		c.logger.Error("node registration failed",
+			zap.String("message", resp.Message),
+			zap.String("status", resp.Status))
 		return "", fmt.Errorf("registration failed: %s", resp.Message)
 	}
 
 	c.nodeID = nodeID
-	c.logger.Info("node registered successfully", zap.String("node_id", c.nodeID))
+	c.fingerprint = info.Fingerprint
+	c.setApproval(resp.Status)
+	c.logger.Info("node registered successfully", zap.String("node_id", c.nodeID), zap.String("status", resp.Status))
 
 	return c.nodeID, nil
 }
@@ -173,8 +201,9 @@ func (c *GRPCClient) Heartbeat(ctx context.Context) error {
 
 	// 构建心跳请求
 	req := &managerpb.HeartbeatRequest{
-		NodeId:    c.nodeID,
-		Timestamp: time.Now().Unix(),
+		NodeId:      c.nodeID,
+		Timestamp:   time.Now().Unix(),
+		Fingerprint: c.fingerprint,
 	}
 
 	// 调用gRPC服务
@@ -185,26 +214,59 @@ func (c *GRPCClient) Heartbeat(ctx context.Context) error {
 	}
 
 	if !resp.Success {
-		c.logger.Warn("heartbeat failed", zap.String("message", resp.Message))
+		c.logger.Warn("heartbeat failed", zap.String("message", resp.Message), zap.String("status", resp.Status))
 		return fmt.Errorf("heartbeat failed: %s", resp.Message)
 	}
+	c.setApproval(resp.Status)
 
 	return nil
 }
 
-// ReportMetrics 上报指标
-func (c *GRPCClient) ReportMetrics(ctx context.Context, metrics map[string]*types.Metrics) error {
-	if c.nodeID == "" {
-		return fmt.Errorf("node not registered")
+// setApproval 记录Manager返回的审批状态，状态变化时输出日志
+// pending节点可以心跳和上报指标，但不会收到任务、配置和更新
+func (c *GRPCClient) setApproval(approval string) {
+	if approval == "" || approval == c.approval {
+		return
 	}
+	c.approval = approval
+	if approval == "pending" {
+		c.logger.Warn("node is pending approval on manager, tasks and updates are withheld until an admin approves it",
+			zap.String("node_id", c.nodeID))
+		return
+	}
+	c.logger.Info("node approval status changed", zap.String("node_id", c.nodeID), zap.String("status", approval))
+}
 
+// RenewCertificate 使用当前客户端证书续期，返回新证书和CA证书(PEM)
+func (c *GRPCClient) RenewCertificate(ctx context.Context, csrPEM []byte) ([]byte, []byte, error) {
 	if c.client == nil || c.conn == nil {
-		return fmt.Errorf("gRPC client not connected")
+		return nil, nil, fmt.Errorf("gRPC client not connected")
 	}
 
-	c.logger.Debug("reporting metrics",
-		zap.String("node_id", c.nodeID),
-		zap.Int("count", len(metrics)))
+	resp, err := c.client.RenewCertificate(ctx, &managerpb.RenewCertificateRequest{
+		NodeId: c.nodeID,
+		Csr:    csrPEM,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("gRPC call failed: %w", err)
+	}
+	return resp.Certificate, resp.CaCertificate, nil
+}
+
+// ReportMetrics 上报指标
+func (c *GRPCClient) ReportMetrics(ctx context.Context, metrics map[string]*types.Metrics) error {
+	return c.SendMetricsReport(ctx, c.BuildMetricsReport(metrics))
+}
+
+// BuildMetricsReport 将采集的指标转换为上报请求(节点ID在发送时填充)
+// 用于先写入本地缓存队列，Manager可达时再发送
+// 默认使用带标签的批量格式，配置 legacy_metrics 时使用旧的扁平化格式
+func (c *GRPCClient) BuildMetricsReport(metrics map[string]*types.Metrics) *managerpb.ReportMetricsRequest {
+	if !c.config.LegacyMetrics {
+		return &managerpb.ReportMetricsRequest{
+			Batch: NewMetricsBatch(metrics, c.logger),
+		}
+	}
 
 	// 转换指标数据
 	var metricData []*managerpb.MetricData
@@ -243,14 +305,30 @@ func (c *GRPCClient) ReportMetrics(ctx context.Context, metrics map[string]*type
 		})
 	}
 
-	// 构建上报请求
-	req := &managerpb.ReportMetricsRequest{
-		NodeId:  c.nodeID,
+	return &managerpb.ReportMetricsRequest{
 		Metrics: metricData,
 	}
+}
+
+// SendMetricsReport 发送指标上报请求
+func (c *GRPCClient) SendMetricsReport(ctx context.Context, req *managerpb.ReportMetricsRequest) error {
+	if c.nodeID == "" {
+		return fmt.Errorf("node not registered")
+	}
+
+	if c.client == nil || c.conn == nil {
+		return fmt.Errorf("gRPC client not connected")
+	}
+
+	c.logger.Debug("reporting metrics",
+		zap.String("node_id", c.nodeID),
+		zap.Int("count", len(req.Metrics)),
+		zap.Int("series", len(req.GetBatch().GetSeries())))
+
+	req.NodeId = c.nodeID
 
 	// 调用gRPC服务
-	resp, err := c.client.ReportMetrics(ctx, req)
+	resp, err := c.reportMetrics(ctx, req)
 	if err != nil {
 		c.logger.Error("failed to report metrics", zap.Error(err))
 		return fmt.Errorf("gRPC call failed: %w", err)
@@ -258,12 +336,29 @@ func (c *GRPCClient) ReportMetrics(ctx context.Context, metrics map[string]*type
 
 	if !resp.Success {
 		c.logger.Warn("metrics report failed", zap.String("message", resp.Message))
-		return fmt.Errorf("metrics report failed: %s", resp.Message)
+		return fmt.Errorf("metrics report failed: %s: %w", resp.Message, spool.ErrRejected)
 	}
 
 	return nil
 }
 
+// reportMetrics 调用ReportMetrics，只对指标上报启用压缩
+// 尚未升级的Manager没有注册gzip解压器，对压缩请求返回Unimplemented，此时改为不压缩重发且之后不再压缩
+func (c *GRPCClient) reportMetrics(ctx context.Context, req *managerpb.ReportMetricsRequest) (*managerpb.ReportMetricsResponse, error) {
+	if c.config.Compression != config.ManagerCompressionGzip || c.compressionUnsupported.Load() {
+		return c.client.ReportMetrics(ctx, req)
+	}
+
+	resp, err := c.client.ReportMetrics(ctx, req, grpc.UseCompressor(gzip.Name))
+	if status.Code(err) != codes.Unimplemented {
+		return resp, err
+	}
+
+	c.logger.Warn("manager does not accept gzip compressed metrics, falling back to uncompressed", zap.Error(err))
+	c.compressionUnsupported.Store(true)
+	return c.client.ReportMetrics(ctx, req)
+}
+
 // GetNodeID 获取节点ID
 func (c *GRPCClient) GetNodeID() string {
 	return c.nodeID
@@ -0,0 +1,90 @@
+//go:build !e2e && !grpc_test
+// +build !e2e,!grpc_test
+
+package comm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bingooyong/ops-scaffold-framework/daemon/internal/config"
+	managerpb "github.com/bingooyong/ops-scaffold-framework/daemon/pkg/proto/manager"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/status"
+)
+
+// fakeManagerClient 记录ReportMetrics调用使用的压缩算法
+// supportsGzip为false时模拟未注册gzip解压器的Manager
+type fakeManagerClient struct {
+	managerpb.ManagerServiceClient
+	supportsGzip bool
+	compressors  []string
+}
+
+func (f *fakeManagerClient) ReportMetrics(ctx context.Context, req *managerpb.ReportMetricsRequest, opts ...grpc.CallOption) (*managerpb.ReportMetricsResponse, error) {
+	compressor := ""
+	for _, opt := range opts {
+		if c, ok := opt.(grpc.CompressorCallOption); ok {
+			compressor = c.CompressorType
+		}
+	}
+	f.compressors = append(f.compressors, compressor)
+	if compressor != "" && !f.supportsGzip {
+		return nil, status.Errorf(codes.Unimplemented, "grpc: Decompressor is not installed for grpc-encoding %q", compressor)
+	}
+	return &managerpb.ReportMetricsResponse{Success: true}, nil
+}
+
+func newFakeGRPCClient(t *testing.T, compression string, fake *fakeManagerClient) *GRPCClient {
+	t.Helper()
+	conn, err := grpc.NewClient("passthrough:///manager", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to create client conn: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	c := NewGRPCClient(&config.ManagerConfig{Compression: compression}, zap.NewNop())
+	c.conn = conn
+	c.client = fake
+	c.nodeID = "node-1"
+	return c
+}
+
+func TestSendMetricsReport_Compression(t *testing.T) {
+	tests := []struct {
+		name         string
+		compression  string
+		supportsGzip bool
+		expected     []string
+	}{
+		{"gzip", config.ManagerCompressionGzip, true, []string{gzip.Name, gzip.Name}},
+		{"none", config.ManagerCompressionNone, false, []string{"", ""}},
+		// 第一次压缩请求被拒绝后不压缩重发，之后不再压缩
+		{"fallback", config.ManagerCompressionGzip, false, []string{gzip.Name, "", ""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeManagerClient{supportsGzip: tt.supportsGzip}
+			c := newFakeGRPCClient(t, tt.compression, fake)
+
+			for i := 0; i < 2; i++ {
+				if err := c.SendMetricsReport(context.Background(), &managerpb.ReportMetricsRequest{}); err != nil {
+					t.Fatalf("report %d failed: %v", i, err)
+				}
+			}
+			if len(fake.compressors) != len(tt.expected) {
+				t.Fatalf("unexpected calls: %q, want %q", fake.compressors, tt.expected)
+			}
+			for i := range tt.expected {
+				if fake.compressors[i] != tt.expected[i] {
+					t.Fatalf("unexpected compressors: %q, want %q", fake.compressors, tt.expected)
+				}
+			}
+		})
+	}
+}
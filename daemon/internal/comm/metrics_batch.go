@@ -0,0 +1,144 @@
+package comm
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	managerpb "github.com/bingooyong/ops-scaffold-framework/daemon/pkg/proto/manager"
+	"github.com/bingooyong/ops-scaffold-framework/daemon/pkg/types"
+	"go.uber.org/zap"
+)
+
+// MetricsBatchVersion 当前指标批量格式版本
+const MetricsBatchVersion = 1
+
+// counterMetrics 各类指标中单调递增的累计值，其余按瞬时值上报
+var counterMetrics = map[string]map[string]bool{
+	"cpu":  {"user": true, "system": true, "idle": true, "iowait": true},
+	"disk": {"read_bytes": true, "write_bytes": true},
+	"network": {
+		"bytes_sent": true, "bytes_recv": true,
+		"packets_sent": true, "packets_recv": true,
+		"error_in": true, "error_out": true,
+		"drop_in": true, "drop_out": true,
+	},
+}
+
+// NewMetricsBatch 将采集的指标转换为带标签、带类型的批量格式
+// 每个值对应一条名为 "<类型>.<键>" 的序列，整数保留为整数，非数值(如CPU型号)跳过
+func NewMetricsBatch(metrics map[string]*types.Metrics, logger *zap.Logger) *managerpb.MetricsBatch {
+	batch := &managerpb.MetricsBatch{Version: MetricsBatchVersion}
+
+	names := make([]string, 0, len(metrics))
+	for name := range metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		m := metrics[name]
+		if m == nil {
+			continue
+		}
+		ts := m.Timestamp.UnixMilli()
+		batch.Series = append(batch.Series, newSeries(name, nil, m.Values, ts, logger)...)
+		for _, lv := range m.Series {
+			batch.Series = append(batch.Series, newSeries(name, lv.Labels, lv.Values, ts, logger)...)
+		}
+	}
+
+	return batch
+}
+
+// newSeries 将一组共享标签的值转换为序列，按键排序保证输出稳定
+func newSeries(metricType string, labels map[string]string, values map[string]interface{}, ts int64, logger *zap.Logger) []*managerpb.MetricSeries {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	series := make([]*managerpb.MetricSeries, 0, len(keys))
+	for _, k := range keys {
+		point := newMetricPoint(values[k], ts)
+		if point == nil {
+			logger.Debug("skipping non-numeric metric value",
+				zap.String("metric", metricType),
+				zap.String("key", k))
+			continue
+		}
+
+		valueType := managerpb.MetricValueType_METRIC_VALUE_TYPE_GAUGE
+		if counterMetrics[metricType][k] {
+			valueType = managerpb.MetricValueType_METRIC_VALUE_TYPE_COUNTER
+		}
+
+		series = append(series, &managerpb.MetricSeries{
+			Name:   metricType + "." + k,
+			Labels: labels,
+			Type:   valueType,
+			Points: []*managerpb.MetricPoint{point},
+		})
+	}
+	return series
+}
+
+// newMetricPoint 按值的实际类型创建采样点，不支持的类型返回nil
+func newMetricPoint(v interface{}, ts int64) *managerpb.MetricPoint {
+	point := &managerpb.MetricPoint{TimestampMs: ts}
+	switch val := v.(type) {
+	case float64:
+		point.Value = &managerpb.MetricPoint_DoubleValue{DoubleValue: val}
+	case float32:
+		point.Value = &managerpb.MetricPoint_DoubleValue{DoubleValue: float64(val)}
+	case int:
+		point.Value = &managerpb.MetricPoint_IntValue{IntValue: int64(val)}
+	case int32:
+		point.Value = &managerpb.MetricPoint_IntValue{IntValue: int64(val)}
+	case int64:
+		point.Value = &managerpb.MetricPoint_IntValue{IntValue: val}
+	case uint32:
+		point.Value = &managerpb.MetricPoint_IntValue{IntValue: int64(val)}
+	case uint64:
+		if val > math.MaxInt64 {
+			point.Value = &managerpb.MetricPoint_DoubleValue{DoubleValue: float64(val)}
+		} else {
+			point.Value = &managerpb.MetricPoint_IntValue{IntValue: int64(val)}
+		}
+	default:
+		return nil
+	}
+	return point
+}
+
+// MergeMetricsBatch 将src合并到dst: 指标名、标签和类型相同的序列合并采样点
+// 用于将多次采集(如本地缓存补发)合并为一次上报
+func MergeMetricsBatch(dst, src *managerpb.MetricsBatch) {
+	if src == nil {
+		return
+	}
+	index := make(map[string]*managerpb.MetricSeries, len(dst.Series))
+	for _, s := range dst.Series {
+		index[seriesKey(s)] = s
+	}
+	for _, s := range src.Series {
+		key := seriesKey(s)
+		if existing, ok := index[key]; ok {
+			existing.Points = append(existing.Points, s.Points...)
+			continue
+		}
+		index[key] = s
+		dst.Series = append(dst.Series, s)
+	}
+}
+
+// seriesKey 序列的唯一标识: 名称、类型和排序后的标签
+func seriesKey(s *managerpb.MetricSeries) string {
+	labels := make([]string, 0, len(s.Labels))
+	for k, v := range s.Labels {
+		labels = append(labels, k+"="+v)
+	}
+	sort.Strings(labels)
+	return s.Name + "|" + s.Type.String() + "|" + strings.Join(labels, ",")
+}
@@ -0,0 +1,110 @@
+package comm
+
+import (
+	"testing"
+	"time"
+
+	managerpb "github.com/bingooyong/ops-scaffold-framework/daemon/pkg/proto/manager"
+	"github.com/bingooyong/ops-scaffold-framework/daemon/pkg/types"
+	"go.uber.org/zap"
+)
+
+func findSeries(batch *managerpb.MetricsBatch, name string, labels map[string]string) *managerpb.MetricSeries {
+	for _, s := range batch.Series {
+		if s.Name != name || len(s.Labels) != len(labels) {
+			continue
+		}
+		match := true
+		for k, v := range labels {
+			if s.Labels[k] != v {
+				match = false
+			}
+		}
+		if match {
+			return s
+		}
+	}
+	return nil
+}
+
+func TestNewMetricsBatch(t *testing.T) {
+	now := time.Now()
+	batch := NewMetricsBatch(map[string]*types.Metrics{
+		"cpu": {
+			Name:      "cpu",
+			Timestamp: now,
+			Values:    map[string]interface{}{"usage_percent": 12.5, "cores": 8, "model": "test", "user": 100.5},
+			Series: []types.LabeledValues{
+				{Labels: map[string]string{"cpu": "cpu0"}, Values: map[string]interface{}{"usage_percent": 50.0}},
+			},
+		},
+		"network": {
+			Name:      "network",
+			Timestamp: now,
+			Series: []types.LabeledValues{
+				{Labels: map[string]string{"interface": "eth0"}, Values: map[string]interface{}{"bytes_sent": uint64(1 << 40)}},
+			},
+		},
+	}, zap.NewNop())
+
+	if batch.Version != MetricsBatchVersion {
+		t.Fatalf("unexpected version: %d", batch.Version)
+	}
+	if len(batch.Series) != 5 {
+		t.Fatalf("expected 5 series (non-numeric values skipped), got %d", len(batch.Series))
+	}
+
+	usage := findSeries(batch, "cpu.usage_percent", nil)
+	if usage == nil || usage.Type != managerpb.MetricValueType_METRIC_VALUE_TYPE_GAUGE {
+		t.Fatalf("missing aggregate gauge series: %v", usage)
+	}
+	if len(usage.Points) != 1 || usage.Points[0].GetDoubleValue() != 12.5 || usage.Points[0].TimestampMs != now.UnixMilli() {
+		t.Fatalf("unexpected points: %v", usage.Points)
+	}
+	if cores := findSeries(batch, "cpu.cores", nil); cores == nil || cores.Points[0].GetIntValue() != 8 {
+		t.Fatalf("integer values should be sent as int points: %v", cores)
+	}
+	if user := findSeries(batch, "cpu.user", nil); user == nil || user.Type != managerpb.MetricValueType_METRIC_VALUE_TYPE_COUNTER {
+		t.Fatalf("cumulative cpu time should be a counter: %v", user)
+	}
+	if core := findSeries(batch, "cpu.usage_percent", map[string]string{"cpu": "cpu0"}); core == nil || core.Points[0].GetDoubleValue() != 50 {
+		t.Fatalf("missing per-cpu series: %v", core)
+	}
+	sent := findSeries(batch, "network.bytes_sent", map[string]string{"interface": "eth0"})
+	if sent == nil || sent.Type != managerpb.MetricValueType_METRIC_VALUE_TYPE_COUNTER || sent.Points[0].GetIntValue() != 1<<40 {
+		t.Fatalf("unexpected per-interface series: %v", sent)
+	}
+}
+
+func TestMergeMetricsBatch(t *testing.T) {
+	t1 := time.Now().Add(-time.Minute)
+	t2 := t1.Add(30 * time.Second)
+	collect := func(ts time.Time, usage float64) *managerpb.MetricsBatch {
+		return NewMetricsBatch(map[string]*types.Metrics{
+			"disk": {
+				Name:      "disk",
+				Timestamp: ts,
+				Values:    map[string]interface{}{"usage_percent": usage},
+				Series: []types.LabeledValues{
+					{Labels: map[string]string{"device": "sda1", "mountpoint": "/"}, Values: map[string]interface{}{"usage_percent": usage}},
+					{Labels: map[string]string{"device": "sdb1", "mountpoint": "/data"}, Values: map[string]interface{}{"usage_percent": usage}},
+				},
+			},
+		}, zap.NewNop())
+	}
+
+	merged := collect(t1, 10)
+	MergeMetricsBatch(merged, collect(t2, 20))
+
+	if len(merged.Series) != 3 {
+		t.Fatalf("series with the same name and labels should be merged, got %d", len(merged.Series))
+	}
+	for _, s := range merged.Series {
+		if len(s.Points) != 2 {
+			t.Fatalf("series %s %v should carry both timestamps, got %d points", s.Name, s.Labels, len(s.Points))
+		}
+		if s.Points[0].TimestampMs != t1.UnixMilli() || s.Points[1].TimestampMs != t2.UnixMilli() {
+			t.Fatalf("points out of order: %v", s.Points)
+		}
+	}
+}
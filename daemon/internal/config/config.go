@@ -3,7 +3,10 @@ package config
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/spf13/viper"
@@ -18,6 +21,13 @@ type Config struct {
 	AgentDefaults AgentDefaultsConfig `mapstructure:"agent_defaults"` // 全局默认配置
 	Collectors    CollectorConfigs    `mapstructure:"collectors"`
 	Update        UpdateConfig        `mapstructure:"update"`
+	Cgroup        CgroupConfig        `mapstructure:"cgroup"`     // cgroup v2资源限制
+	AgentLogs     AgentLogsConfig     `mapstructure:"agent_logs"` // Agent日志保留与磁盘配额
+
+	ResourceHistory ResourceHistoryConfig `mapstructure:"resource_history"` // Agent资源历史持久化
+	HeartbeatAuth   HeartbeatAuthConfig   `mapstructure:"heartbeat_auth"`   // Agent心跳签名认证
+	GRPCAuth        GRPCAuthConfig        `mapstructure:"grpc_auth"`        // Daemon gRPC服务认证与授权
+	Spool           SpoolConfig           `mapstructure:"spool"`            // Manager不可达时的本地缓存队列
 }
 
 // DaemonConfig Daemon基础配置
@@ -28,10 +38,15 @@ type DaemonConfig struct {
 	PIDFile      string `mapstructure:"pid_file"`
 	WorkDir      string `mapstructure:"work_dir"`
 	GRPCPort     int    `mapstructure:"grpc_port"`     // gRPC服务器端口，默认9091
-	HTTPPort     int    `mapstructure:"http_port"`     // HTTP服务器端口（用于接收Agent心跳），默认8084
+	HTTPPort     int    `mapstructure:"http_port"`     // HTTP服务器端口（用于接收Agent心跳和Prometheus /metrics），默认8084
 	PprofPort    string `mapstructure:"pprof_port"`    // pprof性能分析端口，默认不启用（空字符串或0）
 	PprofAddress string `mapstructure:"pprof_address"` // pprof监听地址，默认127.0.0.1
 	MaxProcs     int    `mapstructure:"max_procs"`     // GOMAXPROCS，0表示使用默认值（所有CPU核心）
+	// KeepAgentsRunning daemon退出时是否保留Agent进程继续运行，默认false(停止所有Agent)
+	// 保留运行的Agent会在daemon重启后被重新接管
+	KeepAgentsRunning bool `mapstructure:"keep_agents_running"`
+	// AgentStartStagger 批量启动Agent时相邻两次启动的最小间隔，默认0(不错开)
+	AgentStartStagger time.Duration `mapstructure:"agent_start_stagger"`
 }
 
 // ManagerConfig Manager连接配置
@@ -41,6 +56,28 @@ type ManagerConfig struct {
 	HeartbeatInterval time.Duration `mapstructure:"heartbeat_interval"`
 	ReconnectInterval time.Duration `mapstructure:"reconnect_interval"`
 	Timeout           time.Duration `mapstructure:"timeout"`
+	// ControlStream 主动向Manager建立控制流，Manager通过该流下发调用(用于NAT或只允许出站连接的节点)
+	ControlStream bool `mapstructure:"control_stream"`
+	// Enroll 凭加入令牌向Manager内置CA申请客户端证书
+	Enroll EnrollConfig `mapstructure:"enroll"`
+	// Compression 指标上报的压缩算法: gzip(默认) 或 none，其他调用不压缩
+	Compression string `mapstructure:"compression"`
+	// LegacyMetrics 使用旧的按类型扁平化格式上报指标(用于尚未升级的Manager)
+	LegacyMetrics bool `mapstructure:"legacy_metrics"`
+}
+
+// 与Manager通信的压缩算法
+const (
+	ManagerCompressionGzip = "gzip"
+	ManagerCompressionNone = "none"
+)
+
+// EnrollConfig 节点证书申请配置
+// 客户端证书不存在时使用加入令牌申请，证书由Daemon在到期前自动续期
+type EnrollConfig struct {
+	Token string `mapstructure:"token"` // 加入令牌(由管理员在Manager创建)
+	// CACertHash Manager CA公钥指纹("sha256:<hex>")，ca_file不存在时用于校验Manager身份
+	CACertHash string `mapstructure:"ca_cert_hash"`
 }
 
 // TLSConfig TLS配置
@@ -67,6 +104,7 @@ type HealthCheckConfig struct {
 	CPUThreshold      float64       `mapstructure:"cpu_threshold"`
 	MemoryThreshold   uint64        `mapstructure:"memory_threshold"`
 	ThresholdDuration time.Duration `mapstructure:"threshold_duration"`
+	ThresholdAction   string        `mapstructure:"threshold_action"` // 资源超限持续超过threshold_duration后的动作: none, restart, stop
 }
 
 // RestartConfig 重启配置
@@ -74,7 +112,9 @@ type RestartConfig struct {
 	MaxRetries  int           `mapstructure:"max_retries"`
 	BackoffBase time.Duration `mapstructure:"backoff_base"`
 	BackoffMax  time.Duration `mapstructure:"backoff_max"`
-	Policy      string        `mapstructure:"policy"` // always, never, on-failure
+	Policy      string        `mapstructure:"policy"`   // always, never, on-failure
+	Window      time.Duration `mapstructure:"window"`   // 崩溃统计滑动窗口，窗口内崩溃达到max_retries次后熔断
+	Cooldown    time.Duration `mapstructure:"cooldown"` // 熔断后的冷却时间，冷却结束前不再自动重启
 }
 
 // AgentsConfig 多Agent配置（新格式）
@@ -93,12 +133,152 @@ type AgentItemConfig struct {
 	Args        []string          `mapstructure:"args"`
 	HealthCheck HealthCheckConfig `mapstructure:"health_check"`
 	Restart     RestartConfig     `mapstructure:"restart"`
+	StopSignal  string            `mapstructure:"stop_signal"`  // 优雅停止信号(SIGTERM/SIGINT/SIGQUIT等)，默认SIGTERM
+	StopTimeout time.Duration     `mapstructure:"stop_timeout"` // 优雅停止超时，超时后SIGKILL整个进程组，默认30s
+	PreStop     HookConfig        `mapstructure:"pre_stop"`     // 停止前钩子
+	PostStart   HookConfig        `mapstructure:"post_start"`   // 启动后钩子
+	User        string            `mapstructure:"user"`         // 运行Agent的用户(用户名或UID)，默认与daemon相同
+	Group       string            `mapstructure:"group"`        // 运行Agent的用户组(组名或GID)，默认为用户的主组
+	Env         []string          `mapstructure:"env"`          // 额外的环境变量(KEY=VALUE)，优先于env_file
+	EnvFile     string            `mapstructure:"env_file"`     // 环境变量文件(每行KEY=VALUE)
+	InheritEnv  bool              `mapstructure:"inherit_env"`  // 是否继承daemon的环境变量，默认false(仅提供PATH/HOME等基础变量)
+	Umask       string            `mapstructure:"umask"`        // 文件创建掩码(八进制字符串，如"0027")
+	Rlimits     RlimitsConfig     `mapstructure:"rlimits"`      // 资源限制
+	Resources   ResourcesConfig   `mapstructure:"resources"`    // cgroup v2资源限制(需启用cgroup)
+	DependsOn   []string          `mapstructure:"depends_on"`   // 依赖的Agent ID，依赖就绪后才启动本Agent，停止顺序相反
+	Readiness   ReadinessConfig   `mapstructure:"readiness"`    // 就绪条件(健康探针通过)
+	LogRotation LogRotationConfig `mapstructure:"log_rotation"` // 日志轮转，未配置的项使用agent_defaults.log_rotation
+	Control     ControlConfig     `mapstructure:"control"`      // 控制通道(通过socket_path下发reload等命令)
+}
+
+// ControlConfig Agent控制通道配置
+// 配置了socket_path的Agent通过控制Socket接收命令，Socket不可用或Agent不支持该命令时回退为信号
+type ControlConfig struct {
+	Timeout         time.Duration     `mapstructure:"timeout"`          // 单条命令的超时，默认10s
+	FallbackSignals map[string]string `mapstructure:"fallback_signals"` // 命令到回退信号的映射，默认reload: SIGHUP
+}
+
+// ReadinessConfig Agent就绪条件(命令和HTTP探针二选一)
+// 未配置探针时进程启动即视为就绪
+type ReadinessConfig struct {
+	Command  []string       `mapstructure:"command"`  // 探针命令，退出码为0表示就绪
+	HTTP     HTTPHookConfig `mapstructure:"http"`     // HTTP探针，返回2xx表示就绪(默认GET)
+	Interval time.Duration  `mapstructure:"interval"` // 探测间隔，默认1s
+	Timeout  time.Duration  `mapstructure:"timeout"`  // 等待就绪的超时，默认60s
+}
+
+// IsSet 是否配置了就绪探针
+func (r *ReadinessConfig) IsSet() bool {
+	return len(r.Command) > 0 || r.HTTP.URL != ""
+}
+
+// LogRotationConfig Agent日志轮转配置
+type LogRotationConfig struct {
+	MaxSize    int64  `mapstructure:"max_size"`    // 单个日志文件的最大字节数，超过后轮转，默认100MB
+	MaxFiles   int    `mapstructure:"max_files"`   // 保留的轮转文件数，默认7
+	Period     string `mapstructure:"period"`      // 按时间轮转: hourly、daily，为空时只按大小轮转
+	Mode       string `mapstructure:"mode"`        // 轮转方式: copytruncate(复制后截断，默认)、rename(重命名后创建新文件)
+	DateSuffix bool   `mapstructure:"date_suffix"` // 轮转文件名使用日期后缀(agent.log.2024-03-01)而非序号(agent.log.1)
+}
+
+// AgentLogsConfig Agent日志保留与磁盘配额配置(作用于所有Agent的日志目录)
+type AgentLogsConfig struct {
+	RetentionDays      int           `mapstructure:"retention_days"`       // 轮转文件保留天数，默认30
+	MaxTotalSize       int64         `mapstructure:"max_total_size"`       // 所有Agent日志目录的总字节数上限，0表示不限制；超过时优先删除最旧的压缩文件
+	QuotaCheckInterval time.Duration `mapstructure:"quota_check_interval"` // 配额检查间隔，默认1m
+}
+
+// ResourceHistoryConfig Agent资源历史持久化配置
+// 数据点按时间分段追加写入 {daemon.work_dir}/resource_history，daemon重启或升级后仍可查询
+type ResourceHistoryConfig struct {
+	Retention         time.Duration `mapstructure:"retention"`          // 保留时长，默认168h(7天)
+	SegmentDuration   time.Duration `mapstructure:"segment_duration"`   // 每个段文件覆盖的时长，默认1h
+	CompactAfter      time.Duration `mapstructure:"compact_after"`      // 早于该时长的段文件降采样压缩，默认24h
+	CompactResolution time.Duration `mapstructure:"compact_resolution"` // 压缩后的采样间隔，默认5m
+}
+
+// HeartbeatAuthConfig Agent心跳签名认证配置
+// 启用后daemon启动Agent时通过环境变量下发心跳密钥，Agent使用HMAC为心跳签名
+type HeartbeatAuthConfig struct {
+	Mode    string        `mapstructure:"mode"`     // disabled/optional/required，默认required(拒绝未签名心跳)
+	MaxSkew time.Duration `mapstructure:"max_skew"` // 心跳时间戳允许的最大偏差，默认60s
+	KeyFile string        `mapstructure:"key_file"` // 派生Agent心跳密钥的主密钥文件，默认{daemon.work_dir}/heartbeat.key
+}
+
+// GRPCAuthConfig Daemon gRPC服务认证与授权配置
+// 配置了manager.tls时TCP端口要求Manager出示CA签发的证书(mTLS)，本地daemonctl通过Unix socket按uid授权
+type GRPCAuthConfig struct {
+	SocketPath      string `mapstructure:"socket_path"`       // 本地Unix socket路径，默认/var/run/daemon.sock，设为"-"禁用
+	SocketAdminUIDs []int  `mapstructure:"socket_admin_uids"` // 允许通过socket执行变更操作的uid(root和Daemon运行用户始终允许)
+	SocketReadUIDs  []int  `mapstructure:"socket_read_uids"`  // 只允许通过socket执行只读操作的uid
+	InsecureAccess  string `mapstructure:"insecure_access"`   // 未启用mTLS时TCP调用者的权限: none/read/mutate，默认none
+	AuditLog        string `mapstructure:"audit_log"`         // 变更操作审计日志文件，默认{daemon.work_dir}/audit.log
+}
+
+// SpoolConfig Manager不可达时的本地缓存队列配置
+// 配置了manager.address时，指标上报、Agent状态同步和事件先写入磁盘队列，连接恢复后按原始顺序和时间戳补发
+type SpoolConfig struct {
+	Disabled     bool          `mapstructure:"disabled"`      // 禁用本地缓存(Manager不可达时丢弃数据)，默认false
+	Dir          string        `mapstructure:"dir"`           // 队列目录，默认{daemon.work_dir}/spool
+	MaxBytes     int64         `mapstructure:"max_bytes"`     // 队列最大磁盘占用(字节)，超出时丢弃最旧的数据，默认256MB
+	MaxAge       time.Duration `mapstructure:"max_age"`       // 数据最长保留时间，超出时丢弃，默认72h
+	SegmentBytes int64         `mapstructure:"segment_bytes"` // 单个段文件大小(字节)，默认8MB
+}
+
+// CgroupConfig cgroup v2配置
+// 启用后每个Agent运行在 {root}/{parent}/{agent_id} 独立的cgroup中
+type CgroupConfig struct {
+	Enabled bool   `mapstructure:"enabled"` // 是否启用，默认false
+	Root    string `mapstructure:"root"`    // cgroup v2挂载点，默认/sys/fs/cgroup
+	Parent  string `mapstructure:"parent"`  // Agent cgroup的父cgroup(相对root)，默认ops-agents
+}
+
+// ResourcesConfig Agent cgroup资源限制(零值表示不限制)
+type ResourcesConfig struct {
+	CPUs       float64 `mapstructure:"cpus"`        // CPU核数上限(写入cpu.max)，如0.5表示半个核
+	MemoryMax  uint64  `mapstructure:"memory_max"`  // 内存硬上限(字节，写入memory.max)，超过后触发OOM
+	MemoryHigh uint64  `mapstructure:"memory_high"` // 内存软上限(字节，写入memory.high)，超过后限流并回收内存
+	PidsMax    int64   `mapstructure:"pids_max"`    // 最大进程/线程数(写入pids.max)
+	IOWeight   uint16  `mapstructure:"io_weight"`   // IO权重(1-10000，写入io.weight)，默认100
+}
+
+// RlimitsConfig Agent进程资源限制(软硬限制设为相同值，未配置的项继承daemon的限制)
+type RlimitsConfig struct {
+	Nofile *uint64 `mapstructure:"nofile"` // 最大打开文件数
+	Nproc  *uint64 `mapstructure:"nproc"`  // 用户最大进程数
+	Core   *uint64 `mapstructure:"core"`   // core文件大小上限(字节)，0表示不生成core文件
+}
+
+// IsSet 是否配置了任一资源限制
+func (r *RlimitsConfig) IsSet() bool {
+	return r.Nofile != nil || r.Nproc != nil || r.Core != nil
+}
+
+// HookConfig Agent生命周期钩子配置(命令和HTTP调用二选一)
+type HookConfig struct {
+	Command []string       `mapstructure:"command"` // 执行的命令及参数
+	HTTP    HTTPHookConfig `mapstructure:"http"`    // HTTP调用
+	Timeout time.Duration  `mapstructure:"timeout"` // 钩子执行超时，默认10s
+}
+
+// HTTPHookConfig HTTP钩子配置
+type HTTPHookConfig struct {
+	URL     string            `mapstructure:"url"`
+	Method  string            `mapstructure:"method"` // 默认POST
+	Headers map[string]string `mapstructure:"headers"`
+	Body    string            `mapstructure:"body"`
+}
+
+// IsSet 是否配置了钩子
+func (h *HookConfig) IsSet() bool {
+	return len(h.Command) > 0 || h.HTTP.URL != ""
 }
 
 // AgentDefaultsConfig 全局Agent默认配置
 type AgentDefaultsConfig struct {
 	HealthCheck HealthCheckConfig `mapstructure:"health_check"`
 	Restart     RestartConfig     `mapstructure:"restart"`
+	LogRotation LogRotationConfig `mapstructure:"log_rotation"`
 }
 
 // CollectorConfigs 采集器配置
@@ -194,11 +374,17 @@ func Load(configPath string) (*Config, error) {
 // 保留此函数以保持向后兼容，实际实现在 defaults.go 中
 func setDefaults(config *Config) {
 	setDaemonDefaults(&config.Daemon)
-	setManagerDefaults(&config.Manager)
+	setManagerDefaults(&config.Manager, config.Daemon.WorkDir)
 	setAgentDefaults(&config.Agent)
 	setAgentDefaultsConfig(&config.AgentDefaults)
 	setCollectorDefaults(&config.Collectors)
 	setUpdateDefaults(&config.Update)
+	setCgroupDefaults(&config.Cgroup)
+	setAgentLogsDefaults(&config.AgentLogs)
+	setResourceHistoryDefaults(&config.ResourceHistory)
+	setHeartbeatAuthDefaults(&config.HeartbeatAuth, config.Daemon.WorkDir)
+	setGRPCAuthDefaults(&config.GRPCAuth, config.Daemon.WorkDir)
+	setSpoolDefaults(&config.Spool, config.Daemon.WorkDir)
 }
 
 // validate 验证配置
@@ -219,23 +405,37 @@ func validate(config *Config) error {
 		fmt.Println("Warning: manager.address is empty, running in standalone mode")
 	}
 
-	// 验证TLS证书文件（如果配置了才检查，文件不存在只警告）
-	if config.Manager.TLS.CertFile != "" {
-		if _, err := os.Stat(config.Manager.TLS.CertFile); os.IsNotExist(err) {
-			fmt.Printf("Warning: TLS cert file not found: %s, TLS disabled\n", config.Manager.TLS.CertFile)
-			config.Manager.TLS.CertFile = ""
-		}
+	// 验证压缩算法
+	if c := config.Manager.Compression; c != ManagerCompressionGzip && c != ManagerCompressionNone {
+		return fmt.Errorf("invalid manager.compression: %s (must be gzip or none)", c)
 	}
-	if config.Manager.TLS.KeyFile != "" {
-		if _, err := os.Stat(config.Manager.TLS.KeyFile); os.IsNotExist(err) {
-			fmt.Printf("Warning: TLS key file not found: %s, TLS disabled\n", config.Manager.TLS.KeyFile)
-			config.Manager.TLS.KeyFile = ""
+
+	// 验证TLS证书文件：证书、私钥和CA必须同时配置或都不配置，配置的文件必须存在，避免gRPC服务静默降级为非mTLS
+	// 配置了加入令牌时证书文件由证书申请生成，不存在时需要CA指纹校验Manager身份
+	if config.Manager.Enroll.Token != "" {
+		if _, err := os.Stat(config.Manager.TLS.CAFile); err != nil && config.Manager.Enroll.CACertHash == "" {
+			return fmt.Errorf("manager.enroll.ca_cert_hash is required when manager.tls.ca_file does not exist")
 		}
-	}
-	if config.Manager.TLS.CAFile != "" {
-		if _, err := os.Stat(config.Manager.TLS.CAFile); os.IsNotExist(err) {
-			fmt.Printf("Warning: TLS CA file not found: %s, TLS disabled\n", config.Manager.TLS.CAFile)
-			config.Manager.TLS.CAFile = ""
+	} else {
+		tlsFiles := []struct{ key, path string }{
+			{"cert_file", config.Manager.TLS.CertFile},
+			{"key_file", config.Manager.TLS.KeyFile},
+			{"ca_file", config.Manager.TLS.CAFile},
+		}
+		var configured, missing []string
+		for _, f := range tlsFiles {
+			if f.path == "" {
+				missing = append(missing, f.key)
+				continue
+			}
+			configured = append(configured, f.key)
+			if _, err := os.Stat(f.path); err != nil {
+				return fmt.Errorf("invalid manager.tls.%s: %w", f.key, err)
+			}
+		}
+		if len(configured) > 0 && len(missing) > 0 {
+			return fmt.Errorf("incomplete manager.tls: %s set but %s missing (cert_file, key_file and ca_file must be set together)",
+				strings.Join(configured, ", "), strings.Join(missing, ", "))
 		}
 	}
 
@@ -250,6 +450,56 @@ func validate(config *Config) error {
 		}
 	}
 
+	// 验证cgroup配置: 父cgroup必须是root下的相对路径
+	if config.Cgroup.Enabled {
+		parent := config.Cgroup.Parent
+		if filepath.IsAbs(parent) || parent != filepath.Clean(parent) || strings.HasPrefix(parent, "..") {
+			return fmt.Errorf("invalid cgroup.parent: %s (must be a relative path under cgroup.root)", parent)
+		}
+	}
+
+	// 验证Agent日志配额
+	if config.AgentLogs.RetentionDays < 0 || config.AgentLogs.MaxTotalSize < 0 || config.AgentLogs.QuotaCheckInterval < 0 {
+		return fmt.Errorf("agent_logs.retention_days, max_total_size and quota_check_interval must not be negative")
+	}
+	// 验证资源历史配置
+	history := config.ResourceHistory
+	if history.Retention < 0 || history.SegmentDuration < 0 || history.CompactAfter < 0 || history.CompactResolution < 0 {
+		return fmt.Errorf("resource_history durations must not be negative")
+	}
+	if history.CompactAfter > 0 && history.Retention > 0 && history.CompactAfter > history.Retention {
+		return fmt.Errorf("resource_history.compact_after must not exceed retention")
+	}
+
+	// 验证心跳认证配置
+	switch config.HeartbeatAuth.Mode {
+	case "disabled", "optional", "required":
+	default:
+		return fmt.Errorf("invalid heartbeat_auth.mode: %s (must be disabled, optional or required)", config.HeartbeatAuth.Mode)
+	}
+	if config.HeartbeatAuth.MaxSkew < 0 {
+		return fmt.Errorf("heartbeat_auth.max_skew must not be negative")
+	}
+
+	// 验证gRPC认证配置
+	switch config.GRPCAuth.InsecureAccess {
+	case "none", "read", "mutate":
+	default:
+		return fmt.Errorf("invalid grpc_auth.insecure_access: %s (must be none, read or mutate)", config.GRPCAuth.InsecureAccess)
+	}
+
+	// 验证本地缓存队列配置
+	if config.Spool.MaxBytes < 0 || config.Spool.SegmentBytes < 0 || config.Spool.MaxAge < 0 {
+		return fmt.Errorf("spool.max_bytes, spool.segment_bytes and spool.max_age must not be negative")
+	}
+	if config.Spool.SegmentBytes*2 > config.Spool.MaxBytes {
+		return fmt.Errorf("spool.segment_bytes (%d) must be at most half of spool.max_bytes (%d)", config.Spool.SegmentBytes, config.Spool.MaxBytes)
+	}
+
+	if err := validateLogRotation(config.AgentDefaults.LogRotation); err != nil {
+		return fmt.Errorf("invalid agent_defaults.log_rotation: %w", err)
+	}
+
 	// 验证Agents配置（新格式）
 	if err := validateAgentsConfig(config); err != nil {
 		return err
@@ -312,6 +562,9 @@ func mergeAgentConfigs(config *Config) error {
 		if agent.HealthCheck.ThresholdDuration == 0 {
 			agent.HealthCheck.ThresholdDuration = defaults.HealthCheck.ThresholdDuration
 		}
+		if agent.HealthCheck.ThresholdAction == "" {
+			agent.HealthCheck.ThresholdAction = defaults.HealthCheck.ThresholdAction
+		}
 
 		// 合并重启配置
 		if agent.Restart.MaxRetries == 0 {
@@ -326,6 +579,29 @@ func mergeAgentConfigs(config *Config) error {
 		if agent.Restart.Policy == "" {
 			agent.Restart.Policy = defaults.Restart.Policy
 		}
+		if agent.Restart.Window == 0 {
+			agent.Restart.Window = defaults.Restart.Window
+		}
+		if agent.Restart.Cooldown == 0 {
+			agent.Restart.Cooldown = defaults.Restart.Cooldown
+		}
+
+		// 合并日志轮转配置
+		if agent.LogRotation.MaxSize == 0 {
+			agent.LogRotation.MaxSize = defaults.LogRotation.MaxSize
+		}
+		if agent.LogRotation.MaxFiles == 0 {
+			agent.LogRotation.MaxFiles = defaults.LogRotation.MaxFiles
+		}
+		if agent.LogRotation.Period == "" {
+			agent.LogRotation.Period = defaults.LogRotation.Period
+		}
+		if agent.LogRotation.Mode == "" {
+			agent.LogRotation.Mode = defaults.LogRotation.Mode
+		}
+		if !agent.LogRotation.DateSuffix {
+			agent.LogRotation.DateSuffix = defaults.LogRotation.DateSuffix
+		}
 
 		// 设置默认Name
 		if agent.Name == "" {
@@ -339,6 +615,14 @@ func mergeAgentConfigs(config *Config) error {
 	return nil
 }
 
+// validControlCommands 可以配置回退信号的控制命令
+var validControlCommands = map[string]bool{
+	"reload":           true,
+	"set_log_level":    true,
+	"dump_diagnostics": true,
+	"drain":            true,
+}
+
 // validateAgentsConfig 验证Agents配置
 func validateAgentsConfig(config *Config) error {
 	// 检查ID唯一性
@@ -397,7 +681,162 @@ func validateAgentsConfig(config *Config) error {
 				return fmt.Errorf("invalid restart policy: %s (valid policies: always, never, on-failure)", agent.Restart.Policy)
 			}
 		}
+
+		// 验证资源超限动作
+		if agent.HealthCheck.ThresholdAction != "" {
+			validActions := map[string]bool{
+				"none":    true,
+				"restart": true,
+				"stop":    true,
+			}
+			if !validActions[agent.HealthCheck.ThresholdAction] {
+				return fmt.Errorf("invalid threshold action: %s (valid actions: none, restart, stop)", agent.HealthCheck.ThresholdAction)
+			}
+		}
+
+		// 验证停止信号
+		if agent.StopSignal != "" {
+			if _, err := ParseSignal(agent.StopSignal); err != nil {
+				return fmt.Errorf("invalid stop signal (agent: %s): %w", agent.ID, err)
+			}
+		}
+		if agent.StopTimeout < 0 {
+			return fmt.Errorf("stop_timeout must not be negative (agent: %s)", agent.ID)
+		}
+
+		// 验证控制通道
+		if agent.Control.Timeout < 0 {
+			return fmt.Errorf("control timeout must not be negative (agent: %s)", agent.ID)
+		}
+		for command, signal := range agent.Control.FallbackSignals {
+			if !validControlCommands[command] {
+				return fmt.Errorf("invalid control command in fallback_signals: %s (agent: %s, valid commands: reload, set_log_level, dump_diagnostics, drain)", command, agent.ID)
+			}
+			// SIGKILL无法被Agent处理，不能作为控制命令的回退信号
+			sig, err := ParseSignal(signal)
+			if err != nil {
+				return fmt.Errorf("invalid fallback signal for %s (agent: %s): %w", command, agent.ID, err)
+			}
+			if sig == syscall.SIGKILL {
+				return fmt.Errorf("invalid fallback signal for %s: %s (agent: %s, SIGKILL cannot be handled by the agent)", command, signal, agent.ID)
+			}
+		}
+
+		// 验证生命周期钩子
+		for name, hook := range map[string]HookConfig{"pre_stop": agent.PreStop, "post_start": agent.PostStart} {
+			if len(hook.Command) > 0 && hook.HTTP.URL != "" {
+				return fmt.Errorf("%s hook must configure either command or http, not both (agent: %s)", name, agent.ID)
+			}
+			if hook.Timeout < 0 {
+				return fmt.Errorf("%s hook timeout must not be negative (agent: %s)", name, agent.ID)
+			}
+		}
+
+		// 验证进程运行属性
+		if agent.Umask != "" {
+			if mask, err := strconv.ParseUint(agent.Umask, 8, 32); err != nil || mask > 0777 {
+				return fmt.Errorf("invalid umask: %s (agent: %s, expected octal such as 0027)", agent.Umask, agent.ID)
+			}
+		}
+		if agent.Resources.CPUs < 0 || agent.Resources.PidsMax < 0 {
+			return fmt.Errorf("resources.cpus and resources.pids_max must not be negative (agent: %s)", agent.ID)
+		}
+		if agent.Resources.IOWeight > 10000 {
+			return fmt.Errorf("resources.io_weight must be between 1 and 10000 (agent: %s)", agent.ID)
+		}
+		if r := agent.Resources; r.MemoryMax > 0 && r.MemoryHigh > r.MemoryMax {
+			return fmt.Errorf("resources.memory_high must not exceed memory_max (agent: %s)", agent.ID)
+		}
+		for _, env := range agent.Env {
+			if key, _, ok := strings.Cut(env, "="); !ok || key == "" {
+				return fmt.Errorf("invalid env entry: %q (agent: %s, expected KEY=VALUE)", env, agent.ID)
+			}
+		}
+
+		// 验证就绪探针
+		if len(agent.Readiness.Command) > 0 && agent.Readiness.HTTP.URL != "" {
+			return fmt.Errorf("readiness must configure either command or http, not both (agent: %s)", agent.ID)
+		}
+		if agent.Readiness.Interval < 0 || agent.Readiness.Timeout < 0 {
+			return fmt.Errorf("readiness interval and timeout must not be negative (agent: %s)", agent.ID)
+		}
+
+		// 验证日志轮转
+		if err := validateLogRotation(agent.LogRotation); err != nil {
+			return fmt.Errorf("invalid log_rotation (agent: %s): %w", agent.ID, err)
+		}
+	}
+
+	// 验证依赖关系
+	if err := validateAgentDependencies(config.Agents); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// validateLogRotation 验证日志轮转配置
+func validateLogRotation(rotation LogRotationConfig) error {
+	if rotation.MaxSize < 0 || rotation.MaxFiles < 0 {
+		return fmt.Errorf("max_size and max_files must not be negative")
+	}
+	switch rotation.Period {
+	case "", "hourly", "daily":
+	default:
+		return fmt.Errorf("invalid period: %s (valid periods: hourly, daily)", rotation.Period)
+	}
+	switch rotation.Mode {
+	case "", "copytruncate", "rename":
+	default:
+		return fmt.Errorf("invalid mode: %s (valid modes: copytruncate, rename)", rotation.Mode)
+	}
+	return nil
+}
+
+// validateAgentDependencies 验证depends_on引用的Agent存在且依赖关系无环
+func validateAgentDependencies(agents AgentsConfig) error {
+	deps := make(map[string][]string, len(agents))
+	for _, agent := range agents {
+		deps[agent.ID] = agent.DependsOn
+	}
+	for _, agent := range agents {
+		for _, dep := range agent.DependsOn {
+			if dep == agent.ID {
+				return fmt.Errorf("agent %s cannot depend on itself", agent.ID)
+			}
+			if _, ok := deps[dep]; !ok {
+				return fmt.Errorf("agent %s depends on unknown agent: %s", agent.ID, dep)
+			}
+		}
 	}
 
+	// 深度优先遍历检测环
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int, len(agents))
+	var visit func(id string, path []string) error
+	visit = func(id string, path []string) error {
+		switch state[id] {
+		case visiting:
+			return fmt.Errorf("dependency cycle detected: %s", strings.Join(append(path, id), " -> "))
+		case visited:
+			return nil
+		}
+		state[id] = visiting
+		for _, dep := range deps[id] {
+			if err := visit(dep, append(path, id)); err != nil {
+				return err
+			}
+		}
+		state[id] = visited
+		return nil
+	}
+	for _, agent := range agents {
+		if err := visit(agent.ID, nil); err != nil {
+			return err
+		}
+	}
 	return nil
 }
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -463,3 +464,274 @@ agent:
 		t.Errorf("expected ConfigFile '/etc/agent/agent.yaml', got '%s'", agent.ConfigFile)
 	}
 }
+
+func TestValidateAgentsConfig_StopSignalAndHooks(t *testing.T) {
+	newConfig := func(item AgentItemConfig) *Config {
+		item.ID = "agent-1"
+		item.Type = "filebeat"
+		item.BinaryPath = "/usr/bin/filebeat"
+		return &Config{Agents: AgentsConfig{item}}
+	}
+
+	if err := validateAgentsConfig(newConfig(AgentItemConfig{StopSignal: "SIGQUIT", StopTimeout: 10 * time.Second})); err != nil {
+		t.Errorf("unexpected error for valid stop config: %v", err)
+	}
+	if err := validateAgentsConfig(newConfig(AgentItemConfig{StopSignal: "SIGSTOP"})); err == nil {
+		t.Error("expected error for invalid stop signal")
+	}
+	// SIG前缀可省略且不区分大小写，与Agent启动时的解析一致
+	if err := validateAgentsConfig(newConfig(AgentItemConfig{StopSignal: "term"})); err != nil {
+		t.Errorf("unexpected error for stop signal without SIG prefix: %v", err)
+	}
+	if err := validateAgentsConfig(newConfig(AgentItemConfig{Control: ControlConfig{FallbackSignals: map[string]string{"reload": "KILL"}}})); err == nil {
+		t.Error("expected error for SIGKILL fallback signal")
+	}
+	if err := validateAgentsConfig(newConfig(AgentItemConfig{StopTimeout: -time.Second})); err == nil {
+		t.Error("expected error for negative stop timeout")
+	}
+
+	bothHook := HookConfig{
+		Command: []string{"/bin/true"},
+		HTTP:    HTTPHookConfig{URL: "http://127.0.0.1/flush"},
+	}
+	if err := validateAgentsConfig(newConfig(AgentItemConfig{PreStop: bothHook})); err == nil {
+		t.Error("expected error for hook with both command and http")
+	}
+}
+
+func TestValidateAgentsConfig_ProcessAttrs(t *testing.T) {
+	newConfig := func(item AgentItemConfig) *Config {
+		item.ID = "agent-1"
+		item.Type = "filebeat"
+		item.BinaryPath = "/usr/bin/filebeat"
+		return &Config{Agents: AgentsConfig{item}}
+	}
+
+	nofile := uint64(65536)
+	valid := AgentItemConfig{
+		User:    "filebeat",
+		Umask:   "0027",
+		Env:     []string{"FOO=bar", "EMPTY="},
+		Rlimits: RlimitsConfig{Nofile: &nofile},
+	}
+	if err := validateAgentsConfig(newConfig(valid)); err != nil {
+		t.Errorf("unexpected error for valid process config: %v", err)
+	}
+	if err := validateAgentsConfig(newConfig(AgentItemConfig{Umask: "0999"})); err == nil {
+		t.Error("expected error for invalid umask")
+	}
+	if err := validateAgentsConfig(newConfig(AgentItemConfig{Umask: "01777"})); err == nil {
+		t.Error("expected error for out of range umask")
+	}
+	if err := validateAgentsConfig(newConfig(AgentItemConfig{Env: []string{"NOVALUE"}})); err == nil {
+		t.Error("expected error for env entry without '='")
+	}
+}
+
+func TestValidateAgentsConfig_Resources(t *testing.T) {
+	newConfig := func(resources ResourcesConfig) *Config {
+		return &Config{Agents: AgentsConfig{{
+			ID:         "agent-1",
+			Type:       "filebeat",
+			BinaryPath: "/usr/bin/filebeat",
+			Resources:  resources,
+		}}}
+	}
+
+	valid := ResourcesConfig{CPUs: 1.5, MemoryMax: 512 << 20, MemoryHigh: 400 << 20, PidsMax: 128, IOWeight: 200}
+	if err := validateAgentsConfig(newConfig(valid)); err != nil {
+		t.Errorf("unexpected error for valid resources: %v", err)
+	}
+	if err := validateAgentsConfig(newConfig(ResourcesConfig{CPUs: -1})); err == nil {
+		t.Error("expected error for negative cpus")
+	}
+	if err := validateAgentsConfig(newConfig(ResourcesConfig{PidsMax: -1})); err == nil {
+		t.Error("expected error for negative pids_max")
+	}
+	if err := validateAgentsConfig(newConfig(ResourcesConfig{IOWeight: 20000})); err == nil {
+		t.Error("expected error for io_weight out of range")
+	}
+	if err := validateAgentsConfig(newConfig(ResourcesConfig{MemoryMax: 100, MemoryHigh: 200})); err == nil {
+		t.Error("expected error for memory_high greater than memory_max")
+	}
+}
+
+func TestValidateAgentsConfig_Dependencies(t *testing.T) {
+	newConfig := func(deps map[string][]string) *Config {
+		cfg := &Config{}
+		for _, id := range []string{"shipper", "proxy", "app"} {
+			cfg.Agents = append(cfg.Agents, AgentItemConfig{
+				ID:         id,
+				Type:       "custom",
+				BinaryPath: "/usr/bin/" + id,
+				DependsOn:  deps[id],
+			})
+		}
+		return cfg
+	}
+
+	if err := validateAgentsConfig(newConfig(map[string][]string{
+		"app":   {"shipper", "proxy"},
+		"proxy": {"shipper"},
+	})); err != nil {
+		t.Errorf("unexpected error for valid dependencies: %v", err)
+	}
+	if err := validateAgentsConfig(newConfig(map[string][]string{"app": {"missing"}})); err == nil {
+		t.Error("expected error for unknown dependency")
+	}
+	if err := validateAgentsConfig(newConfig(map[string][]string{"app": {"app"}})); err == nil {
+		t.Error("expected error for self dependency")
+	}
+	err := validateAgentsConfig(newConfig(map[string][]string{
+		"shipper": {"app"},
+		"proxy":   {"shipper"},
+		"app":     {"proxy"},
+	}))
+	if err == nil || !strings.Contains(err.Error(), "dependency cycle") {
+		t.Errorf("expected dependency cycle error, got %v", err)
+	}
+}
+
+func TestValidateAgentsConfig_LogRotation(t *testing.T) {
+	newConfig := func(rotation LogRotationConfig) *Config {
+		return &Config{Agents: AgentsConfig{{
+			ID:          "agent-1",
+			Type:        "filebeat",
+			BinaryPath:  "/usr/bin/filebeat",
+			LogRotation: rotation,
+		}}}
+	}
+
+	valid := LogRotationConfig{MaxSize: 100 << 20, MaxFiles: 7, Period: "hourly", Mode: "rename", DateSuffix: true}
+	if err := validateAgentsConfig(newConfig(valid)); err != nil {
+		t.Errorf("unexpected error for valid log rotation: %v", err)
+	}
+	if err := validateAgentsConfig(newConfig(LogRotationConfig{MaxSize: -1})); err == nil {
+		t.Error("expected error for negative max_size")
+	}
+	if err := validateAgentsConfig(newConfig(LogRotationConfig{Period: "weekly"})); err == nil {
+		t.Error("expected error for invalid period")
+	}
+	if err := validateAgentsConfig(newConfig(LogRotationConfig{Mode: "move"})); err == nil {
+		t.Error("expected error for invalid mode")
+	}
+}
+
+func TestValidateAgentsConfig_ThresholdAction(t *testing.T) {
+	newConfig := func(action string) *Config {
+		return &Config{Agents: AgentsConfig{{
+			ID:          "agent-1",
+			Type:        "filebeat",
+			BinaryPath:  "/usr/bin/filebeat",
+			HealthCheck: HealthCheckConfig{ThresholdAction: action},
+		}}}
+	}
+
+	for _, action := range []string{"", "none", "restart", "stop"} {
+		if err := validateAgentsConfig(newConfig(action)); err != nil {
+			t.Errorf("unexpected error for threshold action %q: %v", action, err)
+		}
+	}
+	if err := validateAgentsConfig(newConfig("kill")); err == nil {
+		t.Error("expected error for invalid threshold action")
+	}
+}
+
+func TestLoadConfig_ResourceHistory(t *testing.T) {
+	load := func(content string) (*Config, error) {
+		configFile := filepath.Join(t.TempDir(), "test-config.yaml")
+		if err := os.WriteFile(configFile, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write config file: %v", err)
+		}
+		return Load(configFile)
+	}
+
+	cfg, err := load("daemon:\n  work_dir: /var/lib/daemon\n")
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if cfg.ResourceHistory.Retention != 7*24*time.Hour || cfg.ResourceHistory.CompactResolution != 5*time.Minute {
+		t.Errorf("unexpected resource_history defaults: %+v", cfg.ResourceHistory)
+	}
+
+	_, err = load("daemon:\n  work_dir: /var/lib/daemon\nresource_history:\n  retention: 12h\n  compact_after: 24h\n")
+	if err == nil || !strings.Contains(err.Error(), "compact_after") {
+		t.Errorf("expected compact_after validation error, got %v", err)
+	}
+}
+
+func TestLoadConfig_ManagerTLSFiles(t *testing.T) {
+	dir := t.TempDir()
+	load := func(content string) (*Config, error) {
+		configFile := filepath.Join(dir, "test-config.yaml")
+		if err := os.WriteFile(configFile, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write config file: %v", err)
+		}
+		return Load(configFile)
+	}
+
+	certFile := filepath.Join(dir, "client.crt")
+	keyFile := filepath.Join(dir, "client.key")
+	caFile := filepath.Join(dir, "ca.crt")
+	for _, file := range []string{certFile, keyFile, caFile} {
+		if err := os.WriteFile(file, []byte("test"), 0600); err != nil {
+			t.Fatalf("failed to write %s: %v", file, err)
+		}
+	}
+	tlsConfig := func(cert, key, ca string) string {
+		return "daemon:\n  work_dir: " + dir + "\nmanager:\n  tls:\n    cert_file: " + cert + "\n    key_file: " + key + "\n    ca_file: " + ca + "\n"
+	}
+
+	cfg, err := load(tlsConfig(certFile, keyFile, caFile))
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if cfg.Manager.TLS.CertFile != certFile || cfg.Manager.TLS.KeyFile != keyFile || cfg.Manager.TLS.CAFile != caFile {
+		t.Errorf("tls files should be kept: %+v", cfg.Manager.TLS)
+	}
+
+	// 配置了但不存在的证书文件是配置错误，不静默关闭TLS
+	missing := filepath.Join(dir, "missing.pem")
+	for key, content := range map[string]string{
+		"cert_file": tlsConfig(missing, keyFile, caFile),
+		"key_file":  tlsConfig(certFile, missing, caFile),
+		"ca_file":   tlsConfig(certFile, keyFile, missing),
+	} {
+		if _, err := load(content); err == nil || !strings.Contains(err.Error(), "manager.tls."+key) {
+			t.Errorf("expected manager.tls.%s validation error, got %v", key, err)
+		}
+	}
+
+	// 只配置部分证书文件时gRPC服务无法启用mTLS，视为配置错误
+	partial := func(lines string) string {
+		return "daemon:\n  work_dir: " + dir + "\nmanager:\n  tls:\n" + lines
+	}
+	for name, content := range map[string]string{
+		"ca only":      partial("    ca_file: " + caFile + "\n"),
+		"cert and key": partial("    cert_file: " + certFile + "\n    key_file: " + keyFile + "\n"),
+		"missing key":  partial("    cert_file: " + certFile + "\n    ca_file: " + caFile + "\n"),
+	} {
+		if _, err := load(content); err == nil || !strings.Contains(err.Error(), "incomplete manager.tls") {
+			t.Errorf("%s: expected incomplete manager.tls error, got %v", name, err)
+		}
+	}
+
+	// 不配置证书文件时使用明文端口，默认拒绝所有调用
+	cfg, err = load("daemon:\n  work_dir: " + dir + "\n")
+	if err != nil {
+		t.Fatalf("failed to load config without tls: %v", err)
+	}
+	if cfg.GRPCAuth.InsecureAccess != "none" {
+		t.Errorf("grpc_auth.insecure_access should default to none, got %s", cfg.GRPCAuth.InsecureAccess)
+	}
+
+	// 证书申请流程中证书文件尚未生成
+	enroll := "daemon:\n  work_dir: " + dir + "\nmanager:\n  enroll:\n    token: test-token\n    ca_cert_hash: sha256:abcd\n"
+	cfg, err = load(enroll)
+	if err != nil {
+		t.Fatalf("enrollment config should load before certificates exist: %v", err)
+	}
+	if cfg.Manager.TLS.CertFile != filepath.Join(dir, "certs", "client.crt") {
+		t.Errorf("unexpected enrollment cert path: %s", cfg.Manager.TLS.CertFile)
+	}
+}
@@ -1,6 +1,9 @@
 package config
 
-import "time"
+import (
+	"path/filepath"
+	"time"
+)
 
 // setDaemonDefaults 设置 Daemon 默认值
 func setDaemonDefaults(daemon *DaemonConfig) {
@@ -28,7 +31,8 @@ func setDaemonDefaults(daemon *DaemonConfig) {
 }
 
 // setManagerDefaults 设置 Manager 默认值
-func setManagerDefaults(manager *ManagerConfig) {
+// 配置了加入令牌时，未配置的证书路径默认放在工作目录的certs子目录下
+func setManagerDefaults(manager *ManagerConfig, workDir string) {
 	if manager.HeartbeatInterval == 0 {
 		manager.HeartbeatInterval = 60 * time.Second
 	}
@@ -38,6 +42,21 @@ func setManagerDefaults(manager *ManagerConfig) {
 	if manager.Timeout == 0 {
 		manager.Timeout = 30 * time.Second
 	}
+	if manager.Compression == "" {
+		manager.Compression = ManagerCompressionGzip
+	}
+	if manager.Enroll.Token != "" && workDir != "" {
+		certDir := filepath.Join(workDir, "certs")
+		if manager.TLS.CertFile == "" {
+			manager.TLS.CertFile = filepath.Join(certDir, "client.crt")
+		}
+		if manager.TLS.KeyFile == "" {
+			manager.TLS.KeyFile = filepath.Join(certDir, "client.key")
+		}
+		if manager.TLS.CAFile == "" {
+			manager.TLS.CAFile = filepath.Join(certDir, "ca.crt")
+		}
+	}
 }
 
 // setAgentDefaults 设置 Agent 默认值（旧格式）
@@ -50,6 +69,22 @@ func setAgentDefaults(agent *AgentConfig) {
 func setAgentDefaultsConfig(defaults *AgentDefaultsConfig) {
 	setHealthCheckDefaults(&defaults.HealthCheck)
 	setRestartDefaults(&defaults.Restart)
+	setLogRotationDefaults(&defaults.LogRotation)
+}
+
+// setLogRotationDefaults 设置日志轮转默认值
+// Agent的stdout/stderr直接重定向到日志文件并一直持有文件句柄，默认使用copytruncate，
+// 否则重命名后Agent仍写入旧文件，删除旧文件也无法释放磁盘空间
+func setLogRotationDefaults(rotation *LogRotationConfig) {
+	if rotation.MaxSize == 0 {
+		rotation.MaxSize = 100 * 1024 * 1024 // 100MB
+	}
+	if rotation.MaxFiles == 0 {
+		rotation.MaxFiles = 7
+	}
+	if rotation.Mode == "" {
+		rotation.Mode = "copytruncate"
+	}
 }
 
 // setHealthCheckDefaults 设置健康检查默认值
@@ -85,6 +120,12 @@ func setRestartDefaults(restart *RestartConfig) {
 	if restart.Policy == "" {
 		restart.Policy = "always"
 	}
+	if restart.Window == 0 {
+		restart.Window = 10 * time.Minute
+	}
+	if restart.Cooldown == 0 {
+		restart.Cooldown = 5 * time.Minute
+	}
 }
 
 // setCollectorDefaults 设置采集器默认值
@@ -112,3 +153,82 @@ func setUpdateDefaults(update *UpdateConfig) {
 		update.VerifyTimeout = 300 * time.Second
 	}
 }
+
+// setCgroupDefaults 设置cgroup默认值
+func setCgroupDefaults(cgroup *CgroupConfig) {
+	if cgroup.Root == "" {
+		cgroup.Root = "/sys/fs/cgroup"
+	}
+	if cgroup.Parent == "" {
+		cgroup.Parent = "ops-agents"
+	}
+}
+
+// setAgentLogsDefaults 设置Agent日志保留与配额默认值
+func setAgentLogsDefaults(logs *AgentLogsConfig) {
+	if logs.RetentionDays == 0 {
+		logs.RetentionDays = 30
+	}
+	if logs.QuotaCheckInterval == 0 {
+		logs.QuotaCheckInterval = time.Minute
+	}
+}
+
+// setResourceHistoryDefaults 设置资源历史持久化默认值
+func setResourceHistoryDefaults(history *ResourceHistoryConfig) {
+	if history.Retention == 0 {
+		history.Retention = 7 * 24 * time.Hour
+	}
+	if history.SegmentDuration == 0 {
+		history.SegmentDuration = time.Hour
+	}
+	if history.CompactAfter == 0 {
+		history.CompactAfter = 24 * time.Hour
+	}
+	if history.CompactResolution == 0 {
+		history.CompactResolution = 5 * time.Minute
+	}
+}
+
+// setHeartbeatAuthDefaults 设置心跳认证默认值
+func setHeartbeatAuthDefaults(auth *HeartbeatAuthConfig, workDir string) {
+	if auth.Mode == "" {
+		auth.Mode = "required"
+	}
+	if auth.MaxSkew == 0 {
+		auth.MaxSkew = 60 * time.Second
+	}
+	if auth.KeyFile == "" && workDir != "" {
+		auth.KeyFile = filepath.Join(workDir, "heartbeat.key")
+	}
+}
+
+// setGRPCAuthDefaults 设置gRPC认证默认值
+func setGRPCAuthDefaults(auth *GRPCAuthConfig, workDir string) {
+	if auth.SocketPath == "" {
+		auth.SocketPath = "/var/run/daemon.sock"
+	}
+	// 明文端口默认拒绝所有调用，Agent日志可能包含凭据，只读访问需显式开启
+	if auth.InsecureAccess == "" {
+		auth.InsecureAccess = "none"
+	}
+	if auth.AuditLog == "" && workDir != "" {
+		auth.AuditLog = filepath.Join(workDir, "audit.log")
+	}
+}
+
+// setSpoolDefaults 设置本地缓存队列默认值
+func setSpoolDefaults(spool *SpoolConfig, workDir string) {
+	if spool.Dir == "" && workDir != "" {
+		spool.Dir = filepath.Join(workDir, "spool")
+	}
+	if spool.MaxBytes == 0 {
+		spool.MaxBytes = 256 << 20
+	}
+	if spool.MaxAge == 0 {
+		spool.MaxAge = 72 * time.Hour
+	}
+	if spool.SegmentBytes == 0 {
+		spool.SegmentBytes = 8 << 20
+	}
+}
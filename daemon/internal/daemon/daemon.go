@@ -2,11 +2,15 @@ package daemon
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -15,15 +19,22 @@ import (
 	"github.com/bingooyong/ops-scaffold-framework/daemon/internal/comm"
 	"github.com/bingooyong/ops-scaffold-framework/daemon/internal/config"
 	grpcclient "github.com/bingooyong/ops-scaffold-framework/daemon/internal/grpc"
+	logpkg "github.com/bingooyong/ops-scaffold-framework/daemon/internal/logger"
+	"github.com/bingooyong/ops-scaffold-framework/daemon/internal/pki"
+	"github.com/bingooyong/ops-scaffold-framework/daemon/internal/spool"
 	"github.com/bingooyong/ops-scaffold-framework/daemon/internal/version"
 	"github.com/bingooyong/ops-scaffold-framework/daemon/pkg/proto"
 	"github.com/bingooyong/ops-scaffold-framework/daemon/pkg/types"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	_ "google.golang.org/grpc/encoding/gzip" // 注册gzip压缩，Manager下发的调用可使用压缩
 	"google.golang.org/grpc/keepalive"
 )
 
+// certRenewalCheckInterval 客户端证书有效期检查间隔
+const certRenewalCheckInterval = time.Hour
+
 // Daemon 守护进程
 type Daemon struct {
 	config                *config.Config
@@ -39,11 +50,17 @@ type Daemon struct {
 	resourceMonitor       *agent.ResourceMonitor       // 资源监控器
 	logManager            *agent.LogManager            // 日志管理器
 	stateSyncer           *agent.StateSyncer           // Agent状态同步器
+	perfMetrics           *agent.PerformanceMetrics    // 性能指标(心跳、状态同步)
 	httpServer            *http.Server                 // HTTP服务器
 	grpcClient            *comm.GRPCClient
 	managerClient         *grpcclient.ManagerClient // Manager gRPC客户端(用于上报Agent状态)
+	credentials           *pki.Credentials          // 连接Manager的mTLS凭证(证书申请和自动续期)
+	spoolQueue            *spool.Queue              // Manager不可达时的本地缓存队列
+	spool                 *spool.Forwarder          // 按顺序补发本地缓存队列中的数据
 	grpcServer            *grpc.Server              // gRPC服务器
 	grpcListener          net.Listener              // gRPC监听器
+	localListener         net.Listener              // 本地Unix socket监听器(daemonctl)
+	auditClose            func() error              // 关闭gRPC审计日志
 	ctx                   context.Context
 	cancel                context.CancelFunc
 	wg                    sync.WaitGroup
@@ -64,6 +81,9 @@ func New(cfg *config.Config, logger *zap.Logger) (*Daemon, error) {
 	collectors := createCollectors(cfg, logger)
 	collectorMgr := collector.NewManager(collectors, logger)
 
+	// 创建性能指标收集器(通过HTTP /metrics 暴露)
+	perfMetrics := agent.NewPerformanceMetrics()
+
 	// 创建Agent管理器（支持新格式和旧格式）
 	var agentMgr *agent.Manager
 	var multiAgentMgr *agent.MultiAgentManager
@@ -99,23 +119,98 @@ func New(cfg *config.Config, logger *zap.Logger) (*Daemon, error) {
 		logger.Info("loaded agents from config",
 			zap.Int("count", multiAgentMgr.Count()))
 
-		// 创建日志管理器
+		// 创建日志管理器: 按保留天数清理过期日志，并限制所有Agent日志的总大小
 		logManager = agent.NewLogManager(cfg.Daemon.WorkDir, logger)
-		// TODO: 从配置读取日志保留天数（如果配置中有）
-		// logManager.SetRetentionDays(cfg.Log.RetentionDays)
+		logManager.SetRetentionDays(cfg.AgentLogs.RetentionDays)
+		logManager.SetDiskQuota(cfg.AgentLogs.MaxTotalSize, cfg.AgentLogs.QuotaCheckInterval)
 
 		// 为每个Agent实例设置日志轮转器
-		// 默认配置：最大100MB，保留7个文件，压缩旧文件
-		const defaultMaxSize = 100 * 1024 * 1024 // 100MB
-		const defaultMaxFiles = 7
+		// 使用Agent的log_rotation配置，未配置的项使用agent_defaults.log_rotation
+		rotationConfigs := make(map[string]config.LogRotationConfig, len(cfg.Agents))
+		for _, agentCfg := range cfg.Agents {
+			rotationConfigs[agentCfg.ID] = agentCfg.LogRotation
+		}
 		for _, instance := range multiAgentMgr.ListAgents() {
 			workDir := instance.GetInfo().WorkDir
 			if workDir == "" {
 				workDir = cfg.Daemon.WorkDir
 			}
 			logPath := fmt.Sprintf("%s/agents/%s/logs/agent.log", workDir, instance.GetInfo().ID)
-			rotator := agent.NewLogRotator(logPath, defaultMaxSize, defaultMaxFiles, logger)
+			rotation, ok := rotationConfigs[instance.GetInfo().ID]
+			if !ok {
+				rotation = cfg.AgentDefaults.LogRotation
+			}
+			rotator := agent.NewLogRotator(logPath, rotation.MaxSize, rotation.MaxFiles, logger)
+			rotator.SetPeriod(rotation.Period)
+			rotator.SetCopyTruncate(rotation.Mode == "copytruncate")
+			rotator.SetDateSuffix(rotation.DateSuffix)
 			instance.SetLogRotator(rotator)
+			logManager.AddLogDir(filepath.Dir(logPath))
+		}
+
+		// 启用cgroup时，每个Agent运行在父cgroup下独立的cgroup v2中
+		var cgroupManager *agent.CgroupManager
+		if cfg.Cgroup.Enabled {
+			cgroupManager, err = agent.NewCgroupManager(cfg.Cgroup.Root, cfg.Cgroup.Parent, logger)
+			if err != nil {
+				cancel()
+				return nil, fmt.Errorf("failed to initialize cgroup: %w", err)
+			}
+		}
+
+		// 启用心跳认证时，启动Agent时下发心跳密钥，心跳接收器拒绝伪造的心跳
+		var heartbeatAuth *agent.HeartbeatAuthenticator
+		if cfg.HeartbeatAuth.Mode != string(agent.HeartbeatAuthDisabled) {
+			heartbeatAuth, err = agent.NewHeartbeatAuthenticator(agent.HeartbeatAuthMode(cfg.HeartbeatAuth.Mode), cfg.HeartbeatAuth.KeyFile, cfg.HeartbeatAuth.MaxSkew)
+			if err != nil {
+				cancel()
+				return nil, fmt.Errorf("failed to initialize heartbeat authentication: %w", err)
+			}
+			multiAgentMgr.SetHeartbeatAuthenticator(heartbeatAuth)
+			logger.Info("agent heartbeat authentication enabled",
+				zap.String("mode", cfg.HeartbeatAuth.Mode),
+				zap.Duration("max_skew", cfg.HeartbeatAuth.MaxSkew))
+		}
+
+		// 为每个Agent实例设置重启策略(崩溃熔断)、生命周期配置(停止信号/超时/钩子)、
+		// 进程属性(运行用户/环境变量/umask/资源限制)、cgroup、启动依赖、就绪条件以及控制通道
+		multiAgentMgr.SetStartStagger(cfg.Daemon.AgentStartStagger)
+		for _, agentCfg := range cfg.Agents {
+			instance := multiAgentMgr.GetAgent(agentCfg.ID)
+			if instance == nil {
+				continue
+			}
+			instance.SetRestartPolicy(agent.NewRestartPolicy(&agentCfg.Restart))
+
+			lifecycle, err := agent.NewLifecycleConfig(&agentCfg)
+			if err != nil {
+				cancel()
+				return nil, fmt.Errorf("invalid lifecycle config for agent %s: %w", agentCfg.ID, err)
+			}
+			instance.SetLifecycle(lifecycle)
+
+			attrs, err := agent.NewProcessAttrs(&agentCfg)
+			if err != nil {
+				cancel()
+				return nil, fmt.Errorf("invalid process config for agent %s: %w", agentCfg.ID, err)
+			}
+			instance.SetProcessAttrs(attrs)
+			instance.SetDependencies(agentCfg.DependsOn)
+			instance.SetReadiness(&agentCfg.Readiness)
+
+			controlCfg, err := agent.NewControlConfig(&agentCfg)
+			if err != nil {
+				cancel()
+				return nil, fmt.Errorf("invalid control config for agent %s: %w", agentCfg.ID, err)
+			}
+			instance.SetControl(controlCfg)
+
+			if cgroupManager != nil {
+				instance.SetCgroup(cgroupManager.Agent(agentCfg.ID, agent.NewCgroupLimits(&agentCfg.Resources)))
+			} else if agentCfg.Resources != (config.ResourcesConfig{}) {
+				logger.Warn("agent resources configured but cgroup is disabled, limits will not be applied",
+					zap.String("agent_id", agentCfg.ID))
+			}
 		}
 
 		// 创建多Agent健康检查器
@@ -124,6 +219,10 @@ func New(cfg *config.Config, logger *zap.Logger) (*Daemon, error) {
 
 		// 创建HTTP心跳接收器(用于接收Agent心跳上报)
 		httpHeartbeatReceiver = agent.NewHTTPHeartbeatReceiver(multiAgentMgr, multiAgentMgr.GetRegistry(), logger)
+		httpHeartbeatReceiver.SetPerformanceMetrics(perfMetrics)
+		if heartbeatAuth != nil {
+			httpHeartbeatReceiver.SetAuthenticator(heartbeatAuth)
+		}
 
 		// 如果配置了 socket_path，也创建 Unix Socket 心跳接收器（向后兼容）
 		// 注意：在多Agent模式下，健康检查由 MultiHealthChecker 统一管理
@@ -136,9 +235,19 @@ func New(cfg *config.Config, logger *zap.Logger) (*Daemon, error) {
 			if multiAgentMgr != nil {
 				heartbeatReceiver.SetMultiAgentManager(multiAgentMgr)
 			}
+			if heartbeatAuth != nil {
+				heartbeatReceiver.SetAuthenticator(heartbeatAuth)
+			}
 			logger.Info("Unix Socket heartbeat receiver will be started for backward compatibility",
 				zap.String("socket_path", cfg.Agent.SocketPath))
 		}
+
+		// Agent启动时通过环境变量获取心跳上报地址
+		var heartbeatURL string
+		if cfg.Daemon.HTTPPort > 0 {
+			heartbeatURL = fmt.Sprintf("http://127.0.0.1:%d/heartbeat", cfg.Daemon.HTTPPort)
+		}
+		multiAgentMgr.SetHeartbeatEndpoints(heartbeatURL, cfg.Agent.SocketPath)
 	} else if cfg.Agent.BinaryPath != "" {
 		// 使用旧格式：单Agent Manager（向后兼容）
 		logger.Info("using legacy single-agent configuration")
@@ -164,6 +273,7 @@ func New(cfg *config.Config, logger *zap.Logger) (*Daemon, error) {
 			logger,
 		)
 		stateSyncer.SetManagerClient(managerClient)
+		stateSyncer.SetPerformanceMetrics(perfMetrics)
 
 		// 注册状态变化回调到MultiAgentManager
 		multiAgentMgr.SetStateChangeCallback(func(agentID string, status agent.AgentStatus, pid int, lastHeartbeat time.Time) {
@@ -171,11 +281,47 @@ func New(cfg *config.Config, logger *zap.Logger) (*Daemon, error) {
 				stateSyncer.OnAgentStateChange(agentID, status, pid, lastHeartbeat)
 			}
 		})
+
+		// 注册Agent事件和崩溃记录回调(熔断事件、崩溃记录上报Manager)
+		multiAgentMgr.SetEventCallback(stateSyncer.OnAgentEvent)
+		multiAgentMgr.SetCrashCallback(stateSyncer.OnAgentCrash)
+	}
+
+	// 创建mTLS凭证(配置了加入令牌或完整的证书文件时启用)，证书申请和续期后新连接自动使用新证书
+	var credentials *pki.Credentials
+	tlsFiles := cfg.Manager.TLS.CertFile != "" && cfg.Manager.TLS.KeyFile != "" && cfg.Manager.TLS.CAFile != ""
+	if cfg.Manager.Address != "" && (cfg.Manager.Enroll.Token != "" || tlsFiles) {
+		credentials = pki.NewCredentials(nodeID, &cfg.Manager.TLS, logger)
+		if err := credentials.Load(); err != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to load manager TLS credentials: %w", err)
+		}
+		grpcClient.SetCredentials(credentials.TransportCredentials())
+		if managerClient != nil {
+			managerClient.SetCredentials(credentials.TransportCredentials())
+		}
 	}
 
 	var resourceMonitor *agent.ResourceMonitor
 	if multiAgentMgr != nil {
 		resourceMonitor = agent.NewResourceMonitor(multiAgentMgr, multiAgentMgr.GetRegistry(), logger)
+		// 资源历史持久化到工作目录，daemon重启后GetAgentMetrics仍可查询历史数据
+		historyStore, err := agent.NewResourceHistoryStore(filepath.Join(cfg.Daemon.WorkDir, "resource_history"), agent.ResourceHistoryOptions{
+			Retention:         cfg.ResourceHistory.Retention,
+			SegmentDuration:   cfg.ResourceHistory.SegmentDuration,
+			CompactAfter:      cfg.ResourceHistory.CompactAfter,
+			CompactResolution: cfg.ResourceHistory.CompactResolution,
+		}, logger)
+		if err != nil {
+			logger.Warn("failed to open resource history store, resource history will not survive restarts",
+				zap.Error(err))
+		} else {
+			resourceMonitor.SetHistoryStore(historyStore)
+		}
+		// 资源告警上报Manager
+		if stateSyncer != nil {
+			resourceMonitor.SetAlertCallback(stateSyncer.OnResourceAlert)
+		}
 		// 从配置读取阈值配置(如果配置中有)
 		// 遍历所有Agent配置,设置资源阈值
 		for _, agentCfg := range cfg.Agents {
@@ -184,12 +330,22 @@ func New(cfg *config.Config, logger *zap.Logger) (*Daemon, error) {
 					CPUThreshold:      agentCfg.HealthCheck.CPUThreshold,
 					MemoryThreshold:   agentCfg.HealthCheck.MemoryThreshold,
 					ThresholdDuration: agentCfg.HealthCheck.ThresholdDuration,
+					Action:            agent.ResourceAlertAction(agentCfg.HealthCheck.ThresholdAction),
 				}
 				resourceMonitor.SetThreshold(agentCfg.ID, threshold)
 			}
 		}
 	}
 
+	// 创建本地缓存队列(Manager不可达时缓存指标、Agent状态和事件，连接恢复后按顺序补发)
+	spoolQueue, forwarder := openSpool(cfg, logger)
+	if forwarder != nil {
+		forwarder.Register(spoolKindMetrics, 0, metricsSender(grpcClient, logger))
+		if stateSyncer != nil {
+			stateSyncer.SetSpool(forwarder)
+		}
+	}
+
 	d := &Daemon{
 		config:                cfg,
 		logger:                logger,
@@ -204,8 +360,12 @@ func New(cfg *config.Config, logger *zap.Logger) (*Daemon, error) {
 		resourceMonitor:       resourceMonitor,
 		logManager:            logManager,
 		stateSyncer:           stateSyncer,
+		perfMetrics:           perfMetrics,
 		grpcClient:            grpcClient,
 		managerClient:         managerClient,
+		credentials:           credentials,
+		spoolQueue:            spoolQueue,
+		spool:                 forwarder,
 		ctx:                   ctx,
 		cancel:                cancel,
 	}
@@ -233,15 +393,44 @@ func New(cfg *config.Config, logger *zap.Logger) (*Daemon, error) {
 			PermitWithoutStream: true,             // 允许无流时发送ping
 		}
 
+		// 创建授权器，变更操作和被拒绝的调用写入审计日志
+		insecureAccess, err := grpcclient.ParseAccessLevel(cfg.GRPCAuth.InsecureAccess)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		auditLogger, auditClose, err := logpkg.NewAuditLogger(cfg.GRPCAuth.AuditLog)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		d.auditClose = auditClose
+		authz := grpcclient.NewAuthorizer(grpcclient.AuthorizerOptions{
+			InsecureAccess: insecureAccess,
+			LocalAdminUIDs: cfg.GRPCAuth.SocketAdminUIDs,
+			LocalReadUIDs:  cfg.GRPCAuth.SocketReadUIDs,
+		}, auditLogger)
+
+		// 启用mTLS凭证时TCP端口要求Manager证书，否则为明文连接(按insecure_access授权)
+		var serverTLS *tls.Config
+		if credentials != nil {
+			serverTLS = credentials.ServerTLSConfig()
+		} else {
+			logger.Warn("daemon gRPC port is not protected by mTLS, configure manager.tls or manager.enroll to require manager certificates",
+				zap.String("insecure_access", cfg.GRPCAuth.InsecureAccess))
+		}
+
 		// 创建gRPC服务器
 		d.grpcServer = grpc.NewServer(
+			grpc.Creds(grpcclient.NewServerCredentials(serverTLS)),
 			grpc.KeepaliveParams(keepaliveParams),
 			grpc.KeepaliveEnforcementPolicy(keepaliveEnforcementPolicy),
 			grpc.MaxRecvMsgSize(10*1024*1024), // 10MB 最大接收消息
 			grpc.MaxSendMsgSize(10*1024*1024), // 10MB 最大发送消息
 			grpc.InitialWindowSize(1<<20),     // 1MB 初始窗口
 			grpc.InitialConnWindowSize(1<<20), // 1MB 连接窗口
-			grpc.UnaryInterceptor(grpcclient.UnaryServerInterceptor(d.logger)),
+			grpc.UnaryInterceptor(grpcclient.UnaryServerInterceptor(d.logger, authz)),
+			grpc.StreamInterceptor(grpcclient.StreamServerInterceptor(d.logger, authz)),
 		)
 
 		// 注册服务
@@ -257,8 +446,21 @@ func New(cfg *config.Config, logger *zap.Logger) (*Daemon, error) {
 		}
 		d.grpcListener = listener
 
+		// 本地Unix socket(daemonctl)，按对端uid授权
+		if cfg.GRPCAuth.SocketPath != "-" {
+			localListener, err := grpcclient.ListenLocalSocket(cfg.GRPCAuth.SocketPath)
+			if err != nil {
+				listener.Close()
+				cancel()
+				return nil, fmt.Errorf("failed to create local gRPC socket: %w", err)
+			}
+			d.localListener = localListener
+		}
+
 		logger.Info("gRPC server initialized",
-			zap.Int("port", grpcPort))
+			zap.Int("port", grpcPort),
+			zap.Bool("mtls", serverTLS != nil),
+			zap.String("socket", cfg.GRPCAuth.SocketPath))
 	}
 
 	return d, nil
@@ -316,6 +518,28 @@ func (d *Daemon) Start() error {
 			}
 		}()
 	}
+	if d.grpcServer != nil && d.localListener != nil {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			d.logger.Info("gRPC local socket starting",
+				zap.String("path", d.config.GRPCAuth.SocketPath))
+			if err := d.grpcServer.Serve(d.localListener); err != nil {
+				d.logger.Error("gRPC local socket error",
+					zap.Error(err))
+			}
+		}()
+	}
+
+	// 10. 启动控制流（Daemon主动连接Manager，用于NAT或只允许出站连接的节点）
+	if d.config.Manager.ControlStream && d.managerClient != nil && d.grpcServer != nil {
+		tunnelClient := grpcclient.NewTunnelClient(d.managerClient, d.grpcServer, d.nodeID, d.config.Manager.ReconnectInterval, d.logger)
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			tunnelClient.Run(d.ctx)
+		}()
+	}
 
 	// 9. 启动后台任务（如果配置了Manager）
 	if d.config.Manager.Address != "" {
@@ -324,6 +548,24 @@ func (d *Daemon) Start() error {
 		go d.reportMetricsLoop()
 	}
 
+	// 启动本地缓存队列补发（如果启用）
+	if d.spool != nil {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			d.spool.Run(d.ctx)
+		}()
+	}
+
+	// 11. 启动客户端证书自动续期
+	if d.credentials != nil {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			d.credentials.RunRenewal(d.ctx, certRenewalCheckInterval, d.grpcClient.RenewCertificate)
+		}()
+	}
+
 	d.logger.Info("daemon started successfully")
 
 	return nil
@@ -382,14 +624,26 @@ func (d *Daemon) Stop() {
 		if d.grpcListener != nil {
 			d.grpcListener.Close()
 		}
+		if d.localListener != nil {
+			d.localListener.Close()
+			os.Remove(d.config.GRPCAuth.SocketPath)
+		}
+		if d.auditClose != nil {
+			d.auditClose()
+		}
 		d.logger.Info("gRPC server stopped")
 	}
 
 	// 停止Agent进程
-	if d.multiAgentManager != nil {
+	if d.multiAgentManager != nil && d.config.Daemon.KeepAgentsRunning {
+		// 保留Agent继续运行，daemon重启后通过持久化的进程状态重新接管
+		d.logger.Info("agents will continue running after daemon stops",
+			zap.Int("count", d.multiAgentManager.Count()))
+	} else if d.multiAgentManager != nil {
 		// 新格式：停止所有Agent
+		// d.ctx已取消，使用新的上下文以便Agent按停止超时优雅退出
 		d.logger.Info("stopping all agents", zap.Int("count", d.multiAgentManager.Count()))
-		results := d.multiAgentManager.StopAll(d.ctx, true)
+		results := d.multiAgentManager.StopAll(context.Background(), true)
 		successCount := 0
 		for agentID, err := range results {
 			if err != nil {
@@ -419,6 +673,13 @@ func (d *Daemon) Stop() {
 		}
 	}
 
+	// 关闭本地缓存队列(未发送的数据保留在磁盘，下次启动后补发)
+	if d.spoolQueue != nil {
+		if err := d.spoolQueue.Close(); err != nil {
+			d.logger.Error("failed to close spool", zap.Error(err))
+		}
+	}
+
 	// 删除PID文件
 	os.Remove(d.config.Daemon.PIDFile)
 
@@ -431,6 +692,13 @@ func (d *Daemon) connectAndRegister() error {
 	ctx, cancel := context.WithTimeout(d.ctx, 30*time.Second)
 	defer cancel()
 
+	// 尚无客户端证书时凭加入令牌申请
+	if d.credentials != nil && !d.credentials.Ready() {
+		if err := d.credentials.Enroll(ctx, d.config.Manager.Address, d.config.Manager.Enroll.Token, d.config.Manager.Enroll.CACertHash); err != nil {
+			return fmt.Errorf("failed to enroll client certificate: %w", err)
+		}
+	}
+
 	if err := d.grpcClient.Connect(ctx); err != nil {
 		return err
 	}
@@ -438,15 +706,16 @@ func (d *Daemon) connectAndRegister() error {
 	// 注册节点
 	hostname, _ := os.Hostname()
 	nodeInfo := &types.NodeInfo{
-		NodeID:     d.nodeID,
-		Hostname:   hostname,
-		IP:         d.getLocalIP(),
-		OS:         d.getOS(),
-		Arch:       d.getArch(),
-		Labels:     make(map[string]string),
-		DaemonVer:  version.GetVersion(),
-		AgentVer:   "", // Agent版本从Agent上报获取
-		RegisterAt: time.Now(),
+		NodeID:      d.nodeID,
+		Hostname:    hostname,
+		IP:          d.getLocalIP(),
+		OS:          d.getOS(),
+		Arch:        d.getArch(),
+		Labels:      make(map[string]string),
+		DaemonVer:   version.GetVersion(),
+		AgentVer:    "", // Agent版本从Agent上报获取
+		Fingerprint: hostFingerprint(hostname),
+		RegisterAt:  time.Now(),
 	}
 
 	nodeID, err := d.grpcClient.Register(ctx, d.nodeID, nodeInfo)
@@ -457,6 +726,11 @@ func (d *Daemon) connectAndRegister() error {
 	d.nodeID = nodeID
 	d.logger.Info("registered to manager", zap.String("node_id", d.nodeID))
 
+	// 连接恢复后立即补发缓存的数据
+	if d.spool != nil {
+		d.spool.Wake()
+	}
+
 	return nil
 }
 
@@ -507,11 +781,14 @@ func (d *Daemon) reportMetricsLoop() {
 			return
 		case <-ticker.C:
 			// 检查连接状态，如果未连接则尝试重连并注册
+			// 启用本地缓存时未连接也继续采集，指标写入队列待连接恢复后补发
 			if d.grpcClient.GetNodeID() == "" || !d.grpcClient.IsConnected() {
 				d.logger.Warn("gRPC client not connected for metrics report, attempting to reconnect and register")
 				if err := d.connectAndRegister(); err != nil {
 					d.logger.Error("failed to reconnect and register for metrics", zap.Error(err))
-					continue
+					if d.spool == nil {
+						continue
+					}
 				}
 			}
 
@@ -520,6 +797,13 @@ func (d *Daemon) reportMetricsLoop() {
 				continue
 			}
 
+			if d.spool != nil {
+				if err := d.spoolMetrics(metrics); err != nil {
+					d.logger.Error("failed to spool metrics", zap.Error(err))
+				}
+				continue
+			}
+
 			ctx, cancel := context.WithTimeout(d.ctx, 10*time.Second)
 			if err := d.grpcClient.ReportMetrics(ctx, metrics); err != nil {
 				d.logger.Error("failed to report metrics", zap.Error(err))
@@ -590,7 +874,30 @@ func (d *Daemon) getArch() string {
 	return "amd64"
 }
 
-// startHTTPServer 启动HTTP服务器(用于接收Agent心跳)
+// machineIDFiles 机器ID文件(systemd/dbus)，按顺序读取第一个存在的文件
+var machineIDFiles = []string{"/etc/machine-id", "/var/lib/dbus/machine-id"}
+
+// hostFingerprint 计算主机指纹(机器ID和主机名的SHA-256)
+// 复制了工作目录(node_id文件)的其他主机指纹不同，Manager据此隔离冲突的注册
+func hostFingerprint(hostname string) string {
+	machineID := ""
+	for _, file := range machineIDFiles {
+		if data, err := os.ReadFile(file); err == nil {
+			machineID = strings.TrimSpace(string(data))
+			if machineID != "" {
+				break
+			}
+		}
+	}
+	if machineID == "" && hostname == "" {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(machineID + "\n" + hostname))
+	return hex.EncodeToString(sum[:])
+}
+
+// startHTTPServer 启动HTTP服务器(用于接收Agent心跳和暴露Prometheus指标)
 // 注意：此方法仅在 HTTPPort > 0 时被调用
 func (d *Daemon) startHTTPServer() error {
 	// 检查端口配置
@@ -608,6 +915,9 @@ func (d *Daemon) startHTTPServer() error {
 	// 注册统计信息路由(可选)
 	mux.HandleFunc("/heartbeat/stats", d.httpHeartbeatReceiver.HandleStats)
 
+	// 注册Prometheus指标路由
+	mux.HandleFunc("/metrics", d.handleMetrics)
+
 	// 创建HTTP服务器
 	addr := fmt.Sprintf(":%d", httpPort)
 	d.httpServer = &http.Server{
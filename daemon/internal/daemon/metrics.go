@@ -0,0 +1,269 @@
+package daemon
+
+import (
+	"bytes"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/bingooyong/ops-scaffold-framework/daemon/internal/metrics"
+	"github.com/bingooyong/ops-scaffold-framework/daemon/internal/spool"
+	"github.com/bingooyong/ops-scaffold-framework/daemon/internal/version"
+	"go.uber.org/zap"
+)
+
+// handleMetrics 以Prometheus文本格式输出Daemon指标
+// GET /metrics
+func (d *Daemon) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var buf bytes.Buffer
+	d.writeMetrics(metrics.NewWriter(&buf), time.Now())
+
+	w.Header().Set("Content-Type", metrics.ContentType)
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		d.logger.Debug("failed to write metrics response", zap.Error(err))
+	}
+}
+
+// writeMetrics 写入所有Daemon指标
+func (d *Daemon) writeMetrics(pw *metrics.Writer, now time.Time) {
+	pw.Gauge("daemon_info", "Daemon build information.", 1,
+		"node_id", d.nodeID, "version", version.GetVersion(), "git_commit", version.GetGitCommit())
+
+	d.writeAgentMetrics(pw, now)
+	d.writeHealthCheckMetrics(pw)
+	d.writeHeartbeatMetrics(pw)
+	d.writeStateSyncMetrics(pw)
+	d.writeSpoolMetrics(pw, now)
+	d.writeHostMetrics(pw)
+}
+
+// writeAgentMetrics 写入每个Agent的状态和资源指标
+func (d *Daemon) writeAgentMetrics(pw *metrics.Writer, now time.Time) {
+	if d.multiAgentManager == nil {
+		return
+	}
+
+	statuses := d.multiAgentManager.GetAllAgentStatus()
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].ID < statuses[j].ID })
+
+	for _, st := range statuses {
+		up := 0.0
+		if st.IsRunning {
+			up = 1
+		}
+		pw.Gauge("daemon_agent_up", "Whether the agent process is running (1) or not (0).", up,
+			"agent_id", st.ID, "type", string(st.Type))
+	}
+	for _, st := range statuses {
+		pw.Gauge("daemon_agent_status", "Current agent status, the status label carries the value.", 1,
+			"agent_id", st.ID, "status", string(st.Status))
+	}
+	for _, st := range statuses {
+		pw.Gauge("daemon_agent_restart_count", "Number of restarts since the restart counter was last reset.", float64(st.RestartCount),
+			"agent_id", st.ID)
+	}
+	for _, st := range statuses {
+		circuitOpen := 0.0
+		if st.CircuitOpen {
+			circuitOpen = 1
+		}
+		pw.Gauge("daemon_agent_circuit_open", "Whether the agent restart circuit breaker is open.", circuitOpen,
+			"agent_id", st.ID)
+	}
+	for _, st := range statuses {
+		metadata, err := d.multiAgentManager.GetAgentMetadata(st.ID)
+		if err != nil || metadata.LastHeartbeat.IsZero() {
+			continue
+		}
+		pw.Gauge("daemon_agent_last_heartbeat_age_seconds", "Seconds since the last heartbeat received from the agent.", now.Sub(metadata.LastHeartbeat).Seconds(),
+			"agent_id", st.ID)
+	}
+	pw.Header("daemon_agent_custom_metric", "Custom gauge reported by the agent in its latest heartbeat.", metrics.TypeGauge)
+	for _, st := range statuses {
+		metadata, err := d.multiAgentManager.GetAgentMetadata(st.ID)
+		if err != nil || len(metadata.CustomMetrics) == 0 {
+			continue
+		}
+		names := make([]string, 0, len(metadata.CustomMetrics))
+		for name := range metadata.CustomMetrics {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			pw.Sample("daemon_agent_custom_metric", metadata.CustomMetrics[name],
+				"agent_id", st.ID, "name", name)
+		}
+	}
+
+	if d.resourceMonitor == nil {
+		return
+	}
+	latest := d.resourceMonitor.GetLatestResources()
+	agentIDs := make([]string, 0, len(latest))
+	for agentID := range latest {
+		agentIDs = append(agentIDs, agentID)
+	}
+	sort.Strings(agentIDs)
+
+	for _, agentID := range agentIDs {
+		pw.Gauge("daemon_agent_cpu_percent", "Agent process CPU usage in percent at the last resource collection.", latest[agentID].CPU,
+			"agent_id", agentID)
+	}
+	for _, agentID := range agentIDs {
+		pw.Gauge("daemon_agent_memory_rss_bytes", "Agent process resident memory in bytes at the last resource collection.", float64(latest[agentID].MemoryRSS),
+			"agent_id", agentID)
+	}
+	for _, agentID := range agentIDs {
+		pw.Gauge("daemon_agent_open_fds", "Agent process open file descriptors at the last resource collection.", float64(latest[agentID].OpenFiles),
+			"agent_id", agentID)
+	}
+}
+
+// writeHealthCheckMetrics 写入健康检查结果指标
+func (d *Daemon) writeHealthCheckMetrics(pw *metrics.Writer) {
+	if d.multiHealthChecker == nil {
+		return
+	}
+
+	statuses := d.multiHealthChecker.GetAllHealthStatuses()
+	agentIDs := make([]string, 0, len(statuses))
+	for agentID := range statuses {
+		agentIDs = append(agentIDs, agentID)
+	}
+	sort.Strings(agentIDs)
+	for _, agentID := range agentIDs {
+		pw.Gauge("daemon_agent_health_status", "Result of the latest health check, the status label carries the value.", 1,
+			"agent_id", agentID, "status", statuses[agentID].GetStatus().String())
+	}
+
+	counts := d.multiHealthChecker.GetCheckCounts()
+	agentIDs = agentIDs[:0]
+	for agentID := range counts {
+		agentIDs = append(agentIDs, agentID)
+	}
+	sort.Strings(agentIDs)
+	pw.Header("daemon_agent_health_checks_total", "Total health checks by result.", metrics.TypeCounter)
+	for _, agentID := range agentIDs {
+		results := make([]string, 0, len(counts[agentID]))
+		values := make(map[string]uint64, len(counts[agentID]))
+		for status, count := range counts[agentID] {
+			results = append(results, status.String())
+			values[status.String()] = count
+		}
+		sort.Strings(results)
+		for _, result := range results {
+			pw.Sample("daemon_agent_health_checks_total", float64(values[result]),
+				"agent_id", agentID, "result", result)
+		}
+	}
+}
+
+// writeHeartbeatMetrics 写入心跳接收指标
+func (d *Daemon) writeHeartbeatMetrics(pw *metrics.Writer) {
+	if d.httpHeartbeatReceiver != nil {
+		stats := d.httpHeartbeatReceiver.GetStats()
+		pw.Counter("daemon_heartbeats_received_total", "Total heartbeats accepted by the HTTP heartbeat endpoint.", float64(stats.TotalReceived))
+		pw.Counter("daemon_heartbeats_processed_total", "Total heartbeats processed successfully.", float64(stats.TotalProcessed))
+		pw.Counter("daemon_heartbeats_errors_total", "Total heartbeats that failed to be processed.", float64(stats.TotalErrors))
+		pw.Counter("daemon_heartbeats_dropped_total", "Total heartbeats dropped because the worker pool was full.", float64(stats.TotalDropped))
+		pw.Counter("daemon_heartbeats_rejected_total", "Total heartbeats rejected because signature authentication failed.", float64(stats.TotalRejected))
+	}
+	if d.perfMetrics != nil {
+		pw.Histogram("daemon_heartbeat_processing_seconds", "Heartbeat processing latency in seconds.", d.perfMetrics.HeartbeatLatencyHistogram())
+	}
+}
+
+// writeStateSyncMetrics 写入状态同步指标
+func (d *Daemon) writeStateSyncMetrics(pw *metrics.Writer) {
+	if d.perfMetrics == nil {
+		return
+	}
+	pw.Header("daemon_state_sync_total", "Total agent state syncs to the manager by result.", metrics.TypeCounter)
+	pw.Sample("daemon_state_sync_total", float64(d.perfMetrics.StateSyncSuccess.Load()), "result", "success")
+	pw.Sample("daemon_state_sync_total", float64(d.perfMetrics.StateSyncFailed.Load()), "result", "failure")
+	pw.Histogram("daemon_state_sync_duration_seconds", "Agent state sync latency in seconds.", d.perfMetrics.StateSyncLatencyHistogram())
+}
+
+// writeSpoolMetrics 写入本地缓存队列指标(积压量、丢弃数和补发进度)
+func (d *Daemon) writeSpoolMetrics(pw *metrics.Writer, now time.Time) {
+	if d.spool == nil {
+		return
+	}
+
+	stats := d.spool.Stats()
+	pw.Gauge("daemon_spool_records", "Records buffered in the spool waiting to be sent to the manager.", float64(stats.Queue.Records))
+	pw.Gauge("daemon_spool_bytes", "Disk space used by records buffered in the spool.", float64(stats.Queue.Bytes))
+	oldestAge := 0.0
+	if !stats.Queue.OldestTime.IsZero() {
+		oldestAge = now.Sub(stats.Queue.OldestTime).Seconds()
+	}
+	pw.Gauge("daemon_spool_oldest_record_age_seconds", "Age of the oldest record buffered in the spool.", oldestAge)
+
+	writeSortedCounter(pw, "daemon_spool_enqueued_total", "Total records written to the spool by kind.", "kind", stats.Enqueued)
+	writeSortedCounter(pw, "daemon_spool_sent_total", "Total spooled records delivered to the manager by kind.", "kind", stats.Sent)
+	dropped := stats.Queue.Dropped
+	for _, reason := range []string{spool.DropReasonSize, spool.DropReasonAge, spool.DropReasonCorrupt, spool.DropReasonUnknown} {
+		if _, ok := dropped[reason]; !ok {
+			dropped[reason] = 0
+		}
+	}
+	writeSortedCounter(pw, "daemon_spool_dropped_total", "Total spooled records dropped before delivery by reason.", "reason", dropped)
+	pw.Counter("daemon_spool_send_failures_total", "Total failed attempts to deliver spooled records.", float64(stats.SendFailures))
+}
+
+// writeSortedCounter 按标签值排序写入一组counter
+func writeSortedCounter(pw *metrics.Writer, name, help, label string, values map[string]uint64) {
+	if len(values) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pw.Header(name, help, metrics.TypeCounter)
+	for _, key := range keys {
+		pw.Sample(name, float64(values[key]), label, key)
+	}
+}
+
+// writeHostMetrics 写入采集器采集的主机指标(每个数值字段一个gauge)
+func (d *Daemon) writeHostMetrics(pw *metrics.Writer) {
+	if d.collectorManager == nil {
+		return
+	}
+
+	latest := d.collectorManager.GetLatest()
+	names := make([]string, 0, len(latest))
+	for name := range latest {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		m := latest[name]
+		if m == nil {
+			continue
+		}
+		keys := make([]string, 0, len(m.Values))
+		for key := range m.Values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			value, ok := metrics.ToFloat(m.Values[key])
+			if !ok {
+				continue
+			}
+			metricName := "daemon_host_" + metrics.SanitizeName(name) + "_" + metrics.SanitizeName(key)
+			pw.Gauge(metricName, "Host metric "+key+" reported by the "+name+" collector.", value)
+		}
+	}
+}
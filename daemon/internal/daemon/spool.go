@@ -0,0 +1,100 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bingooyong/ops-scaffold-framework/daemon/internal/comm"
+	"github.com/bingooyong/ops-scaffold-framework/daemon/internal/config"
+	"github.com/bingooyong/ops-scaffold-framework/daemon/internal/spool"
+	managerpb "github.com/bingooyong/ops-scaffold-framework/daemon/pkg/proto/manager"
+	"github.com/bingooyong/ops-scaffold-framework/daemon/pkg/types"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+// spoolKindMetrics 本地缓存队列中的主机指标上报记录
+const spoolKindMetrics = "metrics"
+
+// openSpool 打开Manager不可达时使用的本地缓存队列
+// 未配置Manager地址或禁用缓存时返回nil，打开失败时降级为直接上报
+func openSpool(cfg *config.Config, logger *zap.Logger) (*spool.Queue, *spool.Forwarder) {
+	if cfg.Manager.Address == "" || cfg.Spool.Disabled || cfg.Spool.Dir == "" {
+		return nil, nil
+	}
+
+	queue, err := spool.Open(cfg.Spool.Dir, spool.Options{
+		MaxBytes:     cfg.Spool.MaxBytes,
+		MaxAge:       cfg.Spool.MaxAge,
+		SegmentBytes: cfg.Spool.SegmentBytes,
+	}, logger.Named("spool"))
+	if err != nil {
+		logger.Warn("failed to open spool, data will be dropped while manager is unreachable",
+			zap.String("dir", cfg.Spool.Dir),
+			zap.Error(err))
+		return nil, nil
+	}
+
+	if stats := queue.Stats(); stats.Records > 0 {
+		logger.Info("spool has pending records from previous run, will replay when manager is reachable",
+			zap.Int("records", stats.Records),
+			zap.Int64("bytes", stats.Bytes),
+			zap.Time("oldest", stats.OldestTime))
+	}
+
+	return queue, spool.NewForwarder(queue, cfg.Manager.ReconnectInterval, logger.Named("spool"))
+}
+
+// metricsSender 返回发送缓存的主机指标的发送器
+// 连续的多条指标记录合并为一次上报，每个指标保留采集时的时间戳
+// 批量格式中相同序列的多个时间点合并到同一条序列
+func metricsSender(client *comm.GRPCClient, logger *zap.Logger) spool.SendFunc {
+	return func(ctx context.Context, records []spool.Record) error {
+		req := &managerpb.ReportMetricsRequest{}
+		decoded := 0
+		for _, rec := range records {
+			var report managerpb.ReportMetricsRequest
+			if err := proto.Unmarshal(rec.Data, &report); err != nil {
+				logger.Warn("dropping undecodable spooled metrics report", zap.Error(err))
+				continue
+			}
+			decoded++
+			req.Metrics = append(req.Metrics, report.Metrics...)
+			if report.Batch != nil {
+				if req.Batch == nil {
+					req.Batch = &managerpb.MetricsBatch{Version: report.Batch.Version}
+				}
+				comm.MergeMetricsBatch(req.Batch, report.Batch)
+			}
+		}
+		if decoded == 0 {
+			return fmt.Errorf("all %d spooled metrics reports are undecodable: %w", len(records), spool.ErrPermanent)
+		}
+		if len(req.Metrics) == 0 && len(req.GetBatch().GetSeries()) == 0 {
+			return nil
+		}
+		return client.SendMetricsReport(ctx, req)
+	}
+}
+
+// spoolMetrics 将采集的主机指标写入本地缓存队列
+func (d *Daemon) spoolMetrics(metrics map[string]*types.Metrics) error {
+	data, err := proto.Marshal(d.grpcClient.BuildMetricsReport(metrics))
+	if err != nil {
+		return fmt.Errorf("failed to encode metrics report: %w", err)
+	}
+
+	// 记录时间取最近一次采集时间
+	var collectedAt time.Time
+	for _, m := range metrics {
+		if m.Timestamp.After(collectedAt) {
+			collectedAt = m.Timestamp
+		}
+	}
+	if collectedAt.IsZero() {
+		collectedAt = time.Now()
+	}
+
+	return d.spool.Enqueue(spoolKindMetrics, collectedAt, data)
+}
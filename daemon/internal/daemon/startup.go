@@ -19,7 +19,10 @@ func (d *Daemon) startMultiAgentMode() error {
 		d.logger.Error("failed to create agents work directory", zap.Error(err))
 	}
 
-	// 启动所有Agent
+	// 接管daemon重启前启动且仍在运行的Agent，避免重复启动
+	d.multiAgentManager.AdoptRunningAgents(d.ctx)
+
+	// 启动所有Agent(已接管的Agent会被跳过)
 	results := d.multiAgentManager.StartAll(d.ctx)
 	successCount := 0
 	for agentID, err := range results {
@@ -0,0 +1,346 @@
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/bingooyong/ops-scaffold-framework/daemon/pkg/proto"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// AccessLevel 调用权限级别
+type AccessLevel int
+
+const (
+	// AccessNone 无权限
+	AccessNone AccessLevel = iota
+	// AccessRead 只读(查询Agent、指标、日志和崩溃记录)
+	AccessRead
+	// AccessMutate 变更(启停、控制和更新Agent)
+	AccessMutate
+)
+
+// String 返回权限级别名称
+func (l AccessLevel) String() string {
+	switch l {
+	case AccessRead:
+		return "read"
+	case AccessMutate:
+		return "mutate"
+	default:
+		return "none"
+	}
+}
+
+// ParseAccessLevel 解析权限级别名称(none/read/mutate)
+func ParseAccessLevel(s string) (AccessLevel, error) {
+	switch s {
+	case "none":
+		return AccessNone, nil
+	case "read":
+		return AccessRead, nil
+	case "mutate":
+		return AccessMutate, nil
+	default:
+		return AccessNone, fmt.Errorf("invalid access level: %s (must be none, read or mutate)", s)
+	}
+}
+
+// readOnlyMethods 只读方法，其余方法(包括未实现的方法)均视为变更操作
+var readOnlyMethods = map[string]bool{
+	proto.DaemonService_ListAgents_FullMethodName:      true,
+	proto.DaemonService_GetAgentMetrics_FullMethodName: true,
+	proto.DaemonService_GetCrashReports_FullMethodName: true,
+	proto.DaemonService_TailAgentLogs_FullMethodName:   true,
+	proto.DaemonService_SearchAgentLogs_FullMethodName: true,
+	proto.DaemonService_FollowAgentLogs_FullMethodName: true,
+	proto.DaemonService_SyncAgentStates_FullMethodName: true,
+}
+
+// MethodAccess 返回调用方法所需的权限级别
+func MethodAccess(fullMethod string) AccessLevel {
+	if readOnlyMethods[fullMethod] {
+		return AccessRead
+	}
+	return AccessMutate
+}
+
+// 调用者类型
+const (
+	// CallerManager 出示CA签发的Manager证书的TCP连接
+	CallerManager = "manager"
+	// CallerTunnel Manager经控制流转发的调用
+	CallerTunnel = "tunnel"
+	// CallerLocal 本地Unix socket连接(daemonctl)
+	CallerLocal = "local"
+	// CallerAnonymous 未启用mTLS的TCP连接
+	CallerAnonymous = "anonymous"
+)
+
+// Caller 调用者身份
+type Caller struct {
+	Kind string // manager/tunnel/local/anonymous
+	Name string // Manager证书CN、本地调用者uid或远端地址
+	UID  int    // 本地调用者uid(仅local)
+}
+
+// String 返回用于日志的调用者描述
+func (c Caller) String() string {
+	if c.Name == "" {
+		return c.Kind
+	}
+	return c.Kind + ":" + c.Name
+}
+
+// localAuthInfo 本地Unix socket连接的认证信息
+type localAuthInfo struct {
+	credentials.CommonAuthInfo
+	uid int
+}
+
+func (localAuthInfo) AuthType() string { return "local" }
+
+// tunnelAuthInfo 控制流连接的认证信息(控制流本身由Daemon以mTLS连接Manager建立)
+type tunnelAuthInfo struct {
+	credentials.CommonAuthInfo
+}
+
+func (tunnelAuthInfo) AuthType() string { return "tunnel" }
+
+// insecureAuthInfo 未加密TCP连接的认证信息
+type insecureAuthInfo struct {
+	credentials.CommonAuthInfo
+}
+
+func (insecureAuthInfo) AuthType() string { return "insecure" }
+
+// ServerCredentials Daemon gRPC服务端传输凭证
+// 根据连接类型确定调用者身份：控制流内存连接视为Manager，Unix socket连接读取对端uid，
+// TCP连接在配置了TLS时要求mTLS，否则为匿名明文连接
+type ServerCredentials struct {
+	tls credentials.TransportCredentials
+}
+
+// NewServerCredentials 创建服务端传输凭证，tlsConfig为nil时TCP连接不加密
+func NewServerCredentials(tlsConfig *tls.Config) *ServerCredentials {
+	c := &ServerCredentials{}
+	if tlsConfig != nil {
+		c.tls = credentials.NewTLS(tlsConfig)
+	}
+	return c
+}
+
+// ClientHandshake 仅用于服务端
+func (c *ServerCredentials) ClientHandshake(ctx context.Context, authority string, conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return nil, nil, errors.New("daemon server credentials do not support client handshakes")
+}
+
+// ServerHandshake 按连接类型完成握手并返回调用者认证信息
+func (c *ServerCredentials) ServerHandshake(conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	switch raw := conn.(type) {
+	case *tunnelConn:
+		return conn, tunnelAuthInfo{CommonAuthInfo: credentials.CommonAuthInfo{SecurityLevel: credentials.PrivacyAndIntegrity}}, nil
+	case *net.UnixConn:
+		uid, err := peerUID(raw)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get unix socket peer credentials: %w", err)
+		}
+		return conn, localAuthInfo{CommonAuthInfo: credentials.CommonAuthInfo{SecurityLevel: credentials.PrivacyAndIntegrity}, uid: uid}, nil
+	}
+	if c.tls != nil {
+		return c.tls.ServerHandshake(conn)
+	}
+	return conn, insecureAuthInfo{CommonAuthInfo: credentials.CommonAuthInfo{SecurityLevel: credentials.NoSecurity}}, nil
+}
+
+// Info 返回协议信息
+func (c *ServerCredentials) Info() credentials.ProtocolInfo {
+	if c.tls != nil {
+		return c.tls.Info()
+	}
+	return credentials.ProtocolInfo{SecurityProtocol: "insecure"}
+}
+
+// Clone 复制凭证
+func (c *ServerCredentials) Clone() credentials.TransportCredentials {
+	return &ServerCredentials{tls: c.tls}
+}
+
+// OverrideServerName 仅用于客户端，服务端忽略
+func (c *ServerCredentials) OverrideServerName(string) error {
+	return nil
+}
+
+// tunnelListener 控制流内存监听器，标记接受的连接以便识别为Manager调用
+type tunnelListener struct {
+	net.Listener
+}
+
+func (l *tunnelListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &tunnelConn{Conn: conn}, nil
+}
+
+// tunnelConn 控制流内存连接
+type tunnelConn struct {
+	net.Conn
+}
+
+// AuthorizerOptions 授权策略配置
+type AuthorizerOptions struct {
+	// InsecureAccess 未启用mTLS时TCP调用者的权限
+	InsecureAccess AccessLevel
+	// LocalAdminUIDs 本地socket允许执行变更操作的uid(root和Daemon自身uid始终允许)
+	LocalAdminUIDs []int
+	// LocalReadUIDs 本地socket只允许只读操作的uid
+	LocalReadUIDs []int
+}
+
+// Authorizer 按调用者身份和方法执行授权，并记录变更操作的审计日志
+type Authorizer struct {
+	insecureAccess AccessLevel
+	localAccess    map[int]AccessLevel
+	audit          *zap.Logger
+}
+
+// NewAuthorizer 创建授权器，audit为审计日志(为nil时不记录)
+func NewAuthorizer(opts AuthorizerOptions, audit *zap.Logger) *Authorizer {
+	localAccess := map[int]AccessLevel{
+		0:            AccessMutate,
+		os.Geteuid(): AccessMutate,
+	}
+	for _, uid := range opts.LocalReadUIDs {
+		if _, ok := localAccess[uid]; !ok {
+			localAccess[uid] = AccessRead
+		}
+	}
+	for _, uid := range opts.LocalAdminUIDs {
+		localAccess[uid] = AccessMutate
+	}
+	if audit == nil {
+		audit = zap.NewNop()
+	}
+	return &Authorizer{
+		insecureAccess: opts.InsecureAccess,
+		localAccess:    localAccess,
+		audit:          audit,
+	}
+}
+
+// CallerFromContext 从连接认证信息中识别调用者及其权限
+func (a *Authorizer) CallerFromContext(ctx context.Context) (Caller, AccessLevel) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return Caller{Kind: CallerAnonymous}, AccessNone
+	}
+	switch info := p.AuthInfo.(type) {
+	case tunnelAuthInfo:
+		return Caller{Kind: CallerTunnel}, AccessMutate
+	case localAuthInfo:
+		caller := Caller{Kind: CallerLocal, Name: "uid=" + strconv.Itoa(info.uid), UID: info.uid}
+		return caller, a.localAccess[info.uid]
+	case credentials.TLSInfo:
+		// 证书链和调用者类型已在TLS握手中校验(节点证书被拒绝)
+		if len(info.State.PeerCertificates) == 0 {
+			return Caller{Kind: CallerAnonymous, Name: addrString(p.Addr)}, AccessNone
+		}
+		return Caller{Kind: CallerManager, Name: info.State.PeerCertificates[0].Subject.CommonName}, AccessMutate
+	default:
+		return Caller{Kind: CallerAnonymous, Name: addrString(p.Addr)}, a.insecureAccess
+	}
+}
+
+// Authorize 检查调用者是否有权调用方法，返回调用者身份
+// 被拒绝的调用返回PermissionDenied并记录审计日志
+func (a *Authorizer) Authorize(ctx context.Context, fullMethod string) (Caller, error) {
+	caller, granted := a.CallerFromContext(ctx)
+	required := MethodAccess(fullMethod)
+	if granted >= required {
+		return caller, nil
+	}
+
+	a.audit.Warn("daemon grpc call denied",
+		zap.String("method", fullMethod),
+		zap.String("caller", caller.String()),
+		zap.String("required", required.String()),
+		zap.String("granted", granted.String()))
+	return caller, status.Errorf(codes.PermissionDenied, "%s is not allowed to call %s (requires %s access)", caller, fullMethod, required)
+}
+
+// AuditCall 记录变更操作的审计日志(只读操作不记录)
+func (a *Authorizer) AuditCall(fullMethod string, caller Caller, req interface{}, err error) {
+	if MethodAccess(fullMethod) != AccessMutate {
+		return
+	}
+	fields := []zap.Field{
+		zap.String("method", fullMethod),
+		zap.String("caller", caller.String()),
+		zap.String("code", status.Code(err).String()),
+	}
+	fields = append(fields, requestFields(req)...)
+	if err != nil {
+		fields = append(fields, zap.String("error", status.Convert(err).Message()))
+	}
+	a.audit.Info("daemon grpc mutating call", fields...)
+}
+
+// requestFields 提取请求中用于审计的字段
+func requestFields(req interface{}) []zap.Field {
+	var fields []zap.Field
+	if r, ok := req.(interface{ GetAgentId() string }); ok && r.GetAgentId() != "" {
+		fields = append(fields, zap.String("agent_id", r.GetAgentId()))
+	}
+	if r, ok := req.(interface{ GetOperation() string }); ok && r.GetOperation() != "" {
+		fields = append(fields, zap.String("operation", r.GetOperation()))
+	}
+	if r, ok := req.(interface{ GetCommand() string }); ok && r.GetCommand() != "" {
+		fields = append(fields, zap.String("command", r.GetCommand()))
+	}
+	if r, ok := req.(interface{ GetArgs() map[string]string }); ok && len(r.GetArgs()) > 0 {
+		fields = append(fields, zap.Any("args", r.GetArgs()))
+	}
+	if r, ok := req.(interface{ GetVersion() string }); ok && r.GetVersion() != "" {
+		fields = append(fields, zap.String("version", r.GetVersion()))
+	}
+	return fields
+}
+
+// addrString 返回地址字符串
+func addrString(addr net.Addr) string {
+	if addr == nil {
+		return ""
+	}
+	return addr.String()
+}
+
+// ListenLocalSocket 创建本地Unix socket监听器，清理上次运行残留的socket文件
+func ListenLocalSocket(path string) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create socket directory: %w", err)
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, socketFileMode); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to set socket permissions: %w", err)
+	}
+	return listener, nil
+}
@@ -0,0 +1,172 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/bingooyong/ops-scaffold-framework/daemon/pkg/proto"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// authTestDaemon 记录OperateAgent调用的DaemonService
+type authTestDaemon struct {
+	proto.UnimplementedDaemonServiceServer
+	operations int
+}
+
+func (d *authTestDaemon) ListAgents(ctx context.Context, req *proto.ListAgentsRequest) (*proto.ListAgentsResponse, error) {
+	return &proto.ListAgentsResponse{}, nil
+}
+
+func (d *authTestDaemon) OperateAgent(ctx context.Context, req *proto.AgentOperationRequest) (*proto.AgentOperationResponse, error) {
+	d.operations++
+	return &proto.AgentOperationResponse{Success: true}, nil
+}
+
+// startAuthServer 启动带授权拦截器的服务器，返回审计日志观察器
+func startAuthServer(t *testing.T, lis net.Listener, opts AuthorizerOptions) (*authTestDaemon, *observer.ObservedLogs) {
+	t.Helper()
+	core, logs := observer.New(zapcore.InfoLevel)
+	authz := NewAuthorizer(opts, zap.New(core))
+	server := grpc.NewServer(
+		grpc.Creds(NewServerCredentials(nil)),
+		grpc.UnaryInterceptor(UnaryServerInterceptor(zap.NewNop(), authz)),
+		grpc.StreamInterceptor(StreamServerInterceptor(zap.NewNop(), authz)),
+	)
+	daemon := &authTestDaemon{}
+	proto.RegisterDaemonServiceServer(server, daemon)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+	return daemon, logs
+}
+
+func dialAuthServer(t *testing.T, target string) proto.DaemonServiceClient {
+	t.Helper()
+	conn, err := grpc.NewClient(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return proto.NewDaemonServiceClient(conn)
+}
+
+func TestAuthorizer_InsecureTCPReadOnly(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	daemon, logs := startAuthServer(t, lis, AuthorizerOptions{InsecureAccess: AccessRead})
+	client := dialAuthServer(t, lis.Addr().String())
+	ctx := context.Background()
+
+	if _, err := client.ListAgents(ctx, &proto.ListAgentsRequest{}); err != nil {
+		t.Fatalf("read-only call should be allowed: %v", err)
+	}
+	_, err = client.OperateAgent(ctx, &proto.AgentOperationRequest{AgentId: "agent-1", Operation: "stop"})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected PermissionDenied, got %v", err)
+	}
+	if daemon.operations != 0 {
+		t.Fatal("denied call must not reach the handler")
+	}
+
+	// 未实现的方法也需要变更权限
+	if _, err := client.PushUpdate(ctx, &proto.UpdateRequest{}); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected PermissionDenied for PushUpdate, got %v", err)
+	}
+
+	denied := logs.FilterMessage("daemon grpc call denied").All()
+	if len(denied) != 2 {
+		t.Fatalf("expected 2 denial audit entries, got %d", len(denied))
+	}
+	if denied[0].ContextMap()["method"] != proto.DaemonService_OperateAgent_FullMethodName {
+		t.Fatalf("unexpected audit entry: %v", denied[0].ContextMap())
+	}
+}
+
+func TestAuthorizer_LocalSocket(t *testing.T) {
+	lis, err := ListenLocalSocket(filepath.Join(t.TempDir(), "daemon.sock"))
+	if err != nil {
+		t.Fatalf("failed to listen on socket: %v", err)
+	}
+	// TCP调用者没有任何权限，本地socket仍按uid授权(Daemon运行用户可以执行变更操作)
+	daemon, logs := startAuthServer(t, lis, AuthorizerOptions{InsecureAccess: AccessNone})
+	client := dialAuthServer(t, "unix://"+lis.Addr().String())
+
+	resp, err := client.OperateAgent(context.Background(), &proto.AgentOperationRequest{AgentId: "agent-1", Operation: "restart"})
+	if err != nil || !resp.Success {
+		t.Fatalf("local caller should be allowed to operate agents: %v", err)
+	}
+	if daemon.operations != 1 {
+		t.Fatalf("expected 1 operation, got %d", daemon.operations)
+	}
+
+	entries := logs.FilterMessage("daemon grpc mutating call").All()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 mutating call audit entry, got %d", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if fields["agent_id"] != "agent-1" || fields["operation"] != "restart" || fields["code"] != "OK" {
+		t.Fatalf("unexpected audit fields: %v", fields)
+	}
+	if caller, _ := fields["caller"].(string); len(caller) < len(CallerLocal) || caller[:len(CallerLocal)] != CallerLocal {
+		t.Fatalf("expected local caller, got %v", fields["caller"])
+	}
+
+	// 只读操作不记录审计日志
+	if _, err := client.ListAgents(context.Background(), &proto.ListAgentsRequest{}); err != nil {
+		t.Fatalf("ListAgents failed: %v", err)
+	}
+	if logs.FilterMessage("daemon grpc mutating call").Len() != 1 {
+		t.Fatal("read-only calls should not be audited")
+	}
+}
+
+func TestAuthorizer_CallerAccess(t *testing.T) {
+	authz := NewAuthorizer(AuthorizerOptions{
+		InsecureAccess: AccessNone,
+		LocalAdminUIDs: []int{1001},
+		LocalReadUIDs:  []int{1002, 1001},
+	}, nil)
+	common := credentials.CommonAuthInfo{SecurityLevel: credentials.PrivacyAndIntegrity}
+	addr := &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 4000}
+
+	tests := []struct {
+		name   string
+		info   credentials.AuthInfo
+		kind   string
+		access AccessLevel
+	}{
+		{"root", localAuthInfo{CommonAuthInfo: common, uid: 0}, CallerLocal, AccessMutate},
+		{"admin uid", localAuthInfo{CommonAuthInfo: common, uid: 1001}, CallerLocal, AccessMutate},
+		{"read uid", localAuthInfo{CommonAuthInfo: common, uid: 1002}, CallerLocal, AccessRead},
+		{"unknown uid", localAuthInfo{CommonAuthInfo: common, uid: 1003}, CallerLocal, AccessNone},
+		{"tunnel", tunnelAuthInfo{CommonAuthInfo: common}, CallerTunnel, AccessMutate},
+		{"insecure tcp", insecureAuthInfo{}, CallerAnonymous, AccessNone},
+		{"tls without certificate", credentials.TLSInfo{}, CallerAnonymous, AccessNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: addr, AuthInfo: tt.info})
+			caller, access := authz.CallerFromContext(ctx)
+			if caller.Kind != tt.kind || access != tt.access {
+				t.Fatalf("got %s/%s, want %s/%s", caller.Kind, access, tt.kind, tt.access)
+			}
+		})
+	}
+
+	if MethodAccess(proto.DaemonService_FollowAgentLogs_FullMethodName) != AccessRead ||
+		MethodAccess(proto.DaemonService_ControlAgent_FullMethodName) != AccessMutate {
+		t.Fatal("unexpected method access policy")
+	}
+}
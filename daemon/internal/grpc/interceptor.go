@@ -58,8 +58,8 @@ func UnaryClientInterceptor(logger *zap.Logger) grpc.UnaryClientInterceptor {
 }
 
 // UnaryServerInterceptor 服务端一元RPC拦截器
-// 用于记录服务端RPC处理的日志和性能指标
-func UnaryServerInterceptor(logger *zap.Logger) grpc.UnaryServerInterceptor {
+// 用于记录服务端RPC处理的日志和性能指标，authz不为nil时按方法执行授权并审计变更操作
+func UnaryServerInterceptor(logger *zap.Logger, authz *Authorizer) grpc.UnaryServerInterceptor {
 	return func(
 		ctx context.Context,
 		req interface{},
@@ -68,8 +68,20 @@ func UnaryServerInterceptor(logger *zap.Logger) grpc.UnaryServerInterceptor {
 	) (interface{}, error) {
 		start := time.Now()
 
+		// 授权检查
+		var caller Caller
+		if authz != nil {
+			var err error
+			if caller, err = authz.Authorize(ctx, info.FullMethod); err != nil {
+				return nil, err
+			}
+		}
+
 		// 处理请求
 		resp, err := handler(ctx, req)
+		if authz != nil {
+			authz.AuditCall(info.FullMethod, caller, req, err)
+		}
 
 		// 计算耗时
 		duration := time.Since(start)
@@ -101,3 +113,29 @@ func UnaryServerInterceptor(logger *zap.Logger) grpc.UnaryServerInterceptor {
 		return resp, err
 	}
 }
+
+// StreamServerInterceptor 服务端流式RPC拦截器
+// authz不为nil时按方法执行授权并审计变更操作
+func StreamServerInterceptor(logger *zap.Logger, authz *Authorizer) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		if authz == nil {
+			return handler(srv, ss)
+		}
+
+		caller, err := authz.Authorize(ss.Context(), info.FullMethod)
+		if err != nil {
+			logger.Warn("grpc server stream denied",
+				zap.String("method", info.FullMethod),
+				zap.String("caller", caller.String()))
+			return err
+		}
+		err = handler(srv, ss)
+		authz.AuditCall(info.FullMethod, caller, nil, err)
+		return err
+	}
+}
@@ -13,6 +13,7 @@ import (
 
 	"github.com/bingooyong/ops-scaffold-framework/daemon/internal/agent"
 	"github.com/bingooyong/ops-scaffold-framework/daemon/internal/config"
+	"github.com/bingooyong/ops-scaffold-framework/daemon/internal/spool"
 	"github.com/bingooyong/ops-scaffold-framework/daemon/pkg/proto"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
@@ -27,6 +28,8 @@ type ManagerClient struct {
 	client proto.DaemonServiceClient
 	config *config.ManagerConfig
 	logger *zap.Logger
+	// creds 动态mTLS凭证，未设置时使用TLS配置中的证书文件
+	creds credentials.TransportCredentials
 }
 
 // NewManagerClient 创建Manager gRPC客户端
@@ -37,12 +40,19 @@ func NewManagerClient(cfg *config.ManagerConfig, logger *zap.Logger) *ManagerCli
 	}
 }
 
+// SetCredentials 设置连接Manager使用的传输凭证
+func (c *ManagerClient) SetCredentials(creds credentials.TransportCredentials) {
+	c.creds = creds
+}
+
 // Connect 连接到Manager
 func (c *ManagerClient) Connect(ctx context.Context) error {
 	// 加载TLS证书
 	var opts []grpc.DialOption
 
-	if c.config.TLS.CertFile != "" && c.config.TLS.KeyFile != "" && c.config.TLS.CAFile != "" {
+	if c.creds != nil {
+		opts = append(opts, grpc.WithTransportCredentials(c.creds))
+	} else if c.config.TLS.CertFile != "" && c.config.TLS.KeyFile != "" && c.config.TLS.CAFile != "" {
 		cert, err := tls.LoadX509KeyPair(c.config.TLS.CertFile, c.config.TLS.KeyFile)
 		if err != nil {
 			return fmt.Errorf("failed to load client cert: %w", err)
@@ -127,8 +137,17 @@ func (c *ManagerClient) Close() error {
 	return nil
 }
 
+// OpenControlStream 打开到Manager的控制流
+func (c *ManagerClient) OpenControlStream(ctx context.Context) (proto.DaemonService_ConnectClient, error) {
+	if c.client == nil {
+		return nil, fmt.Errorf("gRPC client not connected")
+	}
+	return c.client.Connect(ctx)
+}
+
 // SyncAgentStates 同步Agent状态到Manager
-func (c *ManagerClient) SyncAgentStates(ctx context.Context, nodeID string, states []*agent.AgentState) error {
+// collectedAt为快照采集时间，Manager据此忽略比已有状态更旧的快照
+func (c *ManagerClient) SyncAgentStates(ctx context.Context, nodeID string, states []*agent.AgentState, collectedAt time.Time) error {
 	if c.client == nil {
 		return fmt.Errorf("gRPC client not connected")
 	}
@@ -155,13 +174,21 @@ func (c *ManagerClient) SyncAgentStates(ctx context.Context, nodeID string, stat
 			protoState.LastHeartbeat = state.LastHeartbeat.Unix()
 		}
 
+		// 附带最近一次心跳的自定义指标,Manager按时间戳去重
+		if !state.CustomMetricsUpdatedAt.IsZero() {
+			protoState.CustomMetrics = state.CustomMetrics
+			protoState.CustomFields = state.CustomFields
+			protoState.CustomMetricsTimestamp = state.CustomMetricsUpdatedAt.UnixMilli()
+		}
+
 		protoStates = append(protoStates, protoState)
 	}
 
 	// 构建同步请求
 	req := &proto.SyncAgentStatesRequest{
-		NodeId: nodeID,
-		States: protoStates,
+		NodeId:    nodeID,
+		States:    protoStates,
+		Timestamp: collectedAt.Unix(),
 	}
 
 	// 调用gRPC服务
@@ -173,7 +200,7 @@ func (c *ManagerClient) SyncAgentStates(ctx context.Context, nodeID string, stat
 
 	if !resp.Success {
 		c.logger.Warn("agent states sync failed", zap.String("message", resp.Message))
-		return fmt.Errorf("sync failed: %s", resp.Message)
+		return fmt.Errorf("sync failed: %s: %w", resp.Message, spool.ErrRejected)
 	}
 
 	c.logger.Debug("agent states synced successfully",
@@ -182,3 +209,114 @@ func (c *ManagerClient) SyncAgentStates(ctx context.Context, nodeID string, stat
 
 	return nil
 }
+
+// ReportAgentEvents 上报Agent事件到Manager
+func (c *ManagerClient) ReportAgentEvents(ctx context.Context, nodeID string, events []*agent.AgentEvent) error {
+	if c.client == nil {
+		return fmt.Errorf("gRPC client not connected")
+	}
+
+	protoEvents := make([]*proto.AgentEvent, 0, len(events))
+	for _, event := range events {
+		protoEvents = append(protoEvents, &proto.AgentEvent{
+			AgentId:   event.AgentID,
+			Type:      string(event.Type),
+			Message:   event.Message,
+			Timestamp: event.Timestamp.Unix(),
+			Details:   event.Details,
+		})
+	}
+
+	resp, err := c.client.ReportAgentEvents(ctx, &proto.ReportAgentEventsRequest{
+		NodeId: nodeID,
+		Events: protoEvents,
+	})
+	if err != nil {
+		c.logger.Error("failed to report agent events", zap.Error(err))
+		return fmt.Errorf("gRPC call failed: %w", err)
+	}
+
+	if !resp.Success {
+		c.logger.Warn("agent events report failed", zap.String("message", resp.Message))
+		return fmt.Errorf("report events failed: %s: %w", resp.Message, spool.ErrRejected)
+	}
+
+	c.logger.Debug("agent events reported successfully",
+		zap.String("node_id", nodeID),
+		zap.Int("count", len(events)))
+
+	return nil
+}
+
+// ReportCrashes 上报Agent崩溃记录到Manager
+func (c *ManagerClient) ReportCrashes(ctx context.Context, nodeID string, crashes []*agent.CrashRecord) error {
+	if c.client == nil {
+		return fmt.Errorf("gRPC client not connected")
+	}
+
+	protoCrashes := make([]*proto.CrashReport, 0, len(crashes))
+	for _, crash := range crashes {
+		protoCrashes = append(protoCrashes, crashRecordToProto(crash))
+	}
+
+	resp, err := c.client.ReportCrashes(ctx, &proto.ReportCrashesRequest{
+		NodeId:  nodeID,
+		Crashes: protoCrashes,
+	})
+	if err != nil {
+		c.logger.Error("failed to report crash records", zap.Error(err))
+		return fmt.Errorf("gRPC call failed: %w", err)
+	}
+
+	if !resp.Success {
+		c.logger.Warn("crash records report failed", zap.String("message", resp.Message))
+		return fmt.Errorf("report crashes failed: %s: %w", resp.Message, spool.ErrRejected)
+	}
+
+	c.logger.Debug("crash records reported successfully",
+		zap.String("node_id", nodeID),
+		zap.Int("count", len(crashes)))
+
+	return nil
+}
+
+// ReportResourceAlerts 上报Agent资源告警到Manager
+func (c *ManagerClient) ReportResourceAlerts(ctx context.Context, nodeID string, alerts []*agent.ResourceAlert) error {
+	if c.client == nil {
+		return fmt.Errorf("gRPC client not connected")
+	}
+
+	protoAlerts := make([]*proto.ResourceAlert, 0, len(alerts))
+	for _, alert := range alerts {
+		protoAlerts = append(protoAlerts, &proto.ResourceAlert{
+			AgentId:     alert.AgentID,
+			Type:        alert.Type,
+			Value:       alert.Value,
+			Threshold:   alert.Threshold,
+			DurationMs:  alert.Duration.Milliseconds(),
+			Timestamp:   alert.Timestamp.Unix(),
+			Action:      string(alert.Action),
+			ActionError: alert.ActionError,
+		})
+	}
+
+	resp, err := c.client.ReportResourceAlerts(ctx, &proto.ReportResourceAlertsRequest{
+		NodeId: nodeID,
+		Alerts: protoAlerts,
+	})
+	if err != nil {
+		c.logger.Error("failed to report resource alerts", zap.Error(err))
+		return fmt.Errorf("gRPC call failed: %w", err)
+	}
+
+	if !resp.Success {
+		c.logger.Warn("resource alerts report failed", zap.String("message", resp.Message))
+		return fmt.Errorf("report resource alerts failed: %s: %w", resp.Message, spool.ErrRejected)
+	}
+
+	c.logger.Debug("resource alerts reported successfully",
+		zap.String("node_id", nodeID),
+		zap.Int("count", len(alerts)))
+
+	return nil
+}
@@ -5,10 +5,14 @@ package grpc
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/bingooyong/ops-scaffold-framework/daemon/internal/agent"
 	"github.com/bingooyong/ops-scaffold-framework/daemon/internal/config"
+	"github.com/bingooyong/ops-scaffold-framework/daemon/pkg/proto"
 	"go.uber.org/zap"
+	"google.golang.org/grpc/credentials"
 )
 
 // ManagerClient Manager gRPC客户端(用于调用Manager的DaemonService)
@@ -26,6 +30,10 @@ func NewManagerClient(cfg *config.ManagerConfig, logger *zap.Logger) *ManagerCli
 	}
 }
 
+// SetCredentials 设置连接Manager使用的传输凭证 (测试 stub)
+func (c *ManagerClient) SetCredentials(creds credentials.TransportCredentials) {
+}
+
 // Connect 连接到Manager (测试 stub)
 func (c *ManagerClient) Connect(ctx context.Context) error {
 	c.logger.Warn("ManagerClient.Connect called in test mode (stub implementation)")
@@ -37,8 +45,31 @@ func (c *ManagerClient) Close() error {
 	return nil
 }
 
+// OpenControlStream 打开到Manager的控制流 (测试 stub)
+func (c *ManagerClient) OpenControlStream(ctx context.Context) (proto.DaemonService_ConnectClient, error) {
+	return nil, fmt.Errorf("control stream is not supported in test mode")
+}
+
 // SyncAgentStates 同步Agent状态到Manager (测试 stub)
-func (c *ManagerClient) SyncAgentStates(ctx context.Context, nodeID string, states []*agent.AgentState) error {
+func (c *ManagerClient) SyncAgentStates(ctx context.Context, nodeID string, states []*agent.AgentState, collectedAt time.Time) error {
 	c.logger.Warn("ManagerClient.SyncAgentStates called in test mode (stub implementation)")
 	return nil
 }
+
+// ReportAgentEvents 上报Agent事件到Manager (测试 stub)
+func (c *ManagerClient) ReportAgentEvents(ctx context.Context, nodeID string, events []*agent.AgentEvent) error {
+	c.logger.Warn("ManagerClient.ReportAgentEvents called in test mode (stub implementation)")
+	return nil
+}
+
+// ReportCrashes 上报Agent崩溃记录到Manager (测试 stub)
+func (c *ManagerClient) ReportCrashes(ctx context.Context, nodeID string, crashes []*agent.CrashRecord) error {
+	c.logger.Warn("ManagerClient.ReportCrashes called in test mode (stub implementation)")
+	return nil
+}
+
+// ReportResourceAlerts 上报Agent资源告警到Manager (测试 stub)
+func (c *ManagerClient) ReportResourceAlerts(ctx context.Context, nodeID string, alerts []*agent.ResourceAlert) error {
+	c.logger.Warn("ManagerClient.ReportResourceAlerts called in test mode (stub implementation)")
+	return nil
+}
@@ -0,0 +1,33 @@
+//go:build linux
+
+package grpc
+
+import (
+	"fmt"
+	"net"
+
+	"golang.org/x/sys/unix"
+)
+
+// socketFileMode 本地socket文件权限，Linux下通过SO_PEERCRED按uid授权，允许所有用户连接
+const socketFileMode = 0666
+
+// peerUID 通过SO_PEERCRED读取Unix Socket对端进程的uid
+func peerUID(conn *net.UnixConn) (int, error) {
+	rawConn, err := conn.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+
+	var ucred *unix.Ucred
+	var credErr error
+	if err := rawConn.Control(func(fd uintptr) {
+		ucred, credErr = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	}); err != nil {
+		return 0, err
+	}
+	if credErr != nil {
+		return 0, fmt.Errorf("failed to get peer credentials: %w", credErr)
+	}
+	return int(ucred.Uid), nil
+}
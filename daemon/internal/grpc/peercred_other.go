@@ -0,0 +1,16 @@
+//go:build !linux
+
+package grpc
+
+import (
+	"net"
+	"os"
+)
+
+// socketFileMode 非Linux平台无法读取对端uid，socket文件仅允许Daemon运行用户访问
+const socketFileMode = 0600
+
+// peerUID 非Linux平台依赖socket文件权限，对端视为Daemon运行用户
+func peerUID(conn *net.UnixConn) (int, error) {
+	return os.Geteuid(), nil
+}
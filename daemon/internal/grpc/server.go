@@ -2,10 +2,13 @@ package grpc
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/bingooyong/ops-scaffold-framework/daemon/internal/agent"
+	"github.com/bingooyong/ops-scaffold-framework/daemon/pkg/control"
 	"github.com/bingooyong/ops-scaffold-framework/daemon/pkg/proto"
 	"go.uber.org/zap"
 	"google.golang.org/grpc/codes"
@@ -120,9 +123,10 @@ func (s *Server) OperateAgent(ctx context.Context, req *proto.AgentOperationRequ
 		"start":   true,
 		"stop":    true,
 		"restart": true,
+		"reset":   true,
 	}
 	if !validOperations[req.Operation] {
-		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("invalid operation: %s, must be one of: start, stop, restart", req.Operation))
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("invalid operation: %s, must be one of: start, stop, restart, reset", req.Operation))
 	}
 
 	// 检查Agent是否存在
@@ -143,6 +147,9 @@ func (s *Server) OperateAgent(ctx context.Context, req *proto.AgentOperationRequ
 	case "restart":
 		s.logger.Debug("restarting agent", zap.String("agent_id", req.AgentId))
 		err = s.multiAgentManager.RestartAgent(ctx, req.AgentId, true) // 手动重启，跳过回退时间
+	case "reset":
+		s.logger.Debug("resetting agent circuit breaker", zap.String("agent_id", req.AgentId))
+		err = s.multiAgentManager.ResetCircuitBreaker(req.AgentId)
 	default:
 		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("invalid operation: %s", req.Operation))
 	}
@@ -219,6 +226,206 @@ func (s *Server) GetAgentMetrics(ctx context.Context, req *proto.AgentMetricsReq
 	}, nil
 }
 
+// GetCrashReports 获取Agent崩溃记录
+func (s *Server) GetCrashReports(ctx context.Context, req *proto.GetCrashReportsRequest) (*proto.GetCrashReportsResponse, error) {
+	if req.AgentId == "" {
+		return nil, status.Error(codes.InvalidArgument, "agent_id is required")
+	}
+
+	limit := int(req.Limit)
+	if limit <= 0 {
+		limit = 10 // 默认返回最近10条
+	}
+
+	records, err := s.multiAgentManager.GetCrashRecords(req.AgentId, limit)
+	if err != nil {
+		if _, ok := err.(*agent.AgentNotFoundError); ok {
+			return nil, status.Error(codes.NotFound, fmt.Sprintf("agent not found: %s", req.AgentId))
+		}
+		s.logger.Error("failed to get crash records",
+			zap.String("agent_id", req.AgentId),
+			zap.Error(err))
+		return nil, status.Error(codes.Internal, fmt.Sprintf("failed to get crash records: %v", err))
+	}
+
+	crashes := make([]*proto.CrashReport, 0, len(records))
+	for _, record := range records {
+		crashes = append(crashes, crashRecordToProto(record))
+	}
+
+	return &proto.GetCrashReportsResponse{
+		Crashes: crashes,
+	}, nil
+}
+
+// TailAgentLogs 获取Agent日志末尾N行(可按关键词过滤)
+func (s *Server) TailAgentLogs(ctx context.Context, req *proto.TailAgentLogsRequest) (*proto.TailAgentLogsResponse, error) {
+	if req.AgentId == "" {
+		return nil, status.Error(codes.InvalidArgument, "agent_id is required")
+	}
+
+	lines, err := s.multiAgentManager.TailAgentLogs(req.AgentId, int(req.Lines), req.Keyword)
+	if err != nil {
+		if _, ok := err.(*agent.AgentNotFoundError); ok {
+			return nil, status.Error(codes.NotFound, fmt.Sprintf("agent not found: %s", req.AgentId))
+		}
+		s.logger.Error("failed to tail agent logs",
+			zap.String("agent_id", req.AgentId),
+			zap.Error(err))
+		return nil, status.Error(codes.Internal, fmt.Sprintf("failed to tail agent logs: %v", err))
+	}
+
+	return &proto.TailAgentLogsResponse{
+		AgentId: req.AgentId,
+		Lines:   lines,
+	}, nil
+}
+
+// ControlAgent 通过控制通道向Agent下发命令(reload/set_log_level/dump_diagnostics/drain)
+// Agent不支持控制协议或该命令时回退为配置的信号
+func (s *Server) ControlAgent(ctx context.Context, req *proto.ControlAgentRequest) (*proto.ControlAgentResponse, error) {
+	if req.AgentId == "" {
+		return nil, status.Error(codes.InvalidArgument, "agent_id is required")
+	}
+	if !control.IsValidCommand(req.Command) {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("invalid command: %s, must be one of: %s", req.Command, strings.Join(control.Commands(), ", ")))
+	}
+
+	s.logger.Info("received ControlAgent request",
+		zap.String("agent_id", req.AgentId),
+		zap.String("command", req.Command))
+
+	result, err := s.multiAgentManager.ControlAgent(ctx, req.AgentId, req.Command, req.Args)
+	if err != nil {
+		switch err.(type) {
+		case *agent.AgentNotFoundError:
+			return nil, status.Error(codes.NotFound, fmt.Sprintf("agent not found: %s", req.AgentId))
+		case *agent.AgentNotRunningError:
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		case *agent.ControlUnsupportedError:
+			return nil, status.Error(codes.Unimplemented, err.Error())
+		}
+		s.logger.Error("failed to control agent",
+			zap.String("agent_id", req.AgentId),
+			zap.String("command", req.Command),
+			zap.Error(err))
+		return nil, status.Error(codes.Internal, fmt.Sprintf("failed to control agent: %v", err))
+	}
+
+	return &proto.ControlAgentResponse{
+		Success: result.Success,
+		Message: result.Message,
+		Method:  result.Method,
+		Data:    result.Data,
+	}, nil
+}
+
+// SearchAgentLogs 按关键词/正则和时间范围搜索Agent日志(包括已轮转的日志文件)
+func (s *Server) SearchAgentLogs(ctx context.Context, req *proto.SearchAgentLogsRequest) (*proto.SearchAgentLogsResponse, error) {
+	query := agent.LogSearchQuery{
+		AgentID:   req.AgentId,
+		AgentType: req.AgentType,
+		Keyword:   req.Keyword,
+		Regex:     req.Regex,
+		Limit:     int(req.Limit),
+	}
+	if req.SinceMs > 0 {
+		query.Since = time.UnixMilli(req.SinceMs)
+	}
+	if req.UntilMs > 0 {
+		query.Until = time.UnixMilli(req.UntilMs)
+	}
+
+	matches, truncated, err := s.multiAgentManager.SearchAgentLogs(ctx, query)
+	if err != nil {
+		if _, ok := err.(*agent.AgentNotFoundError); ok {
+			return nil, status.Error(codes.NotFound, fmt.Sprintf("agent not found: %s", req.AgentId))
+		}
+		if errors.Is(err, agent.ErrInvalidLogQuery) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if ctx.Err() != nil {
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+		s.logger.Error("failed to search agent logs",
+			zap.String("agent_id", req.AgentId),
+			zap.String("keyword", req.Keyword),
+			zap.Error(err))
+		return nil, status.Error(codes.Internal, fmt.Sprintf("failed to search agent logs: %v", err))
+	}
+
+	hits := make([]*proto.LogSearchHit, 0, len(matches))
+	for _, m := range matches {
+		hits = append(hits, &proto.LogSearchHit{
+			AgentId:     m.AgentID,
+			AgentType:   m.AgentType,
+			TimestampMs: m.Timestamp.UnixMilli(),
+			Line:        m.Content,
+			File:        m.File,
+			LineNumber:  int32(m.LineNumber),
+		})
+	}
+
+	s.logger.Debug("searched agent logs",
+		zap.String("agent_id", req.AgentId),
+		zap.String("agent_type", req.AgentType),
+		zap.String("keyword", req.Keyword),
+		zap.Int("hits", len(hits)),
+		zap.Bool("truncated", truncated))
+
+	return &proto.SearchAgentLogsResponse{
+		Hits:      hits,
+		Truncated: truncated,
+	}, nil
+}
+
+// FollowAgentLogs 持续推送Agent新增日志，直到客户端取消
+// 先推送末尾tail_lines行，之后推送新增行；日志轮转或截断时在推送中标记rotated
+func (s *Server) FollowAgentLogs(req *proto.FollowAgentLogsRequest, stream proto.DaemonService_FollowAgentLogsServer) error {
+	if req.AgentId == "" {
+		return status.Error(codes.InvalidArgument, "agent_id is required")
+	}
+
+	// 先定位到文件末尾再读取末尾行，两者之间写入的日志可能重复推送但不会丢失
+	follower, err := s.multiAgentManager.FollowAgentLogs(req.AgentId, req.Keyword)
+	if err != nil {
+		if _, ok := err.(*agent.AgentNotFoundError); ok {
+			return status.Error(codes.NotFound, fmt.Sprintf("agent not found: %s", req.AgentId))
+		}
+		return status.Error(codes.Internal, fmt.Sprintf("failed to follow agent logs: %v", err))
+	}
+
+	// 首条消息总是立即发送(可能为空)，客户端据此确认跟踪已建立
+	var lines []string
+	if req.TailLines > 0 {
+		lines, err = s.multiAgentManager.TailAgentLogs(req.AgentId, int(req.TailLines), req.Keyword)
+		if err != nil {
+			follower.Close()
+			return status.Error(codes.Internal, fmt.Sprintf("failed to tail agent logs: %v", err))
+		}
+	}
+	if err := stream.Send(&proto.FollowAgentLogsResponse{Lines: lines}); err != nil {
+		follower.Close()
+		return err
+	}
+
+	s.logger.Info("following agent logs",
+		zap.String("agent_id", req.AgentId),
+		zap.String("keyword", req.Keyword))
+
+	err = follower.Follow(stream.Context(), func(lines []string, rotated bool) error {
+		return stream.Send(&proto.FollowAgentLogsResponse{
+			Lines:   lines,
+			Rotated: rotated,
+		})
+	})
+
+	s.logger.Info("stopped following agent logs",
+		zap.String("agent_id", req.AgentId),
+		zap.Error(err))
+	return err
+}
+
 // SyncAgentStates 同步Agent状态(用于Daemon向Manager上报状态)
 func (s *Server) SyncAgentStates(ctx context.Context, req *proto.SyncAgentStatesRequest) (*proto.SyncAgentStatesResponse, error) {
 	// 验证请求参数
@@ -311,3 +518,17 @@ func convertResourceDataPointToProto(dp *agent.ResourceDataPoint) *proto.Resourc
 		OpenFiles:      int32(dp.OpenFiles),
 	}
 }
+
+// crashRecordToProto 将CrashRecord转换为protobuf CrashReport消息
+func crashRecordToProto(record *agent.CrashRecord) *proto.CrashReport {
+	return &proto.CrashReport{
+		AgentId:      record.AgentID,
+		ExitCode:     int32(record.ExitCode),
+		Signal:       record.Signal,
+		StartedAt:    record.StartedAt.Unix(),
+		ExitedAt:     record.ExitedAt.Unix(),
+		DurationMs:   record.Duration.Milliseconds(),
+		LastLogLines: record.LastLogLines,
+		CircuitOpen:  record.CircuitOpen,
+	}
+}
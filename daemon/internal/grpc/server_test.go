@@ -2,12 +2,15 @@ package grpc
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/bingooyong/ops-scaffold-framework/daemon/internal/agent"
 	"github.com/bingooyong/ops-scaffold-framework/daemon/pkg/proto"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -662,3 +665,107 @@ func TestSyncAgentStates_EmptyStates(t *testing.T) {
 		t.Error("expected non-empty message")
 	}
 }
+
+// fakeFollowLogsStream 记录推送内容的FollowAgentLogs服务端流
+type fakeFollowLogsStream struct {
+	grpc.ServerStream
+	ctx       context.Context
+	responses chan *proto.FollowAgentLogsResponse
+}
+
+func (s *fakeFollowLogsStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *fakeFollowLogsStream) Send(resp *proto.FollowAgentLogsResponse) error {
+	s.responses <- resp
+	return nil
+}
+
+func TestTailAndFollowAgentLogs(t *testing.T) {
+	tmpDir := t.TempDir()
+	logger := zap.NewNop()
+
+	mam, err := agent.NewMultiAgentManager(tmpDir, logger)
+	if err != nil {
+		t.Fatalf("failed to create MultiAgentManager: %v", err)
+	}
+	server := NewServer(mam, agent.NewResourceMonitor(mam, mam.GetRegistry(), logger), logger)
+
+	if _, err := mam.RegisterAgent(&agent.AgentInfo{
+		ID:         "agent1",
+		Type:       agent.TypeCustom,
+		BinaryPath: "/bin/true",
+		WorkDir:    tmpDir,
+	}); err != nil {
+		t.Fatalf("failed to register agent: %v", err)
+	}
+	logDir := filepath.Join(tmpDir, "agents", "agent1", "logs")
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		t.Fatalf("failed to create log dir: %v", err)
+	}
+	logPath := filepath.Join(logDir, "agent.log")
+	if err := os.WriteFile(logPath, []byte("INFO a\nERROR b\nINFO c\nERROR d\n"), 0644); err != nil {
+		t.Fatalf("failed to write log: %v", err)
+	}
+
+	// 获取末尾日志(关键词过滤)
+	resp, err := server.TailAgentLogs(context.Background(), &proto.TailAgentLogsRequest{
+		AgentId: "agent1",
+		Lines:   10,
+		Keyword: "ERROR",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Lines) != 2 || resp.Lines[0] != "ERROR b" || resp.Lines[1] != "ERROR d" {
+		t.Errorf("unexpected lines: %v", resp.Lines)
+	}
+
+	_, err = server.TailAgentLogs(context.Background(), &proto.TailAgentLogsRequest{AgentId: "missing"})
+	if st, _ := status.FromError(err); st.Code() != codes.NotFound {
+		t.Errorf("expected NotFound, got %v", err)
+	}
+
+	// 跟踪日志: 先推送末尾行，再推送新增行
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &fakeFollowLogsStream{ctx: ctx, responses: make(chan *proto.FollowAgentLogsResponse, 10)}
+	done := make(chan error, 1)
+	go func() {
+		done <- server.FollowAgentLogs(&proto.FollowAgentLogsRequest{AgentId: "agent1", TailLines: 1}, stream)
+	}()
+
+	receive := func() *proto.FollowAgentLogsResponse {
+		t.Helper()
+		select {
+		case resp := <-stream.responses:
+			return resp
+		case <-time.After(3 * time.Second):
+			t.Fatal("timed out waiting for log lines")
+			return nil
+		}
+	}
+	if first := receive(); len(first.Lines) != 1 || first.Lines[0] != "ERROR d" {
+		t.Errorf("expected tail line first, got %v", first.Lines)
+	}
+
+	file, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("failed to open log: %v", err)
+	}
+	file.WriteString("INFO e\n")
+	file.Close()
+	if next := receive(); len(next.Lines) != 1 || next.Lines[0] != "INFO e" {
+		t.Errorf("expected new line, got %v", next.Lines)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("expected nil error after cancel, got %v", err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("FollowAgentLogs did not return after cancel")
+	}
+}
@@ -0,0 +1,289 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/bingooyong/ops-scaffold-framework/daemon/pkg/proto"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// 控制流帧类型(与 manager/internal/grpc/tunnel.go 保持一致)
+const (
+	tunnelFrameHello   = "hello"
+	tunnelFrameRequest = "request"
+	tunnelFrameMessage = "message"
+	tunnelFrameClose   = "close"
+	tunnelFrameCancel  = "cancel"
+)
+
+const (
+	// tunnelBufferSize 本地内存连接缓冲区大小
+	tunnelBufferSize = 1 << 20
+	// tunnelMaxMsgSize 转发消息的最大大小(与gRPC服务器一致)
+	tunnelMaxMsgSize = 10 * 1024 * 1024
+	// tunnelMaxBackoff 重连最大间隔
+	tunnelMaxBackoff = 5 * time.Minute
+	// tunnelStableDuration 控制流保持超过该时间后重置重连间隔
+	tunnelStableDuration = time.Minute
+)
+
+// ControlStreamOpener 打开到Manager的控制流
+type ControlStreamOpener interface {
+	OpenControlStream(ctx context.Context) (proto.DaemonService_ConnectClient, error)
+}
+
+// TunnelClient 控制流客户端
+// Daemon主动连接Manager并保持控制流，Manager经该流下发的DaemonService调用转发给本地gRPC服务器执行，
+// 使NAT后或只允许出站连接的节点也能被Manager管理
+type TunnelClient struct {
+	opener            ControlStreamOpener
+	server            *grpc.Server
+	nodeID            string
+	reconnectInterval time.Duration
+	logger            *zap.Logger
+}
+
+// NewTunnelClient 创建控制流客户端
+// server为本地DaemonService gRPC服务器，控制流上的调用通过内存连接交给它处理(经过相同的拦截器)
+func NewTunnelClient(opener ControlStreamOpener, server *grpc.Server, nodeID string, reconnectInterval time.Duration, logger *zap.Logger) *TunnelClient {
+	if reconnectInterval <= 0 {
+		reconnectInterval = 10 * time.Second
+	}
+	return &TunnelClient{
+		opener:            opener,
+		server:            server,
+		nodeID:            nodeID,
+		reconnectInterval: reconnectInterval,
+		logger:            logger,
+	}
+}
+
+// Run 保持控制流，断开后按指数退避重连，阻塞直到ctx取消
+func (t *TunnelClient) Run(ctx context.Context) {
+	listener := bufconn.Listen(tunnelBufferSize)
+	go func() {
+		// 控制流内存连接被ServerCredentials识别为Manager调用
+		if err := t.server.Serve(&tunnelListener{Listener: listener}); err != nil {
+			t.logger.Debug("control stream listener stopped", zap.Error(err))
+		}
+	}()
+
+	local, err := grpc.NewClient("passthrough:///control-stream",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(
+			grpc.MaxCallRecvMsgSize(tunnelMaxMsgSize),
+			grpc.MaxCallSendMsgSize(tunnelMaxMsgSize),
+		),
+	)
+	if err != nil {
+		listener.Close()
+		t.logger.Error("failed to create control stream local connection", zap.Error(err))
+		return
+	}
+	defer local.Close()
+	defer listener.Close()
+
+	backoff := t.reconnectInterval
+	for {
+		start := time.Now()
+		err := t.session(ctx, local)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if time.Since(start) > tunnelStableDuration {
+			backoff = t.reconnectInterval
+		}
+		t.logger.Warn("control stream disconnected, reconnecting",
+			zap.Duration("retry_in", backoff),
+			zap.Error(err))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > tunnelMaxBackoff {
+			backoff = tunnelMaxBackoff
+		}
+	}
+}
+
+// session 建立一次控制流并处理请求，直到控制流断开
+func (t *TunnelClient) session(ctx context.Context, local *grpc.ClientConn) error {
+	sessionCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := t.opener.OpenControlStream(sessionCtx)
+	if err != nil {
+		return fmt.Errorf("failed to open control stream: %w", err)
+	}
+
+	var sendMu sync.Mutex
+	send := func(frame *proto.TunnelFrame) error {
+		sendMu.Lock()
+		defer sendMu.Unlock()
+		return stream.Send(frame)
+	}
+
+	if err := send(&proto.TunnelFrame{Type: tunnelFrameHello, NodeId: t.nodeID}); err != nil {
+		return fmt.Errorf("failed to send hello: %w", err)
+	}
+	t.logger.Info("control stream established", zap.String("node_id", t.nodeID))
+
+	var mu sync.Mutex
+	calls := make(map[string]context.CancelFunc)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	defer cancel()
+
+	for {
+		frame, err := stream.Recv()
+		if err != nil {
+			if err == io.EOF {
+				return errors.New("control stream closed by manager")
+			}
+			return err
+		}
+
+		switch frame.Type {
+		case tunnelFrameRequest:
+			callCtx, callCancel := context.WithCancel(sessionCtx)
+			if len(frame.Metadata) > 0 {
+				callCtx = metadata.NewOutgoingContext(callCtx, metadata.New(frame.Metadata))
+			}
+			mu.Lock()
+			calls[frame.CallId] = callCancel
+			mu.Unlock()
+
+			wg.Add(1)
+			go func(frame *proto.TunnelFrame) {
+				defer wg.Done()
+				defer func() {
+					mu.Lock()
+					delete(calls, frame.CallId)
+					mu.Unlock()
+					callCancel()
+				}()
+				t.handleCall(callCtx, local, frame, send)
+			}(frame)
+		case tunnelFrameCancel:
+			mu.Lock()
+			if callCancel, ok := calls[frame.CallId]; ok {
+				callCancel()
+			}
+			mu.Unlock()
+		default:
+			t.logger.Warn("unexpected control stream frame", zap.String("type", frame.Type))
+		}
+	}
+}
+
+// handleCall 将调用转发给本地gRPC服务器，返回响应帧和结束帧
+func (t *TunnelClient) handleCall(ctx context.Context, local *grpc.ClientConn, frame *proto.TunnelFrame, send func(*proto.TunnelFrame) error) {
+	t.logger.Debug("control stream call",
+		zap.String("call_id", frame.CallId),
+		zap.String("method", frame.Method))
+
+	sendMessage := func(payload []byte) error {
+		return send(&proto.TunnelFrame{Type: tunnelFrameMessage, CallId: frame.CallId, Payload: payload})
+	}
+
+	var err error
+	if frame.ServerStream {
+		err = t.forwardStream(ctx, local, frame, sendMessage)
+	} else {
+		reply := &rawMessage{}
+		err = local.Invoke(ctx, frame.Method, &rawMessage{data: frame.Payload}, reply, grpc.ForceCodec(rawCodec{}))
+		if err == nil {
+			err = sendMessage(reply.data)
+		}
+	}
+
+	// Manager取消的调用无需返回结束帧
+	if ctx.Err() != nil {
+		return
+	}
+	st := status.Convert(err)
+	if err := send(&proto.TunnelFrame{
+		Type:    tunnelFrameClose,
+		CallId:  frame.CallId,
+		Code:    int32(st.Code()),
+		Message: st.Message(),
+	}); err != nil {
+		t.logger.Warn("failed to send control stream response",
+			zap.String("method", frame.Method),
+			zap.Error(err))
+	}
+}
+
+// forwardStream 转发服务端流式调用，逐条返回响应
+func (t *TunnelClient) forwardStream(ctx context.Context, local *grpc.ClientConn, frame *proto.TunnelFrame, sendMessage func([]byte) error) error {
+	desc := &grpc.StreamDesc{ServerStreams: true}
+	cs, err := local.NewStream(ctx, desc, frame.Method, grpc.ForceCodec(rawCodec{}))
+	if err != nil {
+		return err
+	}
+	if err := cs.SendMsg(&rawMessage{data: frame.Payload}); err != nil {
+		return err
+	}
+	if err := cs.CloseSend(); err != nil {
+		return err
+	}
+	for {
+		reply := &rawMessage{}
+		if err := cs.RecvMsg(reply); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if err := sendMessage(reply.data); err != nil {
+			return status.Errorf(codes.Unavailable, "failed to send to control stream: %v", err)
+		}
+	}
+}
+
+// rawMessage 未解码的protobuf消息
+type rawMessage struct {
+	data []byte
+}
+
+// rawCodec 原样转发消息字节的编解码器
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v interface{}) ([]byte, error) {
+	msg, ok := v.(*rawMessage)
+	if !ok {
+		return nil, fmt.Errorf("unexpected message type %T", v)
+	}
+	return msg.data, nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	msg, ok := v.(*rawMessage)
+	if !ok {
+		return fmt.Errorf("unexpected message type %T", v)
+	}
+	msg.data = append([]byte(nil), data...)
+	return nil
+}
+
+func (rawCodec) Name() string {
+	return "proto"
+}
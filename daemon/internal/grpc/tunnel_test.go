@@ -0,0 +1,172 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/bingooyong/ops-scaffold-framework/daemon/pkg/proto"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+// tunnelTestDaemon 本地DaemonService(由控制流转发调用)
+type tunnelTestDaemon struct {
+	proto.UnimplementedDaemonServiceServer
+}
+
+func (d *tunnelTestDaemon) ListAgents(ctx context.Context, req *proto.ListAgentsRequest) (*proto.ListAgentsResponse, error) {
+	return &proto.ListAgentsResponse{Agents: []*proto.AgentInfo{{Id: "agent-1"}}}, nil
+}
+
+func (d *tunnelTestDaemon) FollowAgentLogs(req *proto.FollowAgentLogsRequest, stream proto.DaemonService_FollowAgentLogsServer) error {
+	for i := 0; i < 2; i++ {
+		if err := stream.Send(&proto.FollowAgentLogsResponse{Lines: []string{req.AgentId}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tunnelTestManager 模拟Manager，接收控制流并通过channel交给测试
+type tunnelTestManager struct {
+	proto.UnimplementedDaemonServiceServer
+	streams chan proto.DaemonService_ConnectServer
+}
+
+func (m *tunnelTestManager) Connect(stream proto.DaemonService_ConnectServer) error {
+	m.streams <- stream
+	<-stream.Context().Done()
+	return nil
+}
+
+// tunnelTestOpener 直接连接模拟Manager的控制流
+type tunnelTestOpener struct {
+	client proto.DaemonServiceClient
+}
+
+func (o *tunnelTestOpener) OpenControlStream(ctx context.Context) (proto.DaemonService_ConnectClient, error) {
+	return o.client.Connect(ctx)
+}
+
+// recvFrame 接收一帧，超时则测试失败
+func recvFrame(t *testing.T, stream proto.DaemonService_ConnectServer) *proto.TunnelFrame {
+	t.Helper()
+	frames := make(chan *proto.TunnelFrame, 1)
+	errs := make(chan error, 1)
+	go func() {
+		frame, err := stream.Recv()
+		if err != nil {
+			errs <- err
+			return
+		}
+		frames <- frame
+	}()
+	select {
+	case frame := <-frames:
+		return frame
+	case err := <-errs:
+		t.Fatalf("failed to receive frame: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for frame")
+	}
+	return nil
+}
+
+func TestTunnelClient_ForwardsCalls(t *testing.T) {
+	logger := zap.NewNop()
+
+	// 模拟Manager
+	manager := &tunnelTestManager{streams: make(chan proto.DaemonService_ConnectServer, 1)}
+	managerServer := grpc.NewServer()
+	proto.RegisterDaemonServiceServer(managerServer, manager)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	go managerServer.Serve(lis)
+	defer managerServer.Stop()
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to dial manager: %v", err)
+	}
+	defer conn.Close()
+
+	// 本地DaemonService
+	daemonServer := grpc.NewServer()
+	proto.RegisterDaemonServiceServer(daemonServer, &tunnelTestDaemon{})
+	defer daemonServer.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	client := NewTunnelClient(&tunnelTestOpener{client: proto.NewDaemonServiceClient(conn)}, daemonServer, "node-1", time.Second, logger)
+	go func() {
+		client.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	var stream proto.DaemonService_ConnectServer
+	select {
+	case stream = <-manager.streams:
+	case <-time.After(5 * time.Second):
+		t.Fatal("control stream not established")
+	}
+
+	hello := recvFrame(t, stream)
+	if hello.Type != tunnelFrameHello || hello.NodeId != "node-1" {
+		t.Fatalf("unexpected hello frame: %v", hello)
+	}
+
+	// 一元调用
+	payload, _ := protobuf.Marshal(&proto.ListAgentsRequest{})
+	if err := stream.Send(&proto.TunnelFrame{Type: tunnelFrameRequest, CallId: "1", Method: proto.DaemonService_ListAgents_FullMethodName, Payload: payload}); err != nil {
+		t.Fatalf("failed to send request: %v", err)
+	}
+	frame := recvFrame(t, stream)
+	if frame.Type != tunnelFrameMessage || frame.CallId != "1" {
+		t.Fatalf("expected message frame, got %v", frame)
+	}
+	listResp := &proto.ListAgentsResponse{}
+	if err := protobuf.Unmarshal(frame.Payload, listResp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if len(listResp.Agents) != 1 || listResp.Agents[0].Id != "agent-1" {
+		t.Errorf("unexpected agents: %v", listResp.Agents)
+	}
+	if frame := recvFrame(t, stream); frame.Type != tunnelFrameClose || codes.Code(frame.Code) != codes.OK {
+		t.Fatalf("expected OK close frame, got %v", frame)
+	}
+
+	// 服务端流式调用
+	payload, _ = protobuf.Marshal(&proto.FollowAgentLogsRequest{AgentId: "agent-1"})
+	if err := stream.Send(&proto.TunnelFrame{Type: tunnelFrameRequest, CallId: "2", Method: proto.DaemonService_FollowAgentLogs_FullMethodName, Payload: payload, ServerStream: true}); err != nil {
+		t.Fatalf("failed to send request: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		frame := recvFrame(t, stream)
+		logsResp := &proto.FollowAgentLogsResponse{}
+		if frame.Type != tunnelFrameMessage || protobuf.Unmarshal(frame.Payload, logsResp) != nil || len(logsResp.Lines) != 1 {
+			t.Fatalf("unexpected stream message: %v", frame)
+		}
+	}
+	if frame := recvFrame(t, stream); frame.Type != tunnelFrameClose || codes.Code(frame.Code) != codes.OK {
+		t.Fatalf("expected OK close frame, got %v", frame)
+	}
+
+	// 本地未实现的方法返回错误码
+	if err := stream.Send(&proto.TunnelFrame{Type: tunnelFrameRequest, CallId: "3", Method: proto.DaemonService_GetConfig_FullMethodName}); err != nil {
+		t.Fatalf("failed to send request: %v", err)
+	}
+	if frame := recvFrame(t, stream); frame.Type != tunnelFrameClose || codes.Code(frame.Code) != codes.Unimplemented {
+		t.Fatalf("expected Unimplemented close frame, got %v", frame)
+	}
+}
@@ -101,6 +101,30 @@ func newRotateWriter(cfg *Config) (*os.File, error) {
 	return file, nil
 }
 
+// NewAuditLogger 创建审计日志记录器，以JSON行格式追加写入path(文件权限0600)
+// 返回的close函数关闭日志文件
+func NewAuditLogger(path string) (*zap.Logger, func() error, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, nil, fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+
+	encoderConfig := zapcore.EncoderConfig{
+		TimeKey:        "time",
+		LevelKey:       "level",
+		MessageKey:     "msg",
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeLevel:    zapcore.LowercaseLevelEncoder,
+		EncodeTime:     zapcore.ISO8601TimeEncoder,
+		EncodeDuration: zapcore.SecondsDurationEncoder,
+	}
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(encoderConfig), zapcore.AddSync(file), zapcore.InfoLevel)
+	return zap.New(core), file.Close, nil
+}
+
 // Sync 刷新日志缓冲
 func Sync() error {
 	if Logger != nil {
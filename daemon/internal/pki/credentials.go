@@ -0,0 +1,397 @@
+package pki
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bingooyong/ops-scaffold-framework/daemon/internal/config"
+	managerpb "github.com/bingooyong/ops-scaffold-framework/daemon/pkg/proto/manager"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// NodeOrganization Manager内置CA签发的节点证书的Organization(与 manager/internal/pki 保持一致)
+const NodeOrganization = "ops-daemon"
+
+// ErrNoCertificate 客户端证书尚未签发
+var ErrNoCertificate = errors.New("client certificate not available")
+
+// RenewFunc 使用当前证书向Manager申请续期，返回新证书和CA证书(PEM)
+type RenewFunc func(ctx context.Context, csrPEM []byte) (certPEM, caPEM []byte, err error)
+
+// Credentials Daemon连接Manager使用的mTLS凭证
+// 证书和CA在申请、续期后原子替换，之后的新连接立即使用新证书，无需重建客户端
+type Credentials struct {
+	nodeID   string
+	certFile string
+	keyFile  string
+	caFile   string
+	logger   *zap.Logger
+
+	mu    sync.Mutex // 串行化申请和续期
+	cert  atomic.Pointer[tls.Certificate]
+	roots atomic.Pointer[x509.CertPool]
+}
+
+// NewCredentials 创建mTLS凭证，证书文件路径取自Manager TLS配置
+func NewCredentials(nodeID string, cfg *config.TLSConfig, logger *zap.Logger) *Credentials {
+	return &Credentials{
+		nodeID:   nodeID,
+		certFile: cfg.CertFile,
+		keyFile:  cfg.KeyFile,
+		caFile:   cfg.CAFile,
+		logger:   logger,
+	}
+}
+
+// Load 从文件加载CA和客户端证书，文件不存在时不报错(可稍后申请)
+func (c *Credentials) Load() error {
+	if caPEM, err := os.ReadFile(c.caFile); err == nil {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return fmt.Errorf("failed to parse CA file %s", c.caFile)
+		}
+		c.roots.Store(pool)
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read CA file: %w", err)
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to load client certificate: %w", err)
+	}
+	c.cert.Store(&cert)
+	return nil
+}
+
+// Ready 客户端证书和CA均已就绪
+func (c *Credentials) Ready() bool {
+	return c.cert.Load() != nil && c.roots.Load() != nil
+}
+
+// Leaf 返回当前客户端证书
+func (c *Credentials) Leaf() *x509.Certificate {
+	cert := c.cert.Load()
+	if cert == nil {
+		return nil
+	}
+	return cert.Leaf
+}
+
+// TransportCredentials 返回gRPC传输凭证
+// 每次握手读取当前证书和CA，服务端证书按连接地址校验
+func (c *Credentials) TransportCredentials() credentials.TransportCredentials {
+	return credentials.NewTLS(&tls.Config{
+		MinVersion: tls.VersionTLS13,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			if cert := c.cert.Load(); cert != nil {
+				return cert, nil
+			}
+			return &tls.Certificate{}, nil
+		},
+		// 服务端证书在VerifyConnection中使用当前CA校验，CA可能在运行中更新
+		InsecureSkipVerify: true,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			roots := c.roots.Load()
+			if roots == nil {
+				return ErrNoCertificate
+			}
+			return verifyServer(cs, roots)
+		},
+	})
+}
+
+// ServerTLSConfig 返回Daemon gRPC服务端TLS配置
+// 以节点证书作为服务端证书，要求客户端出示当前CA签发的Manager证书，节点证书被拒绝(防止节点之间互相调用)
+func (c *Credentials) ServerTLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS13,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			if cert := c.cert.Load(); cert != nil {
+				return cert, nil
+			}
+			return nil, ErrNoCertificate
+		},
+		// 客户端证书在VerifyConnection中使用当前CA校验
+		ClientAuth: tls.RequireAnyClientCert,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			roots := c.roots.Load()
+			if roots == nil {
+				return ErrNoCertificate
+			}
+			return verifyManagerClient(cs, roots)
+		},
+	}
+}
+
+// Enroll 凭加入令牌向Manager申请客户端证书
+// ca_file不存在时使用caCertHash校验Manager证书链中的CA，避免令牌发送给伪造的Manager
+func (c *Credentials) Enroll(ctx context.Context, address, token, caCertHash string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if token == "" {
+		return fmt.Errorf("%w and manager.enroll.token is not set", ErrNoCertificate)
+	}
+	roots := c.roots.Load()
+	if roots == nil && caCertHash == "" {
+		return errors.New("manager.enroll.ca_cert_hash is required to enroll without ca_file")
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS13,
+		InsecureSkipVerify: true,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			if roots != nil {
+				return verifyServer(cs, roots)
+			}
+			return verifyPinnedServer(cs, caCertHash)
+		},
+	}
+	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	if err != nil {
+		return fmt.Errorf("failed to dial manager: %w", err)
+	}
+	defer conn.Close()
+
+	key, csrPEM, err := c.newCSR()
+	if err != nil {
+		return err
+	}
+	resp, err := managerpb.NewManagerServiceClient(conn).Enroll(ctx, &managerpb.EnrollRequest{
+		NodeId: c.nodeID,
+		Token:  token,
+		Csr:    csrPEM,
+	})
+	if err != nil {
+		return fmt.Errorf("enroll failed: %w", err)
+	}
+
+	if err := c.install(key, resp.Certificate, resp.CaCertificate); err != nil {
+		return err
+	}
+	c.logger.Info("client certificate enrolled",
+		zap.String("node_id", c.nodeID),
+		zap.Time("expires_at", time.Unix(resp.ExpiresAt, 0)))
+	return nil
+}
+
+// NeedsRenewal 证书剩余有效期不足总有效期的1/3时需要续期
+// 只续期由Manager内置CA签发的节点证书，手工放置的证书保持不变
+func (c *Credentials) NeedsRenewal(now time.Time) bool {
+	leaf := c.Leaf()
+	if leaf == nil || !isNodeCert(leaf) {
+		return false
+	}
+	lifetime := leaf.NotAfter.Sub(leaf.NotBefore)
+	return leaf.NotAfter.Sub(now) < lifetime/3
+}
+
+// Renew 生成新密钥并通过当前mTLS连接续期证书
+func (c *Credentials) Renew(ctx context.Context, renew RenewFunc) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cert.Load() == nil {
+		return ErrNoCertificate
+	}
+	key, csrPEM, err := c.newCSR()
+	if err != nil {
+		return err
+	}
+	certPEM, caPEM, err := renew(ctx, csrPEM)
+	if err != nil {
+		return fmt.Errorf("renew certificate failed: %w", err)
+	}
+	if err := c.install(key, certPEM, caPEM); err != nil {
+		return err
+	}
+	c.logger.Info("client certificate renewed",
+		zap.String("node_id", c.nodeID),
+		zap.Time("expires_at", c.Leaf().NotAfter))
+	return nil
+}
+
+// RunRenewal 定期检查证书有效期并在需要时续期，阻塞直到ctx取消
+func (c *Credentials) RunRenewal(ctx context.Context, interval time.Duration, renew RenewFunc) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if c.NeedsRenewal(time.Now()) {
+			renewCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+			if err := c.Renew(renewCtx, renew); err != nil {
+				leaf := c.Leaf()
+				c.logger.Warn("failed to renew client certificate, will retry",
+					zap.Time("expires_at", leaf.NotAfter),
+					zap.Error(err))
+			}
+			cancel()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// newCSR 生成新的ECDSA P-256私钥和以节点ID为CN的证书签名请求
+func (c *Credentials) newCSR() (*ecdsa.PrivateKey, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: c.nodeID},
+	}, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create CSR: %w", err)
+	}
+	return key, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}), nil
+}
+
+// install 校验签发的证书并写入文件，然后替换当前凭证
+func (c *Credentials) install(key *ecdsa.PrivateKey, certPEM, caPEM []byte) error {
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return errors.New("invalid CA certificate in response")
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return fmt.Errorf("failed to marshal key: %w", err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return fmt.Errorf("invalid certificate in response: %w", err)
+	}
+	if _, err := cert.Leaf.Verify(x509.VerifyOptions{
+		Roots:     pool,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}); err != nil {
+		return fmt.Errorf("issued certificate is not signed by CA: %w", err)
+	}
+	if cert.Leaf.Subject.CommonName != c.nodeID {
+		return fmt.Errorf("issued certificate is bound to %q, expected %q", cert.Leaf.Subject.CommonName, c.nodeID)
+	}
+
+	if err := writeFile(c.keyFile, keyPEM, 0600); err != nil {
+		return err
+	}
+	if err := writeFile(c.certFile, certPEM, 0644); err != nil {
+		return err
+	}
+	if err := writeFile(c.caFile, caPEM, 0644); err != nil {
+		return err
+	}
+
+	c.roots.Store(pool)
+	c.cert.Store(&cert)
+	return nil
+}
+
+// verifyServer 使用CA校验服务端证书链和主机名
+func verifyServer(cs tls.ConnectionState, roots *x509.CertPool) error {
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("server did not present a certificate")
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range cs.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := cs.PeerCertificates[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		DNSName:       cs.ServerName,
+	})
+	return err
+}
+
+// verifyManagerClient 使用CA校验客户端证书链，并拒绝节点证书
+func verifyManagerClient(cs tls.ConnectionState, roots *x509.CertPool) error {
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("client did not present a certificate")
+	}
+	leaf := cs.PeerCertificates[0]
+	intermediates := x509.NewCertPool()
+	for _, cert := range cs.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+	if _, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}); err != nil {
+		return err
+	}
+	if isNodeCert(leaf) {
+		return fmt.Errorf("node certificate %s is not allowed to call the daemon", leaf.Subject.CommonName)
+	}
+	return nil
+}
+
+// verifyPinnedServer 在服务端证书链中查找公钥指纹匹配的CA，并以其校验服务端证书
+func verifyPinnedServer(cs tls.ConnectionState, caCertHash string) error {
+	for _, cert := range cs.PeerCertificates {
+		if cert.IsCA && certHash(cert) == caCertHash {
+			roots := x509.NewCertPool()
+			roots.AddCert(cert)
+			return verifyServer(cs, roots)
+		}
+	}
+	return fmt.Errorf("manager CA does not match ca_cert_hash %s", caCertHash)
+}
+
+// certHash 返回证书公钥指纹("sha256:<hex>")
+func certHash(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// isNodeCert 是否为Manager内置CA签发的节点证书
+func isNodeCert(cert *x509.Certificate) bool {
+	for _, org := range cert.Subject.Organization {
+		if org == NodeOrganization {
+			return true
+		}
+	}
+	return false
+}
+
+// writeFile 原子写入文件(先写临时文件再重命名)
+func writeFile(path string, data []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, perm); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
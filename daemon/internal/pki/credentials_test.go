@@ -0,0 +1,322 @@
+package pki
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bingooyong/ops-scaffold-framework/daemon/internal/config"
+	managerpb "github.com/bingooyong/ops-scaffold-framework/daemon/pkg/proto/manager"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// testCA 测试用CA
+type testCA struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	serial  atomic.Int64
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate CA key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create CA: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	ca := &testCA{cert: cert, key: key, certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+	ca.serial.Store(1)
+	return ca
+}
+
+// issue 签发证书，返回PEM
+func (ca *testCA) issue(t *testing.T, template *x509.Certificate, pub interface{}) []byte {
+	t.Helper()
+	template.SerialNumber = big.NewInt(ca.serial.Add(1))
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, pub, ca.key)
+	if err != nil {
+		t.Fatalf("failed to issue certificate: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+// testManager 模拟Manager的证书签发接口，签发的证书剩余有效期不足1/3
+type testManager struct {
+	managerpb.UnimplementedManagerServiceServer
+	t  *testing.T
+	ca *testCA
+}
+
+func (m *testManager) sign(nodeID string, csrPEM []byte) ([]byte, error) {
+	block, _ := pem.Decode(csrPEM)
+	if block == nil {
+		return nil, errors.New("invalid CSR")
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	return m.ca.issue(m.t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: nodeID, Organization: []string{NodeOrganization}},
+		NotBefore:   time.Now().Add(-2 * time.Hour),
+		NotAfter:    time.Now().Add(30 * time.Minute),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, csr.PublicKey), nil
+}
+
+func (m *testManager) Enroll(ctx context.Context, req *managerpb.EnrollRequest) (*managerpb.EnrollResponse, error) {
+	if req.Token != "abc123.secret" {
+		return nil, errors.New("invalid token")
+	}
+	certPEM, err := m.sign(req.NodeId, req.Csr)
+	if err != nil {
+		return nil, err
+	}
+	return &managerpb.EnrollResponse{Certificate: certPEM, CaCertificate: m.ca.certPEM}, nil
+}
+
+func (m *testManager) RenewCertificate(ctx context.Context, req *managerpb.RenewCertificateRequest) (*managerpb.RenewCertificateResponse, error) {
+	certPEM, err := m.sign(peerNodeID(ctx), req.Csr)
+	if err != nil {
+		return nil, err
+	}
+	return &managerpb.RenewCertificateResponse{Certificate: certPEM, CaCertificate: m.ca.certPEM}, nil
+}
+
+func (m *testManager) Heartbeat(ctx context.Context, req *managerpb.HeartbeatRequest) (*managerpb.HeartbeatResponse, error) {
+	return &managerpb.HeartbeatResponse{Success: true, Message: peerNodeID(ctx)}, nil
+}
+
+// peerNodeID 返回客户端证书的CN
+func peerNodeID(ctx context.Context) string {
+	p, _ := peer.FromContext(ctx)
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.PeerCertificates) == 0 {
+		return ""
+	}
+	return tlsInfo.State.PeerCertificates[0].Subject.CommonName
+}
+
+// startTestManager 启动TLS模拟Manager，服务端证书链包含CA
+func startTestManager(t *testing.T, ca *testCA) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate server key: %v", err)
+	}
+	certPEM := ca.issue(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "manager"},
+		NotBefore:   time.Now().Add(-time.Hour),
+		NotAfter:    time.Now().Add(time.Hour),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+	}, &key.PublicKey)
+	keyDER, _ := x509.MarshalPKCS8PrivateKey(key)
+	cert, err := tls.X509KeyPair(append(certPEM, ca.certPEM...), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}))
+	if err != nil {
+		t.Fatalf("failed to load server cert: %v", err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	server := grpc.NewServer(grpc.Creds(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.VerifyClientCertIfGiven,
+		ClientCAs:    pool,
+		MinVersion:   tls.VersionTLS13,
+	})))
+	managerpb.RegisterManagerServiceServer(server, &testManager{t: t, ca: ca})
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+	return lis.Addr().String()
+}
+
+func TestCredentials_EnrollAndRenew(t *testing.T) {
+	ca := newTestCA(t)
+	address := startTestManager(t, ca)
+	dir := t.TempDir()
+	tlsCfg := &config.TLSConfig{
+		CertFile: filepath.Join(dir, "certs", "client.crt"),
+		KeyFile:  filepath.Join(dir, "certs", "client.key"),
+		CAFile:   filepath.Join(dir, "certs", "ca.crt"),
+	}
+	ctx := context.Background()
+
+	creds := NewCredentials("node-1", tlsCfg, zap.NewNop())
+	if err := creds.Load(); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if creds.Ready() {
+		t.Fatal("credentials should not be ready before enrollment")
+	}
+
+	// CA指纹不匹配时不发送令牌
+	if err := creds.Enroll(ctx, address, "abc123.secret", "sha256:0000"); err == nil {
+		t.Fatal("expected enrollment to fail with mismatched CA hash")
+	}
+	if err := creds.Enroll(ctx, address, "abc123.secret", ""); err == nil {
+		t.Fatal("expected enrollment to fail without CA hash or CA file")
+	}
+
+	if err := creds.Enroll(ctx, address, "abc123.secret", certHash(ca.cert)); err != nil {
+		t.Fatalf("Enroll failed: %v", err)
+	}
+	if !creds.Ready() || creds.Leaf().Subject.CommonName != "node-1" {
+		t.Fatalf("unexpected certificate after enrollment: %v", creds.Leaf())
+	}
+	if info, err := os.Stat(tlsCfg.KeyFile); err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("key file not written with 0600: %v", err)
+	}
+
+	// 使用签发的证书调用Manager
+	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(creds.TransportCredentials()))
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer conn.Close()
+	client := managerpb.NewManagerServiceClient(conn)
+	resp, err := client.Heartbeat(ctx, &managerpb.HeartbeatRequest{NodeId: "node-1"})
+	if err != nil || resp.Message != "node-1" {
+		t.Fatalf("Heartbeat with client certificate failed: %v, %v", resp, err)
+	}
+
+	// 剩余有效期不足1/3时续期
+	if !creds.NeedsRenewal(time.Now()) {
+		t.Fatal("expected certificate to need renewal")
+	}
+	oldSerial := creds.Leaf().SerialNumber
+	err = creds.Renew(ctx, func(ctx context.Context, csrPEM []byte) ([]byte, []byte, error) {
+		resp, err := client.RenewCertificate(ctx, &managerpb.RenewCertificateRequest{NodeId: "node-1", Csr: csrPEM})
+		if err != nil {
+			return nil, nil, err
+		}
+		return resp.Certificate, resp.CaCertificate, nil
+	})
+	if err != nil {
+		t.Fatalf("Renew failed: %v", err)
+	}
+	if creds.Leaf().SerialNumber.Cmp(oldSerial) == 0 {
+		t.Fatal("certificate was not replaced")
+	}
+
+	// 重启后从文件加载
+	reloaded := NewCredentials("node-1", tlsCfg, zap.NewNop())
+	if err := reloaded.Load(); err != nil || !reloaded.Ready() {
+		t.Fatalf("failed to reload credentials: %v", err)
+	}
+	if reloaded.Leaf().SerialNumber.Cmp(creds.Leaf().SerialNumber) != 0 {
+		t.Fatal("reloaded certificate does not match renewed certificate")
+	}
+}
+
+// clientCert 签发客户端证书
+func (ca *testCA) clientCert(t *testing.T, cn string, orgs []string) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	certPEM := ca.issue(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: cn, Organization: orgs},
+		NotBefore:   time.Now().Add(-time.Hour),
+		NotAfter:    time.Now().Add(time.Hour),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, &key.PublicKey)
+	keyDER, _ := x509.MarshalPKCS8PrivateKey(key)
+	cert, err := tls.X509KeyPair(certPEM, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}))
+	if err != nil {
+		t.Fatalf("failed to load client cert: %v", err)
+	}
+	return cert
+}
+
+func TestCredentials_ServerTLSConfig(t *testing.T) {
+	ca := newTestCA(t)
+	address := startTestManager(t, ca)
+	dir := t.TempDir()
+	creds := NewCredentials("node-1", &config.TLSConfig{
+		CertFile: filepath.Join(dir, "client.crt"),
+		KeyFile:  filepath.Join(dir, "client.key"),
+		CAFile:   filepath.Join(dir, "ca.crt"),
+	}, zap.NewNop())
+	if err := creds.Enroll(context.Background(), address, "abc123.secret", certHash(ca.cert)); err != nil {
+		t.Fatalf("Enroll failed: %v", err)
+	}
+
+	// Daemon以节点证书提供服务，要求客户端出示Manager证书
+	server := grpc.NewServer(grpc.Creds(credentials.NewTLS(creds.ServerTLSConfig())))
+	managerpb.RegisterManagerServiceServer(server, &testManager{t: t, ca: ca})
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	call := func(certs []tls.Certificate) (*managerpb.HeartbeatResponse, error) {
+		conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{
+			Certificates:       certs,
+			InsecureSkipVerify: true,
+			MinVersion:         tls.VersionTLS13,
+		})))
+		if err != nil {
+			t.Fatalf("failed to dial: %v", err)
+		}
+		defer conn.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return managerpb.NewManagerServiceClient(conn).Heartbeat(ctx, &managerpb.HeartbeatRequest{})
+	}
+
+	resp, err := call([]tls.Certificate{ca.clientCert(t, "manager", nil)})
+	if err != nil || resp.Message != "manager" {
+		t.Fatalf("manager certificate should be accepted: %v, %v", resp, err)
+	}
+	if _, err := call([]tls.Certificate{ca.clientCert(t, "node-2", []string{NodeOrganization})}); err == nil {
+		t.Fatal("node certificate should be rejected")
+	}
+	if _, err := call(nil); err == nil {
+		t.Fatal("connection without client certificate should be rejected")
+	}
+	other := newTestCA(t)
+	if _, err := call([]tls.Certificate{other.clientCert(t, "manager", nil)}); err == nil {
+		t.Fatal("certificate from another CA should be rejected")
+	}
+}
@@ -0,0 +1,275 @@
+package spool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// defaultPeekSize 每次从队列读取的最大记录数
+	defaultPeekSize = 100
+	// defaultSendTimeout 单次发送超时
+	defaultSendTimeout = 30 * time.Second
+	// maxRetryInterval 发送失败后的最大重试间隔
+	maxRetryInterval = 5 * time.Minute
+	// maxRejectedAttempts Manager拒绝同一批记录的最大次数，超过后丢弃该批记录
+	maxRejectedAttempts = 3
+)
+
+var (
+	// ErrPermanent 发送器返回的错误包装了ErrPermanent时直接丢弃该批记录(如记录全部无法解码)
+	ErrPermanent = errors.New("spooled records can never be sent")
+	// ErrRejected Manager收到了记录但处理失败(响应Success为false)
+	// 可能是Manager侧的临时故障，重试maxRejectedAttempts次后丢弃，避免一批记录阻塞整个队列
+	ErrRejected = errors.New("spooled records rejected by manager")
+)
+
+// SendFunc 发送一批同类型记录，返回nil表示全部发送成功
+// 记录按写入顺序排列，Record.Time为记录的原始时间
+// 返回的错误按isPermanent分类：永久错误丢弃该批记录，其余错误保留记录稍后重试
+type SendFunc func(ctx context.Context, records []Record) error
+
+// sender 已注册的发送器
+type sender struct {
+	maxBatch int
+	send     SendFunc
+}
+
+// ForwarderStats 转发器统计
+type ForwarderStats struct {
+	Queue        Stats             // 队列统计
+	Enqueued     map[string]uint64 // 按类型统计的入队记录数
+	Sent         map[string]uint64 // 按类型统计的发送成功记录数
+	SendFailures uint64            // 发送失败次数
+}
+
+// Forwarder 从队列按顺序读取记录并通过已注册的发送器发送
+// 发送失败时保留记录并按指数退避重试，保证记录按写入顺序到达Manager
+// 无法发送成功的记录(永久错误或多次被Manager拒绝)计入DropReasonRejected后丢弃，不阻塞后续记录
+type Forwarder struct {
+	queue         *Queue
+	logger        *zap.Logger
+	retryInterval time.Duration
+
+	mu           sync.RWMutex
+	senders      map[string]sender
+	enqueued     map[string]uint64
+	sent         map[string]uint64
+	sendFailures uint64
+
+	// rejectedHead 最近一次被Manager拒绝的批次的首条记录，rejectedCount为连续被拒绝的次数
+	rejectedHead  position
+	rejectedCount int
+
+	notify chan struct{}
+	wake   chan struct{}
+}
+
+// NewForwarder 创建转发器
+func NewForwarder(queue *Queue, retryInterval time.Duration, logger *zap.Logger) *Forwarder {
+	if retryInterval <= 0 {
+		retryInterval = 10 * time.Second
+	}
+	return &Forwarder{
+		queue:         queue,
+		logger:        logger,
+		retryInterval: retryInterval,
+		senders:       make(map[string]sender),
+		enqueued:      make(map[string]uint64),
+		sent:          make(map[string]uint64),
+		notify:        make(chan struct{}, 1),
+		wake:          make(chan struct{}, 1),
+	}
+}
+
+// Register 注册某类型记录的发送器
+// maxBatch为单次发送的最大记录数，<=0表示不限制(受单次读取数量限制)
+func (f *Forwarder) Register(kind string, maxBatch int, send SendFunc) {
+	if maxBatch <= 0 || maxBatch > defaultPeekSize {
+		maxBatch = defaultPeekSize
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.senders[kind] = sender{maxBatch: maxBatch, send: send}
+}
+
+// Enqueue 写入一条记录并通知发送循环
+func (f *Forwarder) Enqueue(kind string, t time.Time, data []byte) error {
+	if err := f.queue.Append(kind, t, data); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	f.enqueued[kind]++
+	f.mu.Unlock()
+
+	select {
+	case f.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Wake 立即重试发送(例如与Manager的连接恢复后)
+func (f *Forwarder) Wake() {
+	select {
+	case f.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run 运行发送循环，直到ctx取消
+func (f *Forwarder) Run(ctx context.Context) {
+	backoff := f.retryInterval
+	for {
+		err := f.drain(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			f.logger.Warn("failed to forward spooled records, will retry",
+				zap.Duration("retry_in", backoff),
+				zap.Error(err))
+			select {
+			case <-ctx.Done():
+				return
+			case <-f.wake:
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+			if backoff > maxRetryInterval {
+				backoff = maxRetryInterval
+			}
+			continue
+		}
+
+		backoff = f.retryInterval
+		select {
+		case <-ctx.Done():
+			return
+		case <-f.notify:
+		case <-f.wake:
+		}
+	}
+}
+
+// drain 发送队列中的全部记录，直到队列为空或发送失败
+func (f *Forwarder) drain(ctx context.Context) error {
+	for ctx.Err() == nil {
+		records, err := f.queue.Peek(defaultPeekSize)
+		if err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			return nil
+		}
+
+		kind := records[0].Kind
+		f.mu.RLock()
+		s, ok := f.senders[kind]
+		f.mu.RUnlock()
+
+		n := 1
+		for n < len(records) && records[n].Kind == kind && (!ok || n < s.maxBatch) {
+			n++
+		}
+		batch := records[:n]
+
+		if !ok {
+			f.logger.Warn("no sender registered for spooled records, dropping",
+				zap.String("kind", kind),
+				zap.Int("records", n))
+			f.queue.addDropped(DropReasonUnknown, n)
+			if err := f.queue.Ack(batch[n-1]); err != nil {
+				return err
+			}
+			continue
+		}
+
+		sendCtx, cancel := context.WithTimeout(ctx, defaultSendTimeout)
+		err = s.send(sendCtx, batch)
+		cancel()
+		if err != nil {
+			f.mu.Lock()
+			f.sendFailures++
+			f.mu.Unlock()
+			if !f.shouldDrop(batch[0], err) {
+				return err
+			}
+			f.logger.Warn("spooled records can not be delivered, dropping",
+				zap.String("kind", kind),
+				zap.Int("records", n),
+				zap.Error(err))
+			f.queue.addDropped(DropReasonRejected, n)
+			if err := f.queue.Ack(batch[n-1]); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := f.queue.Ack(batch[n-1]); err != nil {
+			return err
+		}
+		f.mu.Lock()
+		f.sent[kind] += uint64(n)
+		f.mu.Unlock()
+	}
+	return ctx.Err()
+}
+
+// shouldDrop 判断发送失败的批次是否应丢弃
+// 永久错误直接丢弃，Manager拒绝的批次在同一首条记录连续被拒绝maxRejectedAttempts次后丢弃
+func (f *Forwarder) shouldDrop(head Record, err error) bool {
+	if isPermanent(err) {
+		return true
+	}
+	if !errors.Is(err, ErrRejected) {
+		return false
+	}
+	if f.rejectedCount == 0 || f.rejectedHead != head.pos {
+		f.rejectedHead = head.pos
+		f.rejectedCount = 0
+	}
+	f.rejectedCount++
+	if f.rejectedCount < maxRejectedAttempts {
+		return false
+	}
+	f.rejectedCount = 0
+	return true
+}
+
+// isPermanent 错误是否表示该批记录重试也无法发送成功
+// Manager返回参数错误、前置条件不满足或不支持的方法时重试结果相同，连接类错误保留重试
+func isPermanent(err error) bool {
+	if errors.Is(err, ErrPermanent) {
+		return true
+	}
+	switch status.Code(err) {
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange, codes.Unimplemented:
+		return true
+	}
+	return false
+}
+
+// Stats 返回转发器统计
+func (f *Forwarder) Stats() ForwarderStats {
+	stats := ForwarderStats{
+		Queue:    f.queue.Stats(),
+		Enqueued: make(map[string]uint64),
+		Sent:     make(map[string]uint64),
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	for kind, n := range f.enqueued {
+		stats.Enqueued[kind] = n
+	}
+	for kind, n := range f.sent {
+		stats.Sent[kind] = n
+	}
+	stats.SendFailures = f.sendFailures
+	return stats
+}
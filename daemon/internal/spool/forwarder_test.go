@@ -0,0 +1,202 @@
+package spool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// recordingSender 记录收到的批次，可模拟发送失败
+type recordingSender struct {
+	mu      sync.Mutex
+	fail    bool
+	batches [][]string
+	times   []time.Time
+}
+
+func (s *recordingSender) send(kind string) SendFunc {
+	return func(ctx context.Context, records []Record) error {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.fail {
+			return errors.New("manager unreachable")
+		}
+		var batch []string
+		for _, rec := range records {
+			batch = append(batch, kind+":"+string(rec.Data))
+			s.times = append(s.times, rec.Time)
+		}
+		s.batches = append(s.batches, batch)
+		return nil
+	}
+}
+
+func (s *recordingSender) setFail(fail bool) {
+	s.mu.Lock()
+	s.fail = fail
+	s.mu.Unlock()
+}
+
+func (s *recordingSender) received() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var all []string
+	for _, batch := range s.batches {
+		all = append(all, batch...)
+	}
+	return all
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestForwarder_ReplaysInOrderAfterOutage(t *testing.T) {
+	q := openTestQueue(t, t.TempDir(), Options{})
+	f := NewForwarder(q, 20*time.Millisecond, zap.NewNop())
+	sender := &recordingSender{fail: true}
+	f.Register("metrics", 0, sender.send("metrics"))
+	f.Register("state", 1, sender.send("state"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go f.Run(ctx)
+
+	// Manager不可达期间写入的记录保留在队列中
+	start := time.Now().Add(-time.Hour)
+	var want []string
+	for i := 0; i < 6; i++ {
+		kind := "metrics"
+		if i == 2 || i == 3 {
+			kind = "state"
+		}
+		data := fmt.Sprintf("%d", i)
+		if err := f.Enqueue(kind, start.Add(time.Duration(i)*time.Minute), []byte(data)); err != nil {
+			t.Fatalf("failed to enqueue: %v", err)
+		}
+		want = append(want, kind+":"+data)
+	}
+	waitFor(t, func() bool { return f.Stats().SendFailures > 0 })
+	if stats := f.Stats(); stats.Queue.Records != 6 {
+		t.Fatalf("records should stay queued during the outage, got %d", stats.Queue.Records)
+	}
+
+	sender.setFail(false)
+	f.Wake()
+	waitFor(t, func() bool { return f.Stats().Queue.Records == 0 })
+
+	got := sender.received()
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("records replayed out of order: got %v, want %v", got, want)
+	}
+	for i, ts := range sender.times {
+		if !ts.Equal(start.Add(time.Duration(i) * time.Minute)) {
+			t.Fatalf("record %d lost its original timestamp: %v", i, ts)
+		}
+	}
+
+	// 同类型的连续记录合并发送，state按单条发送
+	sender.mu.Lock()
+	batches := len(sender.batches)
+	sender.mu.Unlock()
+	if batches != 4 {
+		t.Fatalf("expected 4 batches (metrics, state, state, metrics), got %d", batches)
+	}
+
+	stats := f.Stats()
+	if stats.Enqueued["metrics"] != 4 || stats.Sent["metrics"] != 4 || stats.Sent["state"] != 2 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestForwarder_DropsUnknownKind(t *testing.T) {
+	q := openTestQueue(t, t.TempDir(), Options{})
+	f := NewForwarder(q, 20*time.Millisecond, zap.NewNop())
+	sender := &recordingSender{}
+	f.Register("metrics", 0, sender.send("metrics"))
+
+	q.Append("legacy", time.Now(), []byte("x"))
+	f.Enqueue("metrics", time.Now(), []byte("1"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go f.Run(ctx)
+
+	waitFor(t, func() bool { return f.Stats().Queue.Records == 0 })
+	if got := sender.received(); len(got) != 1 || got[0] != "metrics:1" {
+		t.Fatalf("unexpected records: %v", got)
+	}
+	if f.Stats().Queue.Dropped[DropReasonUnknown] != 1 {
+		t.Fatal("unknown records should be counted as dropped")
+	}
+}
+
+func TestForwarder_DropsPoisonBatches(t *testing.T) {
+	q := openTestQueue(t, t.TempDir(), Options{})
+	f := NewForwarder(q, 10*time.Millisecond, zap.NewNop())
+	sender := &recordingSender{}
+	f.Register("metrics", 0, sender.send("metrics"))
+	f.Register("invalid", 0, func(ctx context.Context, records []Record) error {
+		return fmt.Errorf("gRPC call failed: %w", status.Error(codes.InvalidArgument, "bad node id"))
+	})
+	var rejects int
+	f.Register("rejected", 0, func(ctx context.Context, records []Record) error {
+		rejects++
+		return fmt.Errorf("report failed: database is locked: %w", ErrRejected)
+	})
+
+	f.Enqueue("invalid", time.Now(), []byte("x"))
+	f.Enqueue("metrics", time.Now(), []byte("1"))
+	f.Enqueue("rejected", time.Now(), []byte("y"))
+	f.Enqueue("metrics", time.Now(), []byte("2"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go f.Run(ctx)
+
+	// 永久错误的批次立即丢弃，被拒绝的批次重试有限次后丢弃，都不阻塞后续记录
+	waitFor(t, func() bool { return f.Stats().Queue.Records == 0 })
+	if got := sender.received(); fmt.Sprint(got) != "[metrics:1 metrics:2]" {
+		t.Fatalf("unexpected records: %v", got)
+	}
+	if rejects != maxRejectedAttempts {
+		t.Fatalf("rejected batch should be retried %d times, got %d", maxRejectedAttempts, rejects)
+	}
+	if dropped := f.Stats().Queue.Dropped[DropReasonRejected]; dropped != 2 {
+		t.Fatalf("poison batches should be counted as rejected, got %d", dropped)
+	}
+}
+
+func TestIsPermanent(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{errors.New("gRPC client not connected"), false},
+		{status.Error(codes.Unavailable, "connection refused"), false},
+		{status.Error(codes.DeadlineExceeded, "timeout"), false},
+		{fmt.Errorf("sync failed: db error: %w", ErrRejected), false},
+		{fmt.Errorf("undecodable: %w", ErrPermanent), true},
+		{status.Error(codes.FailedPrecondition, "stale agent state"), true},
+		{fmt.Errorf("gRPC call failed: %w", status.Error(codes.InvalidArgument, "bad request")), true},
+	}
+	for _, tt := range tests {
+		if got := isPermanent(tt.err); got != tt.want {
+			t.Errorf("isPermanent(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,615 @@
+// Package spool 提供Manager不可达时的本地持久化发送队列(预写日志)
+// 记录按写入顺序追加到段文件，发送成功后推进确认位置，Daemon重启后从确认位置继续重放
+package spool
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// segmentExt 段文件扩展名，文件名为段内第一条记录的序号
+	segmentExt = ".wal"
+	// cursorFile 确认位置文件
+	cursorFile = "cursor"
+	// recordHeaderSize 记录头大小(长度+CRC32)
+	recordHeaderSize = 8
+	// maxRecordSize 单条记录最大大小
+	maxRecordSize = 16 << 20
+
+	// DefaultMaxBytes 默认队列最大磁盘占用
+	DefaultMaxBytes = 256 << 20
+	// DefaultMaxAge 默认记录最长保留时间
+	DefaultMaxAge = 72 * time.Hour
+	// DefaultSegmentBytes 默认段文件大小
+	DefaultSegmentBytes = 8 << 20
+)
+
+// ErrClosed 队列已关闭
+var ErrClosed = errors.New("spool queue is closed")
+
+// 记录被丢弃的原因
+const (
+	DropReasonSize     = "size"     // 超出磁盘占用上限
+	DropReasonAge      = "age"      // 超出保留时间
+	DropReasonCorrupt  = "corrupt"  // 段文件损坏
+	DropReasonUnknown  = "unknown"  // 没有对应的发送器
+	DropReasonRejected = "rejected" // 发送器或Manager拒绝，重试无法成功
+)
+
+// Options 队列配置
+type Options struct {
+	MaxBytes     int64         // 队列最大磁盘占用，超出时丢弃最旧的段
+	MaxAge       time.Duration // 记录最长保留时间(按记录原始时间)，超出时丢弃
+	SegmentBytes int64         // 单个段文件大小
+}
+
+// Record 队列中的一条记录
+type Record struct {
+	Kind string    // 记录类型(决定由哪个发送器处理)
+	Time time.Time // 记录原始时间
+	Data []byte    // 记录内容
+
+	pos position
+}
+
+// position 记录在队列中的位置
+type position struct {
+	segment uint64 // 所在段(第一条记录序号)
+	end     int64  // 记录结束偏移
+	index   int    // 记录在段内的序号(从1开始)
+}
+
+// Stats 队列统计
+type Stats struct {
+	Records    int               // 未确认记录数
+	Bytes      int64             // 未确认记录占用的磁盘空间
+	Segments   int               // 段文件数量
+	OldestTime time.Time         // 最旧未确认记录的原始时间(队列为空时为零值)
+	Dropped    map[string]uint64 // 按原因统计的丢弃记录数
+}
+
+// segment 段文件
+type segment struct {
+	first   uint64    // 第一条记录序号(文件名)
+	path    string    // 文件路径
+	size    int64     // 有效数据大小
+	records int       // 记录数
+	last    time.Time // 最后一条记录的原始时间
+}
+
+// Queue 磁盘持久化FIFO队列
+// 所有方法并发安全
+type Queue struct {
+	dir    string
+	opts   Options
+	logger *zap.Logger
+
+	mu         sync.Mutex
+	segments   []*segment
+	active     *os.File // 最后一个段的追加句柄
+	nextSeq    uint64
+	headOffset int64 // segments[0]中第一条未确认记录的偏移
+	headAcked  int   // segments[0]中已确认的记录数
+	dropped    map[string]uint64
+	closed     bool
+}
+
+// Open 打开(或创建)队列目录，恢复上次的确认位置并截断损坏的记录
+func Open(dir string, opts Options, logger *zap.Logger) (*Queue, error) {
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = DefaultMaxBytes
+	}
+	if opts.MaxAge <= 0 {
+		opts.MaxAge = DefaultMaxAge
+	}
+	if opts.SegmentBytes <= 0 {
+		opts.SegmentBytes = DefaultSegmentBytes
+	}
+	// 至少保留两个段，超出上限时才能丢弃最旧的段
+	if opts.SegmentBytes > opts.MaxBytes/2 {
+		opts.SegmentBytes = opts.MaxBytes / 2
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create spool directory: %w", err)
+	}
+
+	q := &Queue{
+		dir:     dir,
+		opts:    opts,
+		logger:  logger,
+		nextSeq: 1,
+		dropped: make(map[string]uint64),
+	}
+	if err := q.load(); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// load 扫描段文件并恢复确认位置
+func (q *Queue) load() error {
+	entries, err := os.ReadDir(q.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read spool directory: %w", err)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		first, err := strconv.ParseUint(strings.TrimSuffix(name, segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		q.segments = append(q.segments, &segment{first: first, path: filepath.Join(q.dir, name)})
+	}
+	sort.Slice(q.segments, func(i, j int) bool { return q.segments[i].first < q.segments[j].first })
+
+	// 扫描段文件，截断损坏的尾部
+	valid := q.segments[:0]
+	for _, seg := range q.segments {
+		lastSeq, err := q.scanSegment(seg)
+		if err != nil {
+			return err
+		}
+		if seg.records == 0 {
+			os.Remove(seg.path)
+			continue
+		}
+		if lastSeq >= q.nextSeq {
+			q.nextSeq = lastSeq + 1
+		}
+		valid = append(valid, seg)
+	}
+	q.segments = valid
+
+	// 恢复确认位置：删除已确认的段
+	cur, err := q.readCursor()
+	if err != nil {
+		q.logger.Warn("failed to read spool cursor, replaying from the oldest record", zap.Error(err))
+	}
+	if cur.segment >= q.nextSeq && len(q.segments) == 0 {
+		q.nextSeq = cur.segment
+	}
+	for len(q.segments) > 0 && q.segments[0].first < cur.segment {
+		os.Remove(q.segments[0].path)
+		q.segments = q.segments[1:]
+	}
+	if len(q.segments) > 0 && q.segments[0].first == cur.segment && cur.end <= q.segments[0].size && cur.index <= q.segments[0].records {
+		q.headOffset = cur.end
+		q.headAcked = cur.index
+	}
+	if len(q.segments) > 0 && q.headAcked == q.segments[0].records && len(q.segments) > 1 {
+		q.removeHeadLocked()
+	}
+	return q.writeCursorLocked()
+}
+
+// scanSegment 扫描段文件，统计记录数并截断损坏的尾部，返回最后一条记录的序号
+func (q *Queue) scanSegment(seg *segment) (uint64, error) {
+	f, err := os.Open(seg.path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open spool segment: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat spool segment: %w", err)
+	}
+
+	var lastSeq uint64
+	reader := bufio.NewReader(f)
+	for {
+		rec, seq, n, err := readRecord(reader)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			q.logger.Warn("spool segment is corrupted, truncating",
+				zap.String("segment", seg.path),
+				zap.Int64("offset", seg.size),
+				zap.Error(err))
+			q.dropped[DropReasonCorrupt]++
+			if err := os.Truncate(seg.path, seg.size); err != nil {
+				return 0, fmt.Errorf("failed to truncate spool segment: %w", err)
+			}
+			break
+		}
+		seg.size += n
+		seg.records++
+		seg.last = rec.Time
+		lastSeq = seq
+	}
+	if seg.size > info.Size() {
+		seg.size = info.Size()
+	}
+	return lastSeq, nil
+}
+
+// Append 追加一条记录并写入磁盘
+// 超出磁盘占用或保留时间上限时丢弃最旧的段
+func (q *Queue) Append(kind string, t time.Time, data []byte) error {
+	if len(kind) == 0 || len(kind) > 255 {
+		return fmt.Errorf("invalid record kind %q", kind)
+	}
+	if len(data) > maxRecordSize-recordHeaderSize-17-len(kind) {
+		return fmt.Errorf("record too large: %d bytes", len(data))
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return ErrClosed
+	}
+
+	buf := encodeRecord(q.nextSeq, kind, t, data)
+
+	seg, err := q.activeSegmentLocked(int64(len(buf)))
+	if err != nil {
+		return err
+	}
+	if _, err := q.active.Write(buf); err != nil {
+		// 写入失败可能留下不完整的记录，截断到写入前的位置
+		q.active.Truncate(seg.size)
+		return fmt.Errorf("failed to write spool record: %w", err)
+	}
+	if err := q.active.Sync(); err != nil {
+		return fmt.Errorf("failed to sync spool segment: %w", err)
+	}
+	seg.size += int64(len(buf))
+	seg.records++
+	seg.last = t
+	q.nextSeq++
+
+	q.enforceLimitsLocked(time.Now())
+	return nil
+}
+
+// activeSegmentLocked 返回可写入size字节的段，当前段已满时新建段
+func (q *Queue) activeSegmentLocked(size int64) (*segment, error) {
+	if n := len(q.segments); n > 0 && q.active != nil {
+		seg := q.segments[n-1]
+		if seg.size == 0 || seg.size+size <= q.opts.SegmentBytes {
+			return seg, nil
+		}
+	}
+	if n := len(q.segments); n > 0 && q.active == nil {
+		// 重启后继续写入最后一个未满的段
+		seg := q.segments[n-1]
+		if seg.size+size <= q.opts.SegmentBytes {
+			f, err := os.OpenFile(seg.path, os.O_WRONLY|os.O_APPEND, 0600)
+			if err != nil {
+				return nil, fmt.Errorf("failed to open spool segment: %w", err)
+			}
+			q.active = f
+			return seg, nil
+		}
+	}
+
+	if q.active != nil {
+		q.active.Close()
+		q.active = nil
+	}
+	seg := &segment{first: q.nextSeq, path: filepath.Join(q.dir, fmt.Sprintf("%020d%s", q.nextSeq, segmentExt))}
+	f, err := os.OpenFile(seg.path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create spool segment: %w", err)
+	}
+	q.active = f
+	q.segments = append(q.segments, seg)
+	return seg, nil
+}
+
+// enforceLimitsLocked 丢弃超出磁盘占用或保留时间的最旧段(正在写入的段除外)
+func (q *Queue) enforceLimitsLocked(now time.Time) {
+	cutoff := now.Add(-q.opts.MaxAge)
+	for len(q.segments) > 1 {
+		head := q.segments[0]
+		var reason string
+		switch {
+		case q.bytesLocked() > q.opts.MaxBytes:
+			reason = DropReasonSize
+		case head.last.Before(cutoff):
+			reason = DropReasonAge
+		default:
+			return
+		}
+		dropped := head.records - q.headAcked
+		q.dropped[reason] += uint64(dropped)
+		q.logger.Warn("spool limit exceeded, dropping oldest records",
+			zap.String("reason", reason),
+			zap.Int("records", dropped))
+		q.removeHeadLocked()
+		if err := q.writeCursorLocked(); err != nil {
+			q.logger.Warn("failed to write spool cursor", zap.Error(err))
+		}
+	}
+}
+
+// removeHeadLocked 删除最旧的段
+func (q *Queue) removeHeadLocked() {
+	head := q.segments[0]
+	if len(q.segments) == 1 && q.active != nil {
+		q.active.Close()
+		q.active = nil
+	}
+	if err := os.Remove(head.path); err != nil && !os.IsNotExist(err) {
+		q.logger.Warn("failed to remove spool segment", zap.String("segment", head.path), zap.Error(err))
+	}
+	q.segments = q.segments[1:]
+	q.headOffset = 0
+	q.headAcked = 0
+}
+
+// Peek 按顺序读取最多max条未确认记录(不推进确认位置)
+// 超出保留时间的记录被丢弃，不会返回
+func (q *Queue) Peek(max int) ([]Record, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return nil, ErrClosed
+	}
+
+	cutoff := time.Now().Add(-q.opts.MaxAge)
+	var records []Record
+	var expired *position
+	expiredCount := 0
+	for i, seg := range q.segments {
+		if len(records) >= max {
+			break
+		}
+		offset, index := int64(0), 0
+		if i == 0 {
+			offset, index = q.headOffset, q.headAcked
+		}
+		if offset >= seg.size {
+			continue
+		}
+		err := readSegment(seg, offset, func(rec Record, n int64) bool {
+			offset += n
+			index++
+			rec.pos = position{segment: seg.first, end: offset, index: index}
+			if len(records) == 0 && rec.Time.Before(cutoff) {
+				expired = &rec.pos
+				expiredCount++
+				return true
+			}
+			records = append(records, rec)
+			return len(records) < max
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if expired != nil {
+		q.dropped[DropReasonAge] += uint64(expiredCount)
+		q.logger.Warn("dropping expired spool records",
+			zap.Int("records", expiredCount),
+			zap.Duration("max_age", q.opts.MaxAge))
+		if err := q.ackLocked(*expired); err != nil {
+			return nil, err
+		}
+	}
+	return records, nil
+}
+
+// Ack 确认rec及之前的所有记录已发送
+func (q *Queue) Ack(rec Record) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return ErrClosed
+	}
+	return q.ackLocked(rec.pos)
+}
+
+// ackLocked 推进确认位置并删除已全部确认的段
+func (q *Queue) ackLocked(pos position) error {
+	for len(q.segments) > 0 && q.segments[0].first < pos.segment {
+		q.removeHeadLocked()
+	}
+	if len(q.segments) == 0 || q.segments[0].first != pos.segment {
+		return q.writeCursorLocked()
+	}
+	if pos.end > q.headOffset {
+		q.headOffset = pos.end
+		q.headAcked = pos.index
+	}
+	// 非写入中的段全部确认后删除
+	if q.headAcked >= q.segments[0].records && len(q.segments) > 1 {
+		q.removeHeadLocked()
+	}
+	return q.writeCursorLocked()
+}
+
+// Stats 返回队列统计
+func (q *Queue) Stats() Stats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	stats := Stats{
+		Bytes:    q.bytesLocked(),
+		Segments: len(q.segments),
+		Dropped:  make(map[string]uint64, len(q.dropped)),
+	}
+	for reason, n := range q.dropped {
+		stats.Dropped[reason] = n
+	}
+	for _, seg := range q.segments {
+		stats.Records += seg.records
+	}
+	stats.Records -= q.headAcked
+	if stats.Records > 0 {
+		for i, seg := range q.segments {
+			offset := int64(0)
+			if i == 0 {
+				offset = q.headOffset
+			}
+			if offset >= seg.size {
+				continue
+			}
+			readSegment(seg, offset, func(rec Record, _ int64) bool {
+				stats.OldestTime = rec.Time
+				return false
+			})
+			break
+		}
+	}
+	return stats
+}
+
+// addDropped 记录被丢弃的记录数
+func (q *Queue) addDropped(reason string, n int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.dropped[reason] += uint64(n)
+}
+
+// Close 关闭队列
+func (q *Queue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return nil
+	}
+	q.closed = true
+	if q.active != nil {
+		err := q.active.Close()
+		q.active = nil
+		return err
+	}
+	return nil
+}
+
+// bytesLocked 未确认记录占用的磁盘空间
+func (q *Queue) bytesLocked() int64 {
+	var total int64
+	for _, seg := range q.segments {
+		total += seg.size
+	}
+	return total - q.headOffset
+}
+
+// writeCursorLocked 持久化确认位置(先写临时文件再重命名)
+func (q *Queue) writeCursorLocked() error {
+	cur := position{segment: q.nextSeq}
+	if len(q.segments) > 0 {
+		cur = position{segment: q.segments[0].first, end: q.headOffset, index: q.headAcked}
+	}
+	path := filepath.Join(q.dir, cursorFile)
+	tmp := path + ".tmp"
+	content := fmt.Sprintf("%d %d %d\n", cur.segment, cur.end, cur.index)
+	if err := os.WriteFile(tmp, []byte(content), 0600); err != nil {
+		return fmt.Errorf("failed to write spool cursor: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write spool cursor: %w", err)
+	}
+	return nil
+}
+
+// readCursor 读取确认位置，文件不存在时返回零值
+func (q *Queue) readCursor() (position, error) {
+	data, err := os.ReadFile(filepath.Join(q.dir, cursorFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return position{}, nil
+		}
+		return position{}, err
+	}
+	var cur position
+	if _, err := fmt.Sscanf(string(data), "%d %d %d", &cur.segment, &cur.end, &cur.index); err != nil {
+		return position{}, fmt.Errorf("invalid spool cursor: %w", err)
+	}
+	return cur, nil
+}
+
+// readSegment 从offset开始顺序读取段内记录，fn返回false时停止
+func readSegment(seg *segment, offset int64, fn func(rec Record, n int64) bool) error {
+	f, err := os.Open(seg.path)
+	if err != nil {
+		return fmt.Errorf("failed to open spool segment: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek spool segment: %w", err)
+	}
+
+	reader := bufio.NewReader(io.LimitReader(f, seg.size-offset))
+	for {
+		rec, _, n, err := readRecord(reader)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read spool segment %s: %w", seg.path, err)
+		}
+		if !fn(rec, n) {
+			return nil
+		}
+	}
+}
+
+// encodeRecord 编码记录：长度(4) | CRC32(4) | 序号(8) | 时间(8) | 类型长度(1) | 类型 | 内容
+func encodeRecord(seq uint64, kind string, t time.Time, data []byte) []byte {
+	bodyLen := 8 + 8 + 1 + len(kind) + len(data)
+	buf := make([]byte, recordHeaderSize+bodyLen)
+	body := buf[recordHeaderSize:]
+	binary.BigEndian.PutUint64(body[0:8], seq)
+	binary.BigEndian.PutUint64(body[8:16], uint64(t.UnixNano()))
+	body[16] = byte(len(kind))
+	copy(body[17:], kind)
+	copy(body[17+len(kind):], data)
+	binary.BigEndian.PutUint32(buf[0:4], uint32(bodyLen))
+	binary.BigEndian.PutUint32(buf[4:8], crc32.ChecksumIEEE(body))
+	return buf
+}
+
+// readRecord 读取一条记录，返回记录、序号和占用的字节数
+// 文件结尾返回io.EOF，不完整或校验失败的记录返回错误
+func readRecord(r io.Reader) (Record, uint64, int64, error) {
+	var header [recordHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		if err == io.EOF {
+			return Record{}, 0, 0, io.EOF
+		}
+		return Record{}, 0, 0, fmt.Errorf("truncated record header: %w", err)
+	}
+	bodyLen := binary.BigEndian.Uint32(header[0:4])
+	if bodyLen < 17 || bodyLen > maxRecordSize {
+		return Record{}, 0, 0, fmt.Errorf("invalid record length %d", bodyLen)
+	}
+	body := make([]byte, bodyLen)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return Record{}, 0, 0, fmt.Errorf("truncated record body: %w", err)
+	}
+	if crc32.ChecksumIEEE(body) != binary.BigEndian.Uint32(header[4:8]) {
+		return Record{}, 0, 0, errors.New("record checksum mismatch")
+	}
+	kindLen := int(body[16])
+	if 17+kindLen > len(body) {
+		return Record{}, 0, 0, errors.New("invalid record kind length")
+	}
+	rec := Record{
+		Kind: string(body[17 : 17+kindLen]),
+		Time: time.Unix(0, int64(binary.BigEndian.Uint64(body[8:16]))),
+		Data: body[17+kindLen:],
+	}
+	return rec, binary.BigEndian.Uint64(body[0:8]), int64(recordHeaderSize) + int64(bodyLen), nil
+}
@@ -0,0 +1,177 @@
+package spool
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func openTestQueue(t *testing.T, dir string, opts Options) *Queue {
+	t.Helper()
+	q, err := Open(dir, opts, zap.NewNop())
+	if err != nil {
+		t.Fatalf("failed to open queue: %v", err)
+	}
+	t.Cleanup(func() { q.Close() })
+	return q
+}
+
+func appendN(t *testing.T, q *Queue, kind string, from, to int) {
+	t.Helper()
+	for i := from; i < to; i++ {
+		if err := q.Append(kind, time.Now(), []byte(fmt.Sprintf("record-%d", i))); err != nil {
+			t.Fatalf("failed to append record %d: %v", i, err)
+		}
+	}
+}
+
+func TestQueue_AppendPeekAck(t *testing.T) {
+	q := openTestQueue(t, t.TempDir(), Options{SegmentBytes: 128})
+	start := time.Now().Add(-time.Hour)
+	for i := 0; i < 10; i++ {
+		if err := q.Append("metrics", start.Add(time.Duration(i)*time.Second), []byte(fmt.Sprintf("record-%d", i))); err != nil {
+			t.Fatalf("failed to append: %v", err)
+		}
+	}
+	if stats := q.Stats(); stats.Records != 10 || stats.Segments < 2 || !stats.OldestTime.Equal(start) {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+
+	records, err := q.Peek(4)
+	if err != nil {
+		t.Fatalf("failed to peek: %v", err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("expected 4 records, got %d", len(records))
+	}
+	for i, rec := range records {
+		if string(rec.Data) != fmt.Sprintf("record-%d", i) || rec.Kind != "metrics" {
+			t.Fatalf("unexpected record %d: %s/%s", i, rec.Kind, rec.Data)
+		}
+		if !rec.Time.Equal(start.Add(time.Duration(i) * time.Second)) {
+			t.Fatalf("record %d lost its original timestamp: %v", i, rec.Time)
+		}
+	}
+
+	// Peek不推进确认位置
+	again, _ := q.Peek(1)
+	if string(again[0].Data) != "record-0" {
+		t.Fatalf("peek should not consume records, got %s", again[0].Data)
+	}
+
+	if err := q.Ack(records[3]); err != nil {
+		t.Fatalf("failed to ack: %v", err)
+	}
+	records, _ = q.Peek(100)
+	if len(records) != 6 || string(records[0].Data) != "record-4" {
+		t.Fatalf("expected records 4..9 after ack, got %d starting at %s", len(records), records[0].Data)
+	}
+	if err := q.Ack(records[len(records)-1]); err != nil {
+		t.Fatalf("failed to ack: %v", err)
+	}
+	if stats := q.Stats(); stats.Records != 0 || stats.Segments != 1 || !stats.OldestTime.IsZero() {
+		t.Fatalf("expected empty queue with only the active segment, got %+v", stats)
+	}
+}
+
+func TestQueue_RecoverAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	q, err := Open(dir, Options{SegmentBytes: 128}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("failed to open queue: %v", err)
+	}
+	appendN(t, q, "state", 0, 8)
+	records, _ := q.Peek(3)
+	q.Ack(records[2])
+	q.Close()
+
+	q = openTestQueue(t, dir, Options{SegmentBytes: 128})
+	records, err = q.Peek(100)
+	if err != nil {
+		t.Fatalf("failed to peek: %v", err)
+	}
+	if len(records) != 5 || string(records[0].Data) != "record-3" {
+		t.Fatalf("expected to resume at record-3, got %d records", len(records))
+	}
+
+	// 重启后追加的记录排在未确认记录之后
+	appendN(t, q, "state", 8, 10)
+	records, _ = q.Peek(100)
+	if len(records) != 7 || string(records[6].Data) != "record-9" {
+		t.Fatalf("unexpected records after append: %d", len(records))
+	}
+}
+
+func TestQueue_TruncatesCorruptTail(t *testing.T) {
+	dir := t.TempDir()
+	q, err := Open(dir, Options{}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("failed to open queue: %v", err)
+	}
+	appendN(t, q, "events", 0, 3)
+	q.Close()
+
+	// 模拟写入过程中断电：追加半条记录
+	matches, _ := filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+	if len(matches) != 1 {
+		t.Fatalf("expected 1 segment, got %d", len(matches))
+	}
+	f, _ := os.OpenFile(matches[0], os.O_WRONLY|os.O_APPEND, 0600)
+	f.Write(encodeRecord(99, "events", time.Now(), []byte("partial"))[:12])
+	f.Close()
+
+	q = openTestQueue(t, dir, Options{})
+	appendN(t, q, "events", 3, 4)
+	records, err := q.Peek(100)
+	if err != nil {
+		t.Fatalf("failed to peek: %v", err)
+	}
+	if len(records) != 4 || string(records[3].Data) != "record-3" {
+		t.Fatalf("expected 4 intact records, got %d", len(records))
+	}
+	if q.Stats().Dropped[DropReasonCorrupt] != 1 {
+		t.Fatalf("expected corrupt drop to be counted, got %v", q.Stats().Dropped)
+	}
+}
+
+func TestQueue_SizeLimit(t *testing.T) {
+	q := openTestQueue(t, t.TempDir(), Options{MaxBytes: 512, SegmentBytes: 128})
+	appendN(t, q, "metrics", 0, 100)
+
+	stats := q.Stats()
+	if stats.Bytes > 512 {
+		t.Fatalf("queue exceeds size limit: %d bytes", stats.Bytes)
+	}
+	if stats.Dropped[DropReasonSize] == 0 || int(stats.Dropped[DropReasonSize])+stats.Records != 100 {
+		t.Fatalf("unexpected drop accounting: %+v", stats)
+	}
+
+	// 丢弃最旧的记录，保留最新的记录
+	records, _ := q.Peek(1000)
+	if string(records[len(records)-1].Data) != "record-99" {
+		t.Fatalf("newest record should be kept, got %s", records[len(records)-1].Data)
+	}
+}
+
+func TestQueue_AgeLimit(t *testing.T) {
+	q := openTestQueue(t, t.TempDir(), Options{MaxAge: time.Hour})
+	old := time.Now().Add(-2 * time.Hour)
+	q.Append("metrics", old, []byte("old-1"))
+	q.Append("metrics", old, []byte("old-2"))
+	q.Append("metrics", time.Now(), []byte("fresh"))
+
+	records, err := q.Peek(10)
+	if err != nil {
+		t.Fatalf("failed to peek: %v", err)
+	}
+	if len(records) != 1 || string(records[0].Data) != "fresh" {
+		t.Fatalf("expected only the fresh record, got %d", len(records))
+	}
+	if stats := q.Stats(); stats.Dropped[DropReasonAge] != 2 || stats.Records != 1 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
@@ -0,0 +1,204 @@
+// Package control 定义Daemon与Agent之间的控制协议
+//
+// Agent在其socket_path上监听Unix Socket(Daemon启动Agent时通过环境变量下发路径)，
+// Daemon连接后发送换行分隔的JSON请求，Agent在同一连接上按顺序返回JSON响应:
+//
+//	-> {"id":"1","command":"set_log_level","args":{"level":"debug"}}
+//	<- {"id":"1","success":true,"message":"log level set to debug"}
+//
+// 一个连接上可以发送多个请求。Agent不认识的命令应返回code为unknown_command的响应，
+// Daemon会改用配置的回退信号(如reload回退为SIGHUP)。
+package control
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// EnvSocket Daemon下发给Agent的控制Socket路径环境变量
+const EnvSocket = "OPS_AGENT_CONTROL_SOCKET"
+
+// 控制命令
+const (
+	// CommandPing 探测Agent是否支持控制协议，Data中返回supported_commands(逗号分隔)
+	CommandPing = "ping"
+
+	// CommandReload 重新加载配置
+	CommandReload = "reload"
+
+	// CommandSetLogLevel 调整日志级别，参数level
+	CommandSetLogLevel = "set_log_level"
+
+	// CommandDumpDiagnostics 返回诊断信息(协程数、内存、运行时长等)
+	CommandDumpDiagnostics = "dump_diagnostics"
+
+	// CommandDrain 停止接收新任务并处理完已有任务，之后Agent可以安全停止
+	CommandDrain = "drain"
+)
+
+// 响应错误码
+const (
+	// CodeUnknownCommand Agent不支持该命令
+	CodeUnknownCommand = "unknown_command"
+
+	// CodeInvalidArgs 命令参数错误
+	CodeInvalidArgs = "invalid_args"
+
+	// CodeFailed 命令执行失败
+	CodeFailed = "failed"
+)
+
+// maxMessageSize 单条请求/响应的最大字节数
+const maxMessageSize = 1 << 20
+
+// Commands 返回Daemon支持下发的控制命令
+func Commands() []string {
+	return []string{CommandReload, CommandSetLogLevel, CommandDumpDiagnostics, CommandDrain}
+}
+
+// IsValidCommand 检查是否为支持下发的控制命令
+func IsValidCommand(command string) bool {
+	for _, c := range Commands() {
+		if c == command {
+			return true
+		}
+	}
+	return false
+}
+
+// Request 控制请求
+type Request struct {
+	ID      string            `json:"id"`
+	Command string            `json:"command"`
+	Args    map[string]string `json:"args,omitempty"`
+}
+
+// Response 控制响应
+type Response struct {
+	ID      string            `json:"id"`
+	Success bool              `json:"success"`
+	Code    string            `json:"code,omitempty"`
+	Message string            `json:"message,omitempty"`
+	Data    map[string]string `json:"data,omitempty"`
+}
+
+// OK 创建成功响应
+func OK(message string, data map[string]string) *Response {
+	return &Response{Success: true, Message: message, Data: data}
+}
+
+// Fail 创建失败响应
+func Fail(code, message string) *Response {
+	return &Response{Success: false, Code: code, Message: message}
+}
+
+// Call 连接Agent控制Socket并发送一条请求，等待响应
+// ctx的截止时间同时作用于连接和读写
+func Call(ctx context.Context, socketPath string, req *Request) (*Response, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "unix", socketPath)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal control request: %w", err)
+	}
+	if _, err := conn.Write(append(data, '\n')); err != nil {
+		return nil, fmt.Errorf("failed to send control request: %w", err)
+	}
+
+	reader := bufio.NewReaderSize(conn, 4096)
+	line, err := readLine(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read control response: %w", err)
+	}
+
+	var resp Response
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return nil, fmt.Errorf("invalid control response: %w", err)
+	}
+	return &resp, nil
+}
+
+// Handler 控制命令处理函数
+type Handler func(ctx context.Context, req *Request) *Response
+
+// Serve 在listener上处理控制请求，直到listener关闭
+// 每个连接按顺序处理请求，handler返回nil时视为不支持该命令
+func Serve(ctx context.Context, listener net.Listener, handler Handler) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go serveConn(ctx, conn, handler)
+	}
+}
+
+// serveConn 处理单个连接上的请求
+func serveConn(ctx context.Context, conn net.Conn, handler Handler) {
+	defer conn.Close()
+
+	reader := bufio.NewReaderSize(conn, 4096)
+	for {
+		line, err := readLine(reader)
+		if err != nil {
+			return
+		}
+
+		var req Request
+		var resp *Response
+		if err := json.Unmarshal(line, &req); err != nil {
+			resp = Fail(CodeInvalidArgs, "invalid request: "+err.Error())
+		} else if resp = handler(ctx, &req); resp == nil {
+			resp = Fail(CodeUnknownCommand, "unknown command: "+req.Command)
+		}
+		resp.ID = req.ID
+
+		data, err := json.Marshal(resp)
+		if err != nil {
+			return
+		}
+		conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		if _, err := conn.Write(append(data, '\n')); err != nil {
+			return
+		}
+	}
+}
+
+// readLine 读取一行(不含换行符)，超过maxMessageSize时返回错误
+func readLine(reader *bufio.Reader) ([]byte, error) {
+	var line []byte
+	for {
+		chunk, isPrefix, err := reader.ReadLine()
+		if err != nil {
+			if err == io.EOF && len(line) > 0 {
+				return line, nil
+			}
+			return nil, err
+		}
+		line = append(line, chunk...)
+		if len(line) > maxMessageSize {
+			return nil, fmt.Errorf("control message exceeds %d bytes", maxMessageSize)
+		}
+		if !isPrefix {
+			return line, nil
+		}
+	}
+}
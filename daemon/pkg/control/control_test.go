@@ -0,0 +1,62 @@
+package control
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCallAndServe(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "control.sock")
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	go Serve(context.Background(), listener, func(ctx context.Context, req *Request) *Response {
+		if req.Command == CommandSetLogLevel {
+			return OK("ok", map[string]string{"level": req.Args["level"]})
+		}
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	resp, err := Call(ctx, socketPath, &Request{ID: "1", Command: CommandSetLogLevel, Args: map[string]string{"level": "debug"}})
+	if err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if resp.ID != "1" || !resp.Success || resp.Data["level"] != "debug" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	resp, err = Call(ctx, socketPath, &Request{ID: "2", Command: CommandDrain})
+	if err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if resp.ID != "2" || resp.Success || resp.Code != CodeUnknownCommand {
+		t.Errorf("expected unknown_command response, got %+v", resp)
+	}
+}
+
+func TestCall_SocketUnavailable(t *testing.T) {
+	_, err := Call(context.Background(), filepath.Join(t.TempDir(), "missing.sock"), &Request{Command: CommandReload})
+	if err == nil {
+		t.Fatal("expected error for missing socket")
+	}
+}
+
+func TestIsValidCommand(t *testing.T) {
+	for _, command := range Commands() {
+		if !IsValidCommand(command) {
+			t.Errorf("expected %s to be valid", command)
+		}
+	}
+	if IsValidCommand(CommandPing) || IsValidCommand("bogus") {
+		t.Error("ping and unknown commands should not be accepted from callers")
+	}
+}
@@ -0,0 +1,73 @@
+// Package heartbeat 定义Agent向Daemon发送签名心跳的协议
+//
+// Daemon启动Agent时通过环境变量下发Agent ID和心跳密钥，
+// Agent使用密钥对心跳内容和时间戳计算HMAC-SHA256签名:
+//
+//	signature = hex(HMAC-SHA256(secret, "<timestamp>.<body>"))
+//
+// 其中timestamp为Unix毫秒时间戳。HTTP心跳通过请求头携带时间戳和签名，
+// Unix Socket心跳将心跳内容包装在Envelope中发送。
+package heartbeat
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+const (
+	// EnvAgentID Daemon下发给Agent的Agent ID环境变量
+	EnvAgentID = "OPS_AGENT_ID"
+
+	// EnvSecret Daemon下发给Agent的心跳密钥环境变量
+	EnvSecret = "OPS_AGENT_HEARTBEAT_SECRET"
+
+	// EnvHeartbeatURL Daemon下发给Agent的HTTP心跳地址环境变量
+	EnvHeartbeatURL = "OPS_DAEMON_HEARTBEAT_URL"
+
+	// EnvHeartbeatSocket Daemon下发给Agent的Unix Socket心跳路径环境变量
+	EnvHeartbeatSocket = "OPS_DAEMON_HEARTBEAT_SOCKET"
+
+	// HeaderTimestamp HTTP心跳的时间戳请求头(Unix毫秒)
+	HeaderTimestamp = "X-Heartbeat-Timestamp"
+
+	// HeaderSignature HTTP心跳的签名请求头(十六进制)
+	HeaderSignature = "X-Heartbeat-Signature"
+)
+
+// Envelope Unix Socket签名心跳信封
+// Payload为原始心跳JSON(types.Heartbeat)，以base64编码传输以保证签名字节不变
+type Envelope struct {
+	AgentID   string `json:"agent_id"`
+	Timestamp int64  `json:"timestamp"`
+	Signature string `json:"signature"`
+	Payload   []byte `json:"payload"`
+}
+
+// Sign 计算心跳签名
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify 校验心跳签名(常量时间比较)
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	expected := Sign(secret, timestamp, body)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// NewEnvelope 使用当前时间为心跳内容签名并构建信封
+func NewEnvelope(agentID, secret string, payload []byte, now time.Time) *Envelope {
+	timestamp := now.UnixMilli()
+	return &Envelope{
+		AgentID:   agentID,
+		Timestamp: timestamp,
+		Signature: Sign(secret, timestamp, payload),
+		Payload:   payload,
+	}
+}
@@ -0,0 +1,60 @@
+package heartbeat
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	body := []byte(`{"agent_id":"a1","pid":1}`)
+	sig := Sign("secret", 1700000000000, body)
+
+	if !Verify("secret", 1700000000000, body, sig) {
+		t.Fatal("expected signature to verify")
+	}
+	if Verify("other", 1700000000000, body, sig) {
+		t.Error("signature verified with wrong secret")
+	}
+	if Verify("secret", 1700000000001, body, sig) {
+		t.Error("signature verified with different timestamp")
+	}
+	if Verify("secret", 1700000000000, []byte(`{"agent_id":"a2","pid":1}`), sig) {
+		t.Error("signature verified with different body")
+	}
+}
+
+// 固定签名向量，agent/pkg/sdk 的签名测试使用同一组数据，保证两端签名算法一致
+const (
+	fixtureSecret    = "fixture-secret"
+	fixtureTimestamp = int64(1700000000000)
+	fixtureBody      = `{"agent_id":"agent-1","pid":4242,"status":"running"}`
+	fixtureSignature = "205018b241f37cd9a3a6731953d310d73565d9fe6cfcc3b91b4939f3e7a3af24"
+)
+
+func TestSignFixture(t *testing.T) {
+	if sig := Sign(fixtureSecret, fixtureTimestamp, []byte(fixtureBody)); sig != fixtureSignature {
+		t.Fatalf("unexpected signature: %s", sig)
+	}
+	if !Verify(fixtureSecret, fixtureTimestamp, []byte(fixtureBody), fixtureSignature) {
+		t.Fatal("expected fixture signature to verify")
+	}
+}
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	payload := []byte(`{"pid":1,"status":"<running>"}`)
+	env := NewEnvelope("a1", "secret", payload, time.Now())
+
+	data, err := json.Marshal(env)
+	if err != nil {
+		t.Fatalf("failed to marshal envelope: %v", err)
+	}
+
+	var decoded Envelope
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("failed to unmarshal envelope: %v", err)
+	}
+	if !Verify("secret", decoded.Timestamp, decoded.Payload, decoded.Signature) {
+		t.Error("expected decoded envelope to verify")
+	}
+}
@@ -289,7 +289,9 @@ func (x *HeartbeatResponse) GetMessage() string {
 	return ""
 }
 
-// MetricsRequest 指标上报请求
+// MetricsRequest 指标上报请求(已废弃)
+//
+// Deprecated: Marked as deprecated in pkg/proto/daemon.proto.
 type MetricsRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
@@ -1153,32 +1155,1204 @@ func (x *AgentMetricsResponse) GetDataPoints() []*ResourceDataPoint {
 
 // AgentState Agent状态
 type AgentState struct {
+	state                  protoimpl.MessageState `protogen:"open.v1"`
+	AgentId                string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`                                                                                               // Agent ID
+	Status                 string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`                                                                                                                // 运行状态
+	Pid                    int32                  `protobuf:"varint,3,opt,name=pid,proto3" json:"pid,omitempty"`                                                                                                                     // 进程ID
+	LastHeartbeat          int64                  `protobuf:"varint,4,opt,name=last_heartbeat,json=lastHeartbeat,proto3" json:"last_heartbeat,omitempty"`                                                                            // 最后心跳时间
+	Type                   string                 `protobuf:"bytes,5,opt,name=type,proto3" json:"type,omitempty"`                                                                                                                    // Agent类型(filebeat/telegraf/node_exporter等)
+	Version                string                 `protobuf:"bytes,6,opt,name=version,proto3" json:"version,omitempty"`                                                                                                              // Agent版本号
+	CustomMetrics          map[string]float64     `protobuf:"bytes,7,rep,name=custom_metrics,json=customMetrics,proto3" json:"custom_metrics,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"` // 最近一次心跳携带的自定义数值指标
+	CustomFields           map[string]string      `protobuf:"bytes,8,rep,name=custom_fields,json=customFields,proto3" json:"custom_fields,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`      // 最近一次心跳携带的自定义状态字段
+	CustomMetricsTimestamp int64                  `protobuf:"varint,9,opt,name=custom_metrics_timestamp,json=customMetricsTimestamp,proto3" json:"custom_metrics_timestamp,omitempty"`                                               // 自定义指标上报时间(毫秒时间戳,0表示无)
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *AgentState) Reset() {
+	*x = AgentState{}
+	mi := &file_pkg_proto_daemon_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentState) ProtoMessage() {}
+
+func (x *AgentState) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_daemon_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentState.ProtoReflect.Descriptor instead.
+func (*AgentState) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_daemon_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *AgentState) GetAgentId() string {
+	if x != nil {
+		return x.AgentId
+	}
+	return ""
+}
+
+func (x *AgentState) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *AgentState) GetPid() int32 {
+	if x != nil {
+		return x.Pid
+	}
+	return 0
+}
+
+func (x *AgentState) GetLastHeartbeat() int64 {
+	if x != nil {
+		return x.LastHeartbeat
+	}
+	return 0
+}
+
+func (x *AgentState) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *AgentState) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *AgentState) GetCustomMetrics() map[string]float64 {
+	if x != nil {
+		return x.CustomMetrics
+	}
+	return nil
+}
+
+func (x *AgentState) GetCustomFields() map[string]string {
+	if x != nil {
+		return x.CustomFields
+	}
+	return nil
+}
+
+func (x *AgentState) GetCustomMetricsTimestamp() int64 {
+	if x != nil {
+		return x.CustomMetricsTimestamp
+	}
+	return 0
+}
+
+// SyncAgentStatesRequest 同步Agent状态请求
+type SyncAgentStatesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"` // 节点ID
+	States        []*AgentState          `protobuf:"bytes,2,rep,name=states,proto3" json:"states,omitempty"`               // Agent状态列表
+	Timestamp     int64                  `protobuf:"varint,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`        // 状态快照采集时间(Unix秒)，补发缓存的快照时早于发送时间；0表示发送时间
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SyncAgentStatesRequest) Reset() {
+	*x = SyncAgentStatesRequest{}
+	mi := &file_pkg_proto_daemon_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SyncAgentStatesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SyncAgentStatesRequest) ProtoMessage() {}
+
+func (x *SyncAgentStatesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_daemon_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SyncAgentStatesRequest.ProtoReflect.Descriptor instead.
+func (*SyncAgentStatesRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_daemon_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *SyncAgentStatesRequest) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *SyncAgentStatesRequest) GetStates() []*AgentState {
+	if x != nil {
+		return x.States
+	}
+	return nil
+}
+
+func (x *SyncAgentStatesRequest) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+// SyncAgentStatesResponse 同步Agent状态响应
+type SyncAgentStatesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"` // 同步是否成功
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`  // 响应消息
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SyncAgentStatesResponse) Reset() {
+	*x = SyncAgentStatesResponse{}
+	mi := &file_pkg_proto_daemon_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SyncAgentStatesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SyncAgentStatesResponse) ProtoMessage() {}
+
+func (x *SyncAgentStatesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_daemon_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SyncAgentStatesResponse.ProtoReflect.Descriptor instead.
+func (*SyncAgentStatesResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_daemon_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *SyncAgentStatesResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *SyncAgentStatesResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+// AgentEvent Agent事件
+type AgentEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AgentId       string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`                                                            // Agent ID
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`                                                                                 // 事件类型(circuit_open/circuit_reset等)
+	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`                                                                           // 事件描述
+	Timestamp     int64                  `protobuf:"varint,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"`                                                                      // 事件时间(Unix时间戳)
+	Details       map[string]string      `protobuf:"bytes,5,rep,name=details,proto3" json:"details,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // 事件详情
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AgentEvent) Reset() {
+	*x = AgentEvent{}
+	mi := &file_pkg_proto_daemon_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentEvent) ProtoMessage() {}
+
+func (x *AgentEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_daemon_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentEvent.ProtoReflect.Descriptor instead.
+func (*AgentEvent) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_daemon_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *AgentEvent) GetAgentId() string {
+	if x != nil {
+		return x.AgentId
+	}
+	return ""
+}
+
+func (x *AgentEvent) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *AgentEvent) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *AgentEvent) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *AgentEvent) GetDetails() map[string]string {
+	if x != nil {
+		return x.Details
+	}
+	return nil
+}
+
+// ReportAgentEventsRequest 上报Agent事件请求
+type ReportAgentEventsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"` // 节点ID
+	Events        []*AgentEvent          `protobuf:"bytes,2,rep,name=events,proto3" json:"events,omitempty"`               // 事件列表
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReportAgentEventsRequest) Reset() {
+	*x = ReportAgentEventsRequest{}
+	mi := &file_pkg_proto_daemon_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReportAgentEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportAgentEventsRequest) ProtoMessage() {}
+
+func (x *ReportAgentEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_daemon_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportAgentEventsRequest.ProtoReflect.Descriptor instead.
+func (*ReportAgentEventsRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_daemon_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *ReportAgentEventsRequest) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *ReportAgentEventsRequest) GetEvents() []*AgentEvent {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+// ReportAgentEventsResponse 上报Agent事件响应
+type ReportAgentEventsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"` // 是否成功
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`  // 响应消息
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReportAgentEventsResponse) Reset() {
+	*x = ReportAgentEventsResponse{}
+	mi := &file_pkg_proto_daemon_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReportAgentEventsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportAgentEventsResponse) ProtoMessage() {}
+
+func (x *ReportAgentEventsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_daemon_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportAgentEventsResponse.ProtoReflect.Descriptor instead.
+func (*ReportAgentEventsResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_daemon_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *ReportAgentEventsResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *ReportAgentEventsResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+// CrashReport Agent崩溃记录
+type CrashReport struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AgentId       string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`                  // Agent ID
+	ExitCode      int32                  `protobuf:"varint,2,opt,name=exit_code,json=exitCode,proto3" json:"exit_code,omitempty"`              // 退出码(被信号终止时为-1)
+	Signal        string                 `protobuf:"bytes,3,opt,name=signal,proto3" json:"signal,omitempty"`                                   // 终止信号(正常退出时为空)
+	StartedAt     int64                  `protobuf:"varint,4,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`           // 本次运行启动时间(Unix时间戳)
+	ExitedAt      int64                  `protobuf:"varint,5,opt,name=exited_at,json=exitedAt,proto3" json:"exited_at,omitempty"`              // 退出时间(Unix时间戳)
+	DurationMs    int64                  `protobuf:"varint,6,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`        // 运行时长(毫秒)
+	LastLogLines  []string               `protobuf:"bytes,7,rep,name=last_log_lines,json=lastLogLines,proto3" json:"last_log_lines,omitempty"` // 退出时日志的最后N行
+	CircuitOpen   bool                   `protobuf:"varint,8,opt,name=circuit_open,json=circuitOpen,proto3" json:"circuit_open,omitempty"`     // 本次崩溃是否触发熔断
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CrashReport) Reset() {
+	*x = CrashReport{}
+	mi := &file_pkg_proto_daemon_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CrashReport) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CrashReport) ProtoMessage() {}
+
+func (x *CrashReport) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_daemon_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CrashReport.ProtoReflect.Descriptor instead.
+func (*CrashReport) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_daemon_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *CrashReport) GetAgentId() string {
+	if x != nil {
+		return x.AgentId
+	}
+	return ""
+}
+
+func (x *CrashReport) GetExitCode() int32 {
+	if x != nil {
+		return x.ExitCode
+	}
+	return 0
+}
+
+func (x *CrashReport) GetSignal() string {
+	if x != nil {
+		return x.Signal
+	}
+	return ""
+}
+
+func (x *CrashReport) GetStartedAt() int64 {
+	if x != nil {
+		return x.StartedAt
+	}
+	return 0
+}
+
+func (x *CrashReport) GetExitedAt() int64 {
+	if x != nil {
+		return x.ExitedAt
+	}
+	return 0
+}
+
+func (x *CrashReport) GetDurationMs() int64 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+func (x *CrashReport) GetLastLogLines() []string {
+	if x != nil {
+		return x.LastLogLines
+	}
+	return nil
+}
+
+func (x *CrashReport) GetCircuitOpen() bool {
+	if x != nil {
+		return x.CircuitOpen
+	}
+	return false
+}
+
+// ReportCrashesRequest 上报崩溃记录请求
+type ReportCrashesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"` // 节点ID
+	Crashes       []*CrashReport         `protobuf:"bytes,2,rep,name=crashes,proto3" json:"crashes,omitempty"`             // 崩溃记录列表
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReportCrashesRequest) Reset() {
+	*x = ReportCrashesRequest{}
+	mi := &file_pkg_proto_daemon_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReportCrashesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportCrashesRequest) ProtoMessage() {}
+
+func (x *ReportCrashesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_daemon_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportCrashesRequest.ProtoReflect.Descriptor instead.
+func (*ReportCrashesRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_daemon_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *ReportCrashesRequest) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *ReportCrashesRequest) GetCrashes() []*CrashReport {
+	if x != nil {
+		return x.Crashes
+	}
+	return nil
+}
+
+// ReportCrashesResponse 上报崩溃记录响应
+type ReportCrashesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"` // 是否成功
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`  // 响应消息
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReportCrashesResponse) Reset() {
+	*x = ReportCrashesResponse{}
+	mi := &file_pkg_proto_daemon_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReportCrashesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportCrashesResponse) ProtoMessage() {}
+
+func (x *ReportCrashesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_daemon_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportCrashesResponse.ProtoReflect.Descriptor instead.
+func (*ReportCrashesResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_daemon_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *ReportCrashesResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *ReportCrashesResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+// GetCrashReportsRequest 获取崩溃记录请求
+type GetCrashReportsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AgentId       string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"` // Agent ID
+	Limit         int32                  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`                   // 返回的最大记录数(0表示默认值)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCrashReportsRequest) Reset() {
+	*x = GetCrashReportsRequest{}
+	mi := &file_pkg_proto_daemon_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCrashReportsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCrashReportsRequest) ProtoMessage() {}
+
+func (x *GetCrashReportsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_daemon_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCrashReportsRequest.ProtoReflect.Descriptor instead.
+func (*GetCrashReportsRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_daemon_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *GetCrashReportsRequest) GetAgentId() string {
+	if x != nil {
+		return x.AgentId
+	}
+	return ""
+}
+
+func (x *GetCrashReportsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+// GetCrashReportsResponse 获取崩溃记录响应
+type GetCrashReportsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Crashes       []*CrashReport         `protobuf:"bytes,1,rep,name=crashes,proto3" json:"crashes,omitempty"` // 崩溃记录列表(按时间倒序)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCrashReportsResponse) Reset() {
+	*x = GetCrashReportsResponse{}
+	mi := &file_pkg_proto_daemon_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCrashReportsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCrashReportsResponse) ProtoMessage() {}
+
+func (x *GetCrashReportsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_daemon_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCrashReportsResponse.ProtoReflect.Descriptor instead.
+func (*GetCrashReportsResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_daemon_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *GetCrashReportsResponse) GetCrashes() []*CrashReport {
+	if x != nil {
+		return x.Crashes
+	}
+	return nil
+}
+
+// TailAgentLogsRequest 获取Agent日志末尾请求
+type TailAgentLogsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AgentId       string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"` // Agent ID
+	Lines         int32                  `protobuf:"varint,2,opt,name=lines,proto3" json:"lines,omitempty"`                   // 返回的最大行数(0表示默认值)
+	Keyword       string                 `protobuf:"bytes,3,opt,name=keyword,proto3" json:"keyword,omitempty"`                // 关键词过滤(为空时不过滤)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TailAgentLogsRequest) Reset() {
+	*x = TailAgentLogsRequest{}
+	mi := &file_pkg_proto_daemon_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TailAgentLogsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TailAgentLogsRequest) ProtoMessage() {}
+
+func (x *TailAgentLogsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_daemon_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TailAgentLogsRequest.ProtoReflect.Descriptor instead.
+func (*TailAgentLogsRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_daemon_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *TailAgentLogsRequest) GetAgentId() string {
+	if x != nil {
+		return x.AgentId
+	}
+	return ""
+}
+
+func (x *TailAgentLogsRequest) GetLines() int32 {
+	if x != nil {
+		return x.Lines
+	}
+	return 0
+}
+
+func (x *TailAgentLogsRequest) GetKeyword() string {
+	if x != nil {
+		return x.Keyword
+	}
+	return ""
+}
+
+// TailAgentLogsResponse 获取Agent日志末尾响应
+type TailAgentLogsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AgentId       string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"` // Agent ID
+	Lines         []string               `protobuf:"bytes,2,rep,name=lines,proto3" json:"lines,omitempty"`                    // 日志行(按时间正序)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TailAgentLogsResponse) Reset() {
+	*x = TailAgentLogsResponse{}
+	mi := &file_pkg_proto_daemon_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TailAgentLogsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TailAgentLogsResponse) ProtoMessage() {}
+
+func (x *TailAgentLogsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_daemon_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TailAgentLogsResponse.ProtoReflect.Descriptor instead.
+func (*TailAgentLogsResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_daemon_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *TailAgentLogsResponse) GetAgentId() string {
+	if x != nil {
+		return x.AgentId
+	}
+	return ""
+}
+
+func (x *TailAgentLogsResponse) GetLines() []string {
+	if x != nil {
+		return x.Lines
+	}
+	return nil
+}
+
+// FollowAgentLogsRequest 跟踪Agent日志请求
+type FollowAgentLogsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AgentId       string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`        // Agent ID
+	TailLines     int32                  `protobuf:"varint,2,opt,name=tail_lines,json=tailLines,proto3" json:"tail_lines,omitempty"` // 开始跟踪前先发送的末尾行数
+	Keyword       string                 `protobuf:"bytes,3,opt,name=keyword,proto3" json:"keyword,omitempty"`                       // 关键词过滤(为空时不过滤)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FollowAgentLogsRequest) Reset() {
+	*x = FollowAgentLogsRequest{}
+	mi := &file_pkg_proto_daemon_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FollowAgentLogsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FollowAgentLogsRequest) ProtoMessage() {}
+
+func (x *FollowAgentLogsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_daemon_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FollowAgentLogsRequest.ProtoReflect.Descriptor instead.
+func (*FollowAgentLogsRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_daemon_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *FollowAgentLogsRequest) GetAgentId() string {
+	if x != nil {
+		return x.AgentId
+	}
+	return ""
+}
+
+func (x *FollowAgentLogsRequest) GetTailLines() int32 {
+	if x != nil {
+		return x.TailLines
+	}
+	return 0
+}
+
+func (x *FollowAgentLogsRequest) GetKeyword() string {
+	if x != nil {
+		return x.Keyword
+	}
+	return ""
+}
+
+// FollowAgentLogsResponse 跟踪Agent日志推送
+type FollowAgentLogsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Lines         []string               `protobuf:"bytes,1,rep,name=lines,proto3" json:"lines,omitempty"`      // 新增日志行
+	Rotated       bool                   `protobuf:"varint,2,opt,name=rotated,proto3" json:"rotated,omitempty"` // 日志文件是否发生了轮转(之后的行来自新文件)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FollowAgentLogsResponse) Reset() {
+	*x = FollowAgentLogsResponse{}
+	mi := &file_pkg_proto_daemon_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FollowAgentLogsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FollowAgentLogsResponse) ProtoMessage() {}
+
+func (x *FollowAgentLogsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_daemon_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FollowAgentLogsResponse.ProtoReflect.Descriptor instead.
+func (*FollowAgentLogsResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_daemon_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *FollowAgentLogsResponse) GetLines() []string {
+	if x != nil {
+		return x.Lines
+	}
+	return nil
+}
+
+func (x *FollowAgentLogsResponse) GetRotated() bool {
+	if x != nil {
+		return x.Rotated
+	}
+	return false
+}
+
+// SearchAgentLogsRequest 搜索Agent日志请求
+type SearchAgentLogsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AgentId       string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`       // Agent ID(为空时搜索所有Agent)
+	AgentType     string                 `protobuf:"bytes,2,opt,name=agent_type,json=agentType,proto3" json:"agent_type,omitempty"` // Agent类型过滤(为空时不过滤)
+	Keyword       string                 `protobuf:"bytes,3,opt,name=keyword,proto3" json:"keyword,omitempty"`                      // 关键词，regex为true时为正则表达式(为空时匹配所有行)
+	Regex         bool                   `protobuf:"varint,4,opt,name=regex,proto3" json:"regex,omitempty"`                         // keyword是否为正则表达式
+	SinceMs       int64                  `protobuf:"varint,5,opt,name=since_ms,json=sinceMs,proto3" json:"since_ms,omitempty"`      // 开始时间(Unix毫秒，0表示不限制)
+	UntilMs       int64                  `protobuf:"varint,6,opt,name=until_ms,json=untilMs,proto3" json:"until_ms,omitempty"`      // 结束时间(Unix毫秒，0表示不限制)
+	Limit         int32                  `protobuf:"varint,7,opt,name=limit,proto3" json:"limit,omitempty"`                         // 最大返回条数(0表示默认值)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchAgentLogsRequest) Reset() {
+	*x = SearchAgentLogsRequest{}
+	mi := &file_pkg_proto_daemon_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchAgentLogsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchAgentLogsRequest) ProtoMessage() {}
+
+func (x *SearchAgentLogsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_daemon_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchAgentLogsRequest.ProtoReflect.Descriptor instead.
+func (*SearchAgentLogsRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_daemon_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *SearchAgentLogsRequest) GetAgentId() string {
+	if x != nil {
+		return x.AgentId
+	}
+	return ""
+}
+
+func (x *SearchAgentLogsRequest) GetAgentType() string {
+	if x != nil {
+		return x.AgentType
+	}
+	return ""
+}
+
+func (x *SearchAgentLogsRequest) GetKeyword() string {
+	if x != nil {
+		return x.Keyword
+	}
+	return ""
+}
+
+func (x *SearchAgentLogsRequest) GetRegex() bool {
+	if x != nil {
+		return x.Regex
+	}
+	return false
+}
+
+func (x *SearchAgentLogsRequest) GetSinceMs() int64 {
+	if x != nil {
+		return x.SinceMs
+	}
+	return 0
+}
+
+func (x *SearchAgentLogsRequest) GetUntilMs() int64 {
+	if x != nil {
+		return x.UntilMs
+	}
+	return 0
+}
+
+func (x *SearchAgentLogsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+// LogSearchHit 日志搜索命中的行
+type LogSearchHit struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AgentId       string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`              // Agent ID
+	AgentType     string                 `protobuf:"bytes,2,opt,name=agent_type,json=agentType,proto3" json:"agent_type,omitempty"`        // Agent类型
+	TimestampMs   int64                  `protobuf:"varint,3,opt,name=timestamp_ms,json=timestampMs,proto3" json:"timestamp_ms,omitempty"` // 日志时间(Unix毫秒，从日志行解析)
+	Line          string                 `protobuf:"bytes,4,opt,name=line,proto3" json:"line,omitempty"`                                   // 日志内容
+	File          string                 `protobuf:"bytes,5,opt,name=file,proto3" json:"file,omitempty"`                                   // 所在日志文件
+	LineNumber    int32                  `protobuf:"varint,6,opt,name=line_number,json=lineNumber,proto3" json:"line_number,omitempty"`    // 所在行号
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogSearchHit) Reset() {
+	*x = LogSearchHit{}
+	mi := &file_pkg_proto_daemon_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogSearchHit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogSearchHit) ProtoMessage() {}
+
+func (x *LogSearchHit) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_daemon_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogSearchHit.ProtoReflect.Descriptor instead.
+func (*LogSearchHit) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_daemon_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *LogSearchHit) GetAgentId() string {
+	if x != nil {
+		return x.AgentId
+	}
+	return ""
+}
+
+func (x *LogSearchHit) GetAgentType() string {
+	if x != nil {
+		return x.AgentType
+	}
+	return ""
+}
+
+func (x *LogSearchHit) GetTimestampMs() int64 {
+	if x != nil {
+		return x.TimestampMs
+	}
+	return 0
+}
+
+func (x *LogSearchHit) GetLine() string {
+	if x != nil {
+		return x.Line
+	}
+	return ""
+}
+
+func (x *LogSearchHit) GetFile() string {
+	if x != nil {
+		return x.File
+	}
+	return ""
+}
+
+func (x *LogSearchHit) GetLineNumber() int32 {
+	if x != nil {
+		return x.LineNumber
+	}
+	return 0
+}
+
+// SearchAgentLogsResponse 搜索Agent日志响应
+type SearchAgentLogsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hits          []*LogSearchHit        `protobuf:"bytes,1,rep,name=hits,proto3" json:"hits,omitempty"`            // 命中的行(按时间倒序)
+	Truncated     bool                   `protobuf:"varint,2,opt,name=truncated,proto3" json:"truncated,omitempty"` // 是否还有更多命中未返回
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchAgentLogsResponse) Reset() {
+	*x = SearchAgentLogsResponse{}
+	mi := &file_pkg_proto_daemon_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchAgentLogsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchAgentLogsResponse) ProtoMessage() {}
+
+func (x *SearchAgentLogsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_daemon_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchAgentLogsResponse.ProtoReflect.Descriptor instead.
+func (*SearchAgentLogsResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_daemon_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *SearchAgentLogsResponse) GetHits() []*LogSearchHit {
+	if x != nil {
+		return x.Hits
+	}
+	return nil
+}
+
+func (x *SearchAgentLogsResponse) GetTruncated() bool {
+	if x != nil {
+		return x.Truncated
+	}
+	return false
+}
+
+// ResourceAlert Agent资源告警(资源使用超过阈值的持续时间达到threshold_duration)
+type ResourceAlert struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	AgentId       string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`                    // Agent ID
-	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`                                     // 运行状态
-	Pid           int32                  `protobuf:"varint,3,opt,name=pid,proto3" json:"pid,omitempty"`                                          // 进程ID
-	LastHeartbeat int64                  `protobuf:"varint,4,opt,name=last_heartbeat,json=lastHeartbeat,proto3" json:"last_heartbeat,omitempty"` // 最后心跳时间
-	Type          string                 `protobuf:"bytes,5,opt,name=type,proto3" json:"type,omitempty"`                                         // Agent类型(filebeat/telegraf/node_exporter等)
-	Version       string                 `protobuf:"bytes,6,opt,name=version,proto3" json:"version,omitempty"`                                   // Agent版本号
+	AgentId       string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`             // Agent ID
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`                                  // 告警类型(cpu/memory/open_files)
+	Value         float64                `protobuf:"fixed64,3,opt,name=value,proto3" json:"value,omitempty"`                              // 当前值
+	Threshold     float64                `protobuf:"fixed64,4,opt,name=threshold,proto3" json:"threshold,omitempty"`                      // 阈值
+	DurationMs    int64                  `protobuf:"varint,5,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`   // 超过阈值的持续时间(毫秒)
+	Timestamp     int64                  `protobuf:"varint,6,opt,name=timestamp,proto3" json:"timestamp,omitempty"`                       // 告警时间(Unix时间戳)
+	Action        string                 `protobuf:"bytes,7,opt,name=action,proto3" json:"action,omitempty"`                              // 执行的动作(none/restart/stop)
+	ActionError   string                 `protobuf:"bytes,8,opt,name=action_error,json=actionError,proto3" json:"action_error,omitempty"` // 动作执行失败时的错误信息
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *AgentState) Reset() {
-	*x = AgentState{}
-	mi := &file_pkg_proto_daemon_proto_msgTypes[18]
+func (x *ResourceAlert) Reset() {
+	*x = ResourceAlert{}
+	mi := &file_pkg_proto_daemon_proto_msgTypes[36]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *AgentState) String() string {
+func (x *ResourceAlert) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*AgentState) ProtoMessage() {}
+func (*ResourceAlert) ProtoMessage() {}
 
-func (x *AgentState) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_proto_daemon_proto_msgTypes[18]
+func (x *ResourceAlert) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_daemon_proto_msgTypes[36]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1189,77 +2363,91 @@ func (x *AgentState) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use AgentState.ProtoReflect.Descriptor instead.
-func (*AgentState) Descriptor() ([]byte, []int) {
-	return file_pkg_proto_daemon_proto_rawDescGZIP(), []int{18}
+// Deprecated: Use ResourceAlert.ProtoReflect.Descriptor instead.
+func (*ResourceAlert) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_daemon_proto_rawDescGZIP(), []int{36}
 }
 
-func (x *AgentState) GetAgentId() string {
+func (x *ResourceAlert) GetAgentId() string {
 	if x != nil {
 		return x.AgentId
 	}
 	return ""
 }
 
-func (x *AgentState) GetStatus() string {
+func (x *ResourceAlert) GetType() string {
 	if x != nil {
-		return x.Status
+		return x.Type
 	}
 	return ""
 }
 
-func (x *AgentState) GetPid() int32 {
+func (x *ResourceAlert) GetValue() float64 {
 	if x != nil {
-		return x.Pid
+		return x.Value
 	}
 	return 0
 }
 
-func (x *AgentState) GetLastHeartbeat() int64 {
+func (x *ResourceAlert) GetThreshold() float64 {
 	if x != nil {
-		return x.LastHeartbeat
+		return x.Threshold
 	}
 	return 0
 }
 
-func (x *AgentState) GetType() string {
+func (x *ResourceAlert) GetDurationMs() int64 {
 	if x != nil {
-		return x.Type
+		return x.DurationMs
+	}
+	return 0
+}
+
+func (x *ResourceAlert) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *ResourceAlert) GetAction() string {
+	if x != nil {
+		return x.Action
 	}
 	return ""
 }
 
-func (x *AgentState) GetVersion() string {
+func (x *ResourceAlert) GetActionError() string {
 	if x != nil {
-		return x.Version
+		return x.ActionError
 	}
 	return ""
 }
 
-// SyncAgentStatesRequest 同步Agent状态请求
-type SyncAgentStatesRequest struct {
+// ReportResourceAlertsRequest 上报资源告警请求
+type ReportResourceAlertsRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"` // 节点ID
-	States        []*AgentState          `protobuf:"bytes,2,rep,name=states,proto3" json:"states,omitempty"`               // Agent状态列表
+	Alerts        []*ResourceAlert       `protobuf:"bytes,2,rep,name=alerts,proto3" json:"alerts,omitempty"`               // 告警列表
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SyncAgentStatesRequest) Reset() {
-	*x = SyncAgentStatesRequest{}
-	mi := &file_pkg_proto_daemon_proto_msgTypes[19]
+func (x *ReportResourceAlertsRequest) Reset() {
+	*x = ReportResourceAlertsRequest{}
+	mi := &file_pkg_proto_daemon_proto_msgTypes[37]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SyncAgentStatesRequest) String() string {
+func (x *ReportResourceAlertsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SyncAgentStatesRequest) ProtoMessage() {}
+func (*ReportResourceAlertsRequest) ProtoMessage() {}
 
-func (x *SyncAgentStatesRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_proto_daemon_proto_msgTypes[19]
+func (x *ReportResourceAlertsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_daemon_proto_msgTypes[37]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1270,49 +2458,49 @@ func (x *SyncAgentStatesRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SyncAgentStatesRequest.ProtoReflect.Descriptor instead.
-func (*SyncAgentStatesRequest) Descriptor() ([]byte, []int) {
-	return file_pkg_proto_daemon_proto_rawDescGZIP(), []int{19}
+// Deprecated: Use ReportResourceAlertsRequest.ProtoReflect.Descriptor instead.
+func (*ReportResourceAlertsRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_daemon_proto_rawDescGZIP(), []int{37}
 }
 
-func (x *SyncAgentStatesRequest) GetNodeId() string {
+func (x *ReportResourceAlertsRequest) GetNodeId() string {
 	if x != nil {
 		return x.NodeId
 	}
 	return ""
 }
 
-func (x *SyncAgentStatesRequest) GetStates() []*AgentState {
+func (x *ReportResourceAlertsRequest) GetAlerts() []*ResourceAlert {
 	if x != nil {
-		return x.States
+		return x.Alerts
 	}
 	return nil
 }
 
-// SyncAgentStatesResponse 同步Agent状态响应
-type SyncAgentStatesResponse struct {
+// ReportResourceAlertsResponse 上报资源告警响应
+type ReportResourceAlertsResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"` // 同步是否成功
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"` // 是否成功
 	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`  // 响应消息
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SyncAgentStatesResponse) Reset() {
-	*x = SyncAgentStatesResponse{}
-	mi := &file_pkg_proto_daemon_proto_msgTypes[20]
+func (x *ReportResourceAlertsResponse) Reset() {
+	*x = ReportResourceAlertsResponse{}
+	mi := &file_pkg_proto_daemon_proto_msgTypes[38]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SyncAgentStatesResponse) String() string {
+func (x *ReportResourceAlertsResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SyncAgentStatesResponse) ProtoMessage() {}
+func (*ReportResourceAlertsResponse) ProtoMessage() {}
 
-func (x *SyncAgentStatesResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_proto_daemon_proto_msgTypes[20]
+func (x *ReportResourceAlertsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_daemon_proto_msgTypes[38]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1323,25 +2511,265 @@ func (x *SyncAgentStatesResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SyncAgentStatesResponse.ProtoReflect.Descriptor instead.
-func (*SyncAgentStatesResponse) Descriptor() ([]byte, []int) {
-	return file_pkg_proto_daemon_proto_rawDescGZIP(), []int{20}
+// Deprecated: Use ReportResourceAlertsResponse.ProtoReflect.Descriptor instead.
+func (*ReportResourceAlertsResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_daemon_proto_rawDescGZIP(), []int{38}
 }
 
-func (x *SyncAgentStatesResponse) GetSuccess() bool {
+func (x *ReportResourceAlertsResponse) GetSuccess() bool {
 	if x != nil {
 		return x.Success
 	}
 	return false
 }
 
-func (x *SyncAgentStatesResponse) GetMessage() string {
+func (x *ReportResourceAlertsResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+// ControlAgentRequest Agent控制命令请求
+type ControlAgentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AgentId       string                 `protobuf:"bytes,1,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`                                                      // Agent ID
+	Command       string                 `protobuf:"bytes,2,opt,name=command,proto3" json:"command,omitempty"`                                                                     // 控制命令(reload/set_log_level/dump_diagnostics/drain)
+	Args          map[string]string      `protobuf:"bytes,3,rep,name=args,proto3" json:"args,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // 命令参数(如set_log_level的level)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ControlAgentRequest) Reset() {
+	*x = ControlAgentRequest{}
+	mi := &file_pkg_proto_daemon_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ControlAgentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ControlAgentRequest) ProtoMessage() {}
+
+func (x *ControlAgentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_daemon_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ControlAgentRequest.ProtoReflect.Descriptor instead.
+func (*ControlAgentRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_daemon_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *ControlAgentRequest) GetAgentId() string {
+	if x != nil {
+		return x.AgentId
+	}
+	return ""
+}
+
+func (x *ControlAgentRequest) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+func (x *ControlAgentRequest) GetArgs() map[string]string {
+	if x != nil {
+		return x.Args
+	}
+	return nil
+}
+
+// ControlAgentResponse Agent控制命令响应
+type ControlAgentResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`                                                                    // Agent是否执行成功(信号方式下表示信号已发送)
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`                                                                     // Agent返回的消息
+	Method        string                 `protobuf:"bytes,3,opt,name=method,proto3" json:"method,omitempty"`                                                                       // 下发方式(socket/signal)
+	Data          map[string]string      `protobuf:"bytes,4,rep,name=data,proto3" json:"data,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // Agent返回的数据(如诊断信息)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ControlAgentResponse) Reset() {
+	*x = ControlAgentResponse{}
+	mi := &file_pkg_proto_daemon_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ControlAgentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ControlAgentResponse) ProtoMessage() {}
+
+func (x *ControlAgentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_daemon_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ControlAgentResponse.ProtoReflect.Descriptor instead.
+func (*ControlAgentResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_daemon_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *ControlAgentResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *ControlAgentResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *ControlAgentResponse) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *ControlAgentResponse) GetData() map[string]string {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+// TunnelFrame 控制流帧
+// Daemon建流后先发送hello帧；Manager发送request帧发起调用，Daemon返回零或多个message帧后以close帧结束调用
+type TunnelFrame struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`                                                                                   // 帧类型(hello/request/message/close/cancel)
+	CallId        string                 `protobuf:"bytes,2,opt,name=call_id,json=callId,proto3" json:"call_id,omitempty"`                                                                 // 调用ID(同一控制流内唯一)
+	NodeId        string                 `protobuf:"bytes,3,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`                                                                 // 节点ID(hello帧)
+	Method        string                 `protobuf:"bytes,4,opt,name=method,proto3" json:"method,omitempty"`                                                                               // 完整方法名(request帧)，如/proto.DaemonService/ListAgents
+	Payload       []byte                 `protobuf:"bytes,5,opt,name=payload,proto3" json:"payload,omitempty"`                                                                             // 序列化的请求(request帧)或响应(message帧)
+	ServerStream  bool                   `protobuf:"varint,6,opt,name=server_stream,json=serverStream,proto3" json:"server_stream,omitempty"`                                              // 是否为服务端流式调用(request帧)
+	Code          int32                  `protobuf:"varint,7,opt,name=code,proto3" json:"code,omitempty"`                                                                                  // gRPC状态码(close帧)
+	Message       string                 `protobuf:"bytes,8,opt,name=message,proto3" json:"message,omitempty"`                                                                             // 错误信息(close帧)
+	Metadata      map[string]string      `protobuf:"bytes,9,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // 调用元数据(request帧)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TunnelFrame) Reset() {
+	*x = TunnelFrame{}
+	mi := &file_pkg_proto_daemon_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TunnelFrame) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TunnelFrame) ProtoMessage() {}
+
+func (x *TunnelFrame) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_daemon_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TunnelFrame.ProtoReflect.Descriptor instead.
+func (*TunnelFrame) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_daemon_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *TunnelFrame) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *TunnelFrame) GetCallId() string {
+	if x != nil {
+		return x.CallId
+	}
+	return ""
+}
+
+func (x *TunnelFrame) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *TunnelFrame) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *TunnelFrame) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *TunnelFrame) GetServerStream() bool {
+	if x != nil {
+		return x.ServerStream
+	}
+	return false
+}
+
+func (x *TunnelFrame) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *TunnelFrame) GetMessage() string {
 	if x != nil {
 		return x.Message
 	}
 	return ""
 }
 
+func (x *TunnelFrame) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
 var File_pkg_proto_daemon_proto protoreflect.FileDescriptor
 
 const file_pkg_proto_daemon_proto_rawDesc = "" +
@@ -1368,11 +2796,11 @@ const file_pkg_proto_daemon_proto_rawDesc = "" +
 	"\x06status\x18\x03 \x01(\tR\x06status\"G\n" +
 	"\x11HeartbeatResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
-	"\amessage\x18\x02 \x01(\tR\amessage\"[\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"_\n" +
 	"\x0eMetricsRequest\x12\x17\n" +
 	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12\x1c\n" +
 	"\ttimestamp\x18\x02 \x01(\x03R\ttimestamp\x12\x12\n" +
-	"\x04data\x18\x03 \x01(\fR\x04data\"E\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data:\x02\x18\x01\"E\n" +
 	"\x0fMetricsResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
 	"\amessage\x18\x02 \x01(\tR\amessage\"I\n" +
@@ -1433,7 +2861,7 @@ const file_pkg_proto_daemon_proto_rawDesc = "" +
 	"\x14AgentMetricsResponse\x12\x19\n" +
 	"\bagent_id\x18\x01 \x01(\tR\aagentId\x129\n" +
 	"\vdata_points\x18\x02 \x03(\v2\x18.proto.ResourceDataPointR\n" +
-	"dataPoints\"\xa6\x01\n" +
+	"dataPoints\"\xfa\x03\n" +
 	"\n" +
 	"AgentState\x12\x19\n" +
 	"\bagent_id\x18\x01 \x01(\tR\aagentId\x12\x16\n" +
@@ -1441,17 +2869,146 @@ const file_pkg_proto_daemon_proto_rawDesc = "" +
 	"\x03pid\x18\x03 \x01(\x05R\x03pid\x12%\n" +
 	"\x0elast_heartbeat\x18\x04 \x01(\x03R\rlastHeartbeat\x12\x12\n" +
 	"\x04type\x18\x05 \x01(\tR\x04type\x12\x18\n" +
-	"\aversion\x18\x06 \x01(\tR\aversion\"\\\n" +
+	"\aversion\x18\x06 \x01(\tR\aversion\x12K\n" +
+	"\x0ecustom_metrics\x18\a \x03(\v2$.proto.AgentState.CustomMetricsEntryR\rcustomMetrics\x12H\n" +
+	"\rcustom_fields\x18\b \x03(\v2#.proto.AgentState.CustomFieldsEntryR\fcustomFields\x128\n" +
+	"\x18custom_metrics_timestamp\x18\t \x01(\x03R\x16customMetricsTimestamp\x1a@\n" +
+	"\x12CustomMetricsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x01R\x05value:\x028\x01\x1a?\n" +
+	"\x11CustomFieldsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"z\n" +
 	"\x16SyncAgentStatesRequest\x12\x17\n" +
 	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12)\n" +
-	"\x06states\x18\x02 \x03(\v2\x11.proto.AgentStateR\x06states\"M\n" +
+	"\x06states\x18\x02 \x03(\v2\x11.proto.AgentStateR\x06states\x12\x1c\n" +
+	"\ttimestamp\x18\x03 \x01(\x03R\ttimestamp\"M\n" +
 	"\x17SyncAgentStatesResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
-	"\amessage\x18\x02 \x01(\tR\amessage2\xef\x04\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"\xe9\x01\n" +
+	"\n" +
+	"AgentEvent\x12\x19\n" +
+	"\bagent_id\x18\x01 \x01(\tR\aagentId\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\x12\x1c\n" +
+	"\ttimestamp\x18\x04 \x01(\x03R\ttimestamp\x128\n" +
+	"\adetails\x18\x05 \x03(\v2\x1e.proto.AgentEvent.DetailsEntryR\adetails\x1a:\n" +
+	"\fDetailsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"^\n" +
+	"\x18ReportAgentEventsRequest\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12)\n" +
+	"\x06events\x18\x02 \x03(\v2\x11.proto.AgentEventR\x06events\"O\n" +
+	"\x19ReportAgentEventsResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"\x83\x02\n" +
+	"\vCrashReport\x12\x19\n" +
+	"\bagent_id\x18\x01 \x01(\tR\aagentId\x12\x1b\n" +
+	"\texit_code\x18\x02 \x01(\x05R\bexitCode\x12\x16\n" +
+	"\x06signal\x18\x03 \x01(\tR\x06signal\x12\x1d\n" +
+	"\n" +
+	"started_at\x18\x04 \x01(\x03R\tstartedAt\x12\x1b\n" +
+	"\texited_at\x18\x05 \x01(\x03R\bexitedAt\x12\x1f\n" +
+	"\vduration_ms\x18\x06 \x01(\x03R\n" +
+	"durationMs\x12$\n" +
+	"\x0elast_log_lines\x18\a \x03(\tR\flastLogLines\x12!\n" +
+	"\fcircuit_open\x18\b \x01(\bR\vcircuitOpen\"]\n" +
+	"\x14ReportCrashesRequest\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12,\n" +
+	"\acrashes\x18\x02 \x03(\v2\x12.proto.CrashReportR\acrashes\"K\n" +
+	"\x15ReportCrashesResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"I\n" +
+	"\x16GetCrashReportsRequest\x12\x19\n" +
+	"\bagent_id\x18\x01 \x01(\tR\aagentId\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\"G\n" +
+	"\x17GetCrashReportsResponse\x12,\n" +
+	"\acrashes\x18\x01 \x03(\v2\x12.proto.CrashReportR\acrashes\"a\n" +
+	"\x14TailAgentLogsRequest\x12\x19\n" +
+	"\bagent_id\x18\x01 \x01(\tR\aagentId\x12\x14\n" +
+	"\x05lines\x18\x02 \x01(\x05R\x05lines\x12\x18\n" +
+	"\akeyword\x18\x03 \x01(\tR\akeyword\"H\n" +
+	"\x15TailAgentLogsResponse\x12\x19\n" +
+	"\bagent_id\x18\x01 \x01(\tR\aagentId\x12\x14\n" +
+	"\x05lines\x18\x02 \x03(\tR\x05lines\"l\n" +
+	"\x16FollowAgentLogsRequest\x12\x19\n" +
+	"\bagent_id\x18\x01 \x01(\tR\aagentId\x12\x1d\n" +
+	"\n" +
+	"tail_lines\x18\x02 \x01(\x05R\ttailLines\x12\x18\n" +
+	"\akeyword\x18\x03 \x01(\tR\akeyword\"I\n" +
+	"\x17FollowAgentLogsResponse\x12\x14\n" +
+	"\x05lines\x18\x01 \x03(\tR\x05lines\x12\x18\n" +
+	"\arotated\x18\x02 \x01(\bR\arotated\"\xce\x01\n" +
+	"\x16SearchAgentLogsRequest\x12\x19\n" +
+	"\bagent_id\x18\x01 \x01(\tR\aagentId\x12\x1d\n" +
+	"\n" +
+	"agent_type\x18\x02 \x01(\tR\tagentType\x12\x18\n" +
+	"\akeyword\x18\x03 \x01(\tR\akeyword\x12\x14\n" +
+	"\x05regex\x18\x04 \x01(\bR\x05regex\x12\x19\n" +
+	"\bsince_ms\x18\x05 \x01(\x03R\asinceMs\x12\x19\n" +
+	"\buntil_ms\x18\x06 \x01(\x03R\auntilMs\x12\x14\n" +
+	"\x05limit\x18\a \x01(\x05R\x05limit\"\xb4\x01\n" +
+	"\fLogSearchHit\x12\x19\n" +
+	"\bagent_id\x18\x01 \x01(\tR\aagentId\x12\x1d\n" +
+	"\n" +
+	"agent_type\x18\x02 \x01(\tR\tagentType\x12!\n" +
+	"\ftimestamp_ms\x18\x03 \x01(\x03R\vtimestampMs\x12\x12\n" +
+	"\x04line\x18\x04 \x01(\tR\x04line\x12\x12\n" +
+	"\x04file\x18\x05 \x01(\tR\x04file\x12\x1f\n" +
+	"\vline_number\x18\x06 \x01(\x05R\n" +
+	"lineNumber\"`\n" +
+	"\x17SearchAgentLogsResponse\x12'\n" +
+	"\x04hits\x18\x01 \x03(\v2\x13.proto.LogSearchHitR\x04hits\x12\x1c\n" +
+	"\ttruncated\x18\x02 \x01(\bR\ttruncated\"\xec\x01\n" +
+	"\rResourceAlert\x12\x19\n" +
+	"\bagent_id\x18\x01 \x01(\tR\aagentId\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\x01R\x05value\x12\x1c\n" +
+	"\tthreshold\x18\x04 \x01(\x01R\tthreshold\x12\x1f\n" +
+	"\vduration_ms\x18\x05 \x01(\x03R\n" +
+	"durationMs\x12\x1c\n" +
+	"\ttimestamp\x18\x06 \x01(\x03R\ttimestamp\x12\x16\n" +
+	"\x06action\x18\a \x01(\tR\x06action\x12!\n" +
+	"\faction_error\x18\b \x01(\tR\vactionError\"d\n" +
+	"\x1bReportResourceAlertsRequest\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12,\n" +
+	"\x06alerts\x18\x02 \x03(\v2\x14.proto.ResourceAlertR\x06alerts\"R\n" +
+	"\x1cReportResourceAlertsResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"\xbd\x01\n" +
+	"\x13ControlAgentRequest\x12\x19\n" +
+	"\bagent_id\x18\x01 \x01(\tR\aagentId\x12\x18\n" +
+	"\acommand\x18\x02 \x01(\tR\acommand\x128\n" +
+	"\x04args\x18\x03 \x03(\v2$.proto.ControlAgentRequest.ArgsEntryR\x04args\x1a7\n" +
+	"\tArgsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xd6\x01\n" +
+	"\x14ControlAgentResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x16\n" +
+	"\x06method\x18\x03 \x01(\tR\x06method\x129\n" +
+	"\x04data\x18\x04 \x03(\v2%.proto.ControlAgentResponse.DataEntryR\x04data\x1a7\n" +
+	"\tDataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xd3\x02\n" +
+	"\vTunnelFrame\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x17\n" +
+	"\acall_id\x18\x02 \x01(\tR\x06callId\x12\x17\n" +
+	"\anode_id\x18\x03 \x01(\tR\x06nodeId\x12\x16\n" +
+	"\x06method\x18\x04 \x01(\tR\x06method\x12\x18\n" +
+	"\apayload\x18\x05 \x01(\fR\apayload\x12#\n" +
+	"\rserver_stream\x18\x06 \x01(\bR\fserverStream\x12\x12\n" +
+	"\x04code\x18\a \x01(\x05R\x04code\x12\x18\n" +
+	"\amessage\x18\b \x01(\tR\amessage\x12<\n" +
+	"\bmetadata\x18\t \x03(\v2 .proto.TunnelFrame.MetadataEntryR\bmetadata\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x012\xbd\n" +
+	"\n" +
 	"\rDaemonService\x12;\n" +
 	"\bRegister\x12\x16.proto.RegisterRequest\x1a\x17.proto.RegisterResponse\x12>\n" +
-	"\tHeartbeat\x12\x17.proto.HeartbeatRequest\x1a\x18.proto.HeartbeatResponse\x12>\n" +
-	"\rReportMetrics\x12\x15.proto.MetricsRequest\x1a\x16.proto.MetricsResponse\x128\n" +
+	"\tHeartbeat\x12\x17.proto.HeartbeatRequest\x1a\x18.proto.HeartbeatResponse\x12C\n" +
+	"\rReportMetrics\x12\x15.proto.MetricsRequest\x1a\x16.proto.MetricsResponse\"\x03\x88\x02\x01\x128\n" +
 	"\tGetConfig\x12\x14.proto.ConfigRequest\x1a\x15.proto.ConfigResponse\x129\n" +
 	"\n" +
 	"PushUpdate\x12\x14.proto.UpdateRequest\x1a\x15.proto.UpdateResponse\x12A\n" +
@@ -1459,7 +3016,16 @@ const file_pkg_proto_daemon_proto_rawDesc = "" +
 	"ListAgents\x12\x18.proto.ListAgentsRequest\x1a\x19.proto.ListAgentsResponse\x12K\n" +
 	"\fOperateAgent\x12\x1c.proto.AgentOperationRequest\x1a\x1d.proto.AgentOperationResponse\x12J\n" +
 	"\x0fGetAgentMetrics\x12\x1a.proto.AgentMetricsRequest\x1a\x1b.proto.AgentMetricsResponse\x12P\n" +
-	"\x0fSyncAgentStates\x12\x1d.proto.SyncAgentStatesRequest\x1a\x1e.proto.SyncAgentStatesResponseB?Z=github.com/bingooyong/ops-scaffold-framework/daemon/pkg/protob\x06proto3"
+	"\x0fSyncAgentStates\x12\x1d.proto.SyncAgentStatesRequest\x1a\x1e.proto.SyncAgentStatesResponse\x12V\n" +
+	"\x11ReportAgentEvents\x12\x1f.proto.ReportAgentEventsRequest\x1a .proto.ReportAgentEventsResponse\x12J\n" +
+	"\rReportCrashes\x12\x1b.proto.ReportCrashesRequest\x1a\x1c.proto.ReportCrashesResponse\x12P\n" +
+	"\x0fGetCrashReports\x12\x1d.proto.GetCrashReportsRequest\x1a\x1e.proto.GetCrashReportsResponse\x12J\n" +
+	"\rTailAgentLogs\x12\x1b.proto.TailAgentLogsRequest\x1a\x1c.proto.TailAgentLogsResponse\x12R\n" +
+	"\x0fFollowAgentLogs\x12\x1d.proto.FollowAgentLogsRequest\x1a\x1e.proto.FollowAgentLogsResponse0\x01\x12P\n" +
+	"\x0fSearchAgentLogs\x12\x1d.proto.SearchAgentLogsRequest\x1a\x1e.proto.SearchAgentLogsResponse\x12_\n" +
+	"\x14ReportResourceAlerts\x12\".proto.ReportResourceAlertsRequest\x1a#.proto.ReportResourceAlertsResponse\x12G\n" +
+	"\fControlAgent\x12\x1a.proto.ControlAgentRequest\x1a\x1b.proto.ControlAgentResponse\x125\n" +
+	"\aConnect\x12\x12.proto.TunnelFrame\x1a\x12.proto.TunnelFrame(\x010\x01B?Z=github.com/bingooyong/ops-scaffold-framework/daemon/pkg/protob\x06proto3"
 
 var (
 	file_pkg_proto_daemon_proto_rawDescOnce sync.Once
@@ -1473,59 +3039,115 @@ func file_pkg_proto_daemon_proto_rawDescGZIP() []byte {
 	return file_pkg_proto_daemon_proto_rawDescData
 }
 
-var file_pkg_proto_daemon_proto_msgTypes = make([]protoimpl.MessageInfo, 22)
+var file_pkg_proto_daemon_proto_msgTypes = make([]protoimpl.MessageInfo, 49)
 var file_pkg_proto_daemon_proto_goTypes = []any{
-	(*RegisterRequest)(nil),         // 0: proto.RegisterRequest
-	(*RegisterResponse)(nil),        // 1: proto.RegisterResponse
-	(*HeartbeatRequest)(nil),        // 2: proto.HeartbeatRequest
-	(*HeartbeatResponse)(nil),       // 3: proto.HeartbeatResponse
-	(*MetricsRequest)(nil),          // 4: proto.MetricsRequest
-	(*MetricsResponse)(nil),         // 5: proto.MetricsResponse
-	(*ConfigRequest)(nil),           // 6: proto.ConfigRequest
-	(*ConfigResponse)(nil),          // 7: proto.ConfigResponse
-	(*UpdateRequest)(nil),           // 8: proto.UpdateRequest
-	(*UpdateResponse)(nil),          // 9: proto.UpdateResponse
-	(*AgentInfo)(nil),               // 10: proto.AgentInfo
-	(*ListAgentsRequest)(nil),       // 11: proto.ListAgentsRequest
-	(*ListAgentsResponse)(nil),      // 12: proto.ListAgentsResponse
-	(*AgentOperationRequest)(nil),   // 13: proto.AgentOperationRequest
-	(*AgentOperationResponse)(nil),  // 14: proto.AgentOperationResponse
-	(*ResourceDataPoint)(nil),       // 15: proto.ResourceDataPoint
-	(*AgentMetricsRequest)(nil),     // 16: proto.AgentMetricsRequest
-	(*AgentMetricsResponse)(nil),    // 17: proto.AgentMetricsResponse
-	(*AgentState)(nil),              // 18: proto.AgentState
-	(*SyncAgentStatesRequest)(nil),  // 19: proto.SyncAgentStatesRequest
-	(*SyncAgentStatesResponse)(nil), // 20: proto.SyncAgentStatesResponse
-	nil,                             // 21: proto.RegisterRequest.LabelsEntry
+	(*RegisterRequest)(nil),              // 0: proto.RegisterRequest
+	(*RegisterResponse)(nil),             // 1: proto.RegisterResponse
+	(*HeartbeatRequest)(nil),             // 2: proto.HeartbeatRequest
+	(*HeartbeatResponse)(nil),            // 3: proto.HeartbeatResponse
+	(*MetricsRequest)(nil),               // 4: proto.MetricsRequest
+	(*MetricsResponse)(nil),              // 5: proto.MetricsResponse
+	(*ConfigRequest)(nil),                // 6: proto.ConfigRequest
+	(*ConfigResponse)(nil),               // 7: proto.ConfigResponse
+	(*UpdateRequest)(nil),                // 8: proto.UpdateRequest
+	(*UpdateResponse)(nil),               // 9: proto.UpdateResponse
+	(*AgentInfo)(nil),                    // 10: proto.AgentInfo
+	(*ListAgentsRequest)(nil),            // 11: proto.ListAgentsRequest
+	(*ListAgentsResponse)(nil),           // 12: proto.ListAgentsResponse
+	(*AgentOperationRequest)(nil),        // 13: proto.AgentOperationRequest
+	(*AgentOperationResponse)(nil),       // 14: proto.AgentOperationResponse
+	(*ResourceDataPoint)(nil),            // 15: proto.ResourceDataPoint
+	(*AgentMetricsRequest)(nil),          // 16: proto.AgentMetricsRequest
+	(*AgentMetricsResponse)(nil),         // 17: proto.AgentMetricsResponse
+	(*AgentState)(nil),                   // 18: proto.AgentState
+	(*SyncAgentStatesRequest)(nil),       // 19: proto.SyncAgentStatesRequest
+	(*SyncAgentStatesResponse)(nil),      // 20: proto.SyncAgentStatesResponse
+	(*AgentEvent)(nil),                   // 21: proto.AgentEvent
+	(*ReportAgentEventsRequest)(nil),     // 22: proto.ReportAgentEventsRequest
+	(*ReportAgentEventsResponse)(nil),    // 23: proto.ReportAgentEventsResponse
+	(*CrashReport)(nil),                  // 24: proto.CrashReport
+	(*ReportCrashesRequest)(nil),         // 25: proto.ReportCrashesRequest
+	(*ReportCrashesResponse)(nil),        // 26: proto.ReportCrashesResponse
+	(*GetCrashReportsRequest)(nil),       // 27: proto.GetCrashReportsRequest
+	(*GetCrashReportsResponse)(nil),      // 28: proto.GetCrashReportsResponse
+	(*TailAgentLogsRequest)(nil),         // 29: proto.TailAgentLogsRequest
+	(*TailAgentLogsResponse)(nil),        // 30: proto.TailAgentLogsResponse
+	(*FollowAgentLogsRequest)(nil),       // 31: proto.FollowAgentLogsRequest
+	(*FollowAgentLogsResponse)(nil),      // 32: proto.FollowAgentLogsResponse
+	(*SearchAgentLogsRequest)(nil),       // 33: proto.SearchAgentLogsRequest
+	(*LogSearchHit)(nil),                 // 34: proto.LogSearchHit
+	(*SearchAgentLogsResponse)(nil),      // 35: proto.SearchAgentLogsResponse
+	(*ResourceAlert)(nil),                // 36: proto.ResourceAlert
+	(*ReportResourceAlertsRequest)(nil),  // 37: proto.ReportResourceAlertsRequest
+	(*ReportResourceAlertsResponse)(nil), // 38: proto.ReportResourceAlertsResponse
+	(*ControlAgentRequest)(nil),          // 39: proto.ControlAgentRequest
+	(*ControlAgentResponse)(nil),         // 40: proto.ControlAgentResponse
+	(*TunnelFrame)(nil),                  // 41: proto.TunnelFrame
+	nil,                                  // 42: proto.RegisterRequest.LabelsEntry
+	nil,                                  // 43: proto.AgentState.CustomMetricsEntry
+	nil,                                  // 44: proto.AgentState.CustomFieldsEntry
+	nil,                                  // 45: proto.AgentEvent.DetailsEntry
+	nil,                                  // 46: proto.ControlAgentRequest.ArgsEntry
+	nil,                                  // 47: proto.ControlAgentResponse.DataEntry
+	nil,                                  // 48: proto.TunnelFrame.MetadataEntry
 }
 var file_pkg_proto_daemon_proto_depIdxs = []int32{
-	21, // 0: proto.RegisterRequest.labels:type_name -> proto.RegisterRequest.LabelsEntry
+	42, // 0: proto.RegisterRequest.labels:type_name -> proto.RegisterRequest.LabelsEntry
 	10, // 1: proto.ListAgentsResponse.agents:type_name -> proto.AgentInfo
 	15, // 2: proto.AgentMetricsResponse.data_points:type_name -> proto.ResourceDataPoint
-	18, // 3: proto.SyncAgentStatesRequest.states:type_name -> proto.AgentState
-	0,  // 4: proto.DaemonService.Register:input_type -> proto.RegisterRequest
-	2,  // 5: proto.DaemonService.Heartbeat:input_type -> proto.HeartbeatRequest
-	4,  // 6: proto.DaemonService.ReportMetrics:input_type -> proto.MetricsRequest
-	6,  // 7: proto.DaemonService.GetConfig:input_type -> proto.ConfigRequest
-	8,  // 8: proto.DaemonService.PushUpdate:input_type -> proto.UpdateRequest
-	11, // 9: proto.DaemonService.ListAgents:input_type -> proto.ListAgentsRequest
-	13, // 10: proto.DaemonService.OperateAgent:input_type -> proto.AgentOperationRequest
-	16, // 11: proto.DaemonService.GetAgentMetrics:input_type -> proto.AgentMetricsRequest
-	19, // 12: proto.DaemonService.SyncAgentStates:input_type -> proto.SyncAgentStatesRequest
-	1,  // 13: proto.DaemonService.Register:output_type -> proto.RegisterResponse
-	3,  // 14: proto.DaemonService.Heartbeat:output_type -> proto.HeartbeatResponse
-	5,  // 15: proto.DaemonService.ReportMetrics:output_type -> proto.MetricsResponse
-	7,  // 16: proto.DaemonService.GetConfig:output_type -> proto.ConfigResponse
-	9,  // 17: proto.DaemonService.PushUpdate:output_type -> proto.UpdateResponse
-	12, // 18: proto.DaemonService.ListAgents:output_type -> proto.ListAgentsResponse
-	14, // 19: proto.DaemonService.OperateAgent:output_type -> proto.AgentOperationResponse
-	17, // 20: proto.DaemonService.GetAgentMetrics:output_type -> proto.AgentMetricsResponse
-	20, // 21: proto.DaemonService.SyncAgentStates:output_type -> proto.SyncAgentStatesResponse
-	13, // [13:22] is the sub-list for method output_type
-	4,  // [4:13] is the sub-list for method input_type
-	4,  // [4:4] is the sub-list for extension type_name
-	4,  // [4:4] is the sub-list for extension extendee
-	0,  // [0:4] is the sub-list for field type_name
+	43, // 3: proto.AgentState.custom_metrics:type_name -> proto.AgentState.CustomMetricsEntry
+	44, // 4: proto.AgentState.custom_fields:type_name -> proto.AgentState.CustomFieldsEntry
+	18, // 5: proto.SyncAgentStatesRequest.states:type_name -> proto.AgentState
+	45, // 6: proto.AgentEvent.details:type_name -> proto.AgentEvent.DetailsEntry
+	21, // 7: proto.ReportAgentEventsRequest.events:type_name -> proto.AgentEvent
+	24, // 8: proto.ReportCrashesRequest.crashes:type_name -> proto.CrashReport
+	24, // 9: proto.GetCrashReportsResponse.crashes:type_name -> proto.CrashReport
+	34, // 10: proto.SearchAgentLogsResponse.hits:type_name -> proto.LogSearchHit
+	36, // 11: proto.ReportResourceAlertsRequest.alerts:type_name -> proto.ResourceAlert
+	46, // 12: proto.ControlAgentRequest.args:type_name -> proto.ControlAgentRequest.ArgsEntry
+	47, // 13: proto.ControlAgentResponse.data:type_name -> proto.ControlAgentResponse.DataEntry
+	48, // 14: proto.TunnelFrame.metadata:type_name -> proto.TunnelFrame.MetadataEntry
+	0,  // 15: proto.DaemonService.Register:input_type -> proto.RegisterRequest
+	2,  // 16: proto.DaemonService.Heartbeat:input_type -> proto.HeartbeatRequest
+	4,  // 17: proto.DaemonService.ReportMetrics:input_type -> proto.MetricsRequest
+	6,  // 18: proto.DaemonService.GetConfig:input_type -> proto.ConfigRequest
+	8,  // 19: proto.DaemonService.PushUpdate:input_type -> proto.UpdateRequest
+	11, // 20: proto.DaemonService.ListAgents:input_type -> proto.ListAgentsRequest
+	13, // 21: proto.DaemonService.OperateAgent:input_type -> proto.AgentOperationRequest
+	16, // 22: proto.DaemonService.GetAgentMetrics:input_type -> proto.AgentMetricsRequest
+	19, // 23: proto.DaemonService.SyncAgentStates:input_type -> proto.SyncAgentStatesRequest
+	22, // 24: proto.DaemonService.ReportAgentEvents:input_type -> proto.ReportAgentEventsRequest
+	25, // 25: proto.DaemonService.ReportCrashes:input_type -> proto.ReportCrashesRequest
+	27, // 26: proto.DaemonService.GetCrashReports:input_type -> proto.GetCrashReportsRequest
+	29, // 27: proto.DaemonService.TailAgentLogs:input_type -> proto.TailAgentLogsRequest
+	31, // 28: proto.DaemonService.FollowAgentLogs:input_type -> proto.FollowAgentLogsRequest
+	33, // 29: proto.DaemonService.SearchAgentLogs:input_type -> proto.SearchAgentLogsRequest
+	37, // 30: proto.DaemonService.ReportResourceAlerts:input_type -> proto.ReportResourceAlertsRequest
+	39, // 31: proto.DaemonService.ControlAgent:input_type -> proto.ControlAgentRequest
+	41, // 32: proto.DaemonService.Connect:input_type -> proto.TunnelFrame
+	1,  // 33: proto.DaemonService.Register:output_type -> proto.RegisterResponse
+	3,  // 34: proto.DaemonService.Heartbeat:output_type -> proto.HeartbeatResponse
+	5,  // 35: proto.DaemonService.ReportMetrics:output_type -> proto.MetricsResponse
+	7,  // 36: proto.DaemonService.GetConfig:output_type -> proto.ConfigResponse
+	9,  // 37: proto.DaemonService.PushUpdate:output_type -> proto.UpdateResponse
+	12, // 38: proto.DaemonService.ListAgents:output_type -> proto.ListAgentsResponse
+	14, // 39: proto.DaemonService.OperateAgent:output_type -> proto.AgentOperationResponse
+	17, // 40: proto.DaemonService.GetAgentMetrics:output_type -> proto.AgentMetricsResponse
+	20, // 41: proto.DaemonService.SyncAgentStates:output_type -> proto.SyncAgentStatesResponse
+	23, // 42: proto.DaemonService.ReportAgentEvents:output_type -> proto.ReportAgentEventsResponse
+	26, // 43: proto.DaemonService.ReportCrashes:output_type -> proto.ReportCrashesResponse
+	28, // 44: proto.DaemonService.GetCrashReports:output_type -> proto.GetCrashReportsResponse
+	30, // 45: proto.DaemonService.TailAgentLogs:output_type -> proto.TailAgentLogsResponse
+	32, // 46: proto.DaemonService.FollowAgentLogs:output_type -> proto.FollowAgentLogsResponse
+	35, // 47: proto.DaemonService.SearchAgentLogs:output_type -> proto.SearchAgentLogsResponse
+	38, // 48: proto.DaemonService.ReportResourceAlerts:output_type -> proto.ReportResourceAlertsResponse
+	40, // 49: proto.DaemonService.ControlAgent:output_type -> proto.ControlAgentResponse
+	41, // 50: proto.DaemonService.Connect:output_type -> proto.TunnelFrame
+	33, // [33:51] is the sub-list for method output_type
+	15, // [15:33] is the sub-list for method input_type
+	15, // [15:15] is the sub-list for extension type_name
+	15, // [15:15] is the sub-list for extension extendee
+	0,  // [0:15] is the sub-list for field type_name
 }
 
 func init() { file_pkg_proto_daemon_proto_init() }
@@ -1539,7 +3161,7 @@ func file_pkg_proto_daemon_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pkg_proto_daemon_proto_rawDesc), len(file_pkg_proto_daemon_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   22,
+			NumMessages:   49,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -19,15 +19,24 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	DaemonService_Register_FullMethodName        = "/proto.DaemonService/Register"
-	DaemonService_Heartbeat_FullMethodName       = "/proto.DaemonService/Heartbeat"
-	DaemonService_ReportMetrics_FullMethodName   = "/proto.DaemonService/ReportMetrics"
-	DaemonService_GetConfig_FullMethodName       = "/proto.DaemonService/GetConfig"
-	DaemonService_PushUpdate_FullMethodName      = "/proto.DaemonService/PushUpdate"
-	DaemonService_ListAgents_FullMethodName      = "/proto.DaemonService/ListAgents"
-	DaemonService_OperateAgent_FullMethodName    = "/proto.DaemonService/OperateAgent"
-	DaemonService_GetAgentMetrics_FullMethodName = "/proto.DaemonService/GetAgentMetrics"
-	DaemonService_SyncAgentStates_FullMethodName = "/proto.DaemonService/SyncAgentStates"
+	DaemonService_Register_FullMethodName             = "/proto.DaemonService/Register"
+	DaemonService_Heartbeat_FullMethodName            = "/proto.DaemonService/Heartbeat"
+	DaemonService_ReportMetrics_FullMethodName        = "/proto.DaemonService/ReportMetrics"
+	DaemonService_GetConfig_FullMethodName            = "/proto.DaemonService/GetConfig"
+	DaemonService_PushUpdate_FullMethodName           = "/proto.DaemonService/PushUpdate"
+	DaemonService_ListAgents_FullMethodName           = "/proto.DaemonService/ListAgents"
+	DaemonService_OperateAgent_FullMethodName         = "/proto.DaemonService/OperateAgent"
+	DaemonService_GetAgentMetrics_FullMethodName      = "/proto.DaemonService/GetAgentMetrics"
+	DaemonService_SyncAgentStates_FullMethodName      = "/proto.DaemonService/SyncAgentStates"
+	DaemonService_ReportAgentEvents_FullMethodName    = "/proto.DaemonService/ReportAgentEvents"
+	DaemonService_ReportCrashes_FullMethodName        = "/proto.DaemonService/ReportCrashes"
+	DaemonService_GetCrashReports_FullMethodName      = "/proto.DaemonService/GetCrashReports"
+	DaemonService_TailAgentLogs_FullMethodName        = "/proto.DaemonService/TailAgentLogs"
+	DaemonService_FollowAgentLogs_FullMethodName      = "/proto.DaemonService/FollowAgentLogs"
+	DaemonService_SearchAgentLogs_FullMethodName      = "/proto.DaemonService/SearchAgentLogs"
+	DaemonService_ReportResourceAlerts_FullMethodName = "/proto.DaemonService/ReportResourceAlerts"
+	DaemonService_ControlAgent_FullMethodName         = "/proto.DaemonService/ControlAgent"
+	DaemonService_Connect_FullMethodName              = "/proto.DaemonService/Connect"
 )
 
 // DaemonServiceClient is the client API for DaemonService service.
@@ -40,7 +49,8 @@ type DaemonServiceClient interface {
 	Register(ctx context.Context, in *RegisterRequest, opts ...grpc.CallOption) (*RegisterResponse, error)
 	// Heartbeat 心跳上报
 	Heartbeat(ctx context.Context, in *HeartbeatRequest, opts ...grpc.CallOption) (*HeartbeatResponse, error)
-	// ReportMetrics 指标上报
+	// Deprecated: Do not use.
+	// ReportMetrics 指标上报(已废弃，使用 ManagerService.ReportMetrics 的 MetricsBatch)
 	ReportMetrics(ctx context.Context, in *MetricsRequest, opts ...grpc.CallOption) (*MetricsResponse, error)
 	// GetConfig 获取配置
 	GetConfig(ctx context.Context, in *ConfigRequest, opts ...grpc.CallOption) (*ConfigResponse, error)
@@ -54,6 +64,25 @@ type DaemonServiceClient interface {
 	GetAgentMetrics(ctx context.Context, in *AgentMetricsRequest, opts ...grpc.CallOption) (*AgentMetricsResponse, error)
 	// SyncAgentStates 同步Agent状态(用于Daemon向Manager上报状态)
 	SyncAgentStates(ctx context.Context, in *SyncAgentStatesRequest, opts ...grpc.CallOption) (*SyncAgentStatesResponse, error)
+	// ReportAgentEvents 上报Agent事件(用于Daemon向Manager上报熔断等事件)
+	ReportAgentEvents(ctx context.Context, in *ReportAgentEventsRequest, opts ...grpc.CallOption) (*ReportAgentEventsResponse, error)
+	// ReportCrashes 上报Agent崩溃记录(用于Daemon向Manager上报)
+	ReportCrashes(ctx context.Context, in *ReportCrashesRequest, opts ...grpc.CallOption) (*ReportCrashesResponse, error)
+	// GetCrashReports 获取Agent崩溃记录
+	GetCrashReports(ctx context.Context, in *GetCrashReportsRequest, opts ...grpc.CallOption) (*GetCrashReportsResponse, error)
+	// TailAgentLogs 获取Agent日志末尾N行(可按关键词过滤)
+	TailAgentLogs(ctx context.Context, in *TailAgentLogsRequest, opts ...grpc.CallOption) (*TailAgentLogsResponse, error)
+	// FollowAgentLogs 持续推送Agent新增日志(follow模式，处理日志轮转)
+	FollowAgentLogs(ctx context.Context, in *FollowAgentLogsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[FollowAgentLogsResponse], error)
+	// SearchAgentLogs 按关键词/正则和时间范围搜索Agent日志(包括已轮转的日志文件)
+	SearchAgentLogs(ctx context.Context, in *SearchAgentLogsRequest, opts ...grpc.CallOption) (*SearchAgentLogsResponse, error)
+	// ReportResourceAlerts 上报Agent资源告警(用于Daemon向Manager上报)
+	ReportResourceAlerts(ctx context.Context, in *ReportResourceAlertsRequest, opts ...grpc.CallOption) (*ReportResourceAlertsResponse, error)
+	// ControlAgent 通过控制通道向Agent下发命令(reload/set_log_level/dump_diagnostics/drain)，不支持时回退为信号
+	ControlAgent(ctx context.Context, in *ControlAgentRequest, opts ...grpc.CallOption) (*ControlAgentResponse, error)
+	// Connect Daemon主动建立的双向控制流(用于仅允许出站连接的节点)
+	// Manager通过该流向Daemon转发DaemonService调用(ListAgents/OperateAgent/GetAgentMetrics等)
+	Connect(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[TunnelFrame, TunnelFrame], error)
 }
 
 type daemonServiceClient struct {
@@ -84,6 +113,7 @@ func (c *daemonServiceClient) Heartbeat(ctx context.Context, in *HeartbeatReques
 	return out, nil
 }
 
+// Deprecated: Do not use.
 func (c *daemonServiceClient) ReportMetrics(ctx context.Context, in *MetricsRequest, opts ...grpc.CallOption) (*MetricsResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(MetricsResponse)
@@ -154,6 +184,108 @@ func (c *daemonServiceClient) SyncAgentStates(ctx context.Context, in *SyncAgent
 	return out, nil
 }
 
+func (c *daemonServiceClient) ReportAgentEvents(ctx context.Context, in *ReportAgentEventsRequest, opts ...grpc.CallOption) (*ReportAgentEventsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReportAgentEventsResponse)
+	err := c.cc.Invoke(ctx, DaemonService_ReportAgentEvents_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonServiceClient) ReportCrashes(ctx context.Context, in *ReportCrashesRequest, opts ...grpc.CallOption) (*ReportCrashesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReportCrashesResponse)
+	err := c.cc.Invoke(ctx, DaemonService_ReportCrashes_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonServiceClient) GetCrashReports(ctx context.Context, in *GetCrashReportsRequest, opts ...grpc.CallOption) (*GetCrashReportsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetCrashReportsResponse)
+	err := c.cc.Invoke(ctx, DaemonService_GetCrashReports_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonServiceClient) TailAgentLogs(ctx context.Context, in *TailAgentLogsRequest, opts ...grpc.CallOption) (*TailAgentLogsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TailAgentLogsResponse)
+	err := c.cc.Invoke(ctx, DaemonService_TailAgentLogs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonServiceClient) FollowAgentLogs(ctx context.Context, in *FollowAgentLogsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[FollowAgentLogsResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &DaemonService_ServiceDesc.Streams[0], DaemonService_FollowAgentLogs_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[FollowAgentLogsRequest, FollowAgentLogsResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type DaemonService_FollowAgentLogsClient = grpc.ServerStreamingClient[FollowAgentLogsResponse]
+
+func (c *daemonServiceClient) SearchAgentLogs(ctx context.Context, in *SearchAgentLogsRequest, opts ...grpc.CallOption) (*SearchAgentLogsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchAgentLogsResponse)
+	err := c.cc.Invoke(ctx, DaemonService_SearchAgentLogs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonServiceClient) ReportResourceAlerts(ctx context.Context, in *ReportResourceAlertsRequest, opts ...grpc.CallOption) (*ReportResourceAlertsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReportResourceAlertsResponse)
+	err := c.cc.Invoke(ctx, DaemonService_ReportResourceAlerts_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonServiceClient) ControlAgent(ctx context.Context, in *ControlAgentRequest, opts ...grpc.CallOption) (*ControlAgentResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ControlAgentResponse)
+	err := c.cc.Invoke(ctx, DaemonService_ControlAgent_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daemonServiceClient) Connect(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[TunnelFrame, TunnelFrame], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &DaemonService_ServiceDesc.Streams[1], DaemonService_Connect_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[TunnelFrame, TunnelFrame]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type DaemonService_ConnectClient = grpc.BidiStreamingClient[TunnelFrame, TunnelFrame]
+
 // DaemonServiceServer is the server API for DaemonService service.
 // All implementations must embed UnimplementedDaemonServiceServer
 // for forward compatibility.
@@ -164,7 +296,8 @@ type DaemonServiceServer interface {
 	Register(context.Context, *RegisterRequest) (*RegisterResponse, error)
 	// Heartbeat 心跳上报
 	Heartbeat(context.Context, *HeartbeatRequest) (*HeartbeatResponse, error)
-	// ReportMetrics 指标上报
+	// Deprecated: Do not use.
+	// ReportMetrics 指标上报(已废弃，使用 ManagerService.ReportMetrics 的 MetricsBatch)
 	ReportMetrics(context.Context, *MetricsRequest) (*MetricsResponse, error)
 	// GetConfig 获取配置
 	GetConfig(context.Context, *ConfigRequest) (*ConfigResponse, error)
@@ -178,6 +311,25 @@ type DaemonServiceServer interface {
 	GetAgentMetrics(context.Context, *AgentMetricsRequest) (*AgentMetricsResponse, error)
 	// SyncAgentStates 同步Agent状态(用于Daemon向Manager上报状态)
 	SyncAgentStates(context.Context, *SyncAgentStatesRequest) (*SyncAgentStatesResponse, error)
+	// ReportAgentEvents 上报Agent事件(用于Daemon向Manager上报熔断等事件)
+	ReportAgentEvents(context.Context, *ReportAgentEventsRequest) (*ReportAgentEventsResponse, error)
+	// ReportCrashes 上报Agent崩溃记录(用于Daemon向Manager上报)
+	ReportCrashes(context.Context, *ReportCrashesRequest) (*ReportCrashesResponse, error)
+	// GetCrashReports 获取Agent崩溃记录
+	GetCrashReports(context.Context, *GetCrashReportsRequest) (*GetCrashReportsResponse, error)
+	// TailAgentLogs 获取Agent日志末尾N行(可按关键词过滤)
+	TailAgentLogs(context.Context, *TailAgentLogsRequest) (*TailAgentLogsResponse, error)
+	// FollowAgentLogs 持续推送Agent新增日志(follow模式，处理日志轮转)
+	FollowAgentLogs(*FollowAgentLogsRequest, grpc.ServerStreamingServer[FollowAgentLogsResponse]) error
+	// SearchAgentLogs 按关键词/正则和时间范围搜索Agent日志(包括已轮转的日志文件)
+	SearchAgentLogs(context.Context, *SearchAgentLogsRequest) (*SearchAgentLogsResponse, error)
+	// ReportResourceAlerts 上报Agent资源告警(用于Daemon向Manager上报)
+	ReportResourceAlerts(context.Context, *ReportResourceAlertsRequest) (*ReportResourceAlertsResponse, error)
+	// ControlAgent 通过控制通道向Agent下发命令(reload/set_log_level/dump_diagnostics/drain)，不支持时回退为信号
+	ControlAgent(context.Context, *ControlAgentRequest) (*ControlAgentResponse, error)
+	// Connect Daemon主动建立的双向控制流(用于仅允许出站连接的节点)
+	// Manager通过该流向Daemon转发DaemonService调用(ListAgents/OperateAgent/GetAgentMetrics等)
+	Connect(grpc.BidiStreamingServer[TunnelFrame, TunnelFrame]) error
 	mustEmbedUnimplementedDaemonServiceServer()
 }
 
@@ -215,6 +367,33 @@ func (UnimplementedDaemonServiceServer) GetAgentMetrics(context.Context, *AgentM
 func (UnimplementedDaemonServiceServer) SyncAgentStates(context.Context, *SyncAgentStatesRequest) (*SyncAgentStatesResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method SyncAgentStates not implemented")
 }
+func (UnimplementedDaemonServiceServer) ReportAgentEvents(context.Context, *ReportAgentEventsRequest) (*ReportAgentEventsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ReportAgentEvents not implemented")
+}
+func (UnimplementedDaemonServiceServer) ReportCrashes(context.Context, *ReportCrashesRequest) (*ReportCrashesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ReportCrashes not implemented")
+}
+func (UnimplementedDaemonServiceServer) GetCrashReports(context.Context, *GetCrashReportsRequest) (*GetCrashReportsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetCrashReports not implemented")
+}
+func (UnimplementedDaemonServiceServer) TailAgentLogs(context.Context, *TailAgentLogsRequest) (*TailAgentLogsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method TailAgentLogs not implemented")
+}
+func (UnimplementedDaemonServiceServer) FollowAgentLogs(*FollowAgentLogsRequest, grpc.ServerStreamingServer[FollowAgentLogsResponse]) error {
+	return status.Error(codes.Unimplemented, "method FollowAgentLogs not implemented")
+}
+func (UnimplementedDaemonServiceServer) SearchAgentLogs(context.Context, *SearchAgentLogsRequest) (*SearchAgentLogsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method SearchAgentLogs not implemented")
+}
+func (UnimplementedDaemonServiceServer) ReportResourceAlerts(context.Context, *ReportResourceAlertsRequest) (*ReportResourceAlertsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ReportResourceAlerts not implemented")
+}
+func (UnimplementedDaemonServiceServer) ControlAgent(context.Context, *ControlAgentRequest) (*ControlAgentResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ControlAgent not implemented")
+}
+func (UnimplementedDaemonServiceServer) Connect(grpc.BidiStreamingServer[TunnelFrame, TunnelFrame]) error {
+	return status.Error(codes.Unimplemented, "method Connect not implemented")
+}
 func (UnimplementedDaemonServiceServer) mustEmbedUnimplementedDaemonServiceServer() {}
 func (UnimplementedDaemonServiceServer) testEmbeddedByValue()                       {}
 
@@ -398,6 +577,150 @@ func _DaemonService_SyncAgentStates_Handler(srv interface{}, ctx context.Context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _DaemonService_ReportAgentEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReportAgentEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServiceServer).ReportAgentEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DaemonService_ReportAgentEvents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServiceServer).ReportAgentEvents(ctx, req.(*ReportAgentEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DaemonService_ReportCrashes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReportCrashesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServiceServer).ReportCrashes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DaemonService_ReportCrashes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServiceServer).ReportCrashes(ctx, req.(*ReportCrashesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DaemonService_GetCrashReports_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCrashReportsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServiceServer).GetCrashReports(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DaemonService_GetCrashReports_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServiceServer).GetCrashReports(ctx, req.(*GetCrashReportsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DaemonService_TailAgentLogs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TailAgentLogsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServiceServer).TailAgentLogs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DaemonService_TailAgentLogs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServiceServer).TailAgentLogs(ctx, req.(*TailAgentLogsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DaemonService_FollowAgentLogs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(FollowAgentLogsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DaemonServiceServer).FollowAgentLogs(m, &grpc.GenericServerStream[FollowAgentLogsRequest, FollowAgentLogsResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type DaemonService_FollowAgentLogsServer = grpc.ServerStreamingServer[FollowAgentLogsResponse]
+
+func _DaemonService_SearchAgentLogs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchAgentLogsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServiceServer).SearchAgentLogs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DaemonService_SearchAgentLogs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServiceServer).SearchAgentLogs(ctx, req.(*SearchAgentLogsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DaemonService_ReportResourceAlerts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReportResourceAlertsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServiceServer).ReportResourceAlerts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DaemonService_ReportResourceAlerts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServiceServer).ReportResourceAlerts(ctx, req.(*ReportResourceAlertsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DaemonService_ControlAgent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ControlAgentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaemonServiceServer).ControlAgent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DaemonService_ControlAgent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaemonServiceServer).ControlAgent(ctx, req.(*ControlAgentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DaemonService_Connect_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(DaemonServiceServer).Connect(&grpc.GenericServerStream[TunnelFrame, TunnelFrame]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type DaemonService_ConnectServer = grpc.BidiStreamingServer[TunnelFrame, TunnelFrame]
+
 // DaemonService_ServiceDesc is the grpc.ServiceDesc for DaemonService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -441,7 +764,47 @@ var DaemonService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "SyncAgentStates",
 			Handler:    _DaemonService_SyncAgentStates_Handler,
 		},
+		{
+			MethodName: "ReportAgentEvents",
+			Handler:    _DaemonService_ReportAgentEvents_Handler,
+		},
+		{
+			MethodName: "ReportCrashes",
+			Handler:    _DaemonService_ReportCrashes_Handler,
+		},
+		{
+			MethodName: "GetCrashReports",
+			Handler:    _DaemonService_GetCrashReports_Handler,
+		},
+		{
+			MethodName: "TailAgentLogs",
+			Handler:    _DaemonService_TailAgentLogs_Handler,
+		},
+		{
+			MethodName: "SearchAgentLogs",
+			Handler:    _DaemonService_SearchAgentLogs_Handler,
+		},
+		{
+			MethodName: "ReportResourceAlerts",
+			Handler:    _DaemonService_ReportResourceAlerts_Handler,
+		},
+		{
+			MethodName: "ControlAgent",
+			Handler:    _DaemonService_ControlAgent_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "FollowAgentLogs",
+			Handler:       _DaemonService_FollowAgentLogs_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Connect",
+			Handler:       _DaemonService_Connect_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "pkg/proto/daemon.proto",
 }
@@ -21,6 +21,56 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// MetricValueType 指标值类型
+type MetricValueType int32
+
+const (
+	MetricValueType_METRIC_VALUE_TYPE_UNSPECIFIED MetricValueType = 0
+	MetricValueType_METRIC_VALUE_TYPE_GAUGE       MetricValueType = 1 // 瞬时值
+	MetricValueType_METRIC_VALUE_TYPE_COUNTER     MetricValueType = 2 // 单调递增的累计值
+)
+
+// Enum value maps for MetricValueType.
+var (
+	MetricValueType_name = map[int32]string{
+		0: "METRIC_VALUE_TYPE_UNSPECIFIED",
+		1: "METRIC_VALUE_TYPE_GAUGE",
+		2: "METRIC_VALUE_TYPE_COUNTER",
+	}
+	MetricValueType_value = map[string]int32{
+		"METRIC_VALUE_TYPE_UNSPECIFIED": 0,
+		"METRIC_VALUE_TYPE_GAUGE":       1,
+		"METRIC_VALUE_TYPE_COUNTER":     2,
+	}
+)
+
+func (x MetricValueType) Enum() *MetricValueType {
+	p := new(MetricValueType)
+	*p = x
+	return p
+}
+
+func (x MetricValueType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (MetricValueType) Descriptor() protoreflect.EnumDescriptor {
+	return file_pkg_proto_manager_manager_proto_enumTypes[0].Descriptor()
+}
+
+func (MetricValueType) Type() protoreflect.EnumType {
+	return &file_pkg_proto_manager_manager_proto_enumTypes[0]
+}
+
+func (x MetricValueType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use MetricValueType.Descriptor instead.
+func (MetricValueType) EnumDescriptor() ([]byte, []int) {
+	return file_pkg_proto_manager_manager_proto_rawDescGZIP(), []int{0}
+}
+
 // RegisterNodeRequest 节点注册请求
 type RegisterNodeRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -32,6 +82,7 @@ type RegisterNodeRequest struct {
 	Labels        map[string]string      `protobuf:"bytes,6,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 	DaemonVersion string                 `protobuf:"bytes,7,opt,name=daemon_version,json=daemonVersion,proto3" json:"daemon_version,omitempty"`
 	AgentVersion  string                 `protobuf:"bytes,8,opt,name=agent_version,json=agentVersion,proto3" json:"agent_version,omitempty"`
+	Fingerprint   string                 `protobuf:"bytes,9,opt,name=fingerprint,proto3" json:"fingerprint,omitempty"` // 主机指纹，用于检测不同主机使用相同node_id
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -122,11 +173,19 @@ func (x *RegisterNodeRequest) GetAgentVersion() string {
 	return ""
 }
 
+func (x *RegisterNodeRequest) GetFingerprint() string {
+	if x != nil {
+		return x.Fingerprint
+	}
+	return ""
+}
+
 // RegisterNodeResponse 节点注册响应
 type RegisterNodeResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
 	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Status        string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"` // 注册审批状态：approved, pending, rejected, quarantined
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -175,11 +234,19 @@ func (x *RegisterNodeResponse) GetMessage() string {
 	return ""
 }
 
+func (x *RegisterNodeResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
 // HeartbeatRequest 心跳请求
 type HeartbeatRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
 	Timestamp     int64                  `protobuf:"varint,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Fingerprint   string                 `protobuf:"bytes,3,opt,name=fingerprint,proto3" json:"fingerprint,omitempty"` // 主机指纹
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -228,11 +295,19 @@ func (x *HeartbeatRequest) GetTimestamp() int64 {
 	return 0
 }
 
+func (x *HeartbeatRequest) GetFingerprint() string {
+	if x != nil {
+		return x.Fingerprint
+	}
+	return ""
+}
+
 // HeartbeatResponse 心跳响应
 type HeartbeatResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
 	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Status        string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"` // 注册审批状态：approved, pending, rejected, quarantined
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -281,7 +356,14 @@ func (x *HeartbeatResponse) GetMessage() string {
 	return ""
 }
 
-// MetricData 指标数据
+func (x *HeartbeatResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+// MetricData 指标数据(旧格式，已由 MetricsBatch 取代)
 type MetricData struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"` // cpu, memory, disk, network
@@ -342,18 +424,233 @@ func (x *MetricData) GetValues() map[string]float64 {
 	return nil
 }
 
+// MetricPoint 指标的一个采样点
+type MetricPoint struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	TimestampMs int64                  `protobuf:"varint,1,opt,name=timestamp_ms,json=timestampMs,proto3" json:"timestamp_ms,omitempty"` // 采集时间(Unix毫秒)
+	// Types that are valid to be assigned to Value:
+	//
+	//	*MetricPoint_DoubleValue
+	//	*MetricPoint_IntValue
+	Value         isMetricPoint_Value `protobuf_oneof:"value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MetricPoint) Reset() {
+	*x = MetricPoint{}
+	mi := &file_pkg_proto_manager_manager_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MetricPoint) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MetricPoint) ProtoMessage() {}
+
+func (x *MetricPoint) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_manager_manager_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MetricPoint.ProtoReflect.Descriptor instead.
+func (*MetricPoint) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_manager_manager_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *MetricPoint) GetTimestampMs() int64 {
+	if x != nil {
+		return x.TimestampMs
+	}
+	return 0
+}
+
+func (x *MetricPoint) GetValue() isMetricPoint_Value {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *MetricPoint) GetDoubleValue() float64 {
+	if x != nil {
+		if x, ok := x.Value.(*MetricPoint_DoubleValue); ok {
+			return x.DoubleValue
+		}
+	}
+	return 0
+}
+
+func (x *MetricPoint) GetIntValue() int64 {
+	if x != nil {
+		if x, ok := x.Value.(*MetricPoint_IntValue); ok {
+			return x.IntValue
+		}
+	}
+	return 0
+}
+
+type isMetricPoint_Value interface {
+	isMetricPoint_Value()
+}
+
+type MetricPoint_DoubleValue struct {
+	DoubleValue float64 `protobuf:"fixed64,2,opt,name=double_value,json=doubleValue,proto3,oneof"`
+}
+
+type MetricPoint_IntValue struct {
+	IntValue int64 `protobuf:"varint,3,opt,name=int_value,json=intValue,proto3,oneof"`
+}
+
+func (*MetricPoint_DoubleValue) isMetricPoint_Value() {}
+
+func (*MetricPoint_IntValue) isMetricPoint_Value() {}
+
+// MetricSeries 一条时间序列：指标名+标签唯一确定，可携带多个时间点
+type MetricSeries struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`                                                                               // 如 cpu.usage_percent、disk.used_bytes
+	Labels        map[string]string      `protobuf:"bytes,2,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // 如 device=sda1、interface=eth0、cpu=cpu0
+	Type          MetricValueType        `protobuf:"varint,3,opt,name=type,proto3,enum=manager.MetricValueType" json:"type,omitempty"`
+	Points        []*MetricPoint         `protobuf:"bytes,4,rep,name=points,proto3" json:"points,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MetricSeries) Reset() {
+	*x = MetricSeries{}
+	mi := &file_pkg_proto_manager_manager_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MetricSeries) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MetricSeries) ProtoMessage() {}
+
+func (x *MetricSeries) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_manager_manager_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MetricSeries.ProtoReflect.Descriptor instead.
+func (*MetricSeries) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_manager_manager_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *MetricSeries) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *MetricSeries) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *MetricSeries) GetType() MetricValueType {
+	if x != nil {
+		return x.Type
+	}
+	return MetricValueType_METRIC_VALUE_TYPE_UNSPECIFIED
+}
+
+func (x *MetricSeries) GetPoints() []*MetricPoint {
+	if x != nil {
+		return x.Points
+	}
+	return nil
+}
+
+// MetricsBatch 版本化的批量指标
+type MetricsBatch struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       uint32                 `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"` // 当前为 1
+	Series        []*MetricSeries        `protobuf:"bytes,2,rep,name=series,proto3" json:"series,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MetricsBatch) Reset() {
+	*x = MetricsBatch{}
+	mi := &file_pkg_proto_manager_manager_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MetricsBatch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MetricsBatch) ProtoMessage() {}
+
+func (x *MetricsBatch) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_manager_manager_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MetricsBatch.ProtoReflect.Descriptor instead.
+func (*MetricsBatch) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_manager_manager_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *MetricsBatch) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *MetricsBatch) GetSeries() []*MetricSeries {
+	if x != nil {
+		return x.Series
+	}
+	return nil
+}
+
 // ReportMetricsRequest 指标上报请求
+// metrics 为旧格式，batch 为新格式；迁移期间 Manager 同时接受两者
 type ReportMetricsRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
 	Metrics       []*MetricData          `protobuf:"bytes,2,rep,name=metrics,proto3" json:"metrics,omitempty"`
+	Batch         *MetricsBatch          `protobuf:"bytes,3,opt,name=batch,proto3" json:"batch,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ReportMetricsRequest) Reset() {
 	*x = ReportMetricsRequest{}
-	mi := &file_pkg_proto_manager_manager_proto_msgTypes[5]
+	mi := &file_pkg_proto_manager_manager_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -365,7 +662,7 @@ func (x *ReportMetricsRequest) String() string {
 func (*ReportMetricsRequest) ProtoMessage() {}
 
 func (x *ReportMetricsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_proto_manager_manager_proto_msgTypes[5]
+	mi := &file_pkg_proto_manager_manager_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -378,7 +675,7 @@ func (x *ReportMetricsRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ReportMetricsRequest.ProtoReflect.Descriptor instead.
 func (*ReportMetricsRequest) Descriptor() ([]byte, []int) {
-	return file_pkg_proto_manager_manager_proto_rawDescGZIP(), []int{5}
+	return file_pkg_proto_manager_manager_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *ReportMetricsRequest) GetNodeId() string {
@@ -395,6 +692,13 @@ func (x *ReportMetricsRequest) GetMetrics() []*MetricData {
 	return nil
 }
 
+func (x *ReportMetricsRequest) GetBatch() *MetricsBatch {
+	if x != nil {
+		return x.Batch
+	}
+	return nil
+}
+
 // ReportMetricsResponse 指标上报响应
 type ReportMetricsResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -406,7 +710,7 @@ type ReportMetricsResponse struct {
 
 func (x *ReportMetricsResponse) Reset() {
 	*x = ReportMetricsResponse{}
-	mi := &file_pkg_proto_manager_manager_proto_msgTypes[6]
+	mi := &file_pkg_proto_manager_manager_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -418,7 +722,7 @@ func (x *ReportMetricsResponse) String() string {
 func (*ReportMetricsResponse) ProtoMessage() {}
 
 func (x *ReportMetricsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_proto_manager_manager_proto_msgTypes[6]
+	mi := &file_pkg_proto_manager_manager_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -431,7 +735,7 @@ func (x *ReportMetricsResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ReportMetricsResponse.ProtoReflect.Descriptor instead.
 func (*ReportMetricsResponse) Descriptor() ([]byte, []int) {
-	return file_pkg_proto_manager_manager_proto_rawDescGZIP(), []int{6}
+	return file_pkg_proto_manager_manager_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *ReportMetricsResponse) GetSuccess() bool {
@@ -448,11 +752,247 @@ func (x *ReportMetricsResponse) GetMessage() string {
 	return ""
 }
 
+// EnrollRequest 节点证书申请请求
+type EnrollRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	Token         string                 `protobuf:"bytes,2,opt,name=token,proto3" json:"token,omitempty"` // 加入令牌 <id>.<secret>
+	Csr           []byte                 `protobuf:"bytes,3,opt,name=csr,proto3" json:"csr,omitempty"`     // PEM编码的证书签名请求
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EnrollRequest) Reset() {
+	*x = EnrollRequest{}
+	mi := &file_pkg_proto_manager_manager_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EnrollRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EnrollRequest) ProtoMessage() {}
+
+func (x *EnrollRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_manager_manager_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EnrollRequest.ProtoReflect.Descriptor instead.
+func (*EnrollRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_manager_manager_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *EnrollRequest) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *EnrollRequest) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *EnrollRequest) GetCsr() []byte {
+	if x != nil {
+		return x.Csr
+	}
+	return nil
+}
+
+// EnrollResponse 节点证书申请响应
+type EnrollResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Certificate   []byte                 `protobuf:"bytes,1,opt,name=certificate,proto3" json:"certificate,omitempty"`                          // PEM编码的客户端证书
+	CaCertificate []byte                 `protobuf:"bytes,2,opt,name=ca_certificate,json=caCertificate,proto3" json:"ca_certificate,omitempty"` // PEM编码的CA证书
+	ExpiresAt     int64                  `protobuf:"varint,3,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`            // 证书过期时间(Unix秒)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EnrollResponse) Reset() {
+	*x = EnrollResponse{}
+	mi := &file_pkg_proto_manager_manager_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EnrollResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EnrollResponse) ProtoMessage() {}
+
+func (x *EnrollResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_manager_manager_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EnrollResponse.ProtoReflect.Descriptor instead.
+func (*EnrollResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_manager_manager_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *EnrollResponse) GetCertificate() []byte {
+	if x != nil {
+		return x.Certificate
+	}
+	return nil
+}
+
+func (x *EnrollResponse) GetCaCertificate() []byte {
+	if x != nil {
+		return x.CaCertificate
+	}
+	return nil
+}
+
+func (x *EnrollResponse) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+// RenewCertificateRequest 证书续期请求
+type RenewCertificateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	Csr           []byte                 `protobuf:"bytes,2,opt,name=csr,proto3" json:"csr,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RenewCertificateRequest) Reset() {
+	*x = RenewCertificateRequest{}
+	mi := &file_pkg_proto_manager_manager_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RenewCertificateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RenewCertificateRequest) ProtoMessage() {}
+
+func (x *RenewCertificateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_manager_manager_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RenewCertificateRequest.ProtoReflect.Descriptor instead.
+func (*RenewCertificateRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_manager_manager_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *RenewCertificateRequest) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *RenewCertificateRequest) GetCsr() []byte {
+	if x != nil {
+		return x.Csr
+	}
+	return nil
+}
+
+// RenewCertificateResponse 证书续期响应
+type RenewCertificateResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Certificate   []byte                 `protobuf:"bytes,1,opt,name=certificate,proto3" json:"certificate,omitempty"`
+	CaCertificate []byte                 `protobuf:"bytes,2,opt,name=ca_certificate,json=caCertificate,proto3" json:"ca_certificate,omitempty"`
+	ExpiresAt     int64                  `protobuf:"varint,3,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RenewCertificateResponse) Reset() {
+	*x = RenewCertificateResponse{}
+	mi := &file_pkg_proto_manager_manager_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RenewCertificateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RenewCertificateResponse) ProtoMessage() {}
+
+func (x *RenewCertificateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_proto_manager_manager_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RenewCertificateResponse.ProtoReflect.Descriptor instead.
+func (*RenewCertificateResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_proto_manager_manager_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *RenewCertificateResponse) GetCertificate() []byte {
+	if x != nil {
+		return x.Certificate
+	}
+	return nil
+}
+
+func (x *RenewCertificateResponse) GetCaCertificate() []byte {
+	if x != nil {
+		return x.CaCertificate
+	}
+	return nil
+}
+
+func (x *RenewCertificateResponse) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
 var File_pkg_proto_manager_manager_proto protoreflect.FileDescriptor
 
 const file_pkg_proto_manager_manager_proto_rawDesc = "" +
 	"\n" +
-	"\x1fpkg/proto/manager/manager.proto\x12\amanager\"\xc7\x02\n" +
+	"\x1fpkg/proto/manager/manager.proto\x12\amanager\"\xe9\x02\n" +
 	"\x13RegisterNodeRequest\x12\x17\n" +
 	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12\x1a\n" +
 	"\bhostname\x18\x02 \x01(\tR\bhostname\x12\x0e\n" +
@@ -461,19 +1001,23 @@ const file_pkg_proto_manager_manager_proto_rawDesc = "" +
 	"\x04arch\x18\x05 \x01(\tR\x04arch\x12@\n" +
 	"\x06labels\x18\x06 \x03(\v2(.manager.RegisterNodeRequest.LabelsEntryR\x06labels\x12%\n" +
 	"\x0edaemon_version\x18\a \x01(\tR\rdaemonVersion\x12#\n" +
-	"\ragent_version\x18\b \x01(\tR\fagentVersion\x1a9\n" +
+	"\ragent_version\x18\b \x01(\tR\fagentVersion\x12 \n" +
+	"\vfingerprint\x18\t \x01(\tR\vfingerprint\x1a9\n" +
 	"\vLabelsEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"J\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"b\n" +
 	"\x14RegisterNodeResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
-	"\amessage\x18\x02 \x01(\tR\amessage\"I\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\"k\n" +
 	"\x10HeartbeatRequest\x12\x17\n" +
 	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12\x1c\n" +
-	"\ttimestamp\x18\x02 \x01(\x03R\ttimestamp\"G\n" +
+	"\ttimestamp\x18\x02 \x01(\x03R\ttimestamp\x12 \n" +
+	"\vfingerprint\x18\x03 \x01(\tR\vfingerprint\"_\n" +
 	"\x11HeartbeatResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
-	"\amessage\x18\x02 \x01(\tR\amessage\"\xb2\x01\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\"\xb2\x01\n" +
 	"\n" +
 	"MetricData\x12\x12\n" +
 	"\x04type\x18\x01 \x01(\tR\x04type\x12\x1c\n" +
@@ -481,17 +1025,57 @@ const file_pkg_proto_manager_manager_proto_rawDesc = "" +
 	"\x06values\x18\x03 \x03(\v2\x1f.manager.MetricData.ValuesEntryR\x06values\x1a9\n" +
 	"\vValuesEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\x01R\x05value:\x028\x01\"^\n" +
+	"\x05value\x18\x02 \x01(\x01R\x05value:\x028\x01\"}\n" +
+	"\vMetricPoint\x12!\n" +
+	"\ftimestamp_ms\x18\x01 \x01(\x03R\vtimestampMs\x12#\n" +
+	"\fdouble_value\x18\x02 \x01(\x01H\x00R\vdoubleValue\x12\x1d\n" +
+	"\tint_value\x18\x03 \x01(\x03H\x00R\bintValueB\a\n" +
+	"\x05value\"\xf4\x01\n" +
+	"\fMetricSeries\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x129\n" +
+	"\x06labels\x18\x02 \x03(\v2!.manager.MetricSeries.LabelsEntryR\x06labels\x12,\n" +
+	"\x04type\x18\x03 \x01(\x0e2\x18.manager.MetricValueTypeR\x04type\x12,\n" +
+	"\x06points\x18\x04 \x03(\v2\x14.manager.MetricPointR\x06points\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"W\n" +
+	"\fMetricsBatch\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\rR\aversion\x12-\n" +
+	"\x06series\x18\x02 \x03(\v2\x15.manager.MetricSeriesR\x06series\"\x8b\x01\n" +
 	"\x14ReportMetricsRequest\x12\x17\n" +
 	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12-\n" +
-	"\ametrics\x18\x02 \x03(\v2\x13.manager.MetricDataR\ametrics\"K\n" +
+	"\ametrics\x18\x02 \x03(\v2\x13.manager.MetricDataR\ametrics\x12+\n" +
+	"\x05batch\x18\x03 \x01(\v2\x15.manager.MetricsBatchR\x05batch\"K\n" +
 	"\x15ReportMetricsResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
-	"\amessage\x18\x02 \x01(\tR\amessage2\xf1\x01\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"P\n" +
+	"\rEnrollRequest\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12\x14\n" +
+	"\x05token\x18\x02 \x01(\tR\x05token\x12\x10\n" +
+	"\x03csr\x18\x03 \x01(\fR\x03csr\"x\n" +
+	"\x0eEnrollResponse\x12 \n" +
+	"\vcertificate\x18\x01 \x01(\fR\vcertificate\x12%\n" +
+	"\x0eca_certificate\x18\x02 \x01(\fR\rcaCertificate\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x03 \x01(\x03R\texpiresAt\"D\n" +
+	"\x17RenewCertificateRequest\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12\x10\n" +
+	"\x03csr\x18\x02 \x01(\fR\x03csr\"\x82\x01\n" +
+	"\x18RenewCertificateResponse\x12 \n" +
+	"\vcertificate\x18\x01 \x01(\fR\vcertificate\x12%\n" +
+	"\x0eca_certificate\x18\x02 \x01(\fR\rcaCertificate\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x03 \x01(\x03R\texpiresAt*p\n" +
+	"\x0fMetricValueType\x12!\n" +
+	"\x1dMETRIC_VALUE_TYPE_UNSPECIFIED\x10\x00\x12\x1b\n" +
+	"\x17METRIC_VALUE_TYPE_GAUGE\x10\x01\x12\x1d\n" +
+	"\x19METRIC_VALUE_TYPE_COUNTER\x10\x022\x85\x03\n" +
 	"\x0eManagerService\x12K\n" +
 	"\fRegisterNode\x12\x1c.manager.RegisterNodeRequest\x1a\x1d.manager.RegisterNodeResponse\x12B\n" +
 	"\tHeartbeat\x12\x19.manager.HeartbeatRequest\x1a\x1a.manager.HeartbeatResponse\x12N\n" +
-	"\rReportMetrics\x12\x1d.manager.ReportMetricsRequest\x1a\x1e.manager.ReportMetricsResponseBGZEgithub.com/bingooyong/ops-scaffold-framework/daemon/pkg/proto/managerb\x06proto3"
+	"\rReportMetrics\x12\x1d.manager.ReportMetricsRequest\x1a\x1e.manager.ReportMetricsResponse\x129\n" +
+	"\x06Enroll\x12\x16.manager.EnrollRequest\x1a\x17.manager.EnrollResponse\x12W\n" +
+	"\x10RenewCertificate\x12 .manager.RenewCertificateRequest\x1a!.manager.RenewCertificateResponseBGZEgithub.com/bingooyong/ops-scaffold-framework/daemon/pkg/proto/managerb\x06proto3"
 
 var (
 	file_pkg_proto_manager_manager_proto_rawDescOnce sync.Once
@@ -505,33 +1089,52 @@ func file_pkg_proto_manager_manager_proto_rawDescGZIP() []byte {
 	return file_pkg_proto_manager_manager_proto_rawDescData
 }
 
-var file_pkg_proto_manager_manager_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_pkg_proto_manager_manager_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_pkg_proto_manager_manager_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
 var file_pkg_proto_manager_manager_proto_goTypes = []any{
-	(*RegisterNodeRequest)(nil),   // 0: manager.RegisterNodeRequest
-	(*RegisterNodeResponse)(nil),  // 1: manager.RegisterNodeResponse
-	(*HeartbeatRequest)(nil),      // 2: manager.HeartbeatRequest
-	(*HeartbeatResponse)(nil),     // 3: manager.HeartbeatResponse
-	(*MetricData)(nil),            // 4: manager.MetricData
-	(*ReportMetricsRequest)(nil),  // 5: manager.ReportMetricsRequest
-	(*ReportMetricsResponse)(nil), // 6: manager.ReportMetricsResponse
-	nil,                           // 7: manager.RegisterNodeRequest.LabelsEntry
-	nil,                           // 8: manager.MetricData.ValuesEntry
+	(MetricValueType)(0),             // 0: manager.MetricValueType
+	(*RegisterNodeRequest)(nil),      // 1: manager.RegisterNodeRequest
+	(*RegisterNodeResponse)(nil),     // 2: manager.RegisterNodeResponse
+	(*HeartbeatRequest)(nil),         // 3: manager.HeartbeatRequest
+	(*HeartbeatResponse)(nil),        // 4: manager.HeartbeatResponse
+	(*MetricData)(nil),               // 5: manager.MetricData
+	(*MetricPoint)(nil),              // 6: manager.MetricPoint
+	(*MetricSeries)(nil),             // 7: manager.MetricSeries
+	(*MetricsBatch)(nil),             // 8: manager.MetricsBatch
+	(*ReportMetricsRequest)(nil),     // 9: manager.ReportMetricsRequest
+	(*ReportMetricsResponse)(nil),    // 10: manager.ReportMetricsResponse
+	(*EnrollRequest)(nil),            // 11: manager.EnrollRequest
+	(*EnrollResponse)(nil),           // 12: manager.EnrollResponse
+	(*RenewCertificateRequest)(nil),  // 13: manager.RenewCertificateRequest
+	(*RenewCertificateResponse)(nil), // 14: manager.RenewCertificateResponse
+	nil,                              // 15: manager.RegisterNodeRequest.LabelsEntry
+	nil,                              // 16: manager.MetricData.ValuesEntry
+	nil,                              // 17: manager.MetricSeries.LabelsEntry
 }
 var file_pkg_proto_manager_manager_proto_depIdxs = []int32{
-	7, // 0: manager.RegisterNodeRequest.labels:type_name -> manager.RegisterNodeRequest.LabelsEntry
-	8, // 1: manager.MetricData.values:type_name -> manager.MetricData.ValuesEntry
-	4, // 2: manager.ReportMetricsRequest.metrics:type_name -> manager.MetricData
-	0, // 3: manager.ManagerService.RegisterNode:input_type -> manager.RegisterNodeRequest
-	2, // 4: manager.ManagerService.Heartbeat:input_type -> manager.HeartbeatRequest
-	5, // 5: manager.ManagerService.ReportMetrics:input_type -> manager.ReportMetricsRequest
-	1, // 6: manager.ManagerService.RegisterNode:output_type -> manager.RegisterNodeResponse
-	3, // 7: manager.ManagerService.Heartbeat:output_type -> manager.HeartbeatResponse
-	6, // 8: manager.ManagerService.ReportMetrics:output_type -> manager.ReportMetricsResponse
-	6, // [6:9] is the sub-list for method output_type
-	3, // [3:6] is the sub-list for method input_type
-	3, // [3:3] is the sub-list for extension type_name
-	3, // [3:3] is the sub-list for extension extendee
-	0, // [0:3] is the sub-list for field type_name
+	15, // 0: manager.RegisterNodeRequest.labels:type_name -> manager.RegisterNodeRequest.LabelsEntry
+	16, // 1: manager.MetricData.values:type_name -> manager.MetricData.ValuesEntry
+	17, // 2: manager.MetricSeries.labels:type_name -> manager.MetricSeries.LabelsEntry
+	0,  // 3: manager.MetricSeries.type:type_name -> manager.MetricValueType
+	6,  // 4: manager.MetricSeries.points:type_name -> manager.MetricPoint
+	7,  // 5: manager.MetricsBatch.series:type_name -> manager.MetricSeries
+	5,  // 6: manager.ReportMetricsRequest.metrics:type_name -> manager.MetricData
+	8,  // 7: manager.ReportMetricsRequest.batch:type_name -> manager.MetricsBatch
+	1,  // 8: manager.ManagerService.RegisterNode:input_type -> manager.RegisterNodeRequest
+	3,  // 9: manager.ManagerService.Heartbeat:input_type -> manager.HeartbeatRequest
+	9,  // 10: manager.ManagerService.ReportMetrics:input_type -> manager.ReportMetricsRequest
+	11, // 11: manager.ManagerService.Enroll:input_type -> manager.EnrollRequest
+	13, // 12: manager.ManagerService.RenewCertificate:input_type -> manager.RenewCertificateRequest
+	2,  // 13: manager.ManagerService.RegisterNode:output_type -> manager.RegisterNodeResponse
+	4,  // 14: manager.ManagerService.Heartbeat:output_type -> manager.HeartbeatResponse
+	10, // 15: manager.ManagerService.ReportMetrics:output_type -> manager.ReportMetricsResponse
+	12, // 16: manager.ManagerService.Enroll:output_type -> manager.EnrollResponse
+	14, // 17: manager.ManagerService.RenewCertificate:output_type -> manager.RenewCertificateResponse
+	13, // [13:18] is the sub-list for method output_type
+	8,  // [8:13] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
 }
 
 func init() { file_pkg_proto_manager_manager_proto_init() }
@@ -539,18 +1142,23 @@ func file_pkg_proto_manager_manager_proto_init() {
 	if File_pkg_proto_manager_manager_proto != nil {
 		return
 	}
+	file_pkg_proto_manager_manager_proto_msgTypes[5].OneofWrappers = []any{
+		(*MetricPoint_DoubleValue)(nil),
+		(*MetricPoint_IntValue)(nil),
+	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pkg_proto_manager_manager_proto_rawDesc), len(file_pkg_proto_manager_manager_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   9,
+			NumEnums:      1,
+			NumMessages:   17,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_pkg_proto_manager_manager_proto_goTypes,
 		DependencyIndexes: file_pkg_proto_manager_manager_proto_depIdxs,
+		EnumInfos:         file_pkg_proto_manager_manager_proto_enumTypes,
 		MessageInfos:      file_pkg_proto_manager_manager_proto_msgTypes,
 	}.Build()
 	File_pkg_proto_manager_manager_proto = out.File
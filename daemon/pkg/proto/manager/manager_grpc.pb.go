@@ -19,9 +19,11 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	ManagerService_RegisterNode_FullMethodName  = "/manager.ManagerService/RegisterNode"
-	ManagerService_Heartbeat_FullMethodName     = "/manager.ManagerService/Heartbeat"
-	ManagerService_ReportMetrics_FullMethodName = "/manager.ManagerService/ReportMetrics"
+	ManagerService_RegisterNode_FullMethodName     = "/manager.ManagerService/RegisterNode"
+	ManagerService_Heartbeat_FullMethodName        = "/manager.ManagerService/Heartbeat"
+	ManagerService_ReportMetrics_FullMethodName    = "/manager.ManagerService/ReportMetrics"
+	ManagerService_Enroll_FullMethodName           = "/manager.ManagerService/Enroll"
+	ManagerService_RenewCertificate_FullMethodName = "/manager.ManagerService/RenewCertificate"
 )
 
 // ManagerServiceClient is the client API for ManagerService service.
@@ -37,6 +39,10 @@ type ManagerServiceClient interface {
 	Heartbeat(ctx context.Context, in *HeartbeatRequest, opts ...grpc.CallOption) (*HeartbeatResponse, error)
 	// ReportMetrics 指标上报
 	ReportMetrics(ctx context.Context, in *ReportMetricsRequest, opts ...grpc.CallOption) (*ReportMetricsResponse, error)
+	// Enroll 凭加入令牌申请节点客户端证书(唯一不要求客户端证书的方法)
+	Enroll(ctx context.Context, in *EnrollRequest, opts ...grpc.CallOption) (*EnrollResponse, error)
+	// RenewCertificate 使用当前有效证书续期客户端证书
+	RenewCertificate(ctx context.Context, in *RenewCertificateRequest, opts ...grpc.CallOption) (*RenewCertificateResponse, error)
 }
 
 type managerServiceClient struct {
@@ -77,6 +83,26 @@ func (c *managerServiceClient) ReportMetrics(ctx context.Context, in *ReportMetr
 	return out, nil
 }
 
+func (c *managerServiceClient) Enroll(ctx context.Context, in *EnrollRequest, opts ...grpc.CallOption) (*EnrollResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EnrollResponse)
+	err := c.cc.Invoke(ctx, ManagerService_Enroll_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *managerServiceClient) RenewCertificate(ctx context.Context, in *RenewCertificateRequest, opts ...grpc.CallOption) (*RenewCertificateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RenewCertificateResponse)
+	err := c.cc.Invoke(ctx, ManagerService_RenewCertificate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ManagerServiceServer is the server API for ManagerService service.
 // All implementations must embed UnimplementedManagerServiceServer
 // for forward compatibility.
@@ -90,6 +116,10 @@ type ManagerServiceServer interface {
 	Heartbeat(context.Context, *HeartbeatRequest) (*HeartbeatResponse, error)
 	// ReportMetrics 指标上报
 	ReportMetrics(context.Context, *ReportMetricsRequest) (*ReportMetricsResponse, error)
+	// Enroll 凭加入令牌申请节点客户端证书(唯一不要求客户端证书的方法)
+	Enroll(context.Context, *EnrollRequest) (*EnrollResponse, error)
+	// RenewCertificate 使用当前有效证书续期客户端证书
+	RenewCertificate(context.Context, *RenewCertificateRequest) (*RenewCertificateResponse, error)
 	mustEmbedUnimplementedManagerServiceServer()
 }
 
@@ -109,6 +139,12 @@ func (UnimplementedManagerServiceServer) Heartbeat(context.Context, *HeartbeatRe
 func (UnimplementedManagerServiceServer) ReportMetrics(context.Context, *ReportMetricsRequest) (*ReportMetricsResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method ReportMetrics not implemented")
 }
+func (UnimplementedManagerServiceServer) Enroll(context.Context, *EnrollRequest) (*EnrollResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Enroll not implemented")
+}
+func (UnimplementedManagerServiceServer) RenewCertificate(context.Context, *RenewCertificateRequest) (*RenewCertificateResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method RenewCertificate not implemented")
+}
 func (UnimplementedManagerServiceServer) mustEmbedUnimplementedManagerServiceServer() {}
 func (UnimplementedManagerServiceServer) testEmbeddedByValue()                        {}
 
@@ -184,6 +220,42 @@ func _ManagerService_ReportMetrics_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ManagerService_Enroll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EnrollRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerServiceServer).Enroll(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ManagerService_Enroll_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerServiceServer).Enroll(ctx, req.(*EnrollRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ManagerService_RenewCertificate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenewCertificateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerServiceServer).RenewCertificate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ManagerService_RenewCertificate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerServiceServer).RenewCertificate(ctx, req.(*RenewCertificateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ManagerService_ServiceDesc is the grpc.ServiceDesc for ManagerService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -203,6 +275,14 @@ var ManagerService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ReportMetrics",
 			Handler:    _ManagerService_ReportMetrics_Handler,
 		},
+		{
+			MethodName: "Enroll",
+			Handler:    _ManagerService_Enroll_Handler,
+		},
+		{
+			MethodName: "RenewCertificate",
+			Handler:    _ManagerService_RenewCertificate_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "pkg/proto/manager/manager.proto",
@@ -4,15 +4,16 @@ import "time"
 
 // NodeInfo 节点信息
 type NodeInfo struct {
-	NodeID     string            `json:"node_id"`
-	Hostname   string            `json:"hostname"`
-	IP         string            `json:"ip"`
-	OS         string            `json:"os"`
-	Arch       string            `json:"arch"`
-	Labels     map[string]string `json:"labels"`
-	DaemonVer  string            `json:"daemon_version"`
-	AgentVer   string            `json:"agent_version"`
-	RegisterAt time.Time         `json:"register_at"`
+	NodeID      string            `json:"node_id"`
+	Hostname    string            `json:"hostname"`
+	IP          string            `json:"ip"`
+	OS          string            `json:"os"`
+	Arch        string            `json:"arch"`
+	Labels      map[string]string `json:"labels"`
+	DaemonVer   string            `json:"daemon_version"`
+	AgentVer    string            `json:"agent_version"`
+	Fingerprint string            `json:"fingerprint"` // 主机指纹，Manager据此检测node_id冲突
+	RegisterAt  time.Time         `json:"register_at"`
 }
 
 // Metrics 指标数据结构
@@ -20,6 +21,14 @@ type Metrics struct {
 	Name      string                 `json:"name"`
 	Timestamp time.Time              `json:"timestamp"`
 	Values    map[string]interface{} `json:"values"`
+	// Series 带标签的明细指标(每块磁盘、每个网卡、每个CPU核等)
+	Series []LabeledValues `json:"series,omitempty"`
+}
+
+// LabeledValues 一组共享相同标签的指标值
+type LabeledValues struct {
+	Labels map[string]string      `json:"labels"`
+	Values map[string]interface{} `json:"values"`
 }
 
 // AgentStatus Agent状态
@@ -36,12 +45,14 @@ type AgentStatus struct {
 
 // Heartbeat 心跳数据
 type Heartbeat struct {
-	PID       int       `json:"pid"`
-	Timestamp time.Time `json:"timestamp"`
-	Version   string    `json:"version"`
-	Status    string    `json:"status"`
-	CPU       float64   `json:"cpu"`
-	Memory    uint64    `json:"memory"`
+	PID       int                `json:"pid"`
+	Timestamp time.Time          `json:"timestamp"`
+	Version   string             `json:"version"`
+	Status    string             `json:"status"`
+	CPU       float64            `json:"cpu"`
+	Memory    uint64             `json:"memory"`
+	Metrics   map[string]float64 `json:"metrics,omitempty"` // 自定义数值指标(如队列深度、每秒事件数)
+	Fields    map[string]string  `json:"fields,omitempty"`  // 自定义状态字段
 }
 
 // ReportData 上报数据结构
@@ -16,8 +16,10 @@ import (
 	"github.com/bingooyong/ops-scaffold-framework/manager/internal/handler"
 	"github.com/bingooyong/ops-scaffold-framework/manager/internal/logger"
 	"github.com/bingooyong/ops-scaffold-framework/manager/internal/middleware"
+	"github.com/bingooyong/ops-scaffold-framework/manager/internal/pki"
 	"github.com/bingooyong/ops-scaffold-framework/manager/internal/repository"
 	"github.com/bingooyong/ops-scaffold-framework/manager/internal/service"
+	"github.com/bingooyong/ops-scaffold-framework/manager/internal/telemetry"
 	"github.com/bingooyong/ops-scaffold-framework/manager/pkg/database"
 	"github.com/bingooyong/ops-scaffold-framework/manager/pkg/jwt"
 	pb "github.com/bingooyong/ops-scaffold-framework/manager/pkg/proto"
@@ -26,6 +28,7 @@ import (
 	"github.com/robfig/cron/v3"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/keepalive"
 )
 
@@ -85,17 +88,47 @@ func main() {
 	versionRepo := repository.NewVersionRepository(db)
 	auditRepo := repository.NewAuditLogRepository(db)
 	agentRepo := repository.NewAgentRepository(db)
+	agentEventRepo := repository.NewAgentEventRepository(db)
+	agentCrashRepo := repository.NewAgentCrashRepository(db)
+	agentAlertRepo := repository.NewAgentAlertRepository(db)
+	agentMetricRepo := repository.NewAgentMetricRepository(db)
+	joinTokenRepo := repository.NewJoinTokenRepository(db)
+	nodeQuarantineRepo := repository.NewNodeQuarantineRepository(db)
 
 	// 6. 初始化Daemon客户端连接池
 	daemonPool := grpcserver.NewDaemonClientPool(log)
 
 	// 7. 初始化Service层
 	authService := service.NewAuthService(userRepo, auditRepo, jwtManager, log)
-	nodeService := service.NewNodeService(nodeRepo, auditRepo, log)
-	metricsService := service.NewMetricsService(metricsRepo, log)
+	nodeService := service.NewNodeService(nodeRepo, nodeQuarantineRepo, auditRepo, cfg.Node.AutoApprove, log)
+	metricsService := service.NewMetricsService(metricsRepo, agentMetricRepo, log)
 	taskService := service.NewTaskService(taskRepo, nodeRepo, auditRepo, log)
 	versionService := service.NewVersionService(versionRepo, auditRepo, log)
-	agentService := service.NewAgentService(agentRepo, nodeRepo, daemonPool, log)
+	agentService := service.NewAgentService(agentRepo, nodeRepo, agentEventRepo, agentCrashRepo, agentAlertRepo, daemonPool, log)
+
+	// 7.1. 初始化内置CA(配置ca_key_file时启用节点证书签发)
+	var ca *pki.CA
+	var enrollmentService service.EnrollmentService
+	if cfg.GRPC.TLS.Enabled && cfg.GRPC.TLS.CAKeyFile != "" {
+		var created bool
+		ca, created, err = pki.LoadOrCreateCA(cfg.GRPC.TLS.CAFile, cfg.GRPC.TLS.CAKeyFile)
+		if err != nil {
+			log.Fatal("Failed to load CA", zap.Error(err))
+		}
+		if created {
+			log.Info("built-in CA created", zap.String("ca_file", cfg.GRPC.TLS.CAFile))
+		}
+		issued, err := ca.EnsureServerCert(cfg.GRPC.TLS.CertFile, cfg.GRPC.TLS.KeyFile, cfg.GRPC.TLS.ServerNames)
+		if err != nil {
+			log.Fatal("Failed to ensure gRPC server certificate", zap.Error(err))
+		}
+		if issued {
+			log.Info("gRPC server certificate issued by built-in CA", zap.String("cert_file", cfg.GRPC.TLS.CertFile))
+		}
+		enrollmentService = service.NewEnrollmentService(ca, joinTokenRepo, nodeRepo, auditRepo,
+			cfg.GRPC.TLS.ClientCertTTL, cfg.GRPC.TLS.JoinTokenTTL, log)
+		log.Info("node enrollment enabled", zap.String("ca_cert_hash", ca.Hash()))
+	}
 
 	// 避免编译器警告
 	_ = taskService
@@ -106,9 +139,23 @@ func main() {
 	nodeHandler := handler.NewNodeHandler(nodeService, log)
 	metricsHandler := handler.NewMetricsHandler(metricsService, log)
 	agentHandler := handler.NewAgentHandler(agentService, log)
+	prometheusHandler := handler.NewPrometheusHandler(nodeService, agentService, daemonPool, db, cfg.Prometheus.Token, log)
 
 	// 9. 初始化gRPC服务器
 	grpcSrv := grpcserver.NewServer(nodeService, metricsService, log)
+	if enrollmentService != nil {
+		grpcSrv.SetEnrollmentService(enrollmentService)
+	}
+
+	var grpcCreds *grpcserver.ServerCredentials
+	if cfg.GRPC.TLS.Enabled {
+		grpcCreds, err = grpcserver.NewServerCredentials(cfg.GRPC.TLS.CertFile, cfg.GRPC.TLS.KeyFile, cfg.GRPC.TLS.CAFile)
+		if err != nil {
+			log.Fatal("Failed to load gRPC TLS credentials", zap.Error(err))
+		}
+		// 直接拨号Daemon时出示服务端证书，并按节点ID校验Daemon证书
+		daemonPool.SetTransportCredentials(grpcCreds.DaemonCredentials)
+	}
 
 	// 9.1. 初始化 Metrics 清理服务
 	metricsCleaner := service.NewMetricsCleaner(db, cfg.Metrics.RetentionDays, log)
@@ -123,7 +170,14 @@ func main() {
 		cronScheduler = cron.New()
 		_, err = cronScheduler.AddFunc(cfg.Metrics.CleanupSchedule, func() {
 			log.Info("starting scheduled metrics cleanup")
-			if err := metricsCleaner.CleanExpiredPartitions(context.Background()); err != nil {
+			start := time.Now()
+			err := metricsCleaner.CleanExpiredPartitions(context.Background())
+			// Agent自定义指标表未分区，按保留天数直接删除
+			if _, cleanErr := metricsService.CleanOldAgentMetrics(context.Background(), cfg.Metrics.RetentionDays); cleanErr != nil && err == nil {
+				err = cleanErr
+			}
+			telemetry.ObserveCronJob("metrics_cleanup", start, err)
+			if err != nil {
 				log.Error("scheduled metrics cleanup failed", zap.Error(err))
 			} else {
 				log.Info("scheduled metrics cleanup completed")
@@ -149,7 +203,10 @@ func main() {
 				zap.Int("offline_duration_minutes", cfg.Node.OfflineDurationMinutes))
 			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 			defer cancel()
-			if err := nodeService.CheckOfflineNodes(ctx, offlineDuration); err != nil {
+			start := time.Now()
+			err := nodeService.CheckOfflineNodes(ctx, offlineDuration)
+			telemetry.ObserveCronJob("node_offline_check", start, err)
+			if err != nil {
 				log.Error("scheduled node offline check failed", zap.Error(err))
 			} else {
 				log.Info("scheduled node offline check completed")
@@ -163,6 +220,33 @@ func main() {
 			zap.Int("offline_duration_minutes", cfg.Node.OfflineDurationMinutes))
 	}
 
+	// 9.2.3 加入令牌清理和服务端证书续期任务
+	if enrollmentService != nil {
+		_, err = cronScheduler.AddFunc("@daily", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			start := time.Now()
+			deleted, err := enrollmentService.CleanExpiredJoinTokens(ctx, 7*24*time.Hour)
+			if err == nil {
+				var issued bool
+				issued, err = ca.EnsureServerCert(cfg.GRPC.TLS.CertFile, cfg.GRPC.TLS.KeyFile, cfg.GRPC.TLS.ServerNames)
+				if err == nil && issued {
+					err = grpcCreds.Reload()
+					log.Info("gRPC server certificate renewed")
+				}
+			}
+			telemetry.ObserveCronJob("pki_maintenance", start, err)
+			if err != nil {
+				log.Error("scheduled PKI maintenance failed", zap.Error(err))
+			} else {
+				log.Info("scheduled PKI maintenance completed", zap.Int64("expired_join_tokens_deleted", deleted))
+			}
+		})
+		if err != nil {
+			log.Fatal("failed to add PKI maintenance cron job", zap.Error(err))
+		}
+	}
+
 	// 10. 初始化Gin引擎
 	gin.SetMode(cfg.Server.Mode)
 	router := gin.New()
@@ -181,6 +265,9 @@ func main() {
 		})
 	})
 
+	// Prometheus 指标(可通过 prometheus.token 配置访问令牌)
+	router.GET(cfg.Prometheus.Path, prometheusHandler.Metrics)
+
 	// 公开API（无需认证）
 	public := router.Group("/api/v1")
 	{
@@ -207,8 +294,22 @@ func main() {
 			agents.GET("", agentHandler.List)
 			agents.POST("/sync", agentHandler.Sync) // 手动同步Agent状态
 			agents.POST("/:agent_id/operate", agentHandler.Operate)
+			agents.POST("/:agent_id/control", agentHandler.Control)
 			agents.GET("/:agent_id/logs", agentHandler.GetLogs)
+			agents.GET("/:agent_id/logs/follow", agentHandler.FollowLogs)
 			agents.GET("/:agent_id/metrics", agentHandler.GetMetrics)
+			agents.GET("/:agent_id/events", agentHandler.GetEvents)
+			agents.GET("/:agent_id/crashes", agentHandler.GetCrashes)
+			agents.GET("/:agent_id/alerts", agentHandler.GetAlerts)
+		}
+
+		// 资源告警相关
+		api.GET("/alerts", agentHandler.ListAlerts) // 跨节点查询Agent资源告警
+
+		// 日志相关
+		logs := api.Group("/logs")
+		{
+			logs.GET("/search", agentHandler.SearchLogs) // 跨节点搜索Agent日志
 		}
 
 		// 监控指标相关
@@ -217,6 +318,8 @@ func main() {
 			metrics.GET("/nodes/:node_id/latest", metricsHandler.GetLatestMetrics)
 			metrics.GET("/nodes/:node_id/:type/history", metricsHandler.GetMetricsHistory)
 			metrics.GET("/nodes/:node_id/summary", metricsHandler.GetMetricsSummary)
+			metrics.GET("/nodes/:node_id/agents/:agent_id/custom/latest", metricsHandler.GetAgentCustomMetricsLatest)
+			metrics.GET("/nodes/:node_id/agents/:agent_id/custom/history", metricsHandler.GetAgentCustomMetricsHistory)
 			metrics.GET("/cluster/overview", metricsHandler.GetClusterOverview)
 		}
 
@@ -239,6 +342,21 @@ func main() {
 
 			// 节点管理
 			admin.DELETE("/nodes/:id", nodeHandler.Delete)
+			admin.POST("/nodes/:id/approve", nodeHandler.Approve)
+			admin.POST("/nodes/:id/reject", nodeHandler.Reject)
+
+			// 节点隔离(不同主机使用相同node_id)
+			admin.GET("/node-quarantine", nodeHandler.ListQuarantine)
+			admin.POST("/node-quarantine/:id/accept", nodeHandler.AcceptQuarantine)
+			admin.DELETE("/node-quarantine/:id", nodeHandler.DismissQuarantine)
+
+			// 节点加入令牌(启用内置CA时可用)
+			if enrollmentService != nil {
+				enrollmentHandler := handler.NewEnrollmentHandler(enrollmentService, log)
+				admin.POST("/join-tokens", enrollmentHandler.CreateJoinToken)
+				admin.GET("/join-tokens", enrollmentHandler.ListJoinTokens)
+				admin.DELETE("/join-tokens/:id", enrollmentHandler.DeleteJoinToken)
+			}
 		}
 	}
 
@@ -276,19 +394,34 @@ func main() {
 		PermitWithoutStream: true,             // 允许无流时发送ping
 	}
 
-	grpcServerInstance := grpc.NewServer(
+	grpcOpts := []grpc.ServerOption{
 		grpc.KeepaliveParams(keepaliveParams),
 		grpc.KeepaliveEnforcementPolicy(keepaliveEnforcementPolicy),
-		grpc.MaxRecvMsgSize(10*1024*1024), // 10MB 最大接收消息
-		grpc.MaxSendMsgSize(10*1024*1024), // 10MB 最大发送消息
-		grpc.InitialWindowSize(1<<20),     // 1MB 初始窗口
-		grpc.InitialConnWindowSize(1<<20), // 1MB 连接窗口
-		grpc.UnaryInterceptor(grpcserver.UnaryServerInterceptor(log)),
-	)
+		grpc.MaxRecvMsgSize(10 * 1024 * 1024), // 10MB 最大接收消息
+		grpc.MaxSendMsgSize(10 * 1024 * 1024), // 10MB 最大发送消息
+		grpc.InitialWindowSize(1 << 20),       // 1MB 初始窗口
+		grpc.InitialConnWindowSize(1 << 20),   // 1MB 连接窗口
+	}
+	if grpcCreds != nil {
+		// 启用TLS时要求mTLS，并校验证书身份与请求中的node_id一致
+		grpcOpts = append(grpcOpts,
+			grpc.Creds(credentials.NewTLS(grpcCreds.TLSConfig())),
+			grpc.ChainUnaryInterceptor(
+				grpcserver.UnaryServerInterceptor(log),
+				grpcserver.UnaryIdentityInterceptor(log),
+			),
+			grpc.StreamInterceptor(grpcserver.StreamIdentityInterceptor(log)),
+		)
+	} else {
+		log.Warn("gRPC TLS is disabled, daemon identities are not verified")
+		grpcOpts = append(grpcOpts, grpc.UnaryInterceptor(grpcserver.UnaryServerInterceptor(log)))
+	}
+	grpcServerInstance := grpc.NewServer(grpcOpts...)
 	pb.RegisterManagerServiceServer(grpcServerInstance, grpcSrv)
 
 	// 注册DaemonService服务器(用于接收Daemon上报的Agent状态)
-	daemonSrv := grpcserver.NewDaemonServer(agentService, log)
+	daemonSrv := grpcserver.NewDaemonServer(agentService, metricsService, log)
+	daemonSrv.SetDaemonClientPool(daemonPool)
 	daemonpb.RegisterDaemonServiceServer(grpcServerInstance, daemonSrv)
 
 	go func() {
@@ -320,14 +453,14 @@ func main() {
 		log.Error("HTTP server shutdown failed", zap.Error(err))
 	}
 
+	// 关闭Daemon客户端连接池(先关闭Daemon建立的控制流，否则GracefulStop会一直等待)
+	daemonPool.CloseAll()
+	log.Info("Daemon client pool closed")
+
 	// 关闭gRPC服务器
 	grpcServerInstance.GracefulStop()
 	log.Info("gRPC server stopped")
 
-	// 关闭Daemon客户端连接池
-	daemonPool.CloseAll()
-	log.Info("Daemon client pool closed")
-
 	// 停止 cron 调度器
 	if cronScheduler != nil {
 		cronScheduler.Stop()
@@ -17,6 +17,8 @@ type Config struct {
 	Log      LogConfig      `mapstructure:"log"`
 	Metrics  MetricsConfig  `mapstructure:"metrics"`
 	Node     NodeConfig     `mapstructure:"node"`
+	// Prometheus 自身指标暴露配置
+	Prometheus PrometheusConfig `mapstructure:"prometheus"`
 }
 
 // ServerConfig HTTP服务配置
@@ -55,11 +57,20 @@ type GRPCConfig struct {
 }
 
 // TLSConfig TLS配置
+// 启用后gRPC端口要求mTLS：客户端证书须由ca_file签发，且证书绑定的节点ID与请求中的node_id一致
 type TLSConfig struct {
 	Enabled  bool   `mapstructure:"enabled"`
 	CertFile string `mapstructure:"cert_file"`
 	KeyFile  string `mapstructure:"key_file"`
 	CAFile   string `mapstructure:"ca_file"`
+	// CAKeyFile CA私钥路径，设置后启用内置CA(文件不存在时自动生成CA和服务端证书)并开放节点证书申请
+	CAKeyFile string `mapstructure:"ca_key_file"`
+	// ServerNames 内置CA签发服务端证书时使用的DNS名称或IP
+	ServerNames []string `mapstructure:"server_names"`
+	// ClientCertTTL 节点客户端证书有效期
+	ClientCertTTL time.Duration `mapstructure:"client_cert_ttl"`
+	// JoinTokenTTL 加入令牌默认有效期
+	JoinTokenTTL time.Duration `mapstructure:"join_token_ttl"`
 }
 
 // JWTConfig JWT配置
@@ -85,10 +96,19 @@ type MetricsConfig struct {
 	CleanupSchedule string `mapstructure:"cleanup_schedule"` // 清理任务调度，默认 "0 2 * * *"（每天凌晨 2 点）
 }
 
+// PrometheusConfig Prometheus指标端点配置
+type PrometheusConfig struct {
+	Path  string `mapstructure:"path"`  // 指标路径，默认 /metrics
+	Token string `mapstructure:"token"` // 访问令牌(可选)，配置后需携带 Authorization: Bearer <token>
+}
+
 // NodeConfig 节点配置
 type NodeConfig struct {
 	OfflineDurationMinutes int    `mapstructure:"offline_duration_minutes"` // 离线判定时长（分钟），默认 3 分钟
 	OfflineCheckSchedule   string `mapstructure:"offline_check_schedule"`   // 离线检查调度，默认 "*/1 * * * *"（每分钟检查一次）
+	// AutoApprove 自动批准未携带节点证书注册的新节点，默认false(进入pending等待管理员批准)
+	// 携带内置CA签发的节点证书注册的节点始终自动批准
+	AutoApprove bool `mapstructure:"auto_approve"`
 }
 
 // Load 加载配置文件
@@ -175,6 +195,12 @@ func setDefaults(config *Config) {
 	if config.GRPC.Port == 0 {
 		config.GRPC.Port = 9090
 	}
+	if config.GRPC.TLS.ClientCertTTL == 0 {
+		config.GRPC.TLS.ClientCertTTL = 30 * 24 * time.Hour
+	}
+	if config.GRPC.TLS.JoinTokenTTL == 0 {
+		config.GRPC.TLS.JoinTokenTTL = time.Hour
+	}
 
 	// JWT默认值
 	if config.JWT.ExpireTime == 0 {
@@ -184,6 +210,11 @@ func setDefaults(config *Config) {
 		config.JWT.Issuer = "ops-manager"
 	}
 
+	// Prometheus默认值
+	if config.Prometheus.Path == "" {
+		config.Prometheus.Path = "/metrics"
+	}
+
 	// Log默认值
 	if config.Log.Level == "" {
 		config.Log.Level = "info"
@@ -255,6 +286,14 @@ func validate(config *Config) error {
 		if config.GRPC.TLS.CertFile == "" || config.GRPC.TLS.KeyFile == "" {
 			return fmt.Errorf("TLS cert_file and key_file are required when TLS is enabled")
 		}
+		if config.GRPC.TLS.CAFile == "" {
+			return fmt.Errorf("TLS ca_file is required when TLS is enabled")
+		}
+		if config.GRPC.TLS.ClientCertTTL < time.Hour {
+			return fmt.Errorf("TLS client_cert_ttl must be at least 1h")
+		}
+	} else if config.GRPC.TLS.CAKeyFile != "" {
+		return fmt.Errorf("TLS ca_key_file requires TLS to be enabled")
 	}
 
 	return nil
@@ -0,0 +1,106 @@
+package grpc
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+
+	"google.golang.org/grpc/credentials"
+)
+
+// ServerCredentials gRPC服务端mTLS凭证
+// 服务端证书可在运行中重新加载(内置CA续期服务端证书后无需重启)
+type ServerCredentials struct {
+	certFile  string
+	keyFile   string
+	clientCAs *x509.CertPool
+	cert      atomic.Pointer[tls.Certificate]
+}
+
+// NewServerCredentials 加载服务端证书和用于校验客户端证书的CA
+func NewServerCredentials(certFile, keyFile, caFile string) (*ServerCredentials, error) {
+	caPEM, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("failed to parse CA file %s", caFile)
+	}
+
+	c := &ServerCredentials{
+		certFile:  certFile,
+		keyFile:   keyFile,
+		clientCAs: pool,
+	}
+	if err := c.Reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Reload 重新加载服务端证书，新连接使用新证书
+func (c *ServerCredentials) Reload() error {
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load server certificate: %w", err)
+	}
+	c.cert.Store(&cert)
+	return nil
+}
+
+// TLSConfig 返回服务端TLS配置
+// 客户端证书在提供时必须通过CA校验；未提供证书的连接只能调用Enroll(由身份拦截器限制)
+func (c *ServerCredentials) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS13,
+		ClientAuth: tls.VerifyClientCertIfGiven,
+		ClientCAs:  c.clientCAs,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return c.cert.Load(), nil
+		},
+	}
+}
+
+// DaemonCredentials 返回直接拨号Daemon使用的传输凭证
+// Manager以服务端证书作为客户端证书，Daemon证书需由同一CA签发且CommonName与节点ID一致
+func (c *ServerCredentials) DaemonCredentials(nodeID string) credentials.TransportCredentials {
+	return credentials.NewTLS(&tls.Config{
+		MinVersion: tls.VersionTLS13,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return c.cert.Load(), nil
+		},
+		// Daemon证书不包含地址，在VerifyConnection中按节点ID校验
+		InsecureSkipVerify: true,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			return verifyDaemon(cs, c.clientCAs, nodeID)
+		},
+	})
+}
+
+// verifyDaemon 使用CA校验Daemon证书链，并校验证书绑定的节点ID
+func verifyDaemon(cs tls.ConnectionState, roots *x509.CertPool, nodeID string) error {
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("daemon did not present a certificate")
+	}
+	leaf := cs.PeerCertificates[0]
+	intermediates := x509.NewCertPool()
+	for _, cert := range cs.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+	// 早期签发的节点证书只有ClientAuth用途
+	if _, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}); err != nil {
+		return fmt.Errorf("failed to verify daemon certificate: %w", err)
+	}
+	if leaf.Subject.CommonName != nodeID {
+		return fmt.Errorf("daemon certificate is issued to %q, expected node %q", leaf.Subject.CommonName, nodeID)
+	}
+	return nil
+}
@@ -3,14 +3,17 @@ package grpc
 import (
 	"context"
 	"fmt"
+	"io"
 	"sync"
 	"time"
 
 	"github.com/bingooyong/ops-scaffold-framework/manager/internal/service"
+	"github.com/bingooyong/ops-scaffold-framework/manager/internal/telemetry"
 	daemonpb "github.com/bingooyong/ops-scaffold-framework/manager/pkg/proto/daemon"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/keepalive"
 )
@@ -36,14 +39,22 @@ type DaemonClient struct {
 	conn    *grpc.ClientConn
 	client  daemonpb.DaemonServiceClient
 	address string
+	creds   credentials.TransportCredentials // 直接拨号Daemon使用的传输凭证
 	logger  *zap.Logger
 	mu      sync.RWMutex // 保护连接状态
 	ctx     context.Context
 	cancel  context.CancelFunc
+	tunnel  *tunnel // 非nil时通过Daemon主动建立的控制流调用
 }
 
 // NewDaemonClient 创建Daemon gRPC客户端
 func NewDaemonClient(address string, logger *zap.Logger) (*DaemonClient, error) {
+	return newDaemonClient(address, "", nil, logger)
+}
+
+// newDaemonClient 创建Daemon客户端，nodeID非空时按节点统计连接失败次数
+// creds为nil时使用明文连接(Daemon未启用mTLS)
+func newDaemonClient(address, nodeID string, creds credentials.TransportCredentials, logger *zap.Logger) (*DaemonClient, error) {
 	if address == "" {
 		return nil, fmt.Errorf("address is required")
 	}
@@ -73,11 +84,13 @@ func NewDaemonClient(address string, logger *zap.Logger) (*DaemonClient, error)
 		}]
 	}`
 
+	if creds == nil {
+		creds = insecure.NewCredentials()
+	}
+
 	// 创建gRPC连接
-	// 注意：根据任务说明，Daemon监听9091端口，这里使用insecure credentials，实际生产环境应使用TLS
-	conn, err := grpc.Dial(
-		address,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	dialOpts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
 		grpc.WithKeepaliveParams(keepaliveParams),
 		grpc.WithDefaultCallOptions(
 			grpc.MaxCallRecvMsgSize(maxMsgSize),
@@ -87,7 +100,11 @@ func NewDaemonClient(address string, logger *zap.Logger) (*DaemonClient, error)
 		grpc.WithInitialConnWindowSize(initialWindowSize),
 		grpc.WithUnaryInterceptor(UnaryClientInterceptor(logger)),
 		grpc.WithDefaultServiceConfig(retryPolicy),
-	)
+	}
+	if nodeID != "" {
+		dialOpts = append(dialOpts, grpc.WithChainUnaryInterceptor(dialErrorInterceptor(nodeID)))
+	}
+	conn, err := grpc.Dial(address, dialOpts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to dial daemon at %s: %w", address, err)
 	}
@@ -101,6 +118,7 @@ func NewDaemonClient(address string, logger *zap.Logger) (*DaemonClient, error)
 		conn:    conn,
 		client:  client,
 		address: address,
+		creds:   creds,
 		logger:  logger,
 		ctx:     ctx,
 		cancel:  cancel,
@@ -112,8 +130,26 @@ func NewDaemonClient(address string, logger *zap.Logger) (*DaemonClient, error)
 	return dc, nil
 }
 
+// newTunnelDaemonClient 创建通过控制流调用Daemon的客户端
+func newTunnelDaemonClient(t *tunnel, logger *zap.Logger) *DaemonClient {
+	return &DaemonClient{
+		client:  daemonpb.NewDaemonServiceClient(t),
+		address: t.address,
+		logger:  logger,
+		tunnel:  t,
+	}
+}
+
 // ensureConnection 确保连接可用，如果断开则尝试重连
 func (c *DaemonClient) ensureConnection(ctx context.Context) error {
+	// 控制流断开后由Daemon重新建立，这里无法重连
+	if c.tunnel != nil {
+		if c.tunnel.closed() {
+			return fmt.Errorf("%w: daemon control stream closed", ErrConnectionFailed)
+		}
+		return nil
+	}
+
 	c.mu.RLock()
 	state := c.conn.GetState()
 	c.mu.RUnlock()
@@ -195,7 +231,7 @@ func (c *DaemonClient) ensureConnection(ctx context.Context) error {
 		conn, err := grpc.DialContext(
 			dialCtx,
 			c.address,
-			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithTransportCredentials(c.creds),
 			grpc.WithKeepaliveParams(keepaliveParams),
 			grpc.WithBlock(), // 等待连接建立
 			grpc.WithDefaultCallOptions(
@@ -395,6 +431,174 @@ func (c *DaemonClient) GetAgentMetrics(ctx context.Context, nodeID, agentID stri
 	return response.DataPoints, nil
 }
 
+// TailAgentLogs 获取Agent日志末尾N行(keyword非空时只返回包含关键词的行)
+func (c *DaemonClient) TailAgentLogs(ctx context.Context, nodeID, agentID string, lines int, keyword string) ([]string, error) {
+	// 参数验证
+	if nodeID == "" {
+		return nil, fmt.Errorf("%w: nodeID is required", ErrInvalidArgument)
+	}
+	if agentID == "" {
+		return nil, fmt.Errorf("%w: agentID is required", ErrInvalidArgument)
+	}
+
+	// 确保连接可用
+	if err := c.ensureConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	// 设置超时
+	timeoutCtx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	response, err := c.client.TailAgentLogs(timeoutCtx, &daemonpb.TailAgentLogsRequest{
+		AgentId: agentID,
+		Lines:   int32(lines),
+		Keyword: keyword,
+	})
+	if err != nil {
+		c.logger.Warn("failed to tail agent logs",
+			zap.String("node_id", nodeID),
+			zap.String("agent_id", agentID),
+			zap.Error(err))
+		return nil, convertGRPCError(err)
+	}
+
+	c.logger.Debug("tail agent logs success",
+		zap.String("node_id", nodeID),
+		zap.String("agent_id", agentID),
+		zap.Int("lines", len(response.Lines)))
+
+	return response.Lines, nil
+}
+
+// ControlAgent 向Agent下发控制命令(reload/set_log_level/dump_diagnostics/drain)
+// Agent不支持控制协议时由Daemon回退为信号，返回结果中的Method表示实际下发方式
+func (c *DaemonClient) ControlAgent(ctx context.Context, nodeID, agentID, command string, args map[string]string) (*daemonpb.ControlAgentResponse, error) {
+	// 参数验证
+	if nodeID == "" {
+		return nil, fmt.Errorf("%w: nodeID is required", ErrInvalidArgument)
+	}
+	if agentID == "" {
+		return nil, fmt.Errorf("%w: agentID is required", ErrInvalidArgument)
+	}
+	if command == "" {
+		return nil, fmt.Errorf("%w: command is required", ErrInvalidArgument)
+	}
+
+	// 确保连接可用
+	if err := c.ensureConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	// 设置超时 (drain等命令需要Agent处理完已有任务，使用Agent操作的超时时间)
+	timeoutCtx, cancel := context.WithTimeout(ctx, operateAgentTimeout)
+	defer cancel()
+
+	response, err := c.client.ControlAgent(timeoutCtx, &daemonpb.ControlAgentRequest{
+		AgentId: agentID,
+		Command: command,
+		Args:    args,
+	})
+	if err != nil {
+		c.logger.Warn("failed to control agent",
+			zap.String("node_id", nodeID),
+			zap.String("agent_id", agentID),
+			zap.String("command", command),
+			zap.Error(err))
+		return nil, convertGRPCError(err)
+	}
+
+	c.logger.Debug("control agent success",
+		zap.String("node_id", nodeID),
+		zap.String("agent_id", agentID),
+		zap.String("command", command),
+		zap.String("method", response.Method),
+		zap.Bool("success", response.Success))
+
+	return response, nil
+}
+
+// SearchAgentLogs 按关键词/正则和时间范围搜索节点上的Agent日志
+// 超时由调用方通过ctx控制(批量搜索时每个节点单独设置超时)
+func (c *DaemonClient) SearchAgentLogs(ctx context.Context, nodeID string, req *daemonpb.SearchAgentLogsRequest) (*daemonpb.SearchAgentLogsResponse, error) {
+	// 参数验证
+	if nodeID == "" {
+		return nil, fmt.Errorf("%w: nodeID is required", ErrInvalidArgument)
+	}
+	if req == nil {
+		return nil, fmt.Errorf("%w: request is required", ErrInvalidArgument)
+	}
+
+	// 确保连接可用
+	if err := c.ensureConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	response, err := c.client.SearchAgentLogs(ctx, req)
+	if err != nil {
+		c.logger.Warn("failed to search agent logs",
+			zap.String("node_id", nodeID),
+			zap.String("keyword", req.Keyword),
+			zap.Error(err))
+		return nil, convertGRPCError(err)
+	}
+
+	c.logger.Debug("search agent logs success",
+		zap.String("node_id", nodeID),
+		zap.Int("hits", len(response.Hits)),
+		zap.Bool("truncated", response.Truncated))
+
+	return response, nil
+}
+
+// FollowAgentLogs 持续接收Agent新增日志，每批日志调用handler
+// 阻塞直到ctx取消(返回nil)、Daemon结束推送或handler返回错误
+func (c *DaemonClient) FollowAgentLogs(ctx context.Context, nodeID, agentID string, tailLines int, keyword string, handler func(lines []string, rotated bool) error) error {
+	// 参数验证
+	if nodeID == "" {
+		return fmt.Errorf("%w: nodeID is required", ErrInvalidArgument)
+	}
+	if agentID == "" {
+		return fmt.Errorf("%w: agentID is required", ErrInvalidArgument)
+	}
+
+	// 确保连接可用
+	if err := c.ensureConnection(ctx); err != nil {
+		return err
+	}
+
+	// 跟踪模式为长连接，不设置超时，由调用方通过ctx结束
+	stream, err := c.client.FollowAgentLogs(ctx, &daemonpb.FollowAgentLogsRequest{
+		AgentId:   agentID,
+		TailLines: int32(tailLines),
+		Keyword:   keyword,
+	})
+	if err != nil {
+		c.logger.Warn("failed to follow agent logs",
+			zap.String("node_id", nodeID),
+			zap.String("agent_id", agentID),
+			zap.Error(err))
+		return convertGRPCError(err)
+	}
+
+	for {
+		response, err := stream.Recv()
+		if err != nil {
+			if err == io.EOF || ctx.Err() != nil {
+				return nil
+			}
+			c.logger.Warn("agent log stream interrupted",
+				zap.String("node_id", nodeID),
+				zap.String("agent_id", agentID),
+				zap.Error(err))
+			return convertGRPCError(err)
+		}
+		if err := handler(response.Lines, response.Rotated); err != nil {
+			return err
+		}
+	}
+}
+
 // Close 关闭客户端连接
 func (c *DaemonClient) Close() error {
 	c.mu.Lock()
@@ -405,6 +609,9 @@ func (c *DaemonClient) Close() error {
 		c.cancel()
 	}
 
+	if c.tunnel != nil {
+		c.tunnel.close()
+	}
 	if c.conn != nil {
 		return c.conn.Close()
 	}
@@ -450,8 +657,11 @@ func (c *DaemonClient) monitorConnection() {
 }
 
 // DaemonClientPool Daemon客户端连接池
+// 节点存在Daemon主动建立的控制流时优先通过控制流调用(用于NAT或只允许出站连接的节点)，否则直接拨号Daemon
 type DaemonClientPool struct {
 	clients map[string]*DaemonClient
+	tunnels map[string]*DaemonClient // 通过控制流调用的客户端
+	creds   func(nodeID string) credentials.TransportCredentials
 	mu      sync.RWMutex
 	logger  *zap.Logger
 }
@@ -460,17 +670,34 @@ type DaemonClientPool struct {
 func NewDaemonClientPool(logger *zap.Logger) *DaemonClientPool {
 	return &DaemonClientPool{
 		clients: make(map[string]*DaemonClient),
+		tunnels: make(map[string]*DaemonClient),
 		logger:  logger,
 	}
 }
 
+// SetTransportCredentials 设置直接拨号Daemon使用的传输凭证(按节点校验Daemon证书)，未设置时使用明文连接
+func (p *DaemonClientPool) SetTransportCredentials(creds func(nodeID string) credentials.TransportCredentials) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.creds = creds
+}
+
 // GetClient 获取或创建客户端
 // nodeID: 节点ID，用于标识连接
-// address: Daemon地址，格式为 "host:port"
+// address: Daemon地址，格式为 "host:port"(节点存在控制流时不使用)
 func (p *DaemonClientPool) GetClient(nodeID, address string) (service.DaemonClient, error) {
 	if nodeID == "" {
 		return nil, fmt.Errorf("%w: nodeID is required", ErrInvalidArgument)
 	}
+
+	// 优先使用Daemon主动建立的控制流
+	p.mu.RLock()
+	if client, exists := p.tunnels[nodeID]; exists {
+		p.mu.RUnlock()
+		return client, nil
+	}
+	p.mu.RUnlock()
+
 	if address == "" {
 		return nil, fmt.Errorf("%w: address is required", ErrInvalidArgument)
 	}
@@ -493,8 +720,13 @@ func (p *DaemonClientPool) GetClient(nodeID, address string) (service.DaemonClie
 	}
 
 	// 创建新客户端
-	client, err := NewDaemonClient(address, p.logger)
+	var creds credentials.TransportCredentials
+	if p.creds != nil {
+		creds = p.creds(nodeID)
+	}
+	client, err := newDaemonClient(address, nodeID, creds, p.logger)
 	if err != nil {
+		telemetry.DaemonDialErrors.WithLabelValues(nodeID).Inc()
 		return nil, fmt.Errorf("failed to create daemon client: %w", err)
 	}
 
@@ -508,7 +740,60 @@ func (p *DaemonClientPool) GetClient(nodeID, address string) (service.DaemonClie
 	return client, nil
 }
 
+// Count 获取当前连接数(包括控制流)
+func (p *DaemonClientPool) Count() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return len(p.clients) + len(p.tunnels)
+}
+
+// HasTunnel 节点是否存在Daemon主动建立的控制流
+func (p *DaemonClientPool) HasTunnel(nodeID string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	_, exists := p.tunnels[nodeID]
+	return exists
+}
+
+// attachTunnel 登记节点的控制流，替换该节点已有的控制流并关闭直连客户端
+func (p *DaemonClientPool) attachTunnel(t *tunnel) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if old, exists := p.tunnels[t.nodeID]; exists {
+		old.tunnel.close()
+	}
+	p.tunnels[t.nodeID] = newTunnelDaemonClient(t, p.logger)
+
+	if client, exists := p.clients[t.nodeID]; exists {
+		if err := client.Close(); err != nil {
+			p.logger.Warn("failed to close daemon client",
+				zap.String("node_id", t.nodeID),
+				zap.Error(err))
+		}
+		delete(p.clients, t.nodeID)
+	}
+
+	p.logger.Info("daemon control stream attached",
+		zap.String("node_id", t.nodeID),
+		zap.String("address", t.address))
+}
+
+// detachTunnel 注销节点的控制流(已被新控制流替换时忽略)
+func (p *DaemonClientPool) detachTunnel(t *tunnel) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if client, exists := p.tunnels[t.nodeID]; exists && client.tunnel == t {
+		delete(p.tunnels, t.nodeID)
+		p.logger.Info("daemon control stream detached",
+			zap.String("node_id", t.nodeID),
+			zap.String("address", t.address))
+	}
+}
+
 // CloseClient 关闭指定节点的客户端
+// 控制流由Daemon维护，只关闭直连客户端
 func (p *DaemonClientPool) CloseClient(nodeID string) error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
@@ -545,8 +830,13 @@ func (p *DaemonClientPool) CloseAll() {
 		}
 	}
 
+	for _, client := range p.tunnels {
+		client.tunnel.close()
+	}
+
 	// 清空map
 	p.clients = make(map[string]*DaemonClient)
+	p.tunnels = make(map[string]*DaemonClient)
 
 	p.logger.Info("closed all daemon clients")
 }
@@ -2,11 +2,15 @@ package grpc
 
 import (
 	"context"
+	"time"
 
+	"github.com/bingooyong/ops-scaffold-framework/manager/internal/model"
 	"github.com/bingooyong/ops-scaffold-framework/manager/internal/service"
+	"github.com/bingooyong/ops-scaffold-framework/manager/internal/telemetry"
 	daemonpb "github.com/bingooyong/ops-scaffold-framework/manager/pkg/proto/daemon"
 	"go.uber.org/zap"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 )
 
@@ -14,21 +18,70 @@ import (
 // 用于接收Daemon上报的Agent状态等信息
 type DaemonServer struct {
 	daemonpb.UnimplementedDaemonServiceServer
-	agentService *service.AgentService
-	logger       *zap.Logger
+	agentService   *service.AgentService
+	metricsService service.MetricsService
+	daemonPool     *DaemonClientPool
+	logger         *zap.Logger
 }
 
 // NewDaemonServer 创建DaemonService服务器实例
 func NewDaemonServer(
 	agentService *service.AgentService,
+	metricsService service.MetricsService,
 	logger *zap.Logger,
 ) *DaemonServer {
 	return &DaemonServer{
-		agentService: agentService,
-		logger:       logger,
+		agentService:   agentService,
+		metricsService: metricsService,
+		logger:         logger,
 	}
 }
 
+// SetDaemonClientPool 设置Daemon客户端连接池，启用Daemon主动建立的控制流
+func (s *DaemonServer) SetDaemonClientPool(pool *DaemonClientPool) {
+	s.daemonPool = pool
+}
+
+// Connect 接收Daemon主动建立的控制流
+// Daemon建流后先发送hello帧表明节点身份，之后连接池中该节点的调用通过此流转发，流断开后回退为直接拨号
+func (s *DaemonServer) Connect(stream daemonpb.DaemonService_ConnectServer) error {
+	if s.daemonPool == nil {
+		return status.Error(codes.Unimplemented, "daemon control stream is not enabled")
+	}
+
+	hello, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	if hello.Type != tunnelFrameHello {
+		return status.Errorf(codes.InvalidArgument, "expected hello frame, got %q", hello.Type)
+	}
+	if hello.NodeId == "" {
+		return status.Error(codes.InvalidArgument, "node_id is required")
+	}
+
+	address := ""
+	if p, ok := peer.FromContext(stream.Context()); ok {
+		address = p.Addr.String()
+	}
+
+	t := newTunnel(hello.NodeId, address, stream, s.logger)
+	s.daemonPool.attachTunnel(t)
+	defer s.daemonPool.detachTunnel(t)
+
+	err = t.serve()
+	if err != nil && stream.Context().Err() == nil {
+		s.logger.Warn("daemon control stream closed",
+			zap.String("node_id", hello.NodeId),
+			zap.String("address", address),
+			zap.Error(err))
+	}
+	return nil
+}
+
+// stateSyncClockSkew 状态快照采集时间与Manager时间的容许偏差，超出时视为补发的历史快照
+const stateSyncClockSkew = time.Minute
+
 // SyncAgentStates 同步Agent状态
 func (s *DaemonServer) SyncAgentStates(ctx context.Context, req *daemonpb.SyncAgentStatesRequest) (*daemonpb.SyncAgentStatesResponse, error) {
 	// 验证请求参数
@@ -48,7 +101,14 @@ func (s *DaemonServer) SyncAgentStates(ctx context.Context, req *daemonpb.SyncAg
 		zap.Int("count", len(req.States)))
 
 	// 调用AgentService同步状态
-	if err := s.agentService.SyncAgentStates(ctx, req.NodeId, req.States); err != nil {
+	// Daemon补发的历史快照使用采集时间，接近当前时间的快照使用Manager时间(避免两端时钟偏差)
+	syncTime := time.Now()
+	if req.Timestamp > 0 {
+		if collectedAt := time.Unix(req.Timestamp, 0); collectedAt.Before(syncTime.Add(-stateSyncClockSkew)) {
+			syncTime = collectedAt
+		}
+	}
+	if err := s.agentService.SyncAgentStatesAt(ctx, req.NodeId, req.States, syncTime); err != nil {
 		s.logger.Error("failed to sync agent states",
 			zap.String("node_id", req.NodeId),
 			zap.Error(err))
@@ -62,8 +122,145 @@ func (s *DaemonServer) SyncAgentStates(ctx context.Context, req *daemonpb.SyncAg
 		zap.String("node_id", req.NodeId),
 		zap.Int("count", len(req.States)))
 
+	// 保存状态中携带的自定义指标，失败不影响状态同步结果
+	s.saveAgentMetrics(ctx, req.NodeId, req.States)
+
 	return &daemonpb.SyncAgentStatesResponse{
 		Success: true,
 		Message: "states synced successfully",
 	}, nil
 }
+
+// saveAgentMetrics 保存Agent状态中携带的自定义指标
+// Daemon每次同步都会附带最近一次的值，相同时间戳的记录由存储层去重
+func (s *DaemonServer) saveAgentMetrics(ctx context.Context, nodeID string, states []*daemonpb.AgentState) {
+	if s.metricsService == nil {
+		return
+	}
+
+	var metrics []*model.AgentMetric
+	for _, state := range states {
+		if state.AgentId == "" || state.CustomMetricsTimestamp <= 0 {
+			continue
+		}
+		if len(state.CustomMetrics) == 0 && len(state.CustomFields) == 0 {
+			continue
+		}
+
+		values := make(model.JSONMap, len(state.CustomMetrics))
+		for k, v := range state.CustomMetrics {
+			values[k] = v
+		}
+		fields := make(model.JSONMap, len(state.CustomFields))
+		for k, v := range state.CustomFields {
+			fields[k] = v
+		}
+		metrics = append(metrics, &model.AgentMetric{
+			NodeID:    nodeID,
+			AgentID:   state.AgentId,
+			Timestamp: time.UnixMilli(state.CustomMetricsTimestamp),
+			Values:    values,
+			Fields:    fields,
+		})
+	}
+	if len(metrics) == 0 {
+		return
+	}
+
+	if err := s.metricsService.SaveAgentMetrics(ctx, metrics); err != nil {
+		telemetry.MetricsIngestErrors.WithLabelValues().Inc()
+		s.logger.Warn("failed to save agent custom metrics",
+			zap.String("node_id", nodeID),
+			zap.Int("count", len(metrics)),
+			zap.Error(err))
+		return
+	}
+	telemetry.MetricsIngested.WithLabelValues("agent_custom").Add(uint64(len(metrics)))
+}
+
+// ReportAgentEvents 接收Daemon上报的Agent事件(熔断触发/复位等)
+func (s *DaemonServer) ReportAgentEvents(ctx context.Context, req *daemonpb.ReportAgentEventsRequest) (*daemonpb.ReportAgentEventsResponse, error) {
+	if req.NodeId == "" {
+		return nil, status.Error(codes.InvalidArgument, "node_id is required")
+	}
+
+	if len(req.Events) == 0 {
+		return &daemonpb.ReportAgentEventsResponse{
+			Success: true,
+			Message: "no events to report",
+		}, nil
+	}
+
+	if err := s.agentService.ReportAgentEvents(ctx, req.NodeId, req.Events); err != nil {
+		s.logger.Error("failed to save agent events",
+			zap.String("node_id", req.NodeId),
+			zap.Error(err))
+		return &daemonpb.ReportAgentEventsResponse{
+			Success: false,
+			Message: "failed to save agent events: " + err.Error(),
+		}, nil
+	}
+
+	return &daemonpb.ReportAgentEventsResponse{
+		Success: true,
+		Message: "events reported successfully",
+	}, nil
+}
+
+// ReportCrashes 接收Daemon上报的Agent崩溃记录
+func (s *DaemonServer) ReportCrashes(ctx context.Context, req *daemonpb.ReportCrashesRequest) (*daemonpb.ReportCrashesResponse, error) {
+	if req.NodeId == "" {
+		return nil, status.Error(codes.InvalidArgument, "node_id is required")
+	}
+
+	if len(req.Crashes) == 0 {
+		return &daemonpb.ReportCrashesResponse{
+			Success: true,
+			Message: "no crashes to report",
+		}, nil
+	}
+
+	if err := s.agentService.ReportCrashes(ctx, req.NodeId, req.Crashes); err != nil {
+		s.logger.Error("failed to save crash reports",
+			zap.String("node_id", req.NodeId),
+			zap.Error(err))
+		return &daemonpb.ReportCrashesResponse{
+			Success: false,
+			Message: "failed to save crash reports: " + err.Error(),
+		}, nil
+	}
+
+	return &daemonpb.ReportCrashesResponse{
+		Success: true,
+		Message: "crashes reported successfully",
+	}, nil
+}
+
+// ReportResourceAlerts 接收Daemon上报的Agent资源告警
+func (s *DaemonServer) ReportResourceAlerts(ctx context.Context, req *daemonpb.ReportResourceAlertsRequest) (*daemonpb.ReportResourceAlertsResponse, error) {
+	if req.NodeId == "" {
+		return nil, status.Error(codes.InvalidArgument, "node_id is required")
+	}
+
+	if len(req.Alerts) == 0 {
+		return &daemonpb.ReportResourceAlertsResponse{
+			Success: true,
+			Message: "no alerts to report",
+		}, nil
+	}
+
+	if err := s.agentService.ReportResourceAlerts(ctx, req.NodeId, req.Alerts); err != nil {
+		s.logger.Error("failed to save resource alerts",
+			zap.String("node_id", req.NodeId),
+			zap.Error(err))
+		return &daemonpb.ReportResourceAlertsResponse{
+			Success: false,
+			Message: "failed to save resource alerts: " + err.Error(),
+		}, nil
+	}
+
+	return &daemonpb.ReportResourceAlertsResponse{
+		Success: true,
+		Message: "alerts reported successfully",
+	}, nil
+}
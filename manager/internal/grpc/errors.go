@@ -3,6 +3,7 @@ package grpc
 import (
 	"errors"
 
+	apierrors "github.com/bingooyong/ops-scaffold-framework/manager/pkg/errors"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -46,3 +47,24 @@ func convertGRPCError(err error) error {
 		return err
 	}
 }
+
+// apiErrorToStatus 将业务层APIError转换为gRPC状态错误
+func apiErrorToStatus(err error) error {
+	apiErr, ok := err.(*apierrors.APIError)
+	if !ok {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	code := codes.Internal
+	switch apiErr.Code {
+	case apierrors.ErrInvalidParams:
+		code = codes.InvalidArgument
+	case apierrors.ErrInvalidToken, apierrors.ErrTokenExpired, apierrors.ErrUnauthorized:
+		code = codes.Unauthenticated
+	case apierrors.ErrForbidden:
+		code = codes.PermissionDenied
+	case apierrors.ErrNotFound:
+		code = codes.NotFound
+	}
+	return status.Error(code, apiErr.Error())
+}
@@ -0,0 +1,134 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/bingooyong/ops-scaffold-framework/manager/internal/pki"
+	pb "github.com/bingooyong/ops-scaffold-framework/manager/pkg/proto"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// nodeIDKey 上下文中已验证的节点ID
+type nodeIDKey struct{}
+
+// nodeIDRequest 携带node_id字段的请求
+type nodeIDRequest interface {
+	GetNodeId() string
+}
+
+// unauthenticatedMethods 无需客户端证书即可调用的方法
+var unauthenticatedMethods = map[string]bool{
+	pb.ManagerService_Enroll_FullMethodName: true,
+}
+
+// NodeIDFromContext 返回由客户端证书验证的节点ID
+func NodeIDFromContext(ctx context.Context) (string, bool) {
+	nodeID, ok := ctx.Value(nodeIDKey{}).(string)
+	return nodeID, ok
+}
+
+// peerNodeID 从TLS连接的已验证客户端证书中提取节点ID
+func peerNodeID(ctx context.Context) (string, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", status.Error(codes.Unauthenticated, "client certificate required")
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return "", status.Error(codes.Unauthenticated, "client certificate required")
+	}
+	nodeID := pki.NodeIDFromCert(tlsInfo.State.VerifiedChains[0][0])
+	if nodeID == "" {
+		return "", status.Error(codes.Unauthenticated, "client certificate is not a node certificate")
+	}
+	return nodeID, nil
+}
+
+// checkNodeID 校验请求中的node_id与证书身份一致
+func checkNodeID(nodeID string, req interface{}) error {
+	r, ok := req.(nodeIDRequest)
+	if !ok {
+		return nil
+	}
+	if reqNodeID := r.GetNodeId(); reqNodeID != "" && reqNodeID != nodeID {
+		return status.Errorf(codes.PermissionDenied, "node_id %q does not match client certificate", reqNodeID)
+	}
+	return nil
+}
+
+// UnaryIdentityInterceptor 服务端一元RPC身份拦截器
+// 要求客户端证书由内置CA签发，且请求中的node_id与证书绑定的节点ID一致(Enroll除外)
+func UnaryIdentityInterceptor(logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if unauthenticatedMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		nodeID, err := peerNodeID(ctx)
+		if err != nil {
+			logger.Warn("grpc call rejected", zap.String("method", info.FullMethod), zap.Error(err))
+			return nil, err
+		}
+		if err := checkNodeID(nodeID, req); err != nil {
+			logger.Warn("grpc call rejected",
+				zap.String("method", info.FullMethod),
+				zap.String("cert_node_id", nodeID),
+				zap.Error(err))
+			return nil, err
+		}
+
+		return handler(context.WithValue(ctx, nodeIDKey{}, nodeID), req)
+	}
+}
+
+// StreamIdentityInterceptor 服务端流式RPC身份拦截器
+// 对流上收到的每条消息校验node_id
+func StreamIdentityInterceptor(logger *zap.Logger) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		nodeID, err := peerNodeID(ss.Context())
+		if err != nil {
+			logger.Warn("grpc stream rejected", zap.String("method", info.FullMethod), zap.Error(err))
+			return err
+		}
+		return handler(srv, &identityServerStream{
+			ServerStream: ss,
+			ctx:          context.WithValue(ss.Context(), nodeIDKey{}, nodeID),
+			nodeID:       nodeID,
+		})
+	}
+}
+
+// identityServerStream 校验每条消息node_id的服务端流
+type identityServerStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	nodeID string
+}
+
+// Context 返回携带节点ID的上下文
+func (s *identityServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// RecvMsg 接收消息并校验node_id
+func (s *identityServerStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return checkNodeID(s.nodeID, m)
+}
@@ -0,0 +1,200 @@
+package grpc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bingooyong/ops-scaffold-framework/manager/internal/pki"
+	pb "github.com/bingooyong/ops-scaffold-framework/manager/pkg/proto"
+	daemonpb "github.com/bingooyong/ops-scaffold-framework/manager/pkg/proto/daemon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+// identityTestManager 只实现Heartbeat的ManagerService，返回证书中的节点ID
+type identityTestManager struct {
+	pb.UnimplementedManagerServiceServer
+}
+
+func (m *identityTestManager) Heartbeat(ctx context.Context, req *pb.HeartbeatRequest) (*pb.HeartbeatResponse, error) {
+	nodeID, _ := NodeIDFromContext(ctx)
+	return &pb.HeartbeatResponse{Success: true, Message: nodeID}, nil
+}
+
+// startIdentityServer 启动要求mTLS的gRPC服务器，返回CA和服务器地址
+func startIdentityServer(t *testing.T, pool *DaemonClientPool) (*pki.CA, string) {
+	t.Helper()
+	dir := t.TempDir()
+	ca, _, err := pki.LoadOrCreateCA(filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key"))
+	require.NoError(t, err)
+	certFile, keyFile := filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key")
+	_, err = ca.EnsureServerCert(certFile, keyFile, []string{"127.0.0.1"})
+	require.NoError(t, err)
+
+	creds, err := NewServerCredentials(certFile, keyFile, filepath.Join(dir, "ca.crt"))
+	require.NoError(t, err)
+
+	logger := zap.NewNop()
+	server := grpc.NewServer(
+		grpc.Creds(credentials.NewTLS(creds.TLSConfig())),
+		grpc.UnaryInterceptor(UnaryIdentityInterceptor(logger)),
+		grpc.StreamInterceptor(StreamIdentityInterceptor(logger)),
+	)
+	pb.RegisterManagerServiceServer(server, &identityTestManager{})
+	daemonSrv := NewDaemonServer(nil, nil, logger)
+	daemonSrv.SetDaemonClientPool(pool)
+	daemonpb.RegisterDaemonServiceServer(server, daemonSrv)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+	return ca, lis.Addr().String()
+}
+
+// dialIdentityServer 使用节点证书(nodeID为空时不带证书)连接服务器
+func dialIdentityServer(t *testing.T, ca *pki.CA, address, nodeID string) *grpc.ClientConn {
+	t.Helper()
+	tlsConfig := &tls.Config{RootCAs: ca.Pool(), MinVersion: tls.VersionTLS13}
+	if nodeID != "" {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{Subject: pkix.Name{CommonName: nodeID}}, key)
+		require.NoError(t, err)
+		certPEM, _, err := ca.SignNodeCSR(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}), nodeID, time.Hour)
+		require.NoError(t, err)
+		keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+		require.NoError(t, err)
+		cert, err := tls.X509KeyPair(certPEM, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}))
+		require.NoError(t, err)
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestIdentityInterceptor_Unary(t *testing.T) {
+	ca, address := startIdentityServer(t, NewDaemonClientPool(zap.NewNop()))
+	ctx := context.Background()
+
+	// 证书身份与node_id一致
+	client := pb.NewManagerServiceClient(dialIdentityServer(t, ca, address, "node-1"))
+	resp, err := client.Heartbeat(ctx, &pb.HeartbeatRequest{NodeId: "node-1"})
+	require.NoError(t, err)
+	assert.Equal(t, "node-1", resp.Message)
+
+	// 冒充其他节点
+	_, err = client.Heartbeat(ctx, &pb.HeartbeatRequest{NodeId: "node-2"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	// 未提供客户端证书只能调用Enroll
+	anonymous := pb.NewManagerServiceClient(dialIdentityServer(t, ca, address, ""))
+	_, err = anonymous.Heartbeat(ctx, &pb.HeartbeatRequest{NodeId: "node-1"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = anonymous.RenewCertificate(ctx, &pb.RenewCertificateRequest{NodeId: "node-1"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = anonymous.Enroll(ctx, &pb.EnrollRequest{NodeId: "node-1"})
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+}
+
+func TestIdentityInterceptor_Stream(t *testing.T) {
+	pool := NewDaemonClientPool(zap.NewNop())
+	defer pool.CloseAll()
+	ca, address := startIdentityServer(t, pool)
+	client := daemonpb.NewDaemonServiceClient(dialIdentityServer(t, ca, address, "node-1"))
+
+	// 控制流hello中的节点ID必须与证书一致
+	stream, err := client.Connect(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&daemonpb.TunnelFrame{Type: tunnelFrameHello, NodeId: "node-2"}))
+	_, err = stream.Recv()
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.False(t, pool.HasTunnel("node-2"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err = client.Connect(ctx)
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&daemonpb.TunnelFrame{Type: tunnelFrameHello, NodeId: "node-1"}))
+	waitForTunnel(t, pool, "node-1", true)
+}
+
+// identityTestDaemon 只实现ListAgents的DaemonService
+type identityTestDaemon struct {
+	daemonpb.UnimplementedDaemonServiceServer
+}
+
+func (d *identityTestDaemon) ListAgents(ctx context.Context, req *daemonpb.ListAgentsRequest) (*daemonpb.ListAgentsResponse, error) {
+	return &daemonpb.ListAgentsResponse{Agents: []*daemonpb.AgentInfo{{Id: "agent-1"}}}, nil
+}
+
+func TestDaemonCredentials_VerifiesNodeID(t *testing.T) {
+	dir := t.TempDir()
+	ca, _, err := pki.LoadOrCreateCA(filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key"))
+	require.NoError(t, err)
+	certFile, keyFile := filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key")
+	_, err = ca.EnsureServerCert(certFile, keyFile, []string{"127.0.0.1"})
+	require.NoError(t, err)
+	creds, err := NewServerCredentials(certFile, keyFile, filepath.Join(dir, "ca.crt"))
+	require.NoError(t, err)
+
+	// 模拟Daemon：以node-1的节点证书提供服务，要求客户端出示CA签发的证书
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{Subject: pkix.Name{CommonName: "node-1"}}, key)
+	require.NoError(t, err)
+	certPEM, _, err := ca.SignNodeCSR(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}), "node-1", time.Hour)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	nodeCert, err := tls.X509KeyPair(certPEM, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}))
+	require.NoError(t, err)
+
+	server := grpc.NewServer(grpc.Creds(credentials.NewTLS(&tls.Config{
+		MinVersion:   tls.VersionTLS13,
+		Certificates: []tls.Certificate{nodeCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    ca.Pool(),
+	})))
+	daemonpb.RegisterDaemonServiceServer(server, &identityTestDaemon{})
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	pool := NewDaemonClientPool(zap.NewNop())
+	pool.SetTransportCredentials(creds.DaemonCredentials)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := pool.GetClient("node-1", lis.Addr().String())
+	require.NoError(t, err)
+	agents, err := client.ListAgents(ctx, "node-1")
+	require.NoError(t, err)
+	require.Len(t, agents, 1)
+
+	// 节点ID与Daemon证书不一致(如地址被劫持或指向其他节点)
+	client, err = pool.GetClient("node-2", lis.Addr().String())
+	require.NoError(t, err)
+	shortCtx, shortCancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer shortCancel()
+	_, err = client.ListAgents(shortCtx, "node-2")
+	assert.Error(t, err)
+}
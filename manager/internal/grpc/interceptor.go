@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/bingooyong/ops-scaffold-framework/manager/internal/telemetry"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -73,6 +74,9 @@ func UnaryServerInterceptor(logger *zap.Logger) grpc.UnaryServerInterceptor {
 
 		// 计算耗时
 		duration := time.Since(start)
+		telemetry.GRPCServerDuration.
+			WithLabelValues(info.FullMethod, status.Code(err).String()).
+			Observe(duration.Seconds())
 
 		// 记录日志
 		fields := []zap.Field{
@@ -101,3 +105,22 @@ func UnaryServerInterceptor(logger *zap.Logger) grpc.UnaryServerInterceptor {
 		return resp, err
 	}
 }
+
+// dialErrorInterceptor 客户端拦截器，统计指定节点因连接不可用而失败的调用次数
+// grpc.Dial 为非阻塞连接，连接失败体现为调用返回 Unavailable
+func dialErrorInterceptor(nodeID string) grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply interface{},
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if status.Code(err) == codes.Unavailable {
+			telemetry.DaemonDialErrors.WithLabelValues(nodeID).Inc()
+		}
+		return err
+	}
+}
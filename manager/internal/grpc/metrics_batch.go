@@ -0,0 +1,129 @@
+package grpc
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bingooyong/ops-scaffold-framework/manager/internal/model"
+	pb "github.com/bingooyong/ops-scaffold-framework/manager/pkg/proto"
+)
+
+// metricsBatchVersion 支持的指标批量格式版本
+const metricsBatchVersion = 1
+
+// metricsSeriesKey 指标记录中保存带标签明细的字段
+const metricsSeriesKey = "series"
+
+// legacyMetricsToModels 将旧格式(按类型扁平化的map)转换为指标记录
+func legacyMetricsToModels(nodeID string, data []*pb.MetricData) []*model.Metrics {
+	metrics := make([]*model.Metrics, 0, len(data))
+	for _, m := range data {
+		values := make(map[string]interface{}, len(m.Values))
+		for k, v := range m.Values {
+			values[k] = v
+		}
+
+		metrics = append(metrics, &model.Metrics{
+			NodeID:    nodeID,
+			Type:      m.Type,
+			Timestamp: time.Unix(m.Timestamp, 0),
+			Values:    values,
+		})
+	}
+	return metrics
+}
+
+// batchToModels 将批量格式转换为指标记录
+// 序列名 "<类型>.<键>" 中的类型和采样时间确定一条记录：无标签的值保存为 Values[键]，
+// 带标签的值按标签分组保存在 Values["series"] 中，格式为 [{"labels": {...}, "values": {...}}]
+// 返回无法识别(名称不含类型或没有值)而跳过的采样点数量
+func batchToModels(nodeID string, batch *pb.MetricsBatch) ([]*model.Metrics, int) {
+	type recordKey struct {
+		metricType string
+		timestamp  int64
+	}
+
+	var (
+		metrics []*model.Metrics
+		skipped int
+	)
+	records := make(map[recordKey]*model.Metrics)
+	labeled := make(map[recordKey]map[string]map[string]interface{})
+
+	for _, s := range batch.GetSeries() {
+		metricType, key, ok := strings.Cut(s.Name, ".")
+		if !ok || metricType == "" || key == "" {
+			skipped += len(s.Points)
+			continue
+		}
+
+		for _, p := range s.Points {
+			value, ok := metricPointValue(p)
+			if !ok {
+				skipped++
+				continue
+			}
+
+			rk := recordKey{metricType: metricType, timestamp: p.TimestampMs}
+			record, exists := records[rk]
+			if !exists {
+				record = &model.Metrics{
+					NodeID:    nodeID,
+					Type:      metricType,
+					Timestamp: time.UnixMilli(p.TimestampMs),
+					Values:    make(map[string]interface{}),
+				}
+				records[rk] = record
+				labeled[rk] = make(map[string]map[string]interface{})
+				metrics = append(metrics, record)
+			}
+
+			if len(s.Labels) == 0 {
+				record.Values[key] = value
+				continue
+			}
+
+			lk := labelsKey(s.Labels)
+			entry, exists := labeled[rk][lk]
+			if !exists {
+				labels := make(map[string]interface{}, len(s.Labels))
+				for k, v := range s.Labels {
+					labels[k] = v
+				}
+				entry = map[string]interface{}{
+					"labels": labels,
+					"values": make(map[string]interface{}),
+				}
+				labeled[rk][lk] = entry
+				series, _ := record.Values[metricsSeriesKey].([]interface{})
+				record.Values[metricsSeriesKey] = append(series, entry)
+			}
+			entry["values"].(map[string]interface{})[key] = value
+		}
+	}
+
+	return metrics, skipped
+}
+
+// metricPointValue 返回采样点的数值
+func metricPointValue(p *pb.MetricPoint) (float64, bool) {
+	switch v := p.Value.(type) {
+	case *pb.MetricPoint_DoubleValue:
+		return v.DoubleValue, true
+	case *pb.MetricPoint_IntValue:
+		return float64(v.IntValue), true
+	default:
+		return 0, false
+	}
+}
+
+// labelsKey 标签的稳定表示，用于合并同一标签组的值
+func labelsKey(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for k, v := range labels {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
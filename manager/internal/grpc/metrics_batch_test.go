@@ -0,0 +1,79 @@
+package grpc
+
+import (
+	"testing"
+	"time"
+
+	pb "github.com/bingooyong/ops-scaffold-framework/manager/pkg/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func doublePoint(ts int64, v float64) *pb.MetricPoint {
+	return &pb.MetricPoint{TimestampMs: ts, Value: &pb.MetricPoint_DoubleValue{DoubleValue: v}}
+}
+
+func intPoint(ts int64, v int64) *pb.MetricPoint {
+	return &pb.MetricPoint{TimestampMs: ts, Value: &pb.MetricPoint_IntValue{IntValue: v}}
+}
+
+func TestBatchToModels(t *testing.T) {
+	t1 := time.Now().Add(-time.Minute).UnixMilli()
+	t2 := t1 + 60_000
+
+	batch := &pb.MetricsBatch{
+		Version: metricsBatchVersion,
+		Series: []*pb.MetricSeries{
+			{Name: "cpu.usage_percent", Type: pb.MetricValueType_METRIC_VALUE_TYPE_GAUGE,
+				Points: []*pb.MetricPoint{doublePoint(t1, 12.5), doublePoint(t2, 30)}},
+			{Name: "cpu.cores", Points: []*pb.MetricPoint{intPoint(t1, 8)}},
+			{Name: "cpu.usage_percent", Labels: map[string]string{"cpu": "cpu0"},
+				Points: []*pb.MetricPoint{doublePoint(t1, 50)}},
+			{Name: "disk.used_bytes", Labels: map[string]string{"device": "/dev/sda1", "mountpoint": "/"},
+				Points: []*pb.MetricPoint{intPoint(t1, 1024)}},
+			{Name: "disk.usage_percent", Labels: map[string]string{"mountpoint": "/", "device": "/dev/sda1"},
+				Points: []*pb.MetricPoint{doublePoint(t1, 25)}},
+			{Name: "invalid", Points: []*pb.MetricPoint{intPoint(t1, 1)}},
+			{Name: "cpu.empty", Points: []*pb.MetricPoint{{TimestampMs: t1}}},
+		},
+	}
+
+	metrics, skipped := batchToModels("node-1", batch)
+	assert.Equal(t, 2, skipped)
+	require.Len(t, metrics, 3)
+
+	// 同一类型和采样时间的值合并为一条记录
+	cpu1 := metrics[0]
+	assert.Equal(t, "node-1", cpu1.NodeID)
+	assert.Equal(t, "cpu", cpu1.Type)
+	assert.Equal(t, t1, cpu1.Timestamp.UnixMilli())
+	assert.Equal(t, 12.5, cpu1.Values["usage_percent"])
+	assert.Equal(t, float64(8), cpu1.Values["cores"])
+	require.Len(t, cpu1.Values["series"], 1)
+	entry := cpu1.Values["series"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"cpu": "cpu0"}, entry["labels"])
+	assert.Equal(t, map[string]interface{}{"usage_percent": float64(50)}, entry["values"])
+
+	// 多时间点的序列拆分为多条记录
+	cpu2 := metrics[1]
+	assert.Equal(t, t2, cpu2.Timestamp.UnixMilli())
+	assert.Equal(t, float64(30), cpu2.Values["usage_percent"])
+	assert.NotContains(t, cpu2.Values, "series")
+
+	// 标签相同(与顺序无关)的值归入同一明细
+	disk := metrics[2]
+	assert.Equal(t, "disk", disk.Type)
+	require.Len(t, disk.Values["series"], 1)
+	entry = disk.Values["series"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"used_bytes": float64(1024), "usage_percent": float64(25)}, entry["values"])
+}
+
+func TestLegacyMetricsToModels(t *testing.T) {
+	metrics := legacyMetricsToModels("node-1", []*pb.MetricData{
+		{Type: "memory", Timestamp: 1700000000, Values: map[string]float64{"usage_percent": 40}},
+	})
+	require.Len(t, metrics, 1)
+	assert.Equal(t, "memory", metrics[0].Type)
+	assert.Equal(t, int64(1700000000), metrics[0].Timestamp.Unix())
+	assert.Equal(t, 40.0, metrics[0].Values["usage_percent"])
+}
@@ -6,16 +6,22 @@ import (
 
 	"github.com/bingooyong/ops-scaffold-framework/manager/internal/model"
 	"github.com/bingooyong/ops-scaffold-framework/manager/internal/service"
+	"github.com/bingooyong/ops-scaffold-framework/manager/internal/telemetry"
+	"github.com/bingooyong/ops-scaffold-framework/manager/pkg/errors"
 	pb "github.com/bingooyong/ops-scaffold-framework/manager/pkg/proto"
 	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	_ "google.golang.org/grpc/encoding/gzip" // 注册gzip解压器，Daemon上报默认启用gzip压缩
+	"google.golang.org/grpc/status"
 )
 
 // Server gRPC服务器
 type Server struct {
 	pb.UnimplementedManagerServiceServer
-	nodeService    service.NodeService
-	metricsService service.MetricsService
-	logger         *zap.Logger
+	nodeService       service.NodeService
+	metricsService    service.MetricsService
+	enrollmentService service.EnrollmentService
+	logger            *zap.Logger
 }
 
 // NewServer 创建gRPC服务器实例
@@ -31,6 +37,11 @@ func NewServer(
 	}
 }
 
+// SetEnrollmentService 设置节点证书签发服务(未设置时Enroll和RenewCertificate返回Unimplemented)
+func (s *Server) SetEnrollmentService(enrollmentService service.EnrollmentService) {
+	s.enrollmentService = enrollmentService
+}
+
 // RegisterNode 节点注册
 func (s *Server) RegisterNode(ctx context.Context, req *pb.RegisterNodeRequest) (*pb.RegisterNodeResponse, error) {
 	s.logger.Info("node registration request",
@@ -50,27 +61,59 @@ func (s *Server) RegisterNode(ctx context.Context, req *pb.RegisterNodeRequest)
 		Labels:        req.Labels,
 		DaemonVersion: req.DaemonVersion,
 		AgentVersion:  req.AgentVersion,
+		Fingerprint:   req.Fingerprint,
 		Status:        "online",
 		RegisterAt:    now,
 	}
 
-	// 注册节点
-	if err := s.nodeService.Register(ctx, node); err != nil {
+	// 注册节点，携带有效节点证书的请求自动批准
+	_, verified := NodeIDFromContext(ctx)
+	if err := s.nodeService.Register(ctx, node, verified); err != nil {
 		s.logger.Error("failed to register node", zap.Error(err))
 		return &pb.RegisterNodeResponse{
 			Success: false,
 			Message: "节点注册失败: " + err.Error(),
+			Status:  registrationStatus(err, ""),
+		}, nil
+	}
+
+	if node.Approval == model.NodeApprovalRejected {
+		s.logger.Warn("rejected node tried to register", zap.String("node_id", req.NodeId))
+		return &pb.RegisterNodeResponse{
+			Success: false,
+			Message: "节点注册已被拒绝",
+			Status:  node.Approval,
 		}, nil
 	}
 
-	s.logger.Info("node registered successfully", zap.String("node_id", req.NodeId))
+	s.logger.Info("node registered successfully",
+		zap.String("node_id", req.NodeId),
+		zap.String("approval", node.Approval))
 
+	message := "节点注册成功"
+	if node.Approval == model.NodeApprovalPending {
+		message = "节点注册成功，等待管理员批准"
+	}
 	return &pb.RegisterNodeResponse{
 		Success: true,
-		Message: "节点注册成功",
+		Message: message,
+		Status:  node.Approval,
 	}, nil
 }
 
+// registrationStatus 根据注册或心跳错误返回响应中的审批状态
+func registrationStatus(err error, fallback string) string {
+	if apiErr, ok := err.(*errors.APIError); ok {
+		switch apiErr.Code {
+		case errors.ErrNodeQuarantined:
+			return "quarantined"
+		case errors.ErrNodeNotApproved:
+			return model.NodeApprovalRejected
+		}
+	}
+	return fallback
+}
+
 // Heartbeat 心跳上报
 func (s *Server) Heartbeat(ctx context.Context, req *pb.HeartbeatRequest) (*pb.HeartbeatResponse, error) {
 	s.logger.Debug("heartbeat received",
@@ -79,7 +122,9 @@ func (s *Server) Heartbeat(ctx context.Context, req *pb.HeartbeatRequest) (*pb.H
 	)
 
 	// 处理心跳
-	if err := s.nodeService.Heartbeat(ctx, req.NodeId); err != nil {
+	_, verified := NodeIDFromContext(ctx)
+	approval, err := s.nodeService.Heartbeat(ctx, req.NodeId, req.Fingerprint, verified)
+	if err != nil {
 		s.logger.Warn("failed to process heartbeat",
 			zap.String("node_id", req.NodeId),
 			zap.Error(err),
@@ -87,41 +132,49 @@ func (s *Server) Heartbeat(ctx context.Context, req *pb.HeartbeatRequest) (*pb.H
 		return &pb.HeartbeatResponse{
 			Success: false,
 			Message: "心跳处理失败: " + err.Error(),
+			Status:  registrationStatus(err, ""),
 		}, nil
 	}
 
+	// 返回审批状态，便于待审批节点得知已被批准
 	return &pb.HeartbeatResponse{
 		Success: true,
 		Message: "心跳已接收",
+		Status:  approval,
 	}, nil
 }
 
 // ReportMetrics 指标上报
+// 迁移期间同时接受旧格式(metrics)和批量格式(batch)，两者可出现在同一请求中
 func (s *Server) ReportMetrics(ctx context.Context, req *pb.ReportMetricsRequest) (*pb.ReportMetricsResponse, error) {
 	s.logger.Debug("metrics report received",
 		zap.String("node_id", req.NodeId),
 		zap.Int("count", len(req.Metrics)),
+		zap.Int("series", len(req.GetBatch().GetSeries())),
 	)
 
 	// 批量创建指标记录
-	var metrics []*model.Metrics
-	for _, m := range req.Metrics {
-		// 转换values map
-		values := make(map[string]interface{})
-		for k, v := range m.Values {
-			values[k] = v
+	metrics := legacyMetricsToModels(req.NodeId, req.Metrics)
+	if batch := req.GetBatch(); batch != nil {
+		if batch.Version > metricsBatchVersion {
+			s.logger.Warn("metrics batch version is newer than supported, unknown fields are ignored",
+				zap.String("node_id", req.NodeId),
+				zap.Uint32("version", batch.Version),
+			)
 		}
-
-		metrics = append(metrics, &model.Metrics{
-			NodeID:    req.NodeId,
-			Type:      m.Type,
-			Timestamp: time.Unix(m.Timestamp, 0),
-			Values:    values,
-		})
+		batchMetrics, skipped := batchToModels(req.NodeId, batch)
+		if skipped > 0 {
+			s.logger.Warn("skipped unrecognized metric points",
+				zap.String("node_id", req.NodeId),
+				zap.Int("skipped", skipped),
+			)
+		}
+		metrics = append(metrics, batchMetrics...)
 	}
 
 	// 批量保存指标
 	if err := s.metricsService.BatchCreate(ctx, metrics); err != nil {
+		telemetry.MetricsIngestErrors.WithLabelValues().Inc()
 		s.logger.Error("failed to save metrics",
 			zap.String("node_id", req.NodeId),
 			zap.Error(err),
@@ -132,6 +185,10 @@ func (s *Server) ReportMetrics(ctx context.Context, req *pb.ReportMetricsRequest
 		}, nil
 	}
 
+	for _, m := range metrics {
+		telemetry.MetricsIngested.WithLabelValues(m.Type).Inc()
+	}
+
 	s.logger.Debug("metrics saved successfully",
 		zap.String("node_id", req.NodeId),
 		zap.Int("count", len(metrics)),
@@ -142,3 +199,54 @@ func (s *Server) ReportMetrics(ctx context.Context, req *pb.ReportMetricsRequest
 		Message: "指标已保存",
 	}, nil
 }
+
+// Enroll 凭加入令牌签发节点客户端证书
+func (s *Server) Enroll(ctx context.Context, req *pb.EnrollRequest) (*pb.EnrollResponse, error) {
+	if s.enrollmentService == nil {
+		return nil, status.Error(codes.Unimplemented, "enrollment is not enabled")
+	}
+
+	s.logger.Info("node enrollment request", zap.String("node_id", req.NodeId))
+
+	issued, err := s.enrollmentService.Enroll(ctx, req.NodeId, req.Token, req.Csr)
+	if err != nil {
+		s.logger.Warn("node enrollment failed",
+			zap.String("node_id", req.NodeId),
+			zap.Error(err),
+		)
+		return nil, apiErrorToStatus(err)
+	}
+
+	return &pb.EnrollResponse{
+		Certificate:   issued.CertPEM,
+		CaCertificate: issued.CAPEM,
+		ExpiresAt:     issued.ExpiresAt.Unix(),
+	}, nil
+}
+
+// RenewCertificate 续期节点客户端证书，节点身份取自当前客户端证书
+func (s *Server) RenewCertificate(ctx context.Context, req *pb.RenewCertificateRequest) (*pb.RenewCertificateResponse, error) {
+	if s.enrollmentService == nil {
+		return nil, status.Error(codes.Unimplemented, "enrollment is not enabled")
+	}
+
+	nodeID, ok := NodeIDFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "client certificate required")
+	}
+
+	issued, err := s.enrollmentService.RenewCertificate(ctx, nodeID, req.Csr)
+	if err != nil {
+		s.logger.Warn("certificate renewal failed",
+			zap.String("node_id", nodeID),
+			zap.Error(err),
+		)
+		return nil, apiErrorToStatus(err)
+	}
+
+	return &pb.RenewCertificateResponse{
+		Certificate:   issued.CertPEM,
+		CaCertificate: issued.CAPEM,
+		ExpiresAt:     issued.ExpiresAt.Unix(),
+	}, nil
+}
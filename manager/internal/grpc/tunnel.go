@@ -0,0 +1,410 @@
+package grpc
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	daemonpb "github.com/bingooyong/ops-scaffold-framework/manager/pkg/proto/daemon"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// 控制流帧类型(与 daemon/internal/grpc/tunnel.go 保持一致)
+const (
+	tunnelFrameHello   = "hello"
+	tunnelFrameRequest = "request"
+	tunnelFrameMessage = "message"
+	tunnelFrameClose   = "close"
+	tunnelFrameCancel  = "cancel"
+)
+
+// 接收循环不能阻塞，调用方消费慢于Daemon发送时响应帧在该调用上排队
+// 只有调用方长时间没有消费(或排队的帧超过上限)时才终止该调用(ResourceExhausted)并通知Daemon取消，
+// 避免一个卡住的调用占用内存，同时不影响偶尔变慢的日志跟踪
+const (
+	// tunnelCallBuffer 每个调用直接交给调用方的响应帧数量
+	tunnelCallBuffer = 16
+	// tunnelCallMaxPending 每个调用在tunnelCallBuffer之外最多排队的响应帧数量
+	tunnelCallMaxPending = 1024
+)
+
+// tunnelCallStallTimeout 有帧排队时调用方超过该时间没有消费即视为卡住
+var tunnelCallStallTimeout = 30 * time.Second
+
+// errTunnelClosed 控制流已断开
+var errTunnelClosed = status.Error(codes.Unavailable, "daemon control stream closed")
+
+// tunnel Daemon主动建立的控制流
+// 实现grpc.ClientConnInterface，DaemonService客户端可以像使用普通连接一样通过控制流调用Daemon，
+// 多个调用通过call_id在同一个流上复用
+type tunnel struct {
+	nodeID  string
+	address string
+	stream  daemonpb.DaemonService_ConnectServer
+	logger  *zap.Logger
+
+	sendMu sync.Mutex // 保护stream.Send
+	nextID uint64
+
+	mu    sync.Mutex
+	calls map[string]*tunnelCall
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// tunnelCall 控制流上的一次调用
+type tunnelCall struct {
+	id         string
+	frames     chan *daemonpb.TunnelFrame
+	done       chan struct{}
+	finishOnce sync.Once
+	closed     atomic.Bool  // 已收到close帧
+	err        atomic.Value // 调用被控制流终止的原因(error)
+
+	pendingMu    sync.Mutex
+	pending      []*daemonpb.TunnelFrame // frames已满时排队的帧，按到达顺序
+	lastProgress time.Time               // 有帧排队以来调用方最近一次取走帧的时间
+}
+
+// newTunnel 创建控制流
+func newTunnel(nodeID, address string, stream daemonpb.DaemonService_ConnectServer, logger *zap.Logger) *tunnel {
+	return &tunnel{
+		nodeID:  nodeID,
+		address: address,
+		stream:  stream,
+		logger:  logger,
+		calls:   make(map[string]*tunnelCall),
+		done:    make(chan struct{}),
+	}
+}
+
+// serve 接收Daemon返回的帧并分发给对应调用，阻塞直到控制流断开或被关闭
+func (t *tunnel) serve() error {
+	defer t.close()
+
+	errCh := make(chan error, 1)
+	go func() {
+		for {
+			frame, err := t.stream.Recv()
+			if err != nil {
+				errCh <- err
+				return
+			}
+			t.dispatch(frame)
+		}
+	}()
+
+	select {
+	case err := <-errCh:
+		if err == io.EOF {
+			return nil
+		}
+		return err
+	case <-t.done:
+		return nil
+	}
+}
+
+// dispatch 将响应帧交给对应调用，调用已结束时丢弃
+func (t *tunnel) dispatch(frame *daemonpb.TunnelFrame) {
+	if frame.Type != tunnelFrameMessage && frame.Type != tunnelFrameClose {
+		t.logger.Warn("unexpected control stream frame",
+			zap.String("node_id", t.nodeID),
+			zap.String("type", frame.Type))
+		return
+	}
+
+	t.mu.Lock()
+	call := t.calls[frame.CallId]
+	t.mu.Unlock()
+	if call == nil {
+		return
+	}
+
+	// 不能阻塞接收循环：调用方消费慢时帧在该调用上排队，卡住时只终止该调用
+	if err := call.enqueue(frame); err != nil {
+		t.logger.Warn("control stream call is not consuming frames, canceling",
+			zap.String("node_id", t.nodeID),
+			zap.String("call_id", call.id),
+			zap.Error(err))
+		call.err.CompareAndSwap(nil, err)
+		// finishCall发送cancel帧，stream.Send可能因流控阻塞，不在接收循环中执行
+		go t.finishCall(call)
+	}
+}
+
+// enqueue 将帧交给调用方，frames已满时排队
+// 排队的帧超过上限或调用方超过tunnelCallStallTimeout没有消费时返回ResourceExhausted
+func (c *tunnelCall) enqueue(frame *daemonpb.TunnelFrame) error {
+	c.pendingMu.Lock()
+	defer c.pendingMu.Unlock()
+
+	if len(c.pending) == 0 {
+		select {
+		case c.frames <- frame:
+			return nil
+		default:
+		}
+		// frames已满，从此刻开始计算调用方没有消费的时间
+		c.lastProgress = time.Now()
+	}
+	if len(c.pending) >= tunnelCallMaxPending {
+		return status.Errorf(codes.ResourceExhausted,
+			"control stream call %s exceeded %d pending frames", c.id, tunnelCallMaxPending)
+	}
+	if stalled := time.Since(c.lastProgress); stalled > tunnelCallStallTimeout {
+		return status.Errorf(codes.ResourceExhausted,
+			"control stream call %s made no progress for %s", c.id, stalled.Truncate(time.Millisecond))
+	}
+	c.pending = append(c.pending, frame)
+	return nil
+}
+
+// refill 调用方取走帧后将排队的帧移入frames
+func (c *tunnelCall) refill() {
+	c.pendingMu.Lock()
+	defer c.pendingMu.Unlock()
+	if len(c.pending) > 0 {
+		c.lastProgress = time.Now()
+	}
+	for len(c.pending) > 0 {
+		select {
+		case c.frames <- c.pending[0]:
+			c.pending[0] = nil
+			c.pending = c.pending[1:]
+		default:
+			return
+		}
+	}
+	c.pending = nil
+}
+
+// close 关闭控制流，所有未完成的调用返回Unavailable
+func (t *tunnel) close() {
+	t.closeOnce.Do(func() {
+		close(t.done)
+	})
+}
+
+// closed 控制流是否已断开
+func (t *tunnel) closed() bool {
+	select {
+	case <-t.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// send 发送帧(stream.Send不能并发调用)
+func (t *tunnel) send(frame *daemonpb.TunnelFrame) error {
+	t.sendMu.Lock()
+	defer t.sendMu.Unlock()
+	if t.closed() {
+		return errTunnelClosed
+	}
+	if err := t.stream.Send(frame); err != nil {
+		t.close()
+		return status.Errorf(codes.Unavailable, "failed to send to daemon control stream: %v", err)
+	}
+	return nil
+}
+
+// startCall 发送请求帧，ctx取消时通知Daemon取消调用
+func (t *tunnel) startCall(ctx context.Context, method string, req interface{}, serverStream bool) (*tunnelCall, error) {
+	msg, ok := req.(proto.Message)
+	if !ok {
+		return nil, status.Errorf(codes.Internal, "unsupported request type %T", req)
+	}
+	payload, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to marshal request: %v", err)
+	}
+
+	call := &tunnelCall{
+		id:     strconv.FormatUint(atomic.AddUint64(&t.nextID, 1), 10),
+		frames: make(chan *daemonpb.TunnelFrame, tunnelCallBuffer),
+		done:   make(chan struct{}),
+	}
+	t.mu.Lock()
+	t.calls[call.id] = call
+	t.mu.Unlock()
+
+	frame := &daemonpb.TunnelFrame{
+		Type:         tunnelFrameRequest,
+		CallId:       call.id,
+		Method:       method,
+		Payload:      payload,
+		ServerStream: serverStream,
+	}
+	if md, ok := metadata.FromOutgoingContext(ctx); ok {
+		frame.Metadata = make(map[string]string, len(md))
+		for key, values := range md {
+			if len(values) > 0 {
+				frame.Metadata[key] = values[0]
+			}
+		}
+	}
+	if err := t.send(frame); err != nil {
+		t.finishCall(call)
+		return nil, err
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			t.finishCall(call)
+		case <-call.done:
+		}
+	}()
+	return call, nil
+}
+
+// finishCall 结束调用，未收到close帧时通知Daemon取消
+func (t *tunnel) finishCall(call *tunnelCall) {
+	call.finishOnce.Do(func() {
+		t.mu.Lock()
+		delete(t.calls, call.id)
+		t.mu.Unlock()
+		close(call.done)
+		if !call.closed.Load() {
+			t.send(&daemonpb.TunnelFrame{Type: tunnelFrameCancel, CallId: call.id})
+		}
+	})
+}
+
+// recv 等待调用的下一个响应帧
+func (t *tunnel) recv(ctx context.Context, call *tunnelCall) (*daemonpb.TunnelFrame, error) {
+	select {
+	case frame := <-call.frames:
+		call.refill()
+		if frame.Type == tunnelFrameClose {
+			call.closed.Store(true)
+		}
+		return frame, nil
+	case <-ctx.Done():
+		return nil, status.FromContextError(ctx.Err()).Err()
+	case <-call.done:
+		if err, ok := call.err.Load().(error); ok {
+			return nil, err
+		}
+		return nil, status.Error(codes.Canceled, "call finished")
+	case <-t.done:
+		return nil, errTunnelClosed
+	}
+}
+
+// Invoke 通过控制流执行一元调用
+func (t *tunnel) Invoke(ctx context.Context, method string, args interface{}, reply interface{}, opts ...grpc.CallOption) error {
+	call, err := t.startCall(ctx, method, args, false)
+	if err != nil {
+		return err
+	}
+	defer t.finishCall(call)
+
+	var payload []byte
+	for {
+		frame, err := t.recv(ctx, call)
+		if err != nil {
+			return err
+		}
+		if frame.Type == tunnelFrameMessage {
+			payload = frame.Payload
+			continue
+		}
+		if code := codes.Code(frame.Code); code != codes.OK {
+			return status.Error(code, frame.Message)
+		}
+		return unmarshalReply(payload, reply)
+	}
+}
+
+// NewStream 通过控制流创建流式调用(只支持服务端流式调用)
+func (t *tunnel) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	if desc.ClientStreams {
+		return nil, status.Errorf(codes.Unimplemented, "client streaming %s is not supported over control stream", method)
+	}
+	return &tunnelStream{tunnel: t, ctx: ctx, method: method}, nil
+}
+
+// unmarshalReply 解析响应消息
+func unmarshalReply(payload []byte, reply interface{}) error {
+	msg, ok := reply.(proto.Message)
+	if !ok {
+		return status.Errorf(codes.Internal, "unsupported response type %T", reply)
+	}
+	if err := proto.Unmarshal(payload, msg); err != nil {
+		return status.Errorf(codes.Internal, "failed to unmarshal response: %v", err)
+	}
+	return nil
+}
+
+// tunnelStream 控制流上的服务端流式调用
+type tunnelStream struct {
+	tunnel *tunnel
+	ctx    context.Context
+	method string
+	call   *tunnelCall
+}
+
+// Header 控制流不转发响应头
+func (s *tunnelStream) Header() (metadata.MD, error) {
+	return metadata.MD{}, nil
+}
+
+// Trailer 控制流不转发响应尾
+func (s *tunnelStream) Trailer() metadata.MD {
+	return metadata.MD{}
+}
+
+// CloseSend 服务端流式调用只发送一个请求，无需处理
+func (s *tunnelStream) CloseSend() error {
+	return nil
+}
+
+// Context 返回调用上下文
+func (s *tunnelStream) Context() context.Context {
+	return s.ctx
+}
+
+// SendMsg 发送请求并开始调用
+func (s *tunnelStream) SendMsg(m interface{}) error {
+	if s.call != nil {
+		return status.Error(codes.Internal, "request already sent")
+	}
+	call, err := s.tunnel.startCall(s.ctx, s.method, m, true)
+	if err != nil {
+		return err
+	}
+	s.call = call
+	return nil
+}
+
+// RecvMsg 接收下一条响应，调用正常结束时返回io.EOF
+func (s *tunnelStream) RecvMsg(m interface{}) error {
+	if s.call == nil {
+		return status.Error(codes.Internal, "request not sent")
+	}
+	frame, err := s.tunnel.recv(s.ctx, s.call)
+	if err != nil {
+		s.tunnel.finishCall(s.call)
+		return err
+	}
+	if frame.Type == tunnelFrameMessage {
+		return unmarshalReply(frame.Payload, m)
+	}
+	s.tunnel.finishCall(s.call)
+	if code := codes.Code(frame.Code); code != codes.OK {
+		return status.Error(code, frame.Message)
+	}
+	return io.EOF
+}
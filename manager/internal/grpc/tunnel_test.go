@@ -0,0 +1,304 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	daemonpb "github.com/bingooyong/ops-scaffold-framework/manager/pkg/proto/daemon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// startTunnelServer 启动接收控制流的Manager gRPC服务器，返回Daemon侧的DaemonService客户端
+func startTunnelServer(t *testing.T, pool *DaemonClientPool) daemonpb.DaemonServiceClient {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := grpc.NewServer()
+	daemonSrv := NewDaemonServer(nil, nil, zap.NewNop())
+	daemonSrv.SetDaemonClientPool(pool)
+	daemonpb.RegisterDaemonServiceServer(server, daemonSrv)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return daemonpb.NewDaemonServiceClient(conn)
+}
+
+// serveFakeDaemon 模拟Daemon处理控制流上的请求
+func serveFakeDaemon(stream daemonpb.DaemonService_ConnectClient, handle func(frame *daemonpb.TunnelFrame) (proto.Message, error)) {
+	for {
+		frame, err := stream.Recv()
+		if err != nil {
+			return
+		}
+		if frame.Type != tunnelFrameRequest {
+			continue
+		}
+		reply, err := handle(frame)
+		if err == nil {
+			payload, _ := proto.Marshal(reply)
+			stream.Send(&daemonpb.TunnelFrame{Type: tunnelFrameMessage, CallId: frame.CallId, Payload: payload})
+		}
+		st := status.Convert(err)
+		stream.Send(&daemonpb.TunnelFrame{Type: tunnelFrameClose, CallId: frame.CallId, Code: int32(st.Code()), Message: st.Message()})
+	}
+}
+
+// waitForTunnel 等待节点的控制流登记到连接池
+func waitForTunnel(t *testing.T, pool *DaemonClientPool, nodeID string, want bool) {
+	t.Helper()
+	require.Eventually(t, func() bool { return pool.HasTunnel(nodeID) == want }, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestDaemonClientPool_Tunnel(t *testing.T) {
+	pool := NewDaemonClientPool(zap.NewNop())
+	defer pool.CloseAll()
+	daemonClient := startTunnelServer(t, pool)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := daemonClient.Connect(ctx)
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&daemonpb.TunnelFrame{Type: tunnelFrameHello, NodeId: "node-nat"}))
+
+	go serveFakeDaemon(stream, func(frame *daemonpb.TunnelFrame) (proto.Message, error) {
+		switch frame.Method {
+		case daemonpb.DaemonService_ListAgents_FullMethodName:
+			return &daemonpb.ListAgentsResponse{Agents: []*daemonpb.AgentInfo{{Id: "agent-1", Status: "running"}}}, nil
+		case daemonpb.DaemonService_OperateAgent_FullMethodName:
+			req := &daemonpb.AgentOperationRequest{}
+			if err := proto.Unmarshal(frame.Payload, req); err != nil {
+				return nil, status.Error(codes.InvalidArgument, err.Error())
+			}
+			if req.AgentId != "agent-1" {
+				return nil, status.Error(codes.NotFound, "agent not found")
+			}
+			return &daemonpb.AgentOperationResponse{Success: true}, nil
+		default:
+			return nil, status.Error(codes.Unimplemented, frame.Method)
+		}
+	})
+	waitForTunnel(t, pool, "node-nat", true)
+
+	// 存在控制流时不拨号Daemon地址
+	client, err := pool.GetClient("node-nat", "127.0.0.1:1")
+	require.NoError(t, err)
+
+	agents, err := client.ListAgents(context.Background(), "node-nat")
+	require.NoError(t, err)
+	require.Len(t, agents, 1)
+	assert.Equal(t, "agent-1", agents[0].Id)
+
+	assert.NoError(t, client.OperateAgent(context.Background(), "node-nat", "agent-1", "restart"))
+
+	// Daemon返回的错误码经控制流透传
+	err = client.OperateAgent(context.Background(), "node-nat", "agent-2", "restart")
+	assert.ErrorIs(t, err, ErrAgentNotFound)
+
+	// 控制流断开后回退为直接拨号
+	cancel()
+	waitForTunnel(t, pool, "node-nat", false)
+	_, err = client.ListAgents(context.Background(), "node-nat")
+	assert.ErrorIs(t, err, ErrConnectionFailed)
+
+	fallback, err := pool.GetClient("node-nat", "127.0.0.1:1")
+	require.NoError(t, err)
+	assert.NotSame(t, client, fallback)
+}
+
+func TestDaemonClientPool_TunnelCallCanceled(t *testing.T) {
+	pool := NewDaemonClientPool(zap.NewNop())
+	defer pool.CloseAll()
+	daemonClient := startTunnelServer(t, pool)
+
+	stream, err := daemonClient.Connect(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&daemonpb.TunnelFrame{Type: tunnelFrameHello, NodeId: "node-slow"}))
+	waitForTunnel(t, pool, "node-slow", true)
+
+	// Daemon不响应请求，调用方超时后应收到cancel帧
+	frames := make(chan *daemonpb.TunnelFrame, 2)
+	go func() {
+		for {
+			frame, err := stream.Recv()
+			if err != nil {
+				return
+			}
+			frames <- frame
+		}
+	}()
+
+	client, err := pool.GetClient("node-slow", "127.0.0.1:1")
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = client.ListAgents(ctx, "node-slow")
+	assert.ErrorIs(t, err, ErrTimeout)
+
+	request := <-frames
+	assert.Equal(t, tunnelFrameRequest, request.Type)
+	select {
+	case frame := <-frames:
+		assert.Equal(t, tunnelFrameCancel, frame.Type)
+		assert.Equal(t, request.CallId, frame.CallId)
+	case <-time.After(5 * time.Second):
+		t.Fatal("cancel frame not received")
+	}
+}
+
+func TestDaemonServer_ConnectRequiresHello(t *testing.T) {
+	pool := NewDaemonClientPool(zap.NewNop())
+	defer pool.CloseAll()
+	daemonClient := startTunnelServer(t, pool)
+
+	stream, err := daemonClient.Connect(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&daemonpb.TunnelFrame{Type: tunnelFrameHello}))
+
+	_, err = stream.Recv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, 0, pool.Count())
+}
+
+// startFollowTunnel 建立模拟Daemon的控制流：FollowAgentLogs按interval推送count条日志后结束(count<=0时一直推送)，
+// ListAgents正常响应，收到的cancel帧写入返回的通道
+func startFollowTunnel(t *testing.T, pool *DaemonClientPool, nodeID string, count int, interval time.Duration) <-chan string {
+	t.Helper()
+	daemonClient := startTunnelServer(t, pool)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	stream, err := daemonClient.Connect(ctx)
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&daemonpb.TunnelFrame{Type: tunnelFrameHello, NodeId: nodeID}))
+
+	var sendMu sync.Mutex
+	send := func(frame *daemonpb.TunnelFrame) error {
+		sendMu.Lock()
+		defer sendMu.Unlock()
+		return stream.Send(frame)
+	}
+	cancels := make(chan string, 1)
+	var canceled sync.Map
+	go func() {
+		for {
+			frame, err := stream.Recv()
+			if err != nil {
+				return
+			}
+			switch {
+			case frame.Type == tunnelFrameCancel:
+				canceled.Store(frame.CallId, true)
+				cancels <- frame.CallId
+			case frame.Method == daemonpb.DaemonService_FollowAgentLogs_FullMethodName:
+				go func(callID string) {
+					payload, _ := proto.Marshal(&daemonpb.FollowAgentLogsResponse{Lines: []string{"line"}})
+					for i := 0; count <= 0 || i < count; i++ {
+						if _, ok := canceled.Load(callID); ok {
+							return
+						}
+						if send(&daemonpb.TunnelFrame{Type: tunnelFrameMessage, CallId: callID, Payload: payload}) != nil {
+							return
+						}
+						if interval > 0 {
+							time.Sleep(interval)
+						}
+					}
+					send(&daemonpb.TunnelFrame{Type: tunnelFrameClose, CallId: callID})
+				}(frame.CallId)
+			case frame.Method == daemonpb.DaemonService_ListAgents_FullMethodName:
+				payload, _ := proto.Marshal(&daemonpb.ListAgentsResponse{Agents: []*daemonpb.AgentInfo{{Id: "agent-1"}}})
+				send(&daemonpb.TunnelFrame{Type: tunnelFrameMessage, CallId: frame.CallId, Payload: payload})
+				send(&daemonpb.TunnelFrame{Type: tunnelFrameClose, CallId: frame.CallId})
+			}
+		}
+	}()
+	waitForTunnel(t, pool, nodeID, true)
+	return cancels
+}
+
+func TestDaemonClientPool_TunnelSlowStream(t *testing.T) {
+	pool := NewDaemonClientPool(zap.NewNop())
+	defer pool.CloseAll()
+	// Daemon一次推送远超tunnelCallBuffer的日志
+	cancels := startFollowTunnel(t, pool, "node-slow", tunnelCallBuffer*8, 0)
+
+	client, err := pool.GetClient("node-slow", "127.0.0.1:1")
+	require.NoError(t, err)
+
+	// 调用方消费较慢但一直在消费，不应被终止
+	var received int
+	err = client.FollowAgentLogs(context.Background(), "node-slow", "agent-1", 0, "", func(lines []string, rotated bool) error {
+		received += len(lines)
+		time.Sleep(2 * time.Millisecond)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, tunnelCallBuffer*8, received)
+	assert.Empty(t, cancels)
+}
+
+func TestDaemonClientPool_TunnelStalledStream(t *testing.T) {
+	oldTimeout := tunnelCallStallTimeout
+	tunnelCallStallTimeout = 200 * time.Millisecond
+	defer func() { tunnelCallStallTimeout = oldTimeout }()
+
+	pool := NewDaemonClientPool(zap.NewNop())
+	defer pool.CloseAll()
+	// Daemon持续推送日志(不等待消费)，同时正常响应一元调用
+	cancels := startFollowTunnel(t, pool, "node-follow", 0, 5*time.Millisecond)
+
+	client, err := pool.GetClient("node-follow", "127.0.0.1:1")
+	require.NoError(t, err)
+
+	// 跟踪日志的调用方卡在第一批日志上
+	stalled := make(chan struct{})
+	release := make(chan struct{})
+	followErr := make(chan error, 1)
+	go func() {
+		var once sync.Once
+		followErr <- client.FollowAgentLogs(context.Background(), "node-follow", "agent-1", 0, "", func(lines []string, rotated bool) error {
+			once.Do(func() { close(stalled) })
+			<-release
+			return nil
+		})
+	}()
+	<-stalled
+
+	// 慢调用不阻塞控制流上的其他调用
+	callCtx, callCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer callCancel()
+	agents, err := client.ListAgents(callCtx, "node-follow")
+	require.NoError(t, err)
+	require.Len(t, agents, 1)
+
+	// 超过tunnelCallStallTimeout没有消费后该调用被取消并通知Daemon
+	select {
+	case <-cancels:
+	case <-time.After(5 * time.Second):
+		t.Fatal("cancel frame not received for stalled call")
+	}
+
+	close(release)
+	select {
+	case err := <-followErr:
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	case <-time.After(5 * time.Second):
+		t.Fatal("stalled follow call did not return")
+	}
+	assert.True(t, pool.HasTunnel("node-follow"))
+}
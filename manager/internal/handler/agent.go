@@ -3,6 +3,7 @@ package handler
 import (
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/bingooyong/ops-scaffold-framework/manager/internal/service"
@@ -28,7 +29,13 @@ func NewAgentHandler(agentService *service.AgentService, logger *zap.Logger) *Ag
 
 // OperateAgentRequest 操作Agent请求
 type OperateAgentRequest struct {
-	Operation string `json:"operation" binding:"required,oneof=start stop restart"`
+	Operation string `json:"operation" binding:"required,oneof=start stop restart reset"`
+}
+
+// ControlAgentRequest Agent控制命令请求
+type ControlAgentRequest struct {
+	Command string            `json:"command" binding:"required,oneof=reload set_log_level dump_diagnostics drain"`
+	Args    map[string]string `json:"args"`
 }
 
 // List 获取节点下的所有Agent
@@ -64,7 +71,7 @@ func (h *AgentHandler) List(c *gin.Context) {
 	})
 }
 
-// Operate 操作Agent(启动/停止/重启)
+// Operate 操作Agent(启动/停止/重启/复位熔断)
 // POST /api/v1/nodes/:node_id/agents/:agent_id/operate
 func (h *AgentHandler) Operate(c *gin.Context) {
 	nodeID := c.Param("node_id")
@@ -112,8 +119,41 @@ func (h *AgentHandler) Operate(c *gin.Context) {
 	})
 }
 
-// GetLogs 获取Agent日志
-// GET /api/v1/nodes/:node_id/agents/:agent_id/logs?lines=100
+// Control 向Agent下发控制命令(重载配置/调整日志级别/导出诊断信息/排空)
+// POST /api/v1/nodes/:node_id/agents/:agent_id/control
+func (h *AgentHandler) Control(c *gin.Context) {
+	nodeID := c.Param("node_id")
+	agentID := c.Param("agent_id")
+	if !validateAndRespond(c, nodeID, agentID) {
+		return
+	}
+
+	var req ControlAgentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "请求参数错误: "+err.Error())
+		return
+	}
+
+	result, err := h.agentService.ControlAgent(c.Request.Context(), nodeID, agentID, req.Command, req.Args)
+	if err != nil {
+		if apiErr, ok := err.(*errors.APIError); ok {
+			response.Error(c, apiErr)
+		} else {
+			h.logger.Error("control agent failed",
+				zap.String("node_id", nodeID),
+				zap.String("agent_id", agentID),
+				zap.String("command", req.Command),
+				zap.Error(err))
+			response.InternalServerError(c, "操作失败，请稍后重试")
+		}
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// GetLogs 获取Agent日志末尾
+// GET /api/v1/nodes/:node_id/agents/:agent_id/logs?lines=100&keyword=error
 func (h *AgentHandler) GetLogs(c *gin.Context) {
 	nodeID := c.Param("node_id")
 	agentID := c.Param("agent_id")
@@ -136,16 +176,13 @@ func (h *AgentHandler) GetLogs(c *gin.Context) {
 		lines = parsedLines
 	}
 
+	keyword := c.Query("keyword")
+
 	// 调用Service层
-	logs, err := h.agentService.GetAgentLogs(c.Request.Context(), nodeID, agentID, lines)
+	logs, err := h.agentService.GetAgentLogs(c.Request.Context(), nodeID, agentID, lines, keyword)
 	if err != nil {
 		if apiErr, ok := err.(*errors.APIError); ok {
-			// 检查是否是功能未实现错误
-			if apiErr.Code == errors.ErrInternalServer {
-				response.ErrorWithMessage(c, errors.ErrInternalServer, "获取日志功能暂未实现")
-			} else {
-				response.Error(c, apiErr)
-			}
+			response.Error(c, apiErr)
 		} else {
 			// 避免泄露内部错误信息
 			h.logger.Error("get agent logs failed",
@@ -169,6 +206,157 @@ func (h *AgentHandler) GetLogs(c *gin.Context) {
 	})
 }
 
+// FollowLogs 跟踪Agent日志，通过SSE持续推送新增日志行
+// GET /api/v1/nodes/:node_id/agents/:agent_id/logs/follow?tail=100&keyword=error
+// 每批日志以"log"事件发送: {"lines": [...], "rotated": false}，rotated表示日志文件发生了轮转或截断
+func (h *AgentHandler) FollowLogs(c *gin.Context) {
+	nodeID := c.Param("node_id")
+	agentID := c.Param("agent_id")
+	if !validateAndRespond(c, nodeID, agentID) {
+		return
+	}
+
+	tailLines := 100 // 默认先返回最后100行
+	if tailStr := c.Query("tail"); tailStr != "" {
+		parsed, err := strconv.Atoi(tailStr)
+		if err != nil || parsed < 0 {
+			response.BadRequest(c, "无效的行数参数")
+			return
+		}
+		tailLines = parsed
+	}
+	keyword := c.Query("keyword")
+
+	// 首批数据到达前不写响应头，连接Daemon失败时仍可返回普通JSON错误
+	started := false
+	err := h.agentService.FollowAgentLogs(c.Request.Context(), nodeID, agentID, tailLines, keyword,
+		func(lines []string, rotated bool) error {
+			if !started {
+				c.Header("Content-Type", "text/event-stream")
+				c.Header("Cache-Control", "no-cache")
+				c.Header("Connection", "keep-alive")
+				c.Header("X-Accel-Buffering", "no")
+				started = true
+			}
+			if lines == nil {
+				lines = []string{}
+			}
+			c.SSEvent("log", gin.H{
+				"lines":   lines,
+				"rotated": rotated,
+			})
+			c.Writer.Flush()
+			return c.Request.Context().Err()
+		})
+	if err != nil {
+		if started {
+			// 流已开始，只能通过事件告知错误
+			c.SSEvent("error", gin.H{"message": "日志跟踪中断"})
+			c.Writer.Flush()
+			h.logger.Warn("follow agent logs interrupted",
+				zap.String("node_id", nodeID),
+				zap.String("agent_id", agentID),
+				zap.Error(err))
+			return
+		}
+		if apiErr, ok := err.(*errors.APIError); ok {
+			response.Error(c, apiErr)
+		} else {
+			h.logger.Error("follow agent logs failed",
+				zap.String("node_id", nodeID),
+				zap.String("agent_id", agentID),
+				zap.Error(err))
+			response.InternalServerError(c, "跟踪日志失败，请稍后重试")
+		}
+		return
+	}
+
+	h.logger.Debug("follow agent logs finished",
+		zap.String("node_id", nodeID),
+		zap.String("agent_id", agentID))
+}
+
+// SearchLogs 跨节点搜索Agent日志
+// GET /api/v1/logs/search?keyword=connection+refused&agent_type=filebeat&since=1h&labels=env=prod&limit=100
+// since/until 支持RFC3339时间或相对当前的时长(如1h、30m)；regex=true时keyword为正则表达式；
+// node_ids(逗号分隔)指定节点，否则按labels(k=v，逗号分隔)选择节点；timeout为单个节点的超时秒数
+func (h *AgentHandler) SearchLogs(c *gin.Context) {
+	keyword := c.Query("keyword")
+	if keyword == "" {
+		response.BadRequest(c, "keyword不能为空")
+		return
+	}
+
+	req := &service.LogSearchRequest{
+		Keyword:   keyword,
+		Regex:     c.Query("regex") == "true",
+		AgentType: c.Query("agent_type"),
+		Limit:     parseIntQuery(c, "limit", 100),
+	}
+
+	now := time.Now()
+	var err error
+	if req.Since, err = parseSearchTime(c.Query("since"), now); err != nil {
+		response.BadRequest(c, "无效的since参数")
+		return
+	}
+	if req.Until, err = parseSearchTime(c.Query("until"), now); err != nil {
+		response.BadRequest(c, "无效的until参数")
+		return
+	}
+
+	if nodeIDs := c.Query("node_ids"); nodeIDs != "" {
+		for _, nodeID := range strings.Split(nodeIDs, ",") {
+			if nodeID = strings.TrimSpace(nodeID); nodeID != "" {
+				req.NodeIDs = append(req.NodeIDs, nodeID)
+			}
+		}
+	}
+	if labels := c.Query("labels"); labels != "" {
+		req.Labels = make(map[string]string)
+		for _, pair := range strings.Split(labels, ",") {
+			key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if !ok || key == "" {
+				response.BadRequest(c, "无效的labels参数，格式为key=value,key2=value2")
+				return
+			}
+			req.Labels[key] = value
+		}
+	}
+	if timeout := parseIntQuery(c, "timeout", 0); timeout > 0 {
+		req.NodeTimeout = time.Duration(timeout) * time.Second
+	}
+
+	result, err := h.agentService.SearchLogs(c.Request.Context(), req)
+	if err != nil {
+		if apiErr, ok := err.(*errors.APIError); ok {
+			response.Error(c, apiErr)
+		} else {
+			h.logger.Error("search logs failed",
+				zap.String("keyword", keyword),
+				zap.Error(err))
+			response.InternalServerError(c, "搜索日志失败，请稍后重试")
+		}
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// parseSearchTime 解析时间参数: RFC3339时间，或相对now之前的时长(如1h)；为空时返回零值
+func parseSearchTime(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		if d < 0 {
+			return time.Time{}, fmt.Errorf("duration must be positive: %s", value)
+		}
+		return now.Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
 // GetMetrics 获取Agent资源使用指标
 // GET /api/v1/nodes/:node_id/agents/:agent_id/metrics?duration=3600
 func (h *AgentHandler) GetMetrics(c *gin.Context) {
@@ -228,6 +416,125 @@ func (h *AgentHandler) GetMetrics(c *gin.Context) {
 	response.Success(c, responseData)
 }
 
+// GetEvents 获取Agent生命周期事件(熔断触发/复位等)
+// GET /api/v1/nodes/:node_id/agents/:agent_id/events?limit=50
+func (h *AgentHandler) GetEvents(c *gin.Context) {
+	nodeID := c.Param("node_id")
+	agentID := c.Param("agent_id")
+	if !validateAndRespond(c, nodeID, agentID) {
+		return
+	}
+
+	limit := parseIntQuery(c, "limit", 50)
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+
+	events, err := h.agentService.ListAgentEvents(c.Request.Context(), nodeID, agentID, limit)
+	if err != nil {
+		if apiErr, ok := err.(*errors.APIError); ok {
+			response.Error(c, apiErr)
+		} else {
+			h.logger.Error("get agent events failed",
+				zap.String("node_id", nodeID),
+				zap.String("agent_id", agentID),
+				zap.Error(err))
+			response.InternalServerError(c, "获取Agent事件失败，请稍后重试")
+		}
+		return
+	}
+
+	response.Success(c, gin.H{
+		"events": events,
+		"count":  len(events),
+	})
+}
+
+// GetCrashes 获取Agent崩溃记录(退出码、信号、运行时长及退出前日志)
+// GET /api/v1/nodes/:node_id/agents/:agent_id/crashes?limit=20
+func (h *AgentHandler) GetCrashes(c *gin.Context) {
+	nodeID := c.Param("node_id")
+	agentID := c.Param("agent_id")
+	if !validateAndRespond(c, nodeID, agentID) {
+		return
+	}
+
+	limit := parseIntQuery(c, "limit", 20)
+	if limit <= 0 || limit > 200 {
+		limit = 20
+	}
+
+	crashes, err := h.agentService.ListAgentCrashes(c.Request.Context(), nodeID, agentID, limit)
+	if err != nil {
+		if apiErr, ok := err.(*errors.APIError); ok {
+			response.Error(c, apiErr)
+		} else {
+			h.logger.Error("get agent crashes failed",
+				zap.String("node_id", nodeID),
+				zap.String("agent_id", agentID),
+				zap.Error(err))
+			response.InternalServerError(c, "获取Agent崩溃记录失败，请稍后重试")
+		}
+		return
+	}
+
+	response.Success(c, gin.H{
+		"crashes": crashes,
+		"count":   len(crashes),
+	})
+}
+
+// GetAlerts 获取Agent资源告警记录
+// GET /api/v1/nodes/:node_id/agents/:agent_id/alerts?type=cpu&limit=20
+func (h *AgentHandler) GetAlerts(c *gin.Context) {
+	nodeID := c.Param("node_id")
+	agentID := c.Param("agent_id")
+	if !validateAndRespond(c, nodeID, agentID) {
+		return
+	}
+
+	h.listAlerts(c, nodeID, agentID)
+}
+
+// ListAlerts 获取所有节点的Agent资源告警记录
+// GET /api/v1/alerts?node_id=xxx&agent_id=xxx&type=memory&limit=50
+func (h *AgentHandler) ListAlerts(c *gin.Context) {
+	h.listAlerts(c, c.Query("node_id"), c.Query("agent_id"))
+}
+
+// listAlerts 按条件查询资源告警并返回
+func (h *AgentHandler) listAlerts(c *gin.Context, nodeID, agentID string) {
+	alertType := c.Query("type")
+	if alertType != "" && alertType != "cpu" && alertType != "memory" && alertType != "open_files" {
+		response.BadRequest(c, "无效的type参数，可选值: cpu, memory, open_files")
+		return
+	}
+
+	limit := parseIntQuery(c, "limit", 20)
+	if limit <= 0 || limit > 200 {
+		limit = 20
+	}
+
+	alerts, err := h.agentService.ListResourceAlerts(c.Request.Context(), nodeID, agentID, alertType, limit)
+	if err != nil {
+		if apiErr, ok := err.(*errors.APIError); ok {
+			response.Error(c, apiErr)
+		} else {
+			h.logger.Error("list resource alerts failed",
+				zap.String("node_id", nodeID),
+				zap.String("agent_id", agentID),
+				zap.Error(err))
+			response.InternalServerError(c, "获取资源告警记录失败，请稍后重试")
+		}
+		return
+	}
+
+	response.Success(c, gin.H{
+		"alerts": alerts,
+		"count":  len(alerts),
+	})
+}
+
 // Sync 手动同步节点下所有Agent的状态
 // POST /api/v1/nodes/:node_id/agents/sync
 // 此接口用于前端手动触发同步，从Daemon获取最新的Agent状态并更新数据库
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/bingooyong/ops-scaffold-framework/manager/internal/middleware"
+	"github.com/bingooyong/ops-scaffold-framework/manager/internal/service"
+	"github.com/bingooyong/ops-scaffold-framework/manager/pkg/errors"
+	"github.com/bingooyong/ops-scaffold-framework/manager/pkg/response"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// EnrollmentHandler 节点加入令牌处理器
+type EnrollmentHandler struct {
+	enrollmentService service.EnrollmentService
+	logger            *zap.Logger
+}
+
+// NewEnrollmentHandler 创建节点加入令牌处理器实例
+func NewEnrollmentHandler(enrollmentService service.EnrollmentService, logger *zap.Logger) *EnrollmentHandler {
+	return &EnrollmentHandler{
+		enrollmentService: enrollmentService,
+		logger:            logger,
+	}
+}
+
+// CreateJoinTokenRequest 创建加入令牌请求
+type CreateJoinTokenRequest struct {
+	Description string `json:"description" binding:"max=200"`
+	NodeID      string `json:"node_id" binding:"max=64"`
+	TTL         string `json:"ttl"` // 如 "1h"，为空使用默认有效期
+	MaxUses     int    `json:"max_uses"`
+}
+
+// CreateJoinToken 创建加入令牌，令牌明文只在响应中返回一次
+func (h *EnrollmentHandler) CreateJoinToken(c *gin.Context) {
+	var req CreateJoinTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "参数错误: "+err.Error())
+		return
+	}
+
+	var ttl time.Duration
+	if req.TTL != "" {
+		parsed, err := time.ParseDuration(req.TTL)
+		if err != nil || parsed <= 0 {
+			response.BadRequest(c, "无效的有效期: "+req.TTL)
+			return
+		}
+		ttl = parsed
+	}
+
+	username, _ := middleware.GetUsername(c)
+	result, err := h.enrollmentService.CreateJoinToken(c.Request.Context(), &service.CreateJoinTokenRequest{
+		Description: req.Description,
+		NodeID:      req.NodeID,
+		TTL:         ttl,
+		MaxUses:     req.MaxUses,
+	}, username)
+	if err != nil {
+		if apiErr, ok := err.(*errors.APIError); ok {
+			response.Error(c, apiErr)
+		} else {
+			response.InternalServerError(c, err.Error())
+		}
+		return
+	}
+
+	response.Created(c, result)
+}
+
+// ListJoinTokens 获取加入令牌列表
+func (h *EnrollmentHandler) ListJoinTokens(c *gin.Context) {
+	tokens, err := h.enrollmentService.ListJoinTokens(c.Request.Context())
+	if err != nil {
+		if apiErr, ok := err.(*errors.APIError); ok {
+			response.Error(c, apiErr)
+		} else {
+			response.InternalServerError(c, err.Error())
+		}
+		return
+	}
+
+	response.Success(c, gin.H{
+		"join_tokens":  tokens,
+		"ca_cert_hash": h.enrollmentService.CACertHash(),
+	})
+}
+
+// DeleteJoinToken 吊销加入令牌
+func (h *EnrollmentHandler) DeleteJoinToken(c *gin.Context) {
+	id := parseUintParam(c, "id")
+	if id == 0 {
+		response.BadRequest(c, "无效的令牌ID")
+		return
+	}
+
+	if err := h.enrollmentService.DeleteJoinToken(c.Request.Context(), id); err != nil {
+		if apiErr, ok := err.(*errors.APIError); ok {
+			response.Error(c, apiErr)
+		} else {
+			response.InternalServerError(c, err.Error())
+		}
+		return
+	}
+
+	response.Success(c, gin.H{
+		"message": "加入令牌已吊销",
+	})
+}
@@ -190,6 +190,92 @@ func (h *MetricsHandler) GetMetricsSummary(c *gin.Context) {
 	response.Success(c, summary)
 }
 
+// GetAgentCustomMetricsLatest 获取Agent最新的自定义指标
+// GET /api/v1/metrics/nodes/:node_id/agents/:agent_id/custom/latest
+func (h *MetricsHandler) GetAgentCustomMetricsLatest(c *gin.Context) {
+	nodeID := c.Param("node_id")
+	agentID := c.Param("agent_id")
+	if nodeID == "" || agentID == "" {
+		response.BadRequest(c, "节点ID和AgentID不能为空")
+		return
+	}
+
+	metric, err := h.metricsService.GetLatestAgentMetrics(c.Request.Context(), nodeID, agentID)
+	if err != nil {
+		if apiErr, ok := err.(*errors.APIError); ok {
+			response.Error(c, apiErr)
+		} else {
+			response.InternalServerError(c, err.Error())
+		}
+		return
+	}
+
+	// 尚未上报过自定义指标时返回 null
+	response.Success(c, metric)
+}
+
+// GetAgentCustomMetricsHistory 获取Agent自定义指标历史
+// GET /api/v1/metrics/nodes/:node_id/agents/:agent_id/custom/history
+func (h *MetricsHandler) GetAgentCustomMetricsHistory(c *gin.Context) {
+	nodeID := c.Param("node_id")
+	agentID := c.Param("agent_id")
+	if nodeID == "" || agentID == "" {
+		response.BadRequest(c, "节点ID和AgentID不能为空")
+		return
+	}
+
+	// 解析可选的时间参数，默认最近 1 小时
+	now := time.Now()
+	startTime := now.Add(-time.Hour)
+	endTime := now
+
+	if startTimeStr := c.Query("start_time"); startTimeStr != "" {
+		parsed, err := time.Parse(time.RFC3339, startTimeStr)
+		if err != nil {
+			response.BadRequest(c, "start_time 格式错误，请使用 ISO8601 格式 (RFC3339)")
+			return
+		}
+		startTime = parsed
+	}
+
+	if endTimeStr := c.Query("end_time"); endTimeStr != "" {
+		parsed, err := time.Parse(time.RFC3339, endTimeStr)
+		if err != nil {
+			response.BadRequest(c, "end_time 格式错误，请使用 ISO8601 格式 (RFC3339)")
+			return
+		}
+		endTime = parsed
+	}
+
+	// 验证时间范围不超过 30 天
+	if endTime.Sub(startTime) > 30*24*time.Hour {
+		response.BadRequest(c, "时间范围不能超过 30 天")
+		return
+	}
+
+	// 验证时间顺序
+	if endTime.Before(startTime) {
+		response.BadRequest(c, "end_time 必须大于 start_time")
+		return
+	}
+
+	metrics, err := h.metricsService.GetAgentMetricsHistory(c.Request.Context(), nodeID, agentID, startTime, endTime)
+	if err != nil {
+		if apiErr, ok := err.(*errors.APIError); ok {
+			response.Error(c, apiErr)
+		} else {
+			response.InternalServerError(c, err.Error())
+		}
+		return
+	}
+
+	if metrics == nil {
+		metrics = make([]*model.AgentMetric, 0)
+	}
+
+	response.Success(c, metrics)
+}
+
 // GetClusterOverview 获取集群资源概览
 // GET /api/v1/metrics/cluster/overview
 func (h *MetricsHandler) GetClusterOverview(c *gin.Context) {
@@ -1,6 +1,9 @@
 package handler
 
 import (
+	"context"
+
+	"github.com/bingooyong/ops-scaffold-framework/manager/internal/middleware"
 	"github.com/bingooyong/ops-scaffold-framework/manager/internal/model"
 	"github.com/bingooyong/ops-scaffold-framework/manager/internal/service"
 	"github.com/bingooyong/ops-scaffold-framework/manager/pkg/errors"
@@ -38,6 +41,7 @@ func (h *NodeHandler) List(c *gin.Context) {
 	page := parseIntQuery(c, "page", 1)
 	pageSize := parseIntQuery(c, "page_size", 20)
 	status := parseStringQuery(c, "status", "")
+	approval := parseStringQuery(c, "approval", "")
 
 	if page < 1 {
 		page = 1
@@ -52,6 +56,8 @@ func (h *NodeHandler) List(c *gin.Context) {
 
 	if status != "" {
 		nodes, total, err = h.nodeService.ListByStatus(c.Request.Context(), status, page, pageSize)
+	} else if approval != "" {
+		nodes, total, err = h.nodeService.ListByApproval(c.Request.Context(), approval, page, pageSize)
 	} else {
 		nodes, total, err = h.nodeService.List(c.Request.Context(), page, pageSize)
 	}
@@ -158,3 +164,111 @@ func (h *NodeHandler) GetStatistics(c *gin.Context) {
 		"statistics": statistics,
 	})
 }
+
+// Approve 批准节点
+func (h *NodeHandler) Approve(c *gin.Context) {
+	h.setApproval(c, h.nodeService.Approve, "节点已批准")
+}
+
+// Reject 拒绝节点
+func (h *NodeHandler) Reject(c *gin.Context) {
+	h.setApproval(c, h.nodeService.Reject, "节点已拒绝")
+}
+
+// setApproval 更新节点审批状态
+func (h *NodeHandler) setApproval(c *gin.Context, update func(ctx context.Context, id uint) (*model.Node, error), message string) {
+	id := parseUintParam(c, "id")
+	if id == 0 {
+		response.BadRequest(c, "无效的节点ID")
+		return
+	}
+
+	node, err := update(c.Request.Context(), id)
+	if err != nil {
+		if apiErr, ok := err.(*errors.APIError); ok {
+			response.Error(c, apiErr)
+		} else {
+			response.InternalServerError(c, err.Error())
+		}
+		return
+	}
+
+	username, _ := middleware.GetUsername(c)
+	h.logger.Info("node approval changed",
+		zap.String("node_id", node.NodeID),
+		zap.String("approval", node.Approval),
+		zap.String("operator", username))
+
+	response.Success(c, gin.H{
+		"message": message,
+		"node":    node,
+	})
+}
+
+// ListQuarantine 获取被隔离的节点注册列表
+func (h *NodeHandler) ListQuarantine(c *gin.Context) {
+	entries, err := h.nodeService.ListQuarantine(c.Request.Context())
+	if err != nil {
+		if apiErr, ok := err.(*errors.APIError); ok {
+			response.Error(c, apiErr)
+		} else {
+			response.InternalServerError(c, err.Error())
+		}
+		return
+	}
+
+	response.Success(c, gin.H{
+		"quarantine": entries,
+	})
+}
+
+// AcceptQuarantine 接受被隔离的主机作为节点的新主机
+func (h *NodeHandler) AcceptQuarantine(c *gin.Context) {
+	id := parseUintParam(c, "id")
+	if id == 0 {
+		response.BadRequest(c, "无效的隔离记录ID")
+		return
+	}
+
+	node, err := h.nodeService.AcceptQuarantine(c.Request.Context(), id)
+	if err != nil {
+		if apiErr, ok := err.(*errors.APIError); ok {
+			response.Error(c, apiErr)
+		} else {
+			response.InternalServerError(c, err.Error())
+		}
+		return
+	}
+
+	username, _ := middleware.GetUsername(c)
+	h.logger.Info("quarantined host accepted",
+		zap.String("node_id", node.NodeID),
+		zap.String("operator", username))
+
+	response.Success(c, gin.H{
+		"message": "已接受隔离主机",
+		"node":    node,
+	})
+}
+
+// DismissQuarantine 忽略隔离记录
+func (h *NodeHandler) DismissQuarantine(c *gin.Context) {
+	id := parseUintParam(c, "id")
+	if id == 0 {
+		response.BadRequest(c, "无效的隔离记录ID")
+		return
+	}
+
+	if err := h.nodeService.DismissQuarantine(c.Request.Context(), id); err != nil {
+		if apiErr, ok := err.(*errors.APIError); ok {
+			response.Error(c, apiErr)
+		} else {
+			response.InternalServerError(c, err.Error())
+		}
+		return
+	}
+
+	response.Success(c, gin.H{
+		"message": "隔离记录已删除",
+	})
+}
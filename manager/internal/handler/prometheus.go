@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bingooyong/ops-scaffold-framework/manager/internal/service"
+	"github.com/bingooyong/ops-scaffold-framework/manager/internal/telemetry"
+	"github.com/bingooyong/ops-scaffold-framework/manager/pkg/metrics"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// statisticsTimeout 抓取指标时查询统计信息的超时时间
+const statisticsTimeout = 5 * time.Second
+
+// DaemonPoolCounter Daemon连接池连接数查询接口
+type DaemonPoolCounter interface {
+	Count() int
+}
+
+// PrometheusHandler Prometheus指标处理器
+type PrometheusHandler struct {
+	nodeService  service.NodeService
+	agentService *service.AgentService
+	daemonPool   DaemonPoolCounter
+	db           *gorm.DB
+	token        string
+	logger       *zap.Logger
+}
+
+// NewPrometheusHandler 创建Prometheus指标处理器，token为空时不校验访问令牌
+func NewPrometheusHandler(nodeService service.NodeService, agentService *service.AgentService, daemonPool DaemonPoolCounter, db *gorm.DB, token string, logger *zap.Logger) *PrometheusHandler {
+	return &PrometheusHandler{
+		nodeService:  nodeService,
+		agentService: agentService,
+		daemonPool:   daemonPool,
+		db:           db,
+		token:        token,
+		logger:       logger,
+	}
+}
+
+// Metrics 以Prometheus文本格式输出Manager指标
+// GET /metrics
+func (h *PrometheusHandler) Metrics(c *gin.Context) {
+	if h.token != "" && !h.authorized(c.GetHeader("Authorization")) {
+		c.Header("WWW-Authenticate", `Bearer realm="metrics"`)
+		c.String(http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var buf bytes.Buffer
+	pw := metrics.NewWriter(&buf)
+
+	telemetry.Write(pw)
+	h.writeDaemonPoolMetrics(pw)
+	h.writeDBMetrics(pw)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), statisticsTimeout)
+	defer cancel()
+	h.writeFleetMetrics(ctx, pw)
+
+	c.Data(http.StatusOK, metrics.ContentType, buf.Bytes())
+}
+
+// authorized 校验 Authorization: Bearer <token>
+func (h *PrometheusHandler) authorized(header string) bool {
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) == 1
+}
+
+// writeDaemonPoolMetrics 写入Daemon连接池指标
+func (h *PrometheusHandler) writeDaemonPoolMetrics(pw *metrics.Writer) {
+	if h.daemonPool == nil {
+		return
+	}
+	pw.Gauge("manager_daemon_pool_connections", "Number of daemon client connections held in the pool.", float64(h.daemonPool.Count()))
+}
+
+// writeDBMetrics 写入数据库连接池指标
+func (h *PrometheusHandler) writeDBMetrics(pw *metrics.Writer) {
+	if h.db == nil {
+		return
+	}
+	sqlDB, err := h.db.DB()
+	if err != nil {
+		h.logger.Warn("failed to get sql db for metrics", zap.Error(err))
+		return
+	}
+
+	stats := sqlDB.Stats()
+	pw.Gauge("manager_db_max_open_connections", "Maximum number of open connections to the database.", float64(stats.MaxOpenConnections))
+	pw.Gauge("manager_db_open_connections", "Number of established database connections, both in use and idle.", float64(stats.OpenConnections))
+	pw.Gauge("manager_db_in_use_connections", "Number of database connections currently in use.", float64(stats.InUse))
+	pw.Gauge("manager_db_idle_connections", "Number of idle database connections.", float64(stats.Idle))
+	pw.Counter("manager_db_wait_count_total", "Total number of connections waited for.", float64(stats.WaitCount))
+	pw.Counter("manager_db_wait_duration_seconds_total", "Total time blocked waiting for a new connection.", stats.WaitDuration.Seconds())
+	pw.Counter("manager_db_max_idle_closed_total", "Total connections closed due to max idle connections.", float64(stats.MaxIdleClosed))
+	pw.Counter("manager_db_max_lifetime_closed_total", "Total connections closed due to max connection lifetime.", float64(stats.MaxLifetimeClosed))
+}
+
+// writeFleetMetrics 写入节点和Agent的按状态数量
+func (h *PrometheusHandler) writeFleetMetrics(ctx context.Context, pw *metrics.Writer) {
+	if h.nodeService != nil {
+		stats, err := h.nodeService.GetStatistics(ctx)
+		if err != nil {
+			h.logger.Warn("failed to get node statistics for metrics", zap.Error(err))
+		} else {
+			// 保证online/offline始终输出，便于告警规则引用
+			for _, status := range []string{"online", "offline"} {
+				if _, ok := stats[status]; !ok {
+					stats[status] = 0
+				}
+			}
+			writeStatusGauge(pw, "manager_nodes", "Number of registered nodes by status.", stats)
+		}
+	}
+
+	if h.agentService != nil {
+		stats, err := h.agentService.GetStatistics(ctx)
+		if err != nil {
+			h.logger.Warn("failed to get agent statistics for metrics", zap.Error(err))
+		} else {
+			writeStatusGauge(pw, "manager_agents", "Number of agents by status.", stats)
+		}
+	}
+}
+
+// writeStatusGauge 写入按status标签区分的gauge
+func writeStatusGauge(pw *metrics.Writer, name, help string, stats map[string]int64) {
+	statuses := make([]string, 0, len(stats))
+	for status := range stats {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+
+	pw.Header(name, help, metrics.TypeGauge)
+	for _, status := range statuses {
+		pw.Sample(name, float64(stats[status]), "status", status)
+	}
+}
@@ -1,8 +1,10 @@
 package middleware
 
 import (
+	"strconv"
 	"time"
 
+	"github.com/bingooyong/ops-scaffold-framework/manager/internal/telemetry"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
@@ -20,6 +22,15 @@ func Logger(logger *zap.Logger) gin.HandlerFunc {
 		// 计算耗时
 		cost := time.Since(start)
 
+		// 记录请求耗时指标(按路由模板聚合，避免路径参数导致标签基数膨胀)
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		telemetry.HTTPRequestDuration.
+			WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).
+			Observe(cost.Seconds())
+
 		// 记录日志
 		logger.Info("HTTP Request",
 			zap.Int("status", c.Writer.Status()),
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"time"
+)
+
+// AgentResourceAlert Agent资源告警模型
+// Daemon检测到Agent资源(CPU/内存/打开文件数)持续超过阈值时上报
+type AgentResourceAlert struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// NodeID 节点ID
+	NodeID string `gorm:"index:idx_alert_node_agent;size:50;not null" json:"node_id"`
+
+	// AgentID Agent唯一标识符
+	AgentID string `gorm:"index:idx_alert_node_agent;size:100;not null" json:"agent_id"`
+
+	// Type 资源类型: cpu, memory, open_files
+	Type string `gorm:"index;size:20;not null" json:"type"`
+
+	// Value 告警时的资源使用值
+	Value float64 `json:"value"`
+
+	// Threshold 配置的阈值
+	Threshold float64 `json:"threshold"`
+
+	// DurationMs 超过阈值的持续时长(毫秒)
+	DurationMs int64 `json:"duration_ms"`
+
+	// Action Daemon执行的动作: none, restart, stop
+	Action string `gorm:"size:20" json:"action"`
+
+	// ActionError 动作执行失败的原因(成功时为空)
+	ActionError string `gorm:"type:text" json:"action_error"`
+
+	// TriggeredAt 告警触发时间
+	TriggeredAt time.Time `gorm:"index;not null" json:"triggered_at"`
+}
+
+// TableName 指定表名
+func (AgentResourceAlert) TableName() string {
+	return "agent_resource_alerts"
+}
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"time"
+)
+
+// AgentCrash Agent崩溃记录模型
+type AgentCrash struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// NodeID 节点ID
+	NodeID string `gorm:"index:idx_crash_node_agent;size:50;not null" json:"node_id"`
+
+	// AgentID Agent唯一标识符
+	AgentID string `gorm:"index:idx_crash_node_agent;size:100;not null" json:"agent_id"`
+
+	// ExitCode 退出码(被信号终止时为-1)
+	ExitCode int `gorm:"not null" json:"exit_code"`
+
+	// Signal 终止信号(正常退出时为空)
+	Signal string `gorm:"size:50" json:"signal"`
+
+	// StartedAt 本次运行启动时间
+	StartedAt time.Time `json:"started_at"`
+
+	// ExitedAt 退出时间
+	ExitedAt time.Time `gorm:"index;not null" json:"exited_at"`
+
+	// DurationMs 运行时长(毫秒)
+	DurationMs int64 `json:"duration_ms"`
+
+	// LastLogLines 退出时日志的最后N行
+	LastLogLines []string `gorm:"serializer:json;type:text" json:"last_log_lines"`
+
+	// CircuitOpen 本次崩溃是否触发熔断
+	CircuitOpen bool `gorm:"default:false" json:"circuit_open"`
+}
+
+// TableName 指定表名
+func (AgentCrash) TableName() string {
+	return "agent_crashes"
+}
@@ -0,0 +1,34 @@
+package model
+
+import (
+	"time"
+)
+
+// AgentEvent Agent生命周期事件模型(熔断触发/熔断复位等)
+type AgentEvent struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// NodeID 节点ID
+	NodeID string `gorm:"index:idx_event_node_agent;size:50;not null" json:"node_id"`
+
+	// AgentID Agent唯一标识符
+	AgentID string `gorm:"index:idx_event_node_agent;size:100;not null" json:"agent_id"`
+
+	// Type 事件类型(circuit_open/circuit_reset)
+	Type string `gorm:"index;size:50;not null" json:"type"`
+
+	// Message 事件描述
+	Message string `gorm:"type:text" json:"message"`
+
+	// Details 事件详情
+	Details JSONMap `gorm:"type:json" json:"details"`
+
+	// Timestamp 事件发生时间(Daemon侧时间)
+	Timestamp time.Time `gorm:"index;not null" json:"timestamp"`
+}
+
+// TableName 指定表名
+func (AgentEvent) TableName() string {
+	return "agent_events"
+}
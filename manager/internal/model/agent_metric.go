@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+)
+
+// AgentMetric Agent自定义指标模型
+// Agent在心跳中上报的自定义数值指标和状态字段，经Daemon状态同步转发后保存
+type AgentMetric struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// NodeID 节点ID
+	NodeID string `gorm:"uniqueIndex:uk_agent_metric_ts,priority:1;size:50;not null" json:"node_id"`
+
+	// AgentID Agent唯一标识符
+	AgentID string `gorm:"uniqueIndex:uk_agent_metric_ts,priority:2;size:100;not null" json:"agent_id"`
+
+	// Timestamp Agent上报时间(同一Agent同一时间戳只保存一次)
+	Timestamp time.Time `gorm:"uniqueIndex:uk_agent_metric_ts,priority:3;index;not null" json:"timestamp"`
+
+	// Values 自定义数值指标
+	Values JSONMap `gorm:"type:json" json:"values"`
+
+	// Fields 自定义状态字段
+	Fields JSONMap `gorm:"type:json" json:"fields"`
+}
+
+// TableName 指定表名
+func (AgentMetric) TableName() string {
+	return "agent_metrics"
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"time"
+)
+
+// JoinToken 节点加入令牌
+// 管理员创建的短期令牌，新Daemon凭令牌和CSR向Manager申请客户端证书
+// 令牌格式为 "<token_id>.<secret>"，数据库只保存secret的SHA-256摘要
+type JoinToken struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// TokenID 令牌公开部分，用于查找令牌
+	TokenID string `gorm:"uniqueIndex;size:16;not null" json:"token_id"`
+
+	// SecretHash 令牌密钥部分的SHA-256摘要(十六进制)
+	SecretHash string `gorm:"size:64;not null" json:"-"`
+
+	// Description 令牌用途说明
+	Description string `gorm:"size:255" json:"description"`
+
+	// NodeID 绑定的节点ID，为空时只能用于尚未注册的新节点
+	NodeID string `gorm:"size:50" json:"node_id"`
+
+	// MaxUses 最大使用次数
+	MaxUses int `gorm:"not null;default:1" json:"max_uses"`
+
+	// UsedCount 已使用次数
+	UsedCount int `gorm:"not null;default:0" json:"used_count"`
+
+	// ExpiresAt 过期时间
+	ExpiresAt time.Time `gorm:"index;not null" json:"expires_at"`
+
+	// CreatedBy 创建者用户名
+	CreatedBy string `gorm:"size:50" json:"created_by"`
+}
+
+// TableName 指定表名
+func (JoinToken) TableName() string {
+	return "join_tokens"
+}
+
+// Usable 令牌在指定时间是否可用(未过期且未用完)
+func (t *JoinToken) Usable(now time.Time) bool {
+	return now.Before(t.ExpiresAt) && t.UsedCount < t.MaxUses
+}
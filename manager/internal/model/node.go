@@ -29,6 +29,12 @@ type Node struct {
 	Status     string     `gorm:"size:20;not null;default:'offline'" json:"status"` // online, offline
 	LastSeenAt *time.Time `json:"last_seen_at"`
 
+	// Approval 注册审批状态：approved, pending, rejected
+	// pending节点可以心跳，但不会收到任务、配置和更新
+	Approval string `gorm:"size:20;not null;default:'approved';index" json:"approval"`
+	// Fingerprint 主机指纹(Daemon上报)，用于检测不同主机使用相同node_id
+	Fingerprint string `gorm:"size:64" json:"fingerprint"`
+
 	RegisterAt time.Time `json:"register_at"`
 }
 
@@ -37,11 +43,23 @@ func (Node) TableName() string {
 	return "nodes"
 }
 
+// 节点注册审批状态
+const (
+	NodeApprovalApproved = "approved"
+	NodeApprovalPending  = "pending"
+	NodeApprovalRejected = "rejected"
+)
+
 // IsOnline 节点是否在线
 func (n *Node) IsOnline() bool {
 	return n.Status == "online"
 }
 
+// IsApproved 节点是否已批准(可以接收任务、配置和更新)
+func (n *Node) IsApproved() bool {
+	return n.Approval == NodeApprovalApproved
+}
+
 // MapString 用于存储JSON格式的map
 type MapString map[string]string
 
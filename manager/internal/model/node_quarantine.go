@@ -0,0 +1,28 @@
+package model
+
+import (
+	"time"
+)
+
+// NodeQuarantine 被隔离的节点注册
+// 已有节点的node_id被另一台主机(主机指纹不同)使用时，新来者被隔离在此表中，
+// 不会覆盖已有节点的信息，等待管理员处理
+type NodeQuarantine struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	NodeID      string `gorm:"uniqueIndex:idx_node_quarantine;size:50;not null" json:"node_id"`
+	Fingerprint string `gorm:"uniqueIndex:idx_node_quarantine;size:64;not null" json:"fingerprint"`
+	Hostname    string `gorm:"size:100" json:"hostname"`
+	IP          string `gorm:"size:50" json:"ip"`
+
+	// Attempts 被拒绝的注册和心跳次数
+	Attempts   int       `gorm:"not null;default:0" json:"attempts"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
+// TableName 指定表名
+func (NodeQuarantine) TableName() string {
+	return "node_quarantines"
+}
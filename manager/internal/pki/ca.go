@@ -0,0 +1,350 @@
+// Package pki 实现Manager内置的证书颁发机构(CA)
+// 为Daemon签发绑定节点ID的客户端证书，并为Manager gRPC服务签发服务端证书
+package pki
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// NodeOrganization 节点客户端证书的组织名(CommonName为节点ID)
+	NodeOrganization = "ops-daemon"
+
+	// caValidity CA证书有效期
+	caValidity = 10 * 365 * 24 * time.Hour
+	// serverCertValidity 服务端证书有效期
+	serverCertValidity = 365 * 24 * time.Hour
+	// serverCertRenewBefore 服务端证书剩余有效期小于该值时重新签发
+	serverCertRenewBefore = 30 * 24 * time.Hour
+	// clockSkew 证书生效时间提前量(容忍节点时钟偏差)
+	clockSkew = 5 * time.Minute
+	// minRSAKeyBits CSR中RSA公钥的最小位数
+	minRSAKeyBits = 2048
+)
+
+// CA 内置证书颁发机构
+type CA struct {
+	cert    *x509.Certificate
+	key     crypto.Signer
+	certPEM []byte
+}
+
+// LoadOrCreateCA 加载CA证书和私钥，两者都不存在时生成新的自签名CA
+// 返回的created表示是否新生成了CA
+func LoadOrCreateCA(certFile, keyFile string) (ca *CA, created bool, err error) {
+	_, certErr := os.Stat(certFile)
+	_, keyErr := os.Stat(keyFile)
+	if os.IsNotExist(certErr) && os.IsNotExist(keyErr) {
+		ca, err := createCA(certFile, keyFile)
+		return ca, err == nil, err
+	}
+
+	certPEM, err := os.ReadFile(certFile)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read CA cert: %w", err)
+	}
+	keyPEM, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read CA key: %w", err)
+	}
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to load CA key pair: %w", err)
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to parse CA cert: %w", err)
+	}
+	if !cert.IsCA {
+		return nil, false, fmt.Errorf("%s is not a CA certificate", certFile)
+	}
+	signer, ok := pair.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, false, errors.New("CA private key does not support signing")
+	}
+	return &CA{cert: cert, key: signer, certPEM: certPEM}, false, nil
+}
+
+// createCA 生成自签名CA并写入文件
+func createCA(certFile, keyFile string) (*CA, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate CA key: %w", err)
+	}
+	serial, err := randomSerial()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "ops-manager CA", Organization: []string{"ops-manager"}},
+		NotBefore:             now.Add(-clockSkew),
+		NotAfter:              now.Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CA cert: %w", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CA cert: %w", err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM, err := encodeKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFile(keyFile, keyPEM, 0600); err != nil {
+		return nil, err
+	}
+	if err := writeFile(certFile, certPEM, 0644); err != nil {
+		return nil, err
+	}
+	return &CA{cert: cert, key: key, certPEM: certPEM}, nil
+}
+
+// Certificate 返回CA证书
+func (ca *CA) Certificate() *x509.Certificate {
+	return ca.cert
+}
+
+// CertPEM 返回PEM编码的CA证书
+func (ca *CA) CertPEM() []byte {
+	return ca.certPEM
+}
+
+// Pool 返回只包含该CA的证书池
+func (ca *CA) Pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	return pool
+}
+
+// Hash 返回CA公钥指纹("sha256:<hex>")，Daemon加入时用于校验Manager身份
+func (ca *CA) Hash() string {
+	return CertHash(ca.cert)
+}
+
+// SignNodeCSR 为节点签发客户端证书
+// 证书主题由CA决定(CommonName=节点ID，Organization=ops-daemon)，CSR中的主题和扩展被忽略
+func (ca *CA) SignNodeCSR(csrPEM []byte, nodeID string, ttl time.Duration) (certPEM []byte, notAfter time.Time, err error) {
+	if nodeID == "" {
+		return nil, time.Time{}, errors.New("node ID is required")
+	}
+	csr, err := ParseCSR(csrPEM)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	serial, err := randomSerial()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	now := time.Now()
+	notAfter = now.Add(ttl)
+	if notAfter.After(ca.cert.NotAfter) {
+		notAfter = ca.cert.NotAfter
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: nodeID, Organization: []string{NodeOrganization}},
+		NotBefore:    now.Add(-clockSkew),
+		NotAfter:     notAfter,
+		KeyUsage:     keyUsage(csr.PublicKey),
+		// 节点证书同时用于连接Manager和Daemon gRPC服务端
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, csr.PublicKey, ca.key)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to sign certificate: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), notAfter, nil
+}
+
+// EnsureServerCert 确保服务端证书存在，不存在或由该CA签发且临近过期时签发新证书
+// 不是由该CA签发的证书(外部PKI)保持不变
+// 证书文件包含服务端证书和CA证书，Daemon加入时可以从握手中校验CA指纹
+func (ca *CA) EnsureServerCert(certFile, keyFile string, names []string) (issued bool, err error) {
+	if _, err := os.Stat(certFile); err == nil {
+		pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return false, fmt.Errorf("failed to load server cert: %w", err)
+		}
+		cert, err := x509.ParseCertificate(pair.Certificate[0])
+		if err != nil {
+			return false, fmt.Errorf("failed to parse server cert: %w", err)
+		}
+		if cert.CheckSignatureFrom(ca.cert) != nil {
+			return false, nil
+		}
+		// 早期签发的证书缺少ClientAuth用途，无法作为客户端证书连接Daemon，重新签发
+		if time.Until(cert.NotAfter) > serverCertRenewBefore && hasExtKeyUsage(cert, x509.ExtKeyUsageClientAuth) {
+			return false, nil
+		}
+	}
+	if len(names) == 0 {
+		return false, errors.New("server names are required to issue server certificate")
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return false, fmt.Errorf("failed to generate server key: %w", err)
+	}
+	serial, err := randomSerial()
+	if err != nil {
+		return false, err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: names[0], Organization: []string{"ops-manager"}},
+		NotBefore:    now.Add(-clockSkew),
+		NotAfter:     now.Add(serverCertValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		// 服务端证书同时作为Manager连接Daemon的客户端证书
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	for _, name := range names {
+		if ip := net.ParseIP(name); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, name)
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		return false, fmt.Errorf("failed to sign server certificate: %w", err)
+	}
+
+	keyPEM, err := encodeKey(key)
+	if err != nil {
+		return false, err
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	certPEM = append(certPEM, ca.certPEM...)
+	if err := writeFile(keyFile, keyPEM, 0600); err != nil {
+		return false, err
+	}
+	if err := writeFile(certFile, certPEM, 0644); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ParseCSR 解析并校验PEM编码的证书签名请求
+func ParseCSR(csrPEM []byte) (*x509.CertificateRequest, error) {
+	block, _ := pem.Decode(csrPEM)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return nil, errors.New("invalid CSR: PEM block of type CERTIFICATE REQUEST expected")
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSR: %w", err)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return nil, fmt.Errorf("invalid CSR signature: %w", err)
+	}
+	switch pub := csr.PublicKey.(type) {
+	case *ecdsa.PublicKey, ed25519.PublicKey:
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("invalid CSR: RSA key must be at least %d bits", minRSAKeyBits)
+		}
+	default:
+		return nil, fmt.Errorf("invalid CSR: unsupported public key type %T", pub)
+	}
+	return csr, nil
+}
+
+// NodeIDFromCert 返回节点客户端证书绑定的节点ID，不是节点证书时返回空
+func NodeIDFromCert(cert *x509.Certificate) string {
+	for _, org := range cert.Subject.Organization {
+		if org == NodeOrganization {
+			return cert.Subject.CommonName
+		}
+	}
+	return ""
+}
+
+// CertHash 返回证书公钥指纹("sha256:<hex>")
+func CertHash(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// hasExtKeyUsage 证书是否包含指定的扩展密钥用途
+func hasExtKeyUsage(cert *x509.Certificate, usage x509.ExtKeyUsage) bool {
+	for _, u := range cert.ExtKeyUsage {
+		if u == usage {
+			return true
+		}
+	}
+	return false
+}
+
+// keyUsage 根据公钥类型返回证书的KeyUsage
+func keyUsage(pub crypto.PublicKey) x509.KeyUsage {
+	if _, ok := pub.(*rsa.PublicKey); ok {
+		return x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+	}
+	return x509.KeyUsageDigitalSignature
+}
+
+// randomSerial 生成128位随机证书序列号
+func randomSerial() (*big.Int, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial number: %w", err)
+	}
+	return serial, nil
+}
+
+// encodeKey PEM编码私钥(PKCS#8)
+func encodeKey(key crypto.PrivateKey) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal private key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// writeFile 原子写入文件(先写临时文件再重命名)
+func writeFile(path string, data []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", path, err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, perm); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
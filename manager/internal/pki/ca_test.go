@@ -0,0 +1,114 @@
+package pki
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestCSR 生成PEM编码的ECDSA证书签名请求
+func newTestCSR(t *testing.T, cn string) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{Subject: pkix.Name{CommonName: cn}}, key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})
+}
+
+func TestLoadOrCreateCA(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "certs", "ca.crt")
+	keyFile := filepath.Join(dir, "certs", "ca.key")
+
+	ca, created, err := LoadOrCreateCA(certFile, keyFile)
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.True(t, ca.Certificate().IsCA)
+
+	info, err := os.Stat(keyFile)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// 再次加载得到同一CA
+	loaded, created, err := LoadOrCreateCA(certFile, keyFile)
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, ca.Hash(), loaded.Hash())
+
+	// 只有一个文件存在时报错，避免覆盖已有CA
+	require.NoError(t, os.Remove(keyFile))
+	_, _, err = LoadOrCreateCA(certFile, keyFile)
+	assert.Error(t, err)
+}
+
+func TestCA_SignNodeCSR(t *testing.T) {
+	dir := t.TempDir()
+	ca, _, err := LoadOrCreateCA(filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key"))
+	require.NoError(t, err)
+
+	// CSR中的CN被忽略，证书始终绑定请求的节点ID
+	certPEM, notAfter, err := ca.SignNodeCSR(newTestCSR(t, "spoofed"), "node-1", time.Hour)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), notAfter, time.Minute)
+
+	block, _ := pem.Decode(certPEM)
+	require.NotNil(t, block)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+	assert.Equal(t, "node-1", NodeIDFromCert(cert))
+
+	_, err = cert.Verify(x509.VerifyOptions{Roots: ca.Pool(), KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}})
+	assert.NoError(t, err)
+
+	_, _, err = ca.SignNodeCSR([]byte("not a csr"), "node-1", time.Hour)
+	assert.Error(t, err)
+}
+
+func TestCA_EnsureServerCert(t *testing.T) {
+	dir := t.TempDir()
+	ca, _, err := LoadOrCreateCA(filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key"))
+	require.NoError(t, err)
+	certFile := filepath.Join(dir, "server.crt")
+	keyFile := filepath.Join(dir, "server.key")
+
+	_, err = ca.EnsureServerCert(certFile, keyFile, nil)
+	assert.Error(t, err, "server names are required")
+
+	issued, err := ca.EnsureServerCert(certFile, keyFile, []string{"manager.local", "127.0.0.1"})
+	require.NoError(t, err)
+	assert.True(t, issued)
+
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	require.NoError(t, err)
+	require.Len(t, pair.Certificate, 2, "certificate file should contain the CA chain")
+	leaf, err := x509.ParseCertificate(pair.Certificate[0])
+	require.NoError(t, err)
+	assert.NoError(t, leaf.VerifyHostname("127.0.0.1"))
+	assert.NoError(t, leaf.VerifyHostname("manager.local"))
+	// 服务端证书同时作为连接Daemon的客户端证书
+	assert.ElementsMatch(t, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}, leaf.ExtKeyUsage)
+
+	// 未临近过期时不重新签发
+	issued, err = ca.EnsureServerCert(certFile, keyFile, []string{"manager.local"})
+	require.NoError(t, err)
+	assert.False(t, issued)
+
+	// 外部CA签发的证书保持不变
+	other, _, err := LoadOrCreateCA(filepath.Join(dir, "other.crt"), filepath.Join(dir, "other.key"))
+	require.NoError(t, err)
+	issued, err = other.EnsureServerCert(certFile, keyFile, []string{"manager.local"})
+	require.NoError(t, err)
+	assert.False(t, issued)
+}
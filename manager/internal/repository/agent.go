@@ -33,6 +33,9 @@ type AgentRepository interface {
 
 	// ClearPID 清空Agent的PID（用于停止操作）
 	ClearPID(ctx context.Context, nodeID, agentID string) error
+
+	// CountByStatus 统计各状态Agent数量
+	CountByStatus(ctx context.Context) (map[string]int64, error)
 }
 
 // agentRepository Agent数据仓库实现
@@ -195,3 +198,29 @@ func (r *agentRepository) ClearPID(ctx context.Context, nodeID, agentID string)
 
 	return nil
 }
+
+// CountByStatus 统计各状态Agent数量
+func (r *agentRepository) CountByStatus(ctx context.Context) (map[string]int64, error) {
+	type StatusCount struct {
+		Status string
+		Count  int64
+	}
+
+	var results []StatusCount
+	err := r.db.WithContext(ctx).
+		Model(&model.Agent{}).
+		Select("status, COUNT(*) as count").
+		Group("status").
+		Find(&results).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to count agents by status: %w", err)
+	}
+
+	counts := make(map[string]int64)
+	for _, result := range results {
+		counts[result.Status] = result.Count
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/bingooyong/ops-scaffold-framework/manager/internal/model"
+	"gorm.io/gorm"
+)
+
+// AgentAlertRepository Agent资源告警数据访问接口
+type AgentAlertRepository interface {
+	// BatchCreate 批量创建资源告警
+	BatchCreate(ctx context.Context, alerts []*model.AgentResourceAlert) error
+	// List 获取资源告警列表(按触发时间倒序)，nodeID/agentID/alertType为空时不过滤
+	List(ctx context.Context, nodeID, agentID, alertType string, limit int) ([]*model.AgentResourceAlert, error)
+}
+
+// agentAlertRepository Agent资源告警数据访问实现
+type agentAlertRepository struct {
+	db *gorm.DB
+}
+
+// NewAgentAlertRepository 创建Agent资源告警数据访问实例
+func NewAgentAlertRepository(db *gorm.DB) AgentAlertRepository {
+	return &agentAlertRepository{db: db}
+}
+
+// BatchCreate 批量创建资源告警
+func (r *agentAlertRepository) BatchCreate(ctx context.Context, alerts []*model.AgentResourceAlert) error {
+	if len(alerts) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Create(&alerts).Error
+}
+
+// List 获取资源告警列表(按触发时间倒序)，nodeID/agentID/alertType为空时不过滤
+func (r *agentAlertRepository) List(ctx context.Context, nodeID, agentID, alertType string, limit int) ([]*model.AgentResourceAlert, error) {
+	var alerts []*model.AgentResourceAlert
+	query := r.db.WithContext(ctx).Model(&model.AgentResourceAlert{})
+	if nodeID != "" {
+		query = query.Where("node_id = ?", nodeID)
+	}
+	if agentID != "" {
+		query = query.Where("agent_id = ?", agentID)
+	}
+	if alertType != "" {
+		query = query.Where("type = ?", alertType)
+	}
+	query = query.Order("triggered_at DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Find(&alerts).Error; err != nil {
+		return nil, err
+	}
+	return alerts, nil
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/bingooyong/ops-scaffold-framework/manager/internal/model"
+	"gorm.io/gorm"
+)
+
+// AgentCrashRepository Agent崩溃记录数据访问接口
+type AgentCrashRepository interface {
+	// BatchCreate 批量创建崩溃记录
+	BatchCreate(ctx context.Context, crashes []*model.AgentCrash) error
+	// ListByNodeIDAndAgentID 获取指定Agent的崩溃记录(按退出时间倒序)
+	ListByNodeIDAndAgentID(ctx context.Context, nodeID, agentID string, limit int) ([]*model.AgentCrash, error)
+}
+
+// agentCrashRepository Agent崩溃记录数据访问实现
+type agentCrashRepository struct {
+	db *gorm.DB
+}
+
+// NewAgentCrashRepository 创建Agent崩溃记录数据访问实例
+func NewAgentCrashRepository(db *gorm.DB) AgentCrashRepository {
+	return &agentCrashRepository{db: db}
+}
+
+// BatchCreate 批量创建崩溃记录
+func (r *agentCrashRepository) BatchCreate(ctx context.Context, crashes []*model.AgentCrash) error {
+	if len(crashes) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Create(&crashes).Error
+}
+
+// ListByNodeIDAndAgentID 获取指定Agent的崩溃记录(按退出时间倒序)
+func (r *agentCrashRepository) ListByNodeIDAndAgentID(ctx context.Context, nodeID, agentID string, limit int) ([]*model.AgentCrash, error) {
+	var crashes []*model.AgentCrash
+	query := r.db.WithContext(ctx).
+		Where("node_id = ? AND agent_id = ?", nodeID, agentID).
+		Order("exited_at DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Find(&crashes).Error; err != nil {
+		return nil, err
+	}
+	return crashes, nil
+}
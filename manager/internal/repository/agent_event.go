@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/bingooyong/ops-scaffold-framework/manager/internal/model"
+	"gorm.io/gorm"
+)
+
+// AgentEventRepository Agent事件数据访问接口
+type AgentEventRepository interface {
+	// BatchCreate 批量创建Agent事件
+	BatchCreate(ctx context.Context, events []*model.AgentEvent) error
+	// ListByNodeIDAndAgentID 获取指定Agent的事件列表(按时间倒序)
+	ListByNodeIDAndAgentID(ctx context.Context, nodeID, agentID string, limit int) ([]*model.AgentEvent, error)
+}
+
+// agentEventRepository Agent事件数据访问实现
+type agentEventRepository struct {
+	db *gorm.DB
+}
+
+// NewAgentEventRepository 创建Agent事件数据访问实例
+func NewAgentEventRepository(db *gorm.DB) AgentEventRepository {
+	return &agentEventRepository{db: db}
+}
+
+// BatchCreate 批量创建Agent事件
+func (r *agentEventRepository) BatchCreate(ctx context.Context, events []*model.AgentEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Create(&events).Error
+}
+
+// ListByNodeIDAndAgentID 获取指定Agent的事件列表(按时间倒序)
+func (r *agentEventRepository) ListByNodeIDAndAgentID(ctx context.Context, nodeID, agentID string, limit int) ([]*model.AgentEvent, error) {
+	var events []*model.AgentEvent
+	query := r.db.WithContext(ctx).
+		Where("node_id = ? AND agent_id = ?", nodeID, agentID).
+		Order("timestamp DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
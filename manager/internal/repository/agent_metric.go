@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bingooyong/ops-scaffold-framework/manager/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AgentMetricRepository Agent自定义指标数据访问接口
+type AgentMetricRepository interface {
+	// BatchCreate 批量保存自定义指标，同一Agent同一时间戳的重复记录会被忽略
+	BatchCreate(ctx context.Context, metrics []*model.AgentMetric) error
+	// GetLatest 获取Agent最新的自定义指标，不存在时返回nil
+	GetLatest(ctx context.Context, nodeID, agentID string) (*model.AgentMetric, error)
+	// ListByTimeRange 获取时间范围内的自定义指标(按时间正序)
+	ListByTimeRange(ctx context.Context, nodeID, agentID string, start, end time.Time) ([]*model.AgentMetric, error)
+	// DeleteOlderThan 删除早于指定时长的自定义指标
+	DeleteOlderThan(ctx context.Context, duration time.Duration) (int64, error)
+}
+
+// agentMetricRepository Agent自定义指标数据访问实现
+type agentMetricRepository struct {
+	db *gorm.DB
+}
+
+// NewAgentMetricRepository 创建Agent自定义指标数据访问实例
+func NewAgentMetricRepository(db *gorm.DB) AgentMetricRepository {
+	return &agentMetricRepository{db: db}
+}
+
+// BatchCreate 批量保存自定义指标，同一Agent同一时间戳的重复记录会被忽略
+func (r *agentMetricRepository) BatchCreate(ctx context.Context, metrics []*model.AgentMetric) error {
+	if len(metrics) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&metrics).Error
+}
+
+// GetLatest 获取Agent最新的自定义指标，不存在时返回nil
+func (r *agentMetricRepository) GetLatest(ctx context.Context, nodeID, agentID string) (*model.AgentMetric, error) {
+	var metric model.AgentMetric
+	err := r.db.WithContext(ctx).
+		Where("node_id = ? AND agent_id = ?", nodeID, agentID).
+		Order("timestamp DESC").
+		First(&metric).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest agent metric: %w", err)
+	}
+	return &metric, nil
+}
+
+// ListByTimeRange 获取时间范围内的自定义指标(按时间正序)
+func (r *agentMetricRepository) ListByTimeRange(ctx context.Context, nodeID, agentID string, start, end time.Time) ([]*model.AgentMetric, error) {
+	var metrics []*model.AgentMetric
+	err := r.db.WithContext(ctx).
+		Where("node_id = ? AND agent_id = ? AND timestamp BETWEEN ? AND ?", nodeID, agentID, start, end).
+		Order("timestamp ASC").
+		Find(&metrics).Error
+	return metrics, err
+}
+
+// DeleteOlderThan 删除早于指定时长的自定义指标
+func (r *agentMetricRepository) DeleteOlderThan(ctx context.Context, duration time.Duration) (int64, error) {
+	cutoff := time.Now().Add(-duration)
+	result := r.db.WithContext(ctx).Where("timestamp < ?", cutoff).Delete(&model.AgentMetric{})
+	return result.RowsAffected, result.Error
+}
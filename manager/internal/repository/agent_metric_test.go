@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bingooyong/ops-scaffold-framework/manager/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// TestAgentMetricRepository 测试Agent自定义指标的保存、去重和查询
+func TestAgentMetricRepository(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&model.AgentMetric{}))
+
+	repo := NewAgentMetricRepository(db)
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Millisecond)
+
+	latest, err := repo.GetLatest(ctx, "node-1", "agent-1")
+	require.NoError(t, err)
+	assert.Nil(t, latest)
+
+	metrics := []*model.AgentMetric{
+		{NodeID: "node-1", AgentID: "agent-1", Timestamp: now.Add(-time.Minute), Values: model.JSONMap{"queue_depth": 1.0}},
+		{NodeID: "node-1", AgentID: "agent-1", Timestamp: now, Values: model.JSONMap{"queue_depth": 2.0}, Fields: model.JSONMap{"state": "ok"}},
+		{NodeID: "node-1", AgentID: "agent-2", Timestamp: now, Values: model.JSONMap{"queue_depth": 3.0}},
+	}
+	require.NoError(t, repo.BatchCreate(ctx, metrics))
+
+	// 重复上报同一时间戳的记录被忽略
+	duplicate := []*model.AgentMetric{
+		{NodeID: "node-1", AgentID: "agent-1", Timestamp: now, Values: model.JSONMap{"queue_depth": 99.0}},
+	}
+	require.NoError(t, repo.BatchCreate(ctx, duplicate))
+
+	latest, err = repo.GetLatest(ctx, "node-1", "agent-1")
+	require.NoError(t, err)
+	require.NotNil(t, latest)
+	assert.Equal(t, 2.0, latest.Values["queue_depth"])
+	assert.Equal(t, "ok", latest.Fields["state"])
+
+	history, err := repo.ListByTimeRange(ctx, "node-1", "agent-1", now.Add(-time.Hour), now.Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, 1.0, history[0].Values["queue_depth"])
+	assert.Equal(t, 2.0, history[1].Values["queue_depth"])
+}
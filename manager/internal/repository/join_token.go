@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/bingooyong/ops-scaffold-framework/manager/internal/model"
+	"gorm.io/gorm"
+)
+
+// JoinTokenRepository 节点加入令牌数据访问接口
+type JoinTokenRepository interface {
+	// Create 创建加入令牌
+	Create(ctx context.Context, token *model.JoinToken) error
+	// GetByTokenID 根据令牌公开部分获取令牌
+	GetByTokenID(ctx context.Context, tokenID string) (*model.JoinToken, error)
+	// List 获取加入令牌列表(按创建时间倒序)
+	List(ctx context.Context) ([]*model.JoinToken, error)
+	// Delete 删除加入令牌
+	Delete(ctx context.Context, id uint) error
+	// Consume 消耗一次令牌使用次数，令牌已过期或已用完时返回false
+	Consume(ctx context.Context, id uint, now time.Time) (bool, error)
+	// DeleteExpired 删除指定时间之前过期的令牌
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+// joinTokenRepository 节点加入令牌数据访问实现
+type joinTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewJoinTokenRepository 创建节点加入令牌数据访问实例
+func NewJoinTokenRepository(db *gorm.DB) JoinTokenRepository {
+	return &joinTokenRepository{db: db}
+}
+
+// Create 创建加入令牌
+func (r *joinTokenRepository) Create(ctx context.Context, token *model.JoinToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+// GetByTokenID 根据令牌公开部分获取令牌
+func (r *joinTokenRepository) GetByTokenID(ctx context.Context, tokenID string) (*model.JoinToken, error) {
+	var token model.JoinToken
+	if err := r.db.WithContext(ctx).Where("token_id = ?", tokenID).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// List 获取加入令牌列表(按创建时间倒序)
+func (r *joinTokenRepository) List(ctx context.Context) ([]*model.JoinToken, error) {
+	var tokens []*model.JoinToken
+	if err := r.db.WithContext(ctx).Order("created_at DESC").Find(&tokens).Error; err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// Delete 删除加入令牌
+func (r *joinTokenRepository) Delete(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).Delete(&model.JoinToken{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Consume 消耗一次令牌使用次数，令牌已过期或已用完时返回false
+// 通过条件更新保证并发加入时不会超过最大使用次数
+func (r *joinTokenRepository) Consume(ctx context.Context, id uint, now time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.JoinToken{}).
+		Where("id = ? AND used_count < max_uses AND expires_at > ?", id, now).
+		Update("used_count", gorm.Expr("used_count + 1"))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// DeleteExpired 删除指定时间之前过期的令牌
+func (r *joinTokenRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&model.JoinToken{})
+	return result.RowsAffected, result.Error
+}
@@ -24,6 +24,8 @@ type NodeRepository interface {
 	List(ctx context.Context, page, pageSize int) ([]*model.Node, int64, error)
 	// ListByStatus 根据状态获取节点列表
 	ListByStatus(ctx context.Context, status string, page, pageSize int) ([]*model.Node, int64, error)
+	// ListByApproval 根据审批状态获取节点列表
+	ListByApproval(ctx context.Context, approval string, page, pageSize int) ([]*model.Node, int64, error)
 	// ListByLabels 根据标签获取节点列表
 	ListByLabels(ctx context.Context, labels map[string]string, page, pageSize int) ([]*model.Node, int64, error)
 	// UpdateStatus 更新节点状态
@@ -32,6 +34,10 @@ type NodeRepository interface {
 	UpdateHeartbeat(ctx context.Context, nodeID string) error
 	// UpdateVersions 更新版本信息
 	UpdateVersions(ctx context.Context, nodeID, daemonVersion, agentVersion string) error
+	// UpdateApproval 更新节点注册审批状态
+	UpdateApproval(ctx context.Context, nodeID string, approval string) error
+	// UpdateFingerprint 更新节点主机指纹
+	UpdateFingerprint(ctx context.Context, nodeID string, fingerprint string) error
 	// GetOfflineNodes 获取离线节点（超过指定时间未心跳）
 	GetOfflineNodes(ctx context.Context, duration time.Duration) ([]*model.Node, error)
 	// CountByStatus 统计各状态节点数量
@@ -127,6 +133,29 @@ func (r *nodeRepository) ListByStatus(ctx context.Context, status string, page,
 	return nodes, total, err
 }
 
+// ListByApproval 根据审批状态获取节点列表
+func (r *nodeRepository) ListByApproval(ctx context.Context, approval string, page, pageSize int) ([]*model.Node, int64, error) {
+	var nodes []*model.Node
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.Node{}).Where("approval = ?", approval)
+
+	// 计算总数
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// 分页查询
+	offset := (page - 1) * pageSize
+	err := query.
+		Offset(offset).
+		Limit(pageSize).
+		Order("id DESC").
+		Find(&nodes).Error
+
+	return nodes, total, err
+}
+
 // ListByLabels 根据标签获取节点列表
 func (r *nodeRepository) ListByLabels(ctx context.Context, labels map[string]string, page, pageSize int) ([]*model.Node, int64, error) {
 	var nodes []*model.Node
@@ -187,6 +216,24 @@ func (r *nodeRepository) UpdateVersions(ctx context.Context, nodeID, daemonVersi
 		Error
 }
 
+// UpdateApproval 更新节点注册审批状态
+func (r *nodeRepository) UpdateApproval(ctx context.Context, nodeID string, approval string) error {
+	return r.db.WithContext(ctx).
+		Model(&model.Node{}).
+		Where("node_id = ?", nodeID).
+		Update("approval", approval).
+		Error
+}
+
+// UpdateFingerprint 更新节点主机指纹
+func (r *nodeRepository) UpdateFingerprint(ctx context.Context, nodeID string, fingerprint string) error {
+	return r.db.WithContext(ctx).
+		Model(&model.Node{}).
+		Where("node_id = ?", nodeID).
+		Update("fingerprint", fingerprint).
+		Error
+}
+
 // GetOfflineNodes 获取离线节点（超过指定时间未心跳）
 func (r *nodeRepository) GetOfflineNodes(ctx context.Context, duration time.Duration) ([]*model.Node, error) {
 	var nodes []*model.Node
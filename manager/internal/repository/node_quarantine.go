@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/bingooyong/ops-scaffold-framework/manager/internal/model"
+	"gorm.io/gorm"
+)
+
+// NodeQuarantineRepository 隔离节点数据访问接口
+type NodeQuarantineRepository interface {
+	// Record 记录一次被隔离的注册或心跳，同一node_id和主机指纹只保留一条记录
+	Record(ctx context.Context, entry *model.NodeQuarantine) error
+	// GetByID 根据ID获取隔离记录
+	GetByID(ctx context.Context, id uint) (*model.NodeQuarantine, error)
+	// List 获取隔离记录列表(按最后出现时间倒序)
+	List(ctx context.Context) ([]*model.NodeQuarantine, error)
+	// Delete 删除隔离记录
+	Delete(ctx context.Context, id uint) error
+	// DeleteByNodeID 删除节点的所有隔离记录
+	DeleteByNodeID(ctx context.Context, nodeID string) error
+}
+
+// nodeQuarantineRepository 隔离节点数据访问实现
+type nodeQuarantineRepository struct {
+	db *gorm.DB
+}
+
+// NewNodeQuarantineRepository 创建隔离节点数据访问实例
+func NewNodeQuarantineRepository(db *gorm.DB) NodeQuarantineRepository {
+	return &nodeQuarantineRepository{db: db}
+}
+
+// Record 记录一次被隔离的注册或心跳，同一node_id和主机指纹只保留一条记录
+func (r *nodeQuarantineRepository) Record(ctx context.Context, entry *model.NodeQuarantine) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		var existing model.NodeQuarantine
+		err := tx.Where("node_id = ? AND fingerprint = ?", entry.NodeID, entry.Fingerprint).First(&existing).Error
+		if err == gorm.ErrRecordNotFound {
+			entry.Attempts = 1
+			entry.LastSeenAt = now
+			return tx.Create(entry).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{
+			"attempts":     gorm.Expr("attempts + 1"),
+			"last_seen_at": now,
+		}
+		// 心跳不携带主机名和IP，只在注册时更新
+		if entry.Hostname != "" {
+			updates["hostname"] = entry.Hostname
+		}
+		if entry.IP != "" {
+			updates["ip"] = entry.IP
+		}
+		if err := tx.Model(&existing).Updates(updates).Error; err != nil {
+			return err
+		}
+		return tx.First(entry, existing.ID).Error
+	})
+}
+
+// GetByID 根据ID获取隔离记录
+func (r *nodeQuarantineRepository) GetByID(ctx context.Context, id uint) (*model.NodeQuarantine, error) {
+	var entry model.NodeQuarantine
+	if err := r.db.WithContext(ctx).First(&entry, id).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// List 获取隔离记录列表(按最后出现时间倒序)
+func (r *nodeQuarantineRepository) List(ctx context.Context) ([]*model.NodeQuarantine, error) {
+	var entries []*model.NodeQuarantine
+	if err := r.db.WithContext(ctx).Order("last_seen_at DESC").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Delete 删除隔离记录
+func (r *nodeQuarantineRepository) Delete(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).Delete(&model.NodeQuarantine{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// DeleteByNodeID 删除节点的所有隔离记录
+func (r *nodeQuarantineRepository) DeleteByNodeID(ctx context.Context, nodeID string) error {
+	return r.db.WithContext(ctx).Where("node_id = ?", nodeID).Delete(&model.NodeQuarantine{}).Error
+}
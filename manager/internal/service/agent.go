@@ -20,6 +20,10 @@ type DaemonClient interface {
 	OperateAgent(ctx context.Context, nodeID, agentID, operation string) error
 	ListAgents(ctx context.Context, nodeID string) ([]*daemonpb.AgentInfo, error)
 	GetAgentMetrics(ctx context.Context, nodeID, agentID string, duration time.Duration) ([]*daemonpb.ResourceDataPoint, error)
+	TailAgentLogs(ctx context.Context, nodeID, agentID string, lines int, keyword string) ([]string, error)
+	FollowAgentLogs(ctx context.Context, nodeID, agentID string, tailLines int, keyword string, handler func(lines []string, rotated bool) error) error
+	SearchAgentLogs(ctx context.Context, nodeID string, req *daemonpb.SearchAgentLogsRequest) (*daemonpb.SearchAgentLogsResponse, error)
+	ControlAgent(ctx context.Context, nodeID, agentID, command string, args map[string]string) (*daemonpb.ControlAgentResponse, error)
 }
 
 // DaemonClientPool Daemon客户端连接池接口，用于避免循环导入
@@ -33,16 +37,22 @@ type DaemonClientPool interface {
 type AgentService struct {
 	agentRepo  repository.AgentRepository
 	nodeRepo   repository.NodeRepository
+	eventRepo  repository.AgentEventRepository
+	crashRepo  repository.AgentCrashRepository
+	alertRepo  repository.AgentAlertRepository
 	daemonPool DaemonClientPool
 	logger     *zap.Logger
 	daemonPort int // Daemon gRPC端口，默认9091
 }
 
 // NewAgentService 创建Agent服务
-func NewAgentService(agentRepo repository.AgentRepository, nodeRepo repository.NodeRepository, daemonPool DaemonClientPool, logger *zap.Logger) *AgentService {
+func NewAgentService(agentRepo repository.AgentRepository, nodeRepo repository.NodeRepository, eventRepo repository.AgentEventRepository, crashRepo repository.AgentCrashRepository, alertRepo repository.AgentAlertRepository, daemonPool DaemonClientPool, logger *zap.Logger) *AgentService {
 	return &AgentService{
 		agentRepo:  agentRepo,
 		nodeRepo:   nodeRepo,
+		eventRepo:  eventRepo,
+		crashRepo:  crashRepo,
+		alertRepo:  alertRepo,
 		daemonPool: daemonPool,
 		logger:     logger,
 		daemonPort: 9091, // 默认Daemon gRPC端口
@@ -51,6 +61,13 @@ func NewAgentService(agentRepo repository.AgentRepository, nodeRepo repository.N
 
 // SyncAgentStates 同步Agent状态
 func (s *AgentService) SyncAgentStates(ctx context.Context, nodeID string, states []*daemonpb.AgentState) error {
+	return s.SyncAgentStatesAt(ctx, nodeID, states, time.Now())
+}
+
+// SyncAgentStatesAt 同步指定时间采集的Agent状态快照
+// Daemon在Manager不可达期间缓存的快照恢复后按顺序补发，syncTime为快照采集时间；
+// 早于已保存状态的快照不会覆盖较新的状态
+func (s *AgentService) SyncAgentStatesAt(ctx context.Context, nodeID string, states []*daemonpb.AgentState, syncTime time.Time) error {
 	if nodeID == "" {
 		return fmt.Errorf("node_id is required")
 	}
@@ -66,6 +83,7 @@ func (s *AgentService) SyncAgentStates(ctx context.Context, nodeID string, state
 		txAgentRepo := repository.NewAgentRepository(tx)
 
 		successCount := 0
+		staleCount := 0
 		for _, protoState := range states {
 			// 验证必要字段
 			if protoState.AgentId == "" {
@@ -85,11 +103,17 @@ func (s *AgentService) SyncAgentStates(ctx context.Context, nodeID string, state
 			}
 
 			if existing != nil {
+				// 已保存的状态比快照新(例如补发的历史快照)，保留已有状态
+				if existing.LastSyncTime.After(syncTime) {
+					staleCount++
+					continue
+				}
+
 				// 更新现有记录
 				agent := &model.Agent{
 					NodeID:       nodeID,
 					AgentID:      protoState.AgentId,
-					LastSyncTime: time.Now(),
+					LastSyncTime: syncTime,
 				}
 
 				// 只更新非空的状态值，如果状态为空则保留现有状态
@@ -158,7 +182,7 @@ func (s *AgentService) SyncAgentStates(ctx context.Context, nodeID string, state
 					Version:      protoState.Version,
 					Status:       status,
 					PID:          int(protoState.Pid),
-					LastSyncTime: time.Now(),
+					LastSyncTime: syncTime,
 				}
 
 				// 转换LastHeartbeat时间戳
@@ -183,7 +207,8 @@ func (s *AgentService) SyncAgentStates(ctx context.Context, nodeID string, state
 			zap.String("node_id", nodeID),
 			zap.Int("total", len(states)),
 			zap.Int("success", successCount),
-			zap.Int("failed", len(states)-successCount))
+			zap.Int("stale", staleCount),
+			zap.Int("failed", len(states)-successCount-staleCount))
 
 		return nil
 	})
@@ -232,7 +257,218 @@ func (s *AgentService) ListAgents(ctx context.Context, nodeID string) ([]*model.
 	return agents, nil
 }
 
-// OperateAgent 操作Agent(启动/停止/重启)
+// ReportAgentEvents 保存Daemon上报的Agent事件
+func (s *AgentService) ReportAgentEvents(ctx context.Context, nodeID string, events []*daemonpb.AgentEvent) error {
+	if nodeID == "" {
+		return fmt.Errorf("node_id is required")
+	}
+
+	records := make([]*model.AgentEvent, 0, len(events))
+	for _, e := range events {
+		if e.AgentId == "" || e.Type == "" {
+			s.logger.Warn("skipping agent event with empty agent_id or type",
+				zap.String("node_id", nodeID))
+			continue
+		}
+
+		details := make(model.JSONMap, len(e.Details))
+		for k, v := range e.Details {
+			details[k] = v
+		}
+
+		timestamp := time.Now()
+		if e.Timestamp > 0 {
+			timestamp = time.Unix(e.Timestamp, 0)
+		}
+
+		records = append(records, &model.AgentEvent{
+			NodeID:    nodeID,
+			AgentID:   e.AgentId,
+			Type:      e.Type,
+			Message:   e.Message,
+			Details:   details,
+			Timestamp: timestamp,
+		})
+	}
+
+	if err := s.eventRepo.BatchCreate(ctx, records); err != nil {
+		return fmt.Errorf("failed to save agent events: %w", err)
+	}
+
+	for _, r := range records {
+		s.logger.Info("agent event received",
+			zap.String("node_id", nodeID),
+			zap.String("agent_id", r.AgentID),
+			zap.String("type", r.Type),
+			zap.String("message", r.Message))
+	}
+
+	return nil
+}
+
+// ListAgentEvents 获取Agent事件列表
+func (s *AgentService) ListAgentEvents(ctx context.Context, nodeID, agentID string, limit int) ([]*model.AgentEvent, error) {
+	if nodeID == "" {
+		return nil, pkgerrors.New(pkgerrors.ErrInvalidParams, "node_id is required")
+	}
+	if agentID == "" {
+		return nil, pkgerrors.New(pkgerrors.ErrInvalidParams, "agent_id is required")
+	}
+
+	events, err := s.eventRepo.ListByNodeIDAndAgentID(ctx, nodeID, agentID, limit)
+	if err != nil {
+		s.logger.Error("failed to list agent events",
+			zap.String("node_id", nodeID),
+			zap.String("agent_id", agentID),
+			zap.Error(err))
+		return nil, pkgerrors.Wrap(pkgerrors.ErrDatabase, "failed to list agent events", err)
+	}
+
+	return events, nil
+}
+
+// ReportCrashes 保存Daemon上报的Agent崩溃记录
+func (s *AgentService) ReportCrashes(ctx context.Context, nodeID string, crashes []*daemonpb.CrashReport) error {
+	if nodeID == "" {
+		return fmt.Errorf("node_id is required")
+	}
+
+	records := make([]*model.AgentCrash, 0, len(crashes))
+	for _, c := range crashes {
+		if c.AgentId == "" {
+			s.logger.Warn("skipping crash report with empty agent_id",
+				zap.String("node_id", nodeID))
+			continue
+		}
+
+		record := &model.AgentCrash{
+			NodeID:       nodeID,
+			AgentID:      c.AgentId,
+			ExitCode:     int(c.ExitCode),
+			Signal:       c.Signal,
+			ExitedAt:     time.Unix(c.ExitedAt, 0),
+			DurationMs:   c.DurationMs,
+			LastLogLines: c.LastLogLines,
+			CircuitOpen:  c.CircuitOpen,
+		}
+		if c.StartedAt > 0 {
+			record.StartedAt = time.Unix(c.StartedAt, 0)
+		}
+		records = append(records, record)
+	}
+
+	if err := s.crashRepo.BatchCreate(ctx, records); err != nil {
+		return fmt.Errorf("failed to save crash reports: %w", err)
+	}
+
+	for _, r := range records {
+		s.logger.Warn("agent crash reported",
+			zap.String("node_id", nodeID),
+			zap.String("agent_id", r.AgentID),
+			zap.Int("exit_code", r.ExitCode),
+			zap.String("signal", r.Signal),
+			zap.Int64("duration_ms", r.DurationMs))
+	}
+
+	return nil
+}
+
+// ListAgentCrashes 获取Agent崩溃记录列表
+func (s *AgentService) ListAgentCrashes(ctx context.Context, nodeID, agentID string, limit int) ([]*model.AgentCrash, error) {
+	if nodeID == "" {
+		return nil, pkgerrors.New(pkgerrors.ErrInvalidParams, "node_id is required")
+	}
+	if agentID == "" {
+		return nil, pkgerrors.New(pkgerrors.ErrInvalidParams, "agent_id is required")
+	}
+
+	crashes, err := s.crashRepo.ListByNodeIDAndAgentID(ctx, nodeID, agentID, limit)
+	if err != nil {
+		s.logger.Error("failed to list agent crashes",
+			zap.String("node_id", nodeID),
+			zap.String("agent_id", agentID),
+			zap.Error(err))
+		return nil, pkgerrors.Wrap(pkgerrors.ErrDatabase, "failed to list agent crashes", err)
+	}
+
+	return crashes, nil
+}
+
+// GetStatistics 获取各状态Agent数量统计
+func (s *AgentService) GetStatistics(ctx context.Context) (map[string]int64, error) {
+	stats, err := s.agentRepo.CountByStatus(ctx)
+	if err != nil {
+		s.logger.Error("failed to get agent statistics", zap.Error(err))
+		return nil, pkgerrors.Wrap(pkgerrors.ErrDatabase, "获取Agent统计信息失败", err)
+	}
+	return stats, nil
+}
+
+// ReportResourceAlerts 保存Daemon上报的Agent资源告警
+func (s *AgentService) ReportResourceAlerts(ctx context.Context, nodeID string, alerts []*daemonpb.ResourceAlert) error {
+	if nodeID == "" {
+		return fmt.Errorf("node_id is required")
+	}
+
+	records := make([]*model.AgentResourceAlert, 0, len(alerts))
+	for _, a := range alerts {
+		if a.AgentId == "" {
+			s.logger.Warn("skipping resource alert with empty agent_id",
+				zap.String("node_id", nodeID))
+			continue
+		}
+
+		record := &model.AgentResourceAlert{
+			NodeID:      nodeID,
+			AgentID:     a.AgentId,
+			Type:        a.Type,
+			Value:       a.Value,
+			Threshold:   a.Threshold,
+			DurationMs:  a.DurationMs,
+			Action:      a.Action,
+			ActionError: a.ActionError,
+			TriggeredAt: time.Unix(a.Timestamp, 0),
+		}
+		if a.Timestamp <= 0 {
+			record.TriggeredAt = time.Now()
+		}
+		records = append(records, record)
+	}
+
+	if err := s.alertRepo.BatchCreate(ctx, records); err != nil {
+		return fmt.Errorf("failed to save resource alerts: %w", err)
+	}
+
+	for _, r := range records {
+		s.logger.Warn("agent resource alert reported",
+			zap.String("node_id", nodeID),
+			zap.String("agent_id", r.AgentID),
+			zap.String("type", r.Type),
+			zap.Float64("value", r.Value),
+			zap.Float64("threshold", r.Threshold),
+			zap.String("action", r.Action),
+			zap.String("action_error", r.ActionError))
+	}
+
+	return nil
+}
+
+// ListResourceAlerts 获取Agent资源告警列表，nodeID/agentID/alertType为空时不过滤
+func (s *AgentService) ListResourceAlerts(ctx context.Context, nodeID, agentID, alertType string, limit int) ([]*model.AgentResourceAlert, error) {
+	alerts, err := s.alertRepo.List(ctx, nodeID, agentID, alertType, limit)
+	if err != nil {
+		s.logger.Error("failed to list resource alerts",
+			zap.String("node_id", nodeID),
+			zap.String("agent_id", agentID),
+			zap.String("type", alertType),
+			zap.Error(err))
+		return nil, pkgerrors.Wrap(pkgerrors.ErrDatabase, "failed to list resource alerts", err)
+	}
+
+	return alerts, nil
+}
+
+// OperateAgent 操作Agent(启动/停止/重启/复位熔断)
 func (s *AgentService) OperateAgent(ctx context.Context, nodeID, agentID, operation string) error {
 	if nodeID == "" {
 		return pkgerrors.New(pkgerrors.ErrInvalidParams, "node_id is required")
@@ -249,9 +485,10 @@ func (s *AgentService) OperateAgent(ctx context.Context, nodeID, agentID, operat
 		"start":   true,
 		"stop":    true,
 		"restart": true,
+		"reset":   true,
 	}
 	if !validOperations[operation] {
-		return pkgerrors.New(pkgerrors.ErrInvalidParams, fmt.Sprintf("invalid operation: %s, must be one of: start, stop, restart", operation))
+		return pkgerrors.New(pkgerrors.ErrInvalidParams, fmt.Sprintf("invalid operation: %s, must be one of: start, stop, restart, reset", operation))
 	}
 
 	// 验证节点是否存在
@@ -265,6 +502,10 @@ func (s *AgentService) OperateAgent(ctx context.Context, nodeID, agentID, operat
 	if node == nil {
 		return pkgerrors.ErrNodeNotFoundMsg
 	}
+	// 待审批和已拒绝的节点不接收控制指令
+	if !node.IsApproved() {
+		return pkgerrors.ErrNodeNotApprovedMsg
+	}
 
 	// 验证Agent是否存在
 	agent, err := s.agentRepo.GetByNodeIDAndAgentID(ctx, nodeID, agentID)
@@ -391,8 +632,8 @@ func isConnectionError(err error) bool {
 		strings.Contains(errStr, "unavailable")
 }
 
-// GetAgentLogs 获取Agent日志
-func (s *AgentService) GetAgentLogs(ctx context.Context, nodeID, agentID string, lines int) ([]string, error) {
+// GetAgentLogs 获取Agent日志末尾lines行(keyword非空时只返回包含关键词的行)
+func (s *AgentService) GetAgentLogs(ctx context.Context, nodeID, agentID string, lines int, keyword string) ([]string, error) {
 	if nodeID == "" {
 		return nil, pkgerrors.New(pkgerrors.ErrInvalidParams, "node_id is required")
 	}
@@ -431,9 +672,113 @@ func (s *AgentService) GetAgentLogs(ctx context.Context, nodeID, agentID string,
 		return nil, pkgerrors.New(pkgerrors.ErrNotFound, "agent not found")
 	}
 
-	// 功能未实现，返回错误
-	// TODO: 实现获取Agent日志的功能
-	return nil, pkgerrors.New(pkgerrors.ErrInternalServer, "get agent logs not implemented yet")
+	// 构建Daemon gRPC地址
+	daemonAddr := fmt.Sprintf("%s:%d", node.IP, s.daemonPort)
+
+	// 从连接池获取Daemon客户端
+	daemonClient, err := s.daemonPool.GetClient(nodeID, daemonAddr)
+	if err != nil {
+		s.logger.Error("failed to get daemon client",
+			zap.String("node_id", nodeID),
+			zap.String("address", daemonAddr),
+			zap.Error(err))
+		return nil, pkgerrors.Wrap(pkgerrors.ErrGRPC, "failed to connect to daemon", err)
+	}
+
+	logs, err := daemonClient.TailAgentLogs(ctx, nodeID, agentID, lines, keyword)
+	if err != nil {
+		s.logger.Warn("failed to tail agent logs",
+			zap.String("node_id", nodeID),
+			zap.String("agent_id", agentID),
+			zap.String("daemon_address", daemonAddr),
+			zap.Error(err))
+
+		// 如果是连接错误，清理连接池中的连接，下次会重新建立
+		if isConnectionError(err) {
+			s.daemonPool.CloseClient(nodeID)
+		}
+
+		return nil, pkgerrors.Wrap(pkgerrors.ErrGRPC, "failed to get agent logs", err)
+	}
+
+	return logs, nil
+}
+
+// FollowAgentLogs 跟踪Agent日志(先返回末尾tailLines行，之后持续推送新增行)
+// 阻塞直到ctx取消、Daemon结束流或handler返回错误；rotated表示日志文件发生了轮转或截断
+func (s *AgentService) FollowAgentLogs(ctx context.Context, nodeID, agentID string, tailLines int, keyword string, handler func(lines []string, rotated bool) error) error {
+	if nodeID == "" {
+		return pkgerrors.New(pkgerrors.ErrInvalidParams, "node_id is required")
+	}
+	if agentID == "" {
+		return pkgerrors.New(pkgerrors.ErrInvalidParams, "agent_id is required")
+	}
+	if tailLines < 0 {
+		tailLines = 0
+	}
+	if tailLines > 1000 {
+		tailLines = 1000 // 限制最大1000行
+	}
+
+	// 验证节点是否存在
+	node, err := s.nodeRepo.GetByNodeID(ctx, nodeID)
+	if err != nil {
+		s.logger.Error("failed to get node",
+			zap.String("node_id", nodeID),
+			zap.Error(err))
+		return pkgerrors.Wrap(pkgerrors.ErrDatabase, "failed to get node", err)
+	}
+	if node == nil {
+		return pkgerrors.ErrNodeNotFoundMsg
+	}
+
+	// 验证Agent是否存在
+	agent, err := s.agentRepo.GetByNodeIDAndAgentID(ctx, nodeID, agentID)
+	if err != nil {
+		s.logger.Error("failed to get agent",
+			zap.String("node_id", nodeID),
+			zap.String("agent_id", agentID),
+			zap.Error(err))
+		return pkgerrors.Wrap(pkgerrors.ErrDatabase, "failed to get agent", err)
+	}
+	if agent == nil {
+		return pkgerrors.New(pkgerrors.ErrNotFound, "agent not found")
+	}
+
+	// 构建Daemon gRPC地址
+	daemonAddr := fmt.Sprintf("%s:%d", node.IP, s.daemonPort)
+
+	// 从连接池获取Daemon客户端
+	daemonClient, err := s.daemonPool.GetClient(nodeID, daemonAddr)
+	if err != nil {
+		s.logger.Error("failed to get daemon client",
+			zap.String("node_id", nodeID),
+			zap.String("address", daemonAddr),
+			zap.Error(err))
+		return pkgerrors.Wrap(pkgerrors.ErrGRPC, "failed to connect to daemon", err)
+	}
+
+	s.logger.Info("following agent logs",
+		zap.String("node_id", nodeID),
+		zap.String("agent_id", agentID),
+		zap.String("keyword", keyword),
+		zap.String("daemon_address", daemonAddr))
+
+	if err := daemonClient.FollowAgentLogs(ctx, nodeID, agentID, tailLines, keyword, handler); err != nil {
+		s.logger.Warn("agent log follow ended with error",
+			zap.String("node_id", nodeID),
+			zap.String("agent_id", agentID),
+			zap.String("daemon_address", daemonAddr),
+			zap.Error(err))
+
+		if isConnectionError(err) {
+			s.daemonPool.CloseClient(nodeID)
+		}
+
+		return pkgerrors.Wrap(pkgerrors.ErrGRPC, "failed to follow agent logs", err)
+	}
+
+	return nil
 }
 
 // GetAgentMetrics 获取Agent资源使用指标
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	pkgerrors "github.com/bingooyong/ops-scaffold-framework/manager/pkg/errors"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// AgentControlCommands 支持下发给Agent的控制命令
+var AgentControlCommands = []string{"reload", "set_log_level", "dump_diagnostics", "drain"}
+
+// AgentControlResult Agent控制命令执行结果
+type AgentControlResult struct {
+	Command string            `json:"command"`
+	Success bool              `json:"success"`
+	Message string            `json:"message"`
+	Method  string            `json:"method"` // 下发方式: socket(控制通道) 或 signal(回退信号)
+	Data    map[string]string `json:"data,omitempty"`
+}
+
+// ControlAgent 通过Daemon向Agent下发控制命令(reload/set_log_level/dump_diagnostics/drain)
+// Agent不支持控制协议时Daemon回退为配置的信号
+func (s *AgentService) ControlAgent(ctx context.Context, nodeID, agentID, command string, args map[string]string) (*AgentControlResult, error) {
+	if nodeID == "" {
+		return nil, pkgerrors.New(pkgerrors.ErrInvalidParams, "node_id is required")
+	}
+	if agentID == "" {
+		return nil, pkgerrors.New(pkgerrors.ErrInvalidParams, "agent_id is required")
+	}
+	if !isAgentControlCommand(command) {
+		return nil, pkgerrors.New(pkgerrors.ErrInvalidParams, fmt.Sprintf("invalid command: %s, must be one of: %s", command, strings.Join(AgentControlCommands, ", ")))
+	}
+	if command == "set_log_level" && args["level"] == "" {
+		return nil, pkgerrors.New(pkgerrors.ErrInvalidParams, "args.level is required for set_log_level")
+	}
+
+	// 验证节点是否存在
+	node, err := s.nodeRepo.GetByNodeID(ctx, nodeID)
+	if err != nil {
+		s.logger.Error("failed to get node",
+			zap.String("node_id", nodeID),
+			zap.Error(err))
+		return nil, pkgerrors.Wrap(pkgerrors.ErrDatabase, "failed to get node", err)
+	}
+	if node == nil {
+		return nil, pkgerrors.ErrNodeNotFoundMsg
+	}
+	// 待审批和已拒绝的节点不接收控制指令
+	if !node.IsApproved() {
+		return nil, pkgerrors.ErrNodeNotApprovedMsg
+	}
+
+	// 验证Agent是否存在
+	agent, err := s.agentRepo.GetByNodeIDAndAgentID(ctx, nodeID, agentID)
+	if err != nil {
+		s.logger.Error("failed to get agent",
+			zap.String("node_id", nodeID),
+			zap.String("agent_id", agentID),
+			zap.Error(err))
+		return nil, pkgerrors.Wrap(pkgerrors.ErrDatabase, "failed to get agent", err)
+	}
+	if agent == nil {
+		return nil, pkgerrors.New(pkgerrors.ErrNotFound, "agent not found")
+	}
+
+	// 构建Daemon gRPC地址
+	daemonAddr := fmt.Sprintf("%s:%d", node.IP, s.daemonPort)
+
+	// 从连接池获取Daemon客户端
+	daemonClient, err := s.daemonPool.GetClient(nodeID, daemonAddr)
+	if err != nil {
+		s.logger.Error("failed to get daemon client",
+			zap.String("node_id", nodeID),
+			zap.String("address", daemonAddr),
+			zap.Error(err))
+		return nil, pkgerrors.Wrap(pkgerrors.ErrGRPC, "failed to connect to daemon", err)
+	}
+
+	startTime := time.Now()
+	resp, err := daemonClient.ControlAgent(ctx, nodeID, agentID, command, args)
+	duration := time.Since(startTime)
+	if err != nil {
+		s.logger.Warn("failed to control agent",
+			zap.String("node_id", nodeID),
+			zap.String("agent_id", agentID),
+			zap.String("command", command),
+			zap.String("daemon_address", daemonAddr),
+			zap.Duration("duration", duration),
+			zap.Error(err))
+
+		switch status.Code(err) {
+		case codes.FailedPrecondition:
+			return nil, pkgerrors.New(pkgerrors.ErrConflict, "agent is not running")
+		case codes.Unimplemented:
+			return nil, pkgerrors.New(pkgerrors.ErrInvalidParams, fmt.Sprintf("agent does not support command %s and no fallback signal is configured", command))
+		}
+
+		// 如果是连接错误，清理连接池中的连接，下次会重新建立
+		if isConnectionError(err) {
+			s.daemonPool.CloseClient(nodeID)
+		}
+
+		return nil, pkgerrors.Wrap(pkgerrors.ErrGRPC, "failed to control agent", err)
+	}
+
+	s.logger.Info("control agent success",
+		zap.String("node_id", nodeID),
+		zap.String("agent_id", agentID),
+		zap.String("command", command),
+		zap.String("method", resp.Method),
+		zap.Bool("success", resp.Success),
+		zap.Duration("duration", duration))
+
+	return &AgentControlResult{
+		Command: command,
+		Success: resp.Success,
+		Message: resp.Message,
+		Method:  resp.Method,
+		Data:    resp.Data,
+	}, nil
+}
+
+// isAgentControlCommand 检查是否为支持的控制命令
+func isAgentControlCommand(command string) bool {
+	for _, c := range AgentControlCommands {
+		if c == command {
+			return true
+		}
+	}
+	return false
+}
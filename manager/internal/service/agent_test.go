@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bingooyong/ops-scaffold-framework/manager/internal/model"
+	"github.com/bingooyong/ops-scaffold-framework/manager/internal/repository"
+	"github.com/bingooyong/ops-scaffold-framework/manager/pkg/database"
+	daemonpb "github.com/bingooyong/ops-scaffold-framework/manager/pkg/proto/daemon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// newAgentTestService 创建使用内存数据库的Agent服务(状态同步使用全局database.DB)
+func newAgentTestService(t *testing.T) (*AgentService, repository.AgentRepository) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&model.Agent{}))
+
+	previous := database.DB
+	database.DB = db
+	t.Cleanup(func() { database.DB = previous })
+
+	agentRepo := repository.NewAgentRepository(db)
+	svc := NewAgentService(agentRepo, nil, nil, nil, nil, nil, zap.NewNop())
+	return svc, agentRepo
+}
+
+func TestAgentService_SyncAgentStatesAt(t *testing.T) {
+	svc, agentRepo := newAgentTestService(t)
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+
+	// 补发的历史快照按采集时间记录
+	collectedAt := now.Add(-time.Hour)
+	require.NoError(t, svc.SyncAgentStatesAt(ctx, "node-1", []*daemonpb.AgentState{
+		{AgentId: "agent-1", Status: "stopped", Type: "filebeat"},
+	}, collectedAt))
+
+	agent, err := agentRepo.GetByNodeIDAndAgentID(ctx, "node-1", "agent-1")
+	require.NoError(t, err)
+	require.NotNil(t, agent)
+	assert.Equal(t, "stopped", agent.Status)
+	assert.True(t, agent.LastSyncTime.Equal(collectedAt), "last sync time should be the snapshot time, got %v", agent.LastSyncTime)
+
+	// 较新的快照覆盖状态
+	require.NoError(t, svc.SyncAgentStates(ctx, "node-1", []*daemonpb.AgentState{
+		{AgentId: "agent-1", Status: "running", Pid: 42},
+	}))
+	agent, err = agentRepo.GetByNodeIDAndAgentID(ctx, "node-1", "agent-1")
+	require.NoError(t, err)
+	assert.Equal(t, "running", agent.Status)
+	assert.Equal(t, 42, agent.PID)
+
+	// 比已保存状态更旧的快照不覆盖
+	require.NoError(t, svc.SyncAgentStatesAt(ctx, "node-1", []*daemonpb.AgentState{
+		{AgentId: "agent-1", Status: "failed", Pid: 7},
+	}, now.Add(-30*time.Minute)))
+	agent, err = agentRepo.GetByNodeIDAndAgentID(ctx, "node-1", "agent-1")
+	require.NoError(t, err)
+	assert.Equal(t, "running", agent.Status)
+	assert.Equal(t, 42, agent.PID)
+}
@@ -0,0 +1,340 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/bingooyong/ops-scaffold-framework/manager/internal/model"
+	"github.com/bingooyong/ops-scaffold-framework/manager/internal/pki"
+	"github.com/bingooyong/ops-scaffold-framework/manager/internal/repository"
+	"github.com/bingooyong/ops-scaffold-framework/manager/pkg/errors"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// joinTokenIDLength 令牌公开部分长度
+	joinTokenIDLength = 6
+	// joinTokenSecretLength 令牌密钥部分长度
+	joinTokenSecretLength = 16
+	// joinTokenAlphabet 令牌字符集
+	joinTokenAlphabet = "0123456789abcdefghijklmnopqrstuvwxyz"
+	// maxJoinTokenTTL 加入令牌最长有效期
+	maxJoinTokenTTL = 7 * 24 * time.Hour
+	// maxJoinTokenUses 加入令牌最大使用次数上限
+	maxJoinTokenUses = 1000
+)
+
+// CreateJoinTokenRequest 创建加入令牌请求
+type CreateJoinTokenRequest struct {
+	Description string        // 用途说明
+	NodeID      string        // 绑定的节点ID(为空时只能用于新节点)
+	TTL         time.Duration // 有效期(0表示使用默认值)
+	MaxUses     int           // 最大使用次数(0表示1次)
+}
+
+// JoinTokenResult 创建加入令牌结果(令牌明文只在创建时返回一次)
+type JoinTokenResult struct {
+	Token      string           `json:"token"`
+	CACertHash string           `json:"ca_cert_hash"`
+	JoinToken  *model.JoinToken `json:"join_token"`
+}
+
+// IssuedCertificate 签发的节点客户端证书
+type IssuedCertificate struct {
+	CertPEM   []byte
+	CAPEM     []byte
+	ExpiresAt time.Time
+}
+
+// EnrollmentService 节点证书签发服务接口
+// Manager作为内置CA，凭加入令牌为新Daemon签发绑定节点ID的客户端证书，并在证书到期前续期
+type EnrollmentService interface {
+	// CreateJoinToken 创建加入令牌
+	CreateJoinToken(ctx context.Context, req *CreateJoinTokenRequest, createdBy string) (*JoinTokenResult, error)
+	// ListJoinTokens 获取加入令牌列表
+	ListJoinTokens(ctx context.Context) ([]*model.JoinToken, error)
+	// DeleteJoinToken 删除(吊销)加入令牌
+	DeleteJoinToken(ctx context.Context, id uint) error
+	// CleanExpiredJoinTokens 删除过期超过指定时间的令牌
+	CleanExpiredJoinTokens(ctx context.Context, retention time.Duration) (int64, error)
+	// Enroll 凭加入令牌为节点签发客户端证书
+	Enroll(ctx context.Context, nodeID, token string, csrPEM []byte) (*IssuedCertificate, error)
+	// RenewCertificate 为已持有有效证书的节点续期(调用方需已验证节点身份)
+	RenewCertificate(ctx context.Context, nodeID string, csrPEM []byte) (*IssuedCertificate, error)
+	// CACertHash 返回CA公钥指纹
+	CACertHash() string
+}
+
+// enrollmentService 节点证书签发服务实现
+type enrollmentService struct {
+	ca         *pki.CA
+	tokenRepo  repository.JoinTokenRepository
+	nodeRepo   repository.NodeRepository
+	auditRepo  repository.AuditLogRepository
+	certTTL    time.Duration
+	defaultTTL time.Duration
+	logger     *zap.Logger
+}
+
+// NewEnrollmentService 创建节点证书签发服务实例
+// certTTL为客户端证书有效期，tokenTTL为加入令牌默认有效期
+func NewEnrollmentService(
+	ca *pki.CA,
+	tokenRepo repository.JoinTokenRepository,
+	nodeRepo repository.NodeRepository,
+	auditRepo repository.AuditLogRepository,
+	certTTL time.Duration,
+	tokenTTL time.Duration,
+	logger *zap.Logger,
+) EnrollmentService {
+	return &enrollmentService{
+		ca:         ca,
+		tokenRepo:  tokenRepo,
+		nodeRepo:   nodeRepo,
+		auditRepo:  auditRepo,
+		certTTL:    certTTL,
+		defaultTTL: tokenTTL,
+		logger:     logger,
+	}
+}
+
+// CreateJoinToken 创建加入令牌
+func (s *enrollmentService) CreateJoinToken(ctx context.Context, req *CreateJoinTokenRequest, createdBy string) (*JoinTokenResult, error) {
+	ttl := req.TTL
+	if ttl == 0 {
+		ttl = s.defaultTTL
+	}
+	if ttl < 0 || ttl > maxJoinTokenTTL {
+		return nil, errors.NewWithDetails(errors.ErrInvalidParams, "参数错误", "ttl must be between 0 and 168h")
+	}
+	maxUses := req.MaxUses
+	if maxUses == 0 {
+		maxUses = 1
+	}
+	if maxUses < 0 || maxUses > maxJoinTokenUses {
+		return nil, errors.NewWithDetails(errors.ErrInvalidParams, "参数错误", "max_uses must be between 1 and 1000")
+	}
+
+	tokenID, err := randomString(joinTokenIDLength)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrInternalServer, "生成令牌失败", err)
+	}
+	secret, err := randomString(joinTokenSecretLength)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrInternalServer, "生成令牌失败", err)
+	}
+
+	joinToken := &model.JoinToken{
+		TokenID:     tokenID,
+		SecretHash:  hashSecret(secret),
+		Description: req.Description,
+		NodeID:      req.NodeID,
+		MaxUses:     maxUses,
+		ExpiresAt:   time.Now().Add(ttl),
+		CreatedBy:   createdBy,
+	}
+	if err := s.tokenRepo.Create(ctx, joinToken); err != nil {
+		s.logger.Error("failed to create join token", zap.Error(err))
+		return nil, errors.Wrap(errors.ErrDatabase, "创建加入令牌失败", err)
+	}
+
+	s.logger.Info("join token created",
+		zap.String("token_id", tokenID),
+		zap.String("node_id", req.NodeID),
+		zap.Int("max_uses", maxUses),
+		zap.Time("expires_at", joinToken.ExpiresAt),
+		zap.String("created_by", createdBy))
+
+	return &JoinTokenResult{
+		Token:      tokenID + "." + secret,
+		CACertHash: s.ca.Hash(),
+		JoinToken:  joinToken,
+	}, nil
+}
+
+// ListJoinTokens 获取加入令牌列表
+func (s *enrollmentService) ListJoinTokens(ctx context.Context) ([]*model.JoinToken, error) {
+	tokens, err := s.tokenRepo.List(ctx)
+	if err != nil {
+		s.logger.Error("failed to list join tokens", zap.Error(err))
+		return nil, errors.Wrap(errors.ErrDatabase, "数据库错误", err)
+	}
+	return tokens, nil
+}
+
+// DeleteJoinToken 删除(吊销)加入令牌
+func (s *enrollmentService) DeleteJoinToken(ctx context.Context, id uint) error {
+	if err := s.tokenRepo.Delete(ctx, id); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.NewWithDetails(errors.ErrNotFound, "资源不存在", "join token not found")
+		}
+		s.logger.Error("failed to delete join token", zap.Error(err))
+		return errors.Wrap(errors.ErrDatabase, "删除加入令牌失败", err)
+	}
+	s.logger.Info("join token deleted", zap.Uint("id", id))
+	return nil
+}
+
+// CleanExpiredJoinTokens 删除过期超过指定时间的令牌
+func (s *enrollmentService) CleanExpiredJoinTokens(ctx context.Context, retention time.Duration) (int64, error) {
+	deleted, err := s.tokenRepo.DeleteExpired(ctx, time.Now().Add(-retention))
+	if err != nil {
+		return 0, errors.Wrap(errors.ErrDatabase, "清理过期加入令牌失败", err)
+	}
+	return deleted, nil
+}
+
+// Enroll 凭加入令牌为节点签发客户端证书
+// 未绑定节点的令牌只能用于尚未注册的节点，避免持有令牌者冒充已有节点
+func (s *enrollmentService) Enroll(ctx context.Context, nodeID, token string, csrPEM []byte) (*IssuedCertificate, error) {
+	if nodeID == "" {
+		return nil, errors.NewWithDetails(errors.ErrInvalidParams, "参数错误", "node_id is required")
+	}
+
+	tokenID, secret, ok := strings.Cut(token, ".")
+	if !ok || tokenID == "" || secret == "" {
+		return nil, errors.ErrInvalidTokenMsg
+	}
+	joinToken, err := s.tokenRepo.GetByTokenID(ctx, tokenID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.ErrInvalidTokenMsg
+		}
+		s.logger.Error("failed to get join token", zap.Error(err))
+		return nil, errors.Wrap(errors.ErrDatabase, "数据库错误", err)
+	}
+	if subtle.ConstantTimeCompare([]byte(hashSecret(secret)), []byte(joinToken.SecretHash)) != 1 {
+		return nil, errors.ErrInvalidTokenMsg
+	}
+	if !joinToken.Usable(time.Now()) {
+		return nil, errors.ErrTokenExpiredMsg
+	}
+
+	if joinToken.NodeID != "" {
+		if joinToken.NodeID != nodeID {
+			return nil, errors.NewWithDetails(errors.ErrForbidden, "禁止访问", "join token is bound to another node")
+		}
+	} else {
+		node, err := s.nodeRepo.GetByNodeID(ctx, nodeID)
+		if err != nil && err != gorm.ErrRecordNotFound {
+			s.logger.Error("failed to check node", zap.Error(err))
+			return nil, errors.Wrap(errors.ErrDatabase, "数据库错误", err)
+		}
+		if node != nil {
+			return nil, errors.NewWithDetails(errors.ErrForbidden, "禁止访问", "node already registered, a join token bound to this node is required")
+		}
+	}
+
+	// 先校验CSR，避免无效请求消耗令牌
+	if _, err := pki.ParseCSR(csrPEM); err != nil {
+		return nil, errors.NewWithDetails(errors.ErrInvalidParams, "参数错误", err.Error())
+	}
+
+	consumed, err := s.tokenRepo.Consume(ctx, joinToken.ID, time.Now())
+	if err != nil {
+		s.logger.Error("failed to consume join token", zap.Error(err))
+		return nil, errors.Wrap(errors.ErrDatabase, "数据库错误", err)
+	}
+	if !consumed {
+		return nil, errors.ErrTokenExpiredMsg
+	}
+
+	issued, err := s.sign(nodeID, csrPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("node enrolled",
+		zap.String("node_id", nodeID),
+		zap.String("token_id", tokenID),
+		zap.Time("expires_at", issued.ExpiresAt))
+	s.audit(ctx, "enroll", nodeID, "token_id="+tokenID)
+
+	return issued, nil
+}
+
+// RenewCertificate 为已持有有效证书的节点续期
+func (s *enrollmentService) RenewCertificate(ctx context.Context, nodeID string, csrPEM []byte) (*IssuedCertificate, error) {
+	if nodeID == "" {
+		return nil, errors.NewWithDetails(errors.ErrInvalidParams, "参数错误", "node_id is required")
+	}
+	if _, err := pki.ParseCSR(csrPEM); err != nil {
+		return nil, errors.NewWithDetails(errors.ErrInvalidParams, "参数错误", err.Error())
+	}
+
+	issued, err := s.sign(nodeID, csrPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("node certificate renewed",
+		zap.String("node_id", nodeID),
+		zap.Time("expires_at", issued.ExpiresAt))
+	s.audit(ctx, "renew_certificate", nodeID, "")
+
+	return issued, nil
+}
+
+// CACertHash 返回CA公钥指纹
+func (s *enrollmentService) CACertHash() string {
+	return s.ca.Hash()
+}
+
+// sign 签发客户端证书
+func (s *enrollmentService) sign(nodeID string, csrPEM []byte) (*IssuedCertificate, error) {
+	certPEM, expiresAt, err := s.ca.SignNodeCSR(csrPEM, nodeID, s.certTTL)
+	if err != nil {
+		s.logger.Error("failed to sign node certificate",
+			zap.String("node_id", nodeID),
+			zap.Error(err))
+		return nil, errors.Wrap(errors.ErrInternalServer, "签发证书失败", err)
+	}
+	return &IssuedCertificate{
+		CertPEM:   certPEM,
+		CAPEM:     s.ca.CertPEM(),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// audit 记录证书签发审计日志，失败只记录日志
+func (s *enrollmentService) audit(ctx context.Context, action, nodeID, detail string) {
+	if s.auditRepo == nil {
+		return
+	}
+	log := &model.AuditLog{
+		Username: "daemon:" + nodeID,
+		Action:   action,
+		Resource: "node:" + nodeID,
+		Method:   "gRPC",
+		Path:     action,
+		Status:   200,
+		Message:  detail,
+	}
+	if err := s.auditRepo.Create(ctx, log); err != nil {
+		s.logger.Warn("failed to create audit log", zap.Error(err))
+	}
+}
+
+// randomString 生成指定长度的随机令牌字符串
+func randomString(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i := range buf {
+		buf[i] = joinTokenAlphabet[int(buf[i])%len(joinTokenAlphabet)]
+	}
+	return string(buf), nil
+}
+
+// hashSecret 计算令牌密钥的SHA-256摘要
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
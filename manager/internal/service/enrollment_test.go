@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bingooyong/ops-scaffold-framework/manager/internal/model"
+	"github.com/bingooyong/ops-scaffold-framework/manager/internal/pki"
+	"github.com/bingooyong/ops-scaffold-framework/manager/internal/repository"
+	"github.com/bingooyong/ops-scaffold-framework/manager/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// newEnrollmentTestService 创建使用内存数据库和临时CA的证书签发服务
+func newEnrollmentTestService(t *testing.T) (EnrollmentService, repository.NodeRepository) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&model.Node{}, &model.JoinToken{}, &model.AuditLog{}))
+
+	dir := t.TempDir()
+	ca, _, err := pki.LoadOrCreateCA(filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key"))
+	require.NoError(t, err)
+
+	nodeRepo := repository.NewNodeRepository(db)
+	svc := NewEnrollmentService(ca, repository.NewJoinTokenRepository(db), nodeRepo,
+		repository.NewAuditLogRepository(db), 24*time.Hour, time.Hour, zap.NewNop())
+	return svc, nodeRepo
+}
+
+// enrollmentTestCSR 生成PEM编码的证书签名请求
+func enrollmentTestCSR(t *testing.T) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{Subject: pkix.Name{CommonName: "node"}}, key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})
+}
+
+// errorCode 返回APIError的错误码
+func errorCode(t *testing.T, err error) errors.ErrorCode {
+	t.Helper()
+	apiErr, ok := err.(*errors.APIError)
+	require.True(t, ok, "expected APIError, got %v", err)
+	return apiErr.Code
+}
+
+func TestEnrollmentService_Enroll(t *testing.T) {
+	svc, _ := newEnrollmentTestService(t)
+	ctx := context.Background()
+
+	result, err := svc.CreateJoinToken(ctx, &CreateJoinTokenRequest{Description: "rack-1"}, "admin")
+	require.NoError(t, err)
+	assert.Equal(t, svc.CACertHash(), result.CACertHash)
+	assert.Equal(t, 1, result.JoinToken.MaxUses)
+
+	issued, err := svc.Enroll(ctx, "node-1", result.Token, enrollmentTestCSR(t))
+	require.NoError(t, err)
+	block, _ := pem.Decode(issued.CertPEM)
+	require.NotNil(t, block)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+	assert.Equal(t, "node-1", pki.NodeIDFromCert(cert))
+	assert.WithinDuration(t, time.Now().Add(24*time.Hour), issued.ExpiresAt, time.Minute)
+
+	// 一次性令牌不能再次使用
+	_, err = svc.Enroll(ctx, "node-2", result.Token, enrollmentTestCSR(t))
+	assert.Equal(t, errors.ErrTokenExpired, errorCode(t, err))
+
+	// 错误的密钥
+	result, err = svc.CreateJoinToken(ctx, &CreateJoinTokenRequest{}, "admin")
+	require.NoError(t, err)
+	_, err = svc.Enroll(ctx, "node-2", result.JoinToken.TokenID+".wrongsecret00000", enrollmentTestCSR(t))
+	assert.Equal(t, errors.ErrInvalidToken, errorCode(t, err))
+	_, err = svc.Enroll(ctx, "node-2", "malformed", enrollmentTestCSR(t))
+	assert.Equal(t, errors.ErrInvalidToken, errorCode(t, err))
+
+	// 无效的CSR不消耗令牌
+	_, err = svc.Enroll(ctx, "node-2", result.Token, []byte("bad csr"))
+	assert.Equal(t, errors.ErrInvalidParams, errorCode(t, err))
+	_, err = svc.Enroll(ctx, "node-2", result.Token, enrollmentTestCSR(t))
+	assert.NoError(t, err)
+}
+
+func TestEnrollmentService_EnrollExistingNode(t *testing.T) {
+	svc, nodeRepo := newEnrollmentTestService(t)
+	ctx := context.Background()
+	require.NoError(t, nodeRepo.Create(ctx, &model.Node{NodeID: "node-1", Hostname: "node-1", IP: "127.0.0.1", Status: "online"}))
+
+	// 未绑定节点的令牌不能用于已注册节点
+	result, err := svc.CreateJoinToken(ctx, &CreateJoinTokenRequest{}, "admin")
+	require.NoError(t, err)
+	_, err = svc.Enroll(ctx, "node-1", result.Token, enrollmentTestCSR(t))
+	assert.Equal(t, errors.ErrForbidden, errorCode(t, err))
+
+	// 绑定节点的令牌只能用于该节点
+	result, err = svc.CreateJoinToken(ctx, &CreateJoinTokenRequest{NodeID: "node-1", MaxUses: 2}, "admin")
+	require.NoError(t, err)
+	_, err = svc.Enroll(ctx, "node-2", result.Token, enrollmentTestCSR(t))
+	assert.Equal(t, errors.ErrForbidden, errorCode(t, err))
+	_, err = svc.Enroll(ctx, "node-1", result.Token, enrollmentTestCSR(t))
+	assert.NoError(t, err)
+}
+
+func TestEnrollmentService_JoinTokens(t *testing.T) {
+	svc, _ := newEnrollmentTestService(t)
+	ctx := context.Background()
+
+	_, err := svc.CreateJoinToken(ctx, &CreateJoinTokenRequest{TTL: 8 * 24 * time.Hour}, "admin")
+	assert.Equal(t, errors.ErrInvalidParams, errorCode(t, err))
+	_, err = svc.CreateJoinToken(ctx, &CreateJoinTokenRequest{MaxUses: -1}, "admin")
+	assert.Equal(t, errors.ErrInvalidParams, errorCode(t, err))
+
+	result, err := svc.CreateJoinToken(ctx, &CreateJoinTokenRequest{TTL: time.Millisecond}, "admin")
+	require.NoError(t, err)
+	tokens, err := svc.ListJoinTokens(ctx)
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	assert.Equal(t, "admin", tokens[0].CreatedBy)
+
+	// 过期令牌
+	time.Sleep(5 * time.Millisecond)
+	_, err = svc.Enroll(ctx, "node-1", result.Token, enrollmentTestCSR(t))
+	assert.Equal(t, errors.ErrTokenExpired, errorCode(t, err))
+
+	deleted, err := svc.CleanExpiredJoinTokens(ctx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	// 吊销令牌
+	result, err = svc.CreateJoinToken(ctx, &CreateJoinTokenRequest{}, "admin")
+	require.NoError(t, err)
+	require.NoError(t, svc.DeleteJoinToken(ctx, result.JoinToken.ID))
+	assert.Equal(t, errors.ErrNotFound, errorCode(t, svc.DeleteJoinToken(ctx, result.JoinToken.ID)))
+	_, err = svc.Enroll(ctx, "node-1", result.Token, enrollmentTestCSR(t))
+	assert.Equal(t, errors.ErrInvalidToken, errorCode(t, err))
+}
+
+func TestEnrollmentService_RenewCertificate(t *testing.T) {
+	svc, _ := newEnrollmentTestService(t)
+	ctx := context.Background()
+
+	issued, err := svc.RenewCertificate(ctx, "node-1", enrollmentTestCSR(t))
+	require.NoError(t, err)
+	block, _ := pem.Decode(issued.CertPEM)
+	require.NotNil(t, block)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+	assert.Equal(t, "node-1", pki.NodeIDFromCert(cert))
+
+	_, err = svc.RenewCertificate(ctx, "", enrollmentTestCSR(t))
+	assert.Equal(t, errors.ErrInvalidParams, errorCode(t, err))
+}
@@ -0,0 +1,314 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bingooyong/ops-scaffold-framework/manager/internal/model"
+	pkgerrors "github.com/bingooyong/ops-scaffold-framework/manager/pkg/errors"
+	daemonpb "github.com/bingooyong/ops-scaffold-framework/manager/pkg/proto/daemon"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultLogSearchLimit 日志搜索默认返回条数
+	defaultLogSearchLimit = 100
+
+	// maxLogSearchLimit 日志搜索最大返回条数
+	maxLogSearchLimit = 1000
+
+	// defaultLogSearchNodeTimeout 单个节点的默认搜索超时
+	defaultLogSearchNodeTimeout = 10 * time.Second
+
+	// maxLogSearchNodeTimeout 单个节点的最大搜索超时
+	maxLogSearchNodeTimeout = 60 * time.Second
+
+	// logSearchConcurrency 同时搜索的最大节点数
+	logSearchConcurrency = 32
+
+	// logSearchNodePageSize 按标签选择节点时每页查询的节点数
+	logSearchNodePageSize = 500
+)
+
+// LogSearchRequest 跨节点日志搜索请求
+type LogSearchRequest struct {
+	// Keyword 关键词，Regex为true时为正则表达式
+	Keyword string
+
+	// Regex Keyword是否为正则表达式
+	Regex bool
+
+	// Since 开始时间(零值表示不限制)
+	Since time.Time
+
+	// Until 结束时间(零值表示不限制)
+	Until time.Time
+
+	// AgentType 只搜索指定类型的Agent(为空时不过滤)
+	AgentType string
+
+	// NodeIDs 指定节点(非空时忽略Labels)
+	NodeIDs []string
+
+	// Labels 节点标签选择器(所有标签都匹配的节点，为空时选择所有节点)
+	Labels map[string]string
+
+	// Limit 最大返回条数
+	Limit int
+
+	// NodeTimeout 单个节点的搜索超时，超时的节点计入FailedNodes
+	NodeTimeout time.Duration
+}
+
+// LogSearchHit 日志搜索命中的行
+type LogSearchHit struct {
+	NodeID     string    `json:"node_id"`
+	Hostname   string    `json:"hostname"`
+	AgentID    string    `json:"agent_id"`
+	AgentType  string    `json:"agent_type"`
+	Timestamp  time.Time `json:"timestamp"`
+	Line       string    `json:"line"`
+	File       string    `json:"file"`
+	LineNumber int       `json:"line_number"`
+}
+
+// LogSearchNodeError 搜索失败的节点
+type LogSearchNodeError struct {
+	NodeID   string `json:"node_id"`
+	Hostname string `json:"hostname"`
+	Error    string `json:"error"`
+}
+
+// LogSearchResult 跨节点日志搜索结果
+type LogSearchResult struct {
+	// Hits 命中的行(按时间倒序)
+	Hits []*LogSearchHit `json:"hits"`
+
+	// Count 返回的命中数
+	Count int `json:"count"`
+
+	// Truncated 是否还有更多命中未返回
+	Truncated bool `json:"truncated"`
+
+	// Partial 是否有节点搜索失败(结果不完整)
+	Partial bool `json:"partial"`
+
+	// NodesTotal 选中的节点数
+	NodesTotal int `json:"nodes_total"`
+
+	// NodesSucceeded 搜索成功的节点数
+	NodesSucceeded int `json:"nodes_succeeded"`
+
+	// FailedNodes 搜索失败(离线、超时或出错)的节点
+	FailedNodes []*LogSearchNodeError `json:"failed_nodes"`
+}
+
+// SearchLogs 在选中的节点上并行搜索Agent日志，合并后按时间倒序返回
+// 单个节点离线、超时或出错时不影响其他节点，返回部分结果并在FailedNodes中说明
+func (s *AgentService) SearchLogs(ctx context.Context, req *LogSearchRequest) (*LogSearchResult, error) {
+	if req.Regex {
+		if _, err := regexp.Compile(req.Keyword); err != nil {
+			return nil, pkgerrors.New(pkgerrors.ErrInvalidParams, fmt.Sprintf("invalid regex: %v", err))
+		}
+	}
+	if !req.Since.IsZero() && !req.Until.IsZero() && req.Until.Before(req.Since) {
+		return nil, pkgerrors.New(pkgerrors.ErrInvalidParams, "until must be after since")
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultLogSearchLimit
+	}
+	if limit > maxLogSearchLimit {
+		limit = maxLogSearchLimit
+	}
+	nodeTimeout := req.NodeTimeout
+	if nodeTimeout <= 0 {
+		nodeTimeout = defaultLogSearchNodeTimeout
+	}
+	if nodeTimeout > maxLogSearchNodeTimeout {
+		nodeTimeout = maxLogSearchNodeTimeout
+	}
+
+	nodes, err := s.selectLogSearchNodes(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	daemonReq := &daemonpb.SearchAgentLogsRequest{
+		AgentType: req.AgentType,
+		Keyword:   req.Keyword,
+		Regex:     req.Regex,
+		Limit:     int32(limit),
+	}
+	if !req.Since.IsZero() {
+		daemonReq.SinceMs = req.Since.UnixMilli()
+	}
+	if !req.Until.IsZero() {
+		daemonReq.UntilMs = req.Until.UnixMilli()
+	}
+
+	result := &LogSearchResult{
+		Hits:        make([]*LogSearchHit, 0),
+		NodesTotal:  len(nodes),
+		FailedNodes: make([]*LogSearchNodeError, 0),
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, logSearchConcurrency)
+
+	for _, node := range nodes {
+		if !node.IsOnline() {
+			mu.Lock()
+			result.FailedNodes = append(result.FailedNodes, &LogSearchNodeError{
+				NodeID:   node.NodeID,
+				Hostname: node.Hostname,
+				Error:    "node is offline",
+			})
+			mu.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			hits, truncated, err := s.searchNodeLogs(ctx, node, daemonReq, nodeTimeout)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				result.FailedNodes = append(result.FailedNodes, &LogSearchNodeError{
+					NodeID:   node.NodeID,
+					Hostname: node.Hostname,
+					Error:    err.Error(),
+				})
+				return
+			}
+			result.NodesSucceeded++
+			result.Hits = append(result.Hits, hits...)
+			result.Truncated = result.Truncated || truncated
+		}()
+	}
+	wg.Wait()
+
+	sort.SliceStable(result.Hits, func(i, j int) bool {
+		a, b := result.Hits[i], result.Hits[j]
+		if !a.Timestamp.Equal(b.Timestamp) {
+			return a.Timestamp.After(b.Timestamp)
+		}
+		if a.NodeID != b.NodeID {
+			return a.NodeID < b.NodeID
+		}
+		if a.AgentID != b.AgentID {
+			return a.AgentID < b.AgentID
+		}
+		return a.LineNumber > b.LineNumber
+	})
+	if len(result.Hits) > limit {
+		result.Hits = result.Hits[:limit]
+		result.Truncated = true
+	}
+	sort.Slice(result.FailedNodes, func(i, j int) bool {
+		return result.FailedNodes[i].NodeID < result.FailedNodes[j].NodeID
+	})
+	result.Count = len(result.Hits)
+	result.Partial = len(result.FailedNodes) > 0
+
+	s.logger.Info("fleet log search completed",
+		zap.String("keyword", req.Keyword),
+		zap.Bool("regex", req.Regex),
+		zap.String("agent_type", req.AgentType),
+		zap.Int("nodes_total", result.NodesTotal),
+		zap.Int("nodes_succeeded", result.NodesSucceeded),
+		zap.Int("nodes_failed", len(result.FailedNodes)),
+		zap.Int("hits", result.Count))
+
+	return result, nil
+}
+
+// selectLogSearchNodes 根据节点ID或标签选择要搜索的节点
+func (s *AgentService) selectLogSearchNodes(ctx context.Context, req *LogSearchRequest) ([]*model.Node, error) {
+	if len(req.NodeIDs) > 0 {
+		nodes := make([]*model.Node, 0, len(req.NodeIDs))
+		for _, nodeID := range req.NodeIDs {
+			node, err := s.nodeRepo.GetByNodeID(ctx, nodeID)
+			if err != nil {
+				s.logger.Error("failed to get node",
+					zap.String("node_id", nodeID),
+					zap.Error(err))
+				return nil, pkgerrors.Wrap(pkgerrors.ErrDatabase, "failed to get node", err)
+			}
+			if node == nil {
+				return nil, pkgerrors.New(pkgerrors.ErrNodeNotFound, fmt.Sprintf("node not found: %s", nodeID))
+			}
+			nodes = append(nodes, node)
+		}
+		return nodes, nil
+	}
+
+	var nodes []*model.Node
+	for page := 1; ; page++ {
+		batch, total, err := s.nodeRepo.ListByLabels(ctx, req.Labels, page, logSearchNodePageSize)
+		if err != nil {
+			s.logger.Error("failed to list nodes by labels",
+				zap.Any("labels", req.Labels),
+				zap.Error(err))
+			return nil, pkgerrors.Wrap(pkgerrors.ErrDatabase, "failed to list nodes", err)
+		}
+		nodes = append(nodes, batch...)
+		if len(batch) < logSearchNodePageSize || int64(len(nodes)) >= total {
+			return nodes, nil
+		}
+	}
+}
+
+// searchNodeLogs 在单个节点上搜索日志(超时由nodeTimeout控制)
+func (s *AgentService) searchNodeLogs(ctx context.Context, node *model.Node, req *daemonpb.SearchAgentLogsRequest, nodeTimeout time.Duration) ([]*LogSearchHit, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, nodeTimeout)
+	defer cancel()
+
+	daemonAddr := fmt.Sprintf("%s:%d", node.IP, s.daemonPort)
+	daemonClient, err := s.daemonPool.GetClient(node.NodeID, daemonAddr)
+	if err != nil {
+		s.logger.Warn("failed to get daemon client",
+			zap.String("node_id", node.NodeID),
+			zap.String("address", daemonAddr),
+			zap.Error(err))
+		return nil, false, err
+	}
+
+	response, err := daemonClient.SearchAgentLogs(ctx, node.NodeID, req)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("search timed out after %s", nodeTimeout)
+		} else if isConnectionError(err) {
+			s.daemonPool.CloseClient(node.NodeID)
+		}
+		s.logger.Warn("failed to search agent logs on node",
+			zap.String("node_id", node.NodeID),
+			zap.String("daemon_address", daemonAddr),
+			zap.Error(err))
+		return nil, false, err
+	}
+
+	hits := make([]*LogSearchHit, 0, len(response.Hits))
+	for _, hit := range response.Hits {
+		hits = append(hits, &LogSearchHit{
+			NodeID:     node.NodeID,
+			Hostname:   node.Hostname,
+			AgentID:    hit.AgentId,
+			AgentType:  hit.AgentType,
+			Timestamp:  time.UnixMilli(hit.TimestampMs),
+			Line:       hit.Line,
+			File:       hit.File,
+			LineNumber: int(hit.LineNumber),
+		})
+	}
+	return hits, response.Truncated, nil
+}
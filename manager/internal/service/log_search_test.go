@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bingooyong/ops-scaffold-framework/manager/internal/model"
+	"github.com/bingooyong/ops-scaffold-framework/manager/internal/repository"
+	daemonpb "github.com/bingooyong/ops-scaffold-framework/manager/pkg/proto/daemon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// fakeSearchDaemonClient 只实现SearchAgentLogs的Daemon客户端
+type fakeSearchDaemonClient struct {
+	DaemonClient
+	hits  []*daemonpb.LogSearchHit
+	delay time.Duration
+	err   error
+}
+
+func (c *fakeSearchDaemonClient) SearchAgentLogs(ctx context.Context, nodeID string, req *daemonpb.SearchAgentLogsRequest) (*daemonpb.SearchAgentLogsResponse, error) {
+	if c.delay > 0 {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(c.delay):
+		}
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &daemonpb.SearchAgentLogsResponse{Hits: c.hits}, nil
+}
+
+// fakeSearchDaemonPool 按节点ID返回预设客户端的连接池
+type fakeSearchDaemonPool struct {
+	clients map[string]*fakeSearchDaemonClient
+}
+
+func (p *fakeSearchDaemonPool) GetClient(nodeID, address string) (DaemonClient, error) {
+	client, ok := p.clients[nodeID]
+	if !ok {
+		return nil, fmt.Errorf("no client for %s", nodeID)
+	}
+	return client, nil
+}
+
+func (p *fakeSearchDaemonPool) CloseClient(nodeID string) error { return nil }
+
+func (p *fakeSearchDaemonPool) CloseAll() {}
+
+func TestAgentService_SearchLogs(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&model.Node{}))
+	nodeRepo := repository.NewNodeRepository(db)
+
+	ctx := context.Background()
+	for _, n := range []struct{ id, status string }{
+		{"node-a", "online"}, {"node-b", "online"}, {"node-slow", "online"}, {"node-down", "offline"},
+	} {
+		require.NoError(t, nodeRepo.Create(ctx, &model.Node{NodeID: n.id, Hostname: n.id + ".local", IP: "127.0.0.1", Status: n.status}))
+	}
+
+	base := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	hit := func(agentID string, offset time.Duration, line string) *daemonpb.LogSearchHit {
+		return &daemonpb.LogSearchHit{AgentId: agentID, AgentType: "filebeat", TimestampMs: base.Add(offset).UnixMilli(), Line: line}
+	}
+	pool := &fakeSearchDaemonPool{clients: map[string]*fakeSearchDaemonClient{
+		"node-a":    {hits: []*daemonpb.LogSearchHit{hit("fb", 3*time.Minute, "a3"), hit("fb", time.Minute, "a1")}},
+		"node-b":    {hits: []*daemonpb.LogSearchHit{hit("fb", 2*time.Minute, "b2")}},
+		"node-slow": {delay: time.Second, hits: []*daemonpb.LogSearchHit{hit("fb", 4*time.Minute, "slow")}},
+	}}
+	svc := NewAgentService(nil, nodeRepo, nil, nil, nil, pool, zap.NewNop())
+
+	result, err := svc.SearchLogs(ctx, &LogSearchRequest{
+		Keyword:     "connection refused",
+		NodeIDs:     []string{"node-a", "node-b", "node-slow", "node-down"},
+		NodeTimeout: 100 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	// 合并后按时间倒序，并附带节点信息
+	var lines []string
+	for _, h := range result.Hits {
+		lines = append(lines, h.Line)
+	}
+	assert.Equal(t, []string{"a3", "b2", "a1"}, lines)
+	assert.Equal(t, "node-b", result.Hits[1].NodeID)
+	assert.Equal(t, "node-b.local", result.Hits[1].Hostname)
+
+	// 超时和离线的节点返回部分结果
+	assert.True(t, result.Partial)
+	assert.Equal(t, 4, result.NodesTotal)
+	assert.Equal(t, 2, result.NodesSucceeded)
+	require.Len(t, result.FailedNodes, 2)
+	assert.Equal(t, "node-down", result.FailedNodes[0].NodeID)
+	assert.Equal(t, "node is offline", result.FailedNodes[0].Error)
+	assert.Equal(t, "node-slow", result.FailedNodes[1].NodeID)
+	assert.Contains(t, result.FailedNodes[1].Error, "timed out")
+
+	// 超过限制时保留最新的命中
+	result, err = svc.SearchLogs(ctx, &LogSearchRequest{
+		Keyword: "connection refused",
+		NodeIDs: []string{"node-a", "node-b"},
+		Limit:   1,
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Hits, 1)
+	assert.Equal(t, "a3", result.Hits[0].Line)
+	assert.True(t, result.Truncated)
+	assert.False(t, result.Partial)
+
+	// 无效的正则和不存在的节点
+	_, err = svc.SearchLogs(ctx, &LogSearchRequest{Keyword: "(", Regex: true})
+	assert.Error(t, err)
+	_, err = svc.SearchLogs(ctx, &LogSearchRequest{Keyword: "x", NodeIDs: []string{"missing"}})
+	assert.Error(t, err)
+}
@@ -37,22 +37,33 @@ type MetricsService interface {
 	GetMetricsSummaryStats(ctx context.Context, nodeID string, startTime, endTime time.Time) (map[string]interface{}, error)
 	// GetClusterOverview 获取集群资源概览
 	GetClusterOverview(ctx context.Context) (map[string]interface{}, error)
+	// SaveAgentMetrics 保存Agent上报的自定义指标(重复时间戳的记录被忽略)
+	SaveAgentMetrics(ctx context.Context, metrics []*model.AgentMetric) error
+	// GetLatestAgentMetrics 获取Agent最新的自定义指标
+	GetLatestAgentMetrics(ctx context.Context, nodeID, agentID string) (*model.AgentMetric, error)
+	// GetAgentMetricsHistory 获取Agent自定义指标历史
+	GetAgentMetricsHistory(ctx context.Context, nodeID, agentID string, startTime, endTime time.Time) ([]*model.AgentMetric, error)
+	// CleanOldAgentMetrics 清理旧的Agent自定义指标
+	CleanOldAgentMetrics(ctx context.Context, retentionDays int) (int64, error)
 }
 
 // metricsService 监控指标服务实现
 type metricsService struct {
-	metricsRepo repository.MetricsRepository
-	logger      *zap.Logger
+	metricsRepo     repository.MetricsRepository
+	agentMetricRepo repository.AgentMetricRepository
+	logger          *zap.Logger
 }
 
 // NewMetricsService 创建监控指标服务实例
 func NewMetricsService(
 	metricsRepo repository.MetricsRepository,
+	agentMetricRepo repository.AgentMetricRepository,
 	logger *zap.Logger,
 ) MetricsService {
 	return &metricsService{
-		metricsRepo: metricsRepo,
-		logger:      logger,
+		metricsRepo:     metricsRepo,
+		agentMetricRepo: agentMetricRepo,
+		logger:          logger,
 	}
 }
 
@@ -368,3 +379,50 @@ func (s *metricsService) GetClusterOverview(ctx context.Context) (map[string]int
 
 	return result, nil
 }
+
+// SaveAgentMetrics 保存Agent上报的自定义指标(重复时间戳的记录被忽略)
+func (s *metricsService) SaveAgentMetrics(ctx context.Context, metrics []*model.AgentMetric) error {
+	if err := s.agentMetricRepo.BatchCreate(ctx, metrics); err != nil {
+		s.logger.Error("failed to save agent metrics", zap.Error(err))
+		return errors.Wrap(errors.ErrDatabase, "保存Agent自定义指标失败", err)
+	}
+	return nil
+}
+
+// GetLatestAgentMetrics 获取Agent最新的自定义指标
+func (s *metricsService) GetLatestAgentMetrics(ctx context.Context, nodeID, agentID string) (*model.AgentMetric, error) {
+	metric, err := s.agentMetricRepo.GetLatest(ctx, nodeID, agentID)
+	if err != nil {
+		s.logger.Error("failed to get latest agent metrics",
+			zap.String("node_id", nodeID),
+			zap.String("agent_id", agentID),
+			zap.Error(err))
+		return nil, errors.Wrap(errors.ErrDatabase, "查询Agent自定义指标失败", err)
+	}
+	return metric, nil
+}
+
+// GetAgentMetricsHistory 获取Agent自定义指标历史
+func (s *metricsService) GetAgentMetricsHistory(ctx context.Context, nodeID, agentID string, startTime, endTime time.Time) ([]*model.AgentMetric, error) {
+	metrics, err := s.agentMetricRepo.ListByTimeRange(ctx, nodeID, agentID, startTime, endTime)
+	if err != nil {
+		s.logger.Error("failed to get agent metrics history",
+			zap.String("node_id", nodeID),
+			zap.String("agent_id", agentID),
+			zap.Error(err))
+		return nil, errors.Wrap(errors.ErrDatabase, "查询Agent自定义指标历史失败", err)
+	}
+	return metrics, nil
+}
+
+// CleanOldAgentMetrics 清理旧的Agent自定义指标
+func (s *metricsService) CleanOldAgentMetrics(ctx context.Context, retentionDays int) (int64, error) {
+	duration := time.Duration(retentionDays) * 24 * time.Hour
+	deleted, err := s.agentMetricRepo.DeleteOlderThan(ctx, duration)
+	if err != nil {
+		s.logger.Error("failed to clean old agent metrics", zap.Error(err))
+		return 0, errors.Wrap(errors.ErrDatabase, "清理旧Agent自定义指标失败", err)
+	}
+	s.logger.Info("cleaned old agent metrics", zap.Int64("deleted", deleted))
+	return deleted, nil
+}
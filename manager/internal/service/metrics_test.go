@@ -39,7 +39,7 @@ func (s *MetricsServiceTestSuite) SetupSuite() {
 	s.db = db
 	s.repo = repository.NewMetricsRepository(db)
 	s.logger = zap.NewNop() // 使用空 logger，避免测试输出日志
-	s.service = NewMetricsService(s.repo, s.logger)
+	s.service = NewMetricsService(s.repo, nil, s.logger)
 	s.ctx = context.Background()
 	s.nodeID = "test-node-001"
 }
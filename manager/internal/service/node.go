@@ -13,8 +13,9 @@ import (
 
 // NodeService 节点服务接口
 type NodeService interface {
-	// Register 注册节点
-	Register(ctx context.Context, node *model.Node) error
+	// Register 注册节点，verified表示请求携带了有效的节点证书
+	// 返回后node.Approval为节点的审批状态；node_id与已有节点的主机指纹冲突时返回ErrNodeQuarantined
+	Register(ctx context.Context, node *model.Node, verified bool) error
 	// GetByNodeID 根据NodeID获取节点
 	GetByNodeID(ctx context.Context, nodeID string) (*model.Node, error)
 	// Update 更新节点信息
@@ -25,42 +26,62 @@ type NodeService interface {
 	List(ctx context.Context, page, pageSize int) ([]*model.Node, int64, error)
 	// ListByStatus 根据状态获取节点列表
 	ListByStatus(ctx context.Context, status string, page, pageSize int) ([]*model.Node, int64, error)
+	// ListByApproval 根据审批状态获取节点列表
+	ListByApproval(ctx context.Context, approval string, page, pageSize int) ([]*model.Node, int64, error)
 	// ListByLabels 根据标签获取节点列表
 	ListByLabels(ctx context.Context, labels map[string]string, page, pageSize int) ([]*model.Node, int64, error)
 	// UpdateStatus 更新节点状态
 	UpdateStatus(ctx context.Context, nodeID string, status string) error
-	// Heartbeat 心跳处理
-	Heartbeat(ctx context.Context, nodeID string) error
+	// Heartbeat 心跳处理，返回节点的审批状态
+	// pending节点可以心跳，rejected和主机指纹冲突的节点返回错误
+	Heartbeat(ctx context.Context, nodeID, fingerprint string, verified bool) (string, error)
 	// UpdateVersions 更新版本信息
 	UpdateVersions(ctx context.Context, nodeID, daemonVersion, agentVersion string) error
 	// CheckOfflineNodes 检查离线节点
 	CheckOfflineNodes(ctx context.Context, offlineDuration time.Duration) error
 	// GetStatistics 获取节点统计信息
 	GetStatistics(ctx context.Context) (map[string]int64, error)
+	// Approve 批准待审批或已拒绝的节点
+	Approve(ctx context.Context, id uint) (*model.Node, error)
+	// Reject 拒绝节点，被拒绝的节点不能注册和心跳
+	Reject(ctx context.Context, id uint) (*model.Node, error)
+	// ListQuarantine 获取被隔离的节点注册列表
+	ListQuarantine(ctx context.Context) ([]*model.NodeQuarantine, error)
+	// AcceptQuarantine 接受被隔离的主机，用其主机指纹替换已有节点的指纹(如主机重装)
+	AcceptQuarantine(ctx context.Context, id uint) (*model.Node, error)
+	// DismissQuarantine 忽略隔离记录
+	DismissQuarantine(ctx context.Context, id uint) error
 }
 
 // nodeService 节点服务实现
 type nodeService struct {
-	nodeRepo  repository.NodeRepository
-	auditRepo repository.AuditLogRepository
-	logger    *zap.Logger
+	nodeRepo       repository.NodeRepository
+	quarantineRepo repository.NodeQuarantineRepository
+	auditRepo      repository.AuditLogRepository
+	// autoApprove 为true时未携带节点证书的新节点也自动批准
+	autoApprove bool
+	logger      *zap.Logger
 }
 
 // NewNodeService 创建节点服务实例
 func NewNodeService(
 	nodeRepo repository.NodeRepository,
+	quarantineRepo repository.NodeQuarantineRepository,
 	auditRepo repository.AuditLogRepository,
+	autoApprove bool,
 	logger *zap.Logger,
 ) NodeService {
 	return &nodeService{
-		nodeRepo:  nodeRepo,
-		auditRepo: auditRepo,
-		logger:    logger,
+		nodeRepo:       nodeRepo,
+		quarantineRepo: quarantineRepo,
+		auditRepo:      auditRepo,
+		autoApprove:    autoApprove,
+		logger:         logger,
 	}
 }
 
 // Register 注册节点
-func (s *nodeService) Register(ctx context.Context, node *model.Node) error {
+func (s *nodeService) Register(ctx context.Context, node *model.Node, verified bool) error {
 	// 检查节点是否已存在
 	existingNode, err := s.nodeRepo.GetByNodeID(ctx, node.NodeID)
 	if err != nil && err != gorm.ErrRecordNotFound {
@@ -70,26 +91,101 @@ func (s *nodeService) Register(ctx context.Context, node *model.Node) error {
 
 	// 如果节点已存在，更新节点信息
 	if existingNode != nil {
+		// 其他主机使用了相同的node_id，隔离新来者，不覆盖已有节点
+		if s.fingerprintConflict(existingNode, node.Fingerprint) {
+			return s.quarantine(ctx, node.NodeID, node.Fingerprint, node.Hostname, node.IP, "register")
+		}
+
 		node.ID = existingNode.ID
 		node.CreatedAt = existingNode.CreatedAt
+		node.Approval = existingNode.Approval
+		// 待审批节点完成证书签发后自动批准
+		if node.Approval == model.NodeApprovalPending && verified {
+			node.Approval = model.NodeApprovalApproved
+		}
 		if err := s.nodeRepo.Update(ctx, node); err != nil {
 			s.logger.Error("failed to update node", zap.Error(err))
 			return errors.Wrap(errors.ErrDatabase, "更新节点失败", err)
 		}
-		s.logger.Info("node updated", zap.String("node_id", node.NodeID))
+		s.logger.Info("node updated", zap.String("node_id", node.NodeID), zap.String("approval", node.Approval))
 		return nil
 	}
 
 	// 创建新节点
+	node.Approval = s.initialApproval(verified)
 	if err := s.nodeRepo.Create(ctx, node); err != nil {
 		s.logger.Error("failed to create node", zap.Error(err))
 		return errors.Wrap(errors.ErrDatabase, "创建节点失败", err)
 	}
 
-	s.logger.Info("node registered", zap.String("node_id", node.NodeID))
+	s.logger.Info("node registered", zap.String("node_id", node.NodeID), zap.String("approval", node.Approval))
 	return nil
 }
 
+// initialApproval 新节点的审批状态：携带有效节点证书或开启自动批准时直接批准，否则待审批
+func (s *nodeService) initialApproval(verified bool) string {
+	if verified || s.autoApprove {
+		return model.NodeApprovalApproved
+	}
+	return model.NodeApprovalPending
+}
+
+// fingerprintConflict 上报的主机指纹是否与已有节点不同
+// 已有节点没有指纹(旧版本Daemon注册)时不认为冲突，注册时记录首次上报的指纹；
+// 已有节点有指纹时，未上报指纹的主机同样视为冲突，避免冒用者以旧版本协议绕过检测
+func (s *nodeService) fingerprintConflict(node *model.Node, fingerprint string) bool {
+	return node.Fingerprint != "" && node.Fingerprint != fingerprint
+}
+
+// quarantine 记录node_id冲突的主机并返回ErrNodeQuarantined
+func (s *nodeService) quarantine(ctx context.Context, nodeID, fingerprint, hostname, ip, source string) error {
+	s.logger.Warn("node_id collision from a different host, quarantined",
+		zap.String("node_id", nodeID),
+		zap.String("fingerprint", fingerprint),
+		zap.String("hostname", hostname),
+		zap.String("ip", ip),
+		zap.String("source", source))
+
+	entry := &model.NodeQuarantine{
+		NodeID:      nodeID,
+		Fingerprint: fingerprint,
+		Hostname:    hostname,
+		IP:          ip,
+	}
+	if err := s.quarantineRepo.Record(ctx, entry); err != nil {
+		s.logger.Error("failed to record quarantined node", zap.Error(err))
+		return errors.Wrap(errors.ErrDatabase, "记录隔离节点失败", err)
+	}
+	// 只在首次出现时写审计日志，避免心跳刷屏
+	if entry.Attempts == 1 {
+		reported := "fingerprint " + fingerprint
+		if fingerprint == "" {
+			reported = "missing fingerprint"
+		}
+		s.audit(ctx, "node_quarantined", nodeID, reported+" from "+hostname+" ("+ip+") conflicts with the registered host")
+	}
+	return errors.ErrNodeQuarantinedMsg
+}
+
+// audit 记录节点审计日志，失败不影响主流程
+func (s *nodeService) audit(ctx context.Context, action, nodeID, detail string) {
+	if s.auditRepo == nil {
+		return
+	}
+	log := &model.AuditLog{
+		Username: "daemon:" + nodeID,
+		Action:   action,
+		Resource: "node:" + nodeID,
+		Method:   "gRPC",
+		Path:     action,
+		Status:   200,
+		Message:  detail,
+	}
+	if err := s.auditRepo.Create(ctx, log); err != nil {
+		s.logger.Warn("failed to create audit log", zap.Error(err))
+	}
+}
+
 // GetByNodeID 根据NodeID获取节点
 func (s *nodeService) GetByNodeID(ctx context.Context, nodeID string) (*model.Node, error) {
 	node, err := s.nodeRepo.GetByNodeID(ctx, nodeID)
@@ -143,6 +239,16 @@ func (s *nodeService) ListByStatus(ctx context.Context, status string, page, pag
 	return nodes, total, nil
 }
 
+// ListByApproval 根据审批状态获取节点列表
+func (s *nodeService) ListByApproval(ctx context.Context, approval string, page, pageSize int) ([]*model.Node, int64, error) {
+	nodes, total, err := s.nodeRepo.ListByApproval(ctx, approval, page, pageSize)
+	if err != nil {
+		s.logger.Error("failed to list nodes by approval", zap.Error(err))
+		return nil, 0, errors.Wrap(errors.ErrDatabase, "查询节点列表失败", err)
+	}
+	return nodes, total, nil
+}
+
 // ListByLabels 根据标签获取节点列表
 func (s *nodeService) ListByLabels(ctx context.Context, labels map[string]string, page, pageSize int) ([]*model.Node, int64, error) {
 	nodes, total, err := s.nodeRepo.ListByLabels(ctx, labels, page, pageSize)
@@ -164,7 +270,7 @@ func (s *nodeService) UpdateStatus(ctx context.Context, nodeID string, status st
 }
 
 // Heartbeat 心跳处理
-func (s *nodeService) Heartbeat(ctx context.Context, nodeID string) error {
+func (s *nodeService) Heartbeat(ctx context.Context, nodeID, fingerprint string, verified bool) (string, error) {
 	// 检查节点是否存在
 	node, err := s.nodeRepo.GetByNodeID(ctx, nodeID)
 	if err != nil {
@@ -183,27 +289,36 @@ func (s *nodeService) Heartbeat(ctx context.Context, nodeID string) error {
 				DaemonVersion: "unknown",
 				AgentVersion:  "unknown",
 				Status:        "online",
+				Approval:      s.initialApproval(verified),
+				Fingerprint:   fingerprint,
 				RegisterAt:    now,
 				LastSeenAt:    &now,
 			}
 
 			if err := s.nodeRepo.Create(ctx, newNode); err != nil {
 				s.logger.Error("failed to auto-register node during heartbeat", zap.Error(err))
-				return errors.Wrap(errors.ErrDatabase, "自动注册节点失败", err)
+				return "", errors.Wrap(errors.ErrDatabase, "自动注册节点失败", err)
 			}
 
-			s.logger.Info("node auto-registered during heartbeat", zap.String("node_id", nodeID))
-			return nil
+			s.logger.Info("node auto-registered during heartbeat", zap.String("node_id", nodeID), zap.String("approval", newNode.Approval))
+			return newNode.Approval, nil
 		}
 
 		s.logger.Error("failed to get node", zap.Error(err))
-		return errors.Wrap(errors.ErrDatabase, "查询节点失败", err)
+		return "", errors.Wrap(errors.ErrDatabase, "查询节点失败", err)
+	}
+
+	if s.fingerprintConflict(node, fingerprint) {
+		return "", s.quarantine(ctx, nodeID, fingerprint, "", "", "heartbeat")
+	}
+	if node.Approval == model.NodeApprovalRejected {
+		return node.Approval, errors.ErrNodeNotApprovedMsg
 	}
 
 	// 更新心跳时间
 	if err := s.nodeRepo.UpdateHeartbeat(ctx, nodeID); err != nil {
 		s.logger.Error("failed to update heartbeat", zap.Error(err))
-		return errors.Wrap(errors.ErrDatabase, "更新心跳失败", err)
+		return "", errors.Wrap(errors.ErrDatabase, "更新心跳失败", err)
 	}
 
 	// 如果节点之前是离线状态，更新为在线
@@ -213,7 +328,7 @@ func (s *nodeService) Heartbeat(ctx context.Context, nodeID string) error {
 		}
 	}
 
-	return nil
+	return node.Approval, nil
 }
 
 // UpdateVersions 更新版本信息
@@ -260,3 +375,102 @@ func (s *nodeService) GetStatistics(ctx context.Context) (map[string]int64, erro
 	}
 	return stats, nil
 }
+
+// Approve 批准待审批或已拒绝的节点
+func (s *nodeService) Approve(ctx context.Context, id uint) (*model.Node, error) {
+	return s.setApproval(ctx, id, model.NodeApprovalApproved)
+}
+
+// Reject 拒绝节点，被拒绝的节点不能注册和心跳
+func (s *nodeService) Reject(ctx context.Context, id uint) (*model.Node, error) {
+	return s.setApproval(ctx, id, model.NodeApprovalRejected)
+}
+
+// setApproval 更新节点审批状态
+func (s *nodeService) setApproval(ctx context.Context, id uint, approval string) (*model.Node, error) {
+	node, err := s.nodeRepo.GetByID(ctx, id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.ErrNodeNotFoundMsg
+		}
+		s.logger.Error("failed to get node", zap.Error(err))
+		return nil, errors.Wrap(errors.ErrDatabase, "数据库错误", err)
+	}
+
+	if err := s.nodeRepo.UpdateApproval(ctx, node.NodeID, approval); err != nil {
+		s.logger.Error("failed to update node approval", zap.Error(err))
+		return nil, errors.Wrap(errors.ErrDatabase, "更新节点审批状态失败", err)
+	}
+	node.Approval = approval
+
+	s.logger.Info("node approval updated", zap.String("node_id", node.NodeID), zap.String("approval", approval))
+	return node, nil
+}
+
+// ListQuarantine 获取被隔离的节点注册列表
+func (s *nodeService) ListQuarantine(ctx context.Context) ([]*model.NodeQuarantine, error) {
+	entries, err := s.quarantineRepo.List(ctx)
+	if err != nil {
+		s.logger.Error("failed to list quarantined nodes", zap.Error(err))
+		return nil, errors.Wrap(errors.ErrDatabase, "查询隔离节点失败", err)
+	}
+	return entries, nil
+}
+
+// AcceptQuarantine 接受被隔离的主机，用其主机指纹替换已有节点的指纹(如主机重装)
+// 原主机此后使用该node_id会被隔离
+func (s *nodeService) AcceptQuarantine(ctx context.Context, id uint) (*model.Node, error) {
+	entry, err := s.getQuarantine(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := s.nodeRepo.GetByNodeID(ctx, entry.NodeID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.ErrNodeNotFoundMsg
+		}
+		s.logger.Error("failed to get node", zap.Error(err))
+		return nil, errors.Wrap(errors.ErrDatabase, "数据库错误", err)
+	}
+
+	if err := s.nodeRepo.UpdateFingerprint(ctx, node.NodeID, entry.Fingerprint); err != nil {
+		s.logger.Error("failed to update node fingerprint", zap.Error(err))
+		return nil, errors.Wrap(errors.ErrDatabase, "更新节点主机指纹失败", err)
+	}
+	if err := s.quarantineRepo.Delete(ctx, entry.ID); err != nil {
+		s.logger.Warn("failed to delete quarantine entry", zap.Uint("id", entry.ID), zap.Error(err))
+	}
+	node.Fingerprint = entry.Fingerprint
+
+	s.logger.Info("quarantined host accepted",
+		zap.String("node_id", node.NodeID),
+		zap.String("fingerprint", entry.Fingerprint))
+	return node, nil
+}
+
+// DismissQuarantine 忽略隔离记录
+func (s *nodeService) DismissQuarantine(ctx context.Context, id uint) error {
+	if err := s.quarantineRepo.Delete(ctx, id); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New(errors.ErrNotFound, "隔离记录不存在")
+		}
+		s.logger.Error("failed to delete quarantine entry", zap.Error(err))
+		return errors.Wrap(errors.ErrDatabase, "删除隔离记录失败", err)
+	}
+	s.logger.Info("quarantine entry dismissed", zap.Uint("id", id))
+	return nil
+}
+
+// getQuarantine 获取隔离记录
+func (s *nodeService) getQuarantine(ctx context.Context, id uint) (*model.NodeQuarantine, error) {
+	entry, err := s.quarantineRepo.GetByID(ctx, id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New(errors.ErrNotFound, "隔离记录不存在")
+		}
+		s.logger.Error("failed to get quarantine entry", zap.Error(err))
+		return nil, errors.Wrap(errors.ErrDatabase, "数据库错误", err)
+	}
+	return entry, nil
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bingooyong/ops-scaffold-framework/manager/internal/model"
+	"github.com/bingooyong/ops-scaffold-framework/manager/internal/repository"
+	"github.com/bingooyong/ops-scaffold-framework/manager/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// newNodeTestService 创建使用内存数据库的节点服务
+func newNodeTestService(t *testing.T, autoApprove bool) (NodeService, *gorm.DB) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&model.Node{}, &model.NodeQuarantine{}, &model.AuditLog{}, &model.Task{}))
+
+	svc := NewNodeService(repository.NewNodeRepository(db), repository.NewNodeQuarantineRepository(db),
+		repository.NewAuditLogRepository(db), autoApprove, zap.NewNop())
+	return svc, db
+}
+
+// testNode 构造注册请求中的节点
+func testNode(nodeID, fingerprint, hostname string) *model.Node {
+	return &model.Node{
+		NodeID:      nodeID,
+		Hostname:    hostname,
+		IP:          "10.0.0.1",
+		OS:          "linux",
+		Arch:        "amd64",
+		Fingerprint: fingerprint,
+		Status:      "online",
+	}
+}
+
+func TestNodeService_RegisterApproval(t *testing.T) {
+	svc, _ := newNodeTestService(t, false)
+	ctx := context.Background()
+
+	// 未携带节点证书的新节点进入待审批
+	node := testNode("node-1", "fp-1", "host-1")
+	require.NoError(t, svc.Register(ctx, node, false))
+	assert.Equal(t, model.NodeApprovalPending, node.Approval)
+
+	// 待审批节点可以心跳
+	approval, err := svc.Heartbeat(ctx, "node-1", "fp-1", false)
+	require.NoError(t, err)
+	assert.Equal(t, model.NodeApprovalPending, approval)
+
+	// 重新注册保持审批状态
+	node = testNode("node-1", "fp-1", "host-1")
+	require.NoError(t, svc.Register(ctx, node, false))
+	assert.Equal(t, model.NodeApprovalPending, node.Approval)
+
+	// 完成证书签发后注册自动批准
+	node = testNode("node-1", "fp-1", "host-1")
+	require.NoError(t, svc.Register(ctx, node, true))
+	assert.Equal(t, model.NodeApprovalApproved, node.Approval)
+
+	// 携带节点证书的新节点直接批准
+	node = testNode("node-2", "fp-2", "host-2")
+	require.NoError(t, svc.Register(ctx, node, true))
+	assert.Equal(t, model.NodeApprovalApproved, node.Approval)
+
+	// 心跳自动注册的节点同样进入待审批
+	approval, err = svc.Heartbeat(ctx, "node-3", "fp-3", false)
+	require.NoError(t, err)
+	assert.Equal(t, model.NodeApprovalPending, approval)
+
+	// 开启自动批准
+	autoSvc, _ := newNodeTestService(t, true)
+	node = testNode("node-1", "fp-1", "host-1")
+	require.NoError(t, autoSvc.Register(ctx, node, false))
+	assert.Equal(t, model.NodeApprovalApproved, node.Approval)
+}
+
+func TestNodeService_ApproveReject(t *testing.T) {
+	svc, _ := newNodeTestService(t, false)
+	ctx := context.Background()
+
+	node := testNode("node-1", "fp-1", "host-1")
+	require.NoError(t, svc.Register(ctx, node, false))
+
+	approved, err := svc.Approve(ctx, node.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.NodeApprovalApproved, approved.Approval)
+
+	rejected, err := svc.Reject(ctx, node.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.NodeApprovalRejected, rejected.Approval)
+
+	// 被拒绝的节点不能心跳，重新注册也不会改变审批状态
+	_, err = svc.Heartbeat(ctx, "node-1", "fp-1", true)
+	assert.Equal(t, errors.ErrNodeNotApproved, errorCode(t, err))
+	node = testNode("node-1", "fp-1", "host-1")
+	require.NoError(t, svc.Register(ctx, node, true))
+	assert.Equal(t, model.NodeApprovalRejected, node.Approval)
+
+	nodes, total, err := svc.ListByApproval(ctx, model.NodeApprovalRejected, 1, 20)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, "node-1", nodes[0].NodeID)
+
+	_, err = svc.Approve(ctx, 9999)
+	assert.Equal(t, errors.ErrNodeNotFound, errorCode(t, err))
+}
+
+func TestNodeService_Quarantine(t *testing.T) {
+	svc, _ := newNodeTestService(t, true)
+	ctx := context.Background()
+
+	require.NoError(t, svc.Register(ctx, testNode("node-1", "fp-1", "host-1"), false))
+
+	// 其他主机使用相同node_id注册被隔离，不覆盖已有节点
+	err := svc.Register(ctx, testNode("node-1", "fp-2", "intruder"), false)
+	assert.Equal(t, errors.ErrNodeQuarantined, errorCode(t, err))
+	_, err = svc.Heartbeat(ctx, "node-1", "fp-2", false)
+	assert.Equal(t, errors.ErrNodeQuarantined, errorCode(t, err))
+
+	existing, err := svc.GetByNodeID(ctx, "node-1")
+	require.NoError(t, err)
+	assert.Equal(t, "host-1", existing.Hostname)
+	assert.Equal(t, "fp-1", existing.Fingerprint)
+
+	entries, err := svc.ListQuarantine(ctx)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "fp-2", entries[0].Fingerprint)
+	assert.Equal(t, "intruder", entries[0].Hostname)
+	assert.Equal(t, 2, entries[0].Attempts)
+
+	// 原主机不受影响
+	_, err = svc.Heartbeat(ctx, "node-1", "fp-1", false)
+	assert.NoError(t, err)
+
+	// 忽略隔离记录
+	require.NoError(t, svc.DismissQuarantine(ctx, entries[0].ID))
+	assert.Equal(t, errors.ErrNotFound, errorCode(t, svc.DismissQuarantine(ctx, entries[0].ID)))
+
+	// 接受隔离主机(如主机重装)后原主机被隔离
+	err = svc.Register(ctx, testNode("node-1", "fp-2", "host-1-reinstalled"), false)
+	assert.Equal(t, errors.ErrNodeQuarantined, errorCode(t, err))
+	entries, err = svc.ListQuarantine(ctx)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	accepted, err := svc.AcceptQuarantine(ctx, entries[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "fp-2", accepted.Fingerprint)
+
+	require.NoError(t, svc.Register(ctx, testNode("node-1", "fp-2", "host-1-reinstalled"), false))
+	_, err = svc.Heartbeat(ctx, "node-1", "fp-1", false)
+	assert.Equal(t, errors.ErrNodeQuarantined, errorCode(t, err))
+
+	// 已有节点有指纹时，未上报指纹的主机同样被隔离
+	err = svc.Register(ctx, testNode("node-1", "", "legacy"), false)
+	assert.Equal(t, errors.ErrNodeQuarantined, errorCode(t, err))
+	_, err = svc.Heartbeat(ctx, "node-1", "", false)
+	assert.Equal(t, errors.ErrNodeQuarantined, errorCode(t, err))
+	existing, err = svc.GetByNodeID(ctx, "node-1")
+	require.NoError(t, err)
+	assert.Equal(t, "fp-2", existing.Fingerprint)
+	assert.Equal(t, "host-1-reinstalled", existing.Hostname)
+	entries, err = svc.ListQuarantine(ctx)
+	require.NoError(t, err)
+	fingerprints := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		fingerprints = append(fingerprints, entry.Fingerprint)
+	}
+	assert.Contains(t, fingerprints, "")
+}
+
+func TestNodeService_FingerprintFirstReport(t *testing.T) {
+	svc, _ := newNodeTestService(t, true)
+	ctx := context.Background()
+
+	// 旧版本Daemon注册的节点没有指纹，不检测冲突
+	require.NoError(t, svc.Register(ctx, testNode("node-1", "", "host-1"), false))
+	_, err := svc.Heartbeat(ctx, "node-1", "", false)
+	assert.NoError(t, err)
+
+	// 升级后首次上报的指纹被记录，之后按指纹检测冲突
+	require.NoError(t, svc.Register(ctx, testNode("node-1", "fp-1", "host-1"), false))
+	existing, err := svc.GetByNodeID(ctx, "node-1")
+	require.NoError(t, err)
+	assert.Equal(t, "fp-1", existing.Fingerprint)
+
+	_, err = svc.Heartbeat(ctx, "node-1", "", false)
+	assert.Equal(t, errors.ErrNodeQuarantined, errorCode(t, err))
+}
+
+func TestTaskService_RequiresApprovedTargets(t *testing.T) {
+	nodeSvc, db := newNodeTestService(t, false)
+	ctx := context.Background()
+	taskSvc := NewTaskService(repository.NewTaskRepository(db), repository.NewNodeRepository(db),
+		repository.NewAuditLogRepository(db), zap.NewNop())
+
+	node := testNode("node-1", "fp-1", "host-1")
+	require.NoError(t, nodeSvc.Register(ctx, node, false))
+
+	// 待审批节点不接收任务
+	task := &model.Task{Name: "t", Type: "script", TargetNodes: model.JSONArray{"node-1"}}
+	assert.Equal(t, errors.ErrNodeNotApproved, errorCode(t, taskSvc.Create(ctx, task)))
+
+	_, err := nodeSvc.Approve(ctx, node.ID)
+	require.NoError(t, err)
+	require.NoError(t, taskSvc.Create(ctx, task))
+
+	// 任务创建后节点被拒绝
+	_, err = nodeSvc.Reject(ctx, node.ID)
+	require.NoError(t, err)
+	assert.Equal(t, errors.ErrNodeNotApproved, errorCode(t, taskSvc.Execute(ctx, task.ID)))
+}
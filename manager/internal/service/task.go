@@ -67,16 +67,9 @@ func NewTaskService(
 
 // Create 创建任务
 func (s *taskService) Create(ctx context.Context, task *model.Task) error {
-	// 验证目标节点是否存在
-	for _, nodeID := range task.TargetNodes {
-		_, err := s.nodeRepo.GetByNodeID(ctx, nodeID)
-		if err != nil {
-			if err == gorm.ErrRecordNotFound {
-				return errors.New(errors.ErrNodeNotFound, "目标节点不存在: "+nodeID)
-			}
-			s.logger.Error("failed to check node", zap.Error(err))
-			return errors.Wrap(errors.ErrDatabase, "数据库错误", err)
-		}
+	// 验证目标节点是否存在且已批准
+	if err := s.checkTargetNodes(ctx, task.TargetNodes); err != nil {
+		return err
 	}
 
 	// 创建任务
@@ -89,6 +82,24 @@ func (s *taskService) Create(ctx context.Context, task *model.Task) error {
 	return nil
 }
 
+// checkTargetNodes 验证目标节点存在且已批准，待审批和已拒绝的节点不接收任务
+func (s *taskService) checkTargetNodes(ctx context.Context, nodeIDs []string) error {
+	for _, nodeID := range nodeIDs {
+		node, err := s.nodeRepo.GetByNodeID(ctx, nodeID)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return errors.New(errors.ErrNodeNotFound, "目标节点不存在: "+nodeID)
+			}
+			s.logger.Error("failed to check node", zap.Error(err))
+			return errors.Wrap(errors.ErrDatabase, "数据库错误", err)
+		}
+		if !node.IsApproved() {
+			return errors.New(errors.ErrNodeNotApproved, "目标节点未批准: "+nodeID)
+		}
+	}
+	return nil
+}
+
 // GetByID 根据ID获取任务
 func (s *taskService) GetByID(ctx context.Context, id uint) (*model.Task, error) {
 	task, err := s.taskRepo.GetByID(ctx, id)
@@ -182,6 +193,11 @@ func (s *taskService) Execute(ctx context.Context, taskID uint) error {
 		return errors.ErrTaskRunningMsg
 	}
 
+	// 目标节点可能在任务创建后被拒绝
+	if err := s.checkTargetNodes(ctx, task.TargetNodes); err != nil {
+		return err
+	}
+
 	// 更新任务状态为运行中
 	if err := s.taskRepo.UpdateStatus(ctx, taskID, "running"); err != nil {
 		s.logger.Error("failed to update task status", zap.Error(err))
@@ -0,0 +1,62 @@
+// Package telemetry 定义Manager自身的可观测性指标(通过 /metrics 以Prometheus格式暴露)
+package telemetry
+
+import (
+	"time"
+
+	"github.com/bingooyong/ops-scaffold-framework/manager/pkg/metrics"
+)
+
+var (
+	// HTTPRequestDuration HTTP请求耗时(按方法、路由模板和状态码)
+	HTTPRequestDuration = metrics.NewHistogramVec("manager_http_request_duration_seconds",
+		"HTTP request latency in seconds by method, route and status.",
+		metrics.DefaultLatencyBuckets, "method", "route", "status")
+
+	// GRPCServerDuration gRPC服务端处理耗时(按方法和状态码)
+	GRPCServerDuration = metrics.NewHistogramVec("manager_grpc_server_handling_seconds",
+		"gRPC server handling latency in seconds by method and code.",
+		metrics.DefaultLatencyBuckets, "method", "code")
+
+	// DaemonDialErrors 创建Daemon客户端连接失败次数(按节点)
+	DaemonDialErrors = metrics.NewCounterVec("manager_daemon_dial_errors_total",
+		"Total failures to create a daemon client connection by node.", "node_id")
+
+	// MetricsIngested 接收并保存的节点指标条数(按指标类型)
+	MetricsIngested = metrics.NewCounterVec("manager_metrics_ingested_total",
+		"Total node metric records ingested from daemons by type.", "type")
+
+	// MetricsIngestErrors 保存节点指标失败的上报批次数
+	MetricsIngestErrors = metrics.NewCounterVec("manager_metrics_ingest_errors_total",
+		"Total metric report batches that failed to be saved.")
+
+	// CronJobDuration 定时任务执行耗时(按任务名)
+	CronJobDuration = metrics.NewHistogramVec("manager_cron_job_duration_seconds",
+		"Cron job run duration in seconds by job.",
+		[]float64{0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60, 300}, "job")
+
+	// CronJobRuns 定时任务执行次数(按任务名和结果)
+	CronJobRuns = metrics.NewCounterVec("manager_cron_job_runs_total",
+		"Total cron job runs by job and result.", "job", "result")
+)
+
+// ObserveCronJob 记录一次定时任务执行的耗时和结果
+func ObserveCronJob(job string, start time.Time, err error) {
+	CronJobDuration.WithLabelValues(job).Observe(time.Since(start).Seconds())
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	CronJobRuns.WithLabelValues(job, result).Inc()
+}
+
+// Write 写入所有进程内累计的指标
+func Write(pw *metrics.Writer) {
+	HTTPRequestDuration.Write(pw)
+	GRPCServerDuration.Write(pw)
+	DaemonDialErrors.Write(pw)
+	MetricsIngested.Write(pw)
+	MetricsIngestErrors.Write(pw)
+	CronJobDuration.Write(pw)
+	CronJobRuns.Write(pw)
+}
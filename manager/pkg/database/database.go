@@ -220,6 +220,12 @@ func AutoMigrate(db *gorm.DB, log *zap.Logger) error {
 		&model.Task{},
 		&model.Version{},
 		&model.Agent{},
+		&model.AgentEvent{},
+		&model.AgentCrash{},
+		&model.AgentResourceAlert{},
+		&model.AgentMetric{},
+		&model.JoinToken{},
+		&model.NodeQuarantine{},
 	}
 
 	// 逐个迁移每个模型，这样一个模型的错误不会影响其他模型
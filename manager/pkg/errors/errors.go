@@ -30,6 +30,8 @@ const (
 	ErrUserNotFound      ErrorCode = 2007 // 用户不存在
 	ErrUserDisabled      ErrorCode = 2008 // 用户已禁用
 	ErrUserAlreadyExists ErrorCode = 2009 // 用户已存在
+	ErrNodeNotApproved   ErrorCode = 2010 // 节点未批准
+	ErrNodeQuarantined   ErrorCode = 2011 // 节点已隔离
 
 	// 3xxx: 版本管理错误
 	ErrVersionNotFound      ErrorCode = 3001 // 版本不存在
@@ -112,6 +114,8 @@ var (
 	ErrUserNotFoundMsg      = New(ErrUserNotFound, "用户不存在")
 	ErrUserDisabledMsg      = New(ErrUserDisabled, "用户已禁用")
 	ErrUserAlreadyExistsMsg = New(ErrUserAlreadyExists, "用户已存在")
+	ErrNodeNotApprovedMsg   = New(ErrNodeNotApproved, "节点未批准")
+	ErrNodeQuarantinedMsg   = New(ErrNodeQuarantined, "节点已隔离")
 
 	// 版本管理错误
 	ErrVersionNotFoundMsg      = New(ErrVersionNotFound, "版本不存在")
@@ -156,7 +160,7 @@ func (e *APIError) GetHTTPStatus() int {
 			return 404
 		case ErrNodeAlreadyExists, ErrUserAlreadyExists, ErrVersionAlreadyExists:
 			return 409
-		case ErrUserDisabled, ErrNodeOffline:
+		case ErrUserDisabled, ErrNodeOffline, ErrNodeNotApproved, ErrNodeQuarantined:
 			return 403
 		default:
 			return 400
@@ -0,0 +1,241 @@
+// Package metrics 提供Prometheus文本格式(0.0.4)的指标输出和直方图实现
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// ContentType Prometheus文本格式的Content-Type
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// 指标类型
+const (
+	TypeCounter   = "counter"
+	TypeGauge     = "gauge"
+	TypeHistogram = "histogram"
+)
+
+// DefaultLatencyBuckets 默认延迟直方图桶(秒)
+var DefaultLatencyBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Histogram 并发安全的累积直方图
+// nil Histogram 的 Observe 为空操作，便于可选埋点
+type Histogram struct {
+	buckets []float64
+	counts  []atomic.Uint64
+	count   atomic.Uint64
+	sumBits atomic.Uint64
+}
+
+// HistogramSnapshot 直方图快照，Counts为各桶的累计计数(与Buckets一一对应)
+type HistogramSnapshot struct {
+	Buckets []float64
+	Counts  []uint64
+	Count   uint64
+	Sum     float64
+}
+
+// NewHistogram 创建直方图，buckets为各桶上限(升序)
+func NewHistogram(buckets []float64) *Histogram {
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	return &Histogram{
+		buckets: sorted,
+		counts:  make([]atomic.Uint64, len(sorted)),
+	}
+}
+
+// Observe 记录一个观测值
+func (h *Histogram) Observe(v float64) {
+	if h == nil {
+		return
+	}
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		h.counts[i].Add(1)
+	}
+	h.count.Add(1)
+	for {
+		old := h.sumBits.Load()
+		sum := math.Float64frombits(old) + v
+		if h.sumBits.CompareAndSwap(old, math.Float64bits(sum)) {
+			return
+		}
+	}
+}
+
+// Snapshot 获取直方图快照
+func (h *Histogram) Snapshot() HistogramSnapshot {
+	if h == nil {
+		return HistogramSnapshot{}
+	}
+	snap := HistogramSnapshot{
+		Buckets: h.buckets,
+		Counts:  make([]uint64, len(h.buckets)),
+		Count:   h.count.Load(),
+		Sum:     math.Float64frombits(h.sumBits.Load()),
+	}
+	var cumulative uint64
+	for i := range h.counts {
+		cumulative += h.counts[i].Load()
+		snap.Counts[i] = cumulative
+	}
+	return snap
+}
+
+// Writer Prometheus文本格式写入器
+// labels参数为成对的标签名和标签值，如 "agent_id", "filebeat-1"
+type Writer struct {
+	w       io.Writer
+	err     error
+	written map[string]bool
+}
+
+// NewWriter 创建Prometheus文本格式写入器
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w, written: make(map[string]bool)}
+}
+
+// Err 返回写入过程中的第一个错误
+func (pw *Writer) Err() error {
+	return pw.err
+}
+
+// Header 写入指标的HELP和TYPE行(同名指标只写一次)
+func (pw *Writer) Header(name, help, metricType string) {
+	if pw.written[name] {
+		return
+	}
+	pw.written[name] = true
+	pw.printf("# HELP %s %s\n# TYPE %s %s\n", name, escapeHelp(help), name, metricType)
+}
+
+// Sample 写入一个样本
+func (pw *Writer) Sample(name string, value float64, labels ...string) {
+	pw.printf("%s%s %s\n", name, formatLabels(labels), formatValue(value))
+}
+
+// Gauge 写入只有一个样本的gauge指标
+func (pw *Writer) Gauge(name, help string, value float64, labels ...string) {
+	pw.Header(name, help, TypeGauge)
+	pw.Sample(name, value, labels...)
+}
+
+// Counter 写入只有一个样本的counter指标
+func (pw *Writer) Counter(name, help string, value float64, labels ...string) {
+	pw.Header(name, help, TypeCounter)
+	pw.Sample(name, value, labels...)
+}
+
+// Histogram 写入直方图指标(_bucket、_sum、_count)
+func (pw *Writer) Histogram(name, help string, snap HistogramSnapshot, labels ...string) {
+	pw.Header(name, help, TypeHistogram)
+	for i, upper := range snap.Buckets {
+		pw.Sample(name+"_bucket", float64(snap.Counts[i]), append(labels[:len(labels):len(labels)], "le", formatValue(upper))...)
+	}
+	pw.Sample(name+"_bucket", float64(snap.Count), append(labels[:len(labels):len(labels)], "le", "+Inf")...)
+	pw.Sample(name+"_sum", snap.Sum, labels...)
+	pw.Sample(name+"_count", float64(snap.Count), labels...)
+}
+
+// SanitizeName 将任意字符串转换为合法的指标名片段(非法字符替换为下划线)
+func SanitizeName(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_', r == ':':
+			b.WriteRune(r)
+		case r >= '0' && r <= '9' && i > 0:
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+// ToFloat 将采集器返回的数值转换为float64，非数值类型返回false
+func ToFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case bool:
+		if n {
+			return 1, true
+		}
+		return 0, true
+	default:
+		return 0, false
+	}
+}
+
+// printf 写入格式化内容，出错后不再写入
+func (pw *Writer) printf(format string, args ...interface{}) {
+	if pw.err != nil {
+		return
+	}
+	_, pw.err = fmt.Fprintf(pw.w, format, args...)
+}
+
+// formatLabels 格式化标签集合
+func formatLabels(labels []string) string {
+	if len(labels) < 2 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i := 0; i+1 < len(labels); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(labels[i])
+		b.WriteString(`="`)
+		b.WriteString(escapeLabelValue(labels[i+1]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// formatValue 格式化样本值
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+// escapeLabelValue 转义标签值中的反斜杠、双引号和换行
+func escapeLabelValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// escapeHelp 转义HELP文本中的反斜杠和换行
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHistogram_ObserveAndSnapshot(t *testing.T) {
+	h := NewHistogram([]float64{1, 0.1, 0.5})
+	for _, v := range []float64{0.05, 0.2, 0.3, 0.7, 3} {
+		h.Observe(v)
+	}
+
+	snap := h.Snapshot()
+	if snap.Count != 5 {
+		t.Errorf("expected count 5, got %d", snap.Count)
+	}
+	if snap.Sum < 4.249 || snap.Sum > 4.251 {
+		t.Errorf("expected sum 4.25, got %v", snap.Sum)
+	}
+	// 桶按升序排列且计数为累计值
+	wantBuckets := []float64{0.1, 0.5, 1}
+	wantCounts := []uint64{1, 3, 4}
+	for i := range wantBuckets {
+		if snap.Buckets[i] != wantBuckets[i] || snap.Counts[i] != wantCounts[i] {
+			t.Errorf("bucket %d: expected le=%v count=%d, got le=%v count=%d",
+				i, wantBuckets[i], wantCounts[i], snap.Buckets[i], snap.Counts[i])
+		}
+	}
+
+	var nilHist *Histogram
+	nilHist.Observe(1)
+	if nilHist.Snapshot().Count != 0 {
+		t.Error("expected empty snapshot for nil histogram")
+	}
+}
+
+func TestWriter_TextFormat(t *testing.T) {
+	var b strings.Builder
+	pw := NewWriter(&b)
+
+	pw.Gauge("agent_up", "Agent up.", 1, "agent_id", `a"b\c`)
+	pw.Gauge("agent_up", "Agent up.", 0, "agent_id", "d")
+	pw.Counter("syncs_total", "Total syncs.", 3)
+	h := NewHistogram([]float64{0.5})
+	h.Observe(0.25)
+	pw.Histogram("latency_seconds", "Latency.", h.Snapshot(), "op", "sync")
+
+	if err := pw.Err(); err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+	want := `# HELP agent_up Agent up.
+# TYPE agent_up gauge
+agent_up{agent_id="a\"b\\c"} 1
+agent_up{agent_id="d"} 0
+# HELP syncs_total Total syncs.
+# TYPE syncs_total counter
+syncs_total 3
+# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{op="sync",le="0.5"} 1
+latency_seconds_bucket{op="sync",le="+Inf"} 1
+latency_seconds_sum{op="sync"} 0.25
+latency_seconds_count{op="sync"} 1
+`
+	if b.String() != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
+
+func TestSanitizeNameAndToFloat(t *testing.T) {
+	if got := SanitizeName("disk.used-percent"); got != "disk_used_percent" {
+		t.Errorf("unexpected sanitized name: %s", got)
+	}
+	if got := SanitizeName("1min"); got != "_min" {
+		t.Errorf("expected leading digit replaced, got %s", got)
+	}
+	if v, ok := ToFloat(uint64(42)); !ok || v != 42 {
+		t.Errorf("expected 42, got %v %v", v, ok)
+	}
+	if _, ok := ToFloat("text"); ok {
+		t.Error("expected non-numeric value to be rejected")
+	}
+}
@@ -0,0 +1,160 @@
+package metrics
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// labelSep 标签值拼接分隔符(不会出现在合法的UTF-8文本中)
+const labelSep = "\xff"
+
+// Counter 并发安全的单调递增计数器
+type Counter struct {
+	value atomic.Uint64
+}
+
+// Inc 计数加1
+func (c *Counter) Inc() {
+	c.value.Add(1)
+}
+
+// Add 计数增加n
+func (c *Counter) Add(n uint64) {
+	c.value.Add(n)
+}
+
+// Value 获取当前计数
+func (c *Counter) Value() uint64 {
+	return c.value.Load()
+}
+
+// CounterVec 按标签区分的计数器集合
+type CounterVec struct {
+	name       string
+	help       string
+	labelNames []string
+
+	mu     sync.RWMutex
+	series map[string]*Counter
+}
+
+// NewCounterVec 创建计数器集合
+func NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	return &CounterVec{
+		name:       name,
+		help:       help,
+		labelNames: labelNames,
+		series:     make(map[string]*Counter),
+	}
+}
+
+// WithLabelValues 获取(必要时创建)指定标签值对应的计数器，标签值顺序与labelNames一致
+func (v *CounterVec) WithLabelValues(values ...string) *Counter {
+	key := strings.Join(values, labelSep)
+
+	v.mu.RLock()
+	c, ok := v.series[key]
+	v.mu.RUnlock()
+	if ok {
+		return c
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if c, ok = v.series[key]; !ok {
+		c = &Counter{}
+		v.series[key] = c
+	}
+	return c
+}
+
+// Write 写入所有计数器(按标签值排序)
+func (v *CounterVec) Write(pw *Writer) {
+	pw.Header(v.name, v.help, TypeCounter)
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	for _, key := range sortedKeys(v.series) {
+		pw.Sample(v.name, float64(v.series[key].Value()), pairLabels(v.labelNames, key)...)
+	}
+}
+
+// HistogramVec 按标签区分的直方图集合
+type HistogramVec struct {
+	name       string
+	help       string
+	labelNames []string
+	buckets    []float64
+
+	mu     sync.RWMutex
+	series map[string]*Histogram
+}
+
+// NewHistogramVec 创建直方图集合
+func NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	return &HistogramVec{
+		name:       name,
+		help:       help,
+		labelNames: labelNames,
+		buckets:    buckets,
+		series:     make(map[string]*Histogram),
+	}
+}
+
+// WithLabelValues 获取(必要时创建)指定标签值对应的直方图，标签值顺序与labelNames一致
+func (v *HistogramVec) WithLabelValues(values ...string) *Histogram {
+	key := strings.Join(values, labelSep)
+
+	v.mu.RLock()
+	h, ok := v.series[key]
+	v.mu.RUnlock()
+	if ok {
+		return h
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if h, ok = v.series[key]; !ok {
+		h = NewHistogram(v.buckets)
+		v.series[key] = h
+	}
+	return h
+}
+
+// Write 写入所有直方图(按标签值排序)
+func (v *HistogramVec) Write(pw *Writer) {
+	pw.Header(v.name, v.help, TypeHistogram)
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	for _, key := range sortedKeys(v.series) {
+		pw.Histogram(v.name, v.help, v.series[key].Snapshot(), pairLabels(v.labelNames, key)...)
+	}
+}
+
+// sortedKeys 返回排序后的map键
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// pairLabels 将标签名和拼接后的标签值组合为成对的标签参数
+func pairLabels(names []string, key string) []string {
+	if len(names) == 0 {
+		return nil
+	}
+	values := strings.Split(key, labelSep)
+	labels := make([]string, 0, len(names)*2)
+	for i, name := range names {
+		value := ""
+		if i < len(values) {
+			value = values[i]
+		}
+		labels = append(labels, name, value)
+	}
+	return labels
+}
@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCounterVec_Write(t *testing.T) {
+	v := NewCounterVec("cron_runs_total", "Cron runs.", "job", "result")
+	v.WithLabelValues("cleanup", "success").Inc()
+	v.WithLabelValues("cleanup", "success").Add(2)
+	v.WithLabelValues("cleanup", "failure").Inc()
+
+	var b strings.Builder
+	v.Write(NewWriter(&b))
+
+	want := `# HELP cron_runs_total Cron runs.
+# TYPE cron_runs_total counter
+cron_runs_total{job="cleanup",result="failure"} 1
+cron_runs_total{job="cleanup",result="success"} 3
+`
+	if b.String() != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
+
+func TestHistogramVec_Write(t *testing.T) {
+	v := NewHistogramVec("http_seconds", "HTTP latency.", []float64{0.1}, "route", "status")
+	v.WithLabelValues("/api/v1/nodes", "200").Observe(0.05)
+	v.WithLabelValues("/api/v1/nodes", "200").Observe(0.5)
+
+	var b strings.Builder
+	v.Write(NewWriter(&b))
+
+	out := b.String()
+	for _, line := range []string{
+		`# TYPE http_seconds histogram`,
+		`http_seconds_bucket{route="/api/v1/nodes",status="200",le="0.1"} 1`,
+		`http_seconds_bucket{route="/api/v1/nodes",status="200",le="+Inf"} 2`,
+		`http_seconds_count{route="/api/v1/nodes",status="200"} 2`,
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("expected line %q in output:\n%s", line, out)
+		}
+	}
+	if strings.Count(out, "# TYPE http_seconds") != 1 {
+		t.Errorf("expected a single TYPE line, got:\n%s", out)
+	}
+
+	// 无标签的集合
+	empty := NewCounterVec("errors_total", "Errors.")
+	empty.WithLabelValues().Inc()
+	b.Reset()
+	empty.Write(NewWriter(&b))
+	if !strings.Contains(b.String(), "errors_total 1\n") {
+		t.Errorf("unexpected output for unlabeled vec:\n%s", b.String())
+	}
+}
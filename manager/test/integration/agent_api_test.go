@@ -670,23 +670,25 @@ func (s *AgentAPITestSuite) TestAgentAPI_GetAgentLogs_Success() {
 	node := s.createTestNode()
 	agent := s.createTestAgent(node.NodeID, "agent-logs", "running")
 
-	// 注意：当前GetAgentLogs功能未实现，返回501
+	// 注意：集成测试使用真实服务，日志由Daemon的TailAgentLogs返回
 	resp, body := s.GET(
-		fmt.Sprintf("/api/v1/nodes/%s/agents/%s/logs?lines=100", node.NodeID, agent.AgentID),
+		fmt.Sprintf("/api/v1/nodes/%s/agents/%s/logs?lines=100&keyword=error", node.NodeID, agent.AgentID),
 		true,
 	)
 
-	// 当前实现返回501（功能未实现）
-	assert.Equal(s.T(), http.StatusInternalServerError, resp.StatusCode, "响应体: %s", string(body))
+	assert.Equal(s.T(), http.StatusOK, resp.StatusCode, "响应体: %s", string(body))
 
 	var result map[string]interface{}
 	err := json.Unmarshal(body, &result)
 	require.NoError(s.T(), err)
 
-	// 验证返回了错误信息
-	assert.NotEqual(s.T(), float64(0), result["code"])
+	assert.Equal(s.T(), float64(0), result["code"])
+	data, ok := result["data"].(map[string]interface{})
+	require.True(s.T(), ok, "data should be an object")
+	assert.Contains(s.T(), data, "logs")
+	assert.Contains(s.T(), data, "count")
 
-	s.T().Logf("✅ GetAgentLogs测试通过（功能未实现）: code=%v", result["code"])
+	s.T().Logf("✅ GetAgentLogs测试通过: count=%v", data["count"])
 }
 
 // TestAgentAPI_GetAgentLogs_NodeNotFound 错误场景：节点不存在
@@ -3,6 +3,7 @@ package integration
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -179,6 +180,85 @@ func (m *MockDaemonClient) GetAgentMetrics(ctx context.Context, nodeID, agentID
 	return response, nil
 }
 
+// TailAgentLogs 实现DaemonClient接口
+func (m *MockDaemonClient) TailAgentLogs(ctx context.Context, nodeID, agentID string, lines int, keyword string) ([]string, error) {
+	m.mu.Lock()
+	m.getAgentLogsCallCount++
+	err := m.getAgentLogsError
+	response := m.getAgentLogsResponse
+	m.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+	if len(response) > lines {
+		response = response[len(response)-lines:]
+	}
+	return response, nil
+}
+
+// FollowAgentLogs 实现DaemonClient接口(将配置的日志作为一批推送后结束)
+func (m *MockDaemonClient) FollowAgentLogs(ctx context.Context, nodeID, agentID string, tailLines int, keyword string, handler func(lines []string, rotated bool) error) error {
+	m.mu.Lock()
+	m.getAgentLogsCallCount++
+	err := m.getAgentLogsError
+	response := m.getAgentLogsResponse
+	m.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+	if len(response) == 0 {
+		return nil
+	}
+	return handler(response, false)
+}
+
+// SearchAgentLogs 实现DaemonClient接口(在配置的日志中按关键词匹配，时间戳为当前时间)
+func (m *MockDaemonClient) SearchAgentLogs(ctx context.Context, nodeID string, req *daemonpb.SearchAgentLogsRequest) (*daemonpb.SearchAgentLogsResponse, error) {
+	m.mu.Lock()
+	m.getAgentLogsCallCount++
+	err := m.getAgentLogsError
+	response := m.getAgentLogsResponse
+	m.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UnixMilli()
+	hits := make([]*daemonpb.LogSearchHit, 0)
+	for i, line := range response {
+		if req.Keyword != "" && !strings.Contains(line, req.Keyword) {
+			continue
+		}
+		hits = append(hits, &daemonpb.LogSearchHit{
+			AgentId:     req.AgentId,
+			AgentType:   req.AgentType,
+			TimestampMs: now,
+			Line:        line,
+			LineNumber:  int32(i + 1),
+		})
+	}
+	return &daemonpb.SearchAgentLogsResponse{Hits: hits}, nil
+}
+
+// ControlAgent Mock实现(与OperateAgent共用错误设置和调用计数)
+func (m *MockDaemonClient) ControlAgent(ctx context.Context, nodeID, agentID, command string, args map[string]string) (*daemonpb.ControlAgentResponse, error) {
+	m.mu.Lock()
+	m.operateAgentCallCount++
+	err := m.operateAgentError
+	m.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+	return &daemonpb.ControlAgentResponse{
+		Success: true,
+		Message: command + " done",
+		Method:  "socket",
+	}, nil
+}
+
 // MockDaemonClientPool Mock Daemon客户端连接池
 type MockDaemonClientPool struct {
 	mu      sync.RWMutex
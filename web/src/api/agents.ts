@@ -3,12 +3,20 @@
  */
 
 import client from './interceptors';
+import { useAuthStore } from '../stores';
 import type {
   APIResponse,
   AgentOperation,
+  AgentControlCommand,
+  AgentControlResult,
   AgentLogsResponse,
+  AgentLogFollowEvent,
+  LogSearchParams,
+  LogSearchResponse,
   AgentListResponse,
   AgentMetricsHistoryResponse,
+  ResourceAlertQuery,
+  ResourceAlertListResponse,
 } from '../types';
 
 /**
@@ -39,23 +47,129 @@ export function operateAgent(
     .then((res) => res.data);
 }
 
+/**
+ * 向 Agent 下发控制命令(重载配置/调整日志级别/导出诊断信息/排空)
+ * @param nodeId 节点ID
+ * @param agentId Agent ID
+ * @param command 控制命令
+ * @param args 命令参数,如 set_log_level 的 { level: 'debug' }
+ */
+export function controlAgent(
+  nodeId: string,
+  agentId: string,
+  command: AgentControlCommand,
+  args?: Record<string, string>
+): Promise<APIResponse<AgentControlResult>> {
+  return client
+    .post(`/api/v1/nodes/${nodeId}/agents/${agentId}/control`, {
+      command,
+      args,
+    })
+    .then((res) => res.data);
+}
+
 /**
  * 获取 Agent 日志
  * @param nodeId 节点ID
  * @param agentId Agent ID
  * @param lines 日志行数,默认 100,最大 1000
+ * @param keyword 关键词,只返回包含关键词的行
  */
 export function getAgentLogs(
   nodeId: string,
   agentId: string,
-  lines?: number
+  lines?: number,
+  keyword?: string
 ): Promise<APIResponse<AgentLogsResponse>> {
-  const params = lines ? { lines } : {};
+  const params: Record<string, string | number> = lines ? { lines } : {};
+  if (keyword) {
+    params.keyword = keyword;
+  }
   return client
     .get(`/api/v1/nodes/${nodeId}/agents/${agentId}/logs`, { params })
     .then((res) => res.data);
 }
 
+/**
+ * 跟踪 Agent 日志(SSE),先推送末尾 tail 行,之后持续推送新增行
+ * EventSource 无法携带 Authorization 头,因此使用 fetch 读取事件流
+ * @param nodeId 节点ID
+ * @param agentId Agent ID
+ * @param onEvent 每批日志的回调
+ * @param options tail: 初始行数(默认 100); keyword: 关键词过滤
+ * @returns 停止跟踪的函数
+ */
+export function followAgentLogs(
+  nodeId: string,
+  agentId: string,
+  onEvent: (event: AgentLogFollowEvent) => void,
+  options: { tail?: number; keyword?: string; onError?: (error: Error) => void } = {}
+): () => void {
+  const controller = new AbortController();
+  const params = new URLSearchParams();
+  if (options.tail !== undefined) {
+    params.set('tail', String(options.tail));
+  }
+  if (options.keyword) {
+    params.set('keyword', options.keyword);
+  }
+  const query = params.toString();
+  const url = `${client.defaults.baseURL ?? ''}/api/v1/nodes/${nodeId}/agents/${agentId}/logs/follow${query ? `?${query}` : ''}`;
+
+  const run = async () => {
+    const token = useAuthStore.getState().token;
+    const res = await fetch(url, {
+      headers: token ? { Authorization: `Bearer ${token}` } : {},
+      signal: controller.signal,
+    });
+    if (!res.ok || !res.body) {
+      throw new Error(`follow agent logs failed: ${res.status}`);
+    }
+
+    const reader = res.body.getReader();
+    const decoder = new TextDecoder();
+    let buffer = '';
+    for (;;) {
+      const { done, value } = await reader.read();
+      if (done) {
+        return;
+      }
+      buffer += decoder.decode(value, { stream: true });
+
+      // SSE 事件以空行分隔
+      let sep = buffer.indexOf('\n\n');
+      while (sep >= 0) {
+        const block = buffer.slice(0, sep);
+        buffer = buffer.slice(sep + 2);
+        sep = buffer.indexOf('\n\n');
+
+        let eventName = 'message';
+        const data: string[] = [];
+        for (const line of block.split('\n')) {
+          if (line.startsWith('event:')) {
+            eventName = line.slice(6).trim();
+          } else if (line.startsWith('data:')) {
+            data.push(line.slice(5));
+          }
+        }
+        if (eventName === 'log') {
+          onEvent(JSON.parse(data.join('\n')) as AgentLogFollowEvent);
+        } else if (eventName === 'error') {
+          throw new Error(JSON.parse(data.join('\n')).message);
+        }
+      }
+    }
+  };
+
+  run().catch((error: Error) => {
+    if (!controller.signal.aborted) {
+      options.onError?.(error);
+    }
+  });
+
+  return () => controller.abort();
+}
+
 /**
  * 获取 Agent 资源使用指标历史数据
  * @param nodeId 节点ID
@@ -91,3 +205,42 @@ export function syncAgents(nodeId: string): Promise<APIResponse<SyncAgentsRespon
     .post(`/api/v1/nodes/${nodeId}/agents/sync`)
     .then((res) => res.data);
 }
+
+/**
+ * 跨节点搜索 Agent 日志
+ * 结果按时间倒序合并,部分节点超时或离线时返回部分结果(partial 为 true)
+ * @param params 搜索条件
+ */
+export function searchLogs(params: LogSearchParams): Promise<APIResponse<LogSearchResponse>> {
+  return client
+    .get('/api/v1/logs/search', { params })
+    .then((res) => res.data);
+}
+
+/**
+ * 获取 Agent 资源告警记录
+ * @param nodeId 节点ID
+ * @param agentId Agent ID
+ * @param params type: 资源类型; limit: 返回条数(默认 20,最大 200)
+ */
+export function getAgentAlerts(
+  nodeId: string,
+  agentId: string,
+  params?: Pick<ResourceAlertQuery, 'type' | 'limit'>
+): Promise<APIResponse<ResourceAlertListResponse>> {
+  return client
+    .get(`/api/v1/nodes/${nodeId}/agents/${agentId}/alerts`, { params })
+    .then((res) => res.data);
+}
+
+/**
+ * 跨节点查询 Agent 资源告警,按触发时间倒序
+ * @param params 过滤条件(均可选)
+ */
+export function listResourceAlerts(
+  params?: ResourceAlertQuery
+): Promise<APIResponse<ResourceAlertListResponse>> {
+  return client
+    .get('/api/v1/alerts', { params })
+    .then((res) => res.data);
+}
@@ -3,7 +3,7 @@
  */
 
 import client from './interceptors';
-import type { APIResponse, MetricsLatestResponse, MetricsHistoryResponse, MetricsSummaryResponse, TimeRange, ClusterOverviewResponse } from '../types';
+import type { APIResponse, MetricsLatestResponse, MetricsHistoryResponse, MetricsSummaryResponse, TimeRange, ClusterOverviewResponse, AgentCustomMetrics } from '../types';
 
 /**
  * 获取节点最新指标
@@ -55,3 +55,34 @@ export function getClusterOverview(): Promise<APIResponse<ClusterOverviewRespons
     .then((res) => res.data);
 }
 
+/**
+ * 获取 Agent 最新的自定义指标（未上报过时 data 为 null）
+ */
+export function getAgentCustomMetricsLatest(
+  nodeId: string,
+  agentId: string
+): Promise<APIResponse<AgentCustomMetrics | null>> {
+  return client
+    .get(`/api/v1/metrics/nodes/${nodeId}/agents/${agentId}/custom/latest`)
+    .then((res) => res.data);
+}
+
+/**
+ * 获取 Agent 自定义指标历史（默认最近 1 小时）
+ */
+export function getAgentCustomMetricsHistory(
+  nodeId: string,
+  agentId: string,
+  timeRange?: TimeRange
+): Promise<APIResponse<AgentCustomMetrics[]>> {
+  const params: Record<string, string> = {};
+
+  if (timeRange) {
+    params.start_time = timeRange.startTime.toISOString();
+    params.end_time = timeRange.endTime.toISOString();
+  }
+
+  return client
+    .get(`/api/v1/metrics/nodes/${nodeId}/agents/${agentId}/custom/history`, { params })
+    .then((res) => res.data);
+}
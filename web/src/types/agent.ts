@@ -23,11 +23,67 @@ export interface OperateAgentRequest {
   operation: AgentOperation;
 }
 
+// Agent 控制命令(通过控制通道下发,不支持时由 Daemon 回退为信号)
+export type AgentControlCommand = 'reload' | 'set_log_level' | 'dump_diagnostics' | 'drain';
+
+export interface ControlAgentRequest {
+  command: AgentControlCommand;
+  args?: Record<string, string>; // 如 set_log_level 的 { level: 'debug' }
+}
+
+export interface AgentControlResult {
+  command: AgentControlCommand;
+  success: boolean;
+  message: string;
+  method: 'socket' | 'signal'; // 实际下发方式
+  data?: Record<string, string>; // 如诊断信息
+}
+
 export interface AgentLogsResponse {
   logs: string[];
   count: number;
 }
 
+// 跨节点日志搜索参数
+export interface LogSearchParams {
+  keyword: string;
+  regex?: boolean;
+  since?: string; // RFC3339 时间或相对时长(如 1h)
+  until?: string;
+  agent_type?: string;
+  node_ids?: string; // 逗号分隔
+  labels?: string; // key=value,key2=value2
+  limit?: number;
+  timeout?: number; // 单个节点超时(秒)
+}
+
+export interface LogSearchHit {
+  node_id: string;
+  hostname: string;
+  agent_id: string;
+  agent_type: string;
+  timestamp: string;
+  line: string;
+  file: string;
+  line_number: number;
+}
+
+export interface LogSearchResponse {
+  hits: LogSearchHit[];
+  count: number;
+  truncated: boolean;
+  partial: boolean;
+  nodes_total: number;
+  nodes_succeeded: number;
+  failed_nodes: { node_id: string; hostname: string; error: string }[];
+}
+
+// 日志跟踪(SSE)推送的一批日志，rotated 表示日志文件发生了轮转或截断
+export interface AgentLogFollowEvent {
+  lines: string[];
+  rotated: boolean;
+}
+
 export interface AgentListResponse {
   agents: Agent[];
   count: number;
@@ -54,3 +110,34 @@ export interface AgentMetricsHistoryResponse {
   data_points: AgentResourceDataPoint[];
   count: number;
 }
+
+/**
+ * Agent 资源告警（资源持续超过阈值时由 Daemon 上报）
+ */
+export type ResourceAlertType = 'cpu' | 'memory' | 'open_files';
+
+export interface AgentResourceAlert {
+  id: number;
+  node_id: string;
+  agent_id: string;
+  type: ResourceAlertType;
+  value: number; // 告警时的资源使用值
+  threshold: number; // 配置的阈值
+  duration_ms: number; // 超过阈值的持续时长（毫秒）
+  action: 'none' | 'restart' | 'stop'; // Daemon 执行的动作
+  action_error: string; // 动作执行失败原因
+  triggered_at: string;
+  created_at: string;
+}
+
+export interface ResourceAlertQuery {
+  node_id?: string;
+  agent_id?: string;
+  type?: ResourceAlertType;
+  limit?: number;
+}
+
+export interface ResourceAlertListResponse {
+  alerts: AgentResourceAlert[];
+  count: number;
+}
@@ -98,3 +98,16 @@ export interface ClusterOverviewResponse {
   nodes: NodeMetrics[];
 }
 
+
+/**
+ * Agent 自定义指标（Agent 通过心跳上报的数值指标和状态字段）
+ */
+export interface AgentCustomMetrics {
+  id: number;
+  node_id: string;
+  agent_id: string;
+  timestamp: string;
+  values: Record<string, number>;
+  fields: Record<string, string>;
+  created_at: string;
+}
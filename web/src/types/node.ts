@@ -10,6 +10,8 @@ export interface Node {
   os: string;
   arch: string;
   status: NodeStatus;
+  approval?: 'approved' | 'pending' | 'rejected';
+  fingerprint?: string;
   labels?: Record<string, string>;
   daemon_version?: string;
   agent_version?: string;